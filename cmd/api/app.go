@@ -3,23 +3,46 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"maglev.onebusaway.org/internal/analytics"
 	"maglev.onebusaway.org/internal/app"
 	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/archive"
+	"maglev.onebusaway.org/internal/audit"
+	"maglev.onebusaway.org/internal/auth"
+	"maglev.onebusaway.org/internal/blocklist"
 	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/crowding"
+	"maglev.onebusaway.org/internal/detours"
+	"maglev.onebusaway.org/internal/errorreport"
+	"maglev.onebusaway.org/internal/events"
+	"maglev.onebusaway.org/internal/gbfs"
+	"maglev.onebusaway.org/internal/geocode"
 	"maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/logging"
 	"maglev.onebusaway.org/internal/metrics"
+	"maglev.onebusaway.org/internal/notify"
+	"maglev.onebusaway.org/internal/popularity"
+	"maglev.onebusaway.org/internal/quota"
+	"maglev.onebusaway.org/internal/registry"
 	"maglev.onebusaway.org/internal/restapi"
+	"maglev.onebusaway.org/internal/ridership"
+	"maglev.onebusaway.org/internal/snapshot"
+	"maglev.onebusaway.org/internal/tracing"
+	"maglev.onebusaway.org/internal/webhooks"
 	"maglev.onebusaway.org/internal/webui"
 )
 
@@ -37,36 +60,254 @@ func ParseAPIKeys(apiKeysFlag string) []string {
 	return keys
 }
 
+// openLogOutput returns the destination of the application log: stdout (the default), stderr,
+// or a log file rotated as configured.
+func openLogOutput(cfg appconf.LoggingConfig) (io.Writer, error) {
+	switch cfg.Output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	file, err := logging.OpenRotatingFile(cfg.Output,
+		int64(cfg.Rotation.MaxSize)<<20,
+		time.Duration(cfg.Rotation.Interval)*time.Hour,
+		cfg.Rotation.MaxBackups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open logging.output: %w", err)
+	}
+	return file, nil
+}
+
+// recentErrorsKept is how many server errors the admin status page lists.
+const recentErrorsKept = 50
+
 // BuildApplication creates and initializes the Application with all dependencies.
 // This includes creating the logger, initializing the GTFS manager, and creating the direction calculator.
 // Returns an error if GTFS manager initialization fails.
 func BuildApplication(cfg appconf.Config, gtfsCfg gtfs.Config) (*app.Application, error) {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	level, err := appconf.ParseLogLevel(cfg.Logging.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(level)
+	logOutput, err := openLogOutput(cfg.Logging)
+	if err != nil {
+		return nil, err
+	}
+	logger := logging.NewLogger(logOutput, cfg.Logging.Format, logLevel)
+
+	// Set up tracing first so the initial feed download is traced
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+
+	var feedRegistry *registry.Resolver
+	if cfg.FeedRegistry.Enabled() {
+		feedRegistry, gtfsCfg, err = resolveRegistryFeeds(context.Background(), cfg.FeedRegistry, gtfsCfg, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	gtfsManager, err := gtfs.InitGTFSManager(gtfsCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize GTFS manager: %w", err)
 	}
 
+	var registryWatcher *registry.Watcher
+	if feedRegistry != nil {
+		registryWatcher = startFeedRegistryWatch(gtfsManager, feedRegistry, cfg.FeedRegistry, gtfsCfg, logger)
+	}
+
 	var directionCalculator *gtfs.AdvancedDirectionCalculator
 	if gtfsManager != nil {
 		directionCalculator = gtfs.NewAdvancedDirectionCalculator(gtfsManager.GtfsDB.Queries)
 	}
 
 	// Select clock implementation based on environment
-	appClock := createClock(cfg.Env)
+	appClock, err := createClock(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.FakeTime.Enabled() {
+		logger.Warn("serving on a simulated clock", "start", appClock.Now().Format(time.RFC3339), "speed", cfg.FakeTime.Speed)
+	}
 
 	// Initialize metrics with logger for error reporting
 	appMetrics := metrics.NewWithLogger(logger)
 
+	// Quotas, rate limits and signed-request timestamps meter real clients, so they keep to
+	// real time even when appClock is simulated
+	wallClock := clock.RealClock{}
+	quotaManager, err := buildQuotaManager(cfg.Quotas, wallClock, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize quotas: %w", err)
+	}
+
+	var analyticsCollector *analytics.Collector
+	if cfg.Analytics.Enabled() {
+		analyticsCollector, err = analytics.NewCollector(cfg.Analytics, appClock, logger, time.Minute)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize analytics: %w", err)
+		}
+	}
+
+	var bikeshare *gbfs.Poller
+	if cfg.GBFS.Enabled() {
+		bikeshare = gbfs.NewPoller(cfg.GBFS, nil, logger)
+		bikeshare.Start()
+	}
+
+	var geocoder *geocode.Geocoder
+	if cfg.Geocoder.Enabled() {
+		geocoder, err = geocode.New(cfg.Geocoder, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize geocoder: %w", err)
+		}
+	}
+
+	var notifications *notify.Manager
+	if cfg.Notifications.Enabled {
+		notifications = notify.NewManager(cfg.Notifications, nil, appClock, logger)
+		notifications.Start()
+	}
+
+	if cfg.SnapshotUpload.Enabled() {
+		startSnapshotUploads(gtfsManager, cfg.SnapshotUpload, gtfsCfg.GtfsURL, logger)
+	}
+
+	var eventPublisher *events.Publisher
+	if cfg.EventPublishing.Enabled() {
+		eventPublisher, err = events.NewPublisher(cfg.EventPublishing, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize event publishing: %w", err)
+		}
+		eventPublisher.Start()
+		gtfsManager.AddRealtimeUpdateHook(eventPublisher.Ingest)
+		// Send what the initial refresh loaded rather than waiting for the next one
+		eventPublisher.Ingest(gtfs.RealtimeUpdate{
+			Trips:    gtfsManager.GetRealTimeTrips(),
+			Vehicles: gtfsManager.GetRealTimeVehicles(),
+			Alerts:   gtfsManager.GetRealTimeAlerts(),
+		})
+	}
+
+	var alertWebhooks *webhooks.Notifier
+	if len(cfg.AlertWebhooks) > 0 {
+		alertWebhooks = webhooks.NewNotifier(cfg.AlertWebhooks, nil, logger)
+		alertWebhooks.Start()
+		gtfsManager.AddRealtimeUpdateHook(alertWebhooks.Ingest)
+		// Record the alerts already in effect, so only those that appear later are posted
+		alertWebhooks.Ingest(gtfs.RealtimeUpdate{Alerts: gtfsManager.GetRealTimeAlerts()})
+	}
+
+	var arrivalArchive *archive.Archive
+	if cfg.ArrivalArchive.Enabled() {
+		arrivalArchive, err = archive.New(cfg.ArrivalArchive, archive.ManagerSchedule(gtfsManager), appClock, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize arrival archive: %w", err)
+		}
+		arrivalArchive.Start()
+		gtfsManager.AddRealtimeUpdateHook(arrivalArchive.Ingest)
+		arrivalArchive.Ingest(gtfs.RealtimeUpdate{Trips: gtfsManager.GetRealTimeTrips()})
+	}
+
+	var ridershipStore *ridership.Store
+	if cfg.Ridership.Enabled() {
+		ridershipStore, err = ridership.New(cfg.Ridership, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize ridership store: %w", err)
+		}
+	}
+
+	var detourStore *detours.Store
+	if cfg.DetoursPath != "" {
+		detourStore, err = detours.New(cfg.DetoursPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var crowdingPredictor crowding.Predictor
+	if cfg.CrowdingPredictor != "" {
+		crowdingPredictor, err = crowding.New(cfg.CrowdingPredictor, crowding.Dependencies{
+			Trips:  crowding.ManagerTrips(gtfsManager),
+			Clock:  appClock,
+			Logger: logger,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize crowding predictor: %w", err)
+		}
+		if ingester, ok := crowdingPredictor.(crowding.Ingester); ok {
+			gtfsManager.AddRealtimeUpdateHook(ingester.Ingest)
+			ingester.Ingest(gtfs.RealtimeUpdate{Vehicles: gtfsManager.GetRealTimeVehicles()})
+		}
+	}
+
+	var popularityTracker *popularity.Tracker
+	if cfg.SearchPopularityWeight > 0 {
+		popularityTracker = buildPopularityTracker(appClock, analyticsCollector, logger)
+	}
+
+	var bearerVerifier *auth.BearerVerifier
+	if cfg.BearerAuth.Enabled() {
+		bearerVerifier, err = auth.NewBearerVerifier(cfg.BearerAuth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize bearer token authentication: %w", err)
+		}
+	}
+
+	var errorReporter errorreport.Reporter
+	if cfg.ErrorReporting.Enabled() {
+		errorReporter, err = errorreport.NewSentryReporter(cfg.ErrorReporting)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize error reporting: %w", err)
+		}
+	}
+
+	auditLog, err := audit.Open(cfg.AuditLogPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	blocked, err := blocklist.Open(cfg.BlocklistPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+
 	coreApp := &app.Application{
 		Config:              cfg,
 		GtfsConfig:          gtfsCfg,
 		Logger:              logger,
+		LogLevel:            logLevel,
+		LogOutput:           logOutput,
 		GtfsManager:         gtfsManager,
 		DirectionCalculator: directionCalculator,
 		Clock:               appClock,
+		WallClock:           wallClock,
 		Metrics:             appMetrics,
+		Quotas:              quotaManager,
+		Analytics:           analyticsCollector,
+		Bikeshare:           bikeshare,
+		Geocoder:            geocoder,
+		Notifications:       notifications,
+		Events:              eventPublisher,
+		AlertWebhooks:       alertWebhooks,
+		FeedRegistry:        registryWatcher,
+		ArrivalArchive:      arrivalArchive,
+		Ridership:           ridershipStore,
+		Detours:             detourStore,
+		Crowding:            crowdingPredictor,
+		Popularity:          popularityTracker,
+		BearerAuth:          bearerVerifier,
+		ShutdownTracing:     shutdownTracing,
+		ErrorReporter:       errorReporter,
+		RecentErrors:        errorreport.NewRecent(recentErrorsKept),
+		AuditLog:            auditLog,
+		Blocklist:           blocked,
 	}
 
 	// Start DB stats collector if database is available
@@ -77,15 +318,120 @@ func BuildApplication(cfg appconf.Config, gtfsCfg gtfs.Config) (*app.Application
 	return coreApp, nil
 }
 
-// createClock returns the appropriate Clock implementation based on environment.
+// startSnapshotUploads publishes the database to object storage now, in the background, and
+// after every static refresh.
+func startSnapshotUploads(manager *gtfs.Manager, cfg appconf.SnapshotUploadConfig, source string, logger *slog.Logger) {
+	uploader := snapshot.NewUploader(cfg, nil, logger)
+	publish := func() {
+		if _, err := uploader.Publish(context.Background(), manager, source); err != nil {
+			logger.Error("failed to publish database snapshot", "error", err)
+		}
+	}
+	manager.SetStaticUpdateHook(publish)
+	go publish()
+}
+
+// resolveRegistryFeeds returns gtfsCfg with the feed URLs the feed registry gives. When the
+// registry cannot be reached, the configured URLs are used if a static one is set.
+func resolveRegistryFeeds(ctx context.Context, cfg appconf.FeedRegistryConfig, gtfsCfg gtfs.Config, logger *slog.Logger) (*registry.Resolver, gtfs.Config, error) {
+	resolver, err := registry.NewResolver(cfg, nil, logger)
+	if err != nil {
+		return nil, gtfsCfg, err
+	}
+	feeds, err := resolver.Resolve(ctx)
+	if err != nil {
+		if gtfsCfg.GtfsURL == "" {
+			return nil, gtfsCfg, fmt.Errorf("%w, and gtfs-static-feed.url is not set to fall back on", err)
+		}
+		logger.Warn("feed registry unavailable; using the configured feed URLs", "error", err)
+		return resolver, gtfsCfg, nil
+	}
+	return resolver, feeds.Apply(gtfsCfg), nil
+}
+
+// startFeedRegistryWatch checks the feed registry every check-interval and moves the manager
+// to URLs that changed: a new static URL is loaded at once, new realtime URLs from the next
+// refresh.
+func startFeedRegistryWatch(manager *gtfs.Manager, resolver *registry.Resolver, cfg appconf.FeedRegistryConfig, gtfsCfg gtfs.Config, logger *slog.Logger) *registry.Watcher {
+	onChange := func(previous, current registry.Feeds) {
+		if current.StaticURL != previous.StaticURL {
+			manager.SetGtfsURL(current.StaticURL)
+			if err := manager.ForceUpdate(context.Background()); err != nil {
+				logger.Error("failed to load the static feed from its new URL", "error", err)
+			}
+		}
+		// The realtime auth header is kept from the configuration
+		if err := manager.SetRealtimeFeeds(current.Apply(gtfsCfg)); errors.Is(err, gtfs.ErrRealtimeToggle) {
+			logger.Warn("feed registry added or removed the realtime feeds; restart to apply")
+		}
+	}
+	watcher := registry.NewWatcher(resolver, time.Duration(cfg.CheckInterval)*time.Second, registry.FromConfig(gtfsCfg), onChange, logger)
+	watcher.Start()
+	return watcher
+}
+
+// buildQuotaManager creates the quota manager, which counts every key's requests for the
+// usage report and enforces quotas when any are configured. Counters are persisted only
+// along with quotas.
+func buildQuotaManager(cfg appconf.QuotaConfig, wallClock clock.Clock, logger *slog.Logger) (*quota.Manager, error) {
+	var store quota.Store
+	if cfg.Enabled() && cfg.DataPath != "" {
+		sqliteStore, err := quota.NewSQLiteStore(cfg.DataPath)
+		if err != nil {
+			return nil, err
+		}
+		store = sqliteStore
+	}
+
+	return quota.NewManager(cfg, store, wallClock, logger, time.Minute)
+}
+
+// popularitySeedStops caps the stops whose request counts are loaded from analytics at startup.
+const popularitySeedStops = 1000
+
+// buildPopularityTracker returns an empty popularity tracker, seeded with the stop request
+// counts of the last two half-lives when analytics are collected, so that search ranking
+// doesn't start from scratch on every restart.
+func buildPopularityTracker(appClock clock.Clock, collector *analytics.Collector, logger *slog.Logger) *popularity.Tracker {
+	tracker := popularity.New(appClock.Now)
+	if collector == nil {
+		return tracker
+	}
+	report, err := collector.Report(context.Background(), appClock.Now().Add(-2*popularity.HalfLife), popularitySeedStops)
+	if err != nil {
+		logger.Warn("failed to load stop popularity from analytics", "error", err)
+		return tracker
+	}
+	for _, count := range report.TopStops {
+		tracker.Add(popularity.Stop, count.Value, float64(count.Requests))
+	}
+	return tracker
+}
+
+// createClock returns the appropriate Clock implementation based on configuration.
+// - fake-time set: SimulatedClock (starts at the given instant, optionally accelerated)
 // - Production/Development: RealClock (uses actual system time)
 // - Test: EnvironmentClock (reads from FAKETIME env var or file, fallback to system time)
-func createClock(env appconf.Environment) clock.Clock {
-	switch env {
+func createClock(cfg appconf.Config) (clock.Clock, error) {
+	if cfg.FakeTime.Enabled() {
+		if cfg.Env == appconf.Production {
+			return nil, fmt.Errorf("fake-time cannot be used in production")
+		}
+		start, err := cfg.FakeTime.StartTime()
+		if err != nil {
+			return nil, err
+		}
+		if cfg.FakeTime.Speed < 0 {
+			return nil, fmt.Errorf("fake-time speed cannot be negative")
+		}
+		return clock.NewSimulatedClock(start, cfg.FakeTime.Speed), nil
+	}
+
+	switch cfg.Env {
 	case appconf.Test:
-		return clock.NewEnvironmentClock("FAKETIME", "/etc/faketimerc", time.Local)
+		return clock.NewEnvironmentClock("FAKETIME", "/etc/faketimerc", time.Local), nil
 	default:
-		return clock.RealClock{}
+		return clock.RealClock{}, nil
 	}
 }
 
@@ -108,14 +454,19 @@ func CreateServer(coreApp *app.Application, cfg appconf.Config) (*http.Server, *
 		ErrorLog: slog.NewLogLogger(coreApp.Logger.Handler(), slog.LevelError),
 	}))
 
-	// Wrap with security middleware
-	secureHandler := api.WithSecurityHeaders(mux)
+	// Wrap with tracing (directly around the mux so spans can be named after the route), panic recovery and security middleware
+	secureHandler := api.WithSecurityHeaders(api.RecoverPanics(restapi.TracingMiddleware(mux)))
 
 	// Add metrics middleware
 	metricsHandler := restapi.MetricsHandler(coreApp.Metrics)(secureHandler)
 
 	// Add request logging middleware (outermost)
-	requestLogger := logging.NewStructuredLogger(os.Stdout, slog.LevelInfo)
+	// Request logs are always JSON, and go wherever the application log does
+	logOutput := coreApp.LogOutput
+	if logOutput == nil {
+		logOutput = os.Stdout
+	}
+	requestLogger := logging.NewStructuredLogger(logOutput, slog.LevelInfo)
 	requestLogMiddleware := restapi.NewRequestLoggingMiddleware(requestLogger)
 
 	handler := restapi.RequestIDMiddleware(requestLogMiddleware(metricsHandler))
@@ -132,43 +483,176 @@ func CreateServer(coreApp *app.Application, cfg appconf.Config) (*http.Server, *
 	return srv, api
 }
 
+// CreateAdminServer creates the optional admin listener that serves the /api/admin endpoints
+// (usage reports and pprof) away from the public port. Returns nil if no admin port is configured.
+func CreateAdminServer(api *restapi.RestAPI, cfg appconf.Config) *http.Server {
+	if cfg.AdminPort == 0 {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	api.SetAdminRoutes(mux)
+
+	return &http.Server{
+		Addr:        fmt.Sprintf(":%d", cfg.AdminPort),
+		Handler:     restapi.RequestIDMiddleware(api.RecoverPanics(mux)),
+		IdleTimeout: time.Minute,
+		ReadTimeout: 5 * time.Second,
+		// No WriteTimeout: CPU profiles and execution traces stream for as long as requested
+		ErrorLog: slog.NewLogLogger(api.Logger.Handler(), slog.LevelError),
+	}
+}
+
+// CreateGRPCServer creates the optional listener for the gRPC API. It speaks plaintext HTTP/2
+// (h2c), so TLS is left to a proxy in front of it. Returns nil if no gRPC port is configured.
+func CreateGRPCServer(api *restapi.RestAPI, cfg appconf.Config) *http.Server {
+	if cfg.GRPCPort == 0 {
+		return nil
+	}
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.GRPCPort),
+		Handler:           api.GRPCHandler(),
+		Protocols:         &protocols,
+		IdleTimeout:       time.Minute,
+		ReadHeaderTimeout: 5 * time.Second,
+		// No read or write timeout: vehicle position streams stay open until the client or shutdown ends them
+		ErrorLog: slog.NewLogLogger(api.Logger.Handler(), slog.LevelError),
+	}
+}
+
+// reloadConfig applies the configuration files again in response to SIGHUP or a file change.
+// actor identifies the trigger in the audit log.
+func reloadConfig(api *restapi.RestAPI, logger *slog.Logger, actor string) {
+	if api == nil {
+		return
+	}
+	status := http.StatusOK
+	if _, err := api.ReloadConfig(); err != nil {
+		logger.Error("failed to reload configuration", "error", err)
+		status = http.StatusUnprocessableEntity
+	}
+	api.RecordAudit(context.Background(), actor, restapi.AuditActionConfigReload, nil, status)
+}
+
+// defaultShutdownTimeout bounds how long Run waits for in-flight requests when none is configured.
+const defaultShutdownTimeout = 30 * time.Second
+
 // Run manages the server lifecycle with graceful shutdown.
 // Starts the server in a goroutine, waits for shutdown signals (SIGINT, SIGTERM) or context cancellation,
-// and performs graceful shutdown with a 30-second timeout.
+// reloading the configuration files on SIGHUP (or when they change, if config-watch-interval is set) in the meantime,
+// and performs graceful shutdown as configured in coreApp.Config.Shutdown: /readyz fails for the drain
+// delay while requests are still served, then in-flight requests get up to the shutdown timeout to finish.
+// Connections still open after the timeout are closed and their request contexts canceled.
 // Returns an error if the server fails to start or shutdown fails.
-func Run(ctx context.Context, srv *http.Server, coreApp *app.Application, api *restapi.RestAPI, logger *slog.Logger) error {
-	logger.Info("starting server", "addr", srv.Addr)
+// auxSrvs are additional listeners (admin, HTTP-to-HTTPS redirect) that share the server's lifecycle.
+func Run(ctx context.Context, srv *http.Server, auxSrvs []*http.Server, coreApp *app.Application, api *restapi.RestAPI, logger *slog.Logger) error {
+	unixSocket := coreApp.Config.UnixSocket
+	if unixSocket != "" {
+		logger.Info("starting server", "unix_socket", unixSocket, "tls", srv.TLSConfig != nil)
+	} else {
+		logger.Info("starting server", "addr", srv.Addr, "tls", srv.TLSConfig != nil)
+	}
 
 	// Set up signal handling for graceful shutdown, merging with provided context
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Request contexts derive from baseCtx so long-running handlers can be stopped at the shutdown deadline
+	servers := append([]*http.Server{srv}, auxSrvs...)
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	for _, s := range servers {
+		s.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	}
+
 	// Channel to capture server errors
-	serverErrors := make(chan error, 1)
+	serverErrors := make(chan error, len(servers))
 
 	// Start server in a goroutine
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := listenAndServe(srv, unixSocket); err != nil && err != http.ErrServerClosed {
 			serverErrors <- err
 		}
 	}()
 
+	for _, auxSrv := range auxSrvs {
+		logger.Info("starting auxiliary server", "addr", auxSrv.Addr)
+		go func() {
+			if err := auxSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serverErrors <- fmt.Errorf("server on %s: %w", auxSrv.Addr, err)
+			}
+		}()
+	}
+
+	// SIGHUP reloads the configuration file while the listeners keep serving
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	defer signal.Stop(reloadSignals)
+
+	// Config file changes are applied like SIGHUP until shutdown begins
+	if api != nil && len(api.ConfigFiles) > 0 && coreApp.Config.ConfigWatchInterval > 0 {
+		go watchConfig(ctx, api, time.Duration(coreApp.Config.ConfigWatchInterval)*time.Second, logger)
+	}
+
 	// Wait for either shutdown signal/context cancellation or server error
-	select {
-	case err := <-serverErrors:
-		return fmt.Errorf("server failed to start: %w", err)
-	case <-ctx.Done():
-		logger.Info("shutting down server...")
+wait:
+	for {
+		select {
+		case err := <-serverErrors:
+			return fmt.Errorf("server failed to start: %w", err)
+		case <-reloadSignals:
+			// Off the signal loop: a reload can wait on an in-progress static GTFS refresh
+			go reloadConfig(api, logger, "SIGHUP")
+		case <-ctx.Done():
+			logger.Info("shutting down server...")
+			break wait
+		}
+	}
+
+	shutdownCfg := coreApp.Config.Shutdown
+
+	// Keep serving while readiness fails so load balancers stop routing new requests here
+	coreApp.StartDraining()
+	if shutdownCfg.DrainDelay > 0 {
+		drainDelay := time.Duration(shutdownCfg.DrainDelay) * time.Second
+		logger.Info("draining before shutdown", "delay", drainDelay)
+		time.Sleep(drainDelay)
 	}
 
 	// Create shutdown context with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownTimeout := defaultShutdownTimeout
+	if shutdownCfg.Timeout > 0 {
+		shutdownTimeout = time.Duration(shutdownCfg.Timeout) * time.Second
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
-	// Shutdown server
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		logger.Error("server forced to shutdown", "error", err)
-		return fmt.Errorf("server forced to shutdown: %w", err)
+	// Stop accepting connections on every listener and wait for in-flight requests
+	var wg sync.WaitGroup
+	shutdownErrors := make([]error, len(servers))
+	for i, s := range servers {
+		s.SetKeepAlivesEnabled(false)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shutdownErrors[i] = s.Shutdown(shutdownCtx)
+		}()
+	}
+	wg.Wait()
+
+	// Requests still running past the deadline are canceled and their connections closed
+	var shutdownErr error
+	if err := errors.Join(shutdownErrors...); err != nil {
+		logger.Error("server forced to shutdown", "timeout", shutdownTimeout, "error", err)
+		cancelRequests()
+		for _, s := range servers {
+			_ = s.Close()
+		}
+		shutdownErr = fmt.Errorf("server forced to shutdown: %w", err)
 	}
 
 	// Shutdown API rate limiter first (stops background goroutines for request handling)
@@ -176,6 +660,74 @@ func Run(ctx context.Context, srv *http.Server, coreApp *app.Application, api *r
 		api.Shutdown()
 	}
 
+	// Flush quota counters so usage survives the restart
+	if coreApp.Quotas != nil {
+		coreApp.Quotas.Shutdown()
+	}
+
+	// Flush analytics so the last minute of traffic is kept
+	if coreApp.Analytics != nil {
+		coreApp.Analytics.Shutdown()
+	}
+
+	if coreApp.Bikeshare != nil {
+		coreApp.Bikeshare.Shutdown()
+	}
+
+	if coreApp.Notifications != nil {
+		coreApp.Notifications.Shutdown()
+	}
+
+	if coreApp.Events != nil {
+		coreApp.Events.Shutdown()
+	}
+
+	if coreApp.AlertWebhooks != nil {
+		coreApp.AlertWebhooks.Shutdown()
+	}
+
+	if coreApp.FeedRegistry != nil {
+		coreApp.FeedRegistry.Shutdown()
+	}
+
+	if coreApp.ArrivalArchive != nil {
+		coreApp.ArrivalArchive.Shutdown()
+	}
+
+	if coreApp.Ridership != nil {
+		coreApp.Ridership.Shutdown()
+	}
+
+	if coreApp.BearerAuth != nil {
+		coreApp.BearerAuth.Close()
+	}
+
+	if coreApp.AuditLog != nil {
+		if err := coreApp.AuditLog.Close(); err != nil {
+			logger.Error("failed to close audit log", "error", err)
+		}
+	}
+
+	if coreApp.Blocklist != nil {
+		if err := coreApp.Blocklist.Close(); err != nil {
+			logger.Error("failed to close blocklist", "error", err)
+		}
+	}
+
+	// Send error reports still queued
+	if coreApp.ErrorReporter != nil {
+		if err := coreApp.ErrorReporter.Close(shutdownCtx); err != nil {
+			logger.Error("failed to flush error reports", "error", err)
+		}
+	}
+
+	// Flush spans still buffered by the exporter
+	if coreApp.ShutdownTracing != nil {
+		if err := coreApp.ShutdownTracing(shutdownCtx); err != nil {
+			logger.Error("failed to shut down tracing", "error", err)
+		}
+	}
+
 	// Shutdown metrics collector (blocks until goroutine exits)
 	if coreApp.Metrics != nil {
 		coreApp.Metrics.Shutdown()
@@ -187,65 +739,17 @@ func Run(ctx context.Context, srv *http.Server, coreApp *app.Application, api *r
 	}
 
 	logger.Info("server exited")
-	return nil
+
+	// Last, so everything above is still logged
+	if logFile, ok := coreApp.LogOutput.(io.Closer); ok {
+		_ = logFile.Close()
+	}
+	return shutdownErr
 }
 
 // dumpConfigJSON converts current configuration to JSON and prints it to stdout
 func dumpConfigJSON(cfg appconf.Config, gtfsCfg gtfs.Config) {
-	// Convert environment enum to string
-	envStr := "development"
-	switch cfg.Env {
-	case appconf.Development:
-		envStr = "development"
-	case appconf.Test:
-		envStr = "test"
-	case appconf.Production:
-		envStr = "production"
-	}
-
-	// Build gtfs-static-feed object
-	staticAuthValue := gtfsCfg.StaticAuthHeaderValue
-	if staticAuthValue != "" {
-		staticAuthValue = "***REDACTED***"
-	}
-	staticFeed := map[string]string{
-		"url": gtfsCfg.GtfsURL,
-	}
-	if gtfsCfg.StaticAuthHeaderKey != "" {
-		staticFeed["auth-header-name"] = gtfsCfg.StaticAuthHeaderKey
-		staticFeed["auth-header-value"] = staticAuthValue
-	}
-
-	// Build JSON config structure
-	jsonConfig := map[string]interface{}{
-		"port":             cfg.Port,
-		"env":              envStr,
-		"api-keys":         cfg.ApiKeys,
-		"exempt-api-keys":  cfg.ExemptApiKeys,
-		"rate-limit":       cfg.RateLimit,
-		"gtfs-static-feed": staticFeed,
-		"data-path":        gtfsCfg.GTFSDataPath,
-	}
-
-	// Add GTFS-RT feed if configured
-	feeds := []map[string]string{}
-	if gtfsCfg.TripUpdatesURL != "" || gtfsCfg.VehiclePositionsURL != "" {
-		// Mask sensitive auth header value
-		authHeaderValue := gtfsCfg.RealTimeAuthHeaderValue
-		if authHeaderValue != "" {
-			authHeaderValue = "***REDACTED***"
-		}
-
-		feed := map[string]string{
-			"trip-updates-url":           gtfsCfg.TripUpdatesURL,
-			"vehicle-positions-url":      gtfsCfg.VehiclePositionsURL,
-			"service-alerts-url":         gtfsCfg.ServiceAlertsURL,
-			"realtime-auth-header-name":  gtfsCfg.RealTimeAuthHeaderKey,
-			"realtime-auth-header-value": authHeaderValue,
-		}
-		feeds = append(feeds, feed)
-	}
-	jsonConfig["gtfs-rt-feeds"] = feeds
+	jsonConfig := app.ConfigJSON(cfg, gtfsCfg)
 
 	// Marshal to JSON with indentation
 	output, err := json.MarshalIndent(jsonConfig, "", "  ")
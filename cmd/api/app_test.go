@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -12,7 +16,9 @@ import (
 	_ "github.com/mattn/go-sqlite3" // CGo-based SQLite driver
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/app"
 	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
 	"maglev.onebusaway.org/internal/gtfs"
 )
 
@@ -62,6 +68,25 @@ func TestParseAPIKeys(t *testing.T) {
 	}
 }
 
+func TestCreateClock(t *testing.T) {
+	simulated, err := createClock(appconf.Config{Env: appconf.Development, FakeTime: appconf.FakeTimeConfig{Start: "2024-03-10T01:59:00-08:00", Speed: 60}})
+	require.NoError(t, err)
+	start := time.Date(2024, 3, 10, 9, 59, 0, 0, time.UTC)
+	now := simulated.Now()
+	assert.False(t, now.Before(start))
+	assert.Less(t, now.Sub(start), time.Minute)
+
+	realClock, err := createClock(appconf.Config{Env: appconf.Production})
+	require.NoError(t, err)
+	assert.IsType(t, clock.RealClock{}, realClock)
+
+	_, err = createClock(appconf.Config{Env: appconf.Production, FakeTime: appconf.FakeTimeConfig{Start: "2024-03-10T01:59:00Z"}})
+	assert.ErrorContains(t, err, "fake-time cannot be used in production")
+
+	_, err = createClock(appconf.Config{Env: appconf.Development, FakeTime: appconf.FakeTimeConfig{Start: "next tuesday"}})
+	assert.ErrorContains(t, err, "fake-time.start")
+}
+
 func TestBuildApplicationWithMemoryDB(t *testing.T) {
 	// Get path to test data
 	testDataPath := filepath.Join("..", "..", "testdata", "raba.zip")
@@ -292,6 +317,17 @@ func TestParseAPIKeysEdgeCases(t *testing.T) {
 	}
 }
 
+func TestConfigFileList(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var files configFileList
+	fs.Var(&files, "f", "")
+
+	require.NoError(t, fs.Parse([]string{"-f", "base.json", "-f", "prod.json"}))
+	assert.Equal(t, configFileList{"base.json", "prod.json"}, files)
+	assert.Equal(t, "base.json,prod.json", files.String())
+	assert.Equal(t, 1, fs.NFlag(), "repeated -f counts as one flag for the exclusivity check")
+}
+
 func TestRunWithPortZeroAndImmediateShutdown(t *testing.T) {
 	// This test verifies Run() can start and shutdown gracefully
 	testDataPath := filepath.Join("..", "..", "testdata", "raba.zip")
@@ -500,3 +536,72 @@ func TestBuildApplicationWithConfigFile(t *testing.T) {
 		assert.Equal(t, 50, coreApp.Config.RateLimit)
 	})
 }
+
+func TestRunCancelsRequestsAfterShutdownTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			// Simulates a streaming response that only ends when the client goes away
+			<-r.Context().Done()
+			close(canceled)
+		}),
+	}
+
+	coreApp := &app.Application{
+		Config: appconf.Config{Shutdown: appconf.ShutdownConfig{Timeout: 1}},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, srv, nil, coreApp, nil, logger)
+	}()
+
+	// Retry until the listener is up; the request then blocks until the server closes it
+	go func() {
+		for {
+			select {
+			case <-started:
+				return
+			default:
+			}
+			if resp, err := http.Get("http://" + addr); err == nil {
+				_ = resp.Body.Close()
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the handler")
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "server forced to shutdown")
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+	assert.True(t, coreApp.Draining())
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("in-flight request context was not canceled")
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/buildinfo"
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/snapshot"
+)
+
+// command is a maglev subcommand. run receives the arguments after the command name and
+// returns the process exit status.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// commands lists the subcommands in the order the usage message shows them.
+var commands = []command{
+	{"serve", "Run the API server (the default when no command is given)", serveCommand},
+	{"build-db", "Download the static GTFS feed, build the SQLite database and exit", buildDBCommand},
+	{"validate", "Check configuration files and optionally probe the feeds", validateCommand},
+	{"export", "Write data derived from the configuration; see 'maglev export -h'", exportCommand},
+	{"version", "Print version information", versionCommand},
+}
+
+// runCommand dispatches args to a subcommand. Arguments that start with a flag run the
+// server, so invocations such as "maglev -f config.json" keep working.
+func runCommand(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serveCommand(args)
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	if args[0] == "help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	printUsage(os.Stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: maglev <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'maglev <command> -h' for the flags of a command.\n")
+}
+
+// parseFlags parses args into fs, reporting whether the command should continue and, if
+// not, the exit status: 0 after -h, 2 for invalid flags.
+func parseFlags(fs *flag.FlagSet, args []string) (bool, int) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return false, 0
+		}
+		return false, 2
+	}
+	return true, 0
+}
+
+// logStartupError reports a failure that happens before the application logger exists.
+func logStartupError(message string, err error) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	logger.Error(message, "error", err)
+}
+
+// serveCommand runs the API server until it receives SIGINT or SIGTERM.
+func serveCommand(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	var flags serverFlags
+	var dumpConfig, validateOnly, validateProbe, showVersion bool
+	flags.register(fs)
+	fs.BoolVar(&showVersion, "version", false, "Print version information and exit")
+	// Kept for scripts written before the export and validate commands
+	fs.BoolVar(&dumpConfig, "dump-config", false, "Dump current configuration as JSON and exit (same as 'maglev export config')")
+	fs.BoolVar(&validateOnly, "validate-config", false, "Load and validate the -f configuration, print a report and exit (same as 'maglev validate')")
+	fs.BoolVar(&validateProbe, "probe", false, "With --validate-config, also request the feed URLs and check the data path")
+	if ok, status := parseFlags(fs, args); !ok {
+		return status
+	}
+	if showVersion {
+		fmt.Println(buildinfo.Get())
+		return 0
+	}
+	if err := flags.applyEnvFile(fs); err != nil {
+		logStartupError("invalid env file", err)
+		return 1
+	}
+
+	// Handle validate-config flag before anything is built
+	if validateOnly {
+		if countConfigFlags(fs) > 0 {
+			logStartupError("invalid flags", errors.New("--validate-config only accepts -f, -env-file and --probe"))
+			return 2
+		}
+		return runValidate(flags.configFiles, validateProbe)
+	}
+
+	cfg, gtfsCfg, err := flags.resolve(fs)
+	if err != nil {
+		logStartupError("invalid configuration", err)
+		fs.Usage()
+		return 1
+	}
+
+	// Handle dump-config flag
+	if dumpConfig {
+		dumpConfigJSON(cfg, gtfsCfg)
+		return 0
+	}
+
+	// Build application with dependencies
+	coreApp, err := BuildApplication(cfg, gtfsCfg)
+	if err != nil {
+		logStartupError("failed to build application", err)
+		return 1
+	}
+	coreApp.ConfigFiles = flags.configFiles
+
+	// Check dependencies before serving traffic; failures are reported by /readyz
+	runSelfCheck(context.Background(), coreApp, gtfsCfg, &http.Client{Timeout: selfCheckTimeout}, coreApp.Logger)
+
+	// Create HTTP server and the optional admin, gRPC and HTTPS redirect listeners
+	srv, api := CreateServer(coreApp, cfg)
+	var auxSrvs []*http.Server
+	if adminSrv := CreateAdminServer(api, cfg); adminSrv != nil {
+		auxSrvs = append(auxSrvs, adminSrv)
+	}
+	if grpcSrv := CreateGRPCServer(api, cfg); grpcSrv != nil {
+		auxSrvs = append(auxSrvs, grpcSrv)
+	}
+	redirectSrv, err := ConfigureTLS(srv, cfg.TLS, coreApp.Logger)
+	if err != nil {
+		coreApp.Logger.Error("failed to configure TLS", "error", err)
+		return 1
+	}
+	if redirectSrv != nil {
+		auxSrvs = append(auxSrvs, redirectSrv)
+	}
+
+	// Run server with graceful shutdown
+	if err := Run(context.Background(), srv, auxSrvs, coreApp, api, coreApp.Logger); err != nil {
+		coreApp.Logger.Error("server error", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// validateCommand checks configuration files without starting the server.
+func validateCommand(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	var configFiles configFileList
+	var probe bool
+	var envFile string
+	fs.Var(&configFiles, "f", "Path to a JSON, YAML or TOML configuration file; repeat to merge overlays in order")
+	fs.BoolVar(&probe, "probe", false, "Also request the feed URLs and check the data path")
+	fs.StringVar(&envFile, "env-file", defaultEnvFile, "File of KEY=VALUE environment variables loaded before the configuration; the default file is skipped in production")
+	if ok, status := parseFlags(fs, args); !ok {
+		return status
+	}
+	explicit := false
+	fs.Visit(func(fl *flag.Flag) { explicit = explicit || fl.Name == "env-file" })
+	if err := loadEnvFile(envFile, explicit, configFilesEnv(configFiles) == "production"); err != nil {
+		logStartupError("invalid env file", err)
+		return 1
+	}
+	return runValidate(configFiles, probe)
+}
+
+func runValidate(configFiles []string, probe bool) int {
+	if len(configFiles) == 0 {
+		logStartupError("nothing to validate", errors.New("give a configuration file with -f"))
+		return 2
+	}
+	if !validateConfig(os.Stdout, configFiles, probe, &http.Client{}) {
+		return 1
+	}
+	return 0
+}
+
+// exportCommand writes data derived from the configuration. "config" prints the effective
+// configuration as JSON, with feed credentials redacted; "parquet" writes the GTFS tables as
+// Parquet files.
+func exportCommand(args []string) int {
+	const exportUsage = "Usage: maglev export config [flags]\n       maglev export parquet -o DIR [flags]"
+	if len(args) == 0 || (args[0] != "config" && args[0] != "parquet") {
+		fmt.Fprintln(os.Stderr, exportUsage)
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+			return 0
+		}
+		return 2
+	}
+	if args[0] == "parquet" {
+		return exportParquetCommand(args[1:])
+	}
+
+	fs := flag.NewFlagSet("export config", flag.ContinueOnError)
+	var flags serverFlags
+	flags.register(fs)
+	if ok, status := parseFlags(fs, args[1:]); !ok {
+		return status
+	}
+	if err := flags.applyEnvFile(fs); err != nil {
+		logStartupError("invalid env file", err)
+		return 1
+	}
+	cfg, gtfsCfg, err := flags.resolve(fs)
+	if err != nil {
+		logStartupError("invalid configuration", err)
+		return 1
+	}
+	dumpConfigJSON(cfg, gtfsCfg)
+	return 0
+}
+
+// exportParquetCommand loads the feed and writes one <table>.parquet file per table to the
+// output directory. The vehicle positions are those of the first realtime fetch.
+func exportParquetCommand(args []string) int {
+	fs := flag.NewFlagSet("export parquet", flag.ContinueOnError)
+	var feed feedFlags
+	feed.register(fs)
+	outDir := fs.String("o", "", "Directory to write the Parquet files to")
+	tablesFlag := fs.String("tables", strings.Join(gtfs.ParquetTables, ","), "Comma-separated tables to export")
+	if ok, status := parseFlags(fs, args); !ok {
+		return status
+	}
+	if *outDir == "" {
+		logStartupError("invalid flags", errors.New("-o is required"))
+		return 2
+	}
+	tables := strings.Split(*tablesFlag, ",")
+	for i, table := range tables {
+		table = strings.TrimSpace(table)
+		tables[i] = table
+		if !slices.Contains(gtfs.ParquetTables, table) {
+			logStartupError("invalid flags", fmt.Errorf("unknown table %q; expected one of %s", table, strings.Join(gtfs.ParquetTables, ", ")))
+			return 2
+		}
+	}
+	gtfsCfg, status := feed.resolve(fs)
+	if status != 0 {
+		return status
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		logStartupError("failed to create output directory", err)
+		return 1
+	}
+	manager, err := gtfs.InitGTFSManager(gtfsCfg)
+	if err != nil {
+		logStartupError("failed to load GTFS data", err)
+		return 1
+	}
+	defer manager.Shutdown()
+
+	for _, table := range tables {
+		path := filepath.Join(*outDir, table+".parquet")
+		if err := writeParquetFile(manager, table, path); err != nil {
+			logStartupError("failed to export "+table, err)
+			return 1
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	return 0
+}
+
+func writeParquetFile(manager *gtfs.Manager, table, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	manager.RLock()
+	err = manager.ExportParquet(context.Background(), table, f)
+	manager.RUnlock()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// feedFlags are the flags of the commands that load the static feed without serving it.
+type feedFlags struct {
+	configFiles    configFileList
+	gtfsCfg        gtfs.Config
+	snapshotUpload appconf.SnapshotUploadConfig // From -f only
+}
+
+func (f *feedFlags) register(fs *flag.FlagSet) {
+	fs.Var(&f.configFiles, "f", "Path to a JSON, YAML or TOML configuration file to read the feed and data path from (mutually exclusive with other flags)")
+	fs.StringVar(&f.gtfsCfg.GtfsURL, "gtfs-url", "https://www.soundtransit.org/GTFS-rail/40_gtfs.zip", "URL or path of a static GTFS zip file")
+	fs.StringVar(&f.gtfsCfg.StaticAuthHeaderKey, "gtfs-static-auth-header-name", "", "Optional header name for static GTFS feed auth")
+	fs.StringVar(&f.gtfsCfg.StaticAuthHeaderValue, "gtfs-static-auth-header-value", "", "Optional header value for static GTFS feed auth")
+	fs.StringVar(&f.gtfsCfg.GTFSDataPath, "data-path", "./gtfs.db", "Path of the SQLite database")
+	fs.BoolVar(&f.gtfsCfg.EnableGTFSTidy, "enable-gtfs-tidy", false, "Clean the feed with gtfstidy before importing it")
+}
+
+// resolve returns the feed configuration from -f or the individual flags, or a non-zero exit
+// status after reporting the problem.
+func (f *feedFlags) resolve(fs *flag.FlagSet) (gtfs.Config, int) {
+	if len(f.configFiles) == 0 {
+		return f.gtfsCfg, 0
+	}
+	if countConfigFlags(fs) > 0 {
+		logStartupError("invalid flags", errors.New("the -f flag is mutually exclusive with other flags"))
+		return gtfs.Config{}, 2
+	}
+	jsonConfig, err := appconf.LoadFromFiles(f.configFiles...)
+	if err != nil {
+		logStartupError("failed to load config file", err)
+		return gtfs.Config{}, 1
+	}
+	f.snapshotUpload = jsonConfig.SnapshotUpload
+	gtfsCfg := gtfsConfigFromJSON(jsonConfig)
+	if jsonConfig.FeedRegistry.Enabled() {
+		_, gtfsCfg, err = resolveRegistryFeeds(context.Background(), jsonConfig.FeedRegistry, gtfsCfg, slog.Default())
+		if err != nil {
+			logStartupError("failed to resolve feeds", err)
+			return gtfs.Config{}, 1
+		}
+	}
+	return gtfsCfg, 0
+}
+
+// buildDBCommand builds the GTFS database from the static feed, so it can be prepared ahead
+// of a deploy or baked into an image. The realtime feeds are not fetched. When the -f config
+// has a snapshot-upload bucket, the database is published there too.
+func buildDBCommand(args []string) int {
+	fs := flag.NewFlagSet("build-db", flag.ContinueOnError)
+	var feed feedFlags
+	feed.register(fs)
+	if ok, status := parseFlags(fs, args); !ok {
+		return status
+	}
+	gtfsCfg, status := feed.resolve(fs)
+	if status != 0 {
+		return status
+	}
+	gtfsCfg.TripUpdatesURL, gtfsCfg.VehiclePositionsURL, gtfsCfg.ServiceAlertsURL = "", "", ""
+	gtfsCfg.ExtraRealtimeFeeds = nil
+
+	manager, err := gtfs.InitGTFSManager(gtfsCfg)
+	if err != nil {
+		logStartupError("failed to build GTFS database", err)
+		return 1
+	}
+	defer manager.Shutdown()
+	counts := manager.Status()
+	fmt.Printf("Built %s: %d agencies, %d routes, %d stops, %d trips\n",
+		gtfsCfg.GTFSDataPath, counts.Agencies, counts.Routes, counts.Stops, counts.Trips)
+
+	if feed.snapshotUpload.Enabled() {
+		meta, err := snapshot.NewUploader(feed.snapshotUpload, nil, nil).Publish(context.Background(), manager, gtfsCfg.GtfsURL)
+		if err != nil {
+			logStartupError("failed to publish database snapshot", err)
+			return 1
+		}
+		fmt.Printf("Published %s to bucket %s\n", meta.Key, feed.snapshotUpload.Bucket)
+	}
+	return 0
+}
+
+// versionCommand prints the version, commit and build date of the binary.
+func versionCommand(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	if ok, status := parseFlags(fs, args); !ok {
+		return status
+	}
+	fmt.Println(buildinfo.Get())
+	return 0
+}
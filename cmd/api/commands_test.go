@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func TestRunCommand(t *testing.T) {
+	assert.Equal(t, 2, runCommand([]string{"frobnicate"}))
+	assert.Equal(t, 0, runCommand([]string{"help"}))
+	assert.Equal(t, 0, runCommand([]string{"version"}))
+	assert.Equal(t, 0, runCommand([]string{"--version"}))
+	assert.Equal(t, 0, runCommand([]string{"serve", "-h"}))
+	assert.Equal(t, 2, runCommand([]string{"serve", "-no-such-flag"}))
+	assert.Equal(t, 2, runCommand([]string{"validate"}), "validate needs -f")
+	assert.Equal(t, 2, runCommand([]string{"export"}))
+	assert.Equal(t, 0, runCommand([]string{"export", "config", "-port", "8080"}))
+	assert.Equal(t, 2, runCommand([]string{"export", "parquet"}), "export parquet needs -o")
+	assert.Equal(t, 2, runCommand([]string{"export", "parquet", "-o", t.TempDir(), "-tables", "agencies"}))
+	assert.Equal(t, 0, runCommand([]string{"validate", "-f", filepath.Join("..", "..", "testdata", "config_valid.json")}))
+	assert.Equal(t, 1, runCommand([]string{"validate", "-f", filepath.Join("..", "..", "testdata", "config_invalid.json")}))
+}
+
+func TestBuildDBCommand(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "gtfs.db")
+	gtfsPath, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err)
+
+	require.Equal(t, 0, runCommand([]string{"build-db", "-gtfs-url", gtfsPath, "-data-path", dataPath}))
+	assert.FileExists(t, dataPath)
+
+	assert.Equal(t, 2, runCommand([]string{"build-db", "-f", "config.json", "-data-path", dataPath}),
+		"-f cannot be combined with other flags")
+}
+
+func TestServerFlagsResolve(t *testing.T) {
+	parse := func(args ...string) (*flag.FlagSet, *serverFlags) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := &serverFlags{}
+		flags.register(fs)
+		require.NoError(t, fs.Parse(args))
+		return fs, flags
+	}
+
+	fs, flags := parse("-port", "8080", "-env", "production", "-api-keys", "a, b", "-admin-api-keys", "admin",
+		"-gtfs-url", "https://example.com/gtfs.zip", "-vehicle-positions-url", "https://example.com/vehicle-positions.pb")
+	cfg, gtfsCfg, err := flags.resolve(fs)
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, appconf.Production, cfg.Env)
+	assert.Equal(t, appconf.Production, gtfsCfg.Env)
+	assert.Equal(t, []string{"a", "b"}, cfg.ApiKeys)
+	assert.Equal(t, []string{"admin"}, cfg.AdminApiKeys)
+	assert.True(t, cfg.Verbose)
+
+	// Production must not fall back to the Puget Sound feeds
+	fs, flags = parse("-env", "production", "-gtfs-url", "https://example.com/gtfs.zip")
+	_, _, err = flags.resolve(fs)
+	assert.ErrorContains(t, err, "-trip-updates-url or -vehicle-positions-url is required with -env production")
+
+	fs, flags = parse("-env", "production", "-trip-updates-url", "https://example.com/trip-updates.pb")
+	_, _, err = flags.resolve(fs)
+	assert.ErrorContains(t, err, "-gtfs-url is required with -env production")
+
+	fs, flags = parse("-f", filepath.Join("..", "..", "testdata", "config_valid.json"))
+	cfg, _, err = flags.resolve(fs)
+	require.NoError(t, err)
+	assert.Equal(t, 3000, cfg.Port)
+
+	fs, flags = parse("-f", "config.json", "-port", "8080")
+	_, _, err = flags.resolve(fs)
+	assert.ErrorContains(t, err, "mutually exclusive")
+}
+
+func TestBuildDBCommandPublishesSnapshot(t *testing.T) {
+	var mu sync.Mutex
+	uploaded := map[string]int{}
+	bucket := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		uploaded[r.URL.Path] = len(body)
+		mu.Unlock()
+	}))
+	t.Cleanup(bucket.Close)
+
+	dir := t.TempDir()
+	gtfsPath, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err)
+	configPath := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{
+		"gtfs-static-feed": {"url": "`+gtfsPath+`"},
+		"data-path": "`+filepath.Join(dir, "gtfs.db")+`",
+		"snapshot-upload": {"endpoint": "`+bucket.URL+`", "bucket": "maglev", "access-key-id": "AKID", "secret-access-key": "secret"}
+	}`), 0o600))
+
+	require.Equal(t, 0, runCommand([]string{"build-db", "-f", configPath}))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Positive(t, uploaded["/maglev/latest.json"])
+	assert.Len(t, uploaded, 2, "the database and its metadata")
+}
+
+func TestExportParquetCommand(t *testing.T) {
+	dir := t.TempDir()
+	gtfsPath, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err)
+
+	require.Equal(t, 0, runCommand([]string{"export", "parquet", "-gtfs-url", gtfsPath,
+		"-data-path", filepath.Join(dir, "gtfs.db"), "-o", filepath.Join(dir, "out"), "-tables", "stops, trips"}))
+
+	for _, table := range []string{"stops", "trips"} {
+		data, err := os.ReadFile(filepath.Join(dir, "out", table+".parquet"))
+		require.NoError(t, err)
+		assert.Equal(t, "PAR1", string(data[:4]))
+		assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "out", "stop_times.parquet"))
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/gtfs"
+)
+
+// configFileList collects repeated -f flags: a base configuration file followed by overlays.
+type configFileList []string
+
+func (l *configFileList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *configFileList) Set(path string) error {
+	*l = append(*l, path)
+	return nil
+}
+
+// modeFlags select what the binary does rather than how the server is configured, so they
+// may be combined with -f.
+var modeFlags = map[string]bool{"f": true, "env-file": true, "dump-config": true, "validate-config": true, "probe": true, "version": true, "o": true, "tables": true}
+
+// countConfigFlags returns how many configuration flags were set on fs.
+func countConfigFlags(fs *flag.FlagSet) int {
+	count := 0
+	fs.Visit(func(f *flag.Flag) {
+		if !modeFlags[f.Name] {
+			count++
+		}
+	})
+	return count
+}
+
+// serverFlags are the flags that configure the server, either one by one or through -f.
+type serverFlags struct {
+	cfg                      appconf.Config
+	gtfsCfg                  gtfs.Config
+	apiKeysFlag              string
+	exemptApiKeysFlag        string
+	adminApiKeysFlag         string
+	bulkApiKeysFlag          string
+	autocertDomainsFlag      string
+	rateLimitExemptPathsFlag string
+	corsOriginsFlag          string
+	corsMethodsFlag          string
+	staticRefreshFlag        int
+	envFlag                  string
+	envFile                  string
+	configFiles              configFileList
+}
+
+// register defines the configuration flags on fs.
+func (f *serverFlags) register(fs *flag.FlagSet) {
+	fs.Var(&f.configFiles, "f", "Path to a JSON, YAML or TOML configuration file; repeat to merge overlays in order (mutually exclusive with other flags)")
+	fs.StringVar(&f.envFile, "env-file", defaultEnvFile, "File of KEY=VALUE environment variables loaded before the configuration; the default file is skipped in production")
+	fs.IntVar(&f.cfg.Port, "port", 4000, "API server port")
+	fs.StringVar(&f.envFlag, "env", "development", "Environment (development|test|production)")
+	fs.StringVar(&f.apiKeysFlag, "api-keys", "test", "Comma Separated API Keys (test, etc)")
+	fs.StringVar(&f.exemptApiKeysFlag, "exempt-api-keys", "org.onebusaway.iphone", "Comma separated list of API keys exempt from rate limiting")
+	fs.StringVar(&f.adminApiKeysFlag, "admin-api-keys", "", "Comma separated list of API keys allowed to call the admin endpoints")
+	fs.StringVar(&f.bulkApiKeysFlag, "bulk-api-keys", "", "Comma separated list of API keys allowed page sizes up to each pagination class's bulk-max-count")
+	fs.IntVar(&f.cfg.AdminPort, "admin-port", 0, "Serve the admin endpoints (usage, pprof) only on this port (0 = serve them on -port)")
+	fs.IntVar(&f.cfg.GRPCPort, "grpc-port", 0, "Serve the gRPC API on this port (0 = disabled)")
+	fs.IntVar(&f.cfg.RateLimit, "rate-limit", 100, "Requests per second per API key for rate limiting")
+	fs.StringVar(&f.rateLimitExemptPathsFlag, "rate-limit-exempt-paths", "", "Comma separated request paths served without rate limits or quotas (a trailing * matches a prefix)")
+	fs.StringVar(&f.cfg.Logging.Level, "log-level", "info", "Minimum level of application logs (debug|info|warn|error)")
+	fs.StringVar(&f.cfg.Logging.Format, "log-format", "text", "Format of application logs (text|json)")
+	fs.StringVar(&f.cfg.Logging.Output, "log-output", "stdout", "Where logs are written: stdout, stderr, or a file path")
+	fs.IntVar(&f.cfg.Logging.Rotation.MaxSize, "log-max-size", 0, "Rotate the -log-output file after this many megabytes (0 = no size limit)")
+	fs.IntVar(&f.cfg.Logging.Rotation.Interval, "log-rotate-interval", 0, "Rotate the -log-output file every this many hours (0 = never)")
+	fs.IntVar(&f.cfg.Logging.Rotation.MaxBackups, "log-max-backups", 0, "Rotated log files to keep (0 = all)")
+	fs.IntVar(&f.cfg.ResponseCache.MaxEntries, "response-cache-entries", 0, "Maximum number of static-data responses kept in the in-memory response cache (0 = disabled)")
+	fs.IntVar(&f.cfg.RequestLimits.MaxURLLength, "max-url-length", appconf.DefaultMaxURLLength, "Reject API requests whose URL is longer than this many bytes")
+	fs.IntVar(&f.cfg.RequestLimits.MaxQueryParams, "max-query-params", appconf.DefaultMaxQueryParams, "Reject API requests with more query parameters than this")
+	fs.IntVar(&f.cfg.RequestLimits.MaxIDLength, "max-id-length", appconf.DefaultMaxIDLength, "Reject API requests whose {id} path segment is longer than this")
+	fs.StringVar(&f.corsOriginsFlag, "cors-allowed-origins", "*", "Comma separated origins allowed to call the API from a browser, e.g. https://*.example.com (* allows any)")
+	fs.StringVar(&f.corsMethodsFlag, "cors-allowed-methods", "GET,OPTIONS", "Comma separated methods sent in Access-Control-Allow-Methods")
+	fs.IntVar(&f.cfg.CORS.MaxAge, "cors-max-age", appconf.DefaultCORSConfig().MaxAge, "Seconds browsers may cache a CORS preflight response")
+	fs.Int64Var(&f.cfg.Quotas.Default.Daily, "daily-quota", 0, "Maximum requests per API key per UTC day (0 = unlimited)")
+	fs.Int64Var(&f.cfg.Quotas.Default.Monthly, "monthly-quota", 0, "Maximum requests per API key per UTC month (0 = unlimited)")
+	fs.StringVar(&f.cfg.Quotas.DataPath, "quota-data-path", "./quota.db", "Path to the SQLite database that persists quota counters")
+	fs.StringVar(&f.cfg.Analytics.DataPath, "analytics-data-path", "", "SQLite file for anonymized usage analytics (disabled when empty)")
+	fs.IntVar(&f.cfg.Analytics.RetentionDays, "analytics-retention-days", 90, "Days of usage analytics to keep")
+	fs.StringVar(&f.cfg.Tracing.Endpoint, "otlp-endpoint", "", "OTLP/HTTP endpoint for OpenTelemetry traces, e.g. http://localhost:4318/v1/traces (tracing is disabled when empty)")
+	fs.Float64Var(&f.cfg.Tracing.SampleRatio, "trace-sample-ratio", 1, "Fraction of new traces to sample (0-1)")
+	fs.StringVar(&f.cfg.UnixSocket, "unix-socket", "", "Listen on this Unix domain socket instead of -port")
+	fs.StringVar(&f.cfg.AuditLogPath, "audit-log-path", "", "SQLite file recording admin actions (kept in memory when empty)")
+	fs.StringVar(&f.cfg.BlocklistPath, "blocklist-path", "", "SQLite file persisting blocked API keys and networks (kept in memory when empty)")
+	fs.StringVar(&f.cfg.CrowdingPredictor, "crowding-predictor", "", "Predictor of how full arriving vehicles will be, e.g. heuristic (no predictions when empty)")
+	fs.Float64Var(&f.cfg.SearchPopularityWeight, "search-popularity-weight", 0, "How much request counts move popular stops and routes up search results, e.g. 0.5 (0 = disabled)")
+	fs.StringVar(&f.cfg.NotFoundResponses, "not-found-responses", appconf.NotFoundConsistent, "How requests for unknown agencies, routes, stops and trips are answered (consistent|java)")
+	fs.BoolVar(&f.cfg.JavaParity, "java-parity", false, "Write responses exactly as the Java OneBusAway server does, for clients that depend on its output")
+	fs.BoolVar(&f.cfg.CanonicalJSON, "canonical-json", false, "Serialize response data deterministically and answer If-None-Match with 304 using ETags")
+	fs.BoolVar(&f.cfg.GraphQL, "graphql", false, "Serve GraphQL queries over agencies, routes, stops, trips and vehicle positions at /graphql")
+	fs.StringVar(&f.cfg.DetoursPath, "detours-path", "", "JSON file of route detours and the paths driven (detours come from service alerts only when empty)")
+	fs.StringVar(&f.cfg.ErrorReporting.SentryDSN, "sentry-dsn", "", "Sentry DSN to report server errors and panics to (disabled when empty)")
+	fs.StringVar(&f.cfg.TLS.CertFile, "tls-cert", "", "Path to a PEM certificate; serve HTTPS with it (requires -tls-key)")
+	fs.StringVar(&f.cfg.TLS.KeyFile, "tls-key", "", "Path to the PEM private key for -tls-cert")
+	fs.StringVar(&f.autocertDomainsFlag, "autocert-domains", "", "Comma separated hostnames to obtain Let's Encrypt certificates for (serves HTTPS)")
+	fs.StringVar(&f.cfg.TLS.AutocertCacheDir, "autocert-cache-dir", "./autocert-cache", "Directory where ACME certificates and the account key are stored")
+	fs.StringVar(&f.cfg.TLS.AutocertEmail, "autocert-email", "", "Optional contact email for the ACME account")
+	fs.IntVar(&f.cfg.TLS.RedirectPort, "http-redirect-port", 0, "Plain HTTP port that redirects to HTTPS and answers ACME challenges (0 = disabled)")
+	fs.IntVar(&f.cfg.Shutdown.Timeout, "shutdown-timeout", 30, "Seconds to wait for in-flight requests on shutdown before closing connections")
+	fs.IntVar(&f.cfg.Shutdown.DrainDelay, "shutdown-drain-delay", 0, "Seconds to keep serving after SIGTERM while /readyz reports draining")
+	fs.StringVar(&f.cfg.FakeTime.Start, "fake-time", "", "Run on a simulated clock starting at this time (RFC3339, or YYYY-MM-DD[ HH:MM:SS] local time); not allowed with -env production")
+	fs.Float64Var(&f.cfg.FakeTime.Speed, "fake-time-speed", 1, "Simulated seconds per real second for -fake-time")
+	fs.IntVar(&f.cfg.RealtimeStalenessBudget, "realtime-staleness-budget", 300, "Seconds without a successful GTFS-RT refresh before /readyz reports not ready")
+	fs.StringVar(&f.gtfsCfg.GtfsURL, "gtfs-url", "https://www.soundtransit.org/GTFS-rail/40_gtfs.zip", "URL for a static GTFS zip file")
+	fs.StringVar(&f.gtfsCfg.StaticAuthHeaderKey, "gtfs-static-auth-header-name", "", "Optional header name for static GTFS feed auth")
+	fs.StringVar(&f.gtfsCfg.StaticAuthHeaderValue, "gtfs-static-auth-header-value", "", "Optional header value for static GTFS feed auth")
+	fs.IntVar(&f.staticRefreshFlag, "static-refresh-interval", 86400, "Seconds between re-downloads of the -gtfs-url feed; an unchanged feed is not re-imported")
+	fs.StringVar(&f.gtfsCfg.TripUpdatesURL, "trip-updates-url", "https://api.pugetsound.onebusaway.org/api/gtfs_realtime/trip-updates-for-agency/40.pb?key=org.onebusaway.iphone", "URL for a GTFS-RT trip updates feed")
+	fs.StringVar(&f.gtfsCfg.VehiclePositionsURL, "vehicle-positions-url", "https://api.pugetsound.onebusaway.org/api/gtfs_realtime/vehicle-positions-for-agency/40.pb?key=org.onebusaway.iphone", "URL for a GTFS-RT vehicle positions feed")
+	fs.StringVar(&f.gtfsCfg.RealTimeAuthHeaderKey, "realtime-auth-header-name", "", "Optional header name for GTFS-RT auth")
+	fs.StringVar(&f.gtfsCfg.RealTimeAuthHeaderValue, "realtime-auth-header-value", "", "Optional header value for GTFS-RT auth")
+	fs.StringVar(&f.gtfsCfg.ServiceAlertsURL, "service-alerts-url", "", "URL for a GTFS-RT service alerts feed")
+	fs.StringVar(&f.gtfsCfg.GTFSDataPath, "data-path", "./gtfs.db", "Path to the SQLite database containing GTFS data")
+}
+
+// applyEnvFile loads the -env-file variables. The environment is taken from -env or, with -f,
+// from the configuration files, since the default file is not loaded in production.
+func (f *serverFlags) applyEnvFile(fs *flag.FlagSet) error {
+	explicit := false
+	fs.Visit(func(fl *flag.Flag) { explicit = explicit || fl.Name == "env-file" })
+	env := f.envFlag
+	if len(f.configFiles) > 0 {
+		env = configFilesEnv(f.configFiles)
+	}
+	return loadEnvFile(f.envFile, explicit, env == "production")
+}
+
+// resolve returns the configuration selected by the parsed flags: the -f files if given,
+// otherwise the individual flags.
+func (f *serverFlags) resolve(fs *flag.FlagSet) (appconf.Config, gtfs.Config, error) {
+	// Enforce mutual exclusivity between -f and other flags (except the dump and validate modes)
+	if len(f.configFiles) > 0 && countConfigFlags(fs) > 0 {
+		return appconf.Config{}, gtfs.Config{}, fmt.Errorf("the -f flag is mutually exclusive with other configuration flags (except -env-file, --dump-config and --validate-config)")
+	}
+
+	if len(f.configFiles) > 0 {
+		// Load configuration from the JSON file and any overlays
+		jsonConfig, err := appconf.LoadFromFiles(f.configFiles...)
+		if err != nil {
+			return appconf.Config{}, gtfs.Config{}, fmt.Errorf("failed to load config file: %w", err)
+		}
+		return jsonConfig.ToAppConfig(), gtfsConfigFromJSON(jsonConfig), nil
+	}
+
+	// Use command-line flags for configuration
+	cfg, gtfsCfg := f.cfg, f.gtfsCfg
+
+	// Set verbosity flags
+	gtfsCfg.Verbose = true
+	cfg.Verbose = true
+
+	// Parse API keys
+	cfg.ApiKeys = ParseAPIKeys(f.apiKeysFlag)
+
+	// Parse Exempt API Keys
+	if f.exemptApiKeysFlag != "" {
+		cfg.ExemptApiKeys = ParseAPIKeys(f.exemptApiKeysFlag)
+	}
+
+	// Parse Admin API Keys
+	cfg.AdminApiKeys = ParseAPIKeys(f.adminApiKeysFlag)
+
+	// Parse Bulk API Keys
+	cfg.BulkApiKeys = ParseAPIKeys(f.bulkApiKeysFlag)
+
+	// Parse rate-limit-exempt paths
+	cfg.RateLimitExemptPaths = ParseAPIKeys(f.rateLimitExemptPathsFlag)
+
+	// Parse CORS origins and methods
+	cfg.CORS.AllowedOrigins = ParseAPIKeys(f.corsOriginsFlag)
+	cfg.CORS.AllowedMethods = ParseAPIKeys(f.corsMethodsFlag)
+
+	// Parse ACME domains
+	cfg.TLS.AutocertDomains = ParseAPIKeys(f.autocertDomainsFlag)
+
+	// Convert environment flag to enum
+	cfg.Env = appconf.EnvFlagToEnvironment(f.envFlag)
+	if cfg.Env == appconf.Production {
+		if err := requireFeedFlags(fs); err != nil {
+			return appconf.Config{}, gtfs.Config{}, err
+		}
+	}
+
+	if f.staticRefreshFlag < 60 {
+		return appconf.Config{}, gtfs.Config{}, fmt.Errorf("-static-refresh-interval must be at least 60 seconds, got %d", f.staticRefreshFlag)
+	}
+	gtfsCfg.StaticRefreshInterval = time.Duration(f.staticRefreshFlag) * time.Second
+
+	// Set GTFS config environment
+	gtfsCfg.Env = cfg.Env
+	return cfg, gtfsCfg, nil
+}
+
+// requireFeedFlags returns an error unless the feed URL flags were given explicitly. Their
+// defaults point at Sound Transit and Puget Sound feeds, which a production instance for any
+// other region must not silently serve.
+func requireFeedFlags(fs *flag.FlagSet) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if !set["gtfs-url"] {
+		return fmt.Errorf("-gtfs-url is required with -env production (the default Sound Transit feed is only used in development and test)")
+	}
+	if !set["trip-updates-url"] && !set["vehicle-positions-url"] {
+		return fmt.Errorf("-trip-updates-url or -vehicle-positions-url is required with -env production (the default Puget Sound feeds are only used in development and test)")
+	}
+	return nil
+}
+
+// gtfsConfigFromJSON converts the feed settings of a configuration file into a GTFS config.
+func gtfsConfigFromJSON(jsonConfig *appconf.JSONConfig) gtfs.Config {
+	gtfsCfgData := jsonConfig.ToGtfsConfigData()
+	return gtfs.Config{
+		GtfsURL:                 gtfsCfgData.GtfsURL,
+		StaticAuthHeaderKey:     gtfsCfgData.StaticAuthHeaderKey,
+		StaticAuthHeaderValue:   gtfsCfgData.StaticAuthHeaderValue,
+		StaticIDPrefix:          gtfsCfgData.StaticIDPrefix,
+		ExtraStaticFeeds:        gtfs.StaticFeedsFromJSON(gtfsCfgData.ExtraStaticFeeds),
+		StaticRefreshInterval:   time.Duration(gtfsCfgData.StaticRefreshInterval) * time.Second,
+		TripUpdatesURL:          gtfsCfgData.TripUpdatesURL,
+		VehiclePositionsURL:     gtfsCfgData.VehiclePositionsURL,
+		ServiceAlertsURL:        gtfsCfgData.ServiceAlertsURL,
+		RealTimeAuthHeaderKey:   gtfsCfgData.RealTimeAuthHeaderKey,
+		RealTimeAuthHeaderValue: gtfsCfgData.RealTimeAuthHeaderValue,
+		RealTimeAgencyID:        gtfsCfgData.RealTimeAgencyID,
+		RealTimeIDPrefix:        gtfsCfgData.RealTimeIDPrefix,
+		ExtraRealtimeFeeds:      gtfs.RealtimeFeedsFromJSON(gtfsCfgData.ExtraRealtimeFeeds),
+		GTFSDataPath:            gtfsCfgData.GTFSDataPath,
+		Env:                     gtfsCfgData.Env,
+		Verbose:                 gtfsCfgData.Verbose,
+		EnableGTFSTidy:          gtfsCfgData.EnableGTFSTidy,
+	}
+}
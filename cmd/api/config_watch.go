@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"maglev.onebusaway.org/internal/restapi"
+)
+
+// watchConfig checks the configuration files every interval and reloads them when their
+// contents change, as if the process had received SIGHUP. Returns when ctx is done.
+func watchConfig(ctx context.Context, api *restapi.RestAPI, interval time.Duration, logger *slog.Logger) {
+	last, err := configFingerprint(api.ConfigFiles)
+	if err != nil {
+		logger.Warn("failed to read configuration files for watching", "error", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := configFingerprint(api.ConfigFiles)
+		if err != nil {
+			// A file replaced by rename can be briefly missing; check again on the next tick
+			logger.Debug("failed to read configuration files for watching", "error", err)
+			continue
+		}
+		if current == last {
+			continue
+		}
+		last = current
+
+		logger.Info("configuration files changed, reloading", "files", api.ConfigFiles)
+		reloadConfig(api, logger, "config-watch")
+	}
+}
+
+// configFingerprint hashes the contents of paths. Contents are compared rather than
+// modification times, which can miss writes within the same second and change on a touch.
+func configFingerprint(paths []string) (string, error) {
+	hash := sha256.New()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		// Length-prefix each file so moving bytes between overlays changes the hash
+		fmt.Fprintf(hash, "%d:", len(data))
+		hash.Write(data)
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
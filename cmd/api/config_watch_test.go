@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/restapi"
+)
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	write := func(apiKey string) {
+		data, err := json.Marshal(map[string]interface{}{
+			"env":      "test",
+			"api-keys": []string{apiKey},
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+	}
+	write("old-key")
+
+	jsonConfig, err := appconf.LoadFromFile(path)
+	require.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	api := restapi.NewRestAPI(&app.Application{
+		Config:      jsonConfig.ToAppConfig(),
+		ConfigFiles: []string{path},
+		Logger:      logger,
+		Clock:       clock.RealClock{},
+	})
+	defer api.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watchConfig(ctx, api, 10*time.Millisecond, logger)
+		close(done)
+	}()
+
+	// Let the watcher record the initial contents
+	time.Sleep(50 * time.Millisecond)
+	write("new-key")
+	assert.Eventually(t, func() bool { return !api.IsInvalidAPIKey("new-key") }, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, api.IsInvalidAPIKey("old-key"))
+
+	// An invalid edit keeps the running keys, and fixing it is picked up
+	require.NoError(t, os.WriteFile(path, []byte(`{"env": "test", "api-keys": [`), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, api.IsInvalidAPIKey("new-key"))
+	write("fixed-key")
+	assert.Eventually(t, func() bool { return !api.IsInvalidAPIKey("fixed-key") }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchConfig did not return after cancellation")
+	}
+}
+
+func TestConfigFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	overlay := filepath.Join(dir, "overlay.json")
+	require.NoError(t, os.WriteFile(base, []byte(`{"a": 1}`), 0o600))
+	require.NoError(t, os.WriteFile(overlay, []byte(`{}`), 0o600))
+
+	first, err := configFingerprint([]string{base, overlay})
+	require.NoError(t, err)
+	again, err := configFingerprint([]string{base, overlay})
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	require.NoError(t, os.WriteFile(overlay, []byte(`{"a": 2}`), 0o600))
+	changed, err := configFingerprint([]string{base, overlay})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+
+	_, err = configFingerprint([]string{filepath.Join(dir, "missing.json")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultEnvFile is read from the working directory when -env-file is not given.
+const defaultEnvFile = ".env"
+
+var envFileKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// loadEnvFile sets the variables in a .env file that are not already in the environment, so
+// they apply to the environment overrides read while loading the configuration. A missing
+// default file is ignored, as is the default file in production, where variables should come
+// from the deployment; naming the file explicitly loads it in any environment.
+func loadEnvFile(path string, explicit, production bool) error {
+	if !explicit && production {
+		if _, err := os.Stat(path); err == nil {
+			slog.New(slog.NewTextHandler(os.Stdout, nil)).Warn("ignoring env file in production; pass -env-file to load it", "path", path)
+		}
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read env file: %w", err)
+	}
+	vars, err := parseEnvFile(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("env file %s: %w", path, err)
+	}
+
+	for _, v := range vars {
+		if _, set := os.LookupEnv(v[0]); set {
+			continue
+		}
+		if err := os.Setenv(v[0], v[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEnvFile reads KEY=VALUE lines in file order. Blank lines, # comments and an "export "
+// prefix are skipped. Double quoted values take Go escapes such as \n; single quoted values
+// are used as is; unquoted values end at " #".
+func parseEnvFile(r io.Reader) ([][2]string, error) {
+	var vars [][2]string
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !envFileKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value for %s", lineNumber, key)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars = append(vars, [2]string{key, value})
+	}
+	return vars, scanner.Err()
+}
+
+// configFilesEnv returns the env named by the configuration files, the last one setting it
+// winning, before they are fully loaded. Unreadable files are skipped here and reported by
+// the full load.
+func configFilesEnv(files []string) string {
+	env := ""
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var layer struct {
+			Env *string `json:"env"`
+		}
+		if json.Unmarshal(data, &layer) == nil && layer.Env != nil {
+			env = *layer.Env
+		}
+	}
+	return env
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	vars, err := parseEnvFile(strings.NewReader(`
+# Local development
+GTFS_API_KEYS=dev-key
+export GTFS_STATIC_AUTH_NAME = X-Api-Key
+GTFS_STATIC_AUTH_VALUE="secret # not a comment\n"
+GTFS_REALTIME_AUTH_VALUE='single $quoted'
+EMPTY=
+TRAILING=value # comment
+`))
+	require.NoError(t, err)
+	assert.Equal(t, [][2]string{
+		{"GTFS_API_KEYS", "dev-key"},
+		{"GTFS_STATIC_AUTH_NAME", "X-Api-Key"},
+		{"GTFS_STATIC_AUTH_VALUE", "secret # not a comment\n"},
+		{"GTFS_REALTIME_AUTH_VALUE", "single $quoted"},
+		{"EMPTY", ""},
+		{"TRAILING", "value"},
+	}, vars)
+
+	_, err = parseEnvFile(strings.NewReader("GOOD=1\nnot a variable\n"))
+	assert.EqualError(t, err, "line 2: expected KEY=VALUE")
+
+	_, err = parseEnvFile(strings.NewReader(`BAD="unterminated\"`))
+	assert.EqualError(t, err, "line 1: invalid quoted value for BAD")
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("MAGLEV_TEST_FROM_FILE=file\nMAGLEV_TEST_ALREADY_SET=file\n"), 0o600))
+	t.Setenv("MAGLEV_TEST_ALREADY_SET", "environment")
+	t.Cleanup(func() { _ = os.Unsetenv("MAGLEV_TEST_FROM_FILE") })
+
+	// The default file is skipped in production
+	require.NoError(t, loadEnvFile(path, false, true))
+	_, set := os.LookupEnv("MAGLEV_TEST_FROM_FILE")
+	assert.False(t, set)
+
+	// Naming it explicitly loads it anyway; the real environment wins
+	require.NoError(t, loadEnvFile(path, true, true))
+	assert.Equal(t, "file", os.Getenv("MAGLEV_TEST_FROM_FILE"))
+	assert.Equal(t, "environment", os.Getenv("MAGLEV_TEST_ALREADY_SET"))
+
+	missing := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, loadEnvFile(missing, false, false), "a missing default file is ignored")
+	assert.Error(t, loadEnvFile(missing, true, false), "a missing explicit file is an error")
+}
+
+func TestConfigFilesEnv(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	prod := filepath.Join(dir, "prod.json")
+	require.NoError(t, os.WriteFile(base, []byte(`{"env": "development", "port": 4000}`), 0o600))
+	require.NoError(t, os.WriteFile(prod, []byte(`{"env": "production"}`), 0o600))
+
+	assert.Equal(t, "development", configFilesEnv([]string{base}))
+	assert.Equal(t, "production", configFilesEnv([]string{base, prod}))
+	assert.Equal(t, "production", configFilesEnv([]string{prod, filepath.Join(dir, "missing.json")}))
+}
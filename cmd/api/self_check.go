@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/gtfs"
+)
+
+// selfCheckTimeout bounds each feed request made by the startup self-check, so an unreachable
+// feed delays startup by seconds rather than minutes.
+const selfCheckTimeout = 5 * time.Second
+
+// requiredTables are the GTFS tables every endpoint depends on.
+var requiredTables = []string{"agencies", "routes", "stops", "calendar", "trips", "stop_times", "shapes"}
+
+// runSelfCheck verifies the dependencies of a built application before it serves traffic: the
+// database and its tables, the static and realtime feeds, and the agency time zones. Results
+// are stored on coreApp for /readyz and logged as one structured record.
+func runSelfCheck(ctx context.Context, coreApp *app.Application, gtfsCfg gtfs.Config, client *http.Client, logger *slog.Logger) {
+	var db *sql.DB
+	if coreApp.GtfsManager != nil && coreApp.GtfsManager.GtfsDB != nil {
+		db = coreApp.GtfsManager.GtfsDB.DB
+	}
+
+	var checks []app.StartupCheck
+	add := func(name string, err error, failure string) {
+		check := app.StartupCheck{Name: name, Status: app.StartupCheckOK}
+		if err != nil {
+			check.Status = failure
+			check.Detail = err.Error()
+		}
+		checks = append(checks, check)
+	}
+
+	add("database", checkDatabase(ctx, db), app.StartupCheckFailed)
+	for i, staticFeed := range gtfsCfg.StaticFeeds() {
+		if isRemoteURL(staticFeed.URL) {
+			add("static-feed"+checkSuffix(i), probeURL(client, staticFeed.URL, staticFeed.AuthHeaderKey, staticFeed.AuthHeaderValue), app.StartupCheckWarn)
+		} else {
+			add("static-feed"+checkSuffix(i), probeLocalFile(staticFeed.URL), app.StartupCheckWarn)
+		}
+	}
+	for i, rtFeed := range gtfsCfg.RealtimeFeeds() {
+		suffix := checkSuffix(i)
+		for _, feed := range []struct{ name, url string }{
+			{"trip-updates-feed", rtFeed.TripUpdatesURL},
+			{"vehicle-positions-feed", rtFeed.VehiclePositionsURL},
+			{"service-alerts-feed", rtFeed.ServiceAlertsURL},
+		} {
+			if feed.url != "" {
+				add(feed.name+suffix, probeURL(client, feed.url, rtFeed.AuthHeaderKey, rtFeed.AuthHeaderValue), app.StartupCheckWarn)
+			}
+		}
+	}
+	add("timezones", checkTimezones(ctx, db), app.StartupCheckFailed)
+
+	coreApp.StartupChecks = checks
+
+	level := slog.LevelInfo
+	result := "ok"
+	attrs := make([]any, 0, len(checks))
+	for _, check := range checks {
+		value := check.Status
+		if check.Detail != "" {
+			value += ": " + check.Detail
+		}
+		attrs = append(attrs, slog.String(check.Name, value))
+		switch {
+		case check.Status == app.StartupCheckFailed:
+			level, result = slog.LevelError, "failed"
+		case check.Status == app.StartupCheckWarn && result == "ok":
+			level, result = slog.LevelWarn, "degraded"
+		}
+	}
+	logger.Log(ctx, level, "startup self-check", "result", result, slog.Group("checks", attrs...))
+}
+
+// checkDatabase checks that the GTFS database answers and has every required table.
+func checkDatabase(ctx context.Context, db *sql.DB) error {
+	if db == nil {
+		return fmt.Errorf("not initialized")
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, table := range requiredTables {
+		var name string
+		err := db.QueryRowContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&name)
+		if err == sql.ErrNoRows {
+			missing = append(missing, table)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing tables: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkTimezones checks that the time zone of every agency loads. Without time zone data the
+// handlers fall back to UTC and report wrong arrival times.
+func checkTimezones(ctx context.Context, db *sql.DB) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	rows, err := db.QueryContext(ctx, "SELECT DISTINCT timezone FROM agencies")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var failed []string
+	for rows.Next() {
+		var timezone string
+		if err := rows.Scan(&timezone); err != nil {
+			return err
+		}
+		if _, err := time.LoadLocation(timezone); err != nil {
+			failed = append(failed, timezone)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("cannot load %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// checkSuffix numbers the checks of the feeds after the first of a list.
+func checkSuffix(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return "-" + strconv.Itoa(i+1)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/gtfs"
+)
+
+func TestRunSelfCheck(t *testing.T) {
+	testDataPath := filepath.Join("..", "..", "testdata", "raba.zip")
+	if _, err := os.Stat(testDataPath); os.IsNotExist(err) {
+		t.Skip("Test data not available, skipping test")
+	}
+
+	gtfsCfg := gtfs.Config{GTFSDataPath: ":memory:", GtfsURL: testDataPath}
+	coreApp, err := BuildApplication(appconf.Config{Port: 4000, Env: appconf.Test, ApiKeys: []string{"test"}, RateLimit: 100}, gtfsCfg)
+	require.NoError(t, err)
+	t.Cleanup(coreApp.GtfsManager.Shutdown)
+
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/alerts.pb" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer feed.Close()
+	gtfsCfg.TripUpdatesURL = feed.URL + "/trip-updates.pb"
+	gtfsCfg.ServiceAlertsURL = feed.URL + "/alerts.pb"
+
+	var logs bytes.Buffer
+	runSelfCheck(context.Background(), coreApp, gtfsCfg, feed.Client(), slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	assert.Equal(t, []app.StartupCheck{
+		{Name: "database", Status: app.StartupCheckOK},
+		{Name: "static-feed", Status: app.StartupCheckOK},
+		{Name: "trip-updates-feed", Status: app.StartupCheckOK},
+		{Name: "service-alerts-feed", Status: app.StartupCheckWarn, Detail: "responded with status 503"},
+		{Name: "timezones", Status: app.StartupCheckOK},
+	}, coreApp.StartupChecks)
+	assert.True(t, coreApp.StartupChecksPassed(), "an unreachable feed is only a warning")
+
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("\n")), "results are logged as one record")
+	assert.Contains(t, logs.String(), `"result":"degraded"`)
+	assert.Contains(t, logs.String(), `"service-alerts-feed":"warn: responded with status 503"`)
+}
+
+func TestCheckDatabase(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	_, err = db.Exec("CREATE TABLE agencies (id TEXT, timezone TEXT)")
+	require.NoError(t, err)
+	assert.EqualError(t, checkDatabase(context.Background(), db), "missing tables: routes, stops, calendar, trips, stop_times, shapes")
+	assert.EqualError(t, checkDatabase(context.Background(), nil), "not initialized")
+
+	_, err = db.Exec("INSERT INTO agencies VALUES ('1', 'America/Los_Angeles'), ('2', 'Mars/Olympus_Mons')")
+	require.NoError(t, err)
+	assert.EqualError(t, checkTimezones(context.Background(), db), "cannot load Mars/Olympus_Mons")
+}
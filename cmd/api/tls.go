@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// ConfigureTLS sets srv.TLSConfig from cfg so that Run serves HTTPS. If cfg.RedirectPort is set,
+// it also returns a plain HTTP server that redirects to HTTPS and, when certificates are
+// obtained via ACME, answers HTTP-01 challenges. Returns a nil server otherwise.
+// Does nothing if TLS is not configured.
+func ConfigureTLS(srv *http.Server, cfg appconf.TLSConfig, logger *slog.Logger) (*http.Server, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	var redirect http.Handler = httpsRedirectHandler(srv.Addr)
+
+	if cfg.Autocert() {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		// The manager's config also answers TLS-ALPN-01 challenges on the HTTPS port itself
+		srv.TLSConfig = manager.TLSConfig()
+		redirect = manager.HTTPHandler(redirect)
+		logger.Info("using ACME certificates", "domains", cfg.AutocertDomains, "cache_dir", cfg.AutocertCacheDir)
+	} else {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		srv.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	if cfg.RedirectPort == 0 {
+		return nil, nil
+	}
+
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.RedirectPort),
+		Handler:      redirect,
+		IdleTimeout:  time.Minute,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}, nil
+}
+
+// httpsRedirectHandler permanently redirects requests to the same host on the HTTPS listener.
+func httpsRedirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// listenAndServe serves HTTPS when srv has a TLS config and plain HTTP otherwise. If unixSocket
+// is set, srv listens on that Unix domain socket instead of srv.Addr.
+func listenAndServe(srv *http.Server, unixSocket string) error {
+	if unixSocket == "" {
+		if srv.TLSConfig != nil {
+			// Certificates come from srv.TLSConfig
+			return srv.ListenAndServeTLS("", "")
+		}
+		return srv.ListenAndServe()
+	}
+
+	listener, err := listenUnix(unixSocket)
+	if err != nil {
+		return err
+	}
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(listener, "", "")
+	}
+	return srv.Serve(listener)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// writeSelfSignedCert writes a throwaway certificate and key for localhost into dir.
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestConfigureTLS(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := &http.Server{Addr: ":4000"}
+		redirectSrv, err := ConfigureTLS(srv, appconf.TLSConfig{}, logger)
+		require.NoError(t, err)
+		assert.Nil(t, redirectSrv)
+		assert.Nil(t, srv.TLSConfig)
+	})
+
+	t.Run("certificate files", func(t *testing.T) {
+		certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+		srv := &http.Server{Addr: ":8443"}
+		redirectSrv, err := ConfigureTLS(srv, appconf.TLSConfig{CertFile: certFile, KeyFile: keyFile, RedirectPort: 8080}, logger)
+		require.NoError(t, err)
+		require.NotNil(t, srv.TLSConfig)
+		assert.Len(t, srv.TLSConfig.Certificates, 1)
+		require.NotNil(t, redirectSrv)
+		assert.Equal(t, ":8080", redirectSrv.Addr)
+	})
+
+	t.Run("missing certificate", func(t *testing.T) {
+		srv := &http.Server{Addr: ":8443"}
+		_, err := ConfigureTLS(srv, appconf.TLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"}, logger)
+		assert.Error(t, err)
+	})
+
+	t.Run("autocert", func(t *testing.T) {
+		srv := &http.Server{Addr: ":443"}
+		redirectSrv, err := ConfigureTLS(srv, appconf.TLSConfig{
+			AutocertDomains:  []string{"api.example.com"},
+			AutocertCacheDir: t.TempDir(),
+		}, logger)
+		require.NoError(t, err)
+		assert.Nil(t, redirectSrv)
+		require.NotNil(t, srv.TLSConfig)
+		assert.NotNil(t, srv.TLSConfig.GetCertificate)
+		assert.Contains(t, srv.TLSConfig.NextProtos, "acme-tls/1")
+	})
+}
+
+func TestHTTPSRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsAddr string
+		host      string
+		expected  string
+	}{
+		{"default port", ":443", "example.com", "https://example.com/api/where/stop/1.json?key=test"},
+		{"custom port", ":8443", "example.com:8080", "https://example.com:8443/api/where/stop/1.json?key=test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://"+tt.host+"/api/where/stop/1.json?key=test", nil)
+			rec := httptest.NewRecorder()
+			httpsRedirectHandler(tt.httpsAddr).ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+			assert.Equal(t, tt.expected, rec.Header().Get("Location"))
+		})
+	}
+}
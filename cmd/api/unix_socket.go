@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// listenUnix listens on the Unix domain socket at path. A socket file left behind by a previous
+// run is replaced, but one another process is still accepting on is not. The socket file is
+// removed when the listener is closed.
+func listenUnix(path string) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil:
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket path %s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("unix socket %s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale unix socket: %w", err)
+		}
+	case !errors.Is(err, syscall.ENOENT):
+		return nil, fmt.Errorf("failed to check unix socket path: %w", err)
+	}
+
+	return net.Listen("unix", path)
+}
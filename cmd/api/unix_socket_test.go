@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// shortTempDir returns a temporary directory whose paths fit in a Unix socket address,
+// which t.TempDir can exceed on some systems.
+func shortTempDir(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "maglev")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func TestListenUnix(t *testing.T) {
+	dir := shortTempDir(t)
+
+	t.Run("replaces stale socket", func(t *testing.T) {
+		path := filepath.Join(dir, "stale.sock")
+		stale, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		// Leave the socket file behind, as a crashed process would
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		listener, err := listenUnix(path)
+		require.NoError(t, err)
+		require.NoError(t, listener.Close())
+
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err), "socket file should be removed on close")
+	})
+
+	t.Run("socket in use", func(t *testing.T) {
+		path := filepath.Join(dir, "busy.sock")
+		listener, err := listenUnix(path)
+		require.NoError(t, err)
+		defer func() { _ = listener.Close() }()
+
+		_, err = listenUnix(path)
+		assert.ErrorContains(t, err, "already in use")
+	})
+
+	t.Run("regular file", func(t *testing.T) {
+		path := filepath.Join(dir, "file.sock")
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+		_, err := listenUnix(path)
+		assert.ErrorContains(t, err, "is not a socket")
+	})
+}
+
+func TestRunOnUnixSocket(t *testing.T) {
+	path := filepath.Join(shortTempDir(t), "maglev.sock")
+	srv := &http.Server{
+		Addr: "127.0.0.1:0",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		}),
+	}
+	coreApp := &app.Application{
+		Config: appconf.Config{UnixSocket: path, Shutdown: appconf.ShutdownConfig{Timeout: 1}},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, srv, nil, coreApp, nil, logger)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("http://maglev/")
+		if err != nil {
+			return false
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return string(body) == "ok"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "socket file should be removed on shutdown")
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/registry"
+)
+
+// probeTimeout bounds each feed request made by --validate-config.
+const probeTimeout = 15 * time.Second
+
+// validateConfig loads and validates the configuration files the way the server would,
+// applying environment overrides, and writes a report to out. With probe set it also
+// requests each feed URL and checks that the data path is usable. It reports whether the
+// configuration is ready to deploy.
+func validateConfig(out io.Writer, files []string, probe bool, client *http.Client) bool {
+	fmt.Fprintf(out, "Configuration: %s\n", strings.Join(files, " + "))
+
+	jsonConfig, err := appconf.LoadFromFiles(files...)
+	if err != nil {
+		fmt.Fprintf(out, "FAIL  %v\n", err)
+		return false
+	}
+	cfg := jsonConfig.ToAppConfig()
+	feeds := gtfsConfigFromJSON(jsonConfig)
+
+	fmt.Fprintf(out, "OK    configuration is valid\n")
+	fmt.Fprintf(out, "      env=%s port=%d api-keys=%d admin-api-keys=%d rate-limit=%d\n",
+		jsonConfig.Env, cfg.Port, len(cfg.ApiKeys), len(cfg.AdminApiKeys), cfg.RateLimit)
+	if !probe {
+		return true
+	}
+
+	ok := true
+	report := func(name string, err error) {
+		if err != nil {
+			ok = false
+			fmt.Fprintf(out, "FAIL  %s: %v\n", name, err)
+			return
+		}
+		fmt.Fprintf(out, "OK    %s\n", name)
+	}
+
+	if jsonConfig.FeedRegistry.Enabled() {
+		resolver, err := registry.NewResolver(jsonConfig.FeedRegistry, client, nil)
+		var resolved registry.Feeds
+		if err == nil {
+			resolved, err = resolver.Resolve(context.Background())
+		}
+		report("feed registry "+jsonConfig.FeedRegistry.Provider+" "+jsonConfig.FeedRegistry.StaticFeedID, err)
+		if err == nil {
+			feeds.GtfsURL = resolved.StaticURL
+			if resolved.HasRealtime() {
+				feeds.TripUpdatesURL, feeds.VehiclePositionsURL, feeds.ServiceAlertsURL =
+					resolved.TripUpdatesURL, resolved.VehiclePositionsURL, resolved.ServiceAlertsURL
+			}
+		}
+	}
+
+	for _, staticFeed := range feeds.StaticFeeds() {
+		switch {
+		case staticFeed.URL == "":
+			// A registry that could not be reached, with no fallback URL; already reported
+		case isRemoteURL(staticFeed.URL):
+			report("static feed "+redactURL(staticFeed.URL), probeURL(client, staticFeed.URL, staticFeed.AuthHeaderKey, staticFeed.AuthHeaderValue))
+		default:
+			degradations, err := probeLocalFeed(staticFeed.URL)
+			report("static feed "+staticFeed.URL, err)
+			for _, degradation := range degradations {
+				fmt.Fprintf(out, "WARN  %s: %s\n", degradation.File, degradation.Detail)
+			}
+		}
+	}
+	for _, rtFeed := range feeds.RealtimeFeeds() {
+		for _, feedURL := range []string{rtFeed.TripUpdatesURL, rtFeed.VehiclePositionsURL, rtFeed.ServiceAlertsURL} {
+			if feedURL == "" {
+				continue
+			}
+			report("realtime feed "+redactURL(feedURL), probeURL(client, feedURL, rtFeed.AuthHeaderKey, rtFeed.AuthHeaderValue))
+		}
+	}
+	report("data path "+feeds.GTFSDataPath, probeDataPath(feeds.GTFSDataPath))
+
+	return ok
+}
+
+func isRemoteURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// redactURL drops the query string and credentials, which often carry API keys, from a feed
+// URL before it is printed.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "(unparseable URL)"
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// probeURL requests a feed with its auth header and expects a 2xx response. Only the status
+// is read; the body is discarded unread.
+func probeURL(client *http.Client, feedURL, authHeaderName, authHeaderValue string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return err
+	}
+	if authHeaderName != "" {
+		req.Header.Set(authHeaderName, authHeaderValue)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error text repeats the URL; keep the query string out of the report
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// probeLocalFeed parses a local static feed as an import would and returns what it lacks.
+func probeLocalFeed(path string) ([]gtfsdb.FeedDegradation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	_, degradations, err := gtfsdb.ParseStaticFeed(b)
+	return degradations, err
+}
+
+func probeLocalFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// probeDataPath checks that the GTFS database can be opened, or created if it doesn't exist yet.
+func probeDataPath(path string) error {
+	if path == ":memory:" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err == nil {
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("not a regular file")
+		}
+		file, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	// The database will be created: the directory must exist and be writable
+	probe, err := os.CreateTemp(filepath.Dir(path), ".maglev-validate-*")
+	if err != nil {
+		return fmt.Errorf("cannot create the database: %w", err)
+	}
+	_ = probe.Close()
+	return os.Remove(probe.Name())
+}
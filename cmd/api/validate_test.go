@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeValidateConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestValidateConfig(t *testing.T) {
+	feeds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer feeds.Close()
+
+	dir := t.TempDir()
+	staticZip, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err)
+
+	t.Run("valid without probing", func(t *testing.T) {
+		path := writeValidateConfig(t, dir, `{"port": 8080, "env": "production", "api-keys": ["k1", "k2"],
+			"gtfs-static-feed": {"url": "https://example.com/gtfs.zip"},
+			"gtfs-rt-feeds": [{"vehicle-positions-url": "https://example.com/vehicle-positions.pb"}]}`)
+		var out bytes.Buffer
+		assert.True(t, validateConfig(&out, []string{path}, false, feeds.Client()))
+		assert.Contains(t, out.String(), "OK    configuration is valid")
+		assert.Contains(t, out.String(), "env=production port=8080 api-keys=2")
+		assert.NotContains(t, out.String(), "FAIL")
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		path := writeValidateConfig(t, dir, `{"port": 70000}`)
+		var out bytes.Buffer
+		assert.False(t, validateConfig(&out, []string{path}, false, feeds.Client()))
+		assert.Contains(t, out.String(), "FAIL  invalid configuration")
+	})
+
+	t.Run("probes feeds and data path", func(t *testing.T) {
+		path := writeValidateConfig(t, dir, `{
+			"gtfs-static-feed": {"url": "`+staticZip+`"},
+			"gtfs-rt-feeds": [{
+				"vehicle-positions-url": "`+feeds.URL+`/vehicles.pb?key=hidden",
+				"realtime-auth-header-name": "X-Api-Key",
+				"realtime-auth-header-value": "secret"
+			}],
+			"data-path": "`+filepath.Join(dir, "gtfs.db")+`"
+		}`)
+		var out bytes.Buffer
+		assert.True(t, validateConfig(&out, []string{path}, true, feeds.Client()), out.String())
+		assert.Contains(t, out.String(), "OK    realtime feed "+feeds.URL+"/vehicles.pb\n")
+		assert.NotContains(t, out.String(), "hidden", "query strings may hold keys")
+		assert.NoFileExists(t, filepath.Join(dir, "gtfs.db"), "probing must not create the database")
+	})
+
+	t.Run("reports what a local feed lacks", func(t *testing.T) {
+		partialZip := filepath.Join(dir, "partial.zip")
+		require.NoError(t, os.WriteFile(partialZip, withoutFeedFiles(t, staticZip, "shapes.txt"), 0o600))
+		path := writeValidateConfig(t, dir, `{
+			"gtfs-static-feed": {"url": "`+partialZip+`"},
+			"gtfs-rt-feeds": [{
+				"vehicle-positions-url": "`+feeds.URL+`/vehicles.pb",
+				"realtime-auth-header-name": "X-Api-Key",
+				"realtime-auth-header-value": "secret"
+			}],
+			"data-path": "`+filepath.Join(dir, "gtfs.db")+`"
+		}`)
+		var out bytes.Buffer
+		assert.True(t, validateConfig(&out, []string{path}, true, feeds.Client()), out.String())
+		assert.Contains(t, out.String(), "OK    static feed "+partialZip+"\n")
+		assert.Contains(t, out.String(), "WARN  shapes.txt: trips and routes have no shapes")
+	})
+
+	t.Run("probe failures", func(t *testing.T) {
+		path := writeValidateConfig(t, dir, `{
+			"gtfs-static-feed": {"url": "`+filepath.Join(dir, "missing.zip")+`"},
+			"gtfs-rt-feeds": [{"trip-updates-url": "`+feeds.URL+`/trips.pb"}],
+			"data-path": "`+filepath.Join(dir, "no-such-dir", "gtfs.db")+`"
+		}`)
+		var out bytes.Buffer
+		assert.False(t, validateConfig(&out, []string{path}, true, feeds.Client()))
+		assert.Contains(t, out.String(), "FAIL  static feed")
+		assert.Contains(t, out.String(), "responded with status 401")
+		assert.Contains(t, out.String(), "cannot create the database")
+	})
+}
+
+// withoutFeedFiles returns the feed at path with the named files left out.
+func withoutFeedFiles(t *testing.T, path string, names ...string) []byte {
+	t.Helper()
+	src, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer func() { _ = src.Close() }()
+
+	var buf bytes.Buffer
+	out := zip.NewWriter(&buf)
+	for _, f := range src.File {
+		if !slices.Contains(names, f.Name) {
+			require.NoError(t, out.Copy(f))
+		}
+	}
+	require.NoError(t, out.Close())
+	return buf.Bytes()
+}
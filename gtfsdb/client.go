@@ -30,7 +30,7 @@ func NewClient(config Config) (*Client, error) {
 		log.Println("Successfully created tables")
 	}
 
-	queries := New(db)
+	queries := New(tracedDB{db})
 
 	client := &Client{
 		config:  config,
@@ -49,7 +49,10 @@ func (c *Client) GetDBPath() string {
 }
 
 // DownloadAndStore downloads GTFS data from the given URL and stores it in the database
-func (c *Client) DownloadAndStore(ctx context.Context, url, authHeaderKey, authHeaderValue string) error {
+func (c *Client) DownloadAndStore(ctx context.Context, url, authHeaderKey, authHeaderValue string) (err error) {
+	ctx, span := tracer.Start(ctx, "gtfsdb.download_and_store")
+	defer func() { endQuerySpan(span, err) }()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return err
@@ -99,3 +102,9 @@ func (c *Client) ImportFromFile(ctx context.Context, path string) error {
 
 	return err
 }
+
+// ImportFromData imports a GTFS zip file already read into memory, such as one merged from
+// several feeds. source names where the data came from.
+func (c *Client) ImportFromData(ctx context.Context, data []byte, source string) error {
+	return c.processAndStoreGTFSDataWithSource(data, source)
+}
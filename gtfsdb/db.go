@@ -231,6 +231,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getStopTimesForTripIDsStmt, err = db.PrepareContext(ctx, getStopTimesForTripIDs); err != nil {
 		return nil, fmt.Errorf("error preparing query GetStopTimesForTripIDs: %w", err)
 	}
+	if q.getStopsByCodeForAgencyStmt, err = db.PrepareContext(ctx, getStopsByCodeForAgency); err != nil {
+		return nil, fmt.Errorf("error preparing query GetStopsByCodeForAgency: %w", err)
+	}
 	if q.getStopsByIDsStmt, err = db.PrepareContext(ctx, getStopsByIDs); err != nil {
 		return nil, fmt.Errorf("error preparing query GetStopsByIDs: %w", err)
 	}
@@ -644,6 +647,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getStopTimesForTripIDsStmt: %w", cerr)
 		}
 	}
+	if q.getStopsByCodeForAgencyStmt != nil {
+		if cerr := q.getStopsByCodeForAgencyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getStopsByCodeForAgencyStmt: %w", cerr)
+		}
+	}
 	if q.getStopsByIDsStmt != nil {
 		if cerr := q.getStopsByIDsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getStopsByIDsStmt: %w", cerr)
@@ -857,6 +865,7 @@ type Queries struct {
 	getStopTimesForStopInWindowStmt           *sql.Stmt
 	getStopTimesForTripStmt                   *sql.Stmt
 	getStopTimesForTripIDsStmt                *sql.Stmt
+	getStopsByCodeForAgencyStmt               *sql.Stmt
 	getStopsByIDsStmt                         *sql.Stmt
 	getStopsForRouteStmt                      *sql.Stmt
 	getStopsWithActiveServiceOnDateStmt       *sql.Stmt
@@ -953,6 +962,7 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		getStopTimesForStopInWindowStmt:           q.getStopTimesForStopInWindowStmt,
 		getStopTimesForTripStmt:                   q.getStopTimesForTripStmt,
 		getStopTimesForTripIDsStmt:                q.getStopTimesForTripIDsStmt,
+		getStopsByCodeForAgencyStmt:               q.getStopsByCodeForAgencyStmt,
 		getStopsByIDsStmt:                         q.getStopsByIDsStmt,
 		getStopsForRouteStmt:                      q.getStopsForRouteStmt,
 		getStopsWithActiveServiceOnDateStmt:       q.getStopsWithActiveServiceOnDateStmt,
@@ -0,0 +1,238 @@
+package gtfsdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// FeedDegradation is a feature served only in part because the static feed lacks an optional
+// file, or has rows that had to be left out of the import.
+type FeedDegradation struct {
+	File   string `json:"file"`
+	Detail string `json:"detail"`
+}
+
+// optionalFeedFiles are the optional files whose absence changes what the server can answer,
+// with what is lost without each.
+var optionalFeedFiles = []FeedDegradation{
+	{File: "shapes.txt", Detail: "trips and routes have no shapes: polylines are empty and stop directions are unknown"},
+	{File: "transfers.txt", Detail: "no transfer rules are known"},
+}
+
+// ParseStaticFeed parses a static GTFS feed, importing as much of it as it can. A feed may lack
+// the optional files, and calendar.txt when calendar_dates.txt defines its services; trips that
+// name an undefined service or route are left out, with their stop times. What the feed lacks
+// is returned as degradations. Only a feed that is not a zip, lacks a required file or cannot be
+// parsed at all is an error.
+func ParseStaticFeed(b []byte) (staticData *gtfs.Static, degradations []FeedDegradation, err error) {
+	archive, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, nil, err
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	for _, optional := range optionalFeedFiles {
+		if files[optional.File] == nil {
+			degradations = append(degradations, optional)
+		}
+	}
+	switch {
+	case files["calendar.txt"] == nil && files["calendar_dates.txt"] == nil:
+		degradations = append(degradations, FeedDegradation{File: "calendar.txt", Detail: "neither calendar.txt nor calendar_dates.txt defines a service, so no trip runs"})
+	case files["calendar.txt"] == nil:
+		degradations = append(degradations, FeedDegradation{File: "calendar.txt", Detail: "services run only on the dates listed in calendar_dates.txt"})
+	}
+
+	dropped, err := undefinedTripReferences(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(dropped) > 0 {
+		degradations = append(degradations, FeedDegradation{
+			File:   "trips.txt",
+			Detail: fmt.Sprintf("%d trips name a service or route the feed does not define and were left out", len(dropped)),
+		})
+		// The parser cannot follow stop times of trips it left out, so they go first
+		if b, err = withoutStopTimes(archive, dropped); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			staticData, degradations, err = nil, nil, fmt.Errorf("malformed GTFS feed: %v", r)
+		}
+	}()
+	staticData, err = gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return staticData, degradations, nil
+}
+
+// undefinedTripReferences returns the IDs of the trips whose service or route the feed does
+// not define.
+func undefinedTripReferences(files map[string]*zip.File) (map[string]bool, error) {
+	services := make(map[string]bool)
+	for _, name := range []string{"calendar.txt", "calendar_dates.txt"} {
+		err := readFeedColumns(files[name], []string{"service_id"}, func(values []string) {
+			services[values[0]] = true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+	}
+	routes := make(map[string]bool)
+	err := readFeedColumns(files["routes.txt"], []string{"route_id"}, func(values []string) {
+		routes[values[0]] = true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes.txt: %w", err)
+	}
+
+	dropped := make(map[string]bool)
+	err = readFeedColumns(files["trips.txt"], []string{"trip_id", "service_id", "route_id"}, func(values []string) {
+		if !services[values[1]] || !routes[values[2]] {
+			dropped[values[0]] = true
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trips.txt: %w", err)
+	}
+	return dropped, nil
+}
+
+// withoutStopTimes rewrites the feed without the stop times of the given trips.
+func withoutStopTimes(archive *zip.Reader, trips map[string]bool) ([]byte, error) {
+	var buf bytes.Buffer
+	out := zip.NewWriter(&buf)
+	for _, f := range archive.File {
+		if f.Name != "stop_times.txt" {
+			if err := out.Copy(f); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		w, err := out.Create(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := filterStopTimes(f, w, trips); err != nil {
+			return nil, fmt.Errorf("failed to read stop_times.txt: %w", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func filterStopTimes(f *zip.File, w io.Writer, trips map[string]bool) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	reader := newFeedReader(rc)
+	writer := csv.NewWriter(w)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	tripColumn := columnIndex(header, "trip_id")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if tripColumn >= 0 && tripColumn < len(record) && trips[strings.TrimSpace(record[tripColumn])] {
+			continue
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// readFeedColumns calls fn with the values of the named columns for each row of a feed file.
+// A missing file or column reads as empty.
+func readFeedColumns(f *zip.File, columns []string, fn func(values []string)) error {
+	if f == nil {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	reader := newFeedReader(rc)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	indices := make([]int, len(columns))
+	for i, column := range columns {
+		indices[i] = columnIndex(header, column)
+	}
+
+	values := make([]string, len(columns))
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for i, index := range indices {
+			values[i] = ""
+			if index >= 0 && index < len(record) {
+				values[i] = strings.TrimSpace(record[index])
+			}
+		}
+		fn(values)
+	}
+}
+
+func newFeedReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+	return reader
+}
+
+func columnIndex(header []string, column string) int {
+	for i, name := range header {
+		if strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")) == column {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,103 @@
+package gtfsdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func buildFeed(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zipWriter.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	return buf.Bytes()
+}
+
+func partialFeedFiles() map[string]string {
+	return map[string]string{
+		"agency.txt": "agency_id,agency_name,agency_url,agency_timezone\nA,Test Transit,https://test.com,America/Los_Angeles\n",
+		"routes.txt": "route_id,agency_id,route_short_name,route_type\nR1,A,1,3\n",
+		"stops.txt":  "stop_id,stop_name,stop_lat,stop_lon\nS1,First,40.71,-74.00\nS2,Second,40.75,-73.98\n",
+		"calendar_dates.txt": "service_id,date,exception_type\n" +
+			"DATES,20250612,1\n",
+		"trips.txt": "route_id,service_id,trip_id\n" +
+			"R1,DATES,T1\n" +
+			"R1,WEEKDAY,T2\n" +
+			"R9,DATES,T3\n" +
+			"R1,DATES,T4\n",
+		// Stop times of a dropped trip between those of kept trips used to crash the parser
+		"stop_times.txt": "\ufefftrip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+			"T1,08:00:00,08:00:00,S1,1\n" +
+			"T1,08:10:00,08:10:00,S2,2\n" +
+			"T2,09:00:00,09:00:00,S1,1\n" +
+			"T2,09:10:00,09:10:00,S2,2\n" +
+			"T3,09:30:00,09:30:00,S1,1\n" +
+			"T4,10:00:00,10:00:00,S2,1\n" +
+			"T4,10:10:00,10:10:00,S1,2\n",
+	}
+}
+
+func TestParseStaticFeed(t *testing.T) {
+	staticData, degradations, err := ParseStaticFeed(buildFeed(t, partialFeedFiles()))
+	require.NoError(t, err)
+
+	var tripIDs []string
+	for _, trip := range staticData.Trips {
+		tripIDs = append(tripIDs, trip.ID)
+		assert.Len(t, trip.StopTimes, 2, trip.ID)
+	}
+	assert.ElementsMatch(t, []string{"T1", "T4"}, tripIDs)
+
+	files := make(map[string]string)
+	for _, degradation := range degradations {
+		files[degradation.File] = degradation.Detail
+	}
+	assert.Contains(t, files, "shapes.txt")
+	assert.Contains(t, files, "transfers.txt")
+	assert.Equal(t, "services run only on the dates listed in calendar_dates.txt", files["calendar.txt"])
+	assert.Equal(t, "2 trips name a service or route the feed does not define and were left out", files["trips.txt"])
+
+	// A complete feed has nothing to report
+	_, degradations, err = ParseStaticFeed(buildFeed(t, map[string]string{
+		"agency.txt":     partialFeedFiles()["agency.txt"],
+		"routes.txt":     partialFeedFiles()["routes.txt"],
+		"stops.txt":      partialFeedFiles()["stops.txt"],
+		"calendar.txt":   "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\nDATES,1,1,1,1,1,0,0,20250101,20251231\n",
+		"shapes.txt":     "shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence\nSH,40.71,-74.00,1\nSH,40.75,-73.98,2\n",
+		"transfers.txt":  "from_stop_id,to_stop_id,transfer_type\nS1,S2,0\n",
+		"trips.txt":      "route_id,service_id,trip_id\nR1,DATES,T1\n",
+		"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\nT1,08:00:00,08:00:00,S1,1\n",
+	}))
+	require.NoError(t, err)
+	assert.Empty(t, degradations)
+
+	// Required files are still required
+	withoutStopTimes := partialFeedFiles()
+	delete(withoutStopTimes, "stop_times.txt")
+	_, _, err = ParseStaticFeed(buildFeed(t, withoutStopTimes))
+	assert.ErrorContains(t, err, "stop_times.txt")
+}
+
+func TestImportPartialFeed(t *testing.T) {
+	client, err := NewClient(Config{DBPath: ":memory:", Env: appconf.Test})
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	require.NoError(t, client.processAndStoreGTFSDataWithSource(buildFeed(t, partialFeedFiles()), "partial"))
+
+	trips, err := client.Queries.ListTrips(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, trips, 2)
+}
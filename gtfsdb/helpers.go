@@ -67,6 +67,10 @@ func performDatabaseMigration(ctx context.Context, db *sql.DB) error {
 	return nil
 }
 
+// importVersion changes whenever the import derives different data from the same feed, such as
+// inherited wheelchair boarding or fallback headsigns.
+const importVersion = "2"
+
 func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) error {
 	logger := slog.Default().With(slog.String("component", "gtfs_importer"))
 
@@ -81,9 +85,12 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 			slog.String("source", source))
 	}()
 
-	// Calculate hash of the GTFS data
-	hash := sha256.Sum256(b)
-	hashStr := hex.EncodeToString(hash[:])
+	// Calculate hash of the GTFS data and the import version, so that feeds imported before a
+	// change to what the import derives from them are imported again
+	hasher := sha256.New()
+	hasher.Write([]byte(importVersion))
+	hasher.Write(b)
+	hashStr := hex.EncodeToString(hasher.Sum(nil))
 
 	ctx := context.Background()
 
@@ -112,10 +119,13 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 
 	var staticCounts map[string]int
 
-	staticData, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+	staticData, degradations, err := ParseStaticFeed(b)
 	if err != nil {
 		return err
 	}
+	for _, degradation := range degradations {
+		logger.Warn("gtfs_feed_degraded", slog.String("file", degradation.File), slog.String("detail", degradation.Detail))
+	}
 
 	fmt.Printf("retrieved static data (warnings: %d)\n", len(staticData.Warnings))
 	fmt.Print("========\n\n")
@@ -204,7 +214,7 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 			Url:                toNullString(s.Url),
 			LocationType:       toNullInt64(int64(s.Type)),
 			Timezone:           toNullString(s.Timezone),
-			WheelchairBoarding: toNullInt64(int64(s.WheelchairBoarding)),
+			WheelchairBoarding: toNullInt64(int64(wheelchairBoarding(s))),
 			PlatformCode:       toNullString(s.PlatformCode),
 			Direction:          sql.NullString{}, // Will be computed later
 		}
@@ -257,7 +267,7 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 			ID:                   t.ID,
 			RouteID:              t.Route.Id,
 			ServiceID:            t.Service.Id,
-			TripHeadsign:         toNullString(t.Headsign),
+			TripHeadsign:         toNullString(tripHeadsign(t)),
 			TripShortName:        toNullString(t.ShortName),
 			DirectionID:          toNullInt64(int64(t.DirectionId)),
 			BlockID:              toNullString(t.BlockID),
@@ -434,6 +444,40 @@ func boolToInt(b bool) int64 {
 	return 0
 }
 
+// tripHeadsign returns the trip's headsign or, when the feed leaves it empty, the name of the
+// trip's last stop, or failing that its route's long name, so that arrivals and trips never show
+// a blank destination.
+func tripHeadsign(trip gtfs.ScheduledTrip) string {
+	if trip.Headsign != "" {
+		return trip.Headsign
+	}
+	var last *gtfs.ScheduledStopTime
+	for i := range trip.StopTimes {
+		if last == nil || trip.StopTimes[i].StopSequence > last.StopSequence {
+			last = &trip.StopTimes[i]
+		}
+	}
+	if last != nil && last.Stop != nil && last.Stop.Name != "" {
+		return last.Stop.Name
+	}
+	if trip.Route != nil {
+		return trip.Route.LongName
+	}
+	return ""
+}
+
+// wheelchairBoarding returns the stop's wheelchair_boarding, or for a platform, entrance or other
+// part of a station that leaves it unspecified, the value of the nearest parent that specifies it,
+// as the GTFS reference says such stops inherit it.
+func wheelchairBoarding(stop gtfs.Stop) gtfs.WheelchairBoarding {
+	for s := &stop; s != nil; s = s.Parent {
+		if s.WheelchairBoarding != gtfs.WheelchairBoarding_NotSpecified {
+			return s.WheelchairBoarding
+		}
+	}
+	return gtfs.WheelchairBoarding_NotSpecified
+}
+
 func toNullInt64(i int64) sql.NullInt64 {
 	if i != 0 {
 		return sql.NullInt64{
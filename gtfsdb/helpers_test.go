@@ -0,0 +1,34 @@
+package gtfsdb
+
+import (
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWheelchairBoarding(t *testing.T) {
+	station := &gtfs.Stop{Id: "STN", Type: gtfs.StopType_Station, WheelchairBoarding: gtfs.WheelchairBoarding_Possible}
+	platform := gtfs.Stop{Id: "P1", Parent: station}
+	boardingArea := gtfs.Stop{Id: "BA1", Parent: &platform}
+	inaccessible := gtfs.Stop{Id: "P2", Parent: station, WheelchairBoarding: gtfs.WheelchairBoarding_NotPossible}
+
+	assert.Equal(t, gtfs.WheelchairBoarding_Possible, wheelchairBoarding(platform))
+	assert.Equal(t, gtfs.WheelchairBoarding_Possible, wheelchairBoarding(boardingArea))
+	assert.Equal(t, gtfs.WheelchairBoarding_NotPossible, wheelchairBoarding(inaccessible), "a platform's own value wins")
+	assert.Equal(t, gtfs.WheelchairBoarding_NotSpecified, wheelchairBoarding(gtfs.Stop{Id: "S1"}))
+}
+
+func TestTripHeadsign(t *testing.T) {
+	route := &gtfs.Route{Id: "R1", LongName: "Crosstown"}
+	terminal := &gtfs.Stop{Id: "S2", Name: "Downtown Terminal"}
+	stopTimes := []gtfs.ScheduledStopTime{
+		{Stop: terminal, StopSequence: 9},
+		{Stop: &gtfs.Stop{Id: "S1", Name: "First Street"}, StopSequence: 1},
+	}
+
+	assert.Equal(t, "Airport", tripHeadsign(gtfs.ScheduledTrip{Headsign: "Airport", Route: route, StopTimes: stopTimes}))
+	assert.Equal(t, "Downtown Terminal", tripHeadsign(gtfs.ScheduledTrip{Route: route, StopTimes: stopTimes}), "last stop by sequence")
+	assert.Equal(t, "Crosstown", tripHeadsign(gtfs.ScheduledTrip{Route: route}))
+	assert.Equal(t, "", tripHeadsign(gtfs.ScheduledTrip{}))
+}
@@ -164,7 +164,7 @@ WHERE
 
 -- name: GetRouteIDsForStop :many
 SELECT DISTINCT
-    (routes.agency_id || '_' || routes.id) AS route_id
+    (replace(replace(routes.agency_id, '~', '~~'), '_', '~_') || '_' || routes.id) AS route_id
 FROM
     stop_times
     JOIN trips ON stop_times.trip_id = trips.id
@@ -509,7 +509,7 @@ WHERE
 
 -- name: GetRouteIDsForStops :many
 SELECT DISTINCT
-    routes.agency_id || '_' || routes.id AS route_id,
+    replace(replace(routes.agency_id, '~', '~~'), '_', '~_') || '_' || routes.id AS route_id,
     stop_times.stop_id
 FROM
     stop_times
@@ -663,6 +663,21 @@ WHERE
 ORDER BY
     id;
 
+-- name: GetStopsByCodeForAgency :many
+-- Return the stops with the rider-facing stop code that are served by the agency's routes.
+SELECT DISTINCT
+    stops.*
+FROM
+    stops
+    JOIN stop_times ON stops.id = stop_times.stop_id
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
+WHERE
+    stops.code = ?
+    AND routes.agency_id = ?
+ORDER BY
+    stops.id;
+
 -- name: GetRoutesByIDs :many
 SELECT
     *
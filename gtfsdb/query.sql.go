@@ -1724,7 +1724,7 @@ func (q *Queries) GetRouteIDsForAgency(ctx context.Context, id string) ([]string
 
 const getRouteIDsForStop = `-- name: GetRouteIDsForStop :many
 SELECT DISTINCT
-    (routes.agency_id || '_' || routes.id) AS route_id
+    (replace(replace(routes.agency_id, '~', '~~'), '_', '~_') || '_' || routes.id) AS route_id
 FROM
     stop_times
     JOIN trips ON stop_times.trip_id = trips.id
@@ -1758,7 +1758,7 @@ func (q *Queries) GetRouteIDsForStop(ctx context.Context, stopID string) ([]inte
 
 const getRouteIDsForStops = `-- name: GetRouteIDsForStops :many
 SELECT DISTINCT
-    routes.agency_id || '_' || routes.id AS route_id,
+    replace(replace(routes.agency_id, '~', '~~'), '_', '~_') || '_' || routes.id AS route_id,
     stop_times.stop_id
 FROM
     stop_times
@@ -2962,6 +2962,65 @@ func (q *Queries) GetStopTimesForTripIDs(ctx context.Context, tripIds []string)
 	return items, nil
 }
 
+const getStopsByCodeForAgency = `-- name: GetStopsByCodeForAgency :many
+SELECT DISTINCT
+    stops.id, stops.code, stops.name, stops."desc", stops.lat, stops.lon, stops.zone_id, stops.url, stops.location_type, stops.timezone, stops.wheelchair_boarding, stops.platform_code, stops.direction, stops.parent_station
+FROM
+    stops
+    JOIN stop_times ON stops.id = stop_times.stop_id
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
+WHERE
+    stops.code = ?
+    AND routes.agency_id = ?
+ORDER BY
+    stops.id
+`
+
+type GetStopsByCodeForAgencyParams struct {
+	Code     sql.NullString
+	AgencyID string
+}
+
+// Return the stops with the rider-facing stop code that are served by the agency's routes.
+func (q *Queries) GetStopsByCodeForAgency(ctx context.Context, arg GetStopsByCodeForAgencyParams) ([]Stop, error) {
+	rows, err := q.query(ctx, q.getStopsByCodeForAgencyStmt, getStopsByCodeForAgency, arg.Code, arg.AgencyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Stop
+	for rows.Next() {
+		var i Stop
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.Desc,
+			&i.Lat,
+			&i.Lon,
+			&i.ZoneID,
+			&i.Url,
+			&i.LocationType,
+			&i.Timezone,
+			&i.WheelchairBoarding,
+			&i.PlatformCode,
+			&i.Direction,
+			&i.ParentStation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getStopsByIDs = `-- name: GetStopsByIDs :many
 SELECT
     id, code, name, "desc", lat, lon, zone_id, url, location_type, timezone, wheelchair_boarding, platform_code, direction, parent_station
@@ -359,6 +359,9 @@ CREATE INDEX IF NOT EXISTS idx_trips_block_id ON trips (block_id);
 -- migrate
 CREATE INDEX IF NOT EXISTS idx_shapes_shape_id ON shapes (shape_id);
 
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_stops_code ON stops (code);
+
 -- Problem reports for trips
 -- migrate
 CREATE TABLE
@@ -0,0 +1,68 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("maglev.onebusaway.org/gtfsdb")
+
+// tracedDB wraps a DBTX so every sqlc query runs in its own OpenTelemetry span.
+type tracedDB struct {
+	DBTX
+}
+
+// queryName extracts the sqlc query name from the "-- name: GetAgency :one" header.
+func queryName(query string) string {
+	if rest, ok := strings.CutPrefix(query, "-- name: "); ok {
+		if name, _, found := strings.Cut(rest, " "); found {
+			return name
+		}
+	}
+	return "query"
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return tracer.Start(ctx, "gtfsdb."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.operation.name", name),
+		))
+}
+
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := t.DBTX.ExecContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+func (t tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := t.DBTX.QueryContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+func (t tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := t.DBTX.QueryRowContext(ctx, query, args...)
+	endQuerySpan(span, row.Err())
+	return row
+}
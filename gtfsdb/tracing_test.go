@@ -0,0 +1,12 @@
+package gtfsdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "GetAgency", queryName(getAgency))
+	assert.Equal(t, "query", queryName("SELECT 1"))
+}
@@ -0,0 +1,299 @@
+// Package analytics aggregates anonymized API usage: requests per endpoint, the most requested
+// stops, and hourly traffic. Only counts are kept, per UTC hour; API keys, client addresses and
+// query strings are never recorded. Counters are buffered in memory and periodically added to
+// a SQLite table, from which hours older than the retention period are deleted.
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/sqlitedb"
+)
+
+// Dimensions requests are counted along
+const (
+	DimensionTotal    = "total"    // Every request; the value is empty
+	DimensionEndpoint = "endpoint" // Route pattern, e.g. /api/where/stop/{id}
+	DimensionStop     = "stop"     // Stop ID of a successful stop lookup
+)
+
+const analyticsSchema = `
+CREATE TABLE IF NOT EXISTS request_counts (
+    hour INTEGER NOT NULL,
+    dimension TEXT NOT NULL,
+    value TEXT NOT NULL,
+    request_count INTEGER NOT NULL,
+    PRIMARY KEY (hour, dimension, value)
+);`
+
+// Counter identifies one aggregated count.
+type Counter struct {
+	Hour      int64 // Unix seconds at the start of the UTC hour
+	Dimension string
+	Value     string
+}
+
+// Row is a stored count, as exported.
+type Row struct {
+	Hour      time.Time
+	Dimension string
+	Value     string
+	Requests  int64
+}
+
+// Count is a value and its number of requests.
+type Count struct {
+	Value    string
+	Requests int64
+}
+
+// HourlyCount is the number of requests in one hour.
+type HourlyCount struct {
+	Hour     time.Time
+	Requests int64
+}
+
+// Report summarizes usage since a point in time.
+type Report struct {
+	Since     time.Time
+	Hourly    []HourlyCount // Oldest first
+	Endpoints []Count       // Most requested first
+	TopStops  []Count       // Most requested first
+}
+
+// Collector counts requests and persists the aggregates.
+type Collector struct {
+	mu        sync.Mutex
+	pending   map[Counter]int64 // Counts not yet added to the database
+	db        *sql.DB
+	retention time.Duration
+	clock     clock.Clock
+	logger    *slog.Logger
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewCollector opens (or creates) the analytics database at cfg.DataPath. Pending counts are
+// flushed every flushInterval, if positive, and on Shutdown.
+func NewCollector(cfg appconf.AnalyticsConfig, c clock.Clock, logger *slog.Logger, flushInterval time.Duration) (*Collector, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	db, err := sqlitedb.Open(cfg.DataPath, analyticsSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open analytics database: %w", err)
+	}
+
+	collector := &Collector{
+		pending:   make(map[Counter]int64),
+		db:        db,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		clock:     c,
+		logger:    logger.With(slog.String("component", "analytics")),
+		stopChan:  make(chan struct{}),
+	}
+	if flushInterval > 0 {
+		collector.wg.Add(1)
+		go collector.flushPeriodically(flushInterval)
+	}
+	return collector, nil
+}
+
+// Record counts one request to endpoint, and to stopID if it is not empty.
+func (c *Collector) Record(endpoint, stopID string) {
+	hour := c.clock.Now().UTC().Truncate(time.Hour).Unix()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[Counter{hour, DimensionTotal, ""}]++
+	c.pending[Counter{hour, DimensionEndpoint, endpoint}]++
+	if stopID != "" {
+		c.pending[Counter{hour, DimensionStop, stopID}]++
+	}
+}
+
+// Flush adds pending counts to the database and deletes hours past the retention period.
+func (c *Collector) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[Counter]int64)
+	c.mu.Unlock()
+
+	if err := c.save(ctx, pending); err != nil {
+		// Merge the counts back so the next flush retries them
+		c.mu.Lock()
+		for counter, count := range pending {
+			c.pending[counter] += count
+		}
+		c.mu.Unlock()
+		return err
+	}
+
+	if c.retention > 0 {
+		cutoff := c.clock.Now().UTC().Add(-c.retention).Truncate(time.Hour).Unix()
+		if _, err := c.db.ExecContext(ctx, "DELETE FROM request_counts WHERE hour < ?", cutoff); err != nil {
+			return fmt.Errorf("failed to delete expired analytics: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Collector) save(ctx context.Context, counts map[Counter]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin analytics transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO request_counts (hour, dimension, value, request_count) VALUES (?, ?, ?, ?)
+		ON CONFLICT (hour, dimension, value) DO UPDATE SET request_count = request_count + excluded.request_count`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare analytics upsert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for counter, count := range counts {
+		if _, err := stmt.ExecContext(ctx, counter.Hour, counter.Dimension, counter.Value, count); err != nil {
+			return fmt.Errorf("failed to save analytics counter: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Report summarizes the requests since the start of since's hour, listing at most topStops stops.
+// Pending counts are flushed first so the report is current.
+func (c *Collector) Report(ctx context.Context, since time.Time, topStops int) (Report, error) {
+	if err := c.Flush(ctx); err != nil {
+		return Report{}, err
+	}
+	since = since.UTC().Truncate(time.Hour)
+	report := Report{Since: since, Hourly: []HourlyCount{}}
+
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT hour, request_count FROM request_counts WHERE dimension = ? AND hour >= ? ORDER BY hour",
+		DimensionTotal, since.Unix())
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to load hourly traffic: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var hour, requests int64
+		if err := rows.Scan(&hour, &requests); err != nil {
+			return Report{}, fmt.Errorf("failed to scan hourly traffic: %w", err)
+		}
+		report.Hourly = append(report.Hourly, HourlyCount{Hour: time.Unix(hour, 0).UTC(), Requests: requests})
+	}
+	if err := rows.Err(); err != nil {
+		return Report{}, err
+	}
+
+	if report.Endpoints, err = c.top(ctx, DimensionEndpoint, since, -1); err != nil {
+		return Report{}, err
+	}
+	if report.TopStops, err = c.top(ctx, DimensionStop, since, topStops); err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// top returns the values of a dimension with the most requests since since. A negative limit
+// returns every value.
+func (c *Collector) top(ctx context.Context, dimension string, since time.Time, limit int) ([]Count, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT value, SUM(request_count) AS requests FROM request_counts
+		WHERE dimension = ? AND hour >= ?
+		GROUP BY value ORDER BY requests DESC, value LIMIT ?`,
+		dimension, since.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s counts: %w", dimension, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := []Count{}
+	for rows.Next() {
+		var count Count
+		if err := rows.Scan(&count.Value, &count.Requests); err != nil {
+			return nil, fmt.Errorf("failed to scan %s count: %w", dimension, err)
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// Rows returns every stored count since the start of since's hour, oldest first, for export.
+func (c *Collector) Rows(ctx context.Context, since time.Time) ([]Row, error) {
+	if err := c.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT hour, dimension, value, request_count FROM request_counts WHERE hour >= ? ORDER BY hour, dimension, value",
+		since.UTC().Truncate(time.Hour).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load analytics: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := []Row{}
+	for rows.Next() {
+		var row Row
+		var hour int64
+		if err := rows.Scan(&hour, &row.Dimension, &row.Value, &row.Requests); err != nil {
+			return nil, fmt.Errorf("failed to scan analytics row: %w", err)
+		}
+		row.Hour = time.Unix(hour, 0).UTC()
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func (c *Collector) flushPeriodically(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Flush(context.Background()); err != nil {
+				logging.LogError(c.logger, "failed to flush analytics", err)
+			}
+		case <-c.stopChan:
+			return
+		}
+	}
+}
+
+// Shutdown stops the background flusher, writes pending counts and closes the database.
+// It is safe to call multiple times.
+func (c *Collector) Shutdown() {
+	c.stopOnce.Do(func() {
+		close(c.stopChan)
+		c.wg.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.Flush(ctx); err != nil {
+			logging.LogError(c.logger, "failed to flush analytics on shutdown", err)
+		}
+		if err := c.db.Close(); err != nil {
+			logging.LogError(c.logger, "failed to close analytics database", err)
+		}
+	})
+}
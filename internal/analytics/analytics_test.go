@@ -0,0 +1,76 @@
+package analytics
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestCollector_Report(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)
+	mockClock := clock.NewMockClock(start)
+	path := filepath.Join(t.TempDir(), "analytics.db")
+	collector, err := NewCollector(appconf.AnalyticsConfig{DataPath: path, RetentionDays: 30}, mockClock, nil, 0)
+	require.NoError(t, err)
+
+	collector.Record("/api/where/stop/{id}", "1_100")
+	collector.Record("/api/where/stop/{id}", "1_100")
+	collector.Record("/api/where/stop/{id}", "1_200")
+	mockClock.Advance(time.Hour)
+	collector.Record("/api/where/current-time.json", "")
+	collector.Shutdown()
+
+	// Counts survive a restart and keep accumulating
+	collector, err = NewCollector(appconf.AnalyticsConfig{DataPath: path, RetentionDays: 30}, mockClock, nil, 0)
+	require.NoError(t, err)
+	defer collector.Shutdown()
+	collector.Record("/api/where/stop/{id}", "1_200")
+	collector.Record("/api/where/stop/{id}", "1_200")
+
+	report, err := collector.Report(context.Background(), start.Add(-24*time.Hour), 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, []HourlyCount{
+		{Hour: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), Requests: 3},
+		{Hour: time.Date(2025, 6, 1, 13, 0, 0, 0, time.UTC), Requests: 3},
+	}, report.Hourly)
+	assert.Equal(t, []Count{
+		{Value: "/api/where/stop/{id}", Requests: 5},
+		{Value: "/api/where/current-time.json", Requests: 1},
+	}, report.Endpoints)
+	assert.Equal(t, []Count{{Value: "1_200", Requests: 3}, {Value: "1_100", Requests: 2}}, report.TopStops)
+
+	report, err = collector.Report(context.Background(), start.Add(-24*time.Hour), 1)
+	require.NoError(t, err)
+	assert.Len(t, report.TopStops, 1)
+
+	rows, err := collector.Rows(context.Background(), start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, rows, 4) // total, two endpoints and a stop in the second hour
+}
+
+func TestCollector_Retention(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	mockClock := clock.NewMockClock(start)
+	collector, err := NewCollector(appconf.AnalyticsConfig{DataPath: ":memory:", RetentionDays: 2}, mockClock, nil, 0)
+	require.NoError(t, err)
+	defer collector.Shutdown()
+
+	collector.Record("/api/where/current-time.json", "")
+	require.NoError(t, collector.Flush(context.Background()))
+
+	mockClock.Advance(3 * 24 * time.Hour)
+	collector.Record("/api/where/current-time.json", "")
+	require.NoError(t, collector.Flush(context.Background()))
+
+	rows, err := collector.Rows(context.Background(), start.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.True(t, rows[0].Hour.Equal(start.Add(3*24*time.Hour)))
+}
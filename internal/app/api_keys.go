@@ -1,12 +1,74 @@
 package app
 
 import (
+	"context"
 	"crypto/subtle"
 	"net/http"
+	"strings"
 )
 
+type apiKeyContextKey struct{}
+
+// authenticatedKey is the identity attached to a request by an authentication middleware.
+type authenticatedKey struct {
+	key     string
+	trusted bool
+}
+
+// WithAPIKey returns a shallow copy of r that is authenticated as key. It is used by
+// authentication methods that don't put the key in the query string. When trusted is true
+// the key has already been verified (e.g. a bearer token subject) and is accepted without
+// being listed in api-keys.
+func WithAPIKey(r *http.Request, key string, trusted bool) *http.Request {
+	ctx := context.WithValue(r.Context(), apiKeyContextKey{}, authenticatedKey{key: key, trusted: trusted})
+	return r.WithContext(ctx)
+}
+
+// APIKeyHeader carries an API key in place of the "key" query parameter, as does an
+// "Authorization: Bearer <key>" header. Keys sent in headers stay out of URLs, and so out of
+// access logs and shared caches.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyFromRequest returns the key a request is authenticated as, falling back to the
+// X-API-Key header, then an "Authorization: Bearer" header, then the "key" query parameter.
+func APIKeyFromRequest(r *http.Request) string {
+	if auth, ok := r.Context().Value(apiKeyContextKey{}).(authenticatedKey); ok {
+		return auth.key
+	}
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		return key
+	}
+	if key, ok := BearerToken(r); ok {
+		return key
+	}
+	return r.URL.Query().Get("key")
+}
+
+// APIKeyInHeader reports whether the request sends its API key in a header, whether or not it
+// also has one in the query string.
+func APIKeyInHeader(r *http.Request) bool {
+	if strings.TrimSpace(r.Header.Get(APIKeyHeader)) != "" {
+		return true
+	}
+	_, ok := BearerToken(r)
+	return ok
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer" header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
 func (app *Application) RequestHasInvalidAPIKey(r *http.Request) bool {
-	key := r.URL.Query().Get("key")
+	if auth, ok := r.Context().Value(apiKeyContextKey{}).(authenticatedKey); ok && auth.trusted {
+		return auth.key == ""
+	}
+	key := APIKeyFromRequest(r)
 	return app.IsInvalidAPIKey(key)
 }
 
@@ -15,6 +77,9 @@ func (app *Application) IsInvalidAPIKey(key string) bool {
 		return true
 	}
 
+	app.accessMu.RLock()
+	defer app.accessMu.RUnlock()
+
 	validKeys := app.Config.ApiKeys
 	for _, validKey := range validKeys {
 		// Use constant-time comparison to prevent timing attacks
@@ -25,3 +90,47 @@ func (app *Application) IsInvalidAPIKey(key string) bool {
 
 	return true
 }
+
+// RequestHasBulkAPIKey reports whether the request carries one of the keys trusted to page past
+// the usual page size limits.
+func (app *Application) RequestHasBulkAPIKey(r *http.Request) bool {
+	key := APIKeyFromRequest(r)
+	if key == "" {
+		return false
+	}
+
+	app.accessMu.RLock()
+	defer app.accessMu.RUnlock()
+
+	for _, bulkKey := range app.Config.BulkApiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(bulkKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestHasAdminAPIKey reports whether the request carries one of the configured admin keys.
+func (app *Application) RequestHasAdminAPIKey(r *http.Request) bool {
+	key := APIKeyFromRequest(r)
+	return app.IsAdminAPIKey(key)
+}
+
+// IsAdminAPIKey reports whether key is one of the configured admin keys.
+// Admin access is disabled entirely when no admin keys are configured.
+func (app *Application) IsAdminAPIKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	app.accessMu.RLock()
+	defer app.accessMu.RUnlock()
+
+	for _, adminKey := range app.Config.AdminApiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
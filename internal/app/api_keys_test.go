@@ -152,3 +152,146 @@ func TestRequestHasInvalidAPIKeyWithNoQueryParam(t *testing.T) {
 	result := app.RequestHasInvalidAPIKey(req)
 	assert.True(t, result, "Request without API key should be invalid")
 }
+
+func TestIsAdminAPIKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		adminKeys []string
+		testKey   string
+		isAdmin   bool
+	}{
+		{
+			name:      "Configured admin key is accepted",
+			adminKeys: []string{"admin-key"},
+			testKey:   "admin-key",
+			isAdmin:   true,
+		},
+		{
+			name:      "Regular key is rejected",
+			adminKeys: []string{"admin-key"},
+			testKey:   "test",
+			isAdmin:   false,
+		},
+		{
+			name:      "Empty key is rejected",
+			adminKeys: []string{"admin-key"},
+			testKey:   "",
+			isAdmin:   false,
+		},
+		{
+			name:      "No admin keys configured disables admin access",
+			adminKeys: nil,
+			testKey:   "admin-key",
+			isAdmin:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Application{
+				Config: appconf.Config{
+					ApiKeys:      []string{"test"},
+					AdminApiKeys: tt.adminKeys,
+				},
+			}
+
+			assert.Equal(t, tt.isAdmin, app.IsAdminAPIKey(tt.testKey))
+
+			req := httptest.NewRequest(http.MethodGet, "/?key="+tt.testKey, nil)
+			assert.Equal(t, tt.isAdmin, app.RequestHasAdminAPIKey(req))
+		})
+	}
+}
+
+func TestWithAPIKey(t *testing.T) {
+	app := &Application{
+		Config: appconf.Config{
+			ApiKeys: []string{"key"},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/test?key=query-key", nil)
+	assert.Equal(t, "query-key", APIKeyFromRequest(req))
+
+	untrusted := WithAPIKey(req, "key", false)
+	assert.Equal(t, "key", APIKeyFromRequest(untrusted))
+	assert.False(t, app.RequestHasInvalidAPIKey(untrusted))
+
+	unknown := WithAPIKey(req, "unknown", false)
+	assert.True(t, app.RequestHasInvalidAPIKey(unknown), "untrusted keys must still be configured")
+
+	trusted := WithAPIKey(req, "token-subject", true)
+	assert.False(t, app.RequestHasInvalidAPIKey(trusted))
+}
+
+func TestAPIKeyFromRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		headers  map[string]string
+		expected string
+		inHeader bool
+	}{
+		{name: "query parameter", target: "/test?key=query-key", expected: "query-key"},
+		{name: "X-API-Key header", target: "/test", headers: map[string]string{"X-API-Key": "header-key"}, expected: "header-key", inHeader: true},
+		{name: "bearer token", target: "/test", headers: map[string]string{"Authorization": "Bearer bearer-key"}, expected: "bearer-key", inHeader: true},
+		{name: "lowercase scheme", target: "/test", headers: map[string]string{"Authorization": "bearer bearer-key"}, expected: "bearer-key", inHeader: true},
+		{name: "other scheme", target: "/test?key=query-key", headers: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, expected: "query-key"},
+		{
+			name:     "headers win over the query parameter",
+			target:   "/test?key=query-key",
+			headers:  map[string]string{"X-API-Key": "header-key", "Authorization": "Bearer bearer-key"},
+			expected: "header-key",
+			inHeader: true,
+		},
+		{name: "no key", target: "/test", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			assert.Equal(t, tt.expected, APIKeyFromRequest(req))
+			assert.Equal(t, tt.inHeader, APIKeyInHeader(req))
+		})
+	}
+}
+
+func TestSetAccessConfig(t *testing.T) {
+	app := &Application{
+		Config: appconf.Config{
+			Port:    4000,
+			ApiKeys: []string{"old-key"},
+		},
+	}
+
+	app.SetAccessConfig(appconf.Config{
+		Port:          9999,
+		ApiKeys:       []string{"new-key"},
+		ExemptApiKeys: []string{"new-key"},
+		AdminApiKeys:  []string{"admin-key"},
+		RateLimit:     50,
+	})
+
+	assert.True(t, app.IsInvalidAPIKey("old-key"))
+	assert.False(t, app.IsInvalidAPIKey("new-key"))
+	assert.True(t, app.IsAdminAPIKey("admin-key"))
+	assert.Equal(t, []string{"new-key"}, app.ExemptAPIKeys())
+	assert.Equal(t, 50, app.Config.RateLimit)
+	// Settings that need a restart are left alone
+	assert.Equal(t, 4000, app.Config.Port)
+}
+
+func TestIsRateLimitExemptPath(t *testing.T) {
+	app := &Application{
+		Config: appconf.Config{
+			RateLimitExemptPaths: []string{"/api/where/current-time.json", "/api/where/config/*"},
+		},
+	}
+	assert.True(t, app.IsRateLimitExemptPath("/api/where/current-time.json"))
+	assert.True(t, app.IsRateLimitExemptPath("/api/where/config/raba.json"))
+	assert.False(t, app.IsRateLimitExemptPath("/api/where/current-time.json/extra"))
+	assert.False(t, app.IsRateLimitExemptPath("/api/where/agency/raba.json"))
+}
@@ -1,12 +1,34 @@
 package app
 
 import (
+	"io"
 	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"maglev.onebusaway.org/internal/analytics"
 	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/archive"
+	"maglev.onebusaway.org/internal/audit"
+	"maglev.onebusaway.org/internal/auth"
+	"maglev.onebusaway.org/internal/blocklist"
 	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/crowding"
+	"maglev.onebusaway.org/internal/detours"
+	"maglev.onebusaway.org/internal/errorreport"
+	"maglev.onebusaway.org/internal/events"
+	"maglev.onebusaway.org/internal/gbfs"
+	"maglev.onebusaway.org/internal/geocode"
 	"maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/metrics"
+	"maglev.onebusaway.org/internal/notify"
+	"maglev.onebusaway.org/internal/popularity"
+	"maglev.onebusaway.org/internal/quota"
+	"maglev.onebusaway.org/internal/registry"
+	"maglev.onebusaway.org/internal/ridership"
+	"maglev.onebusaway.org/internal/tracing"
+	"maglev.onebusaway.org/internal/webhooks"
 )
 
 // Application holds the dependencies for our HTTP handlers, helpers,
@@ -14,10 +36,96 @@ import (
 // logger, but it will grow to include a lot more as our build progresses.
 type Application struct {
 	Config              appconf.Config
+	ConfigFiles         []string // JSON files Config was merged from, base first; empty when configured with flags
 	GtfsConfig          gtfs.Config
 	Logger              *slog.Logger
+	LogLevel            *slog.LevelVar // Minimum level Logger writes; nil if fixed
+	LogOutput           io.Writer      // Where Logger and the request log write; nil means stdout
 	GtfsManager         *gtfs.Manager
 	DirectionCalculator *gtfs.AdvancedDirectionCalculator
 	Clock               clock.Clock
+	WallClock           clock.Clock // Real time, for metering and authenticating clients; Clock may be simulated with fake-time
 	Metrics             *metrics.Metrics
+	Quotas              *quota.Manager
+	Analytics           *analytics.Collector // nil unless analytics are configured
+	Bikeshare           *gbfs.Poller         // nil unless gbfs feeds are configured
+	Geocoder            *geocode.Geocoder    // nil unless geocoder is configured
+	Notifications       *notify.Manager      // nil unless notifications are enabled
+	Events              *events.Publisher    // nil unless event-publishing is configured
+	AlertWebhooks       *webhooks.Notifier   // nil unless alert-webhooks are configured
+	FeedRegistry        *registry.Watcher    // nil unless feed-registry is configured
+	ArrivalArchive      *archive.Archive     // nil unless arrival-archive is configured
+	Ridership           *ridership.Store     // nil unless ridership is configured
+	Detours             *detours.Store       // nil unless detours-path is set
+	Crowding            crowding.Predictor   // nil unless crowding-predictor is set
+	Popularity          *popularity.Tracker  // nil unless search-popularity-weight is set
+	BearerAuth          *auth.BearerVerifier // nil unless bearer-auth is configured
+	ShutdownTracing     tracing.ShutdownFunc // Flushes pending spans; nil when tracing was never set up
+	ErrorReporter       errorreport.Reporter // nil unless error-reporting is configured
+	RecentErrors        *errorreport.Recent  // Latest server errors, shown on the admin status page
+	AuditLog            *audit.Log           // Records admin actions
+	Blocklist           *blocklist.List      // Keys and networks refused with 403
+	StartupChecks       []StartupCheck       // Self-check results from before the server started; reported by /readyz
+	draining            atomic.Bool
+	accessMu            sync.RWMutex // Guards the key lists, key restrictions, rate limit, quota limits and signing secrets in Config, which can be reloaded
+}
+
+// SetAccessConfig replaces the API keys, exempt keys, admin keys, bulk keys, key restrictions, rate
+// limit, rate-limit-exempt paths, quota limits and signed-request settings with those in cfg. It is safe to call
+// while requests are being served.
+func (app *Application) SetAccessConfig(cfg appconf.Config) {
+	app.accessMu.Lock()
+	defer app.accessMu.Unlock()
+	app.Config.ApiKeys = cfg.ApiKeys
+	app.Config.ExemptApiKeys = cfg.ExemptApiKeys
+	app.Config.AdminApiKeys = cfg.AdminApiKeys
+	app.Config.BulkApiKeys = cfg.BulkApiKeys
+	app.Config.KeyRestrictions = cfg.KeyRestrictions
+	app.Config.RateLimit = cfg.RateLimit
+	app.Config.RateLimitExemptPaths = cfg.RateLimitExemptPaths
+	app.Config.Quotas.Default = cfg.Quotas.Default
+	app.Config.Quotas.Keys = cfg.Quotas.Keys
+	app.Config.SignedRequests = cfg.SignedRequests
+}
+
+// SignedRequestConfig returns the signing secrets and settings for signed requests.
+func (app *Application) SignedRequestConfig() appconf.SignedRequestConfig {
+	app.accessMu.RLock()
+	defer app.accessMu.RUnlock()
+	return app.Config.SignedRequests
+}
+
+// ExemptAPIKeys returns the keys that bypass rate limits and quotas.
+func (app *Application) ExemptAPIKeys() []string {
+	app.accessMu.RLock()
+	defer app.accessMu.RUnlock()
+	return app.Config.ExemptApiKeys
+}
+
+// IsRateLimitExemptPath reports whether requests for path bypass rate limits and quotas.
+// Configured paths match exactly, or as a prefix when they end with "*".
+func (app *Application) IsRateLimitExemptPath(path string) bool {
+	app.accessMu.RLock()
+	defer app.accessMu.RUnlock()
+	for _, exempt := range app.Config.RateLimitExemptPaths {
+		if prefix, ok := strings.CutSuffix(exempt, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == exempt {
+			return true
+		}
+	}
+	return false
+}
+
+// StartDraining marks the application as shutting down. Requests are still served, but
+// readiness checks fail so load balancers stop sending new traffic.
+func (app *Application) StartDraining() {
+	app.draining.Store(true)
+}
+
+// Draining reports whether StartDraining has been called.
+func (app *Application) Draining() bool {
+	return app.draining.Load()
 }
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/gtfs"
+)
+
+// ConfigJSON returns the configuration in the layout of a config file, for --dump-config and
+// the admin config endpoint. Credentials of the feeds and of outside services are redacted;
+// API keys are not. Settings left at their defaults are left out.
+func ConfigJSON(cfg appconf.Config, gtfsCfg gtfs.Config) map[string]interface{} {
+	// Convert environment enum to string
+	envStr := "development"
+	switch cfg.Env {
+	case appconf.Development:
+		envStr = "development"
+	case appconf.Test:
+		envStr = "test"
+	case appconf.Production:
+		envStr = "production"
+	}
+
+	// Build the gtfs-static-feed object, or the gtfs-static-feeds list when feeds are merged.
+	// A config without a feed URL, which validation would refuse, dumps neither.
+	staticFeeds := []map[string]string{}
+	for _, feed := range gtfsCfg.StaticFeeds() {
+		if feed.URL == "" {
+			continue
+		}
+		staticFeed := map[string]string{
+			"url": feed.URL,
+		}
+		if feed.AuthHeaderKey != "" {
+			staticFeed["auth-header-name"] = feed.AuthHeaderKey
+			staticFeed["auth-header-value"] = "***REDACTED***"
+		}
+		if feed.IDPrefix != "" {
+			staticFeed["id-prefix"] = feed.IDPrefix
+		}
+		staticFeeds = append(staticFeeds, staticFeed)
+	}
+
+	// Build JSON config structure
+	jsonConfig := map[string]interface{}{
+		"config-version":            appconf.CurrentConfigVersion,
+		"port":                      cfg.Port,
+		"env":                       envStr,
+		"api-keys":                  cfg.ApiKeys,
+		"exempt-api-keys":           cfg.ExemptApiKeys,
+		"admin-api-keys":            cfg.AdminApiKeys,
+		"bulk-api-keys":             cfg.BulkApiKeys,
+		"rate-limit":                cfg.RateLimit,
+		"realtime-staleness-budget": cfg.RealtimeStalenessBudget,
+		"data-path":                 gtfsCfg.GTFSDataPath,
+	}
+	if len(staticFeeds) > 1 {
+		jsonConfig["gtfs-static-feeds"] = staticFeeds
+	} else if len(staticFeeds) == 1 {
+		jsonConfig["gtfs-static-feed"] = staticFeeds[0]
+	}
+	if interval := gtfsCfg.StaticRefreshInterval; interval != 0 && interval != gtfs.DefaultStaticRefreshInterval {
+		jsonConfig["static-refresh-interval"] = int(interval / time.Second)
+	}
+	if cfg.AdminPort != 0 {
+		jsonConfig["admin-port"] = cfg.AdminPort
+	}
+	if cfg.GRPCPort != 0 {
+		jsonConfig["grpc-port"] = cfg.GRPCPort
+	}
+	if len(cfg.RateLimitExemptPaths) > 0 {
+		jsonConfig["rate-limit-exempt-paths"] = cfg.RateLimitExemptPaths
+	}
+	if cfg.UnixSocket != "" {
+		jsonConfig["unix-socket"] = cfg.UnixSocket
+	}
+	if cfg.Logging != (appconf.LoggingConfig{Level: "info", Format: "text", Output: "stdout"}) {
+		jsonConfig["logging"] = cfg.Logging
+	}
+	if cfg.AuditLogPath != "" {
+		jsonConfig["audit-log-path"] = cfg.AuditLogPath
+	}
+	if cfg.BlocklistPath != "" {
+		jsonConfig["blocklist-path"] = cfg.BlocklistPath
+	}
+	if cfg.DetoursPath != "" {
+		jsonConfig["detours-path"] = cfg.DetoursPath
+	}
+	if cfg.CrowdingPredictor != "" {
+		jsonConfig["crowding-predictor"] = cfg.CrowdingPredictor
+	}
+	if cfg.SearchPopularityWeight > 0 {
+		jsonConfig["search-popularity-weight"] = cfg.SearchPopularityWeight
+	}
+	if cfg.NotFoundResponses == appconf.NotFoundJava {
+		jsonConfig["not-found-responses"] = cfg.NotFoundResponses
+	}
+	if cfg.JavaParity {
+		jsonConfig["java-parity"] = true
+	}
+	if cfg.CanonicalJSON {
+		jsonConfig["canonical-json"] = true
+	}
+	if cfg.GraphQL {
+		jsonConfig["graphql"] = true
+	}
+	if cfg.Quotas.Enabled() {
+		jsonConfig["quotas"] = cfg.Quotas
+	}
+	if cfg.Analytics.Enabled() {
+		jsonConfig["analytics"] = cfg.Analytics
+	}
+	if cfg.ArrivalArchive.Enabled() {
+		jsonConfig["arrival-archive"] = cfg.ArrivalArchive
+	}
+	if cfg.Ridership.Enabled() {
+		jsonConfig["ridership"] = cfg.Ridership
+	}
+	if cfg.Tracing.Enabled() {
+		tracing := cfg.Tracing
+		if len(tracing.Headers) > 0 {
+			tracing.Headers = make(map[string]string, len(cfg.Tracing.Headers))
+			for name := range cfg.Tracing.Headers {
+				tracing.Headers[name] = "***REDACTED***"
+			}
+		}
+		jsonConfig["tracing"] = tracing
+	}
+	if cfg.ErrorReporting.Enabled() {
+		errorReporting := cfg.ErrorReporting
+		errorReporting.SentryDSN = redactDSN(errorReporting.SentryDSN)
+		jsonConfig["error-reporting"] = errorReporting
+	}
+	if cfg.ResponseCache.Enabled() {
+		jsonConfig["response-cache"] = cfg.ResponseCache
+	}
+	if len(cfg.ConcurrencyLimits) > 0 {
+		jsonConfig["concurrency-limits"] = cfg.ConcurrencyLimits
+	}
+	if len(cfg.Pagination) > 0 {
+		jsonConfig["pagination"] = cfg.Pagination
+	}
+	if cfg.RequestLimits != (appconf.RequestLimitsConfig{}) {
+		jsonConfig["request-limits"] = cfg.RequestLimits
+	}
+	if cfg.CORS.AllowedOrigins != nil || cfg.CORS.AllowedMethods != nil || cfg.CORS.MaxAge != 0 {
+		jsonConfig["cors"] = cfg.CORS
+	}
+	if cfg.TLS.Enabled() {
+		jsonConfig["tls"] = cfg.TLS
+	}
+	if cfg.Shutdown != (appconf.ShutdownConfig{}) {
+		jsonConfig["shutdown"] = cfg.Shutdown
+	}
+	if cfg.FakeTime.Enabled() {
+		jsonConfig["fake-time"] = cfg.FakeTime
+	}
+	if cfg.GBFS.Enabled() {
+		jsonConfig["gbfs"] = cfg.GBFS
+	}
+	if cfg.Geocoder.Enabled() {
+		geocoder := cfg.Geocoder
+		if geocoder.APIKey != "" {
+			geocoder.APIKey = "***REDACTED***"
+		}
+		jsonConfig["geocoder"] = geocoder
+	}
+	if cfg.Notifications.Enabled {
+		jsonConfig["notifications"] = cfg.Notifications
+	}
+	if cfg.SnapshotUpload.Enabled() {
+		upload := cfg.SnapshotUpload
+		upload.SecretAccessKey, upload.SecretAccessKeyFile = "***REDACTED***", ""
+		jsonConfig["snapshot-upload"] = upload
+	}
+	if cfg.EventPublishing.Enabled() {
+		publishing := cfg.EventPublishing
+		if publishing.Password != "" {
+			publishing.Password = "***REDACTED***"
+		}
+		publishing.PasswordFile = ""
+		jsonConfig["event-publishing"] = publishing
+	}
+	if len(cfg.AlertWebhooks) > 0 {
+		webhooks := make([]appconf.AlertWebhookConfig, len(cfg.AlertWebhooks))
+		for i, webhook := range cfg.AlertWebhooks {
+			if webhook.Secret != "" {
+				webhook.Secret = "***REDACTED***"
+			}
+			webhook.SecretFile = ""
+			webhooks[i] = webhook
+		}
+		jsonConfig["alert-webhooks"] = webhooks
+	}
+	if cfg.FeedRegistry.Enabled() {
+		feedRegistry := cfg.FeedRegistry
+		feedRegistry.Token, feedRegistry.TokenFile = "***REDACTED***", ""
+		jsonConfig["feed-registry"] = feedRegistry
+	}
+
+	// Add the GTFS-RT feeds if configured
+	feeds := []map[string]string{}
+	for _, rtFeed := range gtfsCfg.RealtimeFeeds() {
+		// Mask sensitive auth header value
+		authHeaderValue := rtFeed.AuthHeaderValue
+		if authHeaderValue != "" {
+			authHeaderValue = "***REDACTED***"
+		}
+
+		feed := map[string]string{
+			"trip-updates-url":           rtFeed.TripUpdatesURL,
+			"vehicle-positions-url":      rtFeed.VehiclePositionsURL,
+			"service-alerts-url":         rtFeed.ServiceAlertsURL,
+			"realtime-auth-header-name":  rtFeed.AuthHeaderKey,
+			"realtime-auth-header-value": authHeaderValue,
+		}
+		if rtFeed.AgencyID != "" {
+			feed["agency-id"] = rtFeed.AgencyID
+		}
+		if rtFeed.IDPrefix != "" {
+			feed["id-prefix"] = rtFeed.IDPrefix
+		}
+		feeds = append(feeds, feed)
+	}
+	jsonConfig["gtfs-rt-feeds"] = feeds
+
+	return jsonConfig
+}
+
+// redactDSN redacts the key in the userinfo of a Sentry DSN, leaving the host and project
+// visible. A DSN that doesn't parse is redacted whole.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Host == "" {
+		return "***REDACTED***"
+	}
+	if u.User == nil {
+		return dsn
+	}
+	u.User = nil
+	return strings.Replace(u.String(), "://", "://***REDACTED***@", 1)
+}
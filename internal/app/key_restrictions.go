@@ -0,0 +1,106 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// ClientAddr returns the IP address of the connection r arrived on, or the zero Addr if it
+// can't be parsed. Forwarding headers are ignored since any client can set them.
+func ClientAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, _ := netip.ParseAddr(host)
+	return addr.Unmap()
+}
+
+// RequestViolatesKeyRestrictions reports whether the request's API key is restricted to other
+// origins or networks than the ones the request comes from.
+func (app *Application) RequestViolatesKeyRestrictions(r *http.Request) bool {
+	key := APIKeyFromRequest(r)
+
+	app.accessMu.RLock()
+	restriction, ok := app.Config.KeyRestrictions[key]
+	app.accessMu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	if len(restriction.AllowedOrigins) > 0 && !originAllowed(requestOrigin(r), restriction.AllowedOrigins) {
+		return true
+	}
+	if len(restriction.AllowedIPs) > 0 && !addrAllowed(ClientAddr(r), restriction.AllowedIPs) {
+		return true
+	}
+	return false
+}
+
+// requestOrigin returns the scheme and host the request was made from, taken from the Origin
+// header or, for requests browsers send without one, the Referer.
+func requestOrigin(r *http.Request) *url.URL {
+	value := r.Header.Get("Origin")
+	if value == "" || value == "null" {
+		value = r.Referer()
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// OriginAllowed reports whether origin, the value of an Origin header, matches one of patterns
+// as the allowed origins of key restrictions do.
+func OriginAllowed(origin string, patterns []string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+	return originAllowed(u, patterns)
+}
+
+// originAllowed reports whether origin matches one of patterns. A pattern host starting with
+// "*." matches any subdomain, but not the bare domain.
+func originAllowed(origin *url.URL, patterns []string) bool {
+	if origin == nil {
+		return false
+	}
+	host := strings.ToLower(origin.Host)
+	for _, pattern := range patterns {
+		allowed, err := url.Parse(pattern)
+		if err != nil || !strings.EqualFold(allowed.Scheme, origin.Scheme) {
+			continue
+		}
+		allowedHost := strings.ToLower(allowed.Host)
+		if suffix, wildcard := strings.CutPrefix(allowedHost, "*"); wildcard {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if host == allowedHost {
+			return true
+		}
+	}
+	return false
+}
+
+// addrAllowed reports whether addr is one of ranges, given as CIDR ranges or single addresses.
+func addrAllowed(addr netip.Addr, ranges []string) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, entry := range ranges {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+		} else if allowed, err := netip.ParseAddr(entry); err == nil && allowed.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
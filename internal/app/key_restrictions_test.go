@@ -0,0 +1,63 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func TestRequestViolatesKeyRestrictions(t *testing.T) {
+	app := &Application{
+		Config: appconf.Config{
+			KeyRestrictions: map[string]appconf.KeyRestriction{
+				"browser": {AllowedOrigins: []string{"https://example.com", "https://*.transit.example"}},
+				"server":  {AllowedIPs: []string{"203.0.113.0/24", "2001:db8::5"}},
+			},
+		},
+	}
+
+	request := func(key, remoteAddr string, headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json?key="+key, nil)
+		r.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		violates bool
+	}{
+		{"unrestricted key", request("other", "198.51.100.1:1000", nil), false},
+		{"allowed origin", request("browser", "198.51.100.1:1000", map[string]string{"Origin": "https://example.com"}), false},
+		{"wildcard subdomain", request("browser", "198.51.100.1:1000", map[string]string{"Origin": "https://maps.transit.example"}), false},
+		{"wildcard excludes bare domain", request("browser", "198.51.100.1:1000", map[string]string{"Origin": "https://transit.example"}), true},
+		{"referer fallback", request("browser", "198.51.100.1:1000", map[string]string{"Referer": "https://example.com/trip-planner?stop=1"}), false},
+		{"other origin", request("browser", "198.51.100.1:1000", map[string]string{"Origin": "https://evil.example"}), true},
+		{"scheme mismatch", request("browser", "198.51.100.1:1000", map[string]string{"Origin": "http://example.com"}), true},
+		{"no origin", request("browser", "198.51.100.1:1000", nil), true},
+		{"allowed network", request("server", "203.0.113.9:1000", nil), false},
+		{"allowed single address", request("server", "[2001:db8::5]:1000", nil), false},
+		{"other network", request("server", "198.51.100.1:1000", nil), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.violates, app.RequestViolatesKeyRestrictions(tt.req))
+		})
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "[::ffff:192.0.2.1]:443"
+	r.Header.Set("X-Forwarded-For", "203.0.113.1")
+	assert.Equal(t, "192.0.2.1", ClientAddr(r).String())
+
+	r.RemoteAddr = "not an address"
+	assert.False(t, ClientAddr(r).IsValid())
+}
@@ -0,0 +1,27 @@
+package app
+
+// Startup check statuses. A failed check makes the server not ready; a warning is only
+// reported, for dependencies the server can run without for a while, such as a feed that is
+// unreachable at the moment.
+const (
+	StartupCheckOK     = "ok"
+	StartupCheckWarn   = "warn"
+	StartupCheckFailed = "failed"
+)
+
+// StartupCheck is the result of one check run before the server starts serving traffic.
+type StartupCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// StartupChecksPassed reports whether no startup check failed.
+func (app *Application) StartupChecksPassed() bool {
+	for _, check := range app.StartupChecks {
+		if check.Status == StartupCheckFailed {
+			return false
+		}
+	}
+	return true
+}
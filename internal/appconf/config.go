@@ -1,17 +1,353 @@
 package appconf
 
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/clock"
+)
+
 // Config holds all the configuration settings for our Application.
 // For now, the only configuration settings will be the network port that we want the
 // server to listen on, and the name of the current operating environment for the
 // Application (development, staging, production, etc.). We will read in these
 // configuration settings from command-line flags when the Application starts.
 type Config struct {
-	Port          int
-	Env           Environment
-	ApiKeys       []string
-	ExemptApiKeys []string
-	Verbose       bool
-	RateLimit     int // Requests per second per API key for rate limiting
+	Port                    int
+	Env                     Environment
+	ApiKeys                 []string
+	ExemptApiKeys           []string
+	AdminApiKeys            []string // Keys allowed to call the /api/admin endpoints
+	BulkApiKeys             []string // Keys allowed page sizes up to the bulk-max-count of each pagination class
+	AdminPort               int      // Serve /api/admin endpoints on this port only; 0 serves them on Port
+	GRPCPort                int      // Serve the gRPC API on this port; 0 disables it
+	UnixSocket              string   // Listen on this Unix domain socket instead of Port when set
+	AuditLogPath            string   // SQLite file recording admin actions; empty keeps the log in memory
+	BlocklistPath           string   // SQLite file persisting blocked keys and networks; empty keeps them in memory
+	DetoursPath             string   // JSON file of route detours and their paths; empty serves detours from alerts only
+	CrowdingPredictor       string   // Name of the registered predictor of arrival occupancy; empty disables predictions
+	SearchPopularityWeight  float64  // How much request counts move popular stops and routes up search results; 0 disables
+	NotFoundResponses       string   // How requests for unknown IDs are answered: NotFoundConsistent or NotFoundJava
+	JavaParity              bool     // Write responses as the Java OneBusAway server does, for clients that depend on its output
+	CanonicalJSON           bool     // Serialize response data deterministically and tag it with an ETag
+	GraphQL                 bool     // Serve GraphQL queries over the GTFS data at /graphql
+	Verbose                 bool
+	Logging                 LoggingConfig
+	ConfigWatchInterval     int                       // Seconds between checks of the config files for changes; 0 disables watching
+	RateLimit               int                       // Requests per second per API key for rate limiting
+	RateLimitExemptPaths    []string                  // Request paths served without rate limits or quotas; a trailing * matches a prefix
+	KeyRestrictions         map[string]KeyRestriction // API key -> where it may be used from; unlisted keys are unrestricted
+	Quotas                  QuotaConfig
+	Analytics               AnalyticsConfig
+	SignedRequests          SignedRequestConfig
+	BearerAuth              BearerAuthConfig
+	Tracing                 TracingConfig
+	ErrorReporting          ErrorReportingConfig
+	ResponseCache           ResponseCacheConfig
+	RequestLimits           RequestLimitsConfig
+	CORS                    CORSConfig
+	ConcurrencyLimits       ConcurrencyLimitsConfig
+	Pagination              PaginationConfig
+	TLS                     TLSConfig
+	Shutdown                ShutdownConfig
+	FakeTime                FakeTimeConfig
+	GBFS                    GBFSConfig
+	Geocoder                GeocoderConfig
+	Notifications           NotificationsConfig
+	SnapshotUpload          SnapshotUploadConfig
+	EventPublishing         EventPublishingConfig
+	AlertWebhooks           []AlertWebhookConfig
+	FeedRegistry            FeedRegistryConfig
+	ArrivalArchive          ArrivalArchiveConfig
+	Ridership               RidershipConfig
+	RealtimeStalenessBudget int // Seconds without a successful GTFS-RT refresh before /readyz reports not ready
+}
+
+// QuotaLimits caps the number of requests an API key may make per calendar period.
+// A zero value means the period is unlimited.
+type QuotaLimits struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+// IsZero reports whether neither a daily nor a monthly limit is set.
+func (l QuotaLimits) IsZero() bool {
+	return l.Daily == 0 && l.Monthly == 0
+}
+
+// QuotaConfig holds long-horizon request quotas. Default applies to every key without
+// an entry in Keys. Counters are persisted to DataPath so they survive restarts.
+type QuotaConfig struct {
+	Default  QuotaLimits            `json:"default"`
+	Keys     map[string]QuotaLimits `json:"keys"`
+	DataPath string                 `json:"data-path"`
+}
+
+// Enabled reports whether any quota limit is configured.
+func (q QuotaConfig) Enabled() bool {
+	if !q.Default.IsZero() {
+		return true
+	}
+	for _, limits := range q.Keys {
+		if !limits.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyticsConfig enables aggregated, anonymized usage statistics stored in a SQLite file.
+type AnalyticsConfig struct {
+	DataPath      string `json:"data-path"`      // Analytics are disabled when empty
+	RetentionDays int    `json:"retention-days"` // Hours older than this are deleted; defaults to 90
+}
+
+// Enabled reports whether analytics are collected.
+func (a AnalyticsConfig) Enabled() bool {
+	return a.DataPath != ""
+}
+
+// SignedRequestConfig enables HMAC request signing. Clients whose key has a secret can
+// send the key and a timestamped signature in headers instead of putting the key in the URL.
+type SignedRequestConfig struct {
+	Secrets      map[string]string `json:"secrets"`        // API key -> shared secret
+	MaxClockSkew int               `json:"max-clock-skew"` // Seconds a signature timestamp may differ from server time
+	Required     bool              `json:"required"`       // Reject unsigned requests from keys that have a secret
+}
+
+// Enabled reports whether any signing secret is configured.
+func (s SignedRequestConfig) Enabled() bool {
+	return len(s.Secrets) > 0
+}
+
+// BearerAuthConfig enables JWT bearer tokens from an external identity provider as an
+// alternative to API keys. The identity claim of a valid token takes the place of the key.
+type BearerAuthConfig struct {
+	JWKSURL       string `json:"jwks-url"`
+	Issuer        string `json:"issuer"`
+	Audience      string `json:"audience"`
+	IdentityClaim string `json:"identity-claim"` // Defaults to "sub"
+}
+
+// Enabled reports whether bearer token authentication is configured.
+func (b BearerAuthConfig) Enabled() bool {
+	return b.JWKSURL != ""
+}
+
+// TracingConfig configures OpenTelemetry trace export over OTLP/HTTP.
+type TracingConfig struct {
+	Endpoint    string            `json:"otlp-endpoint"` // e.g. http://otel-collector:4318/v1/traces
+	Headers     map[string]string `json:"otlp-headers"`  // Extra headers, e.g. collector auth
+	ServiceName string            `json:"service-name"`  // Defaults to "maglev"
+	SampleRatio float64           `json:"sample-ratio"`  // Fraction of new traces to sample; defaults to 1
+}
+
+// Enabled reports whether an OTLP endpoint is configured.
+func (t TracingConfig) Enabled() bool {
+	return t.Endpoint != ""
+}
+
+// ErrorReportingConfig forwards server errors and panics to Sentry.
+type ErrorReportingConfig struct {
+	SentryDSN   string `json:"sentry-dsn"`  // e.g. https://<key>@o0.ingest.sentry.io/<project>
+	Environment string `json:"environment"` // Reported environment name, e.g. "production"
+	Release     string `json:"release"`     // Reported release, e.g. a version or commit
+}
+
+// Enabled reports whether a Sentry DSN is configured.
+func (e ErrorReportingConfig) Enabled() bool {
+	return e.SentryDSN != ""
+}
+
+// KeyRestriction scopes an API key to the browser origins or client networks it was issued
+// for. When both lists are set a request must satisfy both.
+type KeyRestriction struct {
+	AllowedOrigins []string `json:"allowed-origins"` // e.g. "https://example.com" or "https://*.example.com"
+	AllowedIPs     []string `json:"allowed-ips"`     // CIDR ranges or single addresses
+}
+
+// Default request limits, generous enough for every OneBusAway client request.
+const (
+	DefaultMaxURLLength   = 4096
+	DefaultMaxQueryParams = 50
+	DefaultMaxIDLength    = 100
+)
+
+// RequestLimitsConfig bounds the size of API requests. Requests over a limit are rejected with
+// a 400 before reaching the handler, so pathological input never reaches the search index or
+// database. A zero field disables that check.
+type RequestLimitsConfig struct {
+	MaxURLLength   int `json:"max-url-length"`   // Bytes in the request URI, including the query string
+	MaxQueryParams int `json:"max-query-params"` // Query parameter values, counting repeats
+	MaxIDLength    int `json:"max-id-length"`    // Characters in an {id} path segment
+}
+
+// CORSConfig controls the CORS headers sent to browsers. Requests from an origin that is not
+// allowed get none, so browsers keep the response from the page that made it.
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowed-origins"` // "*", or e.g. "https://example.com" or "https://*.example.com"
+	AllowedMethods []string `json:"allowed-methods"` // Sent in Access-Control-Allow-Methods
+	MaxAge         int      `json:"max-age"`         // Seconds browsers may cache a preflight response
+}
+
+// DefaultCORSConfig lets browser apps on any site make read-only requests.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodOptions},
+		MaxAge:         86400,
+	}
+}
+
+// Concurrency limit route groups. Each group covers endpoints that are expensive to serve.
+const (
+	ConcurrencyGroupSearch    = "search"    // search/stop, search/route, search/location
+	ConcurrencyGroupSchedules = "schedules" // schedule-for-stop, schedule-for-route
+	ConcurrencyGroupTrips     = "trips"     // trips-for-location, trips-for-route
+)
+
+// ConcurrencyGroups lists the route groups that accept a limit in ConcurrencyLimitsConfig.
+var ConcurrencyGroups = []string{
+	ConcurrencyGroupSearch,
+	ConcurrencyGroupSchedules,
+	ConcurrencyGroupTrips,
+}
+
+// ConcurrencyLimit caps the requests of one route group being served at once. Requests over
+// the cap wait up to MaxWait milliseconds for a slot and are then rejected with a 503.
+type ConcurrencyLimit struct {
+	MaxInFlight int `json:"max-in-flight"`
+	MaxWait     int `json:"max-wait"` // Milliseconds; 0 rejects excess requests immediately
+}
+
+// ConcurrencyLimitsConfig maps route groups to their limit. Groups not listed are unlimited.
+type ConcurrencyLimitsConfig map[string]ConcurrencyLimit
+
+// LoggingConfig controls the application log: its minimum level, format and destination.
+type LoggingConfig struct {
+	Level    string            `json:"level"`    // debug, info, warn or error; can be changed by a config reload
+	Format   string            `json:"format"`   // text or json
+	Output   string            `json:"output"`   // stdout, stderr, or the path of a log file
+	Rotation LogRotationConfig `json:"rotation"` // Applies when Output is a file
+}
+
+// ToFile reports whether logs are written to a file rather than a standard stream.
+func (l LoggingConfig) ToFile() bool {
+	return l.Output != "" && l.Output != "stdout" && l.Output != "stderr"
+}
+
+// LogRotationConfig rotates a log file by size, by age, or both. Rotated files are kept next
+// to the log file with a timestamp suffix.
+type LogRotationConfig struct {
+	MaxSize    int `json:"max-size"`    // Megabytes before the file is rotated; 0 disables size-based rotation
+	Interval   int `json:"interval"`    // Hours between rotations; 0 disables time-based rotation
+	MaxBackups int `json:"max-backups"` // Rotated files to keep; 0 keeps them all
+}
+
+// Pagination endpoint classes. Endpoints in a class share their page size settings.
+const (
+	PaginationClassLocation = "location" // stops-for-location, routes-for-location
+	PaginationClassSearch   = "search"   // search/stop, search/route
+	PaginationClassList     = "list"     // agencies-with-coverage, routes-for-agency, vehicles-for-agency
+)
+
+// PaginationClasses lists the endpoint classes that accept limits in PaginationConfig.
+var PaginationClasses = []string{
+	PaginationClassLocation,
+	PaginationClassSearch,
+	PaginationClassList,
+}
+
+// PaginationLimits sets the page size of an endpoint class. A zero value keeps the
+// endpoint's built-in default or maximum.
+type PaginationLimits struct {
+	DefaultCount int `json:"default-count"`  // Results returned when a request has no maxCount
+	MaxCount     int `json:"max-count"`      // Largest maxCount a request may ask for
+	BulkMaxCount int `json:"bulk-max-count"` // Largest maxCount a request with a bulk API key may ask for
+}
+
+// PaginationConfig maps endpoint classes to their page size limits.
+type PaginationConfig map[string]PaginationLimits
+
+// ShutdownConfig controls graceful shutdown. On SIGINT/SIGTERM the server first keeps serving
+// for DrainDelay while readiness checks fail, then stops accepting connections and waits up to
+// Timeout for in-flight requests before closing the remaining connections.
+type ShutdownConfig struct {
+	Timeout    int `json:"timeout"`     // Seconds to wait for in-flight requests; defaults to 30
+	DrainDelay int `json:"drain-delay"` // Seconds to keep serving while /readyz reports draining
+}
+
+// FakeTimeConfig runs the server on a simulated clock that starts at Start and advances Speed
+// times faster than real time, for exercising schedule boundaries, DST transitions and
+// service dates against real data. It is refused in production.
+type FakeTimeConfig struct {
+	Start string  `json:"start"` // RFC3339, or YYYY-MM-DD[ HH:MM:SS] in the server's local time zone
+	Speed float64 `json:"speed"` // Simulated seconds per real second; 0 means 1
+}
+
+// Enabled reports whether a simulated clock is configured.
+func (f FakeTimeConfig) Enabled() bool {
+	return f.Start != ""
+}
+
+// StartTime parses Start.
+func (f FakeTimeConfig) StartTime() (time.Time, error) {
+	start, err := clock.ParseTime(f.Start, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fake-time.start: %w", err)
+	}
+	return start, nil
+}
+
+// TLSConfig lets the server terminate TLS itself, either with a certificate and key on disk
+// or with certificates obtained automatically from Let's Encrypt for AutocertDomains.
+type TLSConfig struct {
+	CertFile         string   `json:"cert-file"`
+	KeyFile          string   `json:"key-file"`
+	AutocertDomains  []string `json:"autocert-domains"`   // Hostnames to request certificates for
+	AutocertCacheDir string   `json:"autocert-cache-dir"` // Where issued certificates and the account key are kept
+	AutocertEmail    string   `json:"autocert-email"`     // Optional contact address for the ACME account
+	RedirectPort     int      `json:"http-redirect-port"` // Plain HTTP port redirecting to HTTPS (and answering ACME challenges); 0 disables
+}
+
+// Enabled reports whether the server should serve HTTPS.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.Autocert()
+}
+
+// Autocert reports whether certificates are obtained automatically via ACME.
+func (t TLSConfig) Autocert() bool {
+	return len(t.AutocertDomains) > 0
+}
+
+// Response cache route groups. Each group covers endpoints serving the same kind of static data.
+const (
+	ResponseCacheGroupAgencies = "agencies"
+	ResponseCacheGroupRoutes   = "routes"
+	ResponseCacheGroupStops    = "stops"
+	ResponseCacheGroupShapes   = "shapes"
+)
+
+// ResponseCacheGroups lists the route groups that accept a TTL in ResponseCacheConfig.
+var ResponseCacheGroups = []string{
+	ResponseCacheGroupAgencies,
+	ResponseCacheGroupRoutes,
+	ResponseCacheGroupStops,
+	ResponseCacheGroupShapes,
+}
+
+// ResponseCacheConfig enables an in-memory cache of rendered responses for static-data
+// endpoints. Entries are dropped whenever a new static GTFS dataset is swapped in.
+type ResponseCacheConfig struct {
+	MaxEntries int            `json:"max-entries"` // 0 disables the cache
+	TTLs       map[string]int `json:"ttls"`        // Route group -> seconds; groups not listed use the default TTL, 0 disables a group
+}
+
+// Enabled reports whether the response cache may hold any entries.
+func (c ResponseCacheConfig) Enabled() bool {
+	return c.MaxEntries > 0
 }
 
 // Environment is an enumerated type representing various stages or configurations in the system's lifecycle.
@@ -36,3 +372,164 @@ func EnvFlagToEnvironment(envFlag string) Environment {
 		return Development
 	}
 }
+
+// ParseLogLevel converts a logging.level setting into a slog level. An empty level means info.
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch level {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("level must be one of [debug, info, warn, error], got %q", level)
+	}
+}
+
+// GBFSConfig polls GBFS (General Bikeshare Feed Specification) feeds, so bikeshare stations
+// and their availability are served alongside transit stops.
+type GBFSConfig struct {
+	Feeds           []GBFSFeed `json:"feeds"`
+	RefreshInterval int        `json:"refresh-interval"` // Seconds between station status polls; defaults to 60
+}
+
+// GBFSFeed is one bikeshare system.
+type GBFSFeed struct {
+	ID  string `json:"id"`  // Prefix of the system's station IDs, like an agency ID
+	URL string `json:"url"` // gbfs.json auto-discovery file
+}
+
+// Enabled reports whether any GBFS feed is configured.
+func (g GBFSConfig) Enabled() bool {
+	return len(g.Feeds) > 0
+}
+
+// Answers to requests for agencies, routes, stops and trips the feed does not have.
+const (
+	NotFoundConsistent = "consistent" // A 404 from every endpoint
+	NotFoundJava       = "java"       // What the Java server answers: null or an empty list from some list endpoints
+)
+
+// JavaNotFound reports whether unknown IDs are answered as the Java server answers them. That is
+// so when not-found-responses is "java", and always with java-parity, which wins over
+// not-found-responses "consistent".
+func (c Config) JavaNotFound() bool {
+	return c.NotFoundResponses == NotFoundJava || c.JavaParity
+}
+
+// Geocoder providers.
+const (
+	GeocoderPelias    = "pelias"
+	GeocoderNominatim = "nominatim"
+)
+
+// GeocoderConfig points location search at an external geocoder, which resolves addresses and
+// landmarks to the coordinates the stops near them are found from.
+type GeocoderConfig struct {
+	Provider string `json:"provider"` // pelias or nominatim; defaults to pelias
+	URL      string `json:"url"`      // Base URL of the geocoder; location search is disabled when empty
+	APIKey   string `json:"api-key"`  // Sent to Pelias as api_key, when set
+	Radius   int    `json:"radius"`   // Meters around each place to find stops in; defaults to 400
+}
+
+// Enabled reports whether a geocoder is configured.
+func (g GeocoderConfig) Enabled() bool {
+	return g.URL != ""
+}
+
+// NotificationsConfig enables arrival notification subscriptions: clients register a trip and
+// a stop and are called back when the trip's predicted arrival comes within a lead time.
+type NotificationsConfig struct {
+	Enabled            bool `json:"enabled"`
+	MaxPerKey          int  `json:"max-per-key"`         // Active subscriptions one API key may hold; defaults to 100
+	EvaluationInterval int  `json:"evaluation-interval"` // Seconds between checks against predictions; defaults to 15
+}
+
+// SnapshotUploadConfig uploads the GTFS database and its metadata to S3-compatible object
+// storage after every import, so read-only replicas can pull a pre-built dataset. Requests
+// are signed with AWS Signature Version 4, which AWS S3, Google Cloud Storage (with HMAC
+// keys), MinIO and Cloudflare R2 accept.
+type SnapshotUploadConfig struct {
+	Endpoint            string `json:"endpoint"` // e.g. https://s3.us-east-1.amazonaws.com or https://storage.googleapis.com
+	Bucket              string `json:"bucket"`
+	Prefix              string `json:"prefix"` // Prepended to the object keys, e.g. "production/"
+	Region              string `json:"region"` // Signing region; defaults to us-east-1
+	AccessKeyID         string `json:"access-key-id"`
+	SecretAccessKey     string `json:"secret-access-key"`
+	SecretAccessKeyFile string `json:"secret-access-key-file"` // Read SecretAccessKey from this file
+}
+
+// Enabled reports whether a bucket is configured.
+func (s SnapshotUploadConfig) Enabled() bool {
+	return s.Bucket != ""
+}
+
+// EventPublishingConfig publishes every GTFS-RT refresh to a message broker as normalized
+// vehicle position, trip update and alert events, so downstream pipelines need not poll the API.
+type EventPublishingConfig struct {
+	Broker       string `json:"broker"`        // "nats" or "kafka"; empty disables publishing
+	URL          string `json:"url"`           // nats:// or tls:// server, or kafka:// or kafka+tls:// bootstrap brokers
+	TopicPrefix  string `json:"topic-prefix"`  // Prepended to the topic names; defaults to "gtfs-rt."
+	Username     string `json:"username"`      // NATS user, or Kafka SASL/PLAIN user
+	Password     string `json:"password"`      // With no username, sent to NATS as a token
+	PasswordFile string `json:"password-file"` // Read Password from this file
+}
+
+// Enabled reports whether a broker is configured.
+func (e EventPublishingConfig) Enabled() bool {
+	return e.Broker != ""
+}
+
+// AlertWebhookConfig is an endpoint that new and changed service alerts are posted to as they
+// are ingested.
+type AlertWebhookConfig struct {
+	URL        string `json:"url"`
+	Secret     string `json:"secret"`      // Signs each delivery with HMAC-SHA256; empty sends them unsigned
+	SecretFile string `json:"secret-file"` // Read Secret from this file
+}
+
+// FeedRegistryConfig names the feeds by their ID in a feed registry, which supplies the static
+// and realtime URLs. The registry is checked again periodically, so a feed whose URL moves is
+// followed without a config change. Configured feed URLs are used until the registry answers.
+type FeedRegistryConfig struct {
+	Provider        string   `json:"provider"`          // "mobility-database" or "transitland"; empty disables the registry
+	StaticFeedID    string   `json:"static-feed-id"`    // e.g. "mdb-1210" or "f-9q9-bart"
+	RealtimeFeedIDs []string `json:"realtime-feed-ids"` // GTFS-RT feed IDs; the Mobility Database finds the static feed's own when empty
+	Token           string   `json:"token"`             // Mobility Database refresh token, or Transitland API key
+	TokenFile       string   `json:"token-file"`        // Read Token from this file
+	CheckInterval   int      `json:"check-interval"`    // Seconds between checks for moved URLs; defaults to 3600
+	APIURL          string   `json:"api-url"`           // Overrides the provider's API base URL
+}
+
+// Enabled reports whether a registry provider is configured.
+func (r FeedRegistryConfig) Enabled() bool {
+	return r.Provider != ""
+}
+
+// ArrivalArchiveConfig records when vehicles actually reached their stops, as reported by the
+// trip updates feed, in a SQLite file, for on-time performance and headway reporting.
+type ArrivalArchiveConfig struct {
+	DataPath        string `json:"data-path"`        // The archive is disabled when empty
+	RetentionDays   int    `json:"retention-days"`   // Arrivals older than this are deleted; defaults to 365
+	EarlyThreshold  int    `json:"early-threshold"`  // Seconds ahead of schedule an arrival counts as early; defaults to 60
+	LateThreshold   int    `json:"late-threshold"`   // Seconds behind schedule an arrival counts as late; defaults to 300
+	FrequentHeadway int    `json:"frequent-headway"` // Longest scheduled headway, in seconds, the headway metrics cover; defaults to 900
+}
+
+// Enabled reports whether arrivals are archived.
+func (a ArrivalArchiveConfig) Enabled() bool {
+	return a.DataPath != ""
+}
+
+// RidershipConfig stores imported GTFS-ride passenger counts in a SQLite file.
+type RidershipConfig struct {
+	DataPath string `json:"data-path"` // Ridership is disabled when empty
+}
+
+// Enabled reports whether ridership can be imported and reported.
+func (r RidershipConfig) Enabled() bool {
+	return r.DataPath != ""
+}
@@ -0,0 +1,125 @@
+package appconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configFormat names the format of a config file from its extension: "yaml" for .yaml and
+// .yml, "toml" for .toml, and "json" for anything else.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	return "json"
+}
+
+// decodeConfigLayer decodes a config file into the maps, slices and json.Number values of a
+// decoded JSON file, whatever its format, so every format shares the migration, merge,
+// defaults and validation of JSON files.
+func decodeConfigLayer(path string, data []byte) (map[string]any, error) {
+	format := configFormat(path)
+	if format != "json" {
+		var document any
+		var err error
+		if format == "yaml" {
+			var node yaml.Node
+			if err = yaml.Unmarshal(data, &node); err == nil {
+				document, err = yamlValue(&node)
+			}
+		} else {
+			document, err = tomlValue(data)
+		}
+		if err == nil && document == nil {
+			// An empty file, which a JSON file cannot be
+			document = map[string]any{}
+		}
+		if err == nil {
+			// Round trip through JSON so numbers become json.Number as in a JSON file
+			data, err = json.Marshal(document)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s config %s: %w", strings.ToUpper(format), path, err)
+		}
+	}
+
+	var layer map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep integers exact through the merge
+	if err := decoder.Decode(&layer); err != nil {
+		return nil, fmt.Errorf("failed to parse %s config %s: %w", strings.ToUpper(format), path, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("failed to parse %s config %s: unexpected data after the top-level value", strings.ToUpper(format), path)
+	}
+	return layer, nil
+}
+
+// yamlValue converts a YAML node to the value encoding/json would decode from the same
+// document. Timestamps, which YAML does not require quoting, are kept as written: a setting
+// taking a time reads it itself, and a date without a zone means local time there, not UTC.
+func yamlValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[node.Content[i].Value] = value
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	}
+	if node.ShortTag() == "!!timestamp" {
+		return node.Value, nil
+	}
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil, fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return value, nil
+}
+
+// tomlValue decodes a TOML document into maps, slices and scalars. Local dates and times
+// encode to JSON as written in TOML, and datetimes with an offset as RFC 3339.
+func tomlValue(data []byte) (any, error) {
+	var document map[string]any
+	if err := toml.Unmarshal(data, &document); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, _ := decodeErr.Position()
+			return nil, fmt.Errorf("line %d: %w", row, err)
+		}
+		return nil, err
+	}
+	return document, nil
+}
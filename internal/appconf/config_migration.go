@@ -0,0 +1,123 @@
+package appconf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentConfigVersion is the config file layout this build reads. Files without a
+// config-version are treated as version 1 and migrated when loaded.
+//
+// Version 1 named a single feed with top level keys matching the command-line flags
+// (gtfs-url, trip-updates-url, ...) and set the log level with log-level. Version 2 moved
+// them into gtfs-static-feed, gtfs-rt-feeds and logging.
+const CurrentConfigVersion = 2
+
+// configMigrations upgrade a decoded config file from the version they are keyed by to the
+// next one, reporting whether the file used anything that had to move.
+var configMigrations = map[int]func(layer map[string]any) (bool, error){
+	1: migrateConfigV1,
+}
+
+// legacyStaticFeedKeys maps version 1 top level keys to their gtfs-static-feed keys
+var legacyStaticFeedKeys = map[string]string{
+	"gtfs-url":                      "url",
+	"gtfs-static-auth-header-name":  "auth-header-name",
+	"gtfs-static-auth-header-value": "auth-header-value",
+}
+
+// legacyRtFeedKeys are version 1 top level keys that now belong to a gtfs-rt-feeds entry
+var legacyRtFeedKeys = []string{
+	"trip-updates-url",
+	"vehicle-positions-url",
+	"service-alerts-url",
+	"realtime-auth-header-name",
+	"realtime-auth-header-value",
+}
+
+// configVersion returns the config-version of a decoded config file, 1 when it has none.
+func configVersion(layer map[string]any) (int, error) {
+	raw, ok := layer["config-version"]
+	if !ok || raw == nil {
+		return 1, nil
+	}
+	number, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("config-version must be an integer, got %v", raw)
+	}
+	version, err := number.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("config-version must be an integer, got %s", number)
+	}
+	if version < 1 || version > CurrentConfigVersion {
+		return 0, fmt.Errorf("config-version %d is not supported; this build reads versions 1 to %d", version, CurrentConfigVersion)
+	}
+	return int(version), nil
+}
+
+// migrateConfigLayer upgrades a decoded config file in place to CurrentConfigVersion. It
+// returns the version the file started from and whether any setting was moved, so files
+// that merely omit config-version are not reported as outdated.
+func migrateConfigLayer(layer map[string]any) (int, bool, error) {
+	from, err := configVersion(layer)
+	if err != nil {
+		return 0, false, err
+	}
+	migrated := false
+	for version := from; version < CurrentConfigVersion; version++ {
+		changed, err := configMigrations[version](layer)
+		if err != nil {
+			return 0, false, fmt.Errorf("migrating config-version %d: %w", version, err)
+		}
+		migrated = migrated || changed
+	}
+	layer["config-version"] = json.Number(fmt.Sprint(CurrentConfigVersion))
+	return from, migrated, nil
+}
+
+// migrateConfigV1 moves the single-feed and log-level keys into their nested objects. A file
+// that mixes both layouts for the same setting is rejected rather than guessed at.
+func migrateConfigV1(layer map[string]any) (bool, error) {
+	staticFeed := map[string]any{}
+	for oldKey, newKey := range legacyStaticFeedKeys {
+		if value, ok := layer[oldKey]; ok {
+			staticFeed[newKey] = value
+			delete(layer, oldKey)
+		}
+	}
+	if len(staticFeed) > 0 {
+		if _, ok := layer["gtfs-static-feed"]; ok {
+			return false, fmt.Errorf("gtfs-url and gtfs-static-feed cannot both be set; keep gtfs-static-feed")
+		}
+		layer["gtfs-static-feed"] = staticFeed
+	}
+
+	rtFeed := map[string]any{}
+	for _, key := range legacyRtFeedKeys {
+		if value, ok := layer[key]; ok {
+			rtFeed[key] = value
+			delete(layer, key)
+		}
+	}
+	if len(rtFeed) > 0 {
+		if _, ok := layer["gtfs-rt-feeds"]; ok {
+			return false, fmt.Errorf("trip-updates-url and gtfs-rt-feeds cannot both be set; keep gtfs-rt-feeds")
+		}
+		layer["gtfs-rt-feeds"] = []any{rtFeed}
+	}
+
+	level, hasLevel := layer["log-level"]
+	if hasLevel {
+		delete(layer, "log-level")
+		logging, _ := layer["logging"].(map[string]any)
+		if logging == nil {
+			logging = map[string]any{}
+		}
+		if _, ok := logging["level"]; ok {
+			return false, fmt.Errorf("log-level and logging.level cannot both be set; keep logging.level")
+		}
+		logging["level"] = level
+		layer["logging"] = logging
+	}
+	return len(staticFeed) > 0 || len(rtFeed) > 0 || hasLevel, nil
+}
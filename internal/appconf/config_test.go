@@ -1,8 +1,10 @@
 package appconf
 
 import (
-	"github.com/stretchr/testify/assert"
+	"log/slog"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestEnvFlagToEnvironment(t *testing.T) {
@@ -62,3 +64,26 @@ func TestEnvironmentConstants(t *testing.T) {
 	assert.Equal(t, Environment(1), Test)
 	assert.Equal(t, Environment(2), Production)
 }
+
+func TestParseLogLevel(t *testing.T) {
+	for level, expected := range map[string]slog.Level{
+		"":      slog.LevelInfo,
+		"debug": slog.LevelDebug,
+		"info":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		parsed, err := ParseLogLevel(level)
+		assert.NoError(t, err, level)
+		assert.Equal(t, expected, parsed, level)
+	}
+
+	_, err := ParseLogLevel("verbose")
+	assert.ErrorContains(t, err, "level must be one of")
+}
+
+func TestJavaNotFound(t *testing.T) {
+	assert.False(t, Config{NotFoundResponses: NotFoundConsistent}.JavaNotFound())
+	assert.True(t, Config{NotFoundResponses: NotFoundJava}.JavaNotFound())
+	assert.True(t, Config{NotFoundResponses: NotFoundConsistent, JavaParity: true}.JavaNotFound(), "java-parity wins")
+}
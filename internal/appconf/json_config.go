@@ -4,38 +4,94 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
 // GtfsStaticFeed represents the static GTFS feed configuration
 type GtfsStaticFeed struct {
-	URL             string `json:"url"`
-	AuthHeaderName  string `json:"auth-header-name"`
-	AuthHeaderValue string `json:"auth-header-value"`
-	EnableGTFSTidy  bool   `json:"enable-gtfs-tidy"`
+	URL                 string `json:"url"`
+	AuthHeaderName      string `json:"auth-header-name"`
+	AuthHeaderValue     string `json:"auth-header-value"`
+	AuthHeaderValueFile string `json:"auth-header-value-file"` // Read AuthHeaderValue from this file
+	EnableGTFSTidy      bool   `json:"enable-gtfs-tidy"`
+	IDPrefix            string `json:"id-prefix"` // Prepended to the feed's IDs but its agency IDs, keeping them apart from other feeds'
 }
 
 // GtfsRtFeed represents a single GTFS-RT feed configuration
 type GtfsRtFeed struct {
-	TripUpdatesURL          string `json:"trip-updates-url"`
-	VehiclePositionsURL     string `json:"vehicle-positions-url"`
-	ServiceAlertsURL        string `json:"service-alerts-url"`
-	RealTimeAuthHeaderName  string `json:"realtime-auth-header-name"`
-	RealTimeAuthHeaderValue string `json:"realtime-auth-header-value"`
+	TripUpdatesURL              string `json:"trip-updates-url"`
+	VehiclePositionsURL         string `json:"vehicle-positions-url"`
+	ServiceAlertsURL            string `json:"service-alerts-url"`
+	RealTimeAuthHeaderName      string `json:"realtime-auth-header-name"`
+	RealTimeAuthHeaderValue     string `json:"realtime-auth-header-value"`
+	RealTimeAuthHeaderValueFile string `json:"realtime-auth-header-value-file"` // Read RealTimeAuthHeaderValue from this file
+	AgencyID                    string `json:"agency-id"`                       // The agency whose vehicles the feed carries, if it carries one agency's
+	IDPrefix                    string `json:"id-prefix"`                       // The id-prefix of the static feed the feed refers to
 }
 
 // JSONConfig represents the JSON configuration file structure
 type JSONConfig struct {
-	Port           int            `json:"port"`
-	Env            string         `json:"env"`
-	ApiKeys        []string       `json:"api-keys"`
-	ExemptApiKeys  []string       `json:"exempt-api-keys"`
-	RateLimit      int            `json:"rate-limit"`
-	GtfsStaticFeed GtfsStaticFeed `json:"gtfs-static-feed"`
-	GtfsRtFeeds    []GtfsRtFeed   `json:"gtfs-rt-feeds"`
-	DataPath       string         `json:"data-path"`
+	ConfigVersion           int                       `json:"config-version"`
+	Port                    int                       `json:"port"`
+	Env                     string                    `json:"env"`
+	ApiKeys                 []string                  `json:"api-keys"`
+	ApiKeysFile             string                    `json:"api-keys-file"` // One key per line or comma separated
+	ExemptApiKeys           []string                  `json:"exempt-api-keys"`
+	AdminApiKeys            []string                  `json:"admin-api-keys"`
+	AdminApiKeysFile        string                    `json:"admin-api-keys-file"`
+	BulkApiKeys             []string                  `json:"bulk-api-keys"`
+	AdminPort               int                       `json:"admin-port"`
+	GRPCPort                int                       `json:"grpc-port"`
+	UnixSocket              string                    `json:"unix-socket"`
+	AuditLogPath            string                    `json:"audit-log-path"`
+	BlocklistPath           string                    `json:"blocklist-path"`
+	DetoursPath             string                    `json:"detours-path"`
+	CrowdingPredictor       string                    `json:"crowding-predictor"`
+	SearchPopularityWeight  float64                   `json:"search-popularity-weight"`
+	NotFoundResponses       string                    `json:"not-found-responses"`
+	JavaParity              bool                      `json:"java-parity"`
+	CanonicalJSON           bool                      `json:"canonical-json"`
+	GraphQL                 bool                      `json:"graphql"`
+	RateLimit               int                       `json:"rate-limit"`
+	RateLimitExemptPaths    []string                  `json:"rate-limit-exempt-paths"`
+	Logging                 LoggingConfig             `json:"logging"`
+	ConfigWatchInterval     int                       `json:"config-watch-interval"` // Seconds; 0 disables watching
+	KeyRestrictions         map[string]KeyRestriction `json:"key-restrictions"`
+	GtfsStaticFeed          GtfsStaticFeed            `json:"gtfs-static-feed"`
+	GtfsStaticFeeds         []GtfsStaticFeed          `json:"gtfs-static-feeds"` // Merged into one dataset; replaces gtfs-static-feed
+	GtfsRtFeeds             []GtfsRtFeed              `json:"gtfs-rt-feeds"`
+	DataPath                string                    `json:"data-path"`
+	Quotas                  QuotaConfig               `json:"quotas"`
+	Analytics               AnalyticsConfig           `json:"analytics"`
+	SignedRequests          SignedRequestConfig       `json:"signed-requests"`
+	BearerAuth              BearerAuthConfig          `json:"bearer-auth"`
+	Tracing                 TracingConfig             `json:"tracing"`
+	ErrorReporting          ErrorReportingConfig      `json:"error-reporting"`
+	RealtimeStalenessBudget int                       `json:"realtime-staleness-budget"` // Seconds without a GTFS-RT refresh before /readyz fails
+	StaticRefreshInterval   int                       `json:"static-refresh-interval"`   // Seconds between re-downloads of the static feeds
+	ResponseCache           ResponseCacheConfig       `json:"response-cache"`
+	RequestLimits           RequestLimitsConfig       `json:"request-limits"`
+	CORS                    CORSConfig                `json:"cors"`
+	ConcurrencyLimits       ConcurrencyLimitsConfig   `json:"concurrency-limits"`
+	Pagination              PaginationConfig          `json:"pagination"`
+	TLS                     TLSConfig                 `json:"tls"`
+	Shutdown                ShutdownConfig            `json:"shutdown"`
+	FakeTime                FakeTimeConfig            `json:"fake-time"`
+	GBFS                    GBFSConfig                `json:"gbfs"`
+	Geocoder                GeocoderConfig            `json:"geocoder"`
+	Notifications           NotificationsConfig       `json:"notifications"`
+	SnapshotUpload          SnapshotUploadConfig      `json:"snapshot-upload"`
+	EventPublishing         EventPublishingConfig     `json:"event-publishing"`
+	AlertWebhooks           []AlertWebhookConfig      `json:"alert-webhooks"`
+	FeedRegistry            FeedRegistryConfig        `json:"feed-registry"`
+	ArrivalArchive          ArrivalArchiveConfig      `json:"arrival-archive"`
+	Ridership               RidershipConfig           `json:"ridership"`
 }
 
 // setDefaults applies default values to the JSON config if fields are missing or zero
@@ -55,10 +111,27 @@ func (j *JSONConfig) setDefaults() {
 	if j.RateLimit == 0 {
 		j.RateLimit = 100
 	}
-	if j.GtfsStaticFeed.URL == "" {
+	if j.Logging.Level == "" {
+		j.Logging.Level = "info"
+	}
+	if j.Logging.Format == "" {
+		j.Logging.Format = "text"
+	}
+	if j.Logging.Output == "" {
+		j.Logging.Output = "stdout"
+	}
+	if j.RealtimeStalenessBudget == 0 {
+		j.RealtimeStalenessBudget = 300
+	}
+	if j.StaticRefreshInterval == 0 {
+		j.StaticRefreshInterval = 86400
+	}
+	// The Sound Transit feeds are a development convenience; production must name its own, and
+	// a registry's feeds must not fall back to another region's
+	if j.Env != "production" && !j.FeedRegistry.Enabled() && j.GtfsStaticFeed.URL == "" && len(j.GtfsStaticFeeds) == 0 {
 		j.GtfsStaticFeed.URL = "https://www.soundtransit.org/GTFS-rail/40_gtfs.zip"
 	}
-	if len(j.GtfsRtFeeds) == 0 {
+	if j.Env != "production" && !j.FeedRegistry.Enabled() && len(j.GtfsRtFeeds) == 0 {
 		j.GtfsRtFeeds = []GtfsRtFeed{
 			{
 				TripUpdatesURL:      "https://api.pugetsound.onebusaway.org/api/gtfs_realtime/trip-updates-for-agency/40.pb?key=org.onebusaway.iphone",
@@ -69,10 +142,93 @@ func (j *JSONConfig) setDefaults() {
 	if j.DataPath == "" {
 		j.DataPath = "./gtfs.db"
 	}
+	if j.Quotas.Enabled() && j.Quotas.DataPath == "" {
+		j.Quotas.DataPath = "./quota.db"
+	}
+	if j.Analytics.Enabled() && j.Analytics.RetentionDays == 0 {
+		j.Analytics.RetentionDays = 90
+	}
+	if j.GBFS.Enabled() && j.GBFS.RefreshInterval == 0 {
+		j.GBFS.RefreshInterval = 60
+	}
+	if j.NotFoundResponses == "" {
+		j.NotFoundResponses = NotFoundConsistent
+	}
+	if j.Geocoder.Enabled() {
+		if j.Geocoder.Provider == "" {
+			j.Geocoder.Provider = GeocoderPelias
+		}
+		if j.Geocoder.Radius == 0 {
+			j.Geocoder.Radius = 400
+		}
+	}
+	if j.Notifications.Enabled {
+		if j.Notifications.MaxPerKey == 0 {
+			j.Notifications.MaxPerKey = 100
+		}
+		if j.Notifications.EvaluationInterval == 0 {
+			j.Notifications.EvaluationInterval = 15
+		}
+	}
+	if j.SnapshotUpload.Enabled() && j.SnapshotUpload.Region == "" {
+		j.SnapshotUpload.Region = "us-east-1"
+	}
+	if j.EventPublishing.Enabled() && j.EventPublishing.TopicPrefix == "" {
+		j.EventPublishing.TopicPrefix = "gtfs-rt."
+	}
+	if j.FeedRegistry.Enabled() && j.FeedRegistry.CheckInterval == 0 {
+		j.FeedRegistry.CheckInterval = 3600
+	}
+	if j.ArrivalArchive.Enabled() {
+		if j.ArrivalArchive.RetentionDays == 0 {
+			j.ArrivalArchive.RetentionDays = 365
+		}
+		if j.ArrivalArchive.EarlyThreshold == 0 {
+			j.ArrivalArchive.EarlyThreshold = 60
+		}
+		if j.ArrivalArchive.LateThreshold == 0 {
+			j.ArrivalArchive.LateThreshold = 300
+		}
+		if j.ArrivalArchive.FrequentHeadway == 0 {
+			j.ArrivalArchive.FrequentHeadway = 900
+		}
+	}
+	if j.Shutdown.Timeout == 0 {
+		j.Shutdown.Timeout = 30
+	}
+	if j.TLS.Autocert() && j.TLS.AutocertCacheDir == "" {
+		j.TLS.AutocertCacheDir = "./autocert-cache"
+	}
+	if j.SignedRequests.MaxClockSkew == 0 {
+		j.SignedRequests.MaxClockSkew = 300
+	}
+	if j.RequestLimits.MaxURLLength == 0 {
+		j.RequestLimits.MaxURLLength = DefaultMaxURLLength
+	}
+	if j.RequestLimits.MaxQueryParams == 0 {
+		j.RequestLimits.MaxQueryParams = DefaultMaxQueryParams
+	}
+	if j.RequestLimits.MaxIDLength == 0 {
+		j.RequestLimits.MaxIDLength = DefaultMaxIDLength
+	}
+	defaultCORS := DefaultCORSConfig()
+	if j.CORS.AllowedOrigins == nil {
+		j.CORS.AllowedOrigins = defaultCORS.AllowedOrigins
+	}
+	if j.CORS.AllowedMethods == nil {
+		j.CORS.AllowedMethods = defaultCORS.AllowedMethods
+	}
+	if j.CORS.MaxAge == 0 {
+		j.CORS.MaxAge = defaultCORS.MaxAge
+	}
 }
 
 // validate checks that the configuration is valid
 func (j *JSONConfig) validate() error {
+	if j.ConfigVersion != 0 && j.ConfigVersion != CurrentConfigVersion {
+		return fmt.Errorf("config-version %d is not supported; this build reads versions 1 to %d", j.ConfigVersion, CurrentConfigVersion)
+	}
+
 	if j.Port < 1 || j.Port > 65535 {
 		return fmt.Errorf("port must be between 1 and 65535, got %d", j.Port)
 	}
@@ -86,10 +242,43 @@ func (j *JSONConfig) validate() error {
 		return fmt.Errorf("env must be one of [development, test, production], got %q", j.Env)
 	}
 
+	if j.Env == "production" && !j.FeedRegistry.Enabled() {
+		if err := j.validateProductionFeeds(); err != nil {
+			return err
+		}
+	}
+
 	if j.RateLimit < 1 {
 		return fmt.Errorf("rate-limit must be at least 1, got %d", j.RateLimit)
 	}
 
+	for _, path := range j.RateLimitExemptPaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("rate-limit-exempt-paths entries must start with '/', got %q", path)
+		}
+		if strings.Contains(strings.TrimSuffix(path, "*"), "*") {
+			return fmt.Errorf("rate-limit-exempt-paths entries may only end with '*', got %q", path)
+		}
+	}
+
+	if err := j.Logging.validate(); err != nil {
+		return err
+	}
+
+	if j.ConfigWatchInterval < 0 {
+		return fmt.Errorf("config-watch-interval cannot be negative, got %d", j.ConfigWatchInterval)
+	}
+
+	if j.RealtimeStalenessBudget < 0 {
+		return fmt.Errorf("realtime-staleness-budget cannot be negative, got %d", j.RealtimeStalenessBudget)
+	}
+
+	// Each refresh downloads and parses the whole feed, so a refresh every few seconds would
+	// keep the server busy importing
+	if j.StaticRefreshInterval != 0 && j.StaticRefreshInterval < 60 {
+		return fmt.Errorf("static-refresh-interval must be at least 60 seconds, got %d", j.StaticRefreshInterval)
+	}
+
 	if len(j.ApiKeys) == 0 {
 		return fmt.Errorf("api-keys cannot be empty")
 	}
@@ -106,36 +295,584 @@ func (j *JSONConfig) validate() error {
 		seen[key] = true
 	}
 
+	for _, key := range j.AdminApiKeys {
+		if key == "" {
+			return fmt.Errorf("admin-api-keys cannot contain empty strings")
+		}
+	}
+
+	for _, key := range j.BulkApiKeys {
+		if key == "" {
+			return fmt.Errorf("bulk-api-keys cannot contain empty strings")
+		}
+	}
+
+	if j.AdminPort < 0 || j.AdminPort > 65535 {
+		return fmt.Errorf("admin-port must be between 1 and 65535 (or 0 to disable), got %d", j.AdminPort)
+	}
+	if j.AdminPort != 0 && j.AdminPort == j.Port {
+		return fmt.Errorf("admin-port must differ from port")
+	}
+
+	if j.GRPCPort < 0 || j.GRPCPort > 65535 {
+		return fmt.Errorf("grpc-port must be between 1 and 65535 (or 0 to disable), got %d", j.GRPCPort)
+	}
+	if j.GRPCPort != 0 && (j.GRPCPort == j.Port || j.GRPCPort == j.AdminPort) {
+		return fmt.Errorf("grpc-port must differ from port and admin-port")
+	}
+
 	// Validate DataPath for path traversal attempts
 	if err := validatePath(j.DataPath, "data-path"); err != nil {
 		return err
 	}
 
+	if err := validateUnixSocket(j.UnixSocket); err != nil {
+		return err
+	}
+
+	if err := validatePath(j.AuditLogPath, "audit-log-path"); err != nil {
+		return err
+	}
+
+	if err := validatePath(j.BlocklistPath, "blocklist-path"); err != nil {
+		return err
+	}
+
+	if err := validatePath(j.DetoursPath, "detours-path"); err != nil {
+		return err
+	}
+
+	if err := j.Quotas.validate(); err != nil {
+		return err
+	}
+
+	if err := validatePath(j.Analytics.DataPath, "analytics.data-path"); err != nil {
+		return err
+	}
+	if j.Analytics.RetentionDays < 0 {
+		return fmt.Errorf("analytics.retention-days cannot be negative")
+	}
+
+	if err := j.SignedRequests.validate(); err != nil {
+		return err
+	}
+
+	if err := j.BearerAuth.validate(); err != nil {
+		return err
+	}
+
+	if err := j.Tracing.validate(); err != nil {
+		return err
+	}
+
+	if err := j.ErrorReporting.validate(); err != nil {
+		return err
+	}
+
+	if err := j.ResponseCache.validate(); err != nil {
+		return err
+	}
+
+	if err := j.RequestLimits.validate(); err != nil {
+		return err
+	}
+
+	if err := j.CORS.validate(); err != nil {
+		return err
+	}
+
+	if err := j.ConcurrencyLimits.validate(); err != nil {
+		return err
+	}
+
+	if err := j.Pagination.validate(); err != nil {
+		return err
+	}
+
+	for key, restriction := range j.KeyRestrictions {
+		if err := restriction.validate(key); err != nil {
+			return err
+		}
+	}
+
+	if j.Shutdown.Timeout < 0 || j.Shutdown.DrainDelay < 0 {
+		return fmt.Errorf("shutdown.timeout and shutdown.drain-delay cannot be negative")
+	}
+
+	if err := j.FakeTime.validate(j.Env); err != nil {
+		return err
+	}
+
+	if err := j.GBFS.validate(); err != nil {
+		return err
+	}
+
+	if err := j.Geocoder.validate(); err != nil {
+		return err
+	}
+
+	if j.SearchPopularityWeight < 0 {
+		return fmt.Errorf("search-popularity-weight cannot be negative")
+	}
+
+	if j.NotFoundResponses != "" && j.NotFoundResponses != NotFoundConsistent && j.NotFoundResponses != NotFoundJava {
+		return fmt.Errorf("not-found-responses must be %q or %q", NotFoundConsistent, NotFoundJava)
+	}
+
+	if j.Notifications.MaxPerKey < 0 {
+		return fmt.Errorf("notifications.max-per-key cannot be negative")
+	}
+	if j.Notifications.EvaluationInterval < 0 {
+		return fmt.Errorf("notifications.evaluation-interval cannot be negative")
+	}
+
+	if err := j.SnapshotUpload.validate(); err != nil {
+		return err
+	}
+
+	if err := j.EventPublishing.validate(); err != nil {
+		return err
+	}
+
+	for i, webhook := range j.AlertWebhooks {
+		if err := webhook.validate(i); err != nil {
+			return err
+		}
+	}
+
+	if err := j.FeedRegistry.validate(); err != nil {
+		return err
+	}
+
+	if err := j.ArrivalArchive.validate(); err != nil {
+		return err
+	}
+
+	if err := validatePath(j.Ridership.DataPath, "ridership.data-path"); err != nil {
+		return err
+	}
+
+	if err := j.TLS.validate(); err != nil {
+		return err
+	}
+	if j.TLS.RedirectPort != 0 && (j.TLS.RedirectPort == j.Port || j.TLS.RedirectPort == j.AdminPort || j.TLS.RedirectPort == j.GRPCPort) {
+		return fmt.Errorf("tls.http-redirect-port must differ from port, admin-port and grpc-port")
+	}
+
+	if err := j.GtfsStaticFeed.validate("gtfs-static-feed"); err != nil {
+		return err
+	}
+	if len(j.GtfsStaticFeeds) > 0 && j.GtfsStaticFeed != (GtfsStaticFeed{}) {
+		return fmt.Errorf("gtfs-static-feed and gtfs-static-feeds cannot both be set")
+	}
+	for i, feed := range j.GtfsStaticFeeds {
+		name := fmt.Sprintf("gtfs-static-feeds[%d]", i)
+		if feed.URL == "" {
+			return fmt.Errorf("%s.url is required", name)
+		}
+		if err := feed.validate(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate checks that the auth header is set in full or not at all, and that the URL is an
+// HTTP(S) URL or a safe file path. name is the feed's key in the configuration file.
+func (f GtfsStaticFeed) validate(name string) error {
 	// Validate that both auth header fields are provided together or neither
-	if (j.GtfsStaticFeed.AuthHeaderName != "" && j.GtfsStaticFeed.AuthHeaderValue == "") ||
-		(j.GtfsStaticFeed.AuthHeaderName == "" && j.GtfsStaticFeed.AuthHeaderValue != "") {
-		return fmt.Errorf("both auth-header-name and auth-header-value must be provided together for gtfs-static-feed")
+	if (f.AuthHeaderName != "" && f.AuthHeaderValue == "") ||
+		(f.AuthHeaderName == "" && f.AuthHeaderValue != "") {
+		return fmt.Errorf("both auth-header-name and auth-header-value must be provided together for %s", name)
+	}
+	if strings.ContainsAny(f.IDPrefix, " \t\n/") {
+		return fmt.Errorf("%s.id-prefix cannot contain spaces or slashes", name)
+	}
+
+	// Validate the URL to prevent file:// URLs and other security issues
+	if f.URL == "" {
+		return nil
+	}
+	// Block file:// URLs (case-insensitive)
+	if strings.HasPrefix(strings.ToLower(f.URL), "file://") {
+		return fmt.Errorf("file:// URLs are not allowed for %s.url for security reasons", name)
+	}
+	// For HTTP(S) URLs, no path checks needed
+	if strings.HasPrefix(f.URL, "http://") || strings.HasPrefix(f.URL, "https://") {
+		return nil
+	}
+	// For file paths, validate for path traversal
+	return validatePath(f.URL, name+".url")
+}
+
+// validate checks that quota limits are non-negative and the counter store path is safe
+func (q QuotaConfig) validate() error {
+	if q.Default.Daily < 0 || q.Default.Monthly < 0 {
+		return fmt.Errorf("quotas.default limits cannot be negative")
 	}
+	for key, limits := range q.Keys {
+		if key == "" {
+			return fmt.Errorf("quotas.keys cannot contain an empty API key")
+		}
+		if limits.Daily < 0 || limits.Monthly < 0 {
+			return fmt.Errorf("quotas.keys[%q] limits cannot be negative", key)
+		}
+	}
+	return validatePath(q.DataPath, "quotas.data-path")
+}
 
-	// Validate GtfsStaticFeed.URL to prevent file:// URLs and other security issues
-	if j.GtfsStaticFeed.URL != "" {
-		// Block file:// URLs (case-insensitive)
-		if strings.HasPrefix(strings.ToLower(j.GtfsStaticFeed.URL), "file://") {
-			return fmt.Errorf("file:// URLs are not allowed for gtfs-static-feed.url for security reasons")
+// validate checks that every signing secret belongs to a key and the clock skew is usable
+func (s SignedRequestConfig) validate() error {
+	for key, secret := range s.Secrets {
+		if key == "" {
+			return fmt.Errorf("signed-requests.secrets cannot contain an empty API key")
 		}
+		if secret == "" {
+			return fmt.Errorf("signed-requests.secrets[%q] cannot be empty", key)
+		}
+	}
+	if s.MaxClockSkew < 0 {
+		return fmt.Errorf("signed-requests.max-clock-skew cannot be negative, got %d", s.MaxClockSkew)
+	}
+	return nil
+}
 
-		// For HTTP(S) URLs, no path checks needed
-		if strings.HasPrefix(j.GtfsStaticFeed.URL, "http://") ||
-			strings.HasPrefix(j.GtfsStaticFeed.URL, "https://") {
-			return nil
+// validate checks that the JWKS URL is an HTTP(S) URL and tokens are scoped to this service
+func (b BearerAuthConfig) validate() error {
+	if !b.Enabled() {
+		return nil
+	}
+	if !strings.HasPrefix(b.JWKSURL, "https://") && !strings.HasPrefix(b.JWKSURL, "http://") {
+		return fmt.Errorf("bearer-auth.jwks-url must be an http(s) URL")
+	}
+	// Without an audience, tokens issued to any application of the provider would be accepted
+	if b.Audience == "" {
+		return fmt.Errorf("bearer-auth.audience is required when bearer-auth.jwks-url is set")
+	}
+	return nil
+}
+
+// validate checks that the entry limit and TTLs are non-negative and every TTL names a known route group
+func (c ResponseCacheConfig) validate() error {
+	if c.MaxEntries < 0 {
+		return fmt.Errorf("response-cache.max-entries cannot be negative, got %d", c.MaxEntries)
+	}
+	for group, ttl := range c.TTLs {
+		if !slices.Contains(ResponseCacheGroups, group) {
+			return fmt.Errorf("response-cache.ttls has unknown route group %q (expected one of %s)", group, strings.Join(ResponseCacheGroups, ", "))
+		}
+		if ttl < 0 {
+			return fmt.Errorf("response-cache.ttls[%q] cannot be negative", group)
 		}
+	}
+	return nil
+}
 
-		// For file paths, validate for path traversal
-		if err := validatePath(j.GtfsStaticFeed.URL, "gtfs-static-feed.url"); err != nil {
+// validate checks that every origin is a scheme and host, optionally with a leading "*." wildcard,
+// and every IP entry is an address or CIDR range
+func (k KeyRestriction) validate(key string) error {
+	for _, origin := range k.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("key-restrictions[%q].allowed-origins: %q must be a scheme and host, e.g. https://*.example.com", key, origin)
+		}
+	}
+	for _, ip := range k.AllowedIPs {
+		if _, err := netip.ParsePrefix(ip); err != nil {
+			if _, err := netip.ParseAddr(ip); err != nil {
+				return fmt.Errorf("key-restrictions[%q].allowed-ips: %q is not an IP address or CIDR range", key, ip)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks that every limit names a known route group and allows at least one request
+func (c ConcurrencyLimitsConfig) validate() error {
+	for group, limit := range c {
+		if !slices.Contains(ConcurrencyGroups, group) {
+			return fmt.Errorf("concurrency-limits has unknown route group %q (expected one of %s)", group, strings.Join(ConcurrencyGroups, ", "))
+		}
+		if limit.MaxInFlight < 1 {
+			return fmt.Errorf("concurrency-limits[%q].max-in-flight must be at least 1, got %d", group, limit.MaxInFlight)
+		}
+		if limit.MaxWait < 0 {
+			return fmt.Errorf("concurrency-limits[%q].max-wait cannot be negative", group)
+		}
+	}
+	return nil
+}
+
+// validate checks the level and format, and that rotation is only configured for a log file
+func (l LoggingConfig) validate() error {
+	if _, err := ParseLogLevel(l.Level); err != nil {
+		return fmt.Errorf("logging.level: %w", err)
+	}
+	if l.Format != "" && l.Format != "text" && l.Format != "json" {
+		return fmt.Errorf("logging.format must be text or json, got %q", l.Format)
+	}
+	if l.ToFile() {
+		if err := validatePath(l.Output, "logging.output"); err != nil {
 			return err
 		}
+	} else if l.Rotation != (LogRotationConfig{}) {
+		return fmt.Errorf("logging.rotation requires logging.output to be a file path")
+	}
+	if l.Rotation.MaxSize < 0 || l.Rotation.Interval < 0 || l.Rotation.MaxBackups < 0 {
+		return fmt.Errorf("logging.rotation values cannot be negative")
+	}
+	return nil
+}
+
+// validate checks the simulated clock settings; a simulated clock is never allowed in production
+func (f FakeTimeConfig) validate(env string) error {
+	if !f.Enabled() {
+		if f.Speed != 0 {
+			return fmt.Errorf("fake-time.speed requires fake-time.start")
+		}
+		return nil
+	}
+	if env == "production" {
+		return fmt.Errorf("fake-time cannot be used in production")
+	}
+	if _, err := f.StartTime(); err != nil {
+		return err
+	}
+	if f.Speed < 0 {
+		return fmt.Errorf("fake-time.speed cannot be negative")
+	}
+	return nil
+}
+
+// validate checks that every feed has a unique ID usable in station IDs and an http(s) URL
+func (g GBFSConfig) validate() error {
+	seen := make(map[string]bool, len(g.Feeds))
+	for i, feed := range g.Feeds {
+		if feed.ID == "" || strings.Contains(feed.ID, "_") {
+			return fmt.Errorf("gbfs.feeds[%d].id is required and cannot contain an underscore", i)
+		}
+		if seen[feed.ID] {
+			return fmt.Errorf("gbfs.feeds[%d].id %q is used by another feed", i, feed.ID)
+		}
+		seen[feed.ID] = true
+		if !strings.HasPrefix(feed.URL, "https://") && !strings.HasPrefix(feed.URL, "http://") {
+			return fmt.Errorf("gbfs.feeds[%d].url must be an http(s) URL", i)
+		}
+	}
+	if g.RefreshInterval < 0 {
+		return fmt.Errorf("gbfs.refresh-interval cannot be negative")
+	}
+	return nil
+}
+
+// validate checks that an enabled geocoder has a known provider and an http(s) URL
+func (g GeocoderConfig) validate() error {
+	if !g.Enabled() {
+		return nil
+	}
+	if g.Provider != GeocoderPelias && g.Provider != GeocoderNominatim {
+		return fmt.Errorf("geocoder.provider must be %q or %q", GeocoderPelias, GeocoderNominatim)
+	}
+	if !strings.HasPrefix(g.URL, "https://") && !strings.HasPrefix(g.URL, "http://") {
+		return fmt.Errorf("geocoder.url must be an http(s) URL")
+	}
+	if g.Radius < 0 {
+		return fmt.Errorf("geocoder.radius cannot be negative")
+	}
+	return nil
+}
+
+// validate checks that an enabled upload has an http(s) endpoint and a complete key pair
+func (s SnapshotUploadConfig) validate() error {
+	if !s.Enabled() {
+		return nil
+	}
+	if !strings.HasPrefix(s.Endpoint, "https://") && !strings.HasPrefix(s.Endpoint, "http://") {
+		return fmt.Errorf("snapshot-upload.endpoint must be an http(s) URL")
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return fmt.Errorf("snapshot-upload.access-key-id and secret-access-key are required")
+	}
+	return nil
+}
+
+// validate checks that the broker is known and its URL has a scheme it can be reached with
+func (e EventPublishingConfig) validate() error {
+	if !e.Enabled() {
+		return nil
+	}
+	switch e.Broker {
+	case "nats":
+		if !strings.HasPrefix(e.URL, "nats://") && !strings.HasPrefix(e.URL, "tls://") {
+			return fmt.Errorf("event-publishing.url must be a nats:// or tls:// URL for broker nats")
+		}
+	case "kafka":
+		if !strings.HasPrefix(e.URL, "kafka://") && !strings.HasPrefix(e.URL, "kafka+tls://") {
+			return fmt.Errorf("event-publishing.url must be a kafka:// or kafka+tls:// URL of the bootstrap brokers for broker kafka")
+		}
+	default:
+		return fmt.Errorf("event-publishing.broker must be one of [nats, kafka], got %q", e.Broker)
+	}
+	for _, c := range e.TopicPrefix {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '-' || c == '_') {
+			return fmt.Errorf("event-publishing.topic-prefix may only contain letters, digits, '.', '-' and '_'")
+		}
+	}
+	return nil
+}
+
+// validate checks that the webhook, at index i of alert-webhooks, has an http(s) URL
+func (a AlertWebhookConfig) validate(i int) error {
+	u, err := url.Parse(a.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("alert-webhooks[%d].url must be an http(s) URL, got %q", i, a.URL)
+	}
+	return nil
+}
+
+// validate checks that an enabled registry has a known provider, a feed ID and credentials
+func (r FeedRegistryConfig) validate() error {
+	if !r.Enabled() {
+		return nil
+	}
+	if r.Provider != "mobility-database" && r.Provider != "transitland" {
+		return fmt.Errorf("feed-registry.provider must be one of [mobility-database, transitland], got %q", r.Provider)
+	}
+	if r.StaticFeedID == "" {
+		return fmt.Errorf("feed-registry.static-feed-id is required")
+	}
+	if r.Token == "" {
+		return fmt.Errorf("feed-registry.token is required")
+	}
+	if r.CheckInterval < 0 {
+		return fmt.Errorf("feed-registry.check-interval cannot be negative")
+	}
+	if r.APIURL != "" && !strings.HasPrefix(r.APIURL, "https://") && !strings.HasPrefix(r.APIURL, "http://") {
+		return fmt.Errorf("feed-registry.api-url must be an http(s) URL")
+	}
+	return nil
+}
+
+// validate checks the archive path and that the retention, thresholds and frequent headway are
+// not negative
+func (a ArrivalArchiveConfig) validate() error {
+	if err := validatePath(a.DataPath, "arrival-archive.data-path"); err != nil {
+		return err
+	}
+	if a.RetentionDays < 0 {
+		return fmt.Errorf("arrival-archive.retention-days cannot be negative")
+	}
+	if a.EarlyThreshold < 0 || a.LateThreshold < 0 {
+		return fmt.Errorf("arrival-archive thresholds cannot be negative")
+	}
+	if a.FrequentHeadway < 0 {
+		return fmt.Errorf("arrival-archive.frequent-headway cannot be negative")
+	}
+	return nil
+}
+
+// validate checks that every class is known, its default fits within its maximum, and the
+// bulk maximum is at least the maximum
+func (c PaginationConfig) validate() error {
+	for class, limits := range c {
+		if !slices.Contains(PaginationClasses, class) {
+			return fmt.Errorf("pagination has unknown endpoint class %q (expected one of %s)", class, strings.Join(PaginationClasses, ", "))
+		}
+		if limits.DefaultCount < 0 || limits.MaxCount < 0 || limits.BulkMaxCount < 0 {
+			return fmt.Errorf("pagination[%q] counts cannot be negative", class)
+		}
+		if limits.MaxCount > 0 && limits.DefaultCount > limits.MaxCount {
+			return fmt.Errorf("pagination[%q].default-count must not exceed max-count", class)
+		}
+		if limits.BulkMaxCount > 0 && limits.BulkMaxCount < limits.MaxCount {
+			return fmt.Errorf("pagination[%q].bulk-max-count must not be below max-count", class)
+		}
+	}
+	return nil
+}
+
+// validate checks that no limit is negative
+func (l RequestLimitsConfig) validate() error {
+	if l.MaxURLLength < 0 || l.MaxQueryParams < 0 || l.MaxIDLength < 0 {
+		return fmt.Errorf("request-limits values cannot be negative")
+	}
+	return nil
+}
+
+// validate checks that every origin is "*" or a scheme and host, optionally with a leading "*."
+// wildcard, and every method is a standard HTTP method
+func (c CORSConfig) validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("cors.allowed-origins: %q must be \"*\" or a scheme and host, e.g. https://*.example.com", origin)
+		}
+	}
+	for _, method := range c.AllowedMethods {
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("cors.allowed-methods: %q is not an HTTP method", method)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("cors.max-age cannot be negative, got %d", c.MaxAge)
 	}
+	return nil
+}
 
+// validate checks that exactly one certificate source is configured and its paths are safe
+func (t TLSConfig) validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("tls.cert-file and tls.key-file must be provided together")
+	}
+	if t.CertFile != "" && t.Autocert() {
+		return fmt.Errorf("tls.cert-file and tls.autocert-domains are mutually exclusive")
+	}
+	for _, domain := range t.AutocertDomains {
+		if domain == "" {
+			return fmt.Errorf("tls.autocert-domains cannot contain empty strings")
+		}
+	}
+	if t.RedirectPort < 0 || t.RedirectPort > 65535 {
+		return fmt.Errorf("tls.http-redirect-port must be between 1 and 65535 (or 0 to disable), got %d", t.RedirectPort)
+	}
+	if t.RedirectPort != 0 && !t.Enabled() {
+		return fmt.Errorf("tls.http-redirect-port requires tls.cert-file or tls.autocert-domains")
+	}
+	if err := validatePath(t.CertFile, "tls.cert-file"); err != nil {
+		return err
+	}
+	if err := validatePath(t.KeyFile, "tls.key-file"); err != nil {
+		return err
+	}
+	return validatePath(t.AutocertCacheDir, "tls.autocert-cache-dir")
+}
+
+// validate checks the OTLP endpoint and sample ratio
+func (t TracingConfig) validate() error {
+	if t.Endpoint != "" && !strings.HasPrefix(t.Endpoint, "https://") && !strings.HasPrefix(t.Endpoint, "http://") {
+		return fmt.Errorf("tracing.otlp-endpoint must be an http(s) URL")
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample-ratio must be between 0 and 1, got %v", t.SampleRatio)
+	}
+	return nil
+}
+
+// validate checks that the Sentry DSN names a key and a project
+func (e ErrorReportingConfig) validate() error {
+	if e.SentryDSN == "" {
+		return nil
+	}
+	u, err := url.Parse(e.SentryDSN)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.User == nil || strings.Trim(u.Path, "/") == "" {
+		return fmt.Errorf("error-reporting.sentry-dsn must look like https://<key>@<host>/<project>")
+	}
 	return nil
 }
 
@@ -167,15 +904,89 @@ func validatePath(path, fieldName string) error {
 	return nil
 }
 
+// validateProductionFeeds requires production configurations to name their feeds, since the
+// development defaults would serve another region's data.
+func (j *JSONConfig) validateProductionFeeds() error {
+	if j.GtfsStaticFeed.URL == "" && len(j.GtfsStaticFeeds) == 0 {
+		return fmt.Errorf("gtfs-static-feed.url is required in production, or gtfs-static-feeds (the default Sound Transit feed is only used in development and test)")
+	}
+	for _, feed := range j.GtfsRtFeeds {
+		if feed.TripUpdatesURL != "" || feed.VehiclePositionsURL != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("gtfs-rt-feeds with a trip-updates-url or vehicle-positions-url is required in production (the default Puget Sound feeds are only used in development and test)")
+}
+
+// maxUnixSocketPath is the longest socket path Linux accepts (sun_path is 108 bytes,
+// including the terminating NUL).
+const maxUnixSocketPath = 107
+
+// validateUnixSocket checks the unix-socket path, which is created on startup.
+func validateUnixSocket(path string) error {
+	if err := validatePath(path, "unix-socket"); err != nil {
+		return err
+	}
+	if path == ":memory:" {
+		return fmt.Errorf("unix-socket must be a file path")
+	}
+	if len(path) > maxUnixSocketPath {
+		return fmt.Errorf("unix-socket path must be at most %d bytes, got %d", maxUnixSocketPath, len(path))
+	}
+	return nil
+}
+
 // ToAppConfig converts JSONConfig to appconf.Config
 func (j *JSONConfig) ToAppConfig() Config {
 	return Config{
-		Port:          j.Port,
-		Env:           EnvFlagToEnvironment(j.Env),
-		ApiKeys:       j.ApiKeys,
-		ExemptApiKeys: j.ExemptApiKeys,
-		Verbose:       true, // Always set to true like in main.go
-		RateLimit:     j.RateLimit,
+		Port:                    j.Port,
+		Env:                     EnvFlagToEnvironment(j.Env),
+		ApiKeys:                 j.ApiKeys,
+		ExemptApiKeys:           j.ExemptApiKeys,
+		AdminApiKeys:            j.AdminApiKeys,
+		BulkApiKeys:             j.BulkApiKeys,
+		AdminPort:               j.AdminPort,
+		GRPCPort:                j.GRPCPort,
+		UnixSocket:              j.UnixSocket,
+		Logging:                 j.Logging,
+		ConfigWatchInterval:     j.ConfigWatchInterval,
+		AuditLogPath:            j.AuditLogPath,
+		BlocklistPath:           j.BlocklistPath,
+		DetoursPath:             j.DetoursPath,
+		CrowdingPredictor:       j.CrowdingPredictor,
+		SearchPopularityWeight:  j.SearchPopularityWeight,
+		NotFoundResponses:       j.NotFoundResponses,
+		JavaParity:              j.JavaParity,
+		CanonicalJSON:           j.CanonicalJSON,
+		GraphQL:                 j.GraphQL,
+		Verbose:                 true, // Always set to true like in main.go
+		RateLimit:               j.RateLimit,
+		RateLimitExemptPaths:    j.RateLimitExemptPaths,
+		KeyRestrictions:         j.KeyRestrictions,
+		RealtimeStalenessBudget: j.RealtimeStalenessBudget,
+		Quotas:                  j.Quotas,
+		Analytics:               j.Analytics,
+		ResponseCache:           j.ResponseCache,
+		RequestLimits:           j.RequestLimits,
+		CORS:                    j.CORS,
+		ConcurrencyLimits:       j.ConcurrencyLimits,
+		Pagination:              j.Pagination,
+		TLS:                     j.TLS,
+		Shutdown:                j.Shutdown,
+		FakeTime:                j.FakeTime,
+		GBFS:                    j.GBFS,
+		Geocoder:                j.Geocoder,
+		Notifications:           j.Notifications,
+		SnapshotUpload:          j.SnapshotUpload,
+		EventPublishing:         j.EventPublishing,
+		AlertWebhooks:           j.AlertWebhooks,
+		FeedRegistry:            j.FeedRegistry,
+		ArrivalArchive:          j.ArrivalArchive,
+		Ridership:               j.Ridership,
+		SignedRequests:          j.SignedRequests,
+		BearerAuth:              j.BearerAuth,
+		Tracing:                 j.Tracing,
+		ErrorReporting:          j.ErrorReporting,
 	}
 }
 
@@ -185,11 +996,17 @@ type GtfsConfigData struct {
 	GtfsURL                 string
 	StaticAuthHeaderKey     string
 	StaticAuthHeaderValue   string
+	StaticIDPrefix          string
+	ExtraStaticFeeds        []GtfsStaticFeed // The static feeds after the first, which the fields above hold
+	StaticRefreshInterval   int              // Seconds
 	TripUpdatesURL          string
 	VehiclePositionsURL     string
 	ServiceAlertsURL        string
 	RealTimeAuthHeaderKey   string
 	RealTimeAuthHeaderValue string
+	RealTimeAgencyID        string
+	RealTimeIDPrefix        string
+	ExtraRealtimeFeeds      []GtfsRtFeed // The GTFS-RT feeds after the first, which the fields above hold
 	GTFSDataPath            string
 	Env                     Environment
 	Verbose                 bool
@@ -197,7 +1014,6 @@ type GtfsConfigData struct {
 }
 
 // ToGtfsConfigData converts JSONConfig to GtfsConfigData
-// For now, only uses the first GTFS-RT feed
 func (j *JSONConfig) ToGtfsConfigData() GtfsConfigData {
 	cfg := GtfsConfigData{
 		GtfsURL:               j.GtfsStaticFeed.URL,
@@ -207,9 +1023,24 @@ func (j *JSONConfig) ToGtfsConfigData() GtfsConfigData {
 		Env:                   EnvFlagToEnvironment(j.Env),
 		Verbose:               true, // Always set to true like in main.go
 		EnableGTFSTidy:        j.GtfsStaticFeed.EnableGTFSTidy,
+		StaticIDPrefix:        j.GtfsStaticFeed.IDPrefix,
+		StaticRefreshInterval: j.StaticRefreshInterval,
 	}
 
-	// Use first GTFS-RT feed if available
+	// The first of a list of static feeds fills the fields of the single feed; the rest are
+	// merged with it
+	if len(j.GtfsStaticFeeds) > 0 {
+		feed := j.GtfsStaticFeeds[0]
+		cfg.GtfsURL = feed.URL
+		cfg.StaticAuthHeaderKey = feed.AuthHeaderName
+		cfg.StaticAuthHeaderValue = feed.AuthHeaderValue
+		cfg.EnableGTFSTidy = feed.EnableGTFSTidy
+		cfg.StaticIDPrefix = feed.IDPrefix
+		cfg.ExtraStaticFeeds = append([]GtfsStaticFeed(nil), j.GtfsStaticFeeds[1:]...)
+	}
+
+	// The first GTFS-RT feed fills the fields the command-line flags set; the rest are polled
+	// alongside it
 	if len(j.GtfsRtFeeds) > 0 {
 		feed := j.GtfsRtFeeds[0]
 		cfg.TripUpdatesURL = feed.TripUpdatesURL
@@ -217,74 +1048,98 @@ func (j *JSONConfig) ToGtfsConfigData() GtfsConfigData {
 		cfg.ServiceAlertsURL = feed.ServiceAlertsURL
 		cfg.RealTimeAuthHeaderKey = feed.RealTimeAuthHeaderName
 		cfg.RealTimeAuthHeaderValue = feed.RealTimeAuthHeaderValue
+		cfg.RealTimeAgencyID = feed.AgencyID
+		cfg.RealTimeIDPrefix = feed.IDPrefix
+		cfg.ExtraRealtimeFeeds = append([]GtfsRtFeed(nil), j.GtfsRtFeeds[1:]...)
 	}
 
 	return cfg
 }
 
-// LoadFromFile loads configuration from a JSON file
+// LoadFromFile loads configuration from a JSON, YAML or TOML file
 func LoadFromFile(path string) (*JSONConfig, error) {
-	logger := slog.Default().With("config_file", path)
-	logger.Debug("loading configuration file")
+	return LoadFromFiles(path)
+}
 
-	// Use Lstat to prevent symlink attacks
-	info, err := os.Lstat(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat config file: %w", err)
-	}
+// LoadFromFiles loads a base configuration file followed by overlays, merged in order.
+// Objects are merged key by key, so an overlay only lists the settings it changes; any
+// other value, including an array, replaces the earlier one, and null removes it. Files ending
+// in .yaml, .yml or .toml are read as YAML or TOML and may be layered with JSON files.
+func LoadFromFiles(paths ...string) (*JSONConfig, error) {
+	logger := slog.Default().With("config_files", paths)
+	logger.Debug("loading configuration files")
 
-	// Check if it's a regular file (not a symlink, directory, or device)
-	if !info.Mode().IsRegular() {
-		return nil, fmt.Errorf("config file must be a regular file, not a %s", info.Mode().Type())
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no config file given")
 	}
 
-	// Check file size to prevent loading extremely large files
-	const maxConfigSize = 10 * 1024 * 1024 // 10MB limit
-	if info.Size() > maxConfigSize {
-		return nil, fmt.Errorf("config file too large: %d bytes (max: %d)", info.Size(), maxConfigSize)
+	merged := map[string]any{}
+	for _, path := range paths {
+		data, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		layer, err := decodeConfigLayer(path, data)
+		if err != nil {
+			return nil, err
+		}
+		from, migrated, err := migrateConfigLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration in %s: %w", path, err)
+		}
+		if migrated {
+			logger.Warn("config file uses an older layout and was migrated in memory; run with --dump-config to print the current layout",
+				"config_file", path, "config_version", from, "current_version", CurrentConfigVersion)
+		}
+		mergeConfigLayer(merged, layer)
 	}
 
-	// Read file
-	data, err := os.ReadFile(path)
+	// Decode the merged layers
+	data, err := json.Marshal(merged)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, fmt.Errorf("failed to merge config files: %w", err)
 	}
-
-	// Parse JSON
 	var config JSONConfig
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON config: %w", err)
 	}
 
+	// Read secrets kept in separate files before defaults fill in empty key lists
+	if err := config.resolveSecretFiles(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	// Apply defaults
 	config.setDefaults()
 
-	// Override API Keys (Split by comma, trim spaces, ignore empty)
-	if envKeys := os.Getenv("GTFS_API_KEYS"); envKeys != "" {
-		rawKeys := strings.Split(envKeys, ",")
-		var cleanKeys []string
-		for _, k := range rawKeys {
-			if trimmed := strings.TrimSpace(k); trimmed != "" {
-				cleanKeys = append(cleanKeys, trimmed)
-			}
-		}
-		if len(cleanKeys) > 0 {
-			config.ApiKeys = cleanKeys
+	// Environment overrides. Each variable can instead name a file with VARIABLE_FILE.
+	env := map[string]string{}
+	for _, name := range []string{"GTFS_API_KEYS", "GTFS_STATIC_AUTH_NAME", "GTFS_STATIC_AUTH_VALUE", "GTFS_REALTIME_AUTH_NAME", "GTFS_REALTIME_AUTH_VALUE"} {
+		value, err := envOrFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
 		}
+		env[name] = value
+	}
+
+	// Override API Keys (Split by comma or newline, trim spaces, ignore empty)
+	if envKeys := parseKeyList(env["GTFS_API_KEYS"]); len(envKeys) > 0 {
+		config.ApiKeys = envKeys
 	}
 
 	// Override Static Feed Auth (Name + Value)
-	if staticName := os.Getenv("GTFS_STATIC_AUTH_NAME"); staticName != "" {
+	if staticName := env["GTFS_STATIC_AUTH_NAME"]; staticName != "" {
 		config.GtfsStaticFeed.AuthHeaderName = staticName
 	}
-	if staticValue := os.Getenv("GTFS_STATIC_AUTH_VALUE"); staticValue != "" {
+	if staticValue := env["GTFS_STATIC_AUTH_VALUE"]; staticValue != "" {
 		config.GtfsStaticFeed.AuthHeaderValue = staticValue
 	}
 
 	// Override Realtime Feed Auth (Name + Value)
 	// Note: Currently only overrides the first configured realtime feed explicitly
-	rtName := os.Getenv("GTFS_REALTIME_AUTH_NAME")
-	rtValue := os.Getenv("GTFS_REALTIME_AUTH_VALUE")
+	rtName := env["GTFS_REALTIME_AUTH_NAME"]
+	rtValue := env["GTFS_REALTIME_AUTH_VALUE"]
 
 	if rtName != "" || rtValue != "" {
 		if len(config.GtfsRtFeeds) > 0 {
@@ -313,3 +1168,53 @@ func LoadFromFile(path string) (*JSONConfig, error) {
 
 	return &config, nil
 }
+
+// readConfigFile reads a single configuration file after checking it is a regular file of
+// reasonable size.
+func readConfigFile(path string) ([]byte, error) {
+	// Use Lstat to prevent symlink attacks
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat config file: %w", err)
+	}
+
+	// Check if it's a regular file (not a symlink, directory, or device)
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("config file must be a regular file, not a %s", info.Mode().Type())
+	}
+
+	// Check file size to prevent loading extremely large files
+	const maxConfigSize = 10 * 1024 * 1024 // 10MB limit
+	if info.Size() > maxConfigSize {
+		return nil, fmt.Errorf("config file too large: %d bytes (max: %d)", info.Size(), maxConfigSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return data, nil
+}
+
+// mergeConfigLayer applies overlay onto base following JSON Merge Patch (RFC 7386) rules.
+func mergeConfigLayer(base, overlay map[string]any) {
+	for key, value := range overlay {
+		if value == nil {
+			delete(base, key)
+			continue
+		}
+		overlayObject, isObject := value.(map[string]any)
+		baseObject, baseIsObject := base[key].(map[string]any)
+		if isObject && baseIsObject {
+			mergeConfigLayer(baseObject, overlayObject)
+			continue
+		}
+		if isObject {
+			// Drop nulls inside objects that have nothing to merge with
+			baseObject = map[string]any{}
+			mergeConfigLayer(baseObject, overlayObject)
+			value = baseObject
+		}
+		base[key] = value
+	}
+}
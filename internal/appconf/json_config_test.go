@@ -2,6 +2,8 @@ package appconf
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,6 +22,7 @@ func TestLoadFromFile_ValidConfig(t *testing.T) {
 	// Verify defaults were applied
 	assert.Equal(t, []string{"test"}, config.ApiKeys)
 	assert.Equal(t, 100, config.RateLimit)
+	assert.Equal(t, 86400, config.StaticRefreshInterval)
 	assert.Equal(t, "https://www.soundtransit.org/GTFS-rail/40_gtfs.zip", config.GtfsStaticFeed.URL)
 	assert.Equal(t, "./gtfs.db", config.DataPath)
 	assert.Len(t, config.GtfsRtFeeds, 1)
@@ -156,6 +159,548 @@ func TestValidate_DuplicateApiKeys(t *testing.T) {
 	assert.Contains(t, err.Error(), "duplicate API key found")
 }
 
+func TestValidate_EmptyAdminApiKeyString(t *testing.T) {
+	config := &JSONConfig{
+		Port:         4000,
+		Env:          "development",
+		ApiKeys:      []string{"test"},
+		AdminApiKeys: []string{""},
+		RateLimit:    100,
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "admin-api-keys cannot contain empty strings")
+}
+
+func TestValidate_NegativeQuota(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		Quotas: QuotaConfig{
+			Keys: map[string]QuotaLimits{"partner": {Daily: -1}},
+		},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `quotas.keys["partner"] limits cannot be negative`)
+}
+
+func TestValidate_AdminPortMustDifferFromPort(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		AdminPort: 4000,
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "admin-port must differ from port")
+}
+
+func TestValidate_GRPCPortMustDifferFromAdminPort(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		AdminPort: 4001,
+		GRPCPort:  4001,
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "grpc-port must differ from port and admin-port")
+}
+
+func TestLoadFromFile_ProductionRequiresFeeds(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	_, err := LoadFromFile(write("no-static.json", `{"env": "production"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gtfs-static-feed.url is required in production")
+
+	_, err = LoadFromFile(write("no-realtime.json", `{"env": "production", "gtfs-static-feed": {"url": "https://example.com/gtfs.zip"}}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gtfs-rt-feeds with a trip-updates-url or vehicle-positions-url is required in production")
+
+	config, err := LoadFromFile(write("development.json", `{"env": "development"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "https://www.soundtransit.org/GTFS-rail/40_gtfs.zip", config.GtfsStaticFeed.URL)
+	assert.Len(t, config.GtfsRtFeeds, 1)
+
+	// A feed registry supplies the URLs, so no feeds or development defaults are added
+	config, err = LoadFromFile(write("registry.json", `{"env": "production", "feed-registry": {"provider": "transitland", "static-feed-id": "f-9q9-example", "token": "key"}}`))
+	require.NoError(t, err)
+	assert.Empty(t, config.GtfsStaticFeed.URL)
+	assert.Empty(t, config.GtfsRtFeeds)
+	assert.Equal(t, 3600, config.FeedRegistry.CheckInterval)
+}
+
+func TestValidate_UnixSocket(t *testing.T) {
+	config := &JSONConfig{
+		Port:       4000,
+		Env:        "development",
+		ApiKeys:    []string{"test"},
+		RateLimit:  100,
+		UnixSocket: "/run/maglev/maglev.sock",
+	}
+	assert.NoError(t, config.validate())
+
+	config.UnixSocket = "/run/" + strings.Repeat("a", 120) + ".sock"
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unix-socket path must be at most")
+
+	config.UnixSocket = "../maglev.sock"
+	assert.Error(t, config.validate())
+}
+
+func TestValidate_Pagination(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		Pagination: PaginationConfig{
+			PaginationClassLocation: {DefaultCount: 50, MaxCount: 500},
+			PaginationClassList:     {DefaultCount: 100},
+		},
+	}
+	assert.NoError(t, config.validate())
+
+	config.Pagination = PaginationConfig{"schedules": {MaxCount: 10}}
+	assert.ErrorContains(t, config.validate(), `pagination has unknown endpoint class "schedules"`)
+
+	config.Pagination = PaginationConfig{PaginationClassSearch: {DefaultCount: 50, MaxCount: 20}}
+	assert.ErrorContains(t, config.validate(), "default-count must not exceed max-count")
+
+	config.Pagination = PaginationConfig{PaginationClassSearch: {MaxCount: -1}}
+	assert.ErrorContains(t, config.validate(), "cannot be negative")
+
+	config.Pagination = PaginationConfig{PaginationClassList: {MaxCount: 2000, BulkMaxCount: 1500}}
+	assert.ErrorContains(t, config.validate(), "bulk-max-count must not be below max-count")
+
+	config.Pagination = PaginationConfig{PaginationClassList: {BulkMaxCount: 50000}}
+	config.BulkApiKeys = []string{"sync", ""}
+	assert.ErrorContains(t, config.validate(), "bulk-api-keys cannot contain empty strings")
+}
+
+func TestValidate_RateLimitExemptPaths(t *testing.T) {
+	config := &JSONConfig{
+		Port:                 4000,
+		Env:                  "development",
+		ApiKeys:              []string{"test"},
+		RateLimit:            100,
+		RateLimitExemptPaths: []string{"/api/where/current-time.json", "/api/where/config/*"},
+	}
+	assert.NoError(t, config.validate())
+
+	config.RateLimitExemptPaths = []string{"api/where/current-time.json"}
+	assert.ErrorContains(t, config.validate(), "must start with '/'")
+
+	config.RateLimitExemptPaths = []string{"/api/*/current-time.json"}
+	assert.ErrorContains(t, config.validate(), "may only end with '*'")
+}
+
+func TestValidate_Logging(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		Logging: LoggingConfig{
+			Level:    "warn",
+			Format:   "json",
+			Output:   "/var/log/maglev/maglev.log",
+			Rotation: LogRotationConfig{MaxSize: 100, Interval: 24, MaxBackups: 7},
+		},
+	}
+	assert.NoError(t, config.validate())
+
+	config.Logging.Format = "xml"
+	assert.ErrorContains(t, config.validate(), "logging.format must be text or json")
+
+	config.Logging.Format = "text"
+	config.Logging.Level = "trace"
+	assert.ErrorContains(t, config.validate(), "logging.level")
+
+	config.Logging.Level = "info"
+	config.Logging.Output = "stdout"
+	assert.ErrorContains(t, config.validate(), "logging.rotation requires logging.output to be a file path")
+
+	config.Logging.Output = "../maglev.log"
+	assert.Error(t, config.validate())
+}
+
+func TestValidate_FakeTime(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		FakeTime:  FakeTimeConfig{Start: "2024-11-03T01:30:00-07:00", Speed: 10},
+	}
+	assert.NoError(t, config.validate())
+
+	config.FakeTime.Start = "2024-11-03 01:30:00"
+	assert.NoError(t, config.validate(), "times without a zone use the local time zone")
+
+	config.FakeTime.Speed = -1
+	assert.ErrorContains(t, config.validate(), "fake-time.speed cannot be negative")
+
+	config.FakeTime.Speed = 1
+	config.FakeTime.Start = "yesterday"
+	assert.ErrorContains(t, config.validate(), "fake-time.start")
+
+	config.FakeTime.Start = ""
+	assert.ErrorContains(t, config.validate(), "fake-time.speed requires fake-time.start")
+
+	config.Env = "production"
+	config.GtfsStaticFeed.URL = "https://example.com/gtfs.zip"
+	config.GtfsRtFeeds = []GtfsRtFeed{{TripUpdatesURL: "https://example.com/trip-updates.pb"}}
+	config.FakeTime = FakeTimeConfig{Start: "2024-11-03T01:30:00Z"}
+	assert.ErrorContains(t, config.validate(), "fake-time cannot be used in production")
+}
+
+func TestValidate_GBFS(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		GBFS: GBFSConfig{Feeds: []GBFSFeed{
+			{ID: "citibike", URL: "https://gbfs.example.com/citibike/gbfs.json"},
+			{ID: "lime", URL: "https://gbfs.example.com/lime/gbfs.json"},
+		}},
+	}
+	config.setDefaults()
+	assert.NoError(t, config.validate())
+	assert.Equal(t, 60, config.GBFS.RefreshInterval)
+
+	config.GBFS.Feeds[1].ID = "citibike"
+	assert.ErrorContains(t, config.validate(), "used by another feed")
+
+	config.GBFS.Feeds[1].ID = "city_bike"
+	assert.ErrorContains(t, config.validate(), "cannot contain an underscore")
+
+	config.GBFS.Feeds[1].ID = "lime"
+	config.GBFS.Feeds[1].URL = "gbfs.json"
+	assert.ErrorContains(t, config.validate(), "gbfs.feeds[1].url must be an http(s) URL")
+}
+
+func TestValidate_Geocoder(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		Geocoder:  GeocoderConfig{URL: "https://geocode.example.com"},
+	}
+	config.setDefaults()
+	assert.NoError(t, config.validate())
+	assert.Equal(t, GeocoderPelias, config.Geocoder.Provider)
+	assert.Equal(t, 400, config.Geocoder.Radius)
+
+	config.Geocoder.Provider = "google"
+	assert.ErrorContains(t, config.validate(), "geocoder.provider")
+
+	config.Geocoder.Provider = GeocoderNominatim
+	config.Geocoder.URL = "geocode.example.com"
+	assert.ErrorContains(t, config.validate(), "geocoder.url must be an http(s) URL")
+}
+
+func TestValidate_SnapshotUpload(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		SnapshotUpload: SnapshotUploadConfig{
+			Endpoint:        "https://storage.googleapis.com",
+			Bucket:          "maglev-snapshots",
+			AccessKeyID:     "GOOG1EXAMPLE",
+			SecretAccessKey: "secret",
+		},
+	}
+	config.setDefaults()
+	assert.NoError(t, config.validate())
+	assert.Equal(t, "us-east-1", config.SnapshotUpload.Region)
+
+	config.SnapshotUpload.Endpoint = "storage.googleapis.com"
+	assert.ErrorContains(t, config.validate(), "snapshot-upload.endpoint must be an http(s) URL")
+
+	config.SnapshotUpload.Endpoint = "https://storage.googleapis.com"
+	config.SnapshotUpload.SecretAccessKey = ""
+	assert.ErrorContains(t, config.validate(), "access-key-id and secret-access-key are required")
+}
+
+func TestValidate_EventPublishing(t *testing.T) {
+	config := &JSONConfig{
+		Port:            4000,
+		Env:             "development",
+		ApiKeys:         []string{"test"},
+		RateLimit:       100,
+		EventPublishing: EventPublishingConfig{Broker: "nats", URL: "nats://localhost:4222"},
+	}
+	config.setDefaults()
+	assert.NoError(t, config.validate())
+	assert.Equal(t, "gtfs-rt.", config.EventPublishing.TopicPrefix)
+
+	config.EventPublishing.URL = "http://localhost:8082"
+	assert.ErrorContains(t, config.validate(), "nats:// or tls:// URL")
+
+	config.EventPublishing.Broker = "kafka"
+	assert.ErrorContains(t, config.validate(), "kafka:// or kafka+tls:// URL")
+
+	config.EventPublishing.URL = "kafka://kafka-1:9092,kafka-2:9092"
+	assert.NoError(t, config.validate())
+
+	config.EventPublishing.Broker = "rabbitmq"
+	assert.ErrorContains(t, config.validate(), "event-publishing.broker must be one of")
+
+	config.EventPublishing = EventPublishingConfig{Broker: "nats", URL: "tls://nats.example.com:4222", TopicPrefix: "transit/"}
+	assert.ErrorContains(t, config.validate(), "topic-prefix")
+}
+
+func TestValidate_AlertWebhooks(t *testing.T) {
+	config := &JSONConfig{
+		Port:          4000,
+		Env:           "development",
+		ApiKeys:       []string{"test"},
+		RateLimit:     100,
+		AlertWebhooks: []AlertWebhookConfig{{URL: "https://hooks.example.com/alerts"}, {URL: "http://localhost:9000"}},
+	}
+	config.setDefaults()
+	assert.NoError(t, config.validate())
+
+	config.AlertWebhooks[1].URL = "hooks.example.com/alerts"
+	assert.ErrorContains(t, config.validate(), "alert-webhooks[1].url must be an http(s) URL")
+}
+
+func TestValidate_FeedRegistry(t *testing.T) {
+	config := &JSONConfig{
+		Port:         4000,
+		Env:          "development",
+		ApiKeys:      []string{"test"},
+		RateLimit:    100,
+		FeedRegistry: FeedRegistryConfig{Provider: "mobility-database", StaticFeedID: "mdb-1", Token: "refresh"},
+	}
+	config.setDefaults()
+	assert.NoError(t, config.validate())
+
+	config.FeedRegistry.Provider = "transitfeeds"
+	assert.ErrorContains(t, config.validate(), "feed-registry.provider must be one of")
+
+	config.FeedRegistry.Provider = "transitland"
+	config.FeedRegistry.StaticFeedID = ""
+	assert.ErrorContains(t, config.validate(), "static-feed-id is required")
+
+	config.FeedRegistry.StaticFeedID = "f-9q9-example"
+	config.FeedRegistry.Token = ""
+	assert.ErrorContains(t, config.validate(), "token is required")
+
+	config.FeedRegistry.Token = "key"
+	config.FeedRegistry.CheckInterval = -1
+	assert.ErrorContains(t, config.validate(), "check-interval cannot be negative")
+
+	config.FeedRegistry.CheckInterval = 0
+	config.FeedRegistry.APIURL = "ftp://registry.example.com"
+	assert.ErrorContains(t, config.validate(), "api-url must be an http(s) URL")
+}
+
+func TestValidate_ArrivalArchive(t *testing.T) {
+	config := &JSONConfig{
+		Port:           4000,
+		Env:            "development",
+		ApiKeys:        []string{"test"},
+		RateLimit:      100,
+		ArrivalArchive: ArrivalArchiveConfig{DataPath: "./arrivals.db"},
+	}
+	config.setDefaults()
+	assert.NoError(t, config.validate())
+	assert.Equal(t, 365, config.ArrivalArchive.RetentionDays)
+	assert.Equal(t, 60, config.ArrivalArchive.EarlyThreshold)
+	assert.Equal(t, 300, config.ArrivalArchive.LateThreshold)
+	assert.Equal(t, 900, config.ArrivalArchive.FrequentHeadway)
+
+	config.ArrivalArchive.LateThreshold = -1
+	assert.ErrorContains(t, config.validate(), "thresholds cannot be negative")
+
+	config.ArrivalArchive.LateThreshold = 300
+	config.ArrivalArchive.RetentionDays = -1
+	assert.ErrorContains(t, config.validate(), "retention-days cannot be negative")
+
+	config.ArrivalArchive.RetentionDays = 30
+	config.ArrivalArchive.FrequentHeadway = -1
+	assert.ErrorContains(t, config.validate(), "frequent-headway cannot be negative")
+
+	config.ArrivalArchive.FrequentHeadway = 900
+	config.ArrivalArchive.DataPath = "../arrivals.db"
+	assert.Error(t, config.validate())
+}
+
+func TestValidate_ResponseCacheUnknownGroup(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		ResponseCache: ResponseCacheConfig{
+			MaxEntries: 100,
+			TTLs:       map[string]int{"vehicles": 30},
+		},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `response-cache.ttls has unknown route group "vehicles"`)
+}
+
+func TestValidate_CORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		cors        CORSConfig
+		expectedErr string
+	}{
+		{"valid", CORSConfig{AllowedOrigins: []string{"*", "https://*.example.com"}, AllowedMethods: []string{"GET", "POST"}, MaxAge: 600}, ""},
+		{"origin with path", CORSConfig{AllowedOrigins: []string{"https://example.com/app"}}, "cors.allowed-origins"},
+		{"bare host", CORSConfig{AllowedOrigins: []string{"example.com"}}, "cors.allowed-origins"},
+		{"unknown method", CORSConfig{AllowedMethods: []string{"get"}}, `cors.allowed-methods: "get" is not an HTTP method`},
+		{"negative max age", CORSConfig{MaxAge: -1}, "cors.max-age cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &JSONConfig{
+				Port:      4000,
+				Env:       "development",
+				ApiKeys:   []string{"test"},
+				RateLimit: 100,
+				CORS:      tt.cors,
+			}
+			err := config.validate()
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestValidate_TLS(t *testing.T) {
+	tests := []struct {
+		name        string
+		tls         TLSConfig
+		expectedErr string
+	}{
+		{"cert without key", TLSConfig{CertFile: "cert.pem"}, "tls.cert-file and tls.key-file must be provided together"},
+		{"files and autocert", TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", AutocertDomains: []string{"example.com"}}, "mutually exclusive"},
+		{"redirect without tls", TLSConfig{RedirectPort: 80}, "tls.http-redirect-port requires"},
+		{"redirect on main port", TLSConfig{AutocertDomains: []string{"example.com"}, RedirectPort: 4000}, "must differ from port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &JSONConfig{
+				Port:      4000,
+				Env:       "development",
+				ApiKeys:   []string{"test"},
+				RateLimit: 100,
+				TLS:       tt.tls,
+			}
+			err := config.validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestValidate_NegativeShutdownDrainDelay(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		Shutdown:  ShutdownConfig{DrainDelay: -5},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "shutdown.timeout and shutdown.drain-delay cannot be negative")
+}
+
+func TestValidate_EmptySigningSecret(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		SignedRequests: SignedRequestConfig{
+			Secrets: map[string]string{"test": ""},
+		},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `signed-requests.secrets["test"] cannot be empty`)
+}
+
+func TestValidate_BearerAuthRequiresAudience(t *testing.T) {
+	config := &JSONConfig{
+		Port:       4000,
+		Env:        "development",
+		ApiKeys:    []string{"test"},
+		RateLimit:  100,
+		BearerAuth: BearerAuthConfig{JWKSURL: "https://idp.example.com/.well-known/jwks.json"},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bearer-auth.audience is required")
+}
+
+func TestValidate_TracingSampleRatio(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		Tracing:   TracingConfig{Endpoint: "http://localhost:4318/v1/traces", SampleRatio: 1.5},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "tracing.sample-ratio must be between 0 and 1")
+}
+
+func TestValidate_SentryDSN(t *testing.T) {
+	for _, dsn := range []string{"not a url", "https://sentry.example.com/1", "https://key@sentry.example.com", "ftp://key@sentry.example.com/1"} {
+		config := &JSONConfig{
+			Port:           4000,
+			Env:            "development",
+			ApiKeys:        []string{"test"},
+			RateLimit:      100,
+			ErrorReporting: ErrorReportingConfig{SentryDSN: dsn},
+		}
+		err := config.validate()
+		if assert.Error(t, err, dsn) {
+			assert.Contains(t, err.Error(), "error-reporting.sentry-dsn")
+		}
+	}
+
+	config := &JSONConfig{
+		Port:           4000,
+		Env:            "development",
+		ApiKeys:        []string{"test"},
+		RateLimit:      100,
+		ErrorReporting: ErrorReportingConfig{SentryDSN: "https://public@o1.ingest.sentry.io/42"},
+	}
+	assert.NoError(t, config.validate())
+}
+
 func TestToAppConfig(t *testing.T) {
 	jsonConfig := &JSONConfig{
 		Port:          8080,
@@ -163,6 +708,7 @@ func TestToAppConfig(t *testing.T) {
 		ApiKeys:       []string{"key1", "key2"},
 		RateLimit:     50,
 		ExemptApiKeys: []string{"exempt-key-1"},
+		AdminApiKeys:  []string{"admin-key"},
 	}
 
 	appConfig := jsonConfig.ToAppConfig()
@@ -173,6 +719,7 @@ func TestToAppConfig(t *testing.T) {
 	assert.Equal(t, 50, appConfig.RateLimit)
 	assert.True(t, appConfig.Verbose)
 	assert.Equal(t, []string{"exempt-key-1"}, appConfig.ExemptApiKeys)
+	assert.Equal(t, []string{"admin-key"}, appConfig.AdminApiKeys)
 }
 
 func TestToAppConfig_EnvironmentConversion(t *testing.T) {
@@ -230,7 +777,7 @@ func TestToGtfsConfigData_NoFeeds(t *testing.T) {
 	assert.Empty(t, gtfsConfig.RealTimeAuthHeaderValue)
 }
 
-func TestToGtfsConfigData_WithFirstFeed(t *testing.T) {
+func TestToGtfsConfigData_WithFeeds(t *testing.T) {
 	jsonConfig := &JSONConfig{
 		Port: 4000,
 		Env:  "production",
@@ -244,11 +791,12 @@ func TestToGtfsConfigData_WithFirstFeed(t *testing.T) {
 				ServiceAlertsURL:        "https://api.example.com/service-alerts.pb",
 				RealTimeAuthHeaderName:  "Authorization",
 				RealTimeAuthHeaderValue: "Bearer token123",
+				AgencyID:                "1",
 			},
 			{
-				// This second feed should be ignored
 				TripUpdatesURL:      "https://api.other.com/trip-updates.pb",
 				VehiclePositionsURL: "https://api.other.com/vehicle-positions.pb",
+				AgencyID:            "40",
 			},
 		},
 		DataPath: "/data/gtfs.db",
@@ -256,12 +804,75 @@ func TestToGtfsConfigData_WithFirstFeed(t *testing.T) {
 
 	gtfsConfig := jsonConfig.ToGtfsConfigData()
 
-	// Should use first feed only
+	// The first feed fills the flat fields
 	assert.Equal(t, "https://api.example.com/trip-updates.pb", gtfsConfig.TripUpdatesURL)
 	assert.Equal(t, "https://api.example.com/vehicle-positions.pb", gtfsConfig.VehiclePositionsURL)
 	assert.Equal(t, "https://api.example.com/service-alerts.pb", gtfsConfig.ServiceAlertsURL)
 	assert.Equal(t, "Authorization", gtfsConfig.RealTimeAuthHeaderKey)
 	assert.Equal(t, "Bearer token123", gtfsConfig.RealTimeAuthHeaderValue)
+	assert.Equal(t, "1", gtfsConfig.RealTimeAgencyID)
+
+	// The others are polled alongside it
+	require.Len(t, gtfsConfig.ExtraRealtimeFeeds, 1)
+	assert.Equal(t, "https://api.other.com/trip-updates.pb", gtfsConfig.ExtraRealtimeFeeds[0].TripUpdatesURL)
+	assert.Equal(t, "40", gtfsConfig.ExtraRealtimeFeeds[0].AgencyID)
+}
+
+func TestToGtfsConfigData_WithStaticFeeds(t *testing.T) {
+	jsonConfig := &JSONConfig{
+		Port: 4000,
+		Env:  "production",
+		GtfsStaticFeeds: []GtfsStaticFeed{
+			{URL: "https://kcm.example.com/gtfs.zip", IDPrefix: "kcm-", AuthHeaderName: "X-Key", AuthHeaderValue: "secret"},
+			{URL: "https://st.example.com/gtfs.zip"},
+		},
+		GtfsRtFeeds: []GtfsRtFeed{{TripUpdatesURL: "https://kcm.example.com/tu.pb", IDPrefix: "kcm-"}},
+	}
+
+	gtfsConfig := jsonConfig.ToGtfsConfigData()
+	assert.Equal(t, "https://kcm.example.com/gtfs.zip", gtfsConfig.GtfsURL)
+	assert.Equal(t, "kcm-", gtfsConfig.StaticIDPrefix)
+	assert.Equal(t, "X-Key", gtfsConfig.StaticAuthHeaderKey)
+	assert.Equal(t, "secret", gtfsConfig.StaticAuthHeaderValue)
+	require.Len(t, gtfsConfig.ExtraStaticFeeds, 1)
+	assert.Equal(t, "https://st.example.com/gtfs.zip", gtfsConfig.ExtraStaticFeeds[0].URL)
+	assert.Equal(t, "kcm-", gtfsConfig.RealTimeIDPrefix)
+}
+
+func TestValidate_StaticFeeds(t *testing.T) {
+	newConfig := func() *JSONConfig {
+		return &JSONConfig{
+			Port:            4000,
+			Env:             "development",
+			ApiKeys:         []string{"test"},
+			RateLimit:       100,
+			GtfsStaticFeeds: []GtfsStaticFeed{{URL: "https://example.com/a.zip"}, {URL: "https://example.com/b.zip", IDPrefix: "b-"}},
+		}
+	}
+	assert.NoError(t, newConfig().validate())
+
+	config := newConfig()
+	config.GtfsStaticFeed.URL = "https://example.com/c.zip"
+	assert.ErrorContains(t, config.validate(), "cannot both be set")
+
+	config = newConfig()
+	config.GtfsStaticFeeds[1].URL = ""
+	assert.ErrorContains(t, config.validate(), "gtfs-static-feeds[1].url is required")
+
+	config = newConfig()
+	config.GtfsStaticFeeds[1].IDPrefix = "b/"
+	assert.ErrorContains(t, config.validate(), "gtfs-static-feeds[1].id-prefix")
+
+	config = newConfig()
+	config.GtfsStaticFeeds[0].URL = "file:///etc/passwd"
+	assert.ErrorContains(t, config.validate(), "gtfs-static-feeds[0].url")
+
+	config = newConfig()
+	config.StaticRefreshInterval = 30
+	assert.ErrorContains(t, config.validate(), "static-refresh-interval must be at least 60 seconds")
+	config.StaticRefreshInterval = 3600
+	assert.NoError(t, config.validate())
+	assert.Equal(t, 3600, config.ToGtfsConfigData().StaticRefreshInterval)
 }
 
 func TestSetDefaults(t *testing.T) {
@@ -277,6 +888,8 @@ func TestSetDefaults(t *testing.T) {
 	assert.Len(t, config.GtfsRtFeeds, 1)
 	assert.Equal(t, "https://api.pugetsound.onebusaway.org/api/gtfs_realtime/trip-updates-for-agency/40.pb?key=org.onebusaway.iphone", config.GtfsRtFeeds[0].TripUpdatesURL)
 	assert.Equal(t, []string{"org.onebusaway.iphone"}, config.ExemptApiKeys)
+	assert.Equal(t, RequestLimitsConfig{MaxURLLength: DefaultMaxURLLength, MaxQueryParams: DefaultMaxQueryParams, MaxIDLength: DefaultMaxIDLength}, config.RequestLimits)
+	assert.Equal(t, DefaultCORSConfig(), config.CORS)
 }
 
 func TestSetDefaults_PartialConfig(t *testing.T) {
@@ -470,6 +1083,185 @@ func TestValidate_PartialAuthHeaders(t *testing.T) {
 	}
 }
 
+func TestLoadFromFiles_Overlays(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	prod := filepath.Join(dir, "prod.json")
+	require.NoError(t, os.WriteFile(base, []byte(`{
+		"port": 4000,
+		"env": "development",
+		"api-keys": ["base-key"],
+		"rate-limit": 20,
+		"gtfs-static-feed": {"url": "https://example.com/gtfs.zip", "enable-gtfs-tidy": true},
+		"gtfs-rt-feeds": [{"trip-updates-url": "https://example.com/trip-updates.pb"}],
+		"error-reporting": {"environment": "staging"}
+	}`), 0o600))
+	require.NoError(t, os.WriteFile(prod, []byte(`{
+		"env": "production",
+		"api-keys": ["prod-key"],
+		"gtfs-static-feed": {"auth-header-name": "X-Key", "auth-header-value": "secret"},
+		"error-reporting": null
+	}`), 0o600))
+
+	config, err := LoadFromFiles(base, prod)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4000, config.Port)
+	assert.Equal(t, 20, config.RateLimit)
+	assert.Equal(t, "production", config.Env)
+	assert.Equal(t, []string{"prod-key"}, config.ApiKeys, "arrays are replaced, not appended")
+	assert.Equal(t, "https://example.com/gtfs.zip", config.GtfsStaticFeed.URL)
+	assert.True(t, config.GtfsStaticFeed.EnableGTFSTidy)
+	assert.Equal(t, "secret", config.GtfsStaticFeed.AuthHeaderValue)
+	assert.Empty(t, config.ErrorReporting.Environment, "null removes a setting")
+
+	// Overlays apply in the order given
+	config, err = LoadFromFiles(prod, base)
+	require.NoError(t, err)
+	assert.Equal(t, "development", config.Env)
+	assert.Equal(t, []string{"base-key"}, config.ApiKeys)
+
+	// The merged result is validated, and errors name the offending overlay
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"port": -1}`), 0o600))
+	_, err = LoadFromFiles(base, bad)
+	assert.ErrorContains(t, err, "invalid configuration")
+
+	require.NoError(t, os.WriteFile(bad, []byte(`{"port": 1} {}`), 0o600))
+	_, err = LoadFromFiles(base, bad)
+	assert.ErrorContains(t, err, bad)
+}
+
+func TestLoadFromFiles_YAMLAndTOML(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	overlay := filepath.Join(dir, "prod.toml")
+	require.NoError(t, os.WriteFile(base, []byte(`# Shared settings
+port: 4000
+env: development
+api-keys: [base-key]
+rate-limit: 20
+gtfs-static-feed:
+  url: https://example.com/gtfs.zip
+  enable-gtfs-tidy: true
+gtfs-rt-feeds:
+  - trip-updates-url: https://example.com/trip-updates.pb
+fake-time:
+  start: 2025-06-12 08:00:00
+`), 0o600))
+	require.NoError(t, os.WriteFile(overlay, []byte(`api-keys = ["prod-key"]
+rate-limit = 50
+
+[fake-time]
+start = 2025-06-12T09:30:00
+
+[gtfs-static-feed]
+auth-header-name = "X-Key"
+auth-header-value = 'secret'
+
+[[gtfs-rt-feeds]]
+agency-id = "1"
+trip-updates-url = "https://example.com/1/trip-updates.pb"
+
+[[gtfs-rt-feeds]]
+agency-id = "40"
+vehicle-positions-url = "https://example.com/40/vehicle-positions.pb"
+`), 0o600))
+
+	config, err := LoadFromFiles(base, overlay)
+	require.NoError(t, err)
+	assert.Equal(t, 4000, config.Port)
+	assert.Equal(t, 50, config.RateLimit)
+	assert.Equal(t, []string{"prod-key"}, config.ApiKeys)
+	assert.Equal(t, "https://example.com/gtfs.zip", config.GtfsStaticFeed.URL)
+	assert.True(t, config.GtfsStaticFeed.EnableGTFSTidy)
+	assert.Equal(t, "secret", config.GtfsStaticFeed.AuthHeaderValue)
+	require.Len(t, config.GtfsRtFeeds, 2)
+	assert.Equal(t, "40", config.GtfsRtFeeds[1].AgencyID)
+	assert.Equal(t, "2025-06-12T09:30:00", config.FakeTime.Start, "a TOML local datetime is kept in local time")
+
+	config, err = LoadFromFiles(base)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-06-12 08:00:00", config.FakeTime.Start, "an unquoted YAML timestamp is kept as written")
+
+	// A .yml file is YAML too, and errors name the file and its format
+	bad := filepath.Join(dir, "bad.yml")
+	require.NoError(t, os.WriteFile(bad, []byte("port: [1\n"), 0o600))
+	_, err = LoadFromFiles(base, bad)
+	assert.ErrorContains(t, err, "failed to parse YAML config "+bad)
+
+	bad = filepath.Join(dir, "bad.toml")
+	require.NoError(t, os.WriteFile(bad, []byte("port = 4000\nport = 5000\n"), 0o600))
+	_, err = LoadFromFiles(base, bad)
+	assert.ErrorContains(t, err, "failed to parse TOML config "+bad+": toml: key port is already defined")
+
+	require.NoError(t, os.WriteFile(bad, []byte("port = 4000\nenv = \n"), 0o600))
+	_, err = LoadFromFiles(base, bad)
+	assert.ErrorContains(t, err, "failed to parse TOML config "+bad+": line 2: toml:")
+
+	// The merged result is validated whatever the format
+	require.NoError(t, os.WriteFile(bad, []byte("port = -1\n"), 0o600))
+	_, err = LoadFromFiles(base, bad)
+	assert.ErrorContains(t, err, "invalid configuration")
+}
+
+func TestLoadFromFiles_MigratesVersion1(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	overlay := filepath.Join(dir, "overlay.json")
+	require.NoError(t, os.WriteFile(base, []byte(`{
+		"port": 4000,
+		"gtfs-url": "https://example.com/gtfs.zip",
+		"gtfs-static-auth-header-name": "X-Key",
+		"trip-updates-url": "https://example.com/trip-updates.pb",
+		"realtime-auth-header-name": "Authorization",
+		"log-level": "debug"
+	}`), 0o600))
+	require.NoError(t, os.WriteFile(overlay, []byte(`{
+		"config-version": 2,
+		"gtfs-static-feed": {"auth-header-value": "secret"}
+	}`), 0o600))
+
+	config, err := LoadFromFiles(base, overlay)
+	require.NoError(t, err)
+
+	assert.Equal(t, CurrentConfigVersion, config.ConfigVersion)
+	assert.Equal(t, GtfsStaticFeed{URL: "https://example.com/gtfs.zip", AuthHeaderName: "X-Key", AuthHeaderValue: "secret"}, config.GtfsStaticFeed)
+	assert.Equal(t, []GtfsRtFeed{{TripUpdatesURL: "https://example.com/trip-updates.pb", RealTimeAuthHeaderName: "Authorization"}}, config.GtfsRtFeeds)
+	assert.Equal(t, "debug", config.Logging.Level)
+}
+
+func TestLoadFromFile_ConfigVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "current", content: `{"config-version": 2}`},
+		{name: "unversioned", content: `{"port": 4000}`},
+		{name: "newer", content: `{"config-version": 3}`, wantErr: "config-version 3 is not supported; this build reads versions 1 to 2"},
+		{name: "zero", content: `{"config-version": 0}`, wantErr: "config-version 0 is not supported"},
+		{name: "not an integer", content: `{"config-version": "2"}`, wantErr: "config-version must be an integer"},
+		{name: "old and new feed keys", content: `{"trip-updates-url": "https://example.com/a.pb", "gtfs-rt-feeds": []}`, wantErr: "trip-updates-url and gtfs-rt-feeds cannot both be set"},
+		{name: "old and new log level", content: `{"log-level": "warn", "logging": {"level": "info"}}`, wantErr: "log-level and logging.level cannot both be set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			config, err := LoadFromFile(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, CurrentConfigVersion, config.ConfigVersion)
+		})
+	}
+}
+
 func TestLoadFromFile_FileSizeLimit(t *testing.T) {
 	// Create a test config file that's too large (> 10MB)
 	// We'll just test the error case with a mock by checking file size validation works
@@ -571,3 +1363,97 @@ func TestLoadFromFile_EnvVarOverrides(t *testing.T) {
 		assert.Equal(t, "Env-Value", config.GtfsStaticFeed.AuthHeaderValue)
 	})
 }
+
+func TestValidate_ConcurrencyLimits(t *testing.T) {
+	base := func(limits ConcurrencyLimitsConfig) *JSONConfig {
+		return &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100, ConcurrencyLimits: limits}
+	}
+
+	assert.NoError(t, base(ConcurrencyLimitsConfig{"search": {MaxInFlight: 4, MaxWait: 500}}).validate())
+	assert.ErrorContains(t, base(ConcurrencyLimitsConfig{"everything": {MaxInFlight: 4}}).validate(), "unknown route group")
+	assert.ErrorContains(t, base(ConcurrencyLimitsConfig{"search": {MaxInFlight: 0}}).validate(), "max-in-flight")
+	assert.ErrorContains(t, base(ConcurrencyLimitsConfig{"trips": {MaxInFlight: 1, MaxWait: -1}}).validate(), "max-wait")
+}
+
+func TestValidate_KeyRestrictions(t *testing.T) {
+	base := func(restriction KeyRestriction) *JSONConfig {
+		return &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+			KeyRestrictions: map[string]KeyRestriction{"test": restriction}}
+	}
+
+	assert.NoError(t, base(KeyRestriction{AllowedOrigins: []string{"https://*.example.com"}, AllowedIPs: []string{"10.0.0.0/8", "192.0.2.1"}}).validate())
+	assert.ErrorContains(t, base(KeyRestriction{AllowedOrigins: []string{"example.com"}}).validate(), "allowed-origins")
+	assert.ErrorContains(t, base(KeyRestriction{AllowedOrigins: []string{"https://example.com/app"}}).validate(), "allowed-origins")
+	assert.ErrorContains(t, base(KeyRestriction{AllowedIPs: []string{"10.0.0.0/33"}}).validate(), "allowed-ips")
+}
+
+func TestLoadFromFile_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	keysFile := writeFile("api-keys", "key-one\nkey-two, key-three\n\n")
+	adminFile := writeFile("admin-keys", "admin-secret\n")
+	staticFile := writeFile("static-auth", "Bearer static-secret\n")
+	rtFile := writeFile("rt-auth", "Bearer rt-secret")
+	snapshotFile := writeFile("snapshot-secret", "s3-secret\n")
+	brokerFile := writeFile("broker-password", "nats-secret\n")
+	registryFile := writeFile("registry-token", "mdb-refresh\n")
+	webhookFile := writeFile("webhook-secret", "hook-secret\n")
+
+	configPath := writeFile("config.json", `{
+		"api-keys-file": "`+keysFile+`",
+		"admin-api-keys-file": "`+adminFile+`",
+		"gtfs-static-feed": {"url": "https://example.com/gtfs.zip", "auth-header-name": "Authorization", "auth-header-value-file": "`+staticFile+`"},
+		"gtfs-rt-feeds": [{"trip-updates-url": "https://example.com/tu", "realtime-auth-header-name": "Authorization", "realtime-auth-header-value-file": "`+rtFile+`"}],
+		"snapshot-upload": {"endpoint": "https://s3.example.com", "bucket": "maglev", "access-key-id": "AKID", "secret-access-key-file": "`+snapshotFile+`"},
+		"event-publishing": {"broker": "nats", "url": "nats://localhost:4222", "username": "maglev", "password-file": "`+brokerFile+`"},
+		"feed-registry": {"provider": "mobility-database", "static-feed-id": "mdb-1", "token-file": "`+registryFile+`"},
+		"alert-webhooks": [{"url": "https://hooks.example.com/alerts", "secret-file": "`+webhookFile+`"}]
+	}`)
+
+	t.Run("config fields", func(t *testing.T) {
+		config, err := LoadFromFile(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"key-one", "key-two", "key-three"}, config.ApiKeys)
+		assert.Equal(t, []string{"admin-secret"}, config.AdminApiKeys)
+		assert.Equal(t, "Bearer static-secret", config.GtfsStaticFeed.AuthHeaderValue)
+		assert.Equal(t, "Bearer rt-secret", config.GtfsRtFeeds[0].RealTimeAuthHeaderValue)
+		assert.Equal(t, "s3-secret", config.SnapshotUpload.SecretAccessKey)
+		assert.Equal(t, "nats-secret", config.EventPublishing.Password)
+		assert.Equal(t, "mdb-refresh", config.FeedRegistry.Token)
+		assert.Equal(t, "hook-secret", config.AlertWebhooks[0].Secret)
+	})
+
+	t.Run("environment variables", func(t *testing.T) {
+		t.Setenv("GTFS_API_KEYS_FILE", writeFile("env-keys", "env-key\n"))
+		t.Setenv("GTFS_REALTIME_AUTH_VALUE_FILE", writeFile("env-rt", "Bearer env-rt\n"))
+
+		config, err := LoadFromFile(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"env-key"}, config.ApiKeys)
+		assert.Equal(t, "Bearer env-rt", config.GtfsRtFeeds[0].RealTimeAuthHeaderValue)
+	})
+
+	t.Run("variable and file both set", func(t *testing.T) {
+		t.Setenv("GTFS_STATIC_AUTH_VALUE", "inline")
+		t.Setenv("GTFS_STATIC_AUTH_VALUE_FILE", staticFile)
+
+		_, err := LoadFromFile(configPath)
+		assert.ErrorContains(t, err, "only one of GTFS_STATIC_AUTH_VALUE and GTFS_STATIC_AUTH_VALUE_FILE")
+	})
+
+	t.Run("inline value and file both set", func(t *testing.T) {
+		path := writeFile("both.json", `{"api-keys": ["inline"], "api-keys-file": "`+keysFile+`"}`)
+		_, err := LoadFromFile(path)
+		assert.ErrorContains(t, err, "only one of api-keys and api-keys-file")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		path := writeFile("missing.json", `{"admin-api-keys-file": "`+filepath.Join(dir, "nope")+`"}`)
+		_, err := LoadFromFile(path)
+		assert.ErrorContains(t, err, "failed to open admin-api-keys-file")
+	})
+}
@@ -0,0 +1,177 @@
+package appconf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxSecretFileSize bounds secret files; real secrets are a few hundred bytes at most.
+const maxSecretFileSize = 64 * 1024
+
+// readSecretFile returns the contents of a secret file, such as a Docker or Kubernetes
+// secret, without the trailing newline these files usually end with. Symlinks are followed
+// because Kubernetes mounts secrets through them.
+func readSecretFile(path, fieldName string) (string, error) {
+	if err := validatePath(path, fieldName); err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", fieldName, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSecretFileSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", fieldName, err)
+	}
+	if len(data) > maxSecretFileSize {
+		return "", fmt.Errorf("%s is too large (max: %d bytes)", fieldName, maxSecretFileSize)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// envOrFile returns the value of the environment variable name or, following the Docker
+// convention, the contents of the file named by name_FILE. Setting both is an error.
+func envOrFile(name string) (string, error) {
+	value := os.Getenv(name)
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("only one of %s and %s_FILE may be set", name, name)
+	}
+	return readSecretFile(path, name+"_FILE")
+}
+
+// parseKeyList splits keys separated by commas or newlines, trimming spaces and dropping
+// empty entries, so a key file can hold one key per line.
+func parseKeyList(s string) []string {
+	var keys []string
+	for _, key := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if trimmed := strings.TrimSpace(key); trimmed != "" {
+			keys = append(keys, trimmed)
+		}
+	}
+	return keys
+}
+
+// resolveSecretFiles replaces the *-file settings with the contents of the files they name.
+// A setting may be given inline or as a file, not both.
+func (j *JSONConfig) resolveSecretFiles() error {
+	if j.ApiKeysFile != "" {
+		if len(j.ApiKeys) > 0 {
+			return fmt.Errorf("only one of api-keys and api-keys-file may be set")
+		}
+		contents, err := readSecretFile(j.ApiKeysFile, "api-keys-file")
+		if err != nil {
+			return err
+		}
+		j.ApiKeys = parseKeyList(contents)
+	}
+
+	if j.AdminApiKeysFile != "" {
+		if len(j.AdminApiKeys) > 0 {
+			return fmt.Errorf("only one of admin-api-keys and admin-api-keys-file may be set")
+		}
+		contents, err := readSecretFile(j.AdminApiKeysFile, "admin-api-keys-file")
+		if err != nil {
+			return err
+		}
+		j.AdminApiKeys = parseKeyList(contents)
+	}
+
+	if j.GtfsStaticFeed.AuthHeaderValueFile != "" {
+		if j.GtfsStaticFeed.AuthHeaderValue != "" {
+			return fmt.Errorf("only one of gtfs-static-feed.auth-header-value and auth-header-value-file may be set")
+		}
+		value, err := readSecretFile(j.GtfsStaticFeed.AuthHeaderValueFile, "gtfs-static-feed.auth-header-value-file")
+		if err != nil {
+			return err
+		}
+		j.GtfsStaticFeed.AuthHeaderValue = value
+	}
+
+	for i := range j.GtfsStaticFeeds {
+		feed := &j.GtfsStaticFeeds[i]
+		if feed.AuthHeaderValueFile == "" {
+			continue
+		}
+		if feed.AuthHeaderValue != "" {
+			return fmt.Errorf("only one of gtfs-static-feeds[%d].auth-header-value and auth-header-value-file may be set", i)
+		}
+		value, err := readSecretFile(feed.AuthHeaderValueFile, fmt.Sprintf("gtfs-static-feeds[%d].auth-header-value-file", i))
+		if err != nil {
+			return err
+		}
+		feed.AuthHeaderValue = value
+	}
+
+	for i := range j.GtfsRtFeeds {
+		feed := &j.GtfsRtFeeds[i]
+		if feed.RealTimeAuthHeaderValueFile == "" {
+			continue
+		}
+		fieldName := fmt.Sprintf("gtfs-rt-feeds[%d].realtime-auth-header-value-file", i)
+		if feed.RealTimeAuthHeaderValue != "" {
+			return fmt.Errorf("only one of gtfs-rt-feeds[%d].realtime-auth-header-value and realtime-auth-header-value-file may be set", i)
+		}
+		value, err := readSecretFile(feed.RealTimeAuthHeaderValueFile, fieldName)
+		if err != nil {
+			return err
+		}
+		feed.RealTimeAuthHeaderValue = value
+	}
+
+	if j.SnapshotUpload.SecretAccessKeyFile != "" {
+		if j.SnapshotUpload.SecretAccessKey != "" {
+			return fmt.Errorf("only one of snapshot-upload.secret-access-key and secret-access-key-file may be set")
+		}
+		value, err := readSecretFile(j.SnapshotUpload.SecretAccessKeyFile, "snapshot-upload.secret-access-key-file")
+		if err != nil {
+			return err
+		}
+		j.SnapshotUpload.SecretAccessKey = value
+	}
+
+	if j.EventPublishing.PasswordFile != "" {
+		if j.EventPublishing.Password != "" {
+			return fmt.Errorf("only one of event-publishing.password and password-file may be set")
+		}
+		value, err := readSecretFile(j.EventPublishing.PasswordFile, "event-publishing.password-file")
+		if err != nil {
+			return err
+		}
+		j.EventPublishing.Password = value
+	}
+
+	for i := range j.AlertWebhooks {
+		webhook := &j.AlertWebhooks[i]
+		if webhook.SecretFile == "" {
+			continue
+		}
+		if webhook.Secret != "" {
+			return fmt.Errorf("only one of alert-webhooks[%d].secret and secret-file may be set", i)
+		}
+		value, err := readSecretFile(webhook.SecretFile, fmt.Sprintf("alert-webhooks[%d].secret-file", i))
+		if err != nil {
+			return err
+		}
+		webhook.Secret = value
+	}
+
+	if j.FeedRegistry.TokenFile != "" {
+		if j.FeedRegistry.Token != "" {
+			return fmt.Errorf("only one of feed-registry.token and token-file may be set")
+		}
+		value, err := readSecretFile(j.FeedRegistry.TokenFile, "feed-registry.token-file")
+		if err != nil {
+			return err
+		}
+		j.FeedRegistry.Token = value
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+// Package archive records when vehicles actually reach their stops, for on-time performance
+// reporting. After each realtime refresh, the stop time updates in the trip updates feed are
+// followed until the feed reports the arrival as past. That time is stored in a SQLite table
+// with the scheduled time and the first prediction seen for the stop, then summarized by
+// agency, route, stop, day and hour of day.
+//
+// Only stops the feed reports are archived; delays propagated to later stops are not. A stop
+// whose update leaves the feed before its time has passed is archived with its last reported
+// time if that was within droppedArrivalWindow of the refresh, since feeds usually drop stops
+// once the vehicle has served them, and is discarded otherwise.
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/sqlitedb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// droppedArrivalWindow is how far ahead a stop's last reported time may be when its update
+// leaves the feed for it to still count as served.
+const droppedArrivalWindow = 5 * time.Minute
+
+// processTimeout bounds the handling of one refresh, including its schedule lookups.
+const processTimeout = time.Minute
+
+const archiveSchema = `
+CREATE TABLE IF NOT EXISTS arrivals (
+    service_date TEXT NOT NULL,
+    trip_id TEXT NOT NULL,
+    stop_sequence INTEGER NOT NULL,
+    agency_id TEXT NOT NULL,
+    route_id TEXT NOT NULL,
+    stop_id TEXT NOT NULL,
+    vehicle_id TEXT NOT NULL,
+    scheduled_hour INTEGER NOT NULL,
+    scheduled_arrival INTEGER NOT NULL,
+    predicted_arrival INTEGER,
+    predicted_at INTEGER,
+    actual_arrival INTEGER NOT NULL,
+    PRIMARY KEY (service_date, trip_id, stop_sequence)
+);
+CREATE INDEX IF NOT EXISTS idx_arrivals_agency_date ON arrivals (agency_id, service_date);
+CREATE INDEX IF NOT EXISTS idx_arrivals_route_date ON arrivals (route_id, service_date);
+CREATE INDEX IF NOT EXISTS idx_arrivals_stop_date ON arrivals (stop_id, service_date);`
+
+// Arrival is one archived arrival of a trip at a stop. IDs are the feed's own, without an
+// agency prefix.
+type Arrival struct {
+	ServiceDate  string // YYYYMMDD
+	AgencyID     string
+	RouteID      string
+	TripID       string
+	StopID       string
+	StopSequence int64
+	VehicleID    string
+	Scheduled    time.Time
+	Predicted    time.Time // The first prediction seen for the stop; zero when it was first seen as past
+	PredictedAt  time.Time // When Predicted was seen
+	Actual       time.Time
+}
+
+type visitKey struct {
+	serviceDate  string
+	tripID       string
+	stopSequence int64
+}
+
+// visit is a stop time update being followed until its arrival is past.
+type visit struct {
+	arrival Arrival
+	latest  time.Time // Most recently reported arrival
+	seen    bool      // Reported by the refresh being processed
+}
+
+// Archive follows trip updates and stores realized arrivals.
+type Archive struct {
+	db        *sql.DB
+	schedule  Schedule
+	retention time.Duration
+	clock     clock.Clock
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	pending *GTFS.RealtimeUpdate // Latest refresh not yet processed
+	wake    chan struct{}
+
+	// Owned by the processing goroutine
+	visits    map[visitKey]*visit
+	trips     map[string]*TripSchedule // nil for trips the schedule does not know
+	lastPrune time.Time
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// New opens (or creates) the archive database at cfg.DataPath. Scheduled times come from
+// schedule.
+func New(cfg appconf.ArrivalArchiveConfig, schedule Schedule, c clock.Clock, logger *slog.Logger) (*Archive, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	db, err := sqlitedb.Open(cfg.DataPath, archiveSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open arrival archive: %w", err)
+	}
+
+	return &Archive{
+		db:        db,
+		schedule:  schedule,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		clock:     c,
+		logger:    logger.With(slog.String("component", "arrival_archive")),
+		wake:      make(chan struct{}, 1),
+		visits:    make(map[visitKey]*visit),
+		trips:     make(map[string]*TripSchedule),
+		stopChan:  make(chan struct{}),
+	}, nil
+}
+
+// Start processes ingested refreshes in the background until Shutdown.
+func (a *Archive) Start() {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		for {
+			select {
+			case <-a.stopChan:
+				return
+			case <-a.wake:
+				a.mu.Lock()
+				update := a.pending
+				a.pending = nil
+				a.mu.Unlock()
+				if update == nil {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), processTimeout)
+				if err := a.Process(ctx, *update); err != nil {
+					logging.LogError(a.logger, "failed to archive arrivals", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Ingest queues a refresh for processing and returns at once; it is the manager's realtime
+// update hook. A refresh still waiting from before is replaced, unless the newer one did not
+// load trip updates.
+func (a *Archive) Ingest(update GTFS.RealtimeUpdate) {
+	if update.Trips == nil {
+		return
+	}
+	a.mu.Lock()
+	a.pending = &update
+	a.mu.Unlock()
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Process follows the trip updates in update and stores the arrivals that became past. It must
+// not be called concurrently with itself or with the background processing.
+func (a *Archive) Process(ctx context.Context, update GTFS.RealtimeUpdate) error {
+	if update.Trips == nil {
+		return nil
+	}
+	now := a.clock.Now()
+
+	for _, v := range a.visits {
+		v.seen = false
+	}
+	inFeed := make(map[string]bool, len(update.Trips))
+	for _, trip := range update.Trips {
+		if trip.ID.ScheduleRelationship != gtfsrt.TripDescriptor_SCHEDULED {
+			continue
+		}
+		inFeed[trip.ID.ID] = true
+		schedule := a.tripSchedule(ctx, trip.ID.ID)
+		if schedule == nil {
+			continue
+		}
+		a.follow(trip, schedule, now)
+	}
+
+	var arrivals []Arrival
+	for key, v := range a.visits {
+		switch {
+		case !v.latest.After(now):
+			v.arrival.Actual = v.latest
+		case !v.seen && v.latest.Before(now.Add(droppedArrivalWindow)):
+			v.arrival.Actual = v.latest
+		case !v.seen:
+			delete(a.visits, key)
+			continue
+		default:
+			continue
+		}
+		arrivals = append(arrivals, v.arrival)
+		delete(a.visits, key)
+	}
+
+	// Forget the schedules of trips that left the feed
+	for tripID := range a.trips {
+		if !inFeed[tripID] {
+			delete(a.trips, tripID)
+		}
+	}
+
+	if err := a.save(ctx, arrivals); err != nil {
+		return err
+	}
+	if a.retention > 0 && now.Sub(a.lastPrune) >= time.Hour {
+		cutoff := now.Add(-a.retention).Format("20060102")
+		if _, err := a.db.ExecContext(ctx, "DELETE FROM arrivals WHERE service_date < ?", cutoff); err != nil {
+			return fmt.Errorf("failed to delete expired arrivals: %w", err)
+		}
+		a.lastPrune = now
+	}
+	return nil
+}
+
+// tripSchedule returns the cached schedule for tripID, looking it up on first use.
+func (a *Archive) tripSchedule(ctx context.Context, tripID string) *TripSchedule {
+	if schedule, ok := a.trips[tripID]; ok {
+		return schedule
+	}
+	schedule, err := a.schedule.TripSchedule(ctx, tripID)
+	if err != nil {
+		a.logger.Debug("trip not in the static schedule", slog.String("tripID", tripID), slog.Any("error", err))
+		a.trips[tripID] = nil
+		return nil
+	}
+	a.trips[tripID] = &schedule
+	return &schedule
+}
+
+// follow records the arrival times that trip reports for its scheduled stops.
+func (a *Archive) follow(trip gtfs.Trip, schedule *TripSchedule, now time.Time) {
+	var serviceDay time.Time
+	if trip.ID.HasStartDate {
+		start := trip.ID.StartDate
+		serviceDay = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, schedule.Location)
+	} else {
+		localNow := now.In(schedule.Location)
+		serviceDay = time.Date(localNow.Year(), localNow.Month(), localNow.Day(), 0, 0, 0, 0, schedule.Location)
+		// Shortly after midnight, a trip that starts late in the day is still on yesterday's service
+		if len(schedule.Stops) > 0 && utils.ServiceTime(serviceDay, schedule.Stops[0].Arrival).Sub(now) > 12*time.Hour {
+			serviceDay = serviceDay.AddDate(0, 0, -1)
+		}
+	}
+	serviceDate := serviceDay.Format("20060102")
+
+	var vehicleID string
+	if trip.Vehicle != nil && trip.Vehicle.ID != nil {
+		vehicleID = trip.Vehicle.ID.ID
+	}
+
+	for _, update := range trip.StopTimeUpdates {
+		if update.ScheduleRelationship != gtfsrt.TripUpdate_StopTimeUpdate_SCHEDULED {
+			continue
+		}
+		stop, ok := schedule.stop(update)
+		if !ok {
+			continue
+		}
+		scheduled := utils.ServiceTime(serviceDay, stop.Arrival)
+		event := update.Arrival
+		if event == nil || (event.Time == nil && event.Delay == nil) {
+			event = update.Departure
+		}
+		var reported time.Time
+		switch {
+		case event == nil:
+			continue
+		case event.Time != nil:
+			reported = *event.Time
+		case event.Delay != nil:
+			reported = scheduled.Add(*event.Delay)
+		default:
+			continue
+		}
+
+		key := visitKey{serviceDate, trip.ID.ID, stop.Sequence}
+		v, ok := a.visits[key]
+		if !ok {
+			v = &visit{arrival: Arrival{
+				ServiceDate:  serviceDate,
+				AgencyID:     schedule.AgencyID,
+				RouteID:      schedule.RouteID,
+				TripID:       trip.ID.ID,
+				StopID:       stop.StopID,
+				StopSequence: stop.Sequence,
+				Scheduled:    scheduled,
+			}}
+			// A stop first seen as already past was never predicted while we watched
+			if reported.After(now) {
+				v.arrival.Predicted = reported
+				v.arrival.PredictedAt = now
+			}
+			a.visits[key] = v
+		}
+		if vehicleID != "" {
+			v.arrival.VehicleID = vehicleID
+		}
+		v.latest = reported
+		v.seen = true
+	}
+}
+
+func (a *Archive) save(ctx context.Context, arrivals []Arrival) error {
+	if len(arrivals) == 0 {
+		return nil
+	}
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin arrival archive transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Feeds often keep reporting served stops, so an arrival is only stored the first time
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO arrivals (service_date, trip_id, stop_sequence, agency_id, route_id, stop_id, vehicle_id,
+			scheduled_hour, scheduled_arrival, predicted_arrival, predicted_at, actual_arrival)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (service_date, trip_id, stop_sequence) DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare arrival insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, arrival := range arrivals {
+		var predicted, predictedAt sql.NullInt64
+		if !arrival.Predicted.IsZero() {
+			predicted = sql.NullInt64{Int64: arrival.Predicted.Unix(), Valid: true}
+			predictedAt = sql.NullInt64{Int64: arrival.PredictedAt.Unix(), Valid: true}
+		}
+		if _, err := stmt.ExecContext(ctx,
+			arrival.ServiceDate, arrival.TripID, arrival.StopSequence, arrival.AgencyID, arrival.RouteID,
+			arrival.StopID, arrival.VehicleID, arrival.Scheduled.Hour(), arrival.Scheduled.Unix(),
+			predicted, predictedAt, arrival.Actual.Unix()); err != nil {
+			return fmt.Errorf("failed to save arrival: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Shutdown stops processing, waiting for a refresh in progress, and closes the database. It is
+// safe to call multiple times.
+func (a *Archive) Shutdown() {
+	a.stopOnce.Do(func() {
+		close(a.stopChan)
+		a.wg.Wait()
+		if err := a.db.Close(); err != nil {
+			logging.LogError(a.logger, "failed to close arrival archive", err)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+type fakeSchedule map[string]TripSchedule
+
+func (s fakeSchedule) TripSchedule(_ context.Context, tripID string) (TripSchedule, error) {
+	if schedule, ok := s[tripID]; ok {
+		return schedule, nil
+	}
+	return TripSchedule{}, errors.New("trip not found")
+}
+
+var testSchedule = fakeSchedule{
+	"T1": {
+		AgencyID: "A",
+		RouteID:  "R1",
+		Location: time.UTC,
+		Stops: []ScheduledStop{
+			{StopID: "S1", Sequence: 1, Arrival: 12 * time.Hour},
+			{StopID: "S2", Sequence: 2, Arrival: 12*time.Hour + 10*time.Minute},
+			{StopID: "S3", Sequence: 3, Arrival: 12*time.Hour + 20*time.Minute},
+		},
+	},
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2025, 6, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func stopAt(sequence uint32, arrival time.Time) gtfs.StopTimeUpdate {
+	return gtfs.StopTimeUpdate{StopSequence: &sequence, Arrival: &gtfs.StopTimeEvent{Time: &arrival}}
+}
+
+func stopDelayed(stopID string, delay time.Duration) gtfs.StopTimeUpdate {
+	return gtfs.StopTimeUpdate{StopID: &stopID, Arrival: &gtfs.StopTimeEvent{Delay: &delay}}
+}
+
+func trip(id string, updates ...gtfs.StopTimeUpdate) gtfs.Trip {
+	return gtfs.Trip{
+		ID:              gtfs.TripID{ID: id, HasStartDate: true, StartDate: at(0, 0)},
+		StopTimeUpdates: updates,
+		Vehicle:         &gtfs.Vehicle{ID: &gtfs.VehicleID{ID: "V9"}},
+	}
+}
+
+func TestArchive_FollowsTripsToTheirArrivals(t *testing.T) {
+	mockClock := clock.NewMockClock(at(11, 55))
+	archive, err := New(appconf.ArrivalArchiveConfig{DataPath: ":memory:", RetentionDays: 30}, testSchedule, mockClock, nil)
+	require.NoError(t, err)
+	defer archive.Shutdown()
+	ctx := context.Background()
+	filter := Filter{AgencyID: "A", StartDate: "20250601", EndDate: "20250601"}
+
+	canceled := trip("T1")
+	canceled.ID.ScheduleRelationship = gtfsrt.TripDescriptor_CANCELED
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{
+		trip("T1", stopAt(1, at(12, 2)), stopDelayed("S2", 3*time.Minute), stopAt(3, at(12, 25))),
+		trip("UNKNOWN", stopAt(1, at(12, 0))),
+		canceled,
+	}}))
+
+	// S1 is reported as past; S2 is still to come
+	mockClock.Set(at(12, 3))
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{
+		trip("T1", stopAt(1, at(12, 2)), stopDelayed("S2", 2*time.Minute), stopAt(3, at(12, 25))),
+	}}))
+	arrivals, err := archive.Arrivals(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, arrivals, 1)
+	assert.Equal(t, Arrival{
+		ServiceDate: "20250601", AgencyID: "A", RouteID: "R1", TripID: "T1", StopID: "S1", StopSequence: 1, VehicleID: "V9",
+		Scheduled: at(12, 0), Predicted: at(12, 2), PredictedAt: at(11, 55), Actual: at(12, 2),
+	}, arrivals[0])
+
+	// A refresh without trip updates changes nothing
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{}))
+
+	// S2 leaves the feed two minutes before its last reported time, so it was served; S1 is
+	// still reported but only archived once
+	mockClock.Set(at(12, 10))
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{
+		trip("T1", stopAt(1, at(12, 2)), stopAt(3, at(12, 26))),
+	}}))
+
+	// The trip leaves the feed once S3 is past
+	mockClock.Set(at(12, 30))
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{}}))
+
+	arrivals, err = archive.Arrivals(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, arrivals, 3)
+	assert.Equal(t, at(12, 12), arrivals[1].Actual)
+	assert.Equal(t, at(12, 13), arrivals[1].Predicted)
+	assert.Equal(t, at(12, 26), arrivals[2].Actual)
+
+	summary, err := archive.Performance(ctx, filter, GroupNone, time.Minute, 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []Bucket{{
+		Arrivals: 3, OnTime: 2, Late: 1,
+		AverageDelay: 200 * time.Second,
+		Predicted:    3, AveragePrediction: 40 * time.Second,
+	}}, summary)
+
+	byStop, err := archive.Performance(ctx, filter, GroupStop, time.Minute, 5*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, byStop, 3)
+	assert.Equal(t, "S3", byStop[2].Key)
+	assert.Equal(t, int64(1), byStop[2].Late)
+
+	byHour, err := archive.Performance(ctx, filter, GroupHour, time.Minute, 5*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, byHour, 1)
+	assert.Equal(t, "12", byHour[0].Key)
+
+	filter.RouteID = "R2"
+	summary, err = archive.Performance(ctx, filter, GroupNone, time.Minute, 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []Bucket{{}}, summary)
+	byDay, err := archive.Performance(ctx, filter, GroupDay, time.Minute, 5*time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, byDay)
+}
+
+func TestArchive_DiscardsStopsThatVanish(t *testing.T) {
+	mockClock := clock.NewMockClock(at(11, 0))
+	archive, err := New(appconf.ArrivalArchiveConfig{DataPath: ":memory:"}, testSchedule, mockClock, nil)
+	require.NoError(t, err)
+	defer archive.Shutdown()
+	ctx := context.Background()
+
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{trip("T1", stopAt(1, at(12, 0)))}}))
+	// The stop leaves the feed an hour ahead of its time, e.g. when the trip is withdrawn
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{}}))
+	mockClock.Set(at(13, 0))
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{}}))
+
+	arrivals, err := archive.Arrivals(ctx, Filter{AgencyID: "A", StartDate: "20250601", EndDate: "20250601"})
+	require.NoError(t, err)
+	assert.Empty(t, arrivals)
+}
+
+func TestArchive_Retention(t *testing.T) {
+	mockClock := clock.NewMockClock(at(12, 5))
+	archive, err := New(appconf.ArrivalArchiveConfig{DataPath: ":memory:", RetentionDays: 2}, testSchedule, mockClock, nil)
+	require.NoError(t, err)
+	defer archive.Shutdown()
+	ctx := context.Background()
+
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{trip("T1", stopAt(1, at(12, 1)))}}))
+	filter := Filter{AgencyID: "A", StartDate: "20250101", EndDate: "20251231"}
+	arrivals, err := archive.Arrivals(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, arrivals, 1)
+	assert.True(t, arrivals[0].Predicted.IsZero(), "a stop first seen as past was never predicted")
+
+	mockClock.Advance(3 * 24 * time.Hour)
+	require.NoError(t, archive.Process(ctx, GTFS.RealtimeUpdate{Trips: []gtfs.Trip{}}))
+	arrivals, err = archive.Arrivals(ctx, filter)
+	require.NoError(t, err)
+	assert.Empty(t, arrivals)
+}
@@ -0,0 +1,108 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Headway ratios for bunching and gaps, as fractions of the scheduled headway
+const (
+	BunchedRatio = 0.5 // A vehicle this close behind the one before it is bunched
+	GappedRatio  = 1.5 // A vehicle this far behind the one before it leaves a gap
+)
+
+// HeadwayBucket summarizes how evenly spaced a group of arrivals was. Each archived arrival
+// after the first of the day at its stop and route has an observed headway, the time since the
+// previous vehicle reached the stop, and a scheduled headway, the time since the previous
+// scheduled arrival.
+type HeadwayBucket struct {
+	Key              string
+	Headways         int64
+	ScheduledHeadway time.Duration // Mean scheduled headway
+	ObservedHeadway  time.Duration // Mean observed headway
+	Deviation        float64       // Standard deviation of the headway deviations over the mean scheduled headway
+	Bunched          int64         // Observed headways under BunchedRatio of the scheduled one
+	Gapped           int64         // Observed headways over GappedRatio of the scheduled one
+	ScheduledWait    time.Duration // Mean wait of a rider arriving at random, as scheduled
+	ExcessWait       time.Duration // How much longer that rider actually waited
+}
+
+// Headways summarizes the headways of the arrivals matching filter whose scheduled headway is
+// at most frequent, grouped by group and ordered by key. Less frequent service is timed by
+// its schedule, for which Performance is the better measure. With GroupNone, the single bucket
+// is returned even when no headways match.
+//
+// Headways are measured within a route at a stop, so a stop served by both directions of a
+// route mixes them.
+func (a *Archive) Headways(ctx context.Context, filter Filter, group string, frequent time.Duration) ([]HeadwayBucket, error) {
+	key, ok := groupKeys[group]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q", group)
+	}
+	where, args := filter.where()
+	args = append([]any{BunchedRatio, GappedRatio}, args...)
+	args = append(args, frequent.Seconds())
+
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT `+key+` AS bucket,
+			COUNT(*),
+			SUM(scheduled_headway),
+			SUM(scheduled_headway * scheduled_headway),
+			SUM(observed_headway),
+			SUM(observed_headway * observed_headway),
+			SUM(observed_headway - scheduled_headway),
+			SUM((observed_headway - scheduled_headway) * (observed_headway - scheduled_headway)),
+			SUM(observed_headway < ? * scheduled_headway),
+			SUM(observed_headway > ? * scheduled_headway)
+		FROM (
+			SELECT *,
+				scheduled_arrival - LAG(scheduled_arrival) OVER (
+					PARTITION BY service_date, route_id, stop_id ORDER BY scheduled_arrival) AS scheduled_headway,
+				actual_arrival - LAG(actual_arrival) OVER (
+					PARTITION BY service_date, route_id, stop_id ORDER BY actual_arrival) AS observed_headway
+			FROM arrivals WHERE `+where+`
+		)
+		WHERE scheduled_headway > 0 AND scheduled_headway <= ? AND observed_headway IS NOT NULL
+		GROUP BY bucket ORDER BY bucket`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load headways: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	buckets := []HeadwayBucket{}
+	for rows.Next() {
+		var bucket HeadwayBucket
+		var scheduled, scheduledSquares, observed, observedSquares, deviation, deviationSquares float64
+		if err := rows.Scan(&bucket.Key, &bucket.Headways, &scheduled, &scheduledSquares, &observed,
+			&observedSquares, &deviation, &deviationSquares, &bucket.Bunched, &bucket.Gapped); err != nil {
+			return nil, fmt.Errorf("failed to scan headways: %w", err)
+		}
+		n := float64(bucket.Headways)
+		bucket.ScheduledHeadway = seconds(scheduled / n)
+		bucket.ObservedHeadway = seconds(observed / n)
+		if scheduled > 0 {
+			meanDeviation := deviation / n
+			bucket.Deviation = math.Sqrt(max(deviationSquares/n-meanDeviation*meanDeviation, 0)) / (scheduled / n)
+			// A rider arriving at random waits half of the headway they land in, and lands in
+			// long headways more often: the mean wait is the sum of squares over twice the sum
+			bucket.ScheduledWait = seconds(scheduledSquares / (2 * scheduled))
+			if observed > 0 {
+				bucket.ExcessWait = seconds(observedSquares/(2*observed)) - bucket.ScheduledWait
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if group == GroupNone && len(buckets) == 0 {
+		buckets = append(buckets, HeadwayBucket{})
+	}
+	return buckets, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
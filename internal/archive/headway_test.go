@@ -0,0 +1,59 @@
+package archive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestArchive_Headways(t *testing.T) {
+	archive, err := New(appconf.ArrivalArchiveConfig{DataPath: ":memory:"}, testSchedule, clock.NewMockClock(at(14, 0)), nil)
+	require.NoError(t, err)
+	defer archive.Shutdown()
+	ctx := context.Background()
+
+	arrival := func(tripID, routeID string, scheduled, actual time.Time) Arrival {
+		return Arrival{ServiceDate: "20250601", AgencyID: "A", RouteID: routeID, TripID: tripID, StopID: "S1",
+			StopSequence: 1, Scheduled: scheduled, Actual: actual}
+	}
+	// Every ten minutes as scheduled; the third trip catches up with the second, leaving a gap
+	// behind it. The hourly route is not frequent service.
+	require.NoError(t, archive.save(ctx, []Arrival{
+		arrival("T1", "R1", at(12, 0), at(12, 0)),
+		arrival("T2", "R1", at(12, 10), at(12, 12)),
+		arrival("T3", "R1", at(12, 20), at(12, 14)),
+		arrival("T4", "R1", at(12, 30), at(12, 30)),
+		arrival("H1", "R2", at(12, 0), at(12, 0)),
+		arrival("H2", "R2", at(13, 0), at(13, 40)),
+	}))
+
+	filter := Filter{AgencyID: "A", StartDate: "20250601", EndDate: "20250601"}
+	buckets, err := archive.Headways(ctx, filter, GroupNone, 15*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	summary := buckets[0]
+	assert.Equal(t, int64(3), summary.Headways)
+	assert.Equal(t, 10*time.Minute, summary.ScheduledHeadway)
+	assert.Equal(t, 10*time.Minute, summary.ObservedHeadway)
+	assert.Equal(t, int64(1), summary.Bunched)
+	assert.Equal(t, int64(1), summary.Gapped)
+	assert.InDelta(t, 0.589, summary.Deviation, 0.001)
+	assert.Equal(t, 5*time.Minute, summary.ScheduledWait)
+	assert.InDelta(t, 104, summary.ExcessWait.Seconds(), 0.5)
+
+	buckets, err = archive.Headways(ctx, filter, GroupRoute, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, buckets, 2)
+	assert.Equal(t, "R2", buckets[1].Key)
+	assert.Equal(t, int64(1), buckets[1].Gapped)
+
+	filter.StartDate, filter.EndDate = "20250602", "20250602"
+	buckets, err = archive.Headways(ctx, filter, GroupNone, 15*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []HeadwayBucket{{}}, buckets)
+}
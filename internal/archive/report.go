@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Groupings for Performance
+const (
+	GroupNone  = ""      // One bucket with every arrival; its key is empty
+	GroupDay   = "day"   // By service date, YYYYMMDD
+	GroupHour  = "hour"  // By scheduled hour of day in the agency's time zone, 00 to 23
+	GroupRoute = "route" // By route ID
+	GroupStop  = "stop"  // By stop ID
+)
+
+var groupKeys = map[string]string{
+	GroupNone:  "''",
+	GroupDay:   "service_date",
+	GroupHour:  "printf('%02d', scheduled_hour)",
+	GroupRoute: "route_id",
+	GroupStop:  "stop_id",
+}
+
+// Filter selects archived arrivals. AgencyID and the date range are required; RouteID and
+// StopID narrow it further when set.
+type Filter struct {
+	AgencyID  string
+	RouteID   string
+	StopID    string
+	StartDate string // First service date, YYYYMMDD
+	EndDate   string // Last service date, inclusive
+}
+
+func (f Filter) where() (string, []any) {
+	conditions := []string{"agency_id = ?", "service_date BETWEEN ? AND ?"}
+	args := []any{f.AgencyID, f.StartDate, f.EndDate}
+	if f.RouteID != "" {
+		conditions = append(conditions, "route_id = ?")
+		args = append(args, f.RouteID)
+	}
+	if f.StopID != "" {
+		conditions = append(conditions, "stop_id = ?")
+		args = append(args, f.StopID)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// Bucket summarizes the punctuality of a group of arrivals. An arrival is early when it is more
+// than the early threshold ahead of schedule, late when it is more than the late threshold
+// behind, and on time otherwise.
+type Bucket struct {
+	Key               string
+	Arrivals          int64
+	Early             int64
+	OnTime            int64
+	Late              int64
+	AverageDelay      time.Duration // Negative when early on average
+	Predicted         int64         // Arrivals that were predicted before they happened
+	AveragePrediction time.Duration // Mean absolute error of the first prediction
+}
+
+// Performance summarizes the arrivals matching filter, grouped by group and ordered by key.
+// With GroupNone, the single bucket is returned even when no arrivals match.
+func (a *Archive) Performance(ctx context.Context, filter Filter, group string, early, late time.Duration) ([]Bucket, error) {
+	key, ok := groupKeys[group]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q", group)
+	}
+	where, args := filter.where()
+	args = append([]any{-early.Seconds(), -early.Seconds(), late.Seconds(), late.Seconds()}, args...)
+
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT `+key+` AS bucket,
+			COUNT(*),
+			COALESCE(SUM(delay < ?), 0),
+			COALESCE(SUM(delay >= ? AND delay <= ?), 0),
+			COALESCE(SUM(delay > ?), 0),
+			AVG(delay),
+			COUNT(predicted_arrival),
+			AVG(ABS(actual_arrival - predicted_arrival))
+		FROM (SELECT *, actual_arrival - scheduled_arrival AS delay FROM arrivals WHERE `+where+`)
+		GROUP BY bucket ORDER BY bucket`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load on-time performance: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	buckets := []Bucket{}
+	for rows.Next() {
+		var bucket Bucket
+		var delay, predictionError sql.NullFloat64
+		if err := rows.Scan(&bucket.Key, &bucket.Arrivals, &bucket.Early, &bucket.OnTime, &bucket.Late,
+			&delay, &bucket.Predicted, &predictionError); err != nil {
+			return nil, fmt.Errorf("failed to scan on-time performance: %w", err)
+		}
+		bucket.AverageDelay = time.Duration(delay.Float64 * float64(time.Second))
+		bucket.AveragePrediction = time.Duration(predictionError.Float64 * float64(time.Second))
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if group == GroupNone && len(buckets) == 0 {
+		buckets = append(buckets, Bucket{})
+	}
+	return buckets, nil
+}
+
+// Arrivals returns the arrivals matching filter, ordered by service date, trip and stop
+// sequence, for export.
+func (a *Archive) Arrivals(ctx context.Context, filter Filter) ([]Arrival, error) {
+	where, args := filter.where()
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT service_date, agency_id, route_id, trip_id, stop_id, stop_sequence, vehicle_id,
+			scheduled_arrival, predicted_arrival, predicted_at, actual_arrival
+		FROM arrivals WHERE `+where+`
+		ORDER BY service_date, trip_id, stop_sequence`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load arrivals: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	arrivals := []Arrival{}
+	for rows.Next() {
+		var arrival Arrival
+		var scheduled, actual int64
+		var predicted, predictedAt sql.NullInt64
+		if err := rows.Scan(&arrival.ServiceDate, &arrival.AgencyID, &arrival.RouteID, &arrival.TripID,
+			&arrival.StopID, &arrival.StopSequence, &arrival.VehicleID, &scheduled, &predicted, &predictedAt, &actual); err != nil {
+			return nil, fmt.Errorf("failed to scan arrival: %w", err)
+		}
+		arrival.Scheduled = time.Unix(scheduled, 0).UTC()
+		arrival.Actual = time.Unix(actual, 0).UTC()
+		if predicted.Valid {
+			arrival.Predicted = time.Unix(predicted.Int64, 0).UTC()
+			arrival.PredictedAt = time.Unix(predictedAt.Int64, 0).UTC()
+		}
+		arrivals = append(arrivals, arrival)
+	}
+	return arrivals, rows.Err()
+}
@@ -0,0 +1,96 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// ScheduledStop is a stop on a trip's static schedule.
+type ScheduledStop struct {
+	StopID   string
+	Sequence int64
+	Arrival  time.Duration // Since the start of the service day
+}
+
+// TripSchedule is the static schedule of one trip.
+type TripSchedule struct {
+	AgencyID string
+	RouteID  string
+	Location *time.Location  // The agency's time zone
+	Stops    []ScheduledStop // In stop sequence order
+}
+
+// stop finds the scheduled stop a stop time update refers to, by stop sequence when the feed
+// gives one and by stop ID otherwise.
+func (s *TripSchedule) stop(update gtfs.StopTimeUpdate) (ScheduledStop, bool) {
+	for _, stop := range s.Stops {
+		if update.StopSequence != nil {
+			if stop.Sequence == int64(*update.StopSequence) {
+				return stop, true
+			}
+		} else if update.StopID != nil && stop.StopID == *update.StopID {
+			return stop, true
+		}
+	}
+	return ScheduledStop{}, false
+}
+
+// Schedule looks up the static schedule of trips.
+type Schedule interface {
+	TripSchedule(ctx context.Context, tripID string) (TripSchedule, error)
+}
+
+// ManagerSchedule returns a Schedule that reads the manager's current static data.
+func ManagerSchedule(manager *GTFS.Manager) Schedule {
+	return managerSchedule{manager}
+}
+
+type managerSchedule struct {
+	manager *GTFS.Manager
+}
+
+func (s managerSchedule) TripSchedule(ctx context.Context, tripID string) (TripSchedule, error) {
+	s.manager.RLock()
+	defer s.manager.RUnlock()
+	if s.manager.GtfsDB == nil {
+		return TripSchedule{}, errors.New("no static data loaded")
+	}
+	queries := s.manager.GtfsDB.Queries
+
+	trip, err := queries.GetTrip(ctx, tripID)
+	if err != nil {
+		return TripSchedule{}, err
+	}
+	route, err := queries.GetRoute(ctx, trip.RouteID)
+	if err != nil {
+		return TripSchedule{}, err
+	}
+	agency, err := queries.GetAgency(ctx, route.AgencyID)
+	if err != nil {
+		return TripSchedule{}, err
+	}
+	stopTimes, err := queries.GetStopTimesForTrip(ctx, tripID)
+	if err != nil {
+		return TripSchedule{}, err
+	}
+
+	schedule := TripSchedule{
+		AgencyID: agency.ID,
+		RouteID:  route.ID,
+		Location: utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID),
+		Stops:    make([]ScheduledStop, 0, len(stopTimes)),
+	}
+	for _, stopTime := range stopTimes {
+		schedule.Stops = append(schedule.Stops, ScheduledStop{
+			StopID:   stopTime.StopID,
+			Sequence: stopTime.StopSequence,
+			Arrival:  time.Duration(stopTime.ArrivalTime),
+		})
+	}
+	return schedule, nil
+}
@@ -0,0 +1,107 @@
+// Package audit records administrative actions so operators can see who changed what and when.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"maglev.onebusaway.org/internal/sqlitedb"
+)
+
+const auditSchema = `
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recorded_at INTEGER NOT NULL,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    params TEXT NOT NULL,
+    status INTEGER NOT NULL
+);`
+
+// Entry is a single recorded action.
+type Entry struct {
+	ID     int64
+	Time   time.Time
+	Actor  string            // API key (or "SIGHUP", "config-watch") that performed the action
+	Action string            // e.g. "gtfs.refresh"
+	Params map[string]string // Request parameters, without the API key
+	Status int               // HTTP status the action completed with
+}
+
+// Log stores entries in a standalone SQLite database, like the quota counters, so the
+// history survives both restarts and GTFS dataset swaps.
+type Log struct {
+	db *sql.DB
+}
+
+// Open opens (or creates) the audit database at path. An empty path keeps the log in
+// memory, so actions are still queryable until the process exits.
+func Open(path string) (*Log, error) {
+	if path == "" {
+		path = ":memory:"
+	}
+	db, err := sqlitedb.Open(path, auditSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+
+	return &Log{db: db}, nil
+}
+
+// Record appends entry to the log. Entry.ID is ignored.
+func (l *Log) Record(ctx context.Context, entry Entry) error {
+	params, err := json.Marshal(entry.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit parameters: %w", err)
+	}
+
+	_, err = l.db.ExecContext(ctx,
+		"INSERT INTO audit_log (recorded_at, actor, action, params, status) VALUES (?, ?, ?, ?, ?)",
+		entry.Time.UnixMilli(), entry.Actor, entry.Action, string(params), entry.Status)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit entries, newest first. If beforeID is positive, only entries
+// older than it are returned, which lets callers page through the history.
+func (l *Log) List(ctx context.Context, limit int, beforeID int64) ([]Entry, error) {
+	query := "SELECT id, recorded_at, actor, action, params, status FROM audit_log"
+	args := []any{}
+	if beforeID > 0 {
+		query += " WHERE id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		var recordedAt int64
+		var params string
+		if err := rows.Scan(&entry.ID, &recordedAt, &entry.Actor, &entry.Action, &params, &entry.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Time = time.UnixMilli(recordedAt)
+		if err := json.Unmarshal([]byte(params), &entry.Params); err != nil {
+			return nil, fmt.Errorf("failed to decode audit parameters: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (l *Log) Close() error {
+	return l.db.Close()
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_RecordAndList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	log, err := Open(path)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, action := range []string{"gtfs.refresh", "cache.flush", "config.reload"} {
+		require.NoError(t, log.Record(ctx, Entry{
+			Time:   start.Add(time.Duration(i) * time.Minute),
+			Actor:  "admin-key",
+			Action: action,
+			Params: map[string]string{"n": action},
+			Status: 200,
+		}))
+	}
+	require.NoError(t, log.Close())
+
+	// Entries survive reopening the database
+	log, err = Open(path)
+	require.NoError(t, err)
+	defer func() { _ = log.Close() }()
+
+	entries, err := log.List(ctx, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "config.reload", entries[0].Action)
+	assert.Equal(t, "cache.flush", entries[1].Action)
+	assert.Equal(t, "admin-key", entries[0].Actor)
+	assert.Equal(t, map[string]string{"n": "config.reload"}, entries[0].Params)
+	assert.True(t, entries[0].Time.Equal(start.Add(2*time.Minute)))
+
+	older, err := log.List(ctx, 10, entries[1].ID)
+	require.NoError(t, err)
+	require.Len(t, older, 1)
+	assert.Equal(t, "gtfs.refresh", older[0].Action)
+}
+
+func TestLog_InMemory(t *testing.T) {
+	log, err := Open("")
+	require.NoError(t, err)
+	defer func() { _ = log.Close() }()
+
+	require.NoError(t, log.Record(context.Background(), Entry{Time: time.Now(), Actor: "a", Action: "cache.flush", Status: 200}))
+	entries, err := log.List(context.Background(), 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,77 @@
+// Package auth verifies credentials issued by external identity providers.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// ErrMissingIdentity is returned when a valid token lacks the claim used as the caller's identity.
+var ErrMissingIdentity = errors.New("token has no identity claim")
+
+// BearerVerifier validates OAuth2/OIDC bearer tokens (JWTs) against a JWKS endpoint and
+// returns the caller identity used in place of an API key for rate limits, quotas and usage.
+type BearerVerifier struct {
+	keyfunc       jwt.Keyfunc
+	parser        *jwt.Parser
+	identityClaim string
+	cancel        context.CancelFunc
+}
+
+// NewBearerVerifier fetches the signing keys from cfg.JWKSURL. The key set is refreshed in the
+// background until Close is called.
+func NewBearerVerifier(cfg appconf.BearerAuthConfig) (*BearerVerifier, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	jwks, err := keyfunc.NewDefaultCtx(ctx, []string{cfg.JWKSURL})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to load JWKS from %s: %w", cfg.JWKSURL, err)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "PS384", "PS512", "EdDSA"}),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	identityClaim := cfg.IdentityClaim
+	if identityClaim == "" {
+		identityClaim = "sub"
+	}
+
+	return &BearerVerifier{
+		keyfunc:       jwks.Keyfunc,
+		parser:        jwt.NewParser(opts...),
+		identityClaim: identityClaim,
+		cancel:        cancel,
+	}, nil
+}
+
+// Verify checks the token signature, expiry, issuer and audience and returns the caller identity.
+func (v *BearerVerifier) Verify(tokenString string) (string, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(tokenString, claims, v.keyfunc); err != nil {
+		return "", err
+	}
+
+	identity, ok := claims[v.identityClaim].(string)
+	if !ok || identity == "" {
+		return "", ErrMissingIdentity
+	}
+	return identity, nil
+}
+
+// Close stops the background JWKS refresh.
+func (v *BearerVerifier) Close() {
+	v.cancel()
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// newTestJWKS serves a JWKS containing the public half of a fresh RSA key.
+func newTestJWKS(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(server.Close)
+
+	return key, server.URL
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestBearerVerifier(t *testing.T) {
+	key, jwksURL := newTestJWKS(t)
+	verifier, err := NewBearerVerifier(appconf.BearerAuthConfig{
+		JWKSURL:  jwksURL,
+		Issuer:   "https://idp.example.com",
+		Audience: "maglev",
+	})
+	require.NoError(t, err)
+	defer verifier.Close()
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": "https://idp.example.com",
+			"aud": "maglev",
+			"sub": "partner-app",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	t.Run("valid token returns subject", func(t *testing.T) {
+		identity, err := verifier.Verify(signToken(t, key, validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, "partner-app", identity)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		claims := validClaims()
+		claims["aud"] = "other-service"
+		_, err := verifier.Verify(signToken(t, key, claims))
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		claims := validClaims()
+		claims["iss"] = "https://evil.example.com"
+		_, err := verifier.Verify(signToken(t, key, claims))
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		claims := validClaims()
+		claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		_, err := verifier.Verify(signToken(t, key, claims))
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+
+	t.Run("token without expiry", func(t *testing.T) {
+		claims := validClaims()
+		delete(claims, "exp")
+		_, err := verifier.Verify(signToken(t, key, claims))
+		assert.Error(t, err)
+	})
+
+	t.Run("token signed by another key", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		_, err = verifier.Verify(signToken(t, otherKey, validClaims()))
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("missing identity claim", func(t *testing.T) {
+		claims := validClaims()
+		delete(claims, "sub")
+		_, err := verifier.Verify(signToken(t, key, claims))
+		assert.ErrorIs(t, err, ErrMissingIdentity)
+	})
+}
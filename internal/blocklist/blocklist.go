@@ -0,0 +1,225 @@
+// Package blocklist keeps API keys and client networks that are refused service. Entries are
+// persisted to SQLite and held in memory so checks don't touch the database.
+package blocklist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/sqlitedb"
+)
+
+// Entry kinds
+const (
+	KindKey  = "key"
+	KindCIDR = "cidr"
+)
+
+const blocklistSchema = `
+CREATE TABLE IF NOT EXISTS blocklist (
+    kind TEXT NOT NULL,
+    value TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (kind, value)
+);`
+
+// ErrInvalidEntry is returned by Add for an unknown kind or an unparsable network.
+var ErrInvalidEntry = errors.New("invalid blocklist entry")
+
+// Entry is a blocked API key or network.
+type Entry struct {
+	Kind    string // KindKey or KindCIDR
+	Value   string // The key, or the network in CIDR notation
+	Reason  string
+	Created time.Time
+}
+
+// List is a persistent blocklist, safe for concurrent use.
+type List struct {
+	db *sql.DB
+
+	mu       sync.RWMutex
+	keys     map[string]Entry
+	networks map[netip.Prefix]Entry
+}
+
+// Open opens (or creates) the blocklist database at path and loads its entries. An empty
+// path keeps the blocklist in memory only.
+func Open(path string) (*List, error) {
+	if path == "" {
+		path = ":memory:"
+	}
+	db, err := sqlitedb.Open(path, blocklistSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist database: %w", err)
+	}
+
+	l := &List{db: db, keys: map[string]Entry{}, networks: map[netip.Prefix]Entry{}}
+	if err := l.load(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *List) load() error {
+	rows, err := l.db.Query("SELECT kind, value, reason, created_at FROM blocklist")
+	if err != nil {
+		return fmt.Errorf("failed to load blocklist: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var entry Entry
+		var createdAt int64
+		if err := rows.Scan(&entry.Kind, &entry.Value, &entry.Reason, &createdAt); err != nil {
+			return fmt.Errorf("failed to scan blocklist entry: %w", err)
+		}
+		entry.Created = time.UnixMilli(createdAt)
+		if err := l.insert(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// normalize validates entry and rewrites a network to its canonical form. A bare IP address
+// is treated as a single-host network.
+func normalize(entry Entry) (Entry, netip.Prefix, error) {
+	switch entry.Kind {
+	case KindKey:
+		if entry.Value == "" {
+			return entry, netip.Prefix{}, fmt.Errorf("%w: empty key", ErrInvalidEntry)
+		}
+		return entry, netip.Prefix{}, nil
+	case KindCIDR:
+		prefix, err := netip.ParsePrefix(entry.Value)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(entry.Value)
+			if addrErr != nil {
+				return entry, netip.Prefix{}, fmt.Errorf("%w: %q is not a CIDR range or IP address", ErrInvalidEntry, entry.Value)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefix = prefix.Masked()
+		entry.Value = prefix.String()
+		return entry, prefix, nil
+	default:
+		return entry, netip.Prefix{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidEntry, entry.Kind)
+	}
+}
+
+func (l *List) insert(entry Entry) error {
+	entry, prefix, err := normalize(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry.Kind == KindKey {
+		l.keys[entry.Value] = entry
+	} else {
+		l.networks[prefix] = entry
+	}
+	return nil
+}
+
+// Add blocks a key or network, replacing the reason of an existing entry. It returns the
+// entry as stored, with networks in canonical form.
+func (l *List) Add(ctx context.Context, entry Entry) (Entry, error) {
+	entry, _, err := normalize(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	_, err = l.db.ExecContext(ctx,
+		"INSERT OR REPLACE INTO blocklist (kind, value, reason, created_at) VALUES (?, ?, ?, ?)",
+		entry.Kind, entry.Value, entry.Reason, entry.Created.UnixMilli())
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to store blocklist entry: %w", err)
+	}
+	return entry, l.insert(entry)
+}
+
+// Remove unblocks a key or network. It reports whether the entry existed.
+func (l *List) Remove(ctx context.Context, kind, value string) (bool, error) {
+	entry, prefix, err := normalize(Entry{Kind: kind, Value: value})
+	if err != nil {
+		return false, err
+	}
+
+	result, err := l.db.ExecContext(ctx, "DELETE FROM blocklist WHERE kind = ? AND value = ?", entry.Kind, entry.Value)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove blocklist entry: %w", err)
+	}
+	removed, _ := result.RowsAffected()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry.Kind == KindKey {
+		delete(l.keys, entry.Value)
+	} else {
+		delete(l.networks, prefix)
+	}
+	return removed > 0, nil
+}
+
+// Entries returns every entry, keys first, each group sorted by value.
+func (l *List) Entries() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := make([]Entry, 0, len(l.keys)+len(l.networks))
+	for _, entry := range l.keys {
+		entries = append(entries, entry)
+	}
+	for _, entry := range l.networks {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind == KindKey
+		}
+		return entries[i].Value < entries[j].Value
+	})
+	return entries
+}
+
+// BlocksKey reports whether key is blocked.
+func (l *List) BlocksKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, blocked := l.keys[key]
+	return blocked
+}
+
+// BlocksAddr reports whether addr falls in a blocked network.
+func (l *List) BlocksAddr(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for prefix := range l.networks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *List) Close() error {
+	return l.db.Close()
+}
@@ -0,0 +1,72 @@
+package blocklist
+
+import (
+	"context"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestList_AddRemovePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.db")
+	list, err := Open(path)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	_, err = list.Add(ctx, Entry{Kind: KindKey, Value: "abuser", Reason: "scraping", Created: now})
+	require.NoError(t, err)
+	entry, err := list.Add(ctx, Entry{Kind: KindCIDR, Value: "203.0.113.7/24", Created: now})
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.0/24", entry.Value)
+	entry, err = list.Add(ctx, Entry{Kind: KindCIDR, Value: "2001:db8::1", Created: now})
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::1/128", entry.Value)
+	require.NoError(t, list.Close())
+
+	// Entries survive reopening the database
+	list, err = Open(path)
+	require.NoError(t, err)
+	defer func() { _ = list.Close() }()
+
+	assert.True(t, list.BlocksKey("abuser"))
+	assert.False(t, list.BlocksKey("someone-else"))
+	assert.True(t, list.BlocksAddr(netip.MustParseAddr("203.0.113.200")))
+	assert.True(t, list.BlocksAddr(netip.MustParseAddr("::ffff:203.0.113.1")))
+	assert.False(t, list.BlocksAddr(netip.MustParseAddr("198.51.100.1")))
+	assert.True(t, list.BlocksAddr(netip.MustParseAddr("2001:db8::1")))
+
+	entries := list.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, KindKey, entries[0].Kind)
+	assert.Equal(t, "scraping", entries[0].Reason)
+	assert.True(t, entries[0].Created.Equal(now))
+
+	removed, err := list.Remove(ctx, KindCIDR, "203.0.113.0/24")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.False(t, list.BlocksAddr(netip.MustParseAddr("203.0.113.200")))
+
+	removed, err = list.Remove(ctx, KindKey, "never-blocked")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestList_InvalidEntries(t *testing.T) {
+	list, err := Open("")
+	require.NoError(t, err)
+	defer func() { _ = list.Close() }()
+
+	ctx := context.Background()
+	_, err = list.Add(ctx, Entry{Kind: KindCIDR, Value: "not-a-network"})
+	assert.ErrorIs(t, err, ErrInvalidEntry)
+	_, err = list.Add(ctx, Entry{Kind: KindKey})
+	assert.ErrorIs(t, err, ErrInvalidEntry)
+	_, err = list.Add(ctx, Entry{Kind: "user", Value: "x"})
+	assert.ErrorIs(t, err, ErrInvalidEntry)
+	assert.Empty(t, list.Entries())
+}
@@ -0,0 +1,65 @@
+// Package buildinfo identifies the running binary. Release builds set the variables below
+// with -ldflags, e.g.
+//
+//	-X maglev.onebusaway.org/internal/buildinfo.Version=v1.2.0
+//	-X maglev.onebusaway.org/internal/buildinfo.Commit=0f3c2a1
+//	-X maglev.onebusaway.org/internal/buildinfo.Date=2025-06-01T12:00:00Z
+//
+// Builds without them fall back to the VCS details the Go toolchain records.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version string
+	Commit  string
+	Date    string // RFC 3339
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string
+	Commit    string
+	BuildDate string
+	GoVersion string
+}
+
+// Get returns the build details, using "dev" when no version was set.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildDate: Date, GoVersion: runtime.Version()}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// String formats the details for --version output.
+func (i Info) String() string {
+	s := "maglev " + i.Version
+	if i.Commit != "" {
+		s += " (commit " + i.Commit + ")"
+	}
+	if i.BuildDate != "" {
+		s += " built " + i.BuildDate
+	}
+	return fmt.Sprintf("%s with %s", s, i.GoVersion)
+}
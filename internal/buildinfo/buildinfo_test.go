@@ -0,0 +1,21 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	t.Cleanup(func() { Version, Commit, Date = "", "", "" })
+
+	info := Get()
+	assert.Equal(t, "dev", info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+
+	Version, Commit, Date = "v1.2.0", "0f3c2a1", "2025-06-01T12:00:00Z"
+	info = Get()
+	assert.Equal(t, Info{Version: "v1.2.0", Commit: "0f3c2a1", BuildDate: "2025-06-01T12:00:00Z", GoVersion: runtime.Version()}, info)
+	assert.Equal(t, "maglev v1.2.0 (commit 0f3c2a1) built 2025-06-01T12:00:00Z with "+runtime.Version(), info.String())
+}
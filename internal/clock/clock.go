@@ -154,6 +154,12 @@ func (e *EnvironmentClock) syncFromFile() (time.Time, error) {
 
 // parseTime attempts to parse a time string using multiple common formats.
 func (e *EnvironmentClock) parseTime(s string) (time.Time, error) {
+	return ParseTime(s, e.location)
+}
+
+// ParseTime parses an RFC3339 time, or a date and time without a zone interpreted in
+// location. Without a location only RFC3339 is accepted.
+func ParseTime(s string, location *time.Location) (time.Time, error) {
 	s = strings.TrimSpace(s)
 
 	// Try RFC3339 first (includes timezone)
@@ -162,7 +168,7 @@ func (e *EnvironmentClock) parseTime(s string) (time.Time, error) {
 	}
 
 	// requires timezone
-	if e.location == nil {
+	if location == nil {
 		return time.Time{}, errors.New("timezone not configured")
 	}
 
@@ -173,10 +179,40 @@ func (e *EnvironmentClock) parseTime(s string) (time.Time, error) {
 		"2006-01-02",
 	}
 	for _, format := range formats {
-		if t, err := time.ParseInLocation(format, s, e.location); err == nil {
+		if t, err := time.ParseInLocation(format, s, location); err == nil {
 			return t, nil
 		}
 	}
 
 	return time.Time{}, fmt.Errorf("unable to parse time %q: expected RFC3339 (2006-01-02T15:04:05Z07:00), or YYYY-MM-DD HH:MM:SS, YYYY-MM-DDTHH:MM:SS, or YYYY-MM-DD", s)
 }
+
+// SimulatedClock implements Clock starting from an arbitrary instant and advancing with real
+// time, optionally faster or slower. It lets developers run the server at a chosen moment,
+// such as a service day boundary or a DST transition, against real data.
+type SimulatedClock struct {
+	start     time.Time
+	speed     float64
+	realStart time.Time
+	realNow   func() time.Time
+}
+
+// NewSimulatedClock creates a SimulatedClock that reads start now and then advances speed
+// simulated seconds per real second. A speed of 0 is treated as 1.
+func NewSimulatedClock(start time.Time, speed float64) *SimulatedClock {
+	if speed == 0 {
+		speed = 1
+	}
+	return &SimulatedClock{start: start, speed: speed, realStart: time.Now(), realNow: time.Now}
+}
+
+// Now returns the simulated current time.
+func (s *SimulatedClock) Now() time.Time {
+	elapsed := s.realNow().Sub(s.realStart)
+	return s.start.Add(time.Duration(float64(elapsed) * s.speed))
+}
+
+// NowUnixMilli returns the simulated current time as Unix milliseconds.
+func (s *SimulatedClock) NowUnixMilli() int64 {
+	return s.Now().UnixMilli()
+}
@@ -409,3 +409,40 @@ func TestMockClock_ConcurrentAccess(t *testing.T) {
 	// Just verify the clock still works
 	_ = c.Now()
 }
+
+func TestSimulatedClock(t *testing.T) {
+	start := time.Date(2024, 3, 10, 1, 59, 0, 0, time.UTC)
+	realTime := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	c := NewSimulatedClock(start, 60)
+	c.realStart = realTime
+	c.realNow = func() time.Time { return realTime }
+	assert.Equal(t, start, c.Now())
+
+	realTime = realTime.Add(2 * time.Second)
+	assert.Equal(t, start.Add(2*time.Minute), c.Now(), "each real second advances the clock by a simulated minute")
+	assert.Equal(t, start.Add(2*time.Minute).UnixMilli(), c.NowUnixMilli())
+}
+
+func TestSimulatedClock_DefaultSpeed(t *testing.T) {
+	start := time.Date(2024, 11, 3, 1, 30, 0, 0, time.UTC)
+	c := NewSimulatedClock(start, 0)
+
+	now := c.Now()
+	assert.False(t, now.Before(start))
+	assert.Less(t, now.Sub(start), time.Second, "a zero speed runs at real time")
+}
+
+func TestParseTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	got, err := ParseTime("2024-03-10 01:59:00", loc)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 10, 1, 59, 0, 0, loc), got)
+
+	_, err = ParseTime("2024-03-10 01:59:00", nil)
+	assert.Error(t, err, "a time without a zone needs a location")
+}
@@ -0,0 +1,176 @@
+// Package crowding predicts how full a vehicle will be when it reaches a stop, from the
+// occupancy vehicles reported on earlier trips and the occupancy the vehicle reports now.
+//
+// The predictor is chosen by name with crowding-predictor. The built-in "heuristic" predictor
+// keeps running averages in memory. Others, such as one backed by a trained model, are added
+// by calling Register from an init function in package main before the server starts.
+package crowding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"maglev.onebusaway.org/internal/clock"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+// Query asks how full a trip's vehicle will be at a stop.
+type Query struct {
+	RouteID     string
+	TripID      string
+	StopID      string
+	ArrivalTime time.Time     // When the vehicle is expected at the stop
+	Now         time.Time     // When the prediction is made
+	Vehicle     *gtfs.Vehicle // Serving the trip now; nil to predict from history alone
+}
+
+// Predictor predicts the occupancy of a vehicle at a stop. Predict is called while requests are
+// served, so it must be safe for concurrent use and should not block on slow I/O.
+type Predictor interface {
+	// Predict returns the expected occupancy, or false when there is nothing to go on.
+	Predict(ctx context.Context, q Query) (gtfs.OccupancyStatus, bool)
+}
+
+// Ingester is implemented by predictors that learn from each GTFS-RT refresh.
+type Ingester interface {
+	Ingest(update GTFS.RealtimeUpdate)
+}
+
+// Dependencies are what a predictor is built with.
+type Dependencies struct {
+	Trips  Trips
+	Clock  clock.Clock
+	Logger *slog.Logger
+}
+
+// Factory builds a predictor.
+type Factory func(deps Dependencies) (Predictor, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		"heuristic": func(deps Dependencies) (Predictor, error) { return NewHeuristic(deps), nil },
+	}
+)
+
+// Register makes a predictor available by name. It panics if the name is already taken, so
+// a clash is found at startup.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("crowding: predictor %q registered twice", name))
+	}
+	factories[name] = factory
+}
+
+// Names returns the registered predictor names, sorted.
+func Names() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds the predictor registered under name.
+func New(name string, deps Dependencies) (Predictor, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown crowding predictor %q; available: %v", name, Names())
+	}
+	if deps.Clock == nil {
+		deps.Clock = clock.RealClock{}
+	}
+	if deps.Logger == nil {
+		deps.Logger = slog.Default()
+	}
+	deps.Logger = deps.Logger.With(slog.String("component", "crowding"))
+	return factory(deps)
+}
+
+// Trips looks up the route of a trip and the time zone of its agency.
+type Trips interface {
+	TripRoute(ctx context.Context, tripID string) (routeID string, loc *time.Location, err error)
+}
+
+// ManagerTrips returns Trips that reads the manager's current static data.
+func ManagerTrips(manager *GTFS.Manager) Trips {
+	return managerTrips{manager}
+}
+
+type managerTrips struct {
+	manager *GTFS.Manager
+}
+
+func (t managerTrips) TripRoute(ctx context.Context, tripID string) (string, *time.Location, error) {
+	t.manager.RLock()
+	defer t.manager.RUnlock()
+	if t.manager.GtfsDB == nil {
+		return "", nil, errors.New("no static data loaded")
+	}
+	queries := t.manager.GtfsDB.Queries
+	trip, err := queries.GetTrip(ctx, tripID)
+	if err != nil {
+		return "", nil, err
+	}
+	route, err := queries.GetRoute(ctx, trip.RouteID)
+	if err != nil {
+		return "", nil, err
+	}
+	agency, err := queries.GetAgency(ctx, route.AgencyID)
+	if err != nil {
+		return "", nil, err
+	}
+	loc, err := time.LoadLocation(agency.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return trip.RouteID, loc, nil
+}
+
+// levels are the occupancy statuses that say how full a vehicle is, emptiest first. The
+// others, such as NO_DATA_AVAILABLE, carry nothing to average.
+var levels = []gtfs.OccupancyStatus{
+	gtfsrt.VehiclePosition_EMPTY,
+	gtfsrt.VehiclePosition_MANY_SEATS_AVAILABLE,
+	gtfsrt.VehiclePosition_FEW_SEATS_AVAILABLE,
+	gtfsrt.VehiclePosition_STANDING_ROOM_ONLY,
+	gtfsrt.VehiclePosition_CRUSHED_STANDING_ROOM_ONLY,
+	gtfsrt.VehiclePosition_FULL,
+	gtfsrt.VehiclePosition_NOT_ACCEPTING_PASSENGERS,
+}
+
+// level returns the position of status in levels.
+func level(status *gtfs.OccupancyStatus) (float64, bool) {
+	if status == nil {
+		return 0, false
+	}
+	i := slices.Index(levels, *status)
+	return float64(i), i >= 0
+}
+
+// status returns the occupancy status nearest to a level.
+func status(level float64) gtfs.OccupancyStatus {
+	i := int(level + 0.5)
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(levels) {
+		i = len(levels) - 1
+	}
+	return levels[i]
+}
@@ -0,0 +1,92 @@
+package crowding
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+type fakeTrips map[string]string
+
+func (f fakeTrips) TripRoute(_ context.Context, tripID string) (string, *time.Location, error) {
+	routeID, ok := f[tripID]
+	if !ok {
+		return "", nil, errors.New("no such trip")
+	}
+	return routeID, time.UTC, nil
+}
+
+func report(vehicleID, tripID, stopID string, status gtfs.OccupancyStatus, at time.Time) gtfs.Vehicle {
+	return gtfs.Vehicle{
+		ID:              &gtfs.VehicleID{ID: vehicleID},
+		Trip:            &gtfs.Trip{ID: gtfs.TripID{ID: tripID}},
+		StopID:          &stopID,
+		Timestamp:       &at,
+		OccupancyStatus: &status,
+	}
+}
+
+func TestNew(t *testing.T) {
+	predictor, err := New("heuristic", Dependencies{})
+	require.NoError(t, err)
+	assert.IsType(t, &Heuristic{}, predictor)
+
+	_, err = New("neural-net", Dependencies{})
+	assert.ErrorContains(t, err, "heuristic")
+
+	Register("test-only", func(Dependencies) (Predictor, error) { return NewHeuristic(Dependencies{}), nil })
+	assert.Contains(t, Names(), "test-only")
+	assert.Panics(t, func() { Register("heuristic", nil) })
+}
+
+func TestHeuristic(t *testing.T) {
+	monday8am := time.Date(2025, 6, 9, 8, 0, 0, 0, time.UTC)
+	h := NewHeuristic(Dependencies{Trips: fakeTrips{"T1": "R1", "T2": "R1"}, Clock: clock.NewMockClock(monday8am)})
+
+	query := Query{RouteID: "R1", TripID: "T3", StopID: "S1", ArrivalTime: monday8am.AddDate(0, 0, 7).Add(10 * time.Minute), Now: monday8am}
+	_, ok := h.Predict(t.Context(), query)
+	assert.False(t, ok, "no history yet")
+
+	// Repeated refreshes of the same report count once
+	for range 3 {
+		h.Ingest(GTFS.RealtimeUpdate{Vehicles: []gtfs.Vehicle{report("V1", "T1", "S1", gtfsrt.VehiclePosition_FULL, monday8am)}})
+	}
+	_, ok = h.Predict(t.Context(), query)
+	assert.False(t, ok, "one report is not enough history")
+
+	h.Ingest(GTFS.RealtimeUpdate{Vehicles: []gtfs.Vehicle{
+		report("V1", "T1", "S1", gtfsrt.VehiclePosition_STANDING_ROOM_ONLY, monday8am.Add(time.Minute)),
+		report("V2", "T2", "S1", gtfsrt.VehiclePosition_STANDING_ROOM_ONLY, monday8am.Add(2*time.Minute)),
+		report("V3", "unknown", "S1", gtfsrt.VehiclePosition_EMPTY, monday8am),
+	}})
+	status, ok := h.Predict(t.Context(), query)
+	require.True(t, ok)
+	assert.Equal(t, gtfsrt.VehiclePosition_CRUSHED_STANDING_ROOM_ONLY, status)
+
+	// Other stops fall back to the route's history; other hours have none
+	query.StopID = "S2"
+	_, ok = h.Predict(t.Context(), query)
+	assert.True(t, ok)
+	query.ArrivalTime = query.ArrivalTime.Add(3 * time.Hour)
+	_, ok = h.Predict(t.Context(), query)
+	assert.False(t, ok)
+
+	// The vehicle's own report dominates when it is about to arrive
+	empty := gtfsrt.VehiclePosition_EMPTY
+	query.ArrivalTime = monday8am.Add(time.Minute)
+	query.Vehicle = &gtfs.Vehicle{OccupancyStatus: &empty}
+	status, ok = h.Predict(t.Context(), query)
+	require.True(t, ok)
+	assert.Equal(t, gtfsrt.VehiclePosition_EMPTY, status)
+	query.ArrivalTime = monday8am.Add(20 * time.Minute)
+	status, _ = h.Predict(t.Context(), query)
+	assert.Equal(t, gtfsrt.VehiclePosition_FEW_SEATS_AVAILABLE, status)
+}
@@ -0,0 +1,210 @@
+package crowding
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+const (
+	// historyWeight is how much each new report moves a slot's average once it has
+	// 1/historyWeight reports; before that the average is a plain mean.
+	historyWeight = 0.1
+	// minHistory is the number of reports a slot needs before it is used.
+	minHistory = 3
+	// currentHorizon is how far ahead the vehicle's own occupancy still says something;
+	// its weight falls from one now to zero at the horizon.
+	currentHorizon = 30 * time.Minute
+)
+
+// slot is where and when occupancy was reported: a route, a stop on it or "" for the route
+// as a whole, weekday or weekend, and the hour of the day in the agency's time zone.
+type slot struct {
+	routeID string
+	stopID  string
+	weekend bool
+	hour    int
+}
+
+type average struct {
+	level float64
+	count int
+}
+
+func (a *average) add(level float64) {
+	a.count++
+	weight := 1 / float64(a.count)
+	if weight < historyWeight {
+		weight = historyWeight
+	}
+	a.level += weight * (level - a.level)
+}
+
+type tripRoute struct {
+	routeID string
+	loc     *time.Location
+}
+
+// Heuristic predicts occupancy by blending the vehicle's current report with the average
+// reported at the same stop and hour. History is learned from vehicle positions since startup.
+type Heuristic struct {
+	trips  Trips
+	now    func() time.Time
+	logger *slog.Logger
+
+	mu        sync.RWMutex
+	history   map[slot]*average
+	locations map[string]*time.Location // Route ID -> agency time zone
+	seen      map[string]time.Time      // Vehicle ID -> timestamp of the last report counted
+	routes    map[string]tripRoute      // Trip ID -> route, cached from Trips
+}
+
+// NewHeuristic returns a heuristic predictor with no history.
+func NewHeuristic(deps Dependencies) *Heuristic {
+	h := &Heuristic{
+		trips:     deps.Trips,
+		now:       time.Now,
+		logger:    deps.Logger,
+		history:   make(map[slot]*average),
+		locations: make(map[string]*time.Location),
+		seen:      make(map[string]time.Time),
+		routes:    make(map[string]tripRoute),
+	}
+	if deps.Clock != nil {
+		h.now = deps.Clock.Now
+	}
+	if h.logger == nil {
+		h.logger = slog.Default()
+	}
+	return h
+}
+
+func slotAt(routeID, stopID string, t time.Time) slot {
+	weekday := t.Weekday()
+	return slot{routeID: routeID, stopID: stopID, weekend: weekday == time.Saturday || weekday == time.Sunday, hour: t.Hour()}
+}
+
+// Ingest adds the occupancy each vehicle reports to the history of its route and stop. A
+// report is counted once, however many refreshes repeat it.
+func (h *Heuristic) Ingest(update GTFS.RealtimeUpdate) {
+	now := h.now()
+	for i := range update.Vehicles {
+		vehicle := &update.Vehicles[i]
+		lvl, ok := level(vehicle.OccupancyStatus)
+		if !ok || vehicle.Trip == nil || vehicle.Trip.ID.ID == "" {
+			continue
+		}
+		reported := now
+		if vehicle.Timestamp != nil {
+			reported = *vehicle.Timestamp
+		}
+		vehicleID := vehicle.GetID().ID
+		if vehicleID == "" {
+			vehicleID = vehicle.Trip.ID.ID
+		}
+
+		h.mu.RLock()
+		last, counted := h.seen[vehicleID]
+		h.mu.RUnlock()
+		if counted && !reported.After(last) {
+			continue
+		}
+		route, ok := h.route(vehicle.Trip.ID)
+		if !ok {
+			continue
+		}
+		local := reported.In(route.loc)
+
+		h.mu.Lock()
+		h.seen[vehicleID] = reported
+		h.locations[route.routeID] = route.loc
+		keys := []slot{slotAt(route.routeID, "", local)}
+		if vehicle.StopID != nil && *vehicle.StopID != "" {
+			keys = append(keys, slotAt(route.routeID, *vehicle.StopID, local))
+		}
+		for _, key := range keys {
+			avg := h.history[key]
+			if avg == nil {
+				avg = &average{}
+				h.history[key] = avg
+			}
+			avg.add(lvl)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// route returns the route of a trip and its agency's time zone.
+func (h *Heuristic) route(id gtfs.TripID) (tripRoute, bool) {
+	h.mu.RLock()
+	route, ok := h.routes[id.ID]
+	h.mu.RUnlock()
+	if ok {
+		return route, true
+	}
+	if h.trips == nil {
+		return tripRoute{}, false
+	}
+	routeID, loc, err := h.trips.TripRoute(context.Background(), id.ID)
+	if err != nil {
+		h.logger.Debug("skipping occupancy of unknown trip", "trip", id.ID, "error", err)
+		return tripRoute{}, false
+	}
+	route = tripRoute{routeID: routeID, loc: loc}
+	h.mu.Lock()
+	h.routes[id.ID] = route
+	h.mu.Unlock()
+	return route, true
+}
+
+// Predict blends the occupancy the vehicle reports now, weighted by how soon it reaches the
+// stop, with the average reported on the route at the stop, or anywhere on the route, at the
+// same hour on the same kind of day.
+func (h *Heuristic) Predict(_ context.Context, q Query) (gtfs.OccupancyStatus, bool) {
+	historical, hasHistory := h.historical(q.RouteID, q.StopID, q.ArrivalTime)
+
+	var current float64
+	var weight float64
+	if q.Vehicle != nil {
+		if lvl, ok := level(q.Vehicle.OccupancyStatus); ok {
+			ahead := q.ArrivalTime.Sub(q.Now)
+			if ahead < 0 {
+				ahead = 0
+			}
+			if ahead < currentHorizon {
+				current = lvl
+				weight = 1 - float64(ahead)/float64(currentHorizon)
+			}
+		}
+	}
+
+	switch {
+	case weight > 0 && hasHistory:
+		return status(weight*current + (1-weight)*historical), true
+	case weight > 0:
+		return status(current), true
+	case hasHistory:
+		return status(historical), true
+	}
+	return 0, false
+}
+
+func (h *Heuristic) historical(routeID, stopID string, at time.Time) (float64, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	loc, ok := h.locations[routeID]
+	if !ok {
+		return 0, false
+	}
+	local := at.In(loc)
+	for _, key := range []slot{slotAt(routeID, stopID, local), slotAt(routeID, "", local)} {
+		if avg := h.history[key]; avg != nil && avg.count >= minHistory {
+			return avg.level, true
+		}
+	}
+	return 0, false
+}
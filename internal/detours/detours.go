@@ -0,0 +1,203 @@
+// Package detours serves the temporary paths routes take around closures. Detours come from a
+// configured JSON file, which can draw the path driven, and from service alerts with the detour
+// effect, which name the routes and the stops they miss.
+package detours
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// Detour is a temporary change to the path of one or more routes.
+type Detour struct {
+	ID             string      `json:"id"`
+	AlertID        string      `json:"alert-id"` // The service alert announcing the detour, if any
+	RouteIDs       []string    `json:"route-ids"`
+	Start          time.Time   `json:"start"` // Zero when open ended
+	End            time.Time   `json:"end"`
+	Description    string      `json:"description"`
+	Path           [][]float64 `json:"path"`             // [lat, lon] points of the path driven; empty when unknown
+	SkippedStopIDs []string    `json:"skipped-stop-ids"` // Stops of the regular route the detour misses
+}
+
+// activeAt reports whether t falls between the detour's start and end.
+func (d Detour) activeAt(t time.Time) bool {
+	return (d.Start.IsZero() || !t.Before(d.Start)) && (d.End.IsZero() || t.Before(d.End))
+}
+
+// file is the layout of the detours file.
+type file struct {
+	Detours []Detour `json:"detours"`
+}
+
+// Store holds the detours of the configured file.
+type Store struct {
+	path    string
+	mu      sync.RWMutex
+	detours []Detour
+}
+
+// New reads the detours file at path.
+func New(path string) (*Store, error) {
+	store := &Store{path: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload re-reads the detours file. The detours already loaded are kept when it is invalid.
+func (s *Store) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read detours file: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("failed to parse detours file: %w", err)
+	}
+	if err := validate(f.Detours); err != nil {
+		return fmt.Errorf("invalid detours file: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detours = f.Detours
+	return nil
+}
+
+func validate(detours []Detour) error {
+	seen := make(map[string]bool, len(detours))
+	for i, d := range detours {
+		if d.ID == "" {
+			return fmt.Errorf("detour %d has no id", i)
+		}
+		if seen[d.ID] {
+			return fmt.Errorf("detour %q is listed twice", d.ID)
+		}
+		seen[d.ID] = true
+		if len(d.RouteIDs) == 0 {
+			return fmt.Errorf("detour %q has no route-ids", d.ID)
+		}
+		if !d.Start.IsZero() && !d.End.IsZero() && !d.End.After(d.Start) {
+			return fmt.Errorf("detour %q ends before it starts", d.ID)
+		}
+		if len(d.Path) == 1 {
+			return fmt.Errorf("detour %q has a path of one point", d.ID)
+		}
+		for _, point := range d.Path {
+			if len(point) != 2 || point[0] < -90 || point[0] > 90 || point[1] < -180 || point[1] > 180 {
+				return fmt.Errorf("detour %q has a path point that is not [lat, lon]", d.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// Detours returns the detours of the file. A nil Store has none.
+func (s *Store) Detours() []Detour {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.detours
+}
+
+// Active returns the detours in effect at now, in file order followed by alert order. A file
+// detour is in effect during its start and end and, when it names an alert in alerts, while that
+// alert is active; the alert's routes and stops are added to it. Active alerts with the detour
+// effect that no file detour names become detours of their own, without a path.
+func Active(fileDetours []Detour, alerts []gtfs.Alert, now time.Time) []Detour {
+	byID := make(map[string]gtfs.Alert, len(alerts))
+	for _, alert := range alerts {
+		byID[alert.ID] = alert
+	}
+
+	active := []Detour{}
+	named := make(map[string]bool)
+	for _, d := range fileDetours {
+		if !d.activeAt(now) {
+			continue
+		}
+		if alert, ok := byID[d.AlertID]; ok && d.AlertID != "" {
+			named[alert.ID] = true
+			fromAlert, alertActive := alertDetour(alert, now)
+			if !alertActive {
+				continue
+			}
+			d.RouteIDs = appendMissing(append([]string(nil), d.RouteIDs...), fromAlert.RouteIDs...)
+			d.SkippedStopIDs = appendMissing(append([]string(nil), d.SkippedStopIDs...), fromAlert.SkippedStopIDs...)
+			if d.Description == "" {
+				d.Description = fromAlert.Description
+			}
+		}
+		active = append(active, d)
+	}
+
+	for _, alert := range alerts {
+		if alert.Effect != gtfs.Detour || named[alert.ID] {
+			continue
+		}
+		if d, ok := alertDetour(alert, now); ok && len(d.RouteIDs) > 0 {
+			active = append(active, d)
+		}
+	}
+	return active
+}
+
+// alertDetour describes the detour an alert announces, and reports whether the alert is active
+// at now. Its start and end are those of the active period covering now.
+func alertDetour(alert gtfs.Alert, now time.Time) (Detour, bool) {
+	d := Detour{ID: alert.ID, AlertID: alert.ID}
+	for _, entity := range alert.InformedEntities {
+		if entity.RouteID != nil {
+			d.RouteIDs = appendMissing(d.RouteIDs, *entity.RouteID)
+		}
+		if entity.StopID != nil {
+			d.SkippedStopIDs = appendMissing(d.SkippedStopIDs, *entity.StopID)
+		}
+	}
+	if len(alert.Header) > 0 {
+		d.Description = alert.Header[0].Text
+	}
+
+	if len(alert.ActivePeriods) == 0 {
+		return d, true
+	}
+	for _, period := range alert.ActivePeriods {
+		window := Detour{}
+		if period.StartsAt != nil {
+			window.Start = *period.StartsAt
+		}
+		if period.EndsAt != nil {
+			window.End = *period.EndsAt
+		}
+		if window.activeAt(now) {
+			d.Start, d.End = window.Start, window.End
+			return d, true
+		}
+	}
+	return d, false
+}
+
+func appendMissing(values []string, more ...string) []string {
+	for _, value := range more {
+		found := false
+		for _, existing := range values {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,102 @@
+package detours
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDetours(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestStore_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "detours.json")
+	writeDetours(t, path, `{"detours": [{"id": "main-st", "route-ids": ["R1"], "path": [[47.1, -122.1], [47.2, -122.2]]}]}`)
+
+	store, err := New(path)
+	require.NoError(t, err)
+	require.Len(t, store.Detours(), 1)
+	assert.Equal(t, "main-st", store.Detours()[0].ID)
+
+	for _, invalid := range []string{
+		`{"detours": [{"route-ids": ["R1"]}]}`,
+		`{"detours": [{"id": "a", "route-ids": ["R1"]}, {"id": "a", "route-ids": ["R1"]}]}`,
+		`{"detours": [{"id": "a"}]}`,
+		`{"detours": [{"id": "a", "route-ids": ["R1"], "start": "2025-06-02T00:00:00Z", "end": "2025-06-01T00:00:00Z"}]}`,
+		`{"detours": [{"id": "a", "route-ids": ["R1"], "path": [[47.1, -122.1]]}]}`,
+		`{"detours": [{"id": "a", "route-ids": ["R1"], "path": [[147.1, -122.1], [47.1, -122.1]]}]}`,
+		`not json`,
+	} {
+		writeDetours(t, path, invalid)
+		assert.Error(t, store.Reload(), invalid)
+	}
+	assert.Equal(t, "main-st", store.Detours()[0].ID, "an invalid file keeps the loaded detours")
+
+	var none *Store
+	assert.Empty(t, none.Detours())
+}
+
+func TestActive(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+	id := func(s string) *string { return &s }
+
+	fileDetours := []Detour{
+		{ID: "drawn", AlertID: "construction", RouteIDs: []string{"R1"}, Path: [][]float64{{47.1, -122.1}, {47.2, -122.2}}},
+		{ID: "next-week", RouteIDs: []string{"R1"}, Start: later},
+		{ID: "ended-alert", AlertID: "ended", RouteIDs: []string{"R3"}},
+	}
+	alerts := []gtfs.Alert{
+		{
+			ID:     "construction",
+			Effect: gtfs.Detour,
+			Header: []gtfs.AlertText{{Text: "Main St closed"}},
+			InformedEntities: []gtfs.AlertInformedEntity{
+				{RouteID: id("R1"), StopID: id("S1")},
+				{RouteID: id("R2")},
+			},
+		},
+		{
+			ID:               "parade",
+			Effect:           gtfs.Detour,
+			ActivePeriods:    []gtfs.AlertActivePeriod{{StartsAt: &earlier, EndsAt: &later}},
+			InformedEntities: []gtfs.AlertInformedEntity{{RouteID: id("R4")}, {StopID: id("S9")}},
+		},
+		{
+			ID:               "ended",
+			Effect:           gtfs.Detour,
+			ActivePeriods:    []gtfs.AlertActivePeriod{{EndsAt: &earlier}},
+			InformedEntities: []gtfs.AlertInformedEntity{{RouteID: id("R3")}},
+		},
+		{ID: "delays", Effect: gtfs.SignificantDelays, InformedEntities: []gtfs.AlertInformedEntity{{RouteID: id("R1")}}},
+		{ID: "stop-only", Effect: gtfs.Detour, InformedEntities: []gtfs.AlertInformedEntity{{StopID: id("S2")}}},
+	}
+
+	active := Active(fileDetours, alerts, now)
+	require.Len(t, active, 2)
+	assert.Equal(t, Detour{
+		ID:             "drawn",
+		AlertID:        "construction",
+		RouteIDs:       []string{"R1", "R2"},
+		Description:    "Main St closed",
+		Path:           [][]float64{{47.1, -122.1}, {47.2, -122.2}},
+		SkippedStopIDs: []string{"S1"},
+	}, active[0])
+	assert.Equal(t, Detour{
+		ID:             "parade",
+		AlertID:        "parade",
+		RouteIDs:       []string{"R4"},
+		Start:          earlier,
+		End:            later,
+		SkippedStopIDs: []string{"S9"},
+	}, active[1])
+	assert.Equal(t, []string{"R1"}, fileDetours[0].RouteIDs, "the file detours are not modified")
+}
@@ -0,0 +1,40 @@
+// Package errorreport forwards server errors and panics to an external error tracker so
+// operators hear about failures without searching the logs.
+package errorreport
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Event describes a single server error.
+type Event struct {
+	Err        error
+	Panic      bool   // The error was recovered from a panic
+	Stack      []byte // Goroutine stack at the point of failure, if available
+	Method     string
+	Path       string
+	RequestID  string
+	StatusCode int
+	Timestamp  time.Time
+}
+
+// NewRequestEvent builds an event for err raised while serving r.
+func NewRequestEvent(r *http.Request, err error, statusCode int, requestID string) Event {
+	return Event{
+		Err:        err,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RequestID:  requestID,
+		StatusCode: statusCode,
+		Timestamp:  time.Now(),
+	}
+}
+
+// Reporter receives server errors. Report must not block the request being served.
+type Reporter interface {
+	Report(event Event)
+	// Close sends events still queued, giving up when ctx is done.
+	Close(ctx context.Context) error
+}
@@ -0,0 +1,54 @@
+package errorreport
+
+import (
+	"context"
+	"sync"
+)
+
+// Recent keeps the last few events in memory so they can be shown on the admin status page.
+// It implements Reporter; Close is a no-op.
+type Recent struct {
+	mu     sync.Mutex
+	events []Event // Ring buffer; next is the slot the next event is written to
+	next   int
+	full   bool
+}
+
+// NewRecent creates a buffer holding the size most recent events.
+func NewRecent(size int) *Recent {
+	return &Recent{events: make([]Event, size)}
+}
+
+// Report stores event, replacing the oldest one when the buffer is full.
+func (r *Recent) Report(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.events) == 0 {
+		return
+	}
+	r.events[r.next] = event
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Events returns the stored events, newest first.
+func (r *Recent) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.events)
+	}
+	events := make([]Event, 0, count)
+	for i := 1; i <= count; i++ {
+		events = append(events, r.events[(r.next-i+len(r.events))%len(r.events)])
+	}
+	return events
+}
+
+func (r *Recent) Close(context.Context) error {
+	return nil
+}
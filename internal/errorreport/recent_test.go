@@ -0,0 +1,23 @@
+package errorreport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecent_KeepsNewestEvents(t *testing.T) {
+	recent := NewRecent(2)
+	assert.Empty(t, recent.Events())
+
+	for _, msg := range []string{"first", "second", "third"} {
+		recent.Report(Event{Err: errors.New(msg)})
+	}
+
+	events := recent.Events()
+	if assert.Len(t, events, 2) {
+		assert.EqualError(t, events[0].Err, "third")
+		assert.EqualError(t, events[1].Err, "second")
+	}
+}
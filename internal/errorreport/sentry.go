@@ -0,0 +1,100 @@
+package errorreport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// sentryQueueSize bounds the events waiting to be sent. Events beyond it are dropped so an
+// error storm can't exhaust memory.
+const sentryQueueSize = 100
+
+// SentryReporter sends events to Sentry in the background with the Sentry SDK.
+type SentryReporter struct {
+	client *sentry.Client
+
+	mu     sync.Mutex // Guards closed
+	closed bool
+}
+
+// NewSentryReporter creates a reporter for cfg.SentryDSN.
+func NewSentryReporter(cfg appconf.ErrorReportingConfig) (*SentryReporter, error) {
+	transport := sentry.NewHTTPTransport()
+	transport.BufferSize = sentryQueueSize
+	transport.Timeout = 10 * time.Second
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:            cfg.SentryDSN,
+		Environment:    cfg.Environment,
+		Release:        cfg.Release,
+		Transport:      transport,
+		DisableMetrics: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	return &SentryReporter{client: client}, nil
+}
+
+// Report queues event for sending. Events are dropped if the queue is full or the reporter is closed.
+func (r *SentryReporter) Report(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.client.CaptureEvent(buildSentryEvent(event), nil, nil)
+}
+
+// Close stops accepting events and waits until queued ones are sent or ctx is done.
+func (r *SentryReporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	if !r.client.FlushWithContext(ctx) {
+		return ctx.Err()
+	}
+	r.client.Close()
+	return nil
+}
+
+// buildSentryEvent converts event into a Sentry event; the client fills in the event ID,
+// server name, environment and release. Only the request path is sent: query strings carry
+// API keys.
+func buildSentryEvent(event Event) *sentry.Event {
+	handled := !event.Panic
+	exception := sentry.Exception{
+		Type:      fmt.Sprintf("%T", event.Err),
+		Value:     event.Err.Error(),
+		Mechanism: &sentry.Mechanism{Type: "generic", Handled: &handled},
+	}
+	if event.Panic {
+		exception.Type = "panic"
+		exception.Mechanism.Type = "panic"
+	}
+
+	payload := sentry.NewEvent()
+	payload.Level = sentry.LevelError
+	payload.Timestamp = event.Timestamp
+	payload.Exception = []sentry.Exception{exception}
+	if event.Path != "" {
+		payload.Request = &sentry.Request{Method: event.Method, URL: event.Path}
+	}
+	if event.RequestID != "" {
+		payload.Tags["request_id"] = event.RequestID
+	}
+	if event.StatusCode != 0 {
+		payload.Tags["status_code"] = strconv.Itoa(event.StatusCode)
+	}
+	if len(event.Stack) > 0 {
+		payload.Extra["stack"] = string(event.Stack)
+	}
+	return payload
+}
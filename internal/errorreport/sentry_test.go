@@ -0,0 +1,75 @@
+package errorreport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func TestNewSentryReporterRejectsInvalidDSNs(t *testing.T) {
+	for _, dsn := range []string{"https://o1.ingest.sentry.io/42", "https://abc@o1.ingest.sentry.io", "udp://abc@host/1"} {
+		_, err := NewSentryReporter(appconf.ErrorReportingConfig{SentryDSN: dsn})
+		assert.ErrorContains(t, err, "invalid Sentry DSN", dsn)
+	}
+}
+
+func TestSentryReporter(t *testing.T) {
+	type received struct {
+		path, auth string
+		event      map[string]interface{}
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Envelope: a header line, then each item's header line and payload
+		lines := bufio.NewScanner(r.Body)
+		lines.Buffer(nil, 1<<20)
+		var event map[string]interface{}
+		for lines.Scan() {
+			if strings.Contains(lines.Text(), `"type":"event"`) && lines.Scan() {
+				_ = json.Unmarshal(lines.Bytes(), &event)
+			}
+		}
+		requests <- received{path: r.URL.Path, auth: r.Header.Get("X-Sentry-Auth"), event: event}
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://public-key@", 1) + "/42"
+	reporter, err := NewSentryReporter(appconf.ErrorReportingConfig{SentryDSN: dsn, Environment: "staging"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/where/stop/1_75403.json?key=secret", nil)
+	event := NewRequestEvent(req, errors.New("database is locked"), http.StatusInternalServerError, "req-123")
+	event.Panic = true
+	event.Stack = []byte("goroutine 1 [running]")
+	reporter.Report(event)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, reporter.Close(ctx))
+
+	got := <-requests
+	assert.Equal(t, "/api/42/envelope/", got.path)
+	assert.Contains(t, got.auth, "sentry_key=public-key")
+	require.NotNil(t, got.event)
+	assert.Equal(t, "staging", got.event["environment"])
+	assert.Equal(t, map[string]interface{}{"request_id": "req-123", "status_code": "500"}, got.event["tags"])
+	// The query string holds the API key and is never sent
+	assert.Equal(t, "/api/where/stop/1_75403.json", got.event["request"].(map[string]interface{})["url"])
+	exception := got.event["exception"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "panic", exception["type"])
+	assert.Equal(t, "database is locked", exception["value"])
+
+	// Reports after Close are dropped
+	reporter.Report(event)
+	assert.Empty(t, requests)
+}
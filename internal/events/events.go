@@ -0,0 +1,474 @@
+// Package events publishes GTFS-RT data to a message broker as it is ingested. After each
+// realtime refresh, the vehicle positions, trip updates and alerts are normalized to JSON and
+// sent to three topics, <prefix>vehicle-positions, <prefix>trip-updates and <prefix>alerts,
+// keyed by the vehicle, trip or alert ID. An entity is only sent again once it changes, so a
+// feed that is polled faster than its vehicles report does not repeat itself.
+//
+// IDs are the feed's own, so events join with the GTFS files like the Parquet export does.
+// Publishing never holds up ingestion: when the broker is slower than the feed, intermediate
+// refreshes are skipped and the latest one is sent.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/appconf"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+// publishTimeout bounds the delivery of one refresh's events.
+const publishTimeout = 30 * time.Second
+
+// Topic names, after the configured prefix.
+const (
+	VehiclePositionsTopic = "vehicle-positions"
+	TripUpdatesTopic      = "trip-updates"
+	AlertsTopic           = "alerts"
+)
+
+// Message is one event, keyed by the ID of the entity it describes.
+type Message struct {
+	Key   string
+	Value json.RawMessage
+}
+
+// transport delivers messages to a broker.
+type transport interface {
+	publish(ctx context.Context, topic string, messages []Message) error
+	close() error
+}
+
+// Publisher sends normalized realtime events to a broker.
+type Publisher struct {
+	transport transport
+	prefix    string
+	logger    *slog.Logger
+	now       func() time.Time
+
+	mu      sync.Mutex
+	pending *GTFS.RealtimeUpdate // Latest refresh not yet published
+	wake    chan struct{}
+	sent    map[string]map[string]uint64 // Topic -> key -> hash of the last event sent
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPublisher returns a publisher for cfg. The broker is not contacted until the first event.
+func NewPublisher(cfg appconf.EventPublishingConfig, logger *slog.Logger) (*Publisher, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With(slog.String("component", "event_publisher"))
+	var t transport
+	switch cfg.Broker {
+	case "nats":
+		t = newNATSTransport(cfg)
+	case "kafka":
+		t = newKafkaTransport(cfg)
+	default:
+		return nil, fmt.Errorf("unknown broker %q", cfg.Broker)
+	}
+	return newPublisher(t, cfg.TopicPrefix, logger), nil
+}
+
+func newPublisher(t transport, prefix string, logger *slog.Logger) *Publisher {
+	return &Publisher{
+		transport: t,
+		prefix:    prefix,
+		logger:    logger,
+		now:       time.Now,
+		wake:      make(chan struct{}, 1),
+		sent:      make(map[string]map[string]uint64),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start publishes ingested refreshes in the background until Shutdown.
+func (p *Publisher) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			select {
+			case <-p.stopChan:
+				return
+			case <-p.wake:
+				p.mu.Lock()
+				update := p.pending
+				p.pending = nil
+				p.mu.Unlock()
+				if update == nil {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+				if err := p.Publish(ctx, *update); err != nil {
+					p.logger.Error("failed to publish realtime events", "error", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Ingest queues a refresh for publishing and returns at once; it is the manager's realtime
+// update hook. A refresh still waiting from before is replaced.
+func (p *Publisher) Ingest(update GTFS.RealtimeUpdate) {
+	p.mu.Lock()
+	if p.pending != nil {
+		// Keep the feeds the newer refresh did not load
+		if update.Trips == nil {
+			update.Trips = p.pending.Trips
+		}
+		if update.Vehicles == nil {
+			update.Vehicles = p.pending.Vehicles
+		}
+		if update.Alerts == nil {
+			update.Alerts = p.pending.Alerts
+		}
+	}
+	p.pending = &update
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Shutdown stops publishing, waits for a delivery in progress and closes the connection.
+func (p *Publisher) Shutdown() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+	})
+	p.wg.Wait()
+	if err := p.transport.close(); err != nil {
+		p.logger.Warn("failed to close broker connection", "error", err)
+	}
+}
+
+// Publish sends the entities in update that changed since they were last sent.
+func (p *Publisher) Publish(ctx context.Context, update GTFS.RealtimeUpdate) error {
+	ingested := p.now().UnixMilli()
+
+	if update.Vehicles != nil {
+		events := make([]keyedEvent, 0, len(update.Vehicles))
+		for _, vehicle := range update.Vehicles {
+			if event, ok := vehiclePosition(vehicle); ok {
+				events = append(events, keyedEvent{event.VehicleID, &event})
+			}
+		}
+		if err := p.publishChanged(ctx, VehiclePositionsTopic, events, ingested); err != nil {
+			return err
+		}
+	}
+	if update.Trips != nil {
+		events := make([]keyedEvent, 0, len(update.Trips))
+		for _, trip := range update.Trips {
+			if trip.ID.ID == "" {
+				continue
+			}
+			event := tripUpdate(trip)
+			events = append(events, keyedEvent{event.TripID, &event})
+		}
+		if err := p.publishChanged(ctx, TripUpdatesTopic, events, ingested); err != nil {
+			return err
+		}
+	}
+	if update.Alerts != nil {
+		events := make([]keyedEvent, 0, len(update.Alerts))
+		for _, alert := range update.Alerts {
+			event := NewAlert(alert)
+			events = append(events, keyedEvent{event.ID, &event})
+		}
+		if err := p.publishChanged(ctx, AlertsTopic, events, ingested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizedEvent is implemented by the event types.
+type normalizedEvent interface {
+	setIngestedAt(millis int64)
+}
+
+type keyedEvent struct {
+	key   string
+	event normalizedEvent
+}
+
+// publishChanged sends the events that differ from the last ones sent under their keys. The
+// record of sent events is replaced, so entities that left the feed are forgotten, and is
+// only kept when the broker accepted them.
+func (p *Publisher) publishChanged(ctx context.Context, topic string, events []keyedEvent, ingested int64) error {
+	p.mu.Lock()
+	previous := p.sent[topic]
+	p.mu.Unlock()
+
+	sent := make(map[string]uint64, len(events))
+	var messages []Message
+	for _, e := range events {
+		// Hashed before the ingest time is set, which changes on every refresh
+		body, err := json.Marshal(e.event)
+		if err != nil {
+			return err
+		}
+		h := fnv.New64a()
+		_, _ = h.Write(body)
+		sum := h.Sum64()
+		sent[e.key] = sum
+		if last, ok := previous[e.key]; ok && last == sum {
+			continue
+		}
+
+		e.event.setIngestedAt(ingested)
+		if body, err = json.Marshal(e.event); err != nil {
+			return err
+		}
+		messages = append(messages, Message{Key: e.key, Value: body})
+	}
+
+	if len(messages) > 0 {
+		if err := p.transport.publish(ctx, p.prefix+topic, messages); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", p.prefix+topic, err)
+		}
+	}
+
+	p.mu.Lock()
+	p.sent[topic] = sent
+	p.mu.Unlock()
+	return nil
+}
+
+// VehiclePosition is the event published for each vehicle with a new position or status.
+type VehiclePosition struct {
+	VehicleID           string   `json:"vehicleId"`
+	Label               string   `json:"label,omitempty"`
+	TripID              string   `json:"tripId,omitempty"`
+	RouteID             string   `json:"routeId,omitempty"`
+	StartDate           string   `json:"startDate,omitempty"` // YYYYMMDD
+	Latitude            *float64 `json:"latitude,omitempty"`
+	Longitude           *float64 `json:"longitude,omitempty"`
+	Bearing             *float64 `json:"bearing,omitempty"`
+	Speed               *float64 `json:"speed,omitempty"` // Meters per second
+	CurrentStopSequence *uint32  `json:"currentStopSequence,omitempty"`
+	StopID              string   `json:"stopId,omitempty"`
+	CurrentStatus       string   `json:"currentStatus,omitempty"`
+	OccupancyStatus     string   `json:"occupancyStatus,omitempty"`
+	Timestamp           int64    `json:"timestamp,omitempty"` // When the vehicle reported, in Unix milliseconds
+	IngestedAt          int64    `json:"ingestedAt"`
+}
+
+func (e *VehiclePosition) setIngestedAt(millis int64) { e.IngestedAt = millis }
+
+// TripUpdate is the event published for each trip whose predictions changed.
+type TripUpdate struct {
+	TripID               string           `json:"tripId"`
+	RouteID              string           `json:"routeId,omitempty"`
+	StartDate            string           `json:"startDate,omitempty"`
+	ScheduleRelationship string           `json:"scheduleRelationship"`
+	VehicleID            string           `json:"vehicleId,omitempty"`
+	StopTimeUpdates      []StopTimeUpdate `json:"stopTimeUpdates"`
+	IngestedAt           int64            `json:"ingestedAt"`
+}
+
+func (e *TripUpdate) setIngestedAt(millis int64) { e.IngestedAt = millis }
+
+// StopTimeUpdate is the prediction for one stop of a TripUpdate. Times are Unix milliseconds
+// and delays are seconds.
+type StopTimeUpdate struct {
+	StopSequence         *uint32 `json:"stopSequence,omitempty"`
+	StopID               string  `json:"stopId,omitempty"`
+	ArrivalTime          *int64  `json:"arrivalTime,omitempty"`
+	ArrivalDelay         *int64  `json:"arrivalDelay,omitempty"`
+	DepartureTime        *int64  `json:"departureTime,omitempty"`
+	DepartureDelay       *int64  `json:"departureDelay,omitempty"`
+	ScheduleRelationship string  `json:"scheduleRelationship"`
+}
+
+// Alert is the event published for each new or changed service alert.
+type Alert struct {
+	ID               string           `json:"id"`
+	Cause            string           `json:"cause"`
+	Effect           string           `json:"effect"`
+	Header           []TranslatedText `json:"header"`
+	Description      []TranslatedText `json:"description"`
+	URL              []TranslatedText `json:"url"`
+	ActivePeriods    []ActivePeriod   `json:"activePeriods"`
+	InformedEntities []InformedEntity `json:"informedEntities"`
+	IngestedAt       int64            `json:"ingestedAt"`
+}
+
+func (e *Alert) setIngestedAt(millis int64) { e.IngestedAt = millis }
+
+// TranslatedText is one translation of an alert text.
+type TranslatedText struct {
+	Text     string `json:"text"`
+	Language string `json:"language,omitempty"`
+}
+
+// ActivePeriod is when an alert applies, in Unix milliseconds; an open end is omitted.
+type ActivePeriod struct {
+	Start *int64 `json:"start,omitempty"`
+	End   *int64 `json:"end,omitempty"`
+}
+
+// InformedEntity is something an alert applies to.
+type InformedEntity struct {
+	AgencyID string `json:"agencyId,omitempty"`
+	RouteID  string `json:"routeId,omitempty"`
+	TripID   string `json:"tripId,omitempty"`
+	StopID   string `json:"stopId,omitempty"`
+}
+
+func vehiclePosition(vehicle gtfs.Vehicle) (VehiclePosition, bool) {
+	if vehicle.ID == nil || vehicle.ID.ID == "" {
+		return VehiclePosition{}, false
+	}
+	event := VehiclePosition{
+		VehicleID:           vehicle.ID.ID,
+		Label:               vehicle.ID.Label,
+		CurrentStopSequence: vehicle.CurrentStopSequence,
+		StopID:              stringValue(vehicle.StopID),
+		Timestamp:           millis(vehicle.Timestamp),
+	}
+	if vehicle.Trip != nil {
+		event.TripID = vehicle.Trip.ID.ID
+		event.RouteID = vehicle.Trip.ID.RouteID
+		event.StartDate = startDate(vehicle.Trip.ID)
+	}
+	if position := vehicle.Position; position != nil {
+		event.Latitude = float64Value(position.Latitude)
+		event.Longitude = float64Value(position.Longitude)
+		event.Bearing = float64Value(position.Bearing)
+		event.Speed = float64Value(position.Speed)
+	}
+	if vehicle.CurrentStatus != nil {
+		event.CurrentStatus = vehicle.CurrentStatus.String()
+	}
+	if vehicle.OccupancyStatus != nil {
+		event.OccupancyStatus = vehicle.OccupancyStatus.String()
+	}
+	return event, true
+}
+
+func tripUpdate(trip gtfs.Trip) TripUpdate {
+	event := TripUpdate{
+		TripID:               trip.ID.ID,
+		RouteID:              trip.ID.RouteID,
+		StartDate:            startDate(trip.ID),
+		ScheduleRelationship: trip.ID.ScheduleRelationship.String(),
+		StopTimeUpdates:      make([]StopTimeUpdate, 0, len(trip.StopTimeUpdates)),
+	}
+	if trip.Vehicle != nil && trip.Vehicle.ID != nil {
+		event.VehicleID = trip.Vehicle.ID.ID
+	}
+	for _, stu := range trip.StopTimeUpdates {
+		update := StopTimeUpdate{
+			StopSequence:         stu.StopSequence,
+			StopID:               stringValue(stu.StopID),
+			ScheduleRelationship: stu.ScheduleRelationship.String(),
+		}
+		update.ArrivalTime, update.ArrivalDelay = stopTimeEvent(stu.Arrival)
+		update.DepartureTime, update.DepartureDelay = stopTimeEvent(stu.Departure)
+		event.StopTimeUpdates = append(event.StopTimeUpdates, update)
+	}
+	return event
+}
+
+// NewAlert normalizes a GTFS-RT alert to its event, with IngestedAt unset.
+func NewAlert(alert gtfs.Alert) Alert {
+	event := Alert{
+		ID:               alert.ID,
+		Cause:            alert.Cause.String(),
+		Effect:           alert.Effect.String(),
+		Header:           translatedText(alert.Header),
+		Description:      translatedText(alert.Description),
+		URL:              translatedText(alert.URL),
+		ActivePeriods:    make([]ActivePeriod, 0, len(alert.ActivePeriods)),
+		InformedEntities: make([]InformedEntity, 0, len(alert.InformedEntities)),
+	}
+	for _, period := range alert.ActivePeriods {
+		event.ActivePeriods = append(event.ActivePeriods, ActivePeriod{Start: optionalMillis(period.StartsAt), End: optionalMillis(period.EndsAt)})
+	}
+	for _, entity := range alert.InformedEntities {
+		informed := InformedEntity{
+			AgencyID: stringValue(entity.AgencyID),
+			RouteID:  stringValue(entity.RouteID),
+			StopID:   stringValue(entity.StopID),
+		}
+		if entity.TripID != nil {
+			informed.TripID = entity.TripID.ID
+		}
+		event.InformedEntities = append(event.InformedEntities, informed)
+	}
+	return event
+}
+
+func translatedText(texts []gtfs.AlertText) []TranslatedText {
+	result := make([]TranslatedText, 0, len(texts))
+	for _, text := range texts {
+		result = append(result, TranslatedText{Text: text.Text, Language: text.Language})
+	}
+	return result
+}
+
+// stopTimeEvent returns the time, in Unix milliseconds, and delay, in seconds, of a
+// predicted arrival or departure.
+func stopTimeEvent(e *gtfs.StopTimeEvent) (*int64, *int64) {
+	if e == nil {
+		return nil, nil
+	}
+	var delay *int64
+	if e.Delay != nil {
+		seconds := int64(*e.Delay / time.Second)
+		delay = &seconds
+	}
+	return optionalMillis(e.Time), delay
+}
+
+func startDate(id gtfs.TripID) string {
+	if !id.HasStartDate {
+		return ""
+	}
+	return id.StartDate.Format("20060102")
+}
+
+func millis(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func optionalMillis(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ms := t.UnixMilli()
+	return &ms
+}
+
+func float64Value(f *float32) *float64 {
+	if f == nil {
+		return nil
+	}
+	v := float64(*f)
+	return &v
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,294 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+// fakeTransport records what is published, failing while err is set.
+type fakeTransport struct {
+	mu        sync.Mutex
+	published map[string][]Message
+	err       error
+	sent      chan struct{}
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{published: make(map[string][]Message), sent: make(chan struct{}, 10)}
+}
+
+func (f *fakeTransport) publish(_ context.Context, topic string, messages []Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.published[topic] = append(f.published[topic], messages...)
+	f.sent <- struct{}{}
+	return nil
+}
+
+func (f *fakeTransport) close() error { return nil }
+
+// take returns and clears what was published to topic.
+func (f *fakeTransport) take(topic string) []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := f.published[topic]
+	delete(f.published, topic)
+	return messages
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func testUpdate() GTFS.RealtimeUpdate {
+	reported := time.Date(2026, 3, 2, 8, 15, 0, 0, time.UTC)
+	status := gtfs.CurrentStatus(2) // IN_TRANSIT_TO
+	return GTFS.RealtimeUpdate{
+		Vehicles: []gtfs.Vehicle{
+			{
+				ID:        &gtfs.VehicleID{ID: "bus-1", Label: "101"},
+				Trip:      &gtfs.Trip{ID: gtfs.TripID{ID: "trip-1", RouteID: "route-1", HasStartDate: true, StartDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}},
+				Position:  &gtfs.Position{Latitude: ptr(float32(40.5)), Longitude: ptr(float32(-122.25)), Bearing: ptr(float32(90))},
+				StopID:    ptr("stop-9"),
+				Timestamp: &reported,
+
+				CurrentStatus: &status,
+			},
+			{ID: &gtfs.VehicleID{ID: "bus-2"}},
+			{ID: &gtfs.VehicleID{}}, // No ID, not published
+		},
+		Trips: []gtfs.Trip{
+			{
+				ID:      gtfs.TripID{ID: "trip-1", RouteID: "route-1"},
+				Vehicle: &gtfs.Vehicle{ID: &gtfs.VehicleID{ID: "bus-1"}},
+				StopTimeUpdates: []gtfs.StopTimeUpdate{
+					{StopSequence: ptr(uint32(4)), StopID: ptr("stop-9"), Arrival: &gtfs.StopTimeEvent{Time: ptr(reported.Add(3 * time.Minute)), Delay: ptr(90 * time.Second)}},
+				},
+			},
+		},
+		Alerts: []gtfs.Alert{
+			{
+				ID:               "alert-1",
+				Cause:            gtfs.Construction,
+				Effect:           gtfs.Detour,
+				Header:           []gtfs.AlertText{{Text: "Detour on Main St", Language: "en"}},
+				ActivePeriods:    []gtfs.AlertActivePeriod{{StartsAt: &reported}},
+				InformedEntities: []gtfs.AlertInformedEntity{{RouteID: ptr("route-1")}},
+			},
+		},
+	}
+}
+
+func TestPublishNormalizesEvents(t *testing.T) {
+	transport := newFakeTransport()
+	p := newPublisher(transport, "gtfs-rt.", slog.Default())
+	p.now = func() time.Time { return time.UnixMilli(1772439300000) }
+
+	require.NoError(t, p.Publish(context.Background(), testUpdate()))
+
+	vehicles := transport.take("gtfs-rt.vehicle-positions")
+	require.Len(t, vehicles, 2)
+	assert.Equal(t, "bus-1", vehicles[0].Key)
+	assert.JSONEq(t, `{
+		"vehicleId": "bus-1", "label": "101", "tripId": "trip-1", "routeId": "route-1", "startDate": "20260302",
+		"latitude": 40.5, "longitude": -122.25, "bearing": 90, "stopId": "stop-9", "currentStatus": "IN_TRANSIT_TO",
+		"timestamp": 1772439300000, "ingestedAt": 1772439300000
+	}`, string(vehicles[0].Value))
+	assert.JSONEq(t, `{"vehicleId": "bus-2", "ingestedAt": 1772439300000}`, string(vehicles[1].Value))
+
+	trips := transport.take("gtfs-rt.trip-updates")
+	require.Len(t, trips, 1)
+	assert.Equal(t, "trip-1", trips[0].Key)
+	assert.JSONEq(t, `{
+		"tripId": "trip-1", "routeId": "route-1", "scheduleRelationship": "SCHEDULED", "vehicleId": "bus-1",
+		"stopTimeUpdates": [{"stopSequence": 4, "stopId": "stop-9", "arrivalTime": 1772439480000, "arrivalDelay": 90, "scheduleRelationship": "SCHEDULED"}],
+		"ingestedAt": 1772439300000
+	}`, string(trips[0].Value))
+
+	alerts := transport.take("gtfs-rt.alerts")
+	require.Len(t, alerts, 1)
+	assert.JSONEq(t, `{
+		"id": "alert-1", "cause": "CONSTRUCTION", "effect": "DETOUR",
+		"header": [{"text": "Detour on Main St", "language": "en"}], "description": [], "url": [],
+		"activePeriods": [{"start": 1772439300000}], "informedEntities": [{"routeId": "route-1"}],
+		"ingestedAt": 1772439300000
+	}`, string(alerts[0].Value))
+}
+
+func TestPublishSendsOnlyChanges(t *testing.T) {
+	transport := newFakeTransport()
+	p := newPublisher(transport, "gtfs-rt.", slog.Default())
+	update := testUpdate()
+	require.NoError(t, p.Publish(context.Background(), update))
+	transport.take("gtfs-rt.vehicle-positions")
+	transport.take("gtfs-rt.trip-updates")
+	transport.take("gtfs-rt.alerts")
+
+	// Nothing changed
+	p.now = func() time.Time { return time.Now().Add(time.Minute) }
+	require.NoError(t, p.Publish(context.Background(), update))
+	assert.Empty(t, transport.take("gtfs-rt.vehicle-positions"))
+	assert.Empty(t, transport.take("gtfs-rt.trip-updates"))
+	assert.Empty(t, transport.take("gtfs-rt.alerts"))
+
+	// One vehicle moved, and only the vehicle feed was refreshed
+	moved := testUpdate()
+	moved.Vehicles[1].Position = &gtfs.Position{Latitude: ptr(float32(40.6)), Longitude: ptr(float32(-122.3))}
+	require.NoError(t, p.Publish(context.Background(), GTFS.RealtimeUpdate{Vehicles: moved.Vehicles}))
+	vehicles := transport.take("gtfs-rt.vehicle-positions")
+	require.Len(t, vehicles, 1)
+	assert.Equal(t, "bus-2", vehicles[0].Key)
+
+	// A vehicle that left the feed is sent again when it returns
+	require.NoError(t, p.Publish(context.Background(), GTFS.RealtimeUpdate{Vehicles: moved.Vehicles[:1]}))
+	assert.Empty(t, transport.take("gtfs-rt.vehicle-positions"))
+	require.NoError(t, p.Publish(context.Background(), GTFS.RealtimeUpdate{Vehicles: moved.Vehicles}))
+	assert.Len(t, transport.take("gtfs-rt.vehicle-positions"), 1)
+
+	// Events the broker refused are sent again
+	transport.err = errors.New("broker unavailable")
+	changed := testUpdate()
+	changed.Alerts[0].Effect = gtfs.NoService
+	assert.ErrorContains(t, p.Publish(context.Background(), GTFS.RealtimeUpdate{Alerts: changed.Alerts}), "gtfs-rt.alerts")
+	transport.err = nil
+	require.NoError(t, p.Publish(context.Background(), GTFS.RealtimeUpdate{Alerts: changed.Alerts}))
+	assert.Len(t, transport.take("gtfs-rt.alerts"), 1)
+}
+
+func TestPublisherIngest(t *testing.T) {
+	transport := newFakeTransport()
+	p := newPublisher(transport, "gtfs-rt.", slog.Default())
+	p.Start()
+	defer p.Shutdown()
+
+	p.Ingest(GTFS.RealtimeUpdate{Alerts: testUpdate().Alerts})
+	select {
+	case <-transport.sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ingested refresh was not published")
+	}
+	assert.Len(t, transport.take("gtfs-rt.alerts"), 1)
+}
+
+// serveNATS accepts one connection and speaks enough of the NATS protocol to receive
+// publishes, refusing clients whose CONNECT does not carry wantUser.
+func serveNATS(t *testing.T, wantUser string) (address string, received <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	lines := make(chan string, 100)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n")
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			op, args, _ := strings.Cut(line, " ")
+			switch op {
+			case "CONNECT":
+				var connect struct {
+					User string `json:"user"`
+				}
+				_ = json.Unmarshal([]byte(args), &connect)
+				if connect.User != wantUser {
+					_, _ = fmt.Fprintf(conn, "-ERR 'Authorization Violation'\r\n")
+					return
+				}
+			case "PUB":
+				fields := strings.Fields(args)
+				size, _ := strconv.Atoi(fields[1])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(reader, payload); err != nil {
+					return
+				}
+				lines <- fields[0] + " " + string(payload[:size])
+			case "PING":
+				_, _ = fmt.Fprintf(conn, "PONG\r\n")
+			}
+		}
+	}()
+	return listener.Addr().String(), lines
+}
+
+func TestNATSTransport(t *testing.T) {
+	address, received := serveNATS(t, "maglev")
+	transport := newNATSTransport(appconf.EventPublishingConfig{Broker: "nats", URL: "nats://" + address, Username: "maglev", Password: "secret"})
+	defer func() { _ = transport.close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, transport.publish(ctx, "gtfs-rt.alerts", []Message{
+		{Key: "a", Value: json.RawMessage(`{"id":"a"}`)},
+		{Key: "b", Value: json.RawMessage(`{"id":"b"}`)},
+	}))
+	// The PONG arrived after both messages
+	assert.Equal(t, `gtfs-rt.alerts {"id":"a"}`, <-received)
+	assert.Equal(t, `gtfs-rt.alerts {"id":"b"}`, <-received)
+
+	address, _ = serveNATS(t, "someone-else")
+	refused := newNATSTransport(appconf.EventPublishingConfig{Broker: "nats", URL: "nats://" + address, Username: "maglev"})
+	err := refused.publish(ctx, "gtfs-rt.alerts", []Message{{Key: "a", Value: json.RawMessage(`{}`)}})
+	assert.ErrorContains(t, err, "Authorization Violation")
+	assert.Nil(t, refused.conn)
+}
+
+func TestKafkaBrokers(t *testing.T) {
+	brokers, useTLS := kafkaBrokers("kafka://kafka-1:9093, kafka-2")
+	assert.Equal(t, []string{"kafka-1:9093", "kafka-2:9092"}, brokers)
+	assert.False(t, useTLS)
+
+	brokers, useTLS = kafkaBrokers("kafka+tls://kafka.example.com:9094/")
+	assert.Equal(t, []string{"kafka.example.com:9094"}, brokers)
+	assert.True(t, useTLS)
+}
+
+func TestKafkaTransport(t *testing.T) {
+	transport := newKafkaTransport(appconf.EventPublishingConfig{Broker: "kafka", URL: "kafka+tls://kafka.example.com", Username: "maglev", Password: "secret"})
+	defer func() { _ = transport.close() }()
+	kafkaTransport := transport.writer.Transport.(*kafka.Transport)
+	assert.NotNil(t, kafkaTransport.TLS)
+	assert.Equal(t, plain.Mechanism{Username: "maglev", Password: "secret"}, kafkaTransport.SASL)
+	assert.Equal(t, kafka.RequireAll, transport.writer.RequiredAcks)
+
+	// A broker that cannot be reached fails the publish
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	unreachable := newKafkaTransport(appconf.EventPublishingConfig{Broker: "kafka", URL: "kafka://" + address})
+	defer func() { _ = unreachable.close() }()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = unreachable.publish(ctx, "gtfs-rt.vehicle-positions", []Message{{Key: "bus-1", Value: json.RawMessage(`{}`)}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+const (
+	// kafkaBatchSize caps the records sent in one produce request.
+	kafkaBatchSize = 500
+
+	// kafkaBatchTimeout is how long the writer waits for a batch to fill. Each refresh is
+	// written in one call, so there is nothing to wait for.
+	kafkaBatchTimeout = 10 * time.Millisecond
+)
+
+// kafkaTransport produces to the brokers directly: each record's key is the entity ID, so one
+// entity's events stay in order on one partition, and its value the event. Every in-sync
+// replica must acknowledge a batch.
+type kafkaTransport struct {
+	writer *kafka.Writer
+}
+
+func newKafkaTransport(cfg appconf.EventPublishingConfig) *kafkaTransport {
+	brokers, useTLS := kafkaBrokers(cfg.URL)
+	transport := &kafka.Transport{ClientID: "maglev"}
+	if useTLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.Username != "" {
+		transport.SASL = plain.Mechanism{Username: cfg.Username, Password: cfg.Password}
+	}
+	return &kafkaTransport{writer: &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		BatchSize:              kafkaBatchSize,
+		BatchTimeout:           kafkaBatchTimeout,
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+		Transport:              transport,
+	}}
+}
+
+// kafkaBrokers splits a kafka:// or kafka+tls:// URL into its comma-separated bootstrap
+// brokers, adding the default port where one is missing, and reports whether to use TLS.
+func kafkaBrokers(rawURL string) (brokers []string, useTLS bool) {
+	scheme, hosts, _ := strings.Cut(rawURL, "://")
+	hosts, _, _ = strings.Cut(hosts, "/")
+	for _, host := range strings.Split(hosts, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, "9092")
+		}
+		brokers = append(brokers, host)
+	}
+	return brokers, scheme == "kafka+tls"
+}
+
+func (t *kafkaTransport) publish(ctx context.Context, topic string, messages []Message) error {
+	records := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		records = append(records, kafka.Message{Topic: topic, Key: []byte(message.Key), Value: message.Value})
+	}
+	return t.writer.WriteMessages(ctx, records...)
+}
+
+func (t *kafkaTransport) close() error {
+	return t.writer.Close()
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// natsDialTimeout bounds connecting to the server, including the TLS handshake.
+const natsDialTimeout = 10 * time.Second
+
+// natsTransport publishes each event to the topic's subject, then flushes so the server's
+// PONG confirms it accepted the batch. The connection is opened on first use; nats.go
+// reconnects it after it drops, and a connection that failed for good is opened again.
+type natsTransport struct {
+	url     string // nats:// or tls://
+	options []nats.Option
+
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+func newNATSTransport(cfg appconf.EventPublishingConfig) *natsTransport {
+	options := []nats.Option{nats.Name("maglev"), nats.Timeout(natsDialTimeout)}
+	if cfg.Username != "" {
+		options = append(options, nats.UserInfo(cfg.Username, cfg.Password))
+	} else if cfg.Password != "" {
+		options = append(options, nats.Token(cfg.Password))
+	}
+	return &natsTransport{url: cfg.URL, options: options}
+}
+
+func (t *natsTransport) publish(ctx context.Context, subject string, messages []Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == nil || t.conn.IsClosed() {
+		conn, err := nats.Connect(t.url, t.options...)
+		if err != nil {
+			t.conn = nil
+			return fmt.Errorf("failed to connect to NATS: %w", err)
+		}
+		t.conn = conn
+	}
+
+	for _, message := range messages {
+		if err := t.conn.Publish(subject, message.Value); err != nil {
+			return err
+		}
+	}
+	// FlushWithContext needs a deadline
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, publishTimeout)
+		defer cancel()
+	}
+	return t.conn.FlushWithContext(ctx)
+}
+
+func (t *natsTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil
+	}
+	t.conn.Close()
+	t.conn = nil
+	return nil
+}
@@ -0,0 +1,419 @@
+// Package gbfs polls GBFS (General Bikeshare Feed Specification) feeds for the location and
+// availability of bikeshare stations. Each system is found through its gbfs.json
+// auto-discovery file; station_information is re-read hourly and station_status on every
+// poll. GBFS versions 1.x to 3.x are accepted.
+package gbfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// informationRefreshInterval is how often station locations, names and capacities are
+// re-read. They change rarely, unlike availability.
+const informationRefreshInterval = time.Hour
+
+// maxFeedSize bounds a GBFS file.
+const maxFeedSize = 10 * 1024 * 1024
+
+// Station is a bikeshare station and its latest availability.
+type Station struct {
+	SystemID       string
+	ID             string // Station ID within its system
+	Name           string
+	Lat            float64
+	Lon            float64
+	Capacity       int
+	BikesAvailable int
+	DocksAvailable int
+	IsRenting      bool
+	IsReturning    bool
+	LastReported   time.Time // Zero until the station appears in station_status
+}
+
+// system is the state of one feed.
+type system struct {
+	feed              appconf.GBFSFeed
+	informationURL    string
+	statusURL         string
+	informationLoaded time.Time
+	stations          map[string]Station
+}
+
+// Poller keeps the stations of every configured system up to date.
+type Poller struct {
+	client   *http.Client
+	interval time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	systems []*system
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPoller returns a poller for the feeds in cfg. It does not fetch anything until Start or
+// Refresh is called.
+func NewPoller(cfg appconf.GBFSConfig, client *http.Client, logger *slog.Logger) *Poller {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	interval := time.Duration(cfg.RefreshInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	poller := &Poller{
+		client:   client,
+		interval: interval,
+		logger:   logger.With(slog.String("component", "gbfs")),
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+	for _, feed := range cfg.Feeds {
+		poller.systems = append(poller.systems, &system{feed: feed, stations: map[string]Station{}})
+	}
+	return poller
+}
+
+// Start polls every feed now and then every refresh interval until Shutdown.
+func (p *Poller) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-p.stopChan:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			if err := p.Refresh(ctx); err != nil && ctx.Err() == nil {
+				logging.LogError(p.logger, "failed to refresh GBFS feeds", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-p.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown stops polling and waits for a poll in progress to end.
+func (p *Poller) Shutdown() {
+	p.stopOnce.Do(func() { close(p.stopChan) })
+	p.wg.Wait()
+}
+
+// Refresh polls every feed once. A feed that fails keeps its previous stations; the errors of
+// all failed feeds are returned together.
+func (p *Poller) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, sys := range p.systems {
+		if err := p.refreshSystem(ctx, sys); err != nil {
+			errs = append(errs, fmt.Errorf("gbfs feed %s: %w", sys.feed.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Poller) refreshSystem(ctx context.Context, sys *system) error {
+	p.mu.RLock()
+	informationURL, statusURL := sys.informationURL, sys.statusURL
+	stations := sys.stations
+	reloadInformation := p.now().Sub(sys.informationLoaded) >= informationRefreshInterval
+	p.mu.RUnlock()
+
+	if informationURL == "" || statusURL == "" || reloadInformation {
+		var err error
+		informationURL, statusURL, err = p.discover(ctx, sys.feed.URL)
+		if err != nil {
+			return err
+		}
+	}
+
+	if reloadInformation {
+		var information stationInformationFile
+		if err := p.fetch(ctx, informationURL, &information); err != nil {
+			return fmt.Errorf("station_information: %w", err)
+		}
+		previous := stations
+		stations = make(map[string]Station, len(information.Data.Stations))
+		for _, info := range information.Data.Stations {
+			station := previous[info.StationID]
+			station.SystemID = sys.feed.ID
+			station.ID = info.StationID
+			station.Name = string(info.Name)
+			station.Lat = info.Lat
+			station.Lon = info.Lon
+			station.Capacity = info.Capacity
+			stations[info.StationID] = station
+		}
+	}
+
+	var status stationStatusFile
+	if err := p.fetch(ctx, statusURL, &status); err != nil {
+		return fmt.Errorf("station_status: %w", err)
+	}
+	updated := make(map[string]Station, len(stations))
+	for id, station := range stations {
+		updated[id] = station
+	}
+	for _, s := range status.Data.Stations {
+		station, ok := updated[s.StationID]
+		if !ok {
+			continue // Not in station_information, so its location is unknown
+		}
+		station.BikesAvailable = s.NumBikesAvailable
+		if s.NumVehiclesAvailable != nil {
+			station.BikesAvailable = *s.NumVehiclesAvailable
+		}
+		station.DocksAvailable = s.NumDocksAvailable
+		station.IsRenting = bool(s.IsRenting)
+		station.IsReturning = bool(s.IsReturning)
+		station.LastReported = time.Time(s.LastReported)
+		updated[s.StationID] = station
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sys.informationURL, sys.statusURL = informationURL, statusURL
+	if reloadInformation {
+		sys.informationLoaded = p.now()
+	}
+	sys.stations = updated
+	return nil
+}
+
+// discover reads the auto-discovery file for the station_information and station_status
+// URLs. Version 3 lists the files directly; earlier versions list them per language, of
+// which English is preferred.
+func (p *Poller) discover(ctx context.Context, url string) (informationURL, statusURL string, err error) {
+	var discovery struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := p.fetch(ctx, url, &discovery); err != nil {
+		return "", "", fmt.Errorf("gbfs.json: %w", err)
+	}
+
+	feedsJSON, ok := discovery.Data["feeds"]
+	if !ok {
+		languages := make([]string, 0, len(discovery.Data))
+		for language := range discovery.Data {
+			languages = append(languages, language)
+		}
+		sort.Slice(languages, func(i, j int) bool {
+			return languages[i] == "en" || (languages[j] != "en" && languages[i] < languages[j])
+		})
+		for _, language := range languages {
+			var localized struct {
+				Feeds json.RawMessage `json:"feeds"`
+			}
+			if json.Unmarshal(discovery.Data[language], &localized) == nil && localized.Feeds != nil {
+				feedsJSON = localized.Feeds
+				break
+			}
+		}
+	}
+
+	var feeds []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	if err := json.Unmarshal(feedsJSON, &feeds); err != nil {
+		return "", "", fmt.Errorf("gbfs.json: no feeds listed")
+	}
+	for _, feed := range feeds {
+		switch feed.Name {
+		case "station_information":
+			informationURL = feed.URL
+		case "station_status":
+			statusURL = feed.URL
+		}
+	}
+	if informationURL == "" || statusURL == "" {
+		return "", "", fmt.Errorf("gbfs.json: station_information and station_status are required")
+	}
+	return informationURL, statusURL, nil
+}
+
+func (p *Poller) fetch(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer logging.SafeCloseWithLogging(resp.Body, p.logger, "http_response_body")
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxFeedSize {
+		return fmt.Errorf("%s exceeds size limit of %d bytes", url, maxFeedSize)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// Stations returns every station of every system.
+func (p *Poller) Stations() []Station {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var stations []Station
+	for _, sys := range p.systems {
+		for _, station := range sys.stations {
+			stations = append(stations, station)
+		}
+	}
+	return stations
+}
+
+// StationsInBounds returns the stations within bounds, nearest to lat/lon first, and whether
+// more than maxCount were found. A maxCount of zero or less returns them all.
+func (p *Poller) StationsInBounds(bounds utils.CoordinateBounds, lat, lon float64, maxCount int) ([]Station, bool) {
+	var stations []Station
+	for _, station := range p.Stations() {
+		if station.Lat >= bounds.MinLat && station.Lat <= bounds.MaxLat &&
+			station.Lon >= bounds.MinLon && station.Lon <= bounds.MaxLon {
+			stations = append(stations, station)
+		}
+	}
+
+	sort.SliceStable(stations, func(i, j int) bool {
+		di := utils.Distance(lat, lon, stations[i].Lat, stations[i].Lon)
+		dj := utils.Distance(lat, lon, stations[j].Lat, stations[j].Lon)
+		if di != dj {
+			return di < dj
+		}
+		return stations[i].SystemID+stations[i].ID < stations[j].SystemID+stations[j].ID
+	})
+	if maxCount > 0 && len(stations) > maxCount {
+		return stations[:maxCount], true
+	}
+	return stations, false
+}
+
+type stationInformationFile struct {
+	Data struct {
+		Stations []struct {
+			StationID string        `json:"station_id"`
+			Name      localizedName `json:"name"`
+			Lat       float64       `json:"lat"`
+			Lon       float64       `json:"lon"`
+			Capacity  int           `json:"capacity"`
+		} `json:"stations"`
+	} `json:"data"`
+}
+
+type stationStatusFile struct {
+	Data struct {
+		Stations []struct {
+			StationID            string       `json:"station_id"`
+			NumBikesAvailable    int          `json:"num_bikes_available"`
+			NumVehiclesAvailable *int         `json:"num_vehicles_available"` // Version 3
+			NumDocksAvailable    int          `json:"num_docks_available"`
+			IsRenting            flexibleBool `json:"is_renting"`
+			IsReturning          flexibleBool `json:"is_returning"`
+			LastReported         flexibleTime `json:"last_reported"`
+		} `json:"stations"`
+	} `json:"data"`
+}
+
+// localizedName is a plain string before version 3 and a list of translations from it on;
+// the English translation, or else the first, is used.
+type localizedName string
+
+func (n *localizedName) UnmarshalJSON(data []byte) error {
+	var plain string
+	if err := json.Unmarshal(data, &plain); err == nil {
+		*n = localizedName(plain)
+		return nil
+	}
+	var translations []struct {
+		Text     string `json:"text"`
+		Language string `json:"language"`
+	}
+	if err := json.Unmarshal(data, &translations); err != nil {
+		return err
+	}
+	for i, translation := range translations {
+		if i == 0 || translation.Language == "en" {
+			*n = localizedName(translation.Text)
+		}
+		if translation.Language == "en" {
+			break
+		}
+	}
+	return nil
+}
+
+// flexibleBool is a boolean, or 0 or 1 as in version 1.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// flexibleTime is POSIX seconds before version 3 and an RFC3339 string from it on.
+type flexibleTime time.Time
+
+func (t *flexibleTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if seconds, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		*t = flexibleTime(time.Unix(seconds, 0))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return err
+	}
+	*t = flexibleTime(parsed)
+	return nil
+}
@@ -0,0 +1,149 @@
+package gbfs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// newFeedServer serves a GBFS system from files keyed by path. {base} in a file is replaced
+// with the server URL.
+func newFeedServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(strings.ReplaceAll(body, "{base}", server.URL)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+var version2Files = map[string]string{
+	"/gbfs.json": `{"data": {"fr": {"feeds": []}, "en": {"feeds": [
+		{"name": "station_information", "url": "{base}/station_information.json"},
+		{"name": "station_status", "url": "{base}/station_status.json"}
+	]}}}`,
+	"/station_information.json": `{"data": {"stations": [
+		{"station_id": "1", "name": "Main & 1st", "lat": 47.6000, "lon": -122.3300, "capacity": 15},
+		{"station_id": "2", "name": "Main & 9th", "lat": 47.6100, "lon": -122.3300, "capacity": 10},
+		{"station_id": "far", "name": "Far away", "lat": 48.0, "lon": -122.0, "capacity": 5}
+	]}}`,
+	"/station_status.json": `{"data": {"stations": [
+		{"station_id": "1", "num_bikes_available": 4, "num_docks_available": 11, "is_renting": true, "is_returning": 1, "last_reported": 1749740400},
+		{"station_id": "2", "num_bikes_available": 0, "num_docks_available": 10, "is_renting": false, "is_returning": true, "last_reported": 1749740400},
+		{"station_id": "unknown", "num_bikes_available": 1, "num_docks_available": 1, "is_renting": true, "is_returning": true, "last_reported": 1749740400}
+	]}}`,
+}
+
+func TestRefresh_Version2(t *testing.T) {
+	server := newFeedServer(t, version2Files)
+	poller := NewPoller(appconf.GBFSConfig{Feeds: []appconf.GBFSFeed{{ID: "bikes", URL: server.URL + "/gbfs.json"}}}, server.Client(), nil)
+	require.NoError(t, poller.Refresh(context.Background()))
+
+	stations := poller.Stations()
+	require.Len(t, stations, 3, "stations only in station_status are left out")
+
+	nearest, limitExceeded := poller.StationsInBounds(utils.CalculateBounds(47.6, -122.33, 2000), 47.6, -122.33, 0)
+	assert.False(t, limitExceeded)
+	require.Len(t, nearest, 2)
+	assert.Equal(t, Station{
+		SystemID:       "bikes",
+		ID:             "1",
+		Name:           "Main & 1st",
+		Lat:            47.6,
+		Lon:            -122.33,
+		Capacity:       15,
+		BikesAvailable: 4,
+		DocksAvailable: 11,
+		IsRenting:      true,
+		IsReturning:    true,
+		LastReported:   time.Unix(1749740400, 0),
+	}, nearest[0])
+	assert.Equal(t, "2", nearest[1].ID)
+	assert.False(t, nearest[1].IsRenting)
+
+	limited, limitExceeded := poller.StationsInBounds(utils.CalculateBounds(47.6, -122.33, 2000), 47.6, -122.33, 1)
+	assert.True(t, limitExceeded)
+	assert.Len(t, limited, 1)
+}
+
+func TestRefresh_Version3(t *testing.T) {
+	server := newFeedServer(t, map[string]string{
+		"/gbfs.json": `{"version": "3.0", "data": {"feeds": [
+			{"name": "station_information", "url": "{base}/station_information.json"},
+			{"name": "station_status", "url": "{base}/station_status.json"}
+		]}}`,
+		"/station_information.json": `{"data": {"stations": [
+			{"station_id": "a", "name": [{"text": "Gare", "language": "fr"}, {"text": "Station", "language": "en"}], "lat": 45.5, "lon": -73.6}
+		]}}`,
+		"/station_status.json": `{"data": {"stations": [
+			{"station_id": "a", "num_vehicles_available": 7, "num_docks_available": 3, "is_renting": true, "is_returning": true, "last_reported": "2025-06-12T15:00:00Z"}
+		]}}`,
+	})
+	poller := NewPoller(appconf.GBFSConfig{Feeds: []appconf.GBFSFeed{{ID: "bixi", URL: server.URL + "/gbfs.json"}}}, server.Client(), nil)
+	require.NoError(t, poller.Refresh(context.Background()))
+
+	stations := poller.Stations()
+	require.Len(t, stations, 1)
+	assert.Equal(t, "Station", stations[0].Name)
+	assert.Equal(t, 7, stations[0].BikesAvailable)
+	assert.True(t, time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC).Equal(stations[0].LastReported))
+}
+
+func TestRefresh_FailedFeedKeepsStations(t *testing.T) {
+	files := map[string]string{}
+	for path, body := range version2Files {
+		files[path] = body
+	}
+	server := newFeedServer(t, files)
+	poller := NewPoller(appconf.GBFSConfig{Feeds: []appconf.GBFSFeed{
+		{ID: "bikes", URL: server.URL + "/gbfs.json"},
+		{ID: "missing", URL: server.URL + "/missing/gbfs.json"},
+	}}, server.Client(), nil)
+
+	err := poller.Refresh(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gbfs feed missing")
+	assert.Len(t, poller.Stations(), 3, "one failing feed does not affect the others")
+
+	delete(files, "/station_status.json")
+	assert.Error(t, poller.Refresh(context.Background()))
+	assert.Len(t, poller.Stations(), 3)
+}
+
+func TestRefresh_ReloadsInformationHourly(t *testing.T) {
+	files := map[string]string{}
+	for path, body := range version2Files {
+		files[path] = body
+	}
+	server := newFeedServer(t, files)
+	poller := NewPoller(appconf.GBFSConfig{Feeds: []appconf.GBFSFeed{{ID: "bikes", URL: server.URL + "/gbfs.json"}}}, server.Client(), nil)
+	now := time.Date(2025, 6, 12, 8, 0, 0, 0, time.UTC)
+	poller.now = func() time.Time { return now }
+	require.NoError(t, poller.Refresh(context.Background()))
+
+	files["/station_information.json"] = `{"data": {"stations": [{"station_id": "1", "name": "Renamed", "lat": 47.6, "lon": -122.33}]}}`
+	now = now.Add(time.Minute)
+	require.NoError(t, poller.Refresh(context.Background()))
+	assert.Len(t, poller.Stations(), 3, "station information is not re-read within the hour")
+
+	now = now.Add(time.Hour)
+	require.NoError(t, poller.Refresh(context.Background()))
+	stations := poller.Stations()
+	require.Len(t, stations, 1)
+	assert.Equal(t, "Renamed", stations[0].Name)
+	assert.Equal(t, 4, stations[0].BikesAvailable)
+}
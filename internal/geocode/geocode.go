@@ -0,0 +1,209 @@
+// Package geocode resolves addresses and place names to coordinates with an external
+// geocoder, so a search box can find the stops near a place as well as stops by name.
+// Pelias and Nominatim are supported.
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// requestTimeout bounds one geocoder request, which is made while a client waits.
+const requestTimeout = 5 * time.Second
+
+// maxResponseSize bounds a geocoder response.
+const maxResponseSize = 1024 * 1024
+
+// userAgent identifies the server to the geocoder; the Nominatim usage policy requires one.
+const userAgent = "maglev (OneBusAway)"
+
+// Place is a geocoded address or landmark.
+type Place struct {
+	Name string
+	Lat  float64
+	Lon  float64
+}
+
+// Request is a search for places.
+type Request struct {
+	Text   string
+	Limit  int
+	Bounds *utils.CoordinateBounds // Only places inside are returned, when set
+	Focus  *Place                  // Places near it are preferred, when set
+}
+
+// Geocoder looks places up in an external service.
+type Geocoder struct {
+	provider string
+	baseURL  string
+	apiKey   string
+	client   *http.Client
+}
+
+// New returns a geocoder for cfg.
+func New(cfg appconf.GeocoderConfig, client *http.Client) (*Geocoder, error) {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+	switch cfg.Provider {
+	case appconf.GeocoderPelias, appconf.GeocoderNominatim:
+	default:
+		return nil, fmt.Errorf("unknown geocoder provider %q", cfg.Provider)
+	}
+	return &Geocoder{
+		provider: cfg.Provider,
+		baseURL:  strings.TrimSuffix(cfg.URL, "/"),
+		apiKey:   cfg.APIKey,
+		client:   client,
+	}, nil
+}
+
+// Search returns the places matching the request, best match first.
+func (g *Geocoder) Search(ctx context.Context, req Request) ([]Place, error) {
+	if strings.TrimSpace(req.Text) == "" {
+		return nil, nil
+	}
+	if req.Limit <= 0 {
+		req.Limit = 5
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	if g.provider == appconf.GeocoderNominatim {
+		var results []nominatimResult
+		if err := g.get(ctx, "/search", nominatimQuery(req), &results); err != nil {
+			return nil, err
+		}
+		places := make([]Place, 0, len(results))
+		for _, result := range results {
+			lat, latErr := strconv.ParseFloat(result.Lat, 64)
+			lon, lonErr := strconv.ParseFloat(result.Lon, 64)
+			if latErr != nil || lonErr != nil {
+				continue
+			}
+			name := result.DisplayName
+			if name == "" {
+				name = result.Name
+			}
+			places = append(places, Place{Name: name, Lat: lat, Lon: lon})
+		}
+		return places, nil
+	}
+
+	var collection peliasCollection
+	if err := g.get(ctx, "/v1/search", g.peliasQuery(req), &collection); err != nil {
+		return nil, err
+	}
+	places := make([]Place, 0, len(collection.Features))
+	for _, feature := range collection.Features {
+		if len(feature.Geometry.Coordinates) < 2 {
+			continue
+		}
+		name := feature.Properties.Label
+		if name == "" {
+			name = feature.Properties.Name
+		}
+		places = append(places, Place{Name: name, Lat: feature.Geometry.Coordinates[1], Lon: feature.Geometry.Coordinates[0]})
+	}
+	return places, nil
+}
+
+func (g *Geocoder) get(ctx context.Context, path string, query url.Values, into any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("geocoder request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geocoder returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read geocoder response: %w", err)
+	}
+	if len(body) > maxResponseSize {
+		return errors.New("geocoder response is too large")
+	}
+	if err := json.Unmarshal(body, into); err != nil {
+		return fmt.Errorf("failed to parse geocoder response: %w", err)
+	}
+	return nil
+}
+
+// peliasCollection is the GeoJSON a Pelias search returns.
+type peliasCollection struct {
+	Features []struct {
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"` // [lon, lat]
+		} `json:"geometry"`
+		Properties struct {
+			Name  string `json:"name"`
+			Label string `json:"label"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+func (g *Geocoder) peliasQuery(req Request) url.Values {
+	query := url.Values{}
+	query.Set("text", req.Text)
+	query.Set("size", strconv.Itoa(req.Limit))
+	if g.apiKey != "" {
+		query.Set("api_key", g.apiKey)
+	}
+	if b := req.Bounds; b != nil {
+		query.Set("boundary.rect.min_lat", formatCoordinate(b.MinLat))
+		query.Set("boundary.rect.min_lon", formatCoordinate(b.MinLon))
+		query.Set("boundary.rect.max_lat", formatCoordinate(b.MaxLat))
+		query.Set("boundary.rect.max_lon", formatCoordinate(b.MaxLon))
+	}
+	if f := req.Focus; f != nil {
+		query.Set("focus.point.lat", formatCoordinate(f.Lat))
+		query.Set("focus.point.lon", formatCoordinate(f.Lon))
+	}
+	return query
+}
+
+// nominatimResult is one place a Nominatim search returns; coordinates are strings.
+type nominatimResult struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// nominatimQuery builds a Nominatim search. Nominatim has no focus point, so the focus is
+// not sent.
+func nominatimQuery(req Request) url.Values {
+	query := url.Values{}
+	query.Set("q", req.Text)
+	query.Set("format", "jsonv2")
+	query.Set("limit", strconv.Itoa(req.Limit))
+	if b := req.Bounds; b != nil {
+		query.Set("viewbox", strings.Join([]string{
+			formatCoordinate(b.MinLon), formatCoordinate(b.MaxLat), formatCoordinate(b.MaxLon), formatCoordinate(b.MinLat),
+		}, ","))
+		query.Set("bounded", "1")
+	}
+	return query
+}
+
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', 6, 64)
+}
@@ -0,0 +1,88 @@
+package geocode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func geocoderServer(t *testing.T, path, body string) (*httptest.Server, *url.Values) {
+	t.Helper()
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		assert.NotEmpty(t, r.Header.Get("User-Agent"))
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &query
+}
+
+func TestSearch_Pelias(t *testing.T) {
+	server, query := geocoderServer(t, "/v1/search", `{"type": "FeatureCollection", "features": [
+		{"geometry": {"type": "Point", "coordinates": [-122.3331, 47.6097]}, "properties": {"name": "Pike Place", "label": "Pike Place Market, Seattle, WA"}},
+		{"geometry": {"type": "Point", "coordinates": []}, "properties": {"name": "Broken"}}
+	]}`)
+	geocoder, err := New(appconf.GeocoderConfig{Provider: appconf.GeocoderPelias, URL: server.URL + "/", APIKey: "secret"}, nil)
+	require.NoError(t, err)
+
+	places, err := geocoder.Search(t.Context(), Request{
+		Text:   "pike place",
+		Limit:  3,
+		Bounds: &utils.CoordinateBounds{MinLat: 47.5, MaxLat: 47.7, MinLon: -122.4, MaxLon: -122.2},
+		Focus:  &Place{Lat: 47.6, Lon: -122.3},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Place{{Name: "Pike Place Market, Seattle, WA", Lat: 47.6097, Lon: -122.3331}}, places)
+	assert.Equal(t, "pike place", query.Get("text"))
+	assert.Equal(t, "3", query.Get("size"))
+	assert.Equal(t, "secret", query.Get("api_key"))
+	assert.Equal(t, "47.500000", query.Get("boundary.rect.min_lat"))
+	assert.Equal(t, "-122.300000", query.Get("focus.point.lon"))
+}
+
+func TestSearch_Nominatim(t *testing.T) {
+	server, query := geocoderServer(t, "/search", `[
+		{"lat": "47.6097", "lon": "-122.3331", "name": "Pike Place Market", "display_name": "Pike Place Market, Seattle"},
+		{"lat": "north", "lon": "-122.3331", "name": "Broken"}
+	]`)
+	geocoder, err := New(appconf.GeocoderConfig{Provider: appconf.GeocoderNominatim, URL: server.URL}, nil)
+	require.NoError(t, err)
+
+	places, err := geocoder.Search(t.Context(), Request{
+		Text:   "pike place",
+		Bounds: &utils.CoordinateBounds{MinLat: 47.5, MaxLat: 47.7, MinLon: -122.4, MaxLon: -122.2},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Place{{Name: "Pike Place Market, Seattle", Lat: 47.6097, Lon: -122.3331}}, places)
+	assert.Equal(t, "pike place", query.Get("q"))
+	assert.Equal(t, "5", query.Get("limit"))
+	assert.Equal(t, "-122.400000,47.700000,-122.200000,47.500000", query.Get("viewbox"))
+	assert.Equal(t, "1", query.Get("bounded"))
+}
+
+func TestSearch_Errors(t *testing.T) {
+	_, err := New(appconf.GeocoderConfig{Provider: "google", URL: "https://example.com"}, nil)
+	assert.Error(t, err)
+
+	server, _ := geocoderServer(t, "/elsewhere", `[]`)
+	geocoder, err := New(appconf.GeocoderConfig{Provider: appconf.GeocoderNominatim, URL: server.URL}, nil)
+	require.NoError(t, err)
+	_, err = geocoder.Search(t.Context(), Request{Text: "pike place"})
+	assert.ErrorContains(t, err, "404")
+
+	places, err := geocoder.Search(t.Context(), Request{Text: "  "})
+	assert.NoError(t, err)
+	assert.Empty(t, places)
+}
@@ -1,6 +1,8 @@
 package gtfs
 
 import (
+	"time"
+
 	"maglev.onebusaway.org/internal/appconf"
 )
 
@@ -8,17 +10,139 @@ type Config struct {
 	GtfsURL                 string
 	StaticAuthHeaderKey     string
 	StaticAuthHeaderValue   string
+	StaticIDPrefix          string       // Prepended to the IDs of the static feed above
+	ExtraStaticFeeds        []StaticFeed // Imported with the feed above into one dataset
 	TripUpdatesURL          string
 	VehiclePositionsURL     string
 	ServiceAlertsURL        string
 	RealTimeAuthHeaderKey   string
 	RealTimeAuthHeaderValue string
+	RealTimeAgencyID        string         // The agency the GTFS-RT feeds above carry, if they carry one agency's
+	RealTimeIDPrefix        string         // The id-prefix of the static feed the GTFS-RT feeds above refer to
+	ExtraRealtimeFeeds      []RealtimeFeed // Polled alongside the feeds above and merged with them
 	GTFSDataPath            string
 	Env                     appconf.Environment
 	Verbose                 bool
 	EnableGTFSTidy          bool
+	StaticRefreshInterval   time.Duration // Between refreshes of a static feed fetched over HTTP(S); 0 means daily
+}
+
+// DefaultStaticRefreshInterval is how often a static feed is refreshed unless configured otherwise.
+const DefaultStaticRefreshInterval = 24 * time.Hour
+
+func (config Config) staticRefreshInterval() time.Duration {
+	if config.StaticRefreshInterval > 0 {
+		return config.StaticRefreshInterval
+	}
+	return DefaultStaticRefreshInterval
+}
+
+// StaticFeed is one static GTFS feed of a dataset merged from several, typically one per
+// agency of a region.
+type StaticFeed struct {
+	URL             string
+	AuthHeaderKey   string
+	AuthHeaderValue string
+	IDPrefix        string // Prepended to every ID in the feed but its agency IDs, keeping them apart from other feeds' IDs
+	EnableGTFSTidy  bool
+}
+
+// StaticFeedsFromJSON converts gtfs-static-feeds entries of a configuration file.
+func StaticFeedsFromJSON(feeds []appconf.GtfsStaticFeed) []StaticFeed {
+	if len(feeds) == 0 {
+		return nil
+	}
+	converted := make([]StaticFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		converted = append(converted, StaticFeed{
+			URL:             feed.URL,
+			AuthHeaderKey:   feed.AuthHeaderName,
+			AuthHeaderValue: feed.AuthHeaderValue,
+			IDPrefix:        feed.IDPrefix,
+			EnableGTFSTidy:  feed.EnableGTFSTidy,
+		})
+	}
+	return converted
+}
+
+// StaticFeeds returns every configured static feed, the feed in the flat fields first.
+func (config Config) StaticFeeds() []StaticFeed {
+	first := StaticFeed{
+		URL:             config.GtfsURL,
+		AuthHeaderKey:   config.StaticAuthHeaderKey,
+		AuthHeaderValue: config.StaticAuthHeaderValue,
+		IDPrefix:        config.StaticIDPrefix,
+		EnableGTFSTidy:  config.EnableGTFSTidy,
+	}
+	return append([]StaticFeed{first}, config.ExtraStaticFeeds...)
+}
+
+// mergesStaticFeeds reports whether the static feeds are merged before they are imported,
+// rather than the single feed imported as it is.
+func (config Config) mergesStaticFeeds() bool {
+	return len(config.ExtraStaticFeeds) > 0 || config.StaticIDPrefix != ""
+}
+
+// RealtimeFeed is one set of GTFS-RT feeds, typically those of one agency in a region with a
+// feed per agency.
+type RealtimeFeed struct {
+	AgencyID            string // The agency whose vehicles the feeds carry, if they carry one agency's
+	IDPrefix            string // Prepended to the trip, route and stop IDs of the feeds, as to those of their static feed
+	TripUpdatesURL      string
+	VehiclePositionsURL string
+	ServiceAlertsURL    string
+	AuthHeaderKey       string
+	AuthHeaderValue     string
+}
+
+// RealtimeFeedsFromJSON converts gtfs-rt-feeds entries of a configuration file.
+func RealtimeFeedsFromJSON(feeds []appconf.GtfsRtFeed) []RealtimeFeed {
+	if len(feeds) == 0 {
+		return nil
+	}
+	converted := make([]RealtimeFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		converted = append(converted, RealtimeFeed{
+			AgencyID:            feed.AgencyID,
+			IDPrefix:            feed.IDPrefix,
+			TripUpdatesURL:      feed.TripUpdatesURL,
+			VehiclePositionsURL: feed.VehiclePositionsURL,
+			ServiceAlertsURL:    feed.ServiceAlertsURL,
+			AuthHeaderKey:       feed.RealTimeAuthHeaderName,
+			AuthHeaderValue:     feed.RealTimeAuthHeaderValue,
+		})
+	}
+	return converted
+}
+
+// RealtimeFeeds returns every configured set of GTFS-RT feeds with at least one URL, the set
+// in the flat fields first.
+func (config Config) RealtimeFeeds() []RealtimeFeed {
+	first := RealtimeFeed{
+		AgencyID:            config.RealTimeAgencyID,
+		IDPrefix:            config.RealTimeIDPrefix,
+		TripUpdatesURL:      config.TripUpdatesURL,
+		VehiclePositionsURL: config.VehiclePositionsURL,
+		ServiceAlertsURL:    config.ServiceAlertsURL,
+		AuthHeaderKey:       config.RealTimeAuthHeaderKey,
+		AuthHeaderValue:     config.RealTimeAuthHeaderValue,
+	}
+	feeds := make([]RealtimeFeed, 0, 1+len(config.ExtraRealtimeFeeds))
+	for _, feed := range append([]RealtimeFeed{first}, config.ExtraRealtimeFeeds...) {
+		if feed.TripUpdatesURL != "" || feed.VehiclePositionsURL != "" || feed.ServiceAlertsURL != "" {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds
 }
 
+// realTimeDataEnabled reports whether any set of feeds has both trip updates and vehicle
+// positions, which realtime polling needs.
 func (config Config) realTimeDataEnabled() bool {
-	return config.TripUpdatesURL != "" && config.VehiclePositionsURL != ""
+	for _, feed := range config.RealtimeFeeds() {
+		if feed.TripUpdatesURL != "" && feed.VehiclePositionsURL != "" {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,370 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// staticMergeColumns are the files merged from every static feed and, for each, the columns
+// holding the IDs a feed's id-prefix is prepended to. Agency IDs are never prefixed: the API
+// already scopes every other ID by its agency, so they only have to differ between feeds.
+var staticMergeColumns = map[string][]string{
+	"agency.txt":          nil,
+	"stops.txt":           {"stop_id", "parent_station", "zone_id", "level_id"},
+	"routes.txt":          {"route_id"},
+	"trips.txt":           {"route_id", "service_id", "trip_id", "block_id", "shape_id"},
+	"stop_times.txt":      {"trip_id", "stop_id"},
+	"calendar.txt":        {"service_id"},
+	"calendar_dates.txt":  {"service_id"},
+	"shapes.txt":          {"shape_id"},
+	"frequencies.txt":     {"trip_id"},
+	"transfers.txt":       {"from_stop_id", "to_stop_id", "from_route_id", "to_route_id", "from_trip_id", "to_trip_id"},
+	"pathways.txt":        {"pathway_id", "from_stop_id", "to_stop_id"},
+	"levels.txt":          {"level_id"},
+	"fare_attributes.txt": {"fare_id"},
+	"fare_rules.txt":      {"fare_id", "route_id", "origin_id", "destination_id", "contains_id"},
+}
+
+// staticMergeKeys are the columns identifying the rows of a merged file, which two feeds must
+// not share.
+var staticMergeKeys = map[string]string{
+	"agency.txt":          "agency_id",
+	"stops.txt":           "stop_id",
+	"routes.txt":          "route_id",
+	"trips.txt":           "trip_id",
+	"calendar.txt":        "service_id",
+	"levels.txt":          "level_id",
+	"pathways.txt":        "pathway_id",
+	"fare_attributes.txt": "fare_id",
+}
+
+// staticAgencyColumns are the files whose agency_id a single-agency feed may leave blank or out.
+// Merged with other feeds the blank would be ambiguous, so it is filled with the feed's agency.
+var staticAgencyColumns = map[string]bool{
+	"routes.txt":          true,
+	"fare_attributes.txt": true,
+}
+
+// mergeStaticFeeds merges the zip files of static feeds into one, whose files hold the rows of
+// every feed, with the IDs of each feed prefixed by its IDPrefix. Files other than those in
+// staticMergeColumns, such as feed_info.txt, are taken from the first feed alone. It fails if
+// two feeds share an ID that identifies a row, such as a stop ID, since one feed's row would
+// replace the other's; an id-prefix on one of them keeps them apart.
+func mergeStaticFeeds(feeds []StaticFeed, data [][]byte) ([]byte, error) {
+	readers := make([]*zip.Reader, len(data))
+	for i, b := range data {
+		r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, fmt.Errorf("static feed %s is not a zip file: %w", feeds[i].URL, err)
+		}
+		readers[i] = r
+	}
+
+	var out bytes.Buffer
+	w := zip.NewWriter(&out)
+	for _, f := range readers[0].File {
+		if _, merged := staticMergeColumns[f.Name]; merged || f.FileInfo().IsDir() {
+			continue
+		}
+		if err := w.Copy(f); err != nil {
+			return nil, err
+		}
+	}
+
+	agencies := make([]string, len(readers))
+	for i, r := range readers {
+		agency, err := soleAgencyID(r)
+		if err != nil {
+			return nil, fmt.Errorf("static feed %s: %w", feeds[i].URL, err)
+		}
+		agencies[i] = agency
+	}
+
+	names := make([]string, 0, len(staticMergeColumns))
+	for name := range staticMergeColumns {
+		names = append(names, name)
+	}
+	// A stable order keeps the merged zip, and so the import's hash of it, the same for the
+	// same feeds
+	slices.Sort(names)
+	for _, name := range names {
+		if err := mergeStaticFile(w, name, feeds, readers, agencies); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// mergeStaticFile writes the file called name with the rows of every feed that has it, under a
+// header holding every feed's columns.
+func mergeStaticFile(w *zip.Writer, name string, feeds []StaticFeed, readers []*zip.Reader, agencies []string) error {
+	var header []string
+	present := false
+	feedColumns := make([][]string, len(readers))
+	for i, r := range readers {
+		columns, err := readStaticHeader(r, name)
+		if err != nil {
+			return fmt.Errorf("static feed %s: %s: %w", feeds[i].URL, name, err)
+		}
+		if columns == nil {
+			continue
+		}
+		present = true
+		feedColumns[i] = columns
+		for _, column := range columns {
+			if !slices.Contains(header, column) {
+				header = append(header, column)
+			}
+		}
+	}
+	if !present {
+		return nil
+	}
+	if staticAgencyColumns[name] && !slices.Contains(header, "agency_id") {
+		header = append(header, "agency_id")
+	}
+
+	fw, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(fw)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	keyColumn := slices.Index(header, staticMergeKeys[name])
+	agencyColumn := -1
+	if staticAgencyColumns[name] {
+		agencyColumn = slices.Index(header, "agency_id")
+	}
+	keyFeeds := map[string]int{}
+	row := make([]string, len(header))
+	for i, r := range readers {
+		// Where each of the feed's columns goes in the merged header, and what it holds
+		positions := make([]int, len(feedColumns[i]))
+		prefixed := make([]bool, len(feedColumns[i]))
+		for j, column := range feedColumns[i] {
+			positions[j] = slices.Index(header, column)
+			prefixed[j] = feeds[i].IDPrefix != "" && slices.Contains(staticMergeColumns[name], column)
+		}
+
+		err := eachStaticRow(r, name, func(record []string) error {
+			clear(row)
+			for j, value := range record {
+				if j >= len(positions) {
+					break
+				}
+				if value != "" && prefixed[j] {
+					value = feeds[i].IDPrefix + value
+				}
+				row[positions[j]] = value
+			}
+			if agencyColumn >= 0 && row[agencyColumn] == "" {
+				row[agencyColumn] = agencies[i]
+			}
+			if keyColumn >= 0 {
+				key := row[keyColumn]
+				if first, ok := keyFeeds[key]; ok && first != i {
+					return fmt.Errorf("%s %q is in static feeds %s and %s; give one of them an id-prefix",
+						header[keyColumn], key, feeds[first].URL, feeds[i].URL)
+				}
+				keyFeeds[key] = i
+			}
+			return cw.Write(row)
+		})
+		if err != nil {
+			return fmt.Errorf("static feed %s: %s: %w", feeds[i].URL, name, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// soleAgencyID returns the ID of the feed's agency, or "" when the feed has several. Every
+// agency of a merged feed needs an ID.
+func soleAgencyID(r *zip.Reader) (string, error) {
+	columns, err := readStaticHeader(r, "agency.txt")
+	if err != nil {
+		return "", err
+	}
+	column := slices.Index(columns, "agency_id")
+	var ids []string
+	err = eachStaticRow(r, "agency.txt", func(record []string) error {
+		if column < 0 || column >= len(record) || record[column] == "" {
+			return errors.New("agency.txt has an agency without an agency_id, which a merged feed needs")
+		}
+		ids = append(ids, record[column])
+		return nil
+	})
+	if err != nil || len(ids) != 1 {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// readStaticHeader returns the columns of a file of the feed, without the byte order mark and
+// spaces some feeds have around them, or nil if the feed has no such file.
+func readStaticHeader(r *zip.Reader, name string) ([]string, error) {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, nil
+	}
+	defer func() { _ = f.Close() }()
+
+	columns, err := newStaticCSVReader(f).Read()
+	if errors.Is(err, io.EOF) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	trimmed := make([]string, len(columns))
+	for i, column := range columns {
+		trimmed[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+	}
+	return trimmed, nil
+}
+
+// eachStaticRow calls fn with each row of a file of the feed, after its header. An absent
+// file has no rows. The record passed to fn is reused for the next row.
+func eachStaticRow(r *zip.Reader, name string, fn func(record []string) error) error {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	cr := newStaticCSVReader(f)
+	if _, err := cr.Read(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+func newStaticCSVReader(r io.Reader) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+	return cr
+}
+
+// prefixRealtimeIDs prepends prefix to the trip, route and stop IDs in GTFS-RT data, to match
+// the IDs of a static feed imported with the same id-prefix.
+func prefixRealtimeIDs(data *gtfs.Realtime, prefix string) {
+	if data == nil || prefix == "" {
+		return
+	}
+	// A trip update and the vehicle serving it share their trip
+	prefixed := map[*gtfs.Trip]bool{}
+	prefixTrip := func(trip *gtfs.Trip) {
+		if trip == nil || prefixed[trip] {
+			return
+		}
+		prefixed[trip] = true
+		trip.ID.ID = prefixNonEmpty(prefix, trip.ID.ID)
+		trip.ID.RouteID = prefixNonEmpty(prefix, trip.ID.RouteID)
+		for i := range trip.StopTimeUpdates {
+			trip.StopTimeUpdates[i].StopID = prefixIDPointer(prefix, trip.StopTimeUpdates[i].StopID)
+		}
+	}
+	for i := range data.Trips {
+		prefixTrip(&data.Trips[i])
+	}
+	for i := range data.Vehicles {
+		prefixTrip(data.Vehicles[i].Trip)
+		data.Vehicles[i].StopID = prefixIDPointer(prefix, data.Vehicles[i].StopID)
+	}
+	for i := range data.Alerts {
+		for j := range data.Alerts[i].InformedEntities {
+			entity := &data.Alerts[i].InformedEntities[j]
+			entity.RouteID = prefixIDPointer(prefix, entity.RouteID)
+			entity.StopID = prefixIDPointer(prefix, entity.StopID)
+			if entity.TripID != nil {
+				tripID := *entity.TripID
+				tripID.ID = prefixNonEmpty(prefix, tripID.ID)
+				tripID.RouteID = prefixNonEmpty(prefix, tripID.RouteID)
+				entity.TripID = &tripID
+			}
+		}
+	}
+}
+
+// prefixTripModificationIDs returns trip modifications keyed by, and holding, trip and stop IDs
+// with prefix prepended. The stop modifications of a feed entity are shared by the trips it
+// selects, so they are copied rather than changed in place.
+func prefixTripModificationIDs(modifications map[string][]TripModification, prefix string) map[string][]TripModification {
+	if prefix == "" || modifications == nil {
+		return modifications
+	}
+	prefixed := make(map[string][]TripModification, len(modifications))
+	for tripID, tripModifications := range modifications {
+		converted := make([]TripModification, len(tripModifications))
+		for i, modification := range tripModifications {
+			modification.TripID = prefixNonEmpty(prefix, modification.TripID)
+			stopModifications := make([]StopModification, len(modification.Modifications))
+			for j, stopModification := range modification.Modifications {
+				stopModification.Start.StopID = prefixNonEmpty(prefix, stopModification.Start.StopID)
+				if stopModification.End != nil {
+					end := *stopModification.End
+					end.StopID = prefixNonEmpty(prefix, end.StopID)
+					stopModification.End = &end
+				}
+				replacements := make([]ReplacementStop, len(stopModification.ReplacementStops))
+				for k, stop := range stopModification.ReplacementStops {
+					stop.StopID = prefixNonEmpty(prefix, stop.StopID)
+					replacements[k] = stop
+				}
+				stopModification.ReplacementStops = replacements
+				stopModifications[j] = stopModification
+			}
+			modification.Modifications = stopModifications
+			converted[i] = modification
+		}
+		prefixed[prefix+tripID] = converted
+	}
+	return prefixed
+}
+
+func prefixNonEmpty(prefix, id string) string {
+	if id == "" {
+		return ""
+	}
+	return prefix + id
+}
+
+// prefixIDPointer returns a prefixed copy of id, leaving the original, which other entities
+// may share, unchanged.
+func prefixIDPointer(prefix string, id *string) *string {
+	if id == nil || *id == "" {
+		return id
+	}
+	prefixed := prefix + *id
+	return &prefixed
+}
@@ -0,0 +1,170 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// zipFeed builds a feed's zip file from the contents of its files.
+func zipFeed(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, contents := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestMergeStaticFeeds(t *testing.T) {
+	raba, err := os.ReadFile(models.GetFixturePath(t, "raba.zip"))
+	require.NoError(t, err)
+	soundTransit, err := os.ReadFile(models.GetFixturePath(t, "gtfs.zip"))
+	require.NoError(t, err)
+
+	feeds := []StaticFeed{{URL: "raba.zip"}, {URL: "gtfs.zip", IDPrefix: "st-"}}
+	merged, err := mergeStaticFeeds(feeds, [][]byte{raba, soundTransit})
+	require.NoError(t, err)
+	staticData, _, err := gtfsdb.ParseStaticFeed(merged)
+	require.NoError(t, err)
+
+	agencies := map[string]bool{}
+	for _, agency := range staticData.Agencies {
+		agencies[agency.Id] = true
+	}
+	assert.Equal(t, map[string]bool{"25": true, "40": true}, agencies, "agency IDs are not prefixed")
+
+	stops := map[string]gtfs.Stop{}
+	for _, stop := range staticData.Stops {
+		stops[stop.Id] = stop
+	}
+	assert.Contains(t, stops, "1001", "the feed without a prefix keeps its IDs")
+	require.Contains(t, stops, "st-1108")
+	require.NotNil(t, stops["st-1108"].Parent)
+	assert.Equal(t, "st-C03", stops["st-1108"].Parent.Id)
+
+	var rabaTrips, soundTransitTrips int
+	for _, trip := range staticData.Trips {
+		switch trip.Route.Agency.Id {
+		case "25":
+			rabaTrips++
+		case "40":
+			soundTransitTrips++
+			assert.True(t, strings.HasPrefix(trip.ID, "st-"), trip.ID)
+			assert.True(t, strings.HasPrefix(trip.Route.Id, "st-"), trip.Route.Id)
+			assert.True(t, strings.HasPrefix(trip.Service.Id, "st-"), trip.Service.Id)
+		}
+	}
+	assert.NotZero(t, rabaTrips)
+	assert.NotZero(t, soundTransitTrips)
+}
+
+func TestMergeStaticFeeds_Collisions(t *testing.T) {
+	feedA := zipFeed(t, map[string]string{
+		"agency.txt": "agency_id,agency_name,agency_url,agency_timezone\nA,Agency A,https://a.example.com,America/Los_Angeles\n",
+		"stops.txt":  "stop_id,stop_name,stop_lat,stop_lon\n1,First,47.6,-122.3\n",
+	})
+	// A single-agency feed may leave out its agency_id on routes, and some start with a byte
+	// order mark
+	feedB := zipFeed(t, map[string]string{
+		"agency.txt": "\ufeffagency_id,agency_name,agency_url,agency_timezone\nB,Agency B,https://b.example.com,America/Los_Angeles\n",
+		"stops.txt":  "stop_id,stop_name,stop_lat,stop_lon,stop_code\n1,Other first,47.7,-122.4,100\n",
+		"routes.txt": "route_id,route_short_name,route_type\nR,R Line,3\n",
+	})
+
+	_, err := mergeStaticFeeds([]StaticFeed{{URL: "a.zip"}, {URL: "b.zip"}}, [][]byte{feedA, feedB})
+	assert.ErrorContains(t, err, `stop_id "1" is in static feeds a.zip and b.zip; give one of them an id-prefix`)
+
+	merged, err := mergeStaticFeeds([]StaticFeed{{URL: "a.zip"}, {URL: "b.zip", IDPrefix: "b-"}}, [][]byte{feedA, feedB})
+	require.NoError(t, err)
+	r, err := zip.NewReader(bytes.NewReader(merged), int64(len(merged)))
+	require.NoError(t, err)
+
+	header, err := readStaticHeader(r, "stops.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stop_id", "stop_name", "stop_lat", "stop_lon", "stop_code"}, header)
+	var stops [][]string
+	require.NoError(t, eachStaticRow(r, "stops.txt", func(record []string) error {
+		stops = append(stops, append([]string(nil), record...))
+		return nil
+	}))
+	assert.Equal(t, [][]string{
+		{"1", "First", "47.6", "-122.3", ""},
+		{"b-1", "Other first", "47.7", "-122.4", "100"},
+	}, stops)
+
+	header, err = readStaticHeader(r, "routes.txt")
+	require.NoError(t, err)
+	require.NoError(t, eachStaticRow(r, "routes.txt", func(record []string) error {
+		assert.Equal(t, "b-R", record[0])
+		assert.Equal(t, "B", record[len(header)-1], "the blank agency_id is the feed's agency")
+		return nil
+	}))
+
+	// Agency IDs are never prefixed, so they must differ
+	_, err = mergeStaticFeeds([]StaticFeed{{URL: "a.zip"}, {URL: "a2.zip", IDPrefix: "a2-"}}, [][]byte{feedA, feedA})
+	assert.ErrorContains(t, err, `agency_id "A"`)
+}
+
+func TestPrefixRealtimeIDs(t *testing.T) {
+	body, err := os.ReadFile(models.GetFixturePath(t, "raba-trip-updates.pb"))
+	require.NoError(t, err)
+	data, err := gtfs.ParseRealtime(body, &gtfs.ParseRealtimeOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, data.Trips)
+	unprefixed := data.Trips[0].ID.ID
+
+	prefixRealtimeIDs(data, "p-")
+	assert.Equal(t, "p-"+unprefixed, data.Trips[0].ID.ID)
+	for _, trip := range data.Trips {
+		assert.True(t, strings.HasPrefix(trip.ID.ID, "p-"), trip.ID.ID)
+		assert.False(t, strings.HasPrefix(trip.ID.ID, "p-p-"), "a trip shared with a vehicle is prefixed once")
+		for _, update := range trip.StopTimeUpdates {
+			if update.StopID != nil {
+				assert.True(t, strings.HasPrefix(*update.StopID, "p-"), *update.StopID)
+			}
+		}
+	}
+	for _, vehicle := range data.Vehicles {
+		if vehicle.Trip != nil {
+			assert.False(t, strings.HasPrefix(vehicle.Trip.ID.ID, "p-p-"))
+		}
+	}
+}
+
+func TestInitGTFSManager_MergesStaticFeeds(t *testing.T) {
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:          models.GetFixturePath(t, "raba.zip"),
+		ExtraStaticFeeds: []StaticFeed{{URL: models.GetFixturePath(t, "gtfs.zip"), IDPrefix: "st-"}},
+		Env:              appconf.Test,
+		GTFSDataPath:     ":memory:",
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	assert.Len(t, manager.GetAgencies(), 2)
+	assert.NotNil(t, manager.FindAgency("40"))
+
+	ctx := context.Background()
+	_, err = manager.GtfsDB.Queries.GetStop(ctx, "st-1108")
+	assert.NoError(t, err, "the prefixed feed is in the database")
+	_, err = manager.GtfsDB.Queries.GetStop(ctx, "1001")
+	assert.NoError(t, err)
+	_, err = manager.GtfsDB.Queries.GetAgency(ctx, "40")
+	assert.NoError(t, err)
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"log/slog"
 	"sort"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"maglev.onebusaway.org/gtfsdb"
@@ -38,29 +38,47 @@ type Manager struct {
 	realTimeVehicles               []gtfs.Vehicle
 	realTimeMutex                  sync.RWMutex
 	realTimeAlerts                 []gtfs.Alert
+	realTimeTripModifications      map[string][]TripModification // By trip ID
+	realTimeShapes                 map[string][][]float64        // Shapes the trip modifications refer to, by shape ID
+	lastRealtimeUpdate             time.Time                     // Last successful GTFS-RT refresh, protected by realTimeMutex
 	realTimeTripLookup             map[string]int
 	realTimeVehicleLookupByTrip    map[string]int
 	realTimeVehicleLookupByVehicle map[string]int
+	realTimeVehicleLookupByAgency  map[agencyVehicleKey]int
+	realTimeVehicleAgencies        []string           // The agency of the feed each of realTimeVehicles came from, "" if it names none
+	realtimeFeedData               []realtimeFeedData // What each set of GTFS-RT feeds last loaded; protected by realTimeMutex
 	agenciesMap                    map[string]*gtfs.Agency
 	routesMap                      map[string]*gtfs.Route
-	staticUpdateMutex              sync.Mutex   // Protects against concurrent ForceUpdate calls
-	staticMutex                    sync.RWMutex // Protects gtfsData and lastUpdated
+	agencyIDSplitter               atomic.Pointer[utils.AgencyIDSplitter] // For the static data being served; read without the lock
+	staticUpdateMutex              sync.Mutex                             // Protects against concurrent ForceUpdate calls
+	staticMutex                    sync.RWMutex                           // Protects gtfsData and lastUpdated
 	config                         Config
+	realtimeConfigMutex            sync.RWMutex // Protects the GTFS-RT feed fields of config
 	shutdownChan                   chan struct{}
 	wg                             sync.WaitGroup
 	shutdownOnce                   sync.Once
 	stopSpatialIndex               *rtree.RTree
 	blockLayoverIndices            map[string][]*BlockLayoverIndex
 	regionBounds                   *RegionBounds
+	stations                       *Stations                // Pathways and levels, which gtfsData leaves out
+	degradations                   []gtfsdb.FeedDegradation // What the static feed lacks, from its last load
+	staticHash                     string                   // Of the static data being served; protected by staticUpdateMutex
 	isHealthy                      bool
+	staticUpdateHook               func()                           // Run after each hot swap; protected by staticMutex
+	realtimeUpdateHooks            []func(RealtimeUpdate)           // Run after each GTFS-RT refresh; protected by realTimeMutex
+	realtimeSubscribers            map[chan RealtimeUpdate]struct{} // Sent each GTFS-RT refresh; protected by realTimeMutex
 }
 
 // InitGTFSManager initializes the Manager with the GTFS data from the given source
 // The source can be either a URL or a local file path
 func InitGTFSManager(config Config) (*Manager, error) {
-	isLocalFile := !strings.HasPrefix(config.GtfsURL, "http://") && !strings.HasPrefix(config.GtfsURL, "https://")
+	isLocalFile := isLocalSource(config.GtfsURL)
 
-	staticData, err := loadGTFSData(config.GtfsURL, isLocalFile, config)
+	b, err := staticGtfsData(config, isLocalFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading GTFS data: %w", err)
+	}
+	staticData, stations, degradations, err := parseGTFSData(b)
 	if err != nil {
 		return nil, err
 	}
@@ -72,10 +90,11 @@ func InitGTFSManager(config Config) (*Manager, error) {
 		realTimeTripLookup:             make(map[string]int),
 		realTimeVehicleLookupByTrip:    make(map[string]int),
 		realTimeVehicleLookupByVehicle: make(map[string]int),
+		staticHash:                     staticDataHash(b),
 	}
-	manager.setStaticGTFS(staticData)
+	manager.setStaticGTFS(staticData, stations, degradations)
 
-	gtfsDB, err := buildGtfsDB(config, isLocalFile, "")
+	gtfsDB, err := buildGtfsDB(config, b, "")
 	if err != nil {
 		return nil, fmt.Errorf("error building GTFS database: %w", err)
 	}
@@ -97,9 +116,10 @@ func InitGTFSManager(config Config) (*Manager, error) {
 	if config.realTimeDataEnabled() {
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel() // Ensure the context is canceled when done
-		manager.updateGTFSRealtime(ctx, config)
+		// A failed initial fetch is logged and retried periodically
+		_ = manager.updateGTFSRealtime(ctx, config)
 		manager.wg.Add(1)
-		go manager.updateGTFSRealtimePeriodically(config)
+		go manager.updateGTFSRealtimePeriodically()
 	}
 
 	return manager, nil
@@ -111,7 +131,7 @@ func (manager *Manager) SetGtfsURL(url string) {
 	manager.staticUpdateMutex.Lock()
 	defer manager.staticUpdateMutex.Unlock()
 	manager.config.GtfsURL = url
-	manager.isLocalFile = !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://")
+	manager.isLocalFile = isLocalSource(url)
 }
 
 // Shutdown gracefully shuts down the manager and its background goroutines
@@ -171,6 +191,12 @@ func (manager *Manager) FindAgency(id string) *gtfs.Agency {
 	return nil
 }
 
+// ExtractAgencyIDAndCodeID splits a combined ID against the agencies of the feed being served,
+// so IDs formed before agency IDs were escaped split correctly too. The lock is not needed.
+func (manager *Manager) ExtractAgencyIDAndCodeID(combinedID string) (string, string, error) {
+	return manager.agencyIDSplitter.Load().ExtractAgencyIDAndCodeID(combinedID)
+}
+
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
 func (manager *Manager) FindRoute(id string) *gtfs.Route {
 	if route, ok := manager.routesMap[id]; ok {
@@ -346,6 +372,8 @@ func (manager *Manager) GetStopsForLocation(
 }
 
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
+// VehiclesForAgencyID returns the vehicles on the agency's routes, and the vehicles without a
+// route from a GTFS-RT feed configured for the agency.
 func (manager *Manager) VehiclesForAgencyID(agencyID string) []gtfs.Vehicle {
 	routes := manager.RoutesForAgencyID(agencyID)
 	routeIDs := make(map[string]bool) // all route IDs for the agency.
@@ -353,12 +381,15 @@ func (manager *Manager) VehiclesForAgencyID(agencyID string) []gtfs.Vehicle {
 		routeIDs[route.Id] = true
 	}
 
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+
 	var vehicles []gtfs.Vehicle
-	for _, v := range manager.GetRealTimeVehicles() {
-		if v.Trip != nil {
-			if routeIDs[v.Trip.ID.RouteID] {
-				vehicles = append(vehicles, v)
-			}
+	for i, v := range manager.realTimeVehicles {
+		if v.Trip != nil && routeIDs[v.Trip.ID.RouteID] {
+			vehicles = append(vehicles, v)
+		} else if (v.Trip == nil || v.Trip.ID.RouteID == "") && manager.vehicleFeedAgency(i) == agencyID {
+			vehicles = append(vehicles, v)
 		}
 	}
 
@@ -417,6 +448,23 @@ func (manager *Manager) GetVehicleForTrip(tripID string) *gtfs.Vehicle {
 	return nil
 }
 
+// GetVehicleForAgency returns the vehicle with the ID from the GTFS-RT feed configured for the
+// agency, or failing that, from any feed.
+func (manager *Manager) GetVehicleForAgency(agencyID, vehicleID string) (*gtfs.Vehicle, error) {
+	manager.realTimeMutex.RLock()
+	index, exists := manager.realTimeVehicleLookupByAgency[agencyVehicleKey{agencyID, vehicleID}]
+	var vehicle gtfs.Vehicle
+	if exists {
+		vehicle = manager.realTimeVehicles[index]
+	}
+	manager.realTimeMutex.RUnlock()
+
+	if exists {
+		return &vehicle, nil
+	}
+	return manager.GetVehicleByID(vehicleID)
+}
+
 func (manager *Manager) GetVehicleByID(vehicleID string) (*gtfs.Vehicle, error) {
 
 	manager.realTimeMutex.RLock()
@@ -522,6 +570,34 @@ func (manager *Manager) IsServiceActiveOnDate(ctx context.Context, serviceID str
 	}
 }
 
+// ServiceDateRange returns the first and last days the static dataset has service on, from
+// calendar.txt and the dates calendar_dates.txt adds. Both are zero if there is no service.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) ServiceDateRange() (first, last time.Time) {
+	if manager.gtfsData == nil {
+		return time.Time{}, time.Time{}
+	}
+	include := func(date time.Time) {
+		if date.IsZero() {
+			return
+		}
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if last.IsZero() || date.After(last) {
+			last = date
+		}
+	}
+	for _, service := range manager.gtfsData.Services {
+		include(service.StartDate)
+		include(service.EndDate)
+		for _, date := range service.AddedDates {
+			include(date)
+		}
+	}
+	return first, last
+}
+
 // IsHealthy returns true if the GTFS data is loaded and valid.
 func (manager *Manager) IsHealthy() bool {
 	manager.staticMutex.RLock()
@@ -529,6 +605,111 @@ func (manager *Manager) IsHealthy() bool {
 	return manager.isHealthy
 }
 
+// RealtimeEnabled reports whether GTFS-RT feeds are configured.
+func (manager *Manager) RealtimeEnabled() bool {
+	return manager.realtimeConfig().realTimeDataEnabled()
+}
+
+// LastRealtimeUpdate returns when GTFS-RT data was last refreshed successfully.
+// Returns the zero time if no refresh has succeeded yet.
+func (manager *Manager) LastRealtimeUpdate() time.Time {
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+	return manager.lastRealtimeUpdate
+}
+
+// RealtimeFeedStatus is when one GTFS-RT feed last loaded successfully.
+type RealtimeFeedStatus struct {
+	AgencyID    string    // Agency of the set of feeds, if configured
+	Feed        string    // "tripUpdates", "vehiclePositions" or "serviceAlerts"
+	LastFetched time.Time // Zero until the feed has loaded since it was configured
+}
+
+// RealtimeFeedStatuses reports every configured GTFS-RT feed, in configuration order.
+func (manager *Manager) RealtimeFeedStatuses() []RealtimeFeedStatus {
+	feeds := manager.realtimeConfig().RealtimeFeeds()
+
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+
+	var statuses []RealtimeFeedStatus
+	for i, feed := range feeds {
+		// The feeds may have changed since the last refresh; their data is kept by position
+		var data realtimeFeedData
+		if len(manager.realtimeFeedData) == len(feeds) {
+			data = manager.realtimeFeedData[i]
+		}
+		if feed.TripUpdatesURL != "" {
+			statuses = append(statuses, RealtimeFeedStatus{AgencyID: feed.AgencyID, Feed: "tripUpdates", LastFetched: data.tripsFetched})
+		}
+		if feed.VehiclePositionsURL != "" {
+			statuses = append(statuses, RealtimeFeedStatus{AgencyID: feed.AgencyID, Feed: "vehiclePositions", LastFetched: data.vehiclesFetched})
+		}
+		if feed.ServiceAlertsURL != "" {
+			statuses = append(statuses, RealtimeFeedStatus{AgencyID: feed.AgencyID, Feed: "serviceAlerts", LastFetched: data.alertsFetched})
+		}
+	}
+	return statuses
+}
+
+// LastStaticUpdate returns when the static GTFS dataset was last loaded or swapped in.
+// Callers caching data derived from the static feed can compare it to detect a new dataset.
+func (manager *Manager) LastStaticUpdate() time.Time {
+	manager.staticMutex.RLock()
+	defer manager.staticMutex.RUnlock()
+	return manager.lastUpdated
+}
+
+// Status summarizes the loaded static dataset and realtime feeds for operators.
+type Status struct {
+	StaticUpdated    time.Time
+	StaticUpdating   bool // A static refresh is currently downloading or swapping in a new dataset
+	Healthy          bool
+	Agencies         int
+	Routes           int
+	Stops            int
+	Trips            int
+	Degradations     []gtfsdb.FeedDegradation // Features the static feed serves only in part
+	RealtimeEnabled  bool
+	RealtimeUpdated  time.Time
+	RealtimeTrips    int
+	RealtimeVehicles int
+	RealtimeAlerts   int
+}
+
+// Status returns a snapshot of the dataset and feed state.
+func (manager *Manager) Status() Status {
+	status := Status{RealtimeEnabled: manager.RealtimeEnabled()}
+
+	// ForceUpdate holds the update lock for the whole refresh
+	if manager.staticUpdateMutex.TryLock() {
+		manager.staticUpdateMutex.Unlock()
+	} else {
+		status.StaticUpdating = true
+	}
+
+	manager.staticMutex.RLock()
+	status.StaticUpdated = manager.lastUpdated
+	status.Healthy = manager.isHealthy
+	if manager.gtfsData != nil {
+		status.Agencies = len(manager.gtfsData.Agencies)
+		status.Routes = len(manager.gtfsData.Routes)
+		status.Stops = len(manager.gtfsData.Stops)
+		status.Trips = len(manager.gtfsData.Trips)
+	}
+	status.Degradations = manager.degradations
+	manager.staticMutex.RUnlock()
+
+	manager.realTimeMutex.RLock()
+	status.RealtimeUpdated = manager.lastRealtimeUpdate
+	status.RealtimeTrips = len(manager.realTimeTrips)
+	status.RealtimeVehicles = len(manager.realTimeVehicles)
+	status.RealtimeAlerts = len(manager.realTimeAlerts)
+	manager.realTimeMutex.RUnlock()
+
+	return status
+}
+
 // MarkHealthy sets the manager status to healthy.
 func (manager *Manager) MarkHealthy() {
 	manager.staticMutex.Lock()
@@ -58,3 +58,63 @@ func (m *Manager) MockAddTrip(tripID, agencyID, routeID string) {
 		Route: &gtfs.Route{Id: routeID},
 	})
 }
+
+// MockRemoveVehicle removes a vehicle added with MockAddVehicle, so tests sharing a manager do
+// not see each other's vehicles.
+func (m *Manager) MockRemoveVehicle(vehicleID string) {
+	for i, v := range m.realTimeVehicles {
+		if v.ID.ID == vehicleID {
+			m.realTimeVehicles = append(m.realTimeVehicles[:i], m.realTimeVehicles[i+1:]...)
+			break
+		}
+	}
+
+	m.realTimeVehicleLookupByVehicle = make(map[string]int, len(m.realTimeVehicles))
+	for i, v := range m.realTimeVehicles {
+		m.realTimeVehicleLookupByVehicle[v.ID.ID] = i
+	}
+}
+
+func (m *Manager) MockAddAlert(alert gtfs.Alert) {
+	m.realTimeMutex.Lock()
+	defer m.realTimeMutex.Unlock()
+	m.realTimeAlerts = append(m.realTimeAlerts, alert)
+}
+
+// MockRemoveAlert removes an alert added with MockAddAlert.
+func (m *Manager) MockRemoveAlert(alertID string) {
+	m.realTimeMutex.Lock()
+	defer m.realTimeMutex.Unlock()
+	for i, alert := range m.realTimeAlerts {
+		if alert.ID == alertID {
+			m.realTimeAlerts = append(m.realTimeAlerts[:i:i], m.realTimeAlerts[i+1:]...)
+			return
+		}
+	}
+}
+
+// MockLoadStations replaces the manager's stations with those of the static feed in data.
+func (m *Manager) MockLoadStations(data []byte) error {
+	stations, err := parseStations(data)
+	if err != nil {
+		return err
+	}
+	m.staticMutex.Lock()
+	defer m.staticMutex.Unlock()
+	m.stations = stations
+	return nil
+}
+
+// MockLoadTripModifications replaces the trip modifications and realtime shapes with those of the
+// GTFS-RT feed in body.
+func (m *Manager) MockLoadTripModifications(body []byte) error {
+	modifications, shapes, err := parseTripModifications(body)
+	if err != nil {
+		return err
+	}
+	m.realTimeMutex.Lock()
+	defer m.realTimeMutex.Unlock()
+	m.realTimeTripModifications = modifications
+	m.realTimeShapes = shapes
+	return nil
+}
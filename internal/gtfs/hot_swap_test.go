@@ -12,6 +12,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 )
@@ -308,3 +309,67 @@ func TestHotSwap_ConcurrentForceUpdate(t *testing.T) {
 		t.Error("Agencies should not be empty after update")
 	}
 }
+
+func TestHotSwap_StaticUpdateHookAndSnapshot(t *testing.T) {
+	tempDir := t.TempDir()
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      models.GetFixturePath(t, "raba.zip"),
+		GTFSDataPath: tempDir + "/gtfs.db",
+		Env:          appconf.Development,
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	var agencies []string
+	manager.SetStaticUpdateHook(func() {
+		// Readers are unblocked by the time the hook runs
+		manager.RLock()
+		defer manager.RUnlock()
+		agencies = append(agencies, manager.gtfsData.Agencies[0].Id)
+	})
+	manager.SetGtfsURL(models.GetFixturePath(t, "gtfs.zip"))
+	require.NoError(t, manager.ForceUpdate(context.Background()))
+	assert.Equal(t, []string{"40"}, agencies)
+
+	snapshotPath := tempDir + "/snapshot.db"
+	require.NoError(t, manager.WriteSnapshot(context.Background(), snapshotPath))
+	snapshot, err := gtfsdb.NewClient(gtfsdb.NewConfig(snapshotPath, appconf.Development, false))
+	require.NoError(t, err)
+	defer func() { _ = snapshot.Close() }()
+	copied, err := snapshot.Queries.ListAgencies(context.Background())
+	require.NoError(t, err)
+	require.Len(t, copied, 1)
+	assert.Equal(t, "40", copied[0].ID)
+
+	assert.Error(t, manager.WriteSnapshot(context.Background(), snapshotPath), "the target must not exist")
+}
+
+func TestHotSwap_ScheduledRefreshSkipsUnchangedData(t *testing.T) {
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      models.GetFixturePath(t, "raba.zip"),
+		GTFSDataPath: t.TempDir() + "/gtfs.db",
+		Env:          appconf.Development,
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	var agencies []string
+	manager.SetStaticUpdateHook(func() {
+		manager.RLock()
+		defer manager.RUnlock()
+		agencies = append(agencies, manager.gtfsData.Agencies[0].Id)
+	})
+	ctx := context.Background()
+
+	require.NoError(t, manager.updateStatic(ctx, false))
+	assert.Empty(t, agencies, "the data being served is not imported again")
+
+	manager.SetGtfsURL(models.GetFixturePath(t, "gtfs.zip"))
+	require.NoError(t, manager.updateStatic(ctx, false))
+	assert.Equal(t, []string{"40"}, agencies)
+
+	require.NoError(t, manager.ForceUpdate(ctx))
+	assert.Equal(t, []string{"40", "40"}, agencies, "ForceUpdate imports unchanged data")
+
+	assert.Equal(t, DefaultStaticRefreshInterval, manager.config.staticRefreshInterval())
+}
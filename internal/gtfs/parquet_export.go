@@ -0,0 +1,216 @@
+package gtfs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"maglev.onebusaway.org/internal/parquet"
+)
+
+// ParquetTables are the tables ExportParquet writes, in export order.
+var ParquetTables = []string{"stops", "trips", "stop_times", "vehicle_positions"}
+
+// stopTimesExportBatch is how many trips' stop times are read from the database at a time.
+const stopTimesExportBatch = 500
+
+// ExportParquet writes table as a Parquet file to w. The static tables keep the feed's own IDs
+// and columns; stop times are seconds since midnight, as in stop_times.txt. vehicle_positions
+// is the latest GTFS-RT vehicle positions snapshot. While serving, call it with the manager
+// read-locked.
+func (manager *Manager) ExportParquet(ctx context.Context, table string, w io.Writer) error {
+	switch table {
+	case "stops":
+		return manager.exportStops(ctx, w)
+	case "trips":
+		return manager.exportTrips(ctx, w)
+	case "stop_times":
+		return manager.exportStopTimes(ctx, w)
+	case "vehicle_positions":
+		return manager.exportVehiclePositions(w)
+	default:
+		return fmt.Errorf("unknown table %q", table)
+	}
+}
+
+func (manager *Manager) exportStops(ctx context.Context, w io.Writer) error {
+	stops, err := manager.GtfsDB.Queries.ListStops(ctx)
+	if err != nil {
+		return err
+	}
+	pw, err := parquet.NewWriter(w, []parquet.Column{
+		{Name: "stop_id", Type: parquet.String},
+		{Name: "stop_code", Type: parquet.String, Optional: true},
+		{Name: "stop_name", Type: parquet.String, Optional: true},
+		{Name: "stop_desc", Type: parquet.String, Optional: true},
+		{Name: "stop_lat", Type: parquet.Double},
+		{Name: "stop_lon", Type: parquet.Double},
+		{Name: "zone_id", Type: parquet.String, Optional: true},
+		{Name: "location_type", Type: parquet.Int64, Optional: true},
+		{Name: "parent_station", Type: parquet.String, Optional: true},
+		{Name: "wheelchair_boarding", Type: parquet.Int64, Optional: true},
+		{Name: "platform_code", Type: parquet.String, Optional: true},
+	})
+	if err != nil {
+		return err
+	}
+	for _, stop := range stops {
+		err := pw.Write(stop.ID, nullString(stop.Code), nullString(stop.Name), nullString(stop.Desc),
+			stop.Lat, stop.Lon, nullString(stop.ZoneID), nullInt(stop.LocationType),
+			nullString(stop.ParentStation), nullInt(stop.WheelchairBoarding), nullString(stop.PlatformCode))
+		if err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+func (manager *Manager) exportTrips(ctx context.Context, w io.Writer) error {
+	trips, err := manager.GtfsDB.Queries.ListTrips(ctx)
+	if err != nil {
+		return err
+	}
+	pw, err := parquet.NewWriter(w, []parquet.Column{
+		{Name: "trip_id", Type: parquet.String},
+		{Name: "route_id", Type: parquet.String},
+		{Name: "service_id", Type: parquet.String},
+		{Name: "trip_headsign", Type: parquet.String, Optional: true},
+		{Name: "trip_short_name", Type: parquet.String, Optional: true},
+		{Name: "direction_id", Type: parquet.Int64, Optional: true},
+		{Name: "block_id", Type: parquet.String, Optional: true},
+		{Name: "shape_id", Type: parquet.String, Optional: true},
+		{Name: "wheelchair_accessible", Type: parquet.Int64, Optional: true},
+		{Name: "bikes_allowed", Type: parquet.Int64, Optional: true},
+	})
+	if err != nil {
+		return err
+	}
+	for _, trip := range trips {
+		err := pw.Write(trip.ID, trip.RouteID, trip.ServiceID, nullString(trip.TripHeadsign),
+			nullString(trip.TripShortName), nullInt(trip.DirectionID), nullString(trip.BlockID),
+			nullString(trip.ShapeID), nullInt(trip.WheelchairAccessible), nullInt(trip.BikesAllowed))
+		if err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// exportStopTimes reads stop times a batch of trips at a time, so a large feed is never held
+// in memory at once.
+func (manager *Manager) exportStopTimes(ctx context.Context, w io.Writer) error {
+	trips, err := manager.GtfsDB.Queries.ListTrips(ctx)
+	if err != nil {
+		return err
+	}
+	pw, err := parquet.NewWriter(w, []parquet.Column{
+		{Name: "trip_id", Type: parquet.String},
+		{Name: "arrival_time", Type: parquet.Int64},
+		{Name: "departure_time", Type: parquet.Int64},
+		{Name: "stop_id", Type: parquet.String},
+		{Name: "stop_sequence", Type: parquet.Int64},
+		{Name: "stop_headsign", Type: parquet.String, Optional: true},
+		{Name: "pickup_type", Type: parquet.Int64, Optional: true},
+		{Name: "drop_off_type", Type: parquet.Int64, Optional: true},
+		{Name: "shape_dist_traveled", Type: parquet.Double, Optional: true},
+		{Name: "timepoint", Type: parquet.Int64, Optional: true},
+	})
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(trips); start += stopTimesExportBatch {
+		end := start + stopTimesExportBatch
+		if end > len(trips) {
+			end = len(trips)
+		}
+		tripIDs := make([]string, 0, end-start)
+		for _, trip := range trips[start:end] {
+			tripIDs = append(tripIDs, trip.ID)
+		}
+		stopTimes, err := manager.GtfsDB.Queries.GetStopTimesForTripIDs(ctx, tripIDs)
+		if err != nil {
+			return err
+		}
+		for _, st := range stopTimes {
+			var distance any
+			if st.ShapeDistTraveled.Valid {
+				distance = st.ShapeDistTraveled.Float64
+			}
+			// Stored as nanoseconds since midnight
+			err := pw.Write(st.TripID, st.ArrivalTime/int64(time.Second), st.DepartureTime/int64(time.Second), st.StopID, st.StopSequence,
+				nullString(st.StopHeadsign), nullInt(st.PickupType), nullInt(st.DropOffType), distance, nullInt(st.Timepoint))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return pw.Close()
+}
+
+func (manager *Manager) exportVehiclePositions(w io.Writer) error {
+	pw, err := parquet.NewWriter(w, []parquet.Column{
+		{Name: "vehicle_id", Type: parquet.String},
+		{Name: "trip_id", Type: parquet.String, Optional: true},
+		{Name: "route_id", Type: parquet.String, Optional: true},
+		{Name: "latitude", Type: parquet.Double, Optional: true},
+		{Name: "longitude", Type: parquet.Double, Optional: true},
+		{Name: "bearing", Type: parquet.Double, Optional: true},
+		{Name: "speed", Type: parquet.Double, Optional: true},
+		{Name: "current_stop_sequence", Type: parquet.Int64, Optional: true},
+		{Name: "stop_id", Type: parquet.String, Optional: true},
+		{Name: "timestamp", Type: parquet.TimestampMillis, Optional: true},
+	})
+	if err != nil {
+		return err
+	}
+	for _, vehicle := range manager.GetRealTimeVehicles() {
+		if vehicle.ID == nil {
+			continue
+		}
+		var tripID, routeID, lat, lon, bearing, speed, sequence, stopID, timestamp any
+		if vehicle.Trip != nil {
+			tripID, routeID = vehicle.Trip.ID.ID, vehicle.Trip.ID.RouteID
+		}
+		if p := vehicle.Position; p != nil {
+			lat, lon, bearing, speed = float32Value(p.Latitude), float32Value(p.Longitude), float32Value(p.Bearing), float32Value(p.Speed)
+		}
+		if vehicle.CurrentStopSequence != nil {
+			sequence = int64(*vehicle.CurrentStopSequence)
+		}
+		if vehicle.StopID != nil {
+			stopID = *vehicle.StopID
+		}
+		if vehicle.Timestamp != nil {
+			timestamp = *vehicle.Timestamp
+		}
+		if err := pw.Write(vehicle.ID.ID, tripID, routeID, lat, lon, bearing, speed, sequence, stopID, timestamp); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// nullString is the value of s for a Parquet row, or nil when it is NULL.
+func nullString(s sql.NullString) any {
+	if !s.Valid {
+		return nil
+	}
+	return s.String
+}
+
+// nullInt is the value of n for a Parquet row, or nil when it is NULL.
+func nullInt(n sql.NullInt64) any {
+	if !n.Valid {
+		return nil
+	}
+	return n.Int64
+}
+
+func float32Value(f *float32) any {
+	if f == nil {
+		return nil
+	}
+	return float64(*f)
+}
@@ -2,17 +2,52 @@ package gtfs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
 	"github.com/OneBusAway/go-gtfs"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"maglev.onebusaway.org/internal/logging"
 )
 
+// ErrRealtimeDisabled is returned when a realtime operation is requested but no GTFS-RT feeds are configured.
+var ErrRealtimeDisabled = errors.New("no GTFS-RT feeds configured")
+
+// ErrRealtimeToggle is returned when new feeds would enable or disable GTFS-RT polling,
+// which is only started when the manager is initialized.
+var ErrRealtimeToggle = errors.New("enabling or disabling GTFS-RT feeds requires a restart")
+
+var tracer = otel.Tracer("maglev.onebusaway.org/internal/gtfs")
+
+// startFeedSpan starts a span for fetching a feed. Only the host is recorded because feed URLs
+// frequently carry credentials in their query strings.
+func startFeedSpan(ctx context.Context, name, source string) (context.Context, trace.Span) {
+	host := ""
+	if u, err := url.Parse(source); err == nil {
+		host = u.Host
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attribute.String("server.address", host)))
+}
+
+// endFeedSpan records err, if any, on span and ends it.
+func endFeedSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 // realtimeHTTPClient is a dedicated HTTP client for GTFS-RT feed fetching,
 // configured with explicit timeouts and transport limits to avoid the pitfalls
 // of http.DefaultClient (no timeout, shared global state).
@@ -38,8 +73,9 @@ func newRealtimeHTTPClient() *http.Client {
 		// updateGTFSRealtimePeriodically also sets a 15s context timeout;
 		// the stricter of the two wins. Keep this <= the context timeout so
 		// the client enforces the bound even if a caller forgets a context.
-		Timeout:   10 * time.Second,
-		Transport: transport,
+		Timeout: 10 * time.Second,
+		// Propagate trace context to feed servers that participate in tracing
+		Transport: otelhttp.NewTransport(transport),
 	}
 }
 
@@ -57,7 +93,26 @@ func (manager *Manager) GetRealTimeVehicles() []gtfs.Vehicle {
 	return manager.realTimeVehicles
 }
 
+// GetRealTimeAlerts returns the service alerts from the GTFS-RT feed
+func (manager *Manager) GetRealTimeAlerts() []gtfs.Alert {
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+	return manager.realTimeAlerts
+}
+
 func loadRealtimeData(ctx context.Context, source string, headers map[string]string) (*gtfs.Realtime, error) {
+	body, err := fetchRealtimeFeed(ctx, source, headers)
+	if err != nil {
+		return nil, err
+	}
+	return gtfs.ParseRealtime(body, &gtfs.ParseRealtimeOptions{})
+}
+
+// fetchRealtimeFeed downloads the GTFS-RT feed at source.
+func fetchRealtimeFeed(ctx context.Context, source string, headers map[string]string) (body []byte, err error) {
+	ctx, span := startFeedSpan(ctx, "gtfs.realtime.fetch", source)
+	defer func() { endFeedSpan(span, err) }()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
 	if err != nil {
 		return nil, err
@@ -81,7 +136,7 @@ func loadRealtimeData(ctx context.Context, source string, headers map[string]str
 	}
 
 	const maxBodySize = 25 * 1024 * 1024
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
@@ -90,7 +145,7 @@ func loadRealtimeData(ctx context.Context, source string, headers map[string]str
 		return nil, fmt.Errorf("GTFS-RT response exceeds size limit of %d bytes", maxBodySize)
 	}
 
-	return gtfs.ParseRealtime(body, &gtfs.ParseRealtimeOptions{})
+	return body, nil
 }
 
 func (manager *Manager) GetAlertsForRoute(routeID string) []gtfs.Alert {
@@ -177,91 +232,333 @@ func (manager *Manager) GetAlertsForStop(stopID string) []gtfs.Alert {
 	return alerts
 }
 
-func (manager *Manager) updateGTFSRealtime(ctx context.Context, config Config) {
-	logger := logging.FromContext(ctx).With(slog.String("component", "gtfs_realtime"))
+// realtimeFeedData is what was last loaded from one set of GTFS-RT feeds. A feed that fails to
+// load keeps the data it loaded before.
+type realtimeFeedData struct {
+	trips             []gtfs.Trip
+	vehicles          []gtfs.Vehicle
+	alerts            []gtfs.Alert
+	tripModifications map[string][]TripModification
+	shapes            map[string][][]float64
+
+	// When each feed last loaded successfully
+	tripsFetched, vehiclesFetched, alertsFetched time.Time
+}
+
+// realtimeFetch is the outcome of fetching one set of GTFS-RT feeds. The data of a feed is nil
+// when it is not configured or failed to load.
+type realtimeFetch struct {
+	tripData, vehicleData, alertData *gtfs.Realtime
+	tripModifications                map[string][]TripModification
+	shapes                           map[string][][]float64
+	tripErr, vehicleErr              error
+}
 
+// fetchRealtimeFeedSet fetches the trip updates, vehicle positions and service alerts of feed in
+// parallel. Fetch errors are logged.
+func fetchRealtimeFeedSet(ctx context.Context, logger *slog.Logger, feed RealtimeFeed) realtimeFetch {
 	headers := map[string]string{}
-	if config.RealTimeAuthHeaderKey != "" && config.RealTimeAuthHeaderValue != "" {
-		headers[config.RealTimeAuthHeaderKey] = config.RealTimeAuthHeaderValue
+	if feed.AuthHeaderKey != "" && feed.AuthHeaderValue != "" {
+		headers[feed.AuthHeaderKey] = feed.AuthHeaderValue
+	}
+	if feed.AgencyID != "" {
+		logger = logger.With(slog.String("agency_id", feed.AgencyID))
 	}
 
 	var wg sync.WaitGroup
-	var tripData, vehicleData, alertData *gtfs.Realtime
-	var tripErr, vehicleErr, alertErr error
-
-	// Fetch trip updates in parallel
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		tripData, tripErr = loadRealtimeData(ctx, config.TripUpdatesURL, headers)
-		if tripErr != nil {
-			logging.LogError(logger, "Error loading GTFS-RT trip updates data", tripErr,
-				slog.String("url", config.TripUpdatesURL))
-		}
-	}()
-
-	// Fetch vehicle positions in parallel
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		vehicleData, vehicleErr = loadRealtimeData(ctx, config.VehiclePositionsURL, headers)
-		if vehicleErr != nil {
-			logging.LogError(logger, "Error loading GTFS-RT vehicle positions data", vehicleErr,
-				slog.String("url", config.VehiclePositionsURL))
-		}
-	}()
+	var result realtimeFetch
 
-	if config.ServiceAlertsURL != "" {
+	// The trip modifications and their shapes are read from the trip updates feed
+	if feed.TripUpdatesURL != "" {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			alertData, alertErr = loadRealtimeData(ctx, config.ServiceAlertsURL, headers)
-			if alertErr != nil {
-				logging.LogError(logger, "Error loading GTFS-RT service alerts data", alertErr,
-					slog.String("url", config.ServiceAlertsURL))
+			body, err := fetchRealtimeFeed(ctx, feed.TripUpdatesURL, headers)
+			var tripData *gtfs.Realtime
+			if err == nil {
+				tripData, err = gtfs.ParseRealtime(body, &gtfs.ParseRealtimeOptions{})
+			}
+			if err != nil {
+				result.tripErr = err
+				logging.LogError(logger, "Error loading GTFS-RT trip updates data", err,
+					slog.String("url", feed.TripUpdatesURL))
+				return
 			}
+			prefixRealtimeIDs(tripData, feed.IDPrefix)
+			result.tripData = tripData
+			result.tripModifications, result.shapes, err = parseTripModifications(body)
+			if err != nil {
+				logging.LogError(logger, "Error reading GTFS-RT trip modifications", err,
+					slog.String("url", feed.TripUpdatesURL))
+			}
+			result.tripModifications = prefixTripModificationIDs(result.tripModifications, feed.IDPrefix)
 		}()
 	}
 
-	// Wait for both to complete
+	if feed.VehiclePositionsURL != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vehicleData, err := loadRealtimeData(ctx, feed.VehiclePositionsURL, headers)
+			if err != nil {
+				result.vehicleErr = err
+				logging.LogError(logger, "Error loading GTFS-RT vehicle positions data", err,
+					slog.String("url", feed.VehiclePositionsURL))
+				return
+			}
+			prefixRealtimeIDs(vehicleData, feed.IDPrefix)
+			result.vehicleData = vehicleData
+		}()
+	}
+
+	if feed.ServiceAlertsURL != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			alertData, err := loadRealtimeData(ctx, feed.ServiceAlertsURL, headers)
+			if err != nil {
+				logging.LogError(logger, "Error loading GTFS-RT service alerts data", err,
+					slog.String("url", feed.ServiceAlertsURL))
+				return
+			}
+			prefixRealtimeIDs(alertData, feed.IDPrefix)
+			result.alertData = alertData
+		}()
+	}
+
+	wg.Wait()
+	return result
+}
+
+// updateGTFSRealtime fetches all configured GTFS-RT feeds and swaps in whatever loaded successfully,
+// merged across the sets of feeds. A feed that fails keeps its previous data. Fetch errors are
+// logged; the returned error reports whether any trip updates or vehicle positions failed.
+func (manager *Manager) updateGTFSRealtime(ctx context.Context, config Config) error {
+	logger := logging.FromContext(ctx).With(slog.String("component", "gtfs_realtime"))
+
+	feeds := config.RealtimeFeeds()
+	fetches := make([]realtimeFetch, len(feeds))
+	var wg sync.WaitGroup
+	for i, feed := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetches[i] = fetchRealtimeFeedSet(ctx, logger, feed)
+		}()
+	}
 	wg.Wait()
 
 	// Check for context cancellation
-	if ctx.Err() != nil {
-		return
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	// Update data if at least one fetch succeeded
+	var update RealtimeUpdate
+	var errs []error
+	var tripsLoaded, vehiclesLoaded, alertsLoaded bool
 	manager.realTimeMutex.Lock()
-	defer manager.realTimeMutex.Unlock()
 
-	if tripData != nil && tripErr == nil {
-		manager.realTimeTrips = tripData.Trips
-		rebuildRealTimeTripLookup(manager)
+	now := time.Now()
+	if len(manager.realtimeFeedData) != len(feeds) {
+		manager.realtimeFeedData = make([]realtimeFeedData, len(feeds))
+	}
+	for i, fetch := range fetches {
+		data := &manager.realtimeFeedData[i]
+		if fetch.tripData != nil {
+			data.trips = fetch.tripData.Trips
+			data.tripModifications = fetch.tripModifications
+			data.shapes = fetch.shapes
+			data.tripsFetched = now
+			tripsLoaded = true
+		}
+		if fetch.vehicleData != nil {
+			data.vehicles = vehiclesWithID(fetch.vehicleData.Vehicles)
+			data.vehiclesFetched = now
+			vehiclesLoaded = true
+		}
+		if fetch.alertData != nil {
+			data.alerts = fetch.alertData.Alerts
+			data.alertsFetched = now
+			alertsLoaded = true
+		}
+		errs = append(errs, fetch.tripErr, fetch.vehicleErr)
+	}
 
+	if tripsLoaded {
+		mergeRealTimeTrips(manager)
+		rebuildRealTimeTripLookup(manager)
+		update.Trips = manager.realTimeTrips
 	}
-	if vehicleData != nil && vehicleErr == nil {
-		manager.realTimeVehicles = vehicleData.Vehicles
-		filterRealTimeVehicleByValidId(manager)
+	if vehiclesLoaded {
+		mergeRealTimeVehicles(manager, feeds)
 		rebuildRealTimeVehicleLookupByTrip(manager)
 		rebuildRealTimeVehicleLookupByVehicle(manager)
+		update.Vehicles = manager.realTimeVehicles
+	}
+	err := errors.Join(errs...)
+	if err == nil {
+		manager.lastRealtimeUpdate = now
+	}
+	if alertsLoaded {
+		manager.realTimeAlerts = nil
+		for _, data := range manager.realtimeFeedData {
+			manager.realTimeAlerts = append(manager.realTimeAlerts, data.alerts...)
+		}
+		update.Alerts = manager.realTimeAlerts
+	}
+
+	hooks := manager.realtimeUpdateHooks
+	for ch := range manager.realtimeSubscribers {
+		publishRealtimeUpdate(ch, update)
+	}
+	manager.realTimeMutex.Unlock()
+
+	for _, hook := range hooks {
+		hook(update)
+	}
+
+	return err
+}
+
+// mergeRealTimeTrips combines the trip updates and trip modifications of every set of feeds.
+func mergeRealTimeTrips(manager *Manager) {
+	if len(manager.realtimeFeedData) == 1 {
+		data := manager.realtimeFeedData[0]
+		manager.realTimeTrips = data.trips
+		manager.realTimeTripModifications = data.tripModifications
+		manager.realTimeShapes = data.shapes
+		return
+	}
+
+	manager.realTimeTrips = nil
+	manager.realTimeTripModifications = make(map[string][]TripModification)
+	manager.realTimeShapes = make(map[string][][]float64)
+	for _, data := range manager.realtimeFeedData {
+		manager.realTimeTrips = append(manager.realTimeTrips, data.trips...)
+		for tripID, modifications := range data.tripModifications {
+			manager.realTimeTripModifications[tripID] = append(manager.realTimeTripModifications[tripID], modifications...)
+		}
+		for shapeID, shape := range data.shapes {
+			manager.realTimeShapes[shapeID] = shape
+		}
+	}
+}
+
+// mergeRealTimeVehicles combines the vehicle positions of every set of feeds, noting the agency
+// of the feed each vehicle came from.
+func mergeRealTimeVehicles(manager *Manager, feeds []RealtimeFeed) {
+	manager.realTimeVehicles = nil
+	manager.realTimeVehicleAgencies = nil
+	for i, data := range manager.realtimeFeedData {
+		manager.realTimeVehicles = append(manager.realTimeVehicles, data.vehicles...)
+		for range data.vehicles {
+			manager.realTimeVehicleAgencies = append(manager.realTimeVehicleAgencies, feeds[i].AgencyID)
+		}
+	}
+}
+
+// RealtimeUpdate holds the GTFS-RT data swapped in by one refresh. Each slice is nil when its
+// feed was not refreshed or was empty. The slices are shared with the manager and must not be
+// modified.
+type RealtimeUpdate struct {
+	Trips    []gtfs.Trip
+	Vehicles []gtfs.Vehicle
+	Alerts   []gtfs.Alert
+}
+
+// AddRealtimeUpdateHook registers fn to run after each GTFS-RT refresh, with the data it
+// loaded. Hooks run in the order they were added on the refreshing goroutine, so they should
+// hand slow work off.
+func (manager *Manager) AddRealtimeUpdateHook(fn func(RealtimeUpdate)) {
+	manager.realTimeMutex.Lock()
+	defer manager.realTimeMutex.Unlock()
+	manager.realtimeUpdateHooks = append(manager.realtimeUpdateHooks, fn)
+}
+
+// SubscribeRealtime returns a channel that receives the update of each GTFS-RT refresh from
+// now on, and a function that ends the subscription. A subscriber that falls behind only gets
+// the latest update it missed, so a slow one never holds up refreshes. The channel is not
+// closed; stop receiving from it once the subscription is cancelled.
+func (manager *Manager) SubscribeRealtime() (<-chan RealtimeUpdate, func()) {
+	ch := make(chan RealtimeUpdate, 1)
+	manager.realTimeMutex.Lock()
+	if manager.realtimeSubscribers == nil {
+		manager.realtimeSubscribers = make(map[chan RealtimeUpdate]struct{})
+	}
+	manager.realtimeSubscribers[ch] = struct{}{}
+	manager.realTimeMutex.Unlock()
+
+	return ch, func() {
+		manager.realTimeMutex.Lock()
+		delete(manager.realtimeSubscribers, ch)
+		manager.realTimeMutex.Unlock()
 	}
+}
+
+// publishRealtimeUpdate sends update to a subscriber without blocking, replacing an update it
+// has not received yet. Caller must hold realTimeMutex, so refreshes publish one at a time.
+func publishRealtimeUpdate(ch chan RealtimeUpdate, update RealtimeUpdate) {
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- update
+}
 
-	if alertData != nil && alertErr == nil {
-		manager.realTimeAlerts = alertData.Alerts
-	} else if alertErr != nil {
-		logging.LogError(logger, "Error loading GTFS-RT service alerts", alertErr)
+// RefreshRealtime fetches the GTFS-RT feeds immediately instead of waiting for the next refresh interval.
+func (manager *Manager) RefreshRealtime(ctx context.Context) error {
+	if !manager.RealtimeEnabled() {
+		return ErrRealtimeDisabled
 	}
+	return manager.updateGTFSRealtime(ctx, manager.realtimeConfig())
 }
 
-func filterRealTimeVehicleByValidId(manager *Manager) {
-	validVehicles := make([]gtfs.Vehicle, 0, len(manager.realTimeVehicles))
-	for _, v := range manager.realTimeVehicles {
+// SetRealtimeFeeds replaces the GTFS-RT feed URLs, auth headers and agencies with those in config.
+// The new feeds are used from the next refresh. Returns ErrRealtimeToggle if the change
+// would turn realtime polling on or off.
+func (manager *Manager) SetRealtimeFeeds(config Config) error {
+	manager.realtimeConfigMutex.Lock()
+	defer manager.realtimeConfigMutex.Unlock()
+
+	if config.realTimeDataEnabled() != manager.config.realTimeDataEnabled() {
+		return ErrRealtimeToggle
+	}
+	manager.config.TripUpdatesURL = config.TripUpdatesURL
+	manager.config.VehiclePositionsURL = config.VehiclePositionsURL
+	manager.config.ServiceAlertsURL = config.ServiceAlertsURL
+	manager.config.RealTimeAuthHeaderKey = config.RealTimeAuthHeaderKey
+	manager.config.RealTimeAuthHeaderValue = config.RealTimeAuthHeaderValue
+	manager.config.RealTimeAgencyID = config.RealTimeAgencyID
+	manager.config.RealTimeIDPrefix = config.RealTimeIDPrefix
+	manager.config.ExtraRealtimeFeeds = config.ExtraRealtimeFeeds
+	return nil
+}
+
+// realtimeConfig returns a Config holding only the current GTFS-RT feeds.
+func (manager *Manager) realtimeConfig() Config {
+	manager.realtimeConfigMutex.RLock()
+	defer manager.realtimeConfigMutex.RUnlock()
+	return Config{
+		TripUpdatesURL:          manager.config.TripUpdatesURL,
+		VehiclePositionsURL:     manager.config.VehiclePositionsURL,
+		ServiceAlertsURL:        manager.config.ServiceAlertsURL,
+		RealTimeAuthHeaderKey:   manager.config.RealTimeAuthHeaderKey,
+		RealTimeAuthHeaderValue: manager.config.RealTimeAuthHeaderValue,
+		RealTimeAgencyID:        manager.config.RealTimeAgencyID,
+		RealTimeIDPrefix:        manager.config.RealTimeIDPrefix,
+		ExtraRealtimeFeeds:      manager.config.ExtraRealtimeFeeds,
+	}
+}
+
+// vehiclesWithID returns the vehicles that have an ID; the others can't be looked up or shown.
+func vehiclesWithID(vehicles []gtfs.Vehicle) []gtfs.Vehicle {
+	validVehicles := make([]gtfs.Vehicle, 0, len(vehicles))
+	for _, v := range vehicles {
 		if v.ID != nil {
 			validVehicles = append(validVehicles, v)
 		}
 	}
-	manager.realTimeVehicles = validVehicles
+	return validVehicles
 }
 
 func rebuildRealTimeTripLookup(manager *Manager) {
@@ -282,14 +579,36 @@ func rebuildRealTimeVehicleLookupByTrip(manager *Manager) {
 
 func rebuildRealTimeVehicleLookupByVehicle(manager *Manager) {
 	manager.realTimeVehicleLookupByVehicle = make(map[string]int, len(manager.realTimeVehicles))
+	manager.realTimeVehicleLookupByAgency = make(map[agencyVehicleKey]int)
 	for i, vehicle := range manager.realTimeVehicles {
-		if vehicle.ID.ID != "" {
-			manager.realTimeVehicleLookupByVehicle[vehicle.ID.ID] = i
+		if vehicle.ID.ID == "" {
+			continue
+		}
+		manager.realTimeVehicleLookupByVehicle[vehicle.ID.ID] = i
+		if agencyID := manager.vehicleFeedAgency(i); agencyID != "" {
+			manager.realTimeVehicleLookupByAgency[agencyVehicleKey{agencyID, vehicle.ID.ID}] = i
 		}
 	}
 }
 
-func (manager *Manager) updateGTFSRealtimePeriodically(config Config) {
+// agencyVehicleKey identifies a vehicle of a feed that carries one agency's vehicles, whose IDs
+// may repeat those of another agency's feed.
+type agencyVehicleKey struct {
+	agencyID  string
+	vehicleID string
+}
+
+// vehicleFeedAgency returns the agency of the feed that the vehicle at index i came from, or ""
+// if the feed names none.
+// The caller must hold realTimeMutex.
+func (manager *Manager) vehicleFeedAgency(i int) string {
+	if i < len(manager.realTimeVehicleAgencies) {
+		return manager.realTimeVehicleAgencies[i]
+	}
+	return ""
+}
+
+func (manager *Manager) updateGTFSRealtimePeriodically() {
 	defer manager.wg.Done()
 
 	// Create a logger for this goroutine
@@ -306,9 +625,9 @@ func (manager *Manager) updateGTFSRealtimePeriodically(config Config) {
 			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 			ctx = logging.WithLogger(ctx, logger)
 
-			// Download realtime data
 			logging.LogOperation(logger, "updating_gtfs_realtime_data")
-			manager.updateGTFSRealtime(ctx, config)
+			// Download realtime data; errors are logged and the previous data is kept
+			_ = manager.updateGTFSRealtime(ctx, manager.realtimeConfig())
 			cancel() // Ensure the context is canceled when done
 		case <-manager.shutdownChan:
 			logging.LogOperation(logger, "shutting_down_realtime_updates")
@@ -5,11 +5,16 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/OneBusAway/go-gtfs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetAlertsForRoute(t *testing.T) {
@@ -168,7 +173,7 @@ func TestRebuildRealTimeVehicleLookupByVehicle_WithInvalidIDs(t *testing.T) {
 		},
 	}
 
-	filterRealTimeVehicleByValidId(manager)
+	manager.realTimeVehicles = vehiclesWithID(manager.realTimeVehicles)
 	rebuildRealTimeVehicleLookupByVehicle(manager)
 
 	assert.NotNil(t, manager.realTimeVehicleLookupByVehicle)
@@ -201,3 +206,202 @@ func TestLoadRealtimeData_Non200StatusCode(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateGTFSRealtime_RecordsLastUpdate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/trip-updates", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := os.ReadFile(filepath.Join("../../testdata", "raba-trip-updates.pb"))
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/vehicle-positions", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := os.ReadFile(filepath.Join("../../testdata", "raba-vehicle-positions.pb"))
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	manager := &Manager{
+		config: Config{
+			TripUpdatesURL:      server.URL + "/trip-updates",
+			VehiclePositionsURL: server.URL + "/broken",
+		},
+		realTimeTripLookup:             make(map[string]int),
+		realTimeVehicleLookupByTrip:    make(map[string]int),
+		realTimeVehicleLookupByVehicle: make(map[string]int),
+	}
+	assert.True(t, manager.RealtimeEnabled())
+	var updates []RealtimeUpdate
+	manager.AddRealtimeUpdateHook(func(update RealtimeUpdate) {
+		updates = append(updates, update)
+	})
+
+	// A partial failure doesn't count as a fresh update
+	assert.Error(t, manager.updateGTFSRealtime(context.Background(), manager.config))
+	assert.True(t, manager.LastRealtimeUpdate().IsZero())
+	// The hook still sees the feed that loaded
+	require.Len(t, updates, 1)
+	assert.NotEmpty(t, updates[0].Trips)
+	assert.Nil(t, updates[0].Vehicles)
+	// Each feed's last success is kept on its own
+	statuses := manager.RealtimeFeedStatuses()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "tripUpdates", statuses[0].Feed)
+	assert.False(t, statuses[0].LastFetched.IsZero())
+	assert.Equal(t, "vehiclePositions", statuses[1].Feed)
+	assert.True(t, statuses[1].LastFetched.IsZero())
+
+	manager.config.VehiclePositionsURL = server.URL + "/vehicle-positions"
+	before := time.Now()
+	assert.NoError(t, manager.RefreshRealtime(context.Background()))
+	assert.False(t, manager.LastRealtimeUpdate().Before(before))
+	require.Len(t, updates, 2)
+	assert.Equal(t, manager.GetRealTimeVehicles(), updates[1].Vehicles)
+	for _, status := range manager.RealtimeFeedStatuses() {
+		assert.Equal(t, manager.LastRealtimeUpdate(), status.LastFetched, status.Feed)
+	}
+}
+
+func TestSubscribeRealtime(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/trip-updates", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := os.ReadFile(filepath.Join("../../testdata", "raba-trip-updates.pb"))
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/vehicle-positions", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := os.ReadFile(filepath.Join("../../testdata", "raba-vehicle-positions.pb"))
+		_, _ = w.Write(data)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	manager := &Manager{
+		config: Config{
+			TripUpdatesURL:      server.URL + "/trip-updates",
+			VehiclePositionsURL: server.URL + "/vehicle-positions",
+		},
+		realTimeTripLookup:             make(map[string]int),
+		realTimeVehicleLookupByTrip:    make(map[string]int),
+		realTimeVehicleLookupByVehicle: make(map[string]int),
+	}
+	updates, unsubscribe := manager.SubscribeRealtime()
+
+	// A subscriber that falls behind gets the latest update only
+	require.NoError(t, manager.RefreshRealtime(context.Background()))
+	require.NoError(t, manager.RefreshRealtime(context.Background()))
+	select {
+	case update := <-updates:
+		assert.Equal(t, manager.GetRealTimeVehicles(), update.Vehicles)
+	default:
+		t.Fatal("no update published")
+	}
+	select {
+	case <-updates:
+		t.Fatal("missed update published twice")
+	default:
+	}
+
+	unsubscribe()
+	require.NoError(t, manager.RefreshRealtime(context.Background()))
+	select {
+	case <-updates:
+		t.Fatal("update published after unsubscribing")
+	default:
+	}
+}
+
+func TestSetRealtimeFeeds(t *testing.T) {
+	manager := &Manager{config: Config{
+		GtfsURL:             "https://example.com/gtfs.zip",
+		TripUpdatesURL:      "https://example.com/trips",
+		VehiclePositionsURL: "https://example.com/vehicles",
+	}}
+
+	require.NoError(t, manager.SetRealtimeFeeds(Config{
+		TripUpdatesURL:          "https://feeds.example.com/trips",
+		VehiclePositionsURL:     "https://feeds.example.com/vehicles",
+		ServiceAlertsURL:        "https://feeds.example.com/alerts",
+		RealTimeAuthHeaderKey:   "X-Api-Key",
+		RealTimeAuthHeaderValue: "secret",
+	}))
+	feeds := manager.realtimeConfig()
+	assert.Equal(t, "https://feeds.example.com/trips", feeds.TripUpdatesURL)
+	assert.Equal(t, "https://feeds.example.com/alerts", feeds.ServiceAlertsURL)
+	assert.Equal(t, "secret", feeds.RealTimeAuthHeaderValue)
+	assert.Equal(t, "https://example.com/gtfs.zip", manager.config.GtfsURL)
+
+	// Polling is only started at init, so realtime can't be switched off at runtime
+	assert.ErrorIs(t, manager.SetRealtimeFeeds(Config{}), ErrRealtimeToggle)
+	assert.True(t, manager.RealtimeEnabled())
+}
+
+func TestUpdateGTFSRealtime_MergesFeeds(t *testing.T) {
+	var brokenFeeds atomic.Bool
+	mux := http.NewServeMux()
+	for _, name := range []string{"raba-trip-updates", "raba-vehicle-positions", "unitrans-trip-updates", "unitrans-vehicle-positions"} {
+		mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+			if brokenFeeds.Load() && r.Header.Get("X-Feed") == "unitrans" {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			data, _ := os.ReadFile(filepath.Join("../../testdata", name+".pb"))
+			_, _ = w.Write(data)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	readFeed := func(name string) *gtfs.Realtime {
+		data, err := os.ReadFile(filepath.Join("../../testdata", name+".pb"))
+		require.NoError(t, err)
+		feed, err := gtfs.ParseRealtime(data, &gtfs.ParseRealtimeOptions{})
+		require.NoError(t, err)
+		return feed
+	}
+	rabaVehicles := vehiclesWithID(readFeed("raba-vehicle-positions").Vehicles)
+	unitransVehicles := vehiclesWithID(readFeed("unitrans-vehicle-positions").Vehicles)
+	tripCount := len(readFeed("raba-trip-updates").Trips) + len(readFeed("unitrans-trip-updates").Trips)
+	require.NotEmpty(t, unitransVehicles)
+
+	manager := &Manager{
+		gtfsData: &gtfs.Static{},
+		config: Config{
+			TripUpdatesURL:      server.URL + "/raba-trip-updates",
+			VehiclePositionsURL: server.URL + "/raba-vehicle-positions",
+			RealTimeAgencyID:    "25",
+			ExtraRealtimeFeeds: []RealtimeFeed{{
+				AgencyID:            "unitrans",
+				TripUpdatesURL:      server.URL + "/unitrans-trip-updates",
+				VehiclePositionsURL: server.URL + "/unitrans-vehicle-positions",
+				AuthHeaderKey:       "X-Feed",
+				AuthHeaderValue:     "unitrans",
+			}},
+		},
+	}
+	require.NoError(t, manager.RefreshRealtime(context.Background()))
+	assert.Len(t, manager.GetRealTimeTrips(), tripCount)
+	assert.Len(t, manager.GetRealTimeVehicles(), len(rabaVehicles)+len(unitransVehicles))
+
+	// A vehicle is found by the agency of its feed
+	vehicleID := unitransVehicles[0].ID.ID
+	vehicle, err := manager.GetVehicleForAgency("unitrans", vehicleID)
+	require.NoError(t, err)
+	assert.Equal(t, unitransVehicles[0].Position, vehicle.Position)
+
+	// Vehicles without a route belong to the agency of their feed
+	var unrouted int
+	for _, v := range unitransVehicles {
+		if v.Trip == nil || v.Trip.ID.RouteID == "" {
+			unrouted++
+		}
+	}
+	assert.Len(t, manager.VehiclesForAgencyID("unitrans"), unrouted)
+
+	// A failing feed keeps its last data while the others refresh
+	brokenFeeds.Store(true)
+	assert.Error(t, manager.RefreshRealtime(context.Background()))
+	assert.Len(t, manager.GetRealTimeTrips(), tripCount)
+	assert.Len(t, manager.GetRealTimeVehicles(), len(rabaVehicles)+len(unitransVehicles))
+}
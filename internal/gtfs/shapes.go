@@ -3,38 +3,38 @@ package gtfs
 import "github.com/OneBusAway/go-gtfs"
 
 // ComputeRegionBounds calculates the geographic boundaries of the GTFS region
-// from all shape points. Returns nil if no shapes exist.
-func ComputeRegionBounds(shapes []gtfs.Shape) *RegionBounds {
-	if len(shapes) == 0 {
-		return nil
-	}
-
-	var minLat, maxLat, minLon, maxLon float64
-	first := true
-
+// from all shape points, or from the stop locations when the feed has no shapes.
+// Returns nil if there are neither.
+func ComputeRegionBounds(shapes []gtfs.Shape, stops []gtfs.Stop) *RegionBounds {
+	var points []gtfs.ShapePoint
 	for _, shape := range shapes {
-		for _, point := range shape.Points {
-			if first {
-				minLat = point.Latitude
-				maxLat = point.Latitude
-				minLon = point.Longitude
-				maxLon = point.Longitude
-				first = false
-				continue
+		points = append(points, shape.Points...)
+	}
+	if len(points) == 0 {
+		for _, stop := range stops {
+			if stop.Latitude != nil && stop.Longitude != nil {
+				points = append(points, gtfs.ShapePoint{Latitude: *stop.Latitude, Longitude: *stop.Longitude})
 			}
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
 
-			if point.Latitude < minLat {
-				minLat = point.Latitude
-			}
-			if point.Latitude > maxLat {
-				maxLat = point.Latitude
-			}
-			if point.Longitude < minLon {
-				minLon = point.Longitude
-			}
-			if point.Longitude > maxLon {
-				maxLon = point.Longitude
-			}
+	minLat, maxLat := points[0].Latitude, points[0].Latitude
+	minLon, maxLon := points[0].Longitude, points[0].Longitude
+	for _, point := range points[1:] {
+		if point.Latitude < minLat {
+			minLat = point.Latitude
+		}
+		if point.Latitude > maxLat {
+			maxLat = point.Latitude
+		}
+		if point.Longitude < minLon {
+			minLon = point.Longitude
+		}
+		if point.Longitude > maxLon {
+			maxLon = point.Longitude
 		}
 	}
 
@@ -8,9 +8,12 @@ import (
 )
 
 func TestGetRegionBounds(t *testing.T) {
+	southLat, northLat, westLon, eastLon := 47.0, 48.0, -122.0, -121.5
+
 	tests := []struct {
 		name            string
 		shapes          []gtfs.Shape
+		stops           []gtfs.Stop
 		expectedLat     float64
 		expectedLon     float64
 		expectedLatSpan float64
@@ -76,6 +79,19 @@ func TestGetRegionBounds(t *testing.T) {
 			expectedLatSpan: 0.0,
 			expectedLonSpan: 0.0,
 		},
+		{
+			name:   "Stops Without Shapes",
+			shapes: []gtfs.Shape{},
+			stops: []gtfs.Stop{
+				{Id: "1", Latitude: &southLat, Longitude: &westLon},
+				{Id: "2", Latitude: &northLat, Longitude: &eastLon},
+				{Id: "station"},
+			},
+			expectedLat:     47.5,
+			expectedLon:     -121.75,
+			expectedLatSpan: 1.0,
+			expectedLonSpan: 0.5,
+		},
 		{
 			name: "Real Example",
 			shapes: []gtfs.Shape{
@@ -106,7 +122,7 @@ func TestGetRegionBounds(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			bounds := ComputeRegionBounds(tc.shapes)
+			bounds := ComputeRegionBounds(tc.shapes, tc.stops)
 
 			var lat, lon, latSpan, lonSpan float64
 			if bounds != nil {
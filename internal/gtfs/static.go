@@ -2,6 +2,8 @@ package gtfs
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
@@ -13,11 +15,41 @@ import (
 	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/utils"
 )
 
-func rawGtfsData(source string, isLocalFile bool, config Config) ([]byte, error) {
-	var b []byte
-	var err error
+// staticGtfsData reads the static feed, merging it with the other configured static feeds, if
+// any, into one feed. isLocalFile tells whether the first feed is a file; the others are
+// files unless their URL is HTTP(S).
+func staticGtfsData(config Config, isLocalFile bool) ([]byte, error) {
+	feeds := config.StaticFeeds()
+	if !config.mergesStaticFeeds() {
+		return rawGtfsData(feeds[0], isLocalFile)
+	}
+	data := make([][]byte, len(feeds))
+	for i, feed := range feeds {
+		local := isLocalFile
+		if i > 0 {
+			local = isLocalSource(feed.URL)
+		}
+		b, err := rawGtfsData(feed, local)
+		if err != nil {
+			return nil, fmt.Errorf("static feed %s: %w", feed.URL, err)
+		}
+		data[i] = b
+	}
+	return mergeStaticFeeds(feeds, data)
+}
+
+// isLocalSource reports whether a static feed's URL is the path of a local file.
+func isLocalSource(source string) bool {
+	return !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://")
+}
+
+func rawGtfsData(feed StaticFeed, isLocalFile bool) (b []byte, err error) {
+	source := feed.URL
+	ctx, span := startFeedSpan(context.Background(), "gtfs.static.fetch", source)
+	defer func() { endFeedSpan(span, err) }()
 
 	logger := slog.Default().With(slog.String("component", "gtfs_loader"))
 
@@ -27,14 +59,14 @@ func rawGtfsData(source string, isLocalFile bool, config Config) ([]byte, error)
 			return nil, fmt.Errorf("error reading local GTFS file: %w", err)
 		}
 	} else {
-		req, err := http.NewRequest("GET", source, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
 		if err != nil {
 			return nil, fmt.Errorf("error creating GTFS request: %w", err)
 		}
 
 		// Add auth header if provided
-		if config.StaticAuthHeaderKey != "" && config.StaticAuthHeaderValue != "" {
-			req.Header.Set(config.StaticAuthHeaderKey, config.StaticAuthHeaderValue)
+		if feed.AuthHeaderKey != "" && feed.AuthHeaderValue != "" {
+			req.Header.Set(feed.AuthHeaderKey, feed.AuthHeaderValue)
 		}
 
 		client := &http.Client{
@@ -67,7 +99,7 @@ func rawGtfsData(source string, isLocalFile bool, config Config) ([]byte, error)
 	}
 
 	// Process through gtfstidy if enabled
-	if config.EnableGTFSTidy {
+	if feed.EnableGTFSTidy {
 		logging.LogOperation(logger, "gtfstidy_enabled_processing_gtfs_data")
 		tidiedData, err := tidyGTFSData(b, logger)
 		if err != nil {
@@ -80,7 +112,9 @@ func rawGtfsData(source string, isLocalFile bool, config Config) ([]byte, error)
 	return b, nil
 }
 
-func buildGtfsDB(config Config, isLocalFile bool, dbPath string) (*gtfsdb.Client, error) {
+// buildGtfsDB imports the static feed's data, as read by staticGtfsData, into the database at
+// dbPath.
+func buildGtfsDB(config Config, data []byte, dbPath string) (*gtfsdb.Client, error) {
 	// If no specific path is provided, use the one from config
 	if dbPath == "" {
 		dbPath = config.GTFSDataPath
@@ -93,12 +127,11 @@ func buildGtfsDB(config Config, isLocalFile bool, dbPath string) (*gtfsdb.Client
 
 	ctx := context.Background()
 
-	if isLocalFile {
-		err = client.ImportFromFile(ctx, config.GtfsURL)
-	} else {
-		err = client.DownloadAndStore(ctx, config.GtfsURL, config.StaticAuthHeaderKey, config.StaticAuthHeaderValue)
+	source := config.GtfsURL
+	if config.mergesStaticFeeds() {
+		source = mergedStaticSource(config)
 	}
-
+	err = client.ImportFromData(ctx, data, source)
 	if err != nil {
 		return nil, err
 	}
@@ -114,22 +147,42 @@ func buildGtfsDB(config Config, isLocalFile bool, dbPath string) (*gtfsdb.Client
 	return client, nil
 }
 
-// loadGTFSData loads and parses GTFS data from either a URL or a local file
-func loadGTFSData(source string, isLocalFile bool, config Config) (*gtfs.Static, error) {
-	b, err := rawGtfsData(source, isLocalFile, config)
+// mergedStaticSource names the feeds of a merged import, with their prefixes, for the import's
+// record of its source.
+func mergedStaticSource(config Config) string {
+	sources := make([]string, 0, 1+len(config.ExtraStaticFeeds))
+	for _, feed := range config.StaticFeeds() {
+		sources = append(sources, feed.IDPrefix+feed.URL)
+	}
+	return strings.Join(sources, " ")
+}
+
+// parseGTFSData parses the static feed's data, as read by staticGtfsData. The feed's stations
+// are parsed alongside; a feed whose stations cannot be read still loads, without them. A feed
+// missing optional files loads too, with the features it lacks returned as degradations.
+func parseGTFSData(b []byte) (*gtfs.Static, *Stations, []gtfsdb.FeedDegradation, error) {
+	staticData, degradations, err := gtfsdb.ParseStaticFeed(b)
 	if err != nil {
-		return nil, fmt.Errorf("error reading GTFS data: %w", err)
+		return nil, nil, nil, fmt.Errorf("error parsing GTFS data: %w", err)
 	}
 
-	staticData, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+	stations, err := parseStations(b)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing GTFS data: %w", err)
+		logger := slog.Default().With(slog.String("component", "gtfs_loader"))
+		logging.LogError(logger, "Failed to parse stations, pathways and levels", err)
 	}
 
-	return staticData, nil
+	return staticData, stations, degradations, nil
+}
+
+// staticDataHash identifies a static feed's data, to tell whether a refresh brought anything new.
+func staticDataHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
 }
 
-// UpdateGTFSPeriodically updates the GTFS data on a regular schedule
+// updateStaticGTFS refreshes the static feed every StaticRefreshInterval, hot swapping in
+// the new data when it changed.
 func (manager *Manager) updateStaticGTFS() { // nolint
 	defer manager.wg.Done()
 
@@ -143,8 +196,7 @@ func (manager *Manager) updateStaticGTFS() { // nolint
 		return
 	}
 
-	// Update every 24 hours
-	ticker := time.NewTicker(24 * time.Hour)
+	ticker := time.NewTicker(manager.config.staticRefreshInterval())
 	defer ticker.Stop()
 
 	for { // nolint
@@ -153,7 +205,7 @@ func (manager *Manager) updateStaticGTFS() { // nolint
 
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 
-			err := manager.ForceUpdate(ctx)
+			err := manager.updateStatic(ctx, false)
 			cancel()
 
 			if err != nil {
@@ -184,13 +236,36 @@ func (manager *Manager) updateStaticGTFS() { // nolint
 //
 // If the update fails at any point before the swap, temporary files are cleaned up, and the application continues serving the old data.
 // If the final swap (file rename) fails, the system attempts to recover by re-opening the existing database.
+//
+// The data is swapped in even if it is the same as the data being served; the scheduled
+// refresh skips unchanged data.
 func (manager *Manager) ForceUpdate(ctx context.Context) error {
+	return manager.updateStatic(ctx, true)
+}
+
+// updateStatic fetches the static feed and hot swaps it in as ForceUpdate describes. Unless
+// force is set, data identical to what is being served is left alone.
+func (manager *Manager) updateStatic(ctx context.Context, force bool) error {
 	manager.staticUpdateMutex.Lock()
 	defer manager.staticUpdateMutex.Unlock()
 
 	logger := slog.Default().With(slog.String("component", "gtfs_updater"))
 
-	newStaticData, err := loadGTFSData(manager.config.GtfsURL, manager.isLocalFile, manager.config)
+	b, err := staticGtfsData(manager.config, manager.isLocalFile)
+	if err != nil {
+		err = fmt.Errorf("error reading GTFS data: %w", err)
+		logging.LogError(logger, "Error updating GTFS data", err,
+			slog.String("source", manager.config.GtfsURL))
+		return err
+	}
+	newHash := staticDataHash(b)
+	if !force && newHash == manager.staticHash {
+		logging.LogOperation(logger, "gtfs_static_data_unchanged_skipping_update",
+			slog.String("source", manager.config.GtfsURL))
+		return nil
+	}
+
+	newStaticData, newStations, newDegradations, err := parseGTFSData(b)
 	if err != nil {
 		logging.LogError(logger, "Error updating GTFS data", err,
 			slog.String("source", manager.config.GtfsURL))
@@ -208,7 +283,7 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 		logging.LogError(logger, "Failed to remove existing temp DB", err)
 	}
 
-	newGtfsDB, err := buildGtfsDB(manager.config, manager.isLocalFile, tempDBPath)
+	newGtfsDB, err := buildGtfsDB(manager.config, b, tempDBPath)
 	if err != nil {
 		logging.LogError(logger, "Error building new GTFS DB", err)
 		return err
@@ -237,7 +312,7 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 		return err
 	}
 
-	newRegionBounds := ComputeRegionBounds(newStaticData.Shapes)
+	newRegionBounds := ComputeRegionBounds(newStaticData.Shapes, newStaticData.Stops)
 
 	if err := ctx.Err(); err != nil {
 		if closeErr := newGtfsDB.Close(); closeErr != nil {
@@ -253,6 +328,13 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 		logging.LogError(logger, "Error closing new GTFS DB", err)
 		return err
 	}
+	// The hook runs once the swap is done and readers are unblocked
+	var hook func()
+	defer func() {
+		if hook != nil {
+			hook()
+		}
+	}()
 	manager.staticMutex.Lock()
 	defer manager.staticMutex.Unlock()
 
@@ -304,14 +386,19 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 	}
 
 	manager.gtfsData = newStaticData
+	manager.stations = newStations
+	manager.degradations = newDegradations
 	manager.GtfsDB = client
 	manager.agenciesMap, manager.routesMap = buildLookupMaps(newStaticData)
+	manager.agencyIDSplitter.Store(utils.NewAgencyIDSplitter(agencyIDs(newStaticData)))
 	manager.blockLayoverIndices = newBlockLayoverIndices
 	manager.stopSpatialIndex = newStopSpatialIndex
 	manager.regionBounds = newRegionBounds
 	manager.lastUpdated = time.Now()
+	manager.staticHash = newHash
 
 	manager.isHealthy = true
+	hook = manager.staticUpdateHook
 
 	logging.LogOperation(logger, "gtfs_static_data_updated_hot_swap",
 		slog.String("source", manager.config.GtfsURL),
@@ -320,19 +407,45 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 	return nil
 }
 
+// SetStaticUpdateHook registers fn to run after each successful ForceUpdate, once the new
+// dataset is being served. It runs on the refreshing goroutine, and the refresh is reported
+// as in progress until it returns.
+func (manager *Manager) SetStaticUpdateHook(fn func()) {
+	manager.staticMutex.Lock()
+	defer manager.staticMutex.Unlock()
+	manager.staticUpdateHook = fn
+}
+
+// WriteSnapshot writes a consistent, compacted copy of the GTFS database to path, which must
+// not exist. The current dataset cannot be swapped out while the copy is made.
+func (manager *Manager) WriteSnapshot(ctx context.Context, path string) error {
+	manager.staticMutex.RLock()
+	defer manager.staticMutex.RUnlock()
+	if manager.GtfsDB == nil {
+		return fmt.Errorf("GTFS database is not available")
+	}
+	if _, err := manager.GtfsDB.DB.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to write database snapshot: %w", err)
+	}
+	return nil
+}
+
 // setStaticGTFS is used for initial load.
-func (manager *Manager) setStaticGTFS(staticData *gtfs.Static) {
+func (manager *Manager) setStaticGTFS(staticData *gtfs.Static, stations *Stations, degradations []gtfsdb.FeedDegradation) {
 	manager.staticMutex.Lock()
 	defer manager.staticMutex.Unlock()
 
 	manager.gtfsData = staticData
+	manager.stations = stations
+	manager.degradations = degradations
 	manager.lastUpdated = time.Now()
 	manager.isHealthy = true
 
 	manager.agenciesMap, manager.routesMap = buildLookupMaps(staticData)
+	manager.agencyIDSplitter.Store(utils.NewAgencyIDSplitter(agencyIDs(staticData)))
 
 	manager.blockLayoverIndices = buildBlockLayoverIndices(staticData)
-	manager.regionBounds = ComputeRegionBounds(staticData.Shapes)
+	manager.regionBounds = ComputeRegionBounds(staticData.Shapes, staticData.Stops)
 
 	// Rebuild spatial index with updated data
 	ctx := context.Background()
@@ -367,3 +480,12 @@ func buildLookupMaps(data *gtfs.Static) (map[string]*gtfs.Agency, map[string]*gt
 	}
 	return agencies, routes
 }
+
+// agencyIDs returns the IDs of the feed's agencies.
+func agencyIDs(data *gtfs.Static) []string {
+	ids := make([]string, len(data.Agencies))
+	for i, agency := range data.Agencies {
+		ids[i] = agency.Id
+	}
+	return ids
+}
@@ -0,0 +1,337 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// Location types, from stops.txt
+const (
+	LocationTypeStop         = 0
+	LocationTypeStation      = 1
+	LocationTypeEntrance     = 2
+	LocationTypeGenericNode  = 3
+	LocationTypeBoardingArea = 4
+)
+
+// Pathway modes, from pathways.txt
+const (
+	PathwayModeWalkway        = 1
+	PathwayModeStairs         = 2
+	PathwayModeMovingSidewalk = 3
+	PathwayModeEscalator      = 4
+	PathwayModeElevator       = 5
+	PathwayModeFareGate       = 6
+	PathwayModeExitGate       = 7
+)
+
+// StationNode is a location inside a station: a platform, entrance, generic node or boarding area.
+type StationNode struct {
+	ID                 string
+	Name               string
+	LocationType       int
+	LevelID            string
+	WheelchairBoarding int
+}
+
+// Pathway links two locations of a station.
+type Pathway struct {
+	ID                   string
+	FromStopID           string
+	ToStopID             string
+	Mode                 int
+	IsBidirectional      bool
+	SignpostedAs         string
+	ReversedSignpostedAs string
+}
+
+// Level is a floor of a station.
+type Level struct {
+	ID    string
+	Index float64
+	Name  string
+}
+
+// Station is a station with the locations, pathways and levels inside it.
+type Station struct {
+	ID       string
+	Name     string
+	Nodes    []StationNode // In stops.txt order
+	Pathways []Pathway     // In pathways.txt order
+	Levels   map[string]Level
+}
+
+// Stations indexes the stations of a static feed. The go-gtfs parser leaves out pathways and
+// levels, and the locations without coordinates, so they are read from the feed directly.
+type Stations struct {
+	byID      map[string]*Station
+	stationOf map[string]string // Location and pathway IDs to the ID of their station
+}
+
+// Station returns the station with the given ID, or the station holding the location or
+// pathway with that ID.
+func (s *Stations) Station(id string) (*Station, bool) {
+	if s == nil {
+		return nil, false
+	}
+	if station, ok := s.byID[id]; ok {
+		return station, true
+	}
+	if stationID, ok := s.stationOf[id]; ok {
+		return s.byID[stationID], true
+	}
+	return nil, false
+}
+
+// parseStations reads the stations of the static feed in data. A feed without stops.txt has no
+// stations; pathways.txt and levels.txt are optional.
+func parseStations(data []byte) (*Stations, error) {
+	stations := &Stations{byID: map[string]*Station{}, stationOf: map[string]string{}}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return stations, fmt.Errorf("failed to open GTFS zip: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		files[path.Base(f.Name)] = f
+	}
+
+	stops, err := readFeedTable(files["stops.txt"])
+	if err != nil {
+		return stations, fmt.Errorf("failed to read stops.txt: %w", err)
+	}
+	parents := make(map[string]string)
+	boarding := make(map[string]int)
+	var nodes []StationNode
+	for _, row := range stops {
+		node := StationNode{
+			ID:                 row["stop_id"],
+			Name:               row["stop_name"],
+			LevelID:            row["level_id"],
+			LocationType:       atoiOrZero(row["location_type"]),
+			WheelchairBoarding: atoiOrZero(row["wheelchair_boarding"]),
+		}
+		if node.ID == "" {
+			continue
+		}
+		boarding[node.ID] = node.WheelchairBoarding
+		if node.LocationType == LocationTypeStation {
+			stations.byID[node.ID] = &Station{ID: node.ID, Name: node.Name, Levels: map[string]Level{}}
+			continue
+		}
+		if parent := row["parent_station"]; parent != "" {
+			parents[node.ID] = parent
+			nodes = append(nodes, node)
+		}
+	}
+
+	// Boarding areas belong to a platform, which belongs to the station
+	for _, node := range nodes {
+		// Locations that leave wheelchair_boarding unspecified inherit it from their parents
+		parent := parents[node.ID]
+		for depth := 0; depth < 3 && node.WheelchairBoarding == 0 && parent != ""; depth++ {
+			node.WheelchairBoarding = boarding[parent]
+			parent = parents[parent]
+		}
+
+		stationID := parents[node.ID]
+		for depth := 0; depth < 3 && stations.byID[stationID] == nil && stationID != ""; depth++ {
+			stationID = parents[stationID]
+		}
+		station := stations.byID[stationID]
+		if station == nil {
+			continue
+		}
+		station.Nodes = append(station.Nodes, node)
+		stations.stationOf[node.ID] = station.ID
+	}
+
+	levelRows, err := readFeedTable(files["levels.txt"])
+	if err != nil {
+		return stations, fmt.Errorf("failed to read levels.txt: %w", err)
+	}
+	levels := make(map[string]Level, len(levelRows))
+	for _, row := range levelRows {
+		index, _ := strconv.ParseFloat(row["level_index"], 64)
+		levels[row["level_id"]] = Level{ID: row["level_id"], Index: index, Name: row["level_name"]}
+	}
+	for _, station := range stations.byID {
+		for _, node := range station.Nodes {
+			if level, ok := levels[node.LevelID]; ok {
+				station.Levels[level.ID] = level
+			}
+		}
+	}
+
+	pathways, err := readFeedTable(files["pathways.txt"])
+	if err != nil {
+		return stations, fmt.Errorf("failed to read pathways.txt: %w", err)
+	}
+	for _, row := range pathways {
+		pathway := Pathway{
+			ID:                   row["pathway_id"],
+			FromStopID:           row["from_stop_id"],
+			ToStopID:             row["to_stop_id"],
+			Mode:                 atoiOrZero(row["pathway_mode"]),
+			IsBidirectional:      row["is_bidirectional"] == "1",
+			SignpostedAs:         row["signposted_as"],
+			ReversedSignpostedAs: row["reversed_signposted_as"],
+		}
+		stationID, ok := stations.stationOf[pathway.FromStopID]
+		if !ok {
+			stationID, ok = stations.stationOf[pathway.ToStopID]
+		}
+		if !ok || pathway.ID == "" {
+			continue
+		}
+		station := stations.byID[stationID]
+		station.Pathways = append(station.Pathways, pathway)
+		stations.stationOf[pathway.ID] = stationID
+	}
+
+	return stations, nil
+}
+
+// readFeedTable reads the rows of a feed file, keyed by column name. A missing file has no rows.
+func readFeedTable(f *zip.File) ([]map[string]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	reader := csv.NewReader(rc)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// FacilityOutages maps the alerts active at now onto the station. An alert naming a pathway
+// puts it out of service; one naming an entrance or generic node puts that node out of service,
+// with the elevators and escalators reaching it. outages is keyed by pathway or node ID and
+// lists the IDs of the alerts; stationAlerts lists the accessibility alerts naming the station
+// or one of its platforms.
+func (station *Station) FacilityOutages(alerts []gtfs.Alert, now time.Time) (outages map[string][]string, stationAlerts []string) {
+	nodes := make(map[string]StationNode, len(station.Nodes))
+	for _, node := range station.Nodes {
+		nodes[node.ID] = node
+	}
+	pathways := make(map[string]bool, len(station.Pathways))
+	for _, pathway := range station.Pathways {
+		pathways[pathway.ID] = true
+	}
+
+	outages = make(map[string][]string)
+	mark := func(id, alertID string) {
+		for _, existing := range outages[id] {
+			if existing == alertID {
+				return
+			}
+		}
+		outages[id] = append(outages[id], alertID)
+	}
+
+	for _, alert := range alerts {
+		if !alertActiveAt(alert, now) {
+			continue
+		}
+		stationWide := false
+		for _, entity := range alert.InformedEntities {
+			if entity.StopID == nil {
+				continue
+			}
+			id := *entity.StopID
+			if pathways[id] {
+				mark(id, alert.ID)
+				continue
+			}
+			node, isNode := nodes[id]
+			switch {
+			case isNode && (node.LocationType == LocationTypeEntrance || node.LocationType == LocationTypeGenericNode):
+				mark(id, alert.ID)
+				for _, pathway := range station.Pathways {
+					if (pathway.Mode == PathwayModeElevator || pathway.Mode == PathwayModeEscalator) &&
+						(pathway.FromStopID == id || pathway.ToStopID == id) {
+						mark(pathway.ID, alert.ID)
+					}
+				}
+			case id == station.ID || isNode:
+				stationWide = true
+			}
+		}
+		if stationWide && alert.Effect == gtfs.AccessibilityIssue {
+			stationAlerts = append(stationAlerts, alert.ID)
+		}
+	}
+	return outages, stationAlerts
+}
+
+// alertActiveAt reports whether one of the alert's active periods covers t. An alert without
+// active periods is always active.
+func alertActiveAt(alert gtfs.Alert, t time.Time) bool {
+	if len(alert.ActivePeriods) == 0 {
+		return true
+	}
+	for _, period := range alert.ActivePeriods {
+		if period.StartsAt != nil && t.Before(*period.StartsAt) {
+			continue
+		}
+		if period.EndsAt != nil && !t.Before(*period.EndsAt) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// GetStation returns the station with the given ID, or the station holding the location or
+// pathway with that ID.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetStation(id string) (*Station, bool) {
+	return manager.stations.Station(id)
+}
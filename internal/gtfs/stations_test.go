@@ -0,0 +1,100 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stationFeed(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"feed/stops.txt": "\ufeffstop_id,stop_name,stop_lat,stop_lon,location_type,parent_station,level_id,wheelchair_boarding\n" +
+			"STN,Central,47.0,-122.0,1,,,1\n" +
+			"P1,Central Platform 1,47.0,-122.0,0,STN,L-1,1\n" +
+			"BA1,Platform 1 Car 1,,,4,P1,L-1,\n" +
+			"E1,Main Street Entrance,47.0,-122.0,2,STN,L0,1\n" +
+			"E2,Side Entrance,47.0,-122.0,2,STN,L0,2\n" +
+			"N1,Mezzanine,,,3,STN,L-1,\n" +
+			"S1,Street Stop,47.1,-122.1,0,,,\n",
+		"feed/levels.txt": "level_id,level_index,level_name\nL0,0,Street\nL-1,-1,Concourse\n",
+		"feed/pathways.txt": "pathway_id,from_stop_id,to_stop_id,pathway_mode,is_bidirectional,signposted_as\n" +
+			"EL1,E1,N1,5,1,Elevator to concourse\n" +
+			"ES1,E2,N1,4,0,\n" +
+			"W1,N1,P1,1,1,\n" +
+			"X1,S1,S1,1,1,\n",
+	} {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseStations(t *testing.T) {
+	stations, err := parseStations(stationFeed(t))
+	require.NoError(t, err)
+
+	station, ok := stations.Station("STN")
+	require.True(t, ok)
+	assert.Equal(t, "Central", station.Name)
+	assert.Len(t, station.Nodes, 5)
+	assert.Len(t, station.Pathways, 3, "pathways outside stations are left out")
+	assert.Equal(t, Level{ID: "L-1", Index: -1, Name: "Concourse"}, station.Levels["L-1"])
+
+	boarding := make(map[string]int)
+	for _, node := range station.Nodes {
+		boarding[node.ID] = node.WheelchairBoarding
+	}
+	assert.Equal(t, 2, boarding["E2"])
+	assert.Equal(t, 1, boarding["BA1"], "boarding area inherits from its platform")
+	assert.Equal(t, 1, boarding["N1"], "node inherits from its station")
+
+	byBoardingArea, ok := stations.Station("BA1")
+	require.True(t, ok)
+	assert.Same(t, station, byBoardingArea)
+	byPathway, ok := stations.Station("EL1")
+	require.True(t, ok)
+	assert.Same(t, station, byPathway)
+
+	_, ok = stations.Station("S1")
+	assert.False(t, ok)
+
+	_, err = parseStations([]byte("not a zip"))
+	assert.Error(t, err)
+}
+
+func TestStation_FacilityOutages(t *testing.T) {
+	stations, err := parseStations(stationFeed(t))
+	require.NoError(t, err)
+	station, _ := stations.Station("STN")
+
+	id := func(s string) *string { return &s }
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	alerts := []gtfs.Alert{
+		{ID: "elevator", Effect: gtfs.AccessibilityIssue, InformedEntities: []gtfs.AlertInformedEntity{{StopID: id("EL1")}}},
+		{ID: "entrance", Effect: gtfs.NoService, InformedEntities: []gtfs.AlertInformedEntity{{StopID: id("E2")}}},
+		{ID: "station", Effect: gtfs.AccessibilityIssue, InformedEntities: []gtfs.AlertInformedEntity{{StopID: id("STN")}}},
+		{ID: "platform-detour", Effect: gtfs.Detour, InformedEntities: []gtfs.AlertInformedEntity{{StopID: id("P1")}}},
+		{ID: "ended", ActivePeriods: []gtfs.AlertActivePeriod{{EndsAt: &past}},
+			InformedEntities: []gtfs.AlertInformedEntity{{StopID: id("W1")}}},
+	}
+
+	outages, stationAlerts := station.FacilityOutages(alerts, now)
+	assert.Equal(t, map[string][]string{
+		"EL1": {"elevator"},
+		"E2":  {"entrance"},
+		"ES1": {"entrance"},
+	}, outages)
+	assert.Equal(t, []string{"station"}, stationAlerts)
+}
@@ -0,0 +1,256 @@
+package gtfs
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/twpayne/go-polyline"
+	"google.golang.org/protobuf/proto"
+)
+
+// TripModification is how a GTFS-RT TripModifications entity changes one of its selected trips,
+// usually to send it around a detour.
+type TripModification struct {
+	ID            string // ID of the feed entity
+	TripID        string
+	ShapeID       string   // Realtime shape the trip follows instead of its own; empty when unchanged
+	ServiceDates  []string // YYYYMMDD
+	Modifications []StopModification
+}
+
+// StopModification replaces a run of a trip's stops, from the start stop to the end stop
+// inclusive, with other stops.
+type StopModification struct {
+	Start            StopSelector
+	End              *StopSelector // nil when the replacement stops are added before Start without removing any
+	ReplacementStops []ReplacementStop
+	PropagatedDelay  time.Duration // Added to the times of every stop after the modification
+	ServiceAlertID   string
+}
+
+// StopSelector picks a stop of a trip by its stop sequence or, without one, its stop ID.
+type StopSelector struct {
+	StopSequence *uint32
+	StopID       string
+}
+
+func (s StopSelector) matches(stop ScheduledStop) bool {
+	if s.StopSequence != nil {
+		return *s.StopSequence == stop.StopSequence
+	}
+	return s.StopID != "" && s.StopID == stop.StopID
+}
+
+// ReplacementStop is a stop a modified trip serves instead of its scheduled ones.
+type ReplacementStop struct {
+	StopID           string
+	TravelTimeToStop time.Duration // From the arrival at the stop before the modification
+}
+
+// ActiveOn reports whether the modification applies on the service date, YYYYMMDD.
+func (m TripModification) ActiveOn(serviceDate string) bool {
+	return slices.Contains(m.ServiceDates, serviceDate)
+}
+
+// ScheduledStop is a stop of a trip with its scheduled times since the start of the service day.
+type ScheduledStop struct {
+	StopID        string
+	StopSequence  uint32
+	ArrivalTime   time.Duration
+	DepartureTime time.Duration
+}
+
+// ModifiedStop is a stop of a modified trip.
+type ModifiedStop struct {
+	ScheduledStop
+	Replacement    bool   // Served instead of scheduled stops; its stop sequence is that of the first stop it replaces
+	ServiceAlertID string // Of the modification a replacement stop belongs to
+}
+
+// Apply returns the trip's stops, given in stop sequence order, as the modification changes them.
+// Replacement stops are timed from the arrival at the stop before their modification, and the
+// stops after a modification are delayed by its propagated delay. A modification whose stops are
+// not found is ignored.
+func (m TripModification) Apply(stops []ScheduledStop) []ModifiedStop {
+	modified := make([]ModifiedStop, 0, len(stops))
+	var delay time.Duration
+	for i := 0; i < len(stops); {
+		mod, end, ok := m.startingAt(stops, i)
+		if !ok {
+			stop := stops[i]
+			stop.ArrivalTime += delay
+			stop.DepartureTime += delay
+			modified = append(modified, ModifiedStop{ScheduledStop: stop})
+			i++
+			continue
+		}
+
+		// The stop before the modification, or the first stop when it starts there
+		reference := stops[0].ArrivalTime + delay
+		if i > 0 {
+			reference = stops[i-1].ArrivalTime + delay
+		}
+		for _, replacement := range mod.ReplacementStops {
+			at := reference + replacement.TravelTimeToStop
+			modified = append(modified, ModifiedStop{
+				ScheduledStop: ScheduledStop{
+					StopID:        replacement.StopID,
+					StopSequence:  stops[i].StopSequence,
+					ArrivalTime:   at,
+					DepartureTime: at,
+				},
+				Replacement:    true,
+				ServiceAlertID: mod.ServiceAlertID,
+			})
+		}
+		delay += mod.PropagatedDelay
+		if end > i {
+			i = end
+			continue
+		}
+		// Nothing was removed, so the stop the replacement stops come before is still served
+		stop := stops[i]
+		stop.ArrivalTime += delay
+		stop.DepartureTime += delay
+		modified = append(modified, ModifiedStop{ScheduledStop: stop})
+		i++
+	}
+	return modified
+}
+
+// startingAt finds the modification starting at stops[i], and the index of the first stop after
+// the stops it removes; that is i when it removes none.
+func (m TripModification) startingAt(stops []ScheduledStop, i int) (StopModification, int, bool) {
+	for _, mod := range m.Modifications {
+		if !mod.Start.matches(stops[i]) {
+			continue
+		}
+		if mod.End == nil {
+			return mod, i, true
+		}
+		for j := i; j < len(stops); j++ {
+			if mod.End.matches(stops[j]) {
+				return mod, j + 1, true
+			}
+		}
+	}
+	return StopModification{}, 0, false
+}
+
+// parseTripModifications reads the TripModifications and Shape entities of a GTFS-RT feed, which
+// the go-gtfs parser leaves out. Modifications are keyed by trip ID and shapes, as [lat, lon]
+// points, by shape ID.
+func parseTripModifications(body []byte) (map[string][]TripModification, map[string][][]float64, error) {
+	var feed gtfsrt.FeedMessage
+	if err := proto.Unmarshal(body, &feed); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse GTFS-RT feed: %w", err)
+	}
+
+	modifications := make(map[string][]TripModification)
+	shapes := make(map[string][][]float64)
+	for _, entity := range feed.GetEntity() {
+		if entity.GetIsDeleted() {
+			continue
+		}
+		if shape := entity.GetShape(); shape != nil && shape.GetShapeId() != "" {
+			points, _, err := polyline.DecodeCoords([]byte(shape.GetEncodedPolyline()))
+			if err == nil && len(points) >= 2 {
+				shapes[shape.GetShapeId()] = points
+			}
+		}
+
+		tm := entity.GetTripModifications()
+		if tm == nil {
+			continue
+		}
+		mods := make([]StopModification, 0, len(tm.GetModifications()))
+		for _, m := range tm.GetModifications() {
+			mod := StopModification{
+				Start:           stopSelector(m.GetStartStopSelector()),
+				PropagatedDelay: time.Duration(m.GetPropagatedModificationDelay()) * time.Second,
+				ServiceAlertID:  m.GetServiceAlertId(),
+			}
+			if m.GetEndStopSelector() != nil {
+				end := stopSelector(m.GetEndStopSelector())
+				mod.End = &end
+			}
+			for _, replacement := range m.GetReplacementStops() {
+				mod.ReplacementStops = append(mod.ReplacementStops, ReplacementStop{
+					StopID:           replacement.GetStopId(),
+					TravelTimeToStop: time.Duration(replacement.GetTravelTimeToStop()) * time.Second,
+				})
+			}
+			mods = append(mods, mod)
+		}
+		for _, selected := range tm.GetSelectedTrips() {
+			for _, tripID := range selected.GetTripIds() {
+				modifications[tripID] = append(modifications[tripID], TripModification{
+					ID:            entity.GetId(),
+					TripID:        tripID,
+					ShapeID:       selected.GetShapeId(),
+					ServiceDates:  tm.GetServiceDates(),
+					Modifications: mods,
+				})
+			}
+		}
+	}
+	return modifications, shapes, nil
+}
+
+func stopSelector(s *gtfsrt.StopSelector) StopSelector {
+	selector := StopSelector{StopID: s.GetStopId()}
+	if s != nil && s.StopSequence != nil {
+		sequence := s.GetStopSequence()
+		selector.StopSequence = &sequence
+	}
+	return selector
+}
+
+// GetTripModification returns the modification of the trip on the service date, YYYYMMDD.
+func (manager *Manager) GetTripModification(tripID, serviceDate string) (TripModification, bool) {
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+	for _, m := range manager.realTimeTripModifications[tripID] {
+		if m.ActiveOn(serviceDate) {
+			return m, true
+		}
+	}
+	return TripModification{}, false
+}
+
+// GetTripModificationsServingStop returns the modifications on the service date that make
+// their trips serve the stop as a replacement stop.
+func (manager *Manager) GetTripModificationsServingStop(stopID, serviceDate string) []TripModification {
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+	var serving []TripModification
+	for _, modifications := range manager.realTimeTripModifications {
+		for _, m := range modifications {
+			if m.ActiveOn(serviceDate) && m.replaces(stopID) {
+				serving = append(serving, m)
+			}
+		}
+	}
+	return serving
+}
+
+func (m TripModification) replaces(stopID string) bool {
+	for _, mod := range m.Modifications {
+		for _, replacement := range mod.ReplacementStops {
+			if replacement.StopID == stopID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetRealtimeShape returns the [lat, lon] points of a shape published in the trip updates feed.
+func (manager *Manager) GetRealtimeShape(shapeID string) ([][]float64, bool) {
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+	points, ok := manager.realTimeShapes[shapeID]
+	return points, ok
+}
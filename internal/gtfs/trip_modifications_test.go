@@ -0,0 +1,101 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func tripModificationsFeed(t *testing.T) []byte {
+	t.Helper()
+	feed := &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{GtfsRealtimeVersion: proto.String("2.0")},
+		Entity: []*gtfsrt.FeedEntity{
+			{
+				Id: proto.String("detour-1"),
+				TripModifications: &gtfsrt.TripModifications{
+					SelectedTrips: []*gtfsrt.TripModifications_SelectedTrips{
+						{TripIds: []string{"T1", "T2"}, ShapeId: proto.String("detour-shape")},
+					},
+					ServiceDates: []string{"20250610"},
+					Modifications: []*gtfsrt.TripModifications_Modification{{
+						StartStopSelector:           &gtfsrt.StopSelector{StopSequence: proto.Uint32(2)},
+						EndStopSelector:             &gtfsrt.StopSelector{StopId: proto.String("S3")},
+						PropagatedModificationDelay: proto.Int32(120),
+						ServiceAlertId:              proto.String("alert-1"),
+						ReplacementStops: []*gtfsrt.ReplacementStop{
+							{StopId: proto.String("R1"), TravelTimeToStop: proto.Int32(180)},
+							{StopId: proto.String("R2"), TravelTimeToStop: proto.Int32(300)},
+						},
+					}},
+				},
+			},
+			{
+				Id:    proto.String("shape-entity"),
+				Shape: &gtfsrt.Shape{ShapeId: proto.String("detour-shape"), EncodedPolyline: proto.String("_p~iF~ps|U_ulLnnqC_mqNvxq`@")},
+			},
+		},
+	}
+	body, err := proto.Marshal(feed)
+	require.NoError(t, err)
+	return body
+}
+
+func TestParseTripModifications(t *testing.T) {
+	modifications, shapes, err := parseTripModifications(tripModificationsFeed(t))
+	require.NoError(t, err)
+
+	require.Len(t, modifications["T2"], 1)
+	m := modifications["T1"][0]
+	assert.Equal(t, "detour-1", m.ID)
+	assert.Equal(t, "detour-shape", m.ShapeID)
+	assert.True(t, m.ActiveOn("20250610"))
+	assert.False(t, m.ActiveOn("20250611"))
+	require.Len(t, m.Modifications, 1)
+	assert.Equal(t, uint32(2), *m.Modifications[0].Start.StopSequence)
+	assert.Equal(t, "S3", m.Modifications[0].End.StopID)
+
+	require.Len(t, shapes["detour-shape"], 3)
+	assert.InDelta(t, 38.5, shapes["detour-shape"][0][0], 1e-6)
+
+	_, _, err = parseTripModifications([]byte("not a feed"))
+	assert.Error(t, err)
+}
+
+func TestTripModification_Apply(t *testing.T) {
+	modifications, _, err := parseTripModifications(tripModificationsFeed(t))
+	require.NoError(t, err)
+	m := modifications["T1"][0]
+
+	minutes := func(n int) time.Duration { return time.Duration(n) * time.Minute }
+	stops := []ScheduledStop{
+		{StopID: "S1", StopSequence: 1, ArrivalTime: minutes(600), DepartureTime: minutes(600)},
+		{StopID: "S2", StopSequence: 2, ArrivalTime: minutes(605), DepartureTime: minutes(605)},
+		{StopID: "S3", StopSequence: 3, ArrivalTime: minutes(610), DepartureTime: minutes(610)},
+		{StopID: "S4", StopSequence: 4, ArrivalTime: minutes(615), DepartureTime: minutes(616)},
+	}
+
+	modified := m.Apply(stops)
+	require.Len(t, modified, 4)
+	assert.Equal(t, "S1", modified[0].StopID)
+	assert.Equal(t, ModifiedStop{
+		ScheduledStop:  ScheduledStop{StopID: "R1", StopSequence: 2, ArrivalTime: minutes(603), DepartureTime: minutes(603)},
+		Replacement:    true,
+		ServiceAlertID: "alert-1",
+	}, modified[1])
+	assert.Equal(t, minutes(605), modified[2].ArrivalTime)
+	assert.Equal(t, ModifiedStop{ScheduledStop: ScheduledStop{StopID: "S4", StopSequence: 4, ArrivalTime: minutes(617), DepartureTime: minutes(618)}}, modified[3])
+
+	// Replacement stops added without removing any come before the start stop
+	m.Modifications[0].End = nil
+	modified = m.Apply(stops)
+	require.Len(t, modified, 6)
+	assert.Equal(t, []string{"S1", "R1", "R2", "S2", "S3", "S4"}, []string{
+		modified[0].StopID, modified[1].StopID, modified[2].StopID, modified[3].StopID, modified[4].StopID, modified[5].StopID,
+	})
+	assert.Equal(t, minutes(607), modified[3].ArrivalTime)
+}
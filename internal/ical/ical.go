@@ -0,0 +1,138 @@
+// Package ical writes iCalendar (RFC 5545) feeds of scheduled events, so a schedule can be
+// downloaded into, or subscribed to from, standard calendar tools. Only the properties maglev
+// needs are supported. Times are written in UTC, which spares the feed a VTIMEZONE component.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MediaType is the Content-Type of an iCalendar feed.
+const MediaType = "text/calendar; charset=utf-8"
+
+// maxLineOctets is the longest content line RFC 5545 allows before folding.
+const maxLineOctets = 75
+
+const utcFormat = "20060102T150405Z"
+
+// Calendar is a VCALENDAR holding one feed's events.
+type Calendar struct {
+	ProdID string // Identifies the producer, such as "-//OneBusAway//Maglev//EN"
+	Name   string // Display name, for clients that read X-WR-CALNAME
+	// RefreshInterval tells subscribing clients how often to fetch the feed again. Zero omits it.
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is a VEVENT.
+type Event struct {
+	UID         string // Globally unique and stable across fetches, so clients update the event in place
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	// Latitude and Longitude are written as GEO when HasGeo is set.
+	Latitude  float64
+	Longitude float64
+	HasGeo    bool
+}
+
+// Write encodes the calendar to w. stamp is the DTSTAMP of every event, normally the time the
+// feed was generated.
+func (c *Calendar) Write(w io.Writer, stamp time.Time) error {
+	bw := bufio.NewWriter(w)
+	e := &encoder{w: bw}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", escape(c.ProdID))
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		e.line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		e.line("REFRESH-INTERVAL;VALUE=DURATION", duration(c.RefreshInterval))
+		e.line("X-PUBLISHED-TTL", duration(c.RefreshInterval))
+	}
+	for _, event := range c.Events {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", escape(event.UID))
+		e.line("DTSTAMP", stamp.UTC().Format(utcFormat))
+		e.line("DTSTART", event.Start.UTC().Format(utcFormat))
+		e.line("DTEND", event.End.UTC().Format(utcFormat))
+		e.line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			e.line("DESCRIPTION", escape(event.Description))
+		}
+		if event.Location != "" {
+			e.line("LOCATION", escape(event.Location))
+		}
+		if event.HasGeo {
+			e.line("GEO", fmt.Sprintf("%.6f;%.6f", event.Latitude, event.Longitude))
+		}
+		e.line("TRANSP", "TRANSPARENT")
+		e.line("END", "VEVENT")
+	}
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// encoder writes folded content lines, keeping the first write error.
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes "name:value", folding it into continuation lines that start with a space when
+// it is longer than 75 octets. A fold never splits a UTF-8 sequence.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	content := name + ":" + value
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		if _, e.err = e.w.WriteString(content[:cut] + "\r\n "); e.err != nil {
+			return
+		}
+		content = content[cut:]
+		// The leading space of a continuation line counts toward its length
+		limit = maxLineOctets - 1
+	}
+	_, e.err = e.w.WriteString(content + "\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escape encodes a TEXT value.
+func escape(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// duration formats d as an RFC 5545 duration, such as PT1H or P1D.
+func duration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	if seconds%86400 == 0 {
+		return fmt.Sprintf("P%dD", seconds/86400)
+	}
+	if seconds%3600 == 0 {
+		return fmt.Sprintf("PT%dH", seconds/3600)
+	}
+	if seconds%60 == 0 {
+		return fmt.Sprintf("PT%dM", seconds/60)
+	}
+	return fmt.Sprintf("PT%dS", seconds)
+}
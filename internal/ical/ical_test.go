@@ -0,0 +1,77 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	pacific, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	start := time.Date(2025, 6, 12, 8, 15, 0, 0, pacific)
+	calendar := &Calendar{
+		ProdID:          "-//OneBusAway//Maglev//EN",
+		Name:            "Stop 1_100, Pine St; 3rd Ave",
+		RefreshInterval: 24 * time.Hour,
+		Events: []Event{{
+			UID:         "20250612-1_t1-1_100@maglev.onebusaway.org",
+			Start:       start,
+			End:         start.Add(time.Minute),
+			Summary:     "10 to Downtown, Seattle",
+			Description: "Trip 1_t1\nStop 1_100",
+			Location:    "Pine St & 3rd Ave",
+			Latitude:    47.6105,
+			Longitude:   -122.3381,
+			HasGeo:      true,
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, calendar.Write(&buf, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, out, "X-WR-CALNAME:Stop 1_100\\, Pine St\\; 3rd Ave\r\n")
+	assert.Contains(t, out, "REFRESH-INTERVAL;VALUE=DURATION:P1D\r\n")
+	assert.Contains(t, out, "DTSTAMP:20250601T000000Z\r\n")
+	assert.Contains(t, out, "DTSTART:20250612T151500Z\r\n", "times are converted to UTC")
+	assert.Contains(t, out, "DTEND:20250612T151600Z\r\n")
+	assert.Contains(t, out, "SUMMARY:10 to Downtown\\, Seattle\r\n")
+	assert.Contains(t, out, "DESCRIPTION:Trip 1_t1\\nStop 1_100\r\n")
+	assert.Contains(t, out, "GEO:47.610500;-122.338100\r\n")
+}
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	summary := strings.Repeat("Straße ", 30)
+	calendar := &Calendar{ProdID: "-//test//EN", Events: []Event{{UID: "1", Summary: summary}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, calendar.Write(&buf, time.Now()))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	var unfolded strings.Builder
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), 75)
+		assert.True(t, utf8.ValidString(line), "a fold must not split a character")
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	assert.Contains(t, unfolded.String(), "\nSUMMARY:"+summary+"\n")
+}
+
+func TestDuration(t *testing.T) {
+	assert.Equal(t, "P1D", duration(24*time.Hour))
+	assert.Equal(t, "PT6H", duration(6*time.Hour))
+	assert.Equal(t, "PT90M", duration(90*time.Minute))
+	assert.Equal(t, "PT45S", duration(45*time.Second))
+}
@@ -0,0 +1,137 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rotatedSuffixFormat names rotated files after the time they were rotated, so they sort
+// oldest first.
+const rotatedSuffixFormat = "20060102T150405.000"
+
+// RotatingFile is a log file that is renamed aside and replaced once it reaches a size or age
+// limit. It is safe for concurrent writes.
+type RotatingFile struct {
+	path       string
+	maxSize    int64         // Bytes; 0 disables size-based rotation
+	interval   time.Duration // 0 disables time-based rotation
+	maxBackups int           // Rotated files kept; 0 keeps them all
+	now        func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// OpenRotatingFile opens path for appending, creating it if needed. The file is rotated before a
+// write would take it past maxSize bytes, or once interval has passed since it was opened.
+func OpenRotatingFile(path string, maxSize int64, interval time.Duration, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		interval:   interval,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+// Write appends p to the file, rotating it first if p would exceed a limit.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) shouldRotate(incoming int64) bool {
+	// An empty file is never rotated, even if a single write is larger than maxSize
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+incoming > f.maxSize {
+		return true
+	}
+	return f.interval > 0 && f.now().Sub(f.openedAt) >= f.interval
+}
+
+// rotate renames the current file aside, opens a new one and removes backups beyond maxBackups.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file for rotation: %w", err)
+	}
+	f.file = nil
+
+	rotated := f.path + "." + f.now().UTC().Format(rotatedSuffixFormat)
+	if err := os.Rename(f.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.removeOldBackups()
+}
+
+func (f *RotatingFile) removeOldBackups() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+	backups, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+	sort.Strings(backups)
+	for len(backups) > f.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("failed to remove old log file: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Close closes the current file. Later writes fail with os.ErrClosed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile_Size(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maglev.log")
+	f, err := OpenRotatingFile(path, 10, 0, 2)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fourth\n", string(current))
+
+	// Only the two newest backups are kept
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	oldest, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(oldest))
+}
+
+func TestRotatingFile_Interval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maglev.log")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0o644))
+
+	f, err := OpenRotatingFile(path, 0, time.Hour, 0)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	f.openedAt = now
+
+	_, err = f.Write([]byte("same hour\n"))
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	_, err = f.Write([]byte("next hour\n"))
+	require.NoError(t, err)
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "next hour\n", string(current))
+	rotated, err := os.ReadFile(path + "." + now.Format(rotatedSuffixFormat))
+	require.NoError(t, err)
+	assert.Equal(t, "existing\nsame hour\n", string(rotated))
+}
+
+func TestRotatingFile_Closed(t *testing.T) {
+	f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "maglev.log"), 0, 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	_, err = f.Write([]byte("late\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
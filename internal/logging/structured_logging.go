@@ -19,6 +19,15 @@ func NewStructuredLogger(w io.Writer, level slog.Level) *slog.Logger {
 	return slog.New(handler)
 }
 
+// NewLogger creates a logger writing to w in format, "json" or "text" (the default).
+func NewLogger(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
 // LogError logs an error with structured context
 func LogError(logger *slog.Logger, message string, err error, attrs ...slog.Attr) {
 	if logger == nil {
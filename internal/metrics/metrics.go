@@ -3,7 +3,9 @@ package metrics
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"log/slog"
 	"sync"
 	"sync/atomic"
@@ -27,6 +29,13 @@ type Metrics struct {
 	DBConnectionsIdle  prometheus.Gauge
 	DBWaitSecondsTotal prometheus.Counter
 
+	// Rate limiter metrics. Per-key series are labelled with a fingerprint of the key, never
+	// the key itself, because /metrics is served without authentication.
+	RateLimitClients             prometheus.Gauge
+	RateLimitEvictionsTotal      prometheus.Counter
+	RateLimitThrottledTotal      *prometheus.CounterVec
+	RateLimitExemptRequestsTotal *prometheus.CounterVec
+
 	// logger for error reporting
 	logger *slog.Logger
 
@@ -86,6 +95,32 @@ func NewWithLogger(logger *slog.Logger) *Metrics {
 		Help: "Total time blocked waiting for a database connection",
 	})
 
+	rateLimitClients := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "maglev_rate_limit_clients",
+		Help: "Number of API keys with a rate limiter in memory",
+	})
+
+	rateLimitEvictionsTotal := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "maglev_rate_limit_evictions_total",
+		Help: "Total number of idle rate limiters removed by cleanup",
+	})
+
+	rateLimitThrottledTotal := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "maglev_rate_limit_throttled_total",
+			Help: "Total number of requests rejected by the rate limiter, by API key fingerprint",
+		},
+		[]string{"key"},
+	)
+
+	rateLimitExemptRequestsTotal := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "maglev_rate_limit_exempt_requests_total",
+			Help: "Total number of requests from rate-limit-exempt API keys, by API key fingerprint",
+		},
+		[]string{"key"},
+	)
+
 	// Register all metrics with the custom registry
 	registry.MustRegister(
 		httpRequestsTotal,
@@ -94,6 +129,10 @@ func NewWithLogger(logger *slog.Logger) *Metrics {
 		dbConnectionsInUse,
 		dbConnectionsIdle,
 		dbWaitSecondsTotal,
+		rateLimitClients,
+		rateLimitEvictionsTotal,
+		rateLimitThrottledTotal,
+		rateLimitExemptRequestsTotal,
 	)
 
 	return &Metrics{
@@ -104,10 +143,24 @@ func NewWithLogger(logger *slog.Logger) *Metrics {
 		DBConnectionsInUse:  dbConnectionsInUse,
 		DBConnectionsIdle:   dbConnectionsIdle,
 		DBWaitSecondsTotal:  dbWaitSecondsTotal,
-		logger:              logger,
+
+		RateLimitClients:             rateLimitClients,
+		RateLimitEvictionsTotal:      rateLimitEvictionsTotal,
+		RateLimitThrottledTotal:      rateLimitThrottledTotal,
+		RateLimitExemptRequestsTotal: rateLimitExemptRequestsTotal,
+
+		logger: logger,
 	}
 }
 
+// KeyFingerprint returns the label used for apiKey in per-key metrics: the first 12 hex
+// digits of its SHA-256 hash. Operators can compute it for a known key without the
+// metrics endpoint exposing the keys themselves.
+func KeyFingerprint(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:6])
+}
+
 // StartDBStatsCollector starts a goroutine that periodically collects database
 // connection pool statistics and updates the corresponding metrics.
 // The interval specifies how often to collect stats.
@@ -21,6 +21,16 @@ func TestNew(t *testing.T) {
 	assert.NotNil(t, m.DBConnectionsInUse)
 	assert.NotNil(t, m.DBConnectionsIdle)
 	assert.NotNil(t, m.DBWaitSecondsTotal)
+	assert.NotNil(t, m.RateLimitClients)
+	assert.NotNil(t, m.RateLimitEvictionsTotal)
+	assert.NotNil(t, m.RateLimitThrottledTotal)
+	assert.NotNil(t, m.RateLimitExemptRequestsTotal)
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	fingerprint := KeyFingerprint("test")
+	assert.Equal(t, "9f86d081884c", fingerprint)
+	assert.NotEqual(t, fingerprint, KeyFingerprint("TEST"))
 }
 
 func TestNewWithLogger(t *testing.T) {
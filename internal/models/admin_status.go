@@ -0,0 +1,38 @@
+package models
+
+// StaticDatasetStatus describes the static GTFS dataset currently being served.
+type StaticDatasetStatus struct {
+	LastUpdated  int64             `json:"lastUpdated"`
+	Updating     bool              `json:"updating"`
+	Healthy      bool              `json:"healthy"`
+	Agencies     int               `json:"agencies"`
+	Routes       int               `json:"routes"`
+	Stops        int               `json:"stops"`
+	Trips        int               `json:"trips"`
+	Degradations []FeedDegradation `json:"degradations"`
+}
+
+// FeedDegradation is a feature served only in part because the static feed lacks an optional
+// file, or had rows left out of the import.
+type FeedDegradation struct {
+	File   string `json:"file"`
+	Detail string `json:"detail"`
+}
+
+// RealtimeFeedStatus describes the most recent GTFS-RT data. LastUpdated is 0 if no refresh has succeeded.
+type RealtimeFeedStatus struct {
+	Enabled     bool  `json:"enabled"`
+	LastUpdated int64 `json:"lastUpdated"`
+	Updating    bool  `json:"updating"`
+	Trips       int   `json:"trips"`
+	Vehicles    int   `json:"vehicles"`
+	Alerts      int   `json:"alerts"`
+}
+
+// AdminStatus is the entry returned by the admin status endpoint.
+type AdminStatus struct {
+	Static               StaticDatasetStatus `json:"static"`
+	Realtime             RealtimeFeedStatus  `json:"realtime"`
+	ResponseCacheEntries int                 `json:"responseCacheEntries"`
+	Draining             bool                `json:"draining"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// AdminMapVehicle is one vehicle on the admin live vehicle map.
+type AdminMapVehicle struct {
+	ID             string   `json:"id"`
+	TripID         string   `json:"tripId,omitempty"`
+	RouteID        string   `json:"routeId,omitempty"`
+	RouteShortName string   `json:"routeShortName,omitempty"`
+	Lat            float64  `json:"lat"`
+	Lon            float64  `json:"lon"`
+	Bearing        *float64 `json:"bearing,omitempty"`
+	Timestamp      int64    `json:"timestamp"`   // Time of the vehicle's last report; 0 if the feed gave none
+	TripMatched    bool     `json:"tripMatched"` // The vehicle's trip is in the static dataset
+	Stale          bool     `json:"stale"`
+}
+
+// AdminVehicleSnapshot is one event of the admin vehicle stream. Vehicles without a
+// position are counted but not listed.
+type AdminVehicleSnapshot struct {
+	CurrentTime             int64             `json:"currentTime"`
+	RealtimeEnabled         bool              `json:"realtimeEnabled"`
+	RealtimeUpdated         int64             `json:"realtimeUpdated"`
+	RealtimeStale           bool              `json:"realtimeStale"`
+	Vehicles                []AdminMapVehicle `json:"vehicles"`
+	VehiclesWithoutPosition int               `json:"vehiclesWithoutPosition"`
+	StaleVehicleSeconds     int               `json:"staleVehicleSeconds"`
+}
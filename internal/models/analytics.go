@@ -0,0 +1,21 @@
+package models
+
+// AnalyticsReport summarizes anonymized API usage since a point in time.
+type AnalyticsReport struct {
+	Since     int64           `json:"since"`
+	Hourly    []HourlyTraffic `json:"hourly"`
+	Endpoints []RequestCount  `json:"endpoints"`
+	TopStops  []RequestCount  `json:"topStops"`
+}
+
+// HourlyTraffic is the number of requests served in the hour starting at Hour.
+type HourlyTraffic struct {
+	Hour     int64 `json:"hour"`
+	Requests int64 `json:"requests"`
+}
+
+// RequestCount is the number of requests for an endpoint or stop.
+type RequestCount struct {
+	ID       string `json:"id"`
+	Requests int64  `json:"requests"`
+}
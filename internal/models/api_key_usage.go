@@ -0,0 +1,41 @@
+package models
+
+// EndpointUsage holds request counts for a single endpoint, broken down by HTTP status code.
+type EndpointUsage struct {
+	Endpoint      string         `json:"endpoint"`
+	TotalRequests int64          `json:"totalRequests"`
+	StatusCodes   map[string]int `json:"statusCodes"`
+}
+
+// APIKeyUsage summarizes the traffic recorded for a single API key.
+type APIKeyUsage struct {
+	Key           string          `json:"key"`
+	TotalRequests int64           `json:"totalRequests"`
+	FirstSeen     int64           `json:"firstSeen"`
+	LastSeen      int64           `json:"lastSeen"`
+	Endpoints     []EndpointUsage `json:"endpoints"`
+}
+
+// APIKeyUsageReport is the entry returned by the admin usage endpoint.
+type APIKeyUsageReport struct {
+	Since int64         `json:"since"`
+	Keys  []APIKeyUsage `json:"keys"`
+}
+
+// QuotaUsage is an API key's requests in the current UTC day and month, and its quota.
+// A zero limit is unlimited.
+type QuotaUsage struct {
+	Key             string `json:"key"`
+	DailyRequests   int64  `json:"dailyRequests"`
+	DailyLimit      int64  `json:"dailyLimit"`
+	MonthlyRequests int64  `json:"monthlyRequests"`
+	MonthlyLimit    int64  `json:"monthlyLimit"`
+}
+
+// QuotaUsageReport is the entry returned by the admin quotas endpoint. The resets are when
+// the current day and month end, in milliseconds since the epoch.
+type QuotaUsageReport struct {
+	DailyReset   int64        `json:"dailyReset"`
+	MonthlyReset int64        `json:"monthlyReset"`
+	Keys         []QuotaUsage `json:"keys"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// ArrivalNotification is an arrival notification subscription: CallbackURL is posted to once
+// TripID is predicted to be MinutesBefore minutes from StopID on ServiceDate. Times are Unix
+// milliseconds.
+type ArrivalNotification struct {
+	ID                   string `json:"id"`
+	StopID               string `json:"stopId"`
+	TripID               string `json:"tripId"`
+	ServiceDate          int64  `json:"serviceDate"`
+	StopSequence         int64  `json:"stopSequence"`
+	ScheduledArrivalTime int64  `json:"scheduledArrivalTime"`
+	MinutesBefore        int    `json:"minutesBefore"`
+	CallbackURL          string `json:"callbackUrl"`
+	CreatedTime          int64  `json:"createdTime"`
+	ExpiresTime          int64  `json:"expiresTime"`
+}
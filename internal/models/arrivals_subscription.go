@@ -0,0 +1,41 @@
+package models
+
+// ArrivalsSubscriptionRequest is a message from a client of the arrivals WebSocket. Action is
+// "subscribe" or "unsubscribe".
+type ArrivalsSubscriptionRequest struct {
+	Action  string   `json:"action"`
+	StopIDs []string `json:"stopIds"`
+}
+
+// SubscribedArrival is one upcoming arrival at a stop pushed over the arrivals WebSocket.
+// Times are in milliseconds since the epoch.
+type SubscribedArrival struct {
+	RouteID                string `json:"routeId"`
+	RouteShortName         string `json:"routeShortName,omitempty"`
+	TripID                 string `json:"tripId"`
+	TripHeadsign           string `json:"tripHeadsign,omitempty"`
+	ServiceDate            int64  `json:"serviceDate"`
+	StopSequence           int64  `json:"stopSequence"`
+	ScheduledArrivalTime   int64  `json:"scheduledArrivalTime"`
+	ScheduledDepartureTime int64  `json:"scheduledDepartureTime"`
+	Predicted              bool   `json:"predicted"`
+	PredictedArrivalTime   int64  `json:"predictedArrivalTime,omitempty"`
+	PredictedDepartureTime int64  `json:"predictedDepartureTime,omitempty"`
+	VehicleID              string `json:"vehicleId,omitempty"`
+}
+
+// ArrivalsSubscriptionMessage pushes a stop's upcoming arrivals to a client of the arrivals
+// WebSocket. Type is "arrivals".
+type ArrivalsSubscriptionMessage struct {
+	Type     string              `json:"type"`
+	StopID   string              `json:"stopId"`
+	Arrivals []SubscribedArrival `json:"arrivals"`
+}
+
+// ArrivalsSubscriptionError tells a client of the arrivals WebSocket that a request could not
+// be followed. Type is "error"; StopID is set when one stop was at fault.
+type ArrivalsSubscriptionError struct {
+	Type    string `json:"type"`
+	StopID  string `json:"stopId,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// AuditEntry is a recorded admin action, as listed by the audit endpoint.
+type AuditEntry struct {
+	ID     int64             `json:"id"`
+	Time   int64             `json:"time"`
+	Actor  string            `json:"actor"`
+	Action string            `json:"action"`
+	Params map[string]string `json:"params"`
+	Status int               `json:"status"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// BikeshareStation is a GBFS bikeshare station and its latest availability. Its ID combines
+// the configured system ID and the GBFS station ID, like an agency-prefixed stop ID.
+type BikeshareStation struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Lat              float64 `json:"lat"`
+	Lon              float64 `json:"lon"`
+	Capacity         int     `json:"capacity"`
+	BikesAvailable   int     `json:"bikesAvailable"`
+	DocksAvailable   int     `json:"docksAvailable"`
+	IsRenting        bool    `json:"isRenting"`
+	IsReturning      bool    `json:"isReturning"`
+	LastReportedTime int64   `json:"lastReportedTime"` // Unix milliseconds; 0 before the first status
+}
@@ -0,0 +1,9 @@
+package models
+
+// BlocklistEntry is a blocked API key or network, as listed by the admin blocklist endpoint.
+type BlocklistEntry struct {
+	Type    string `json:"type"` // "key" or "cidr"
+	Value   string `json:"value"`
+	Reason  string `json:"reason,omitempty"`
+	Created int64  `json:"created"`
+}
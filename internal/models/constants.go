@@ -26,4 +26,5 @@ const (
 	DefaultMaxCountForRoutes = 50
 	DefaultMaxCountForStops  = 100
 	MaxAllowedCount          = 250
+	MaxPageSize              = 1000 // Cap on offset/limit paginated lists
 )
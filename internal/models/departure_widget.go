@@ -0,0 +1,24 @@
+package models
+
+// WidgetDeparture is one departure on the stop departure widget.
+type WidgetDeparture struct {
+	RouteID            string `json:"routeId"`
+	RouteShortName     string `json:"routeShortName"`
+	RouteColor         string `json:"routeColor,omitempty"`
+	RouteTextColor     string `json:"routeTextColor,omitempty"`
+	TripID             string `json:"tripId"`
+	Headsign           string `json:"headsign"`
+	ScheduledDeparture int64  `json:"scheduledDepartureTime"`
+	DepartureTime      int64  `json:"departureTime"` // Predicted when Predicted is set, scheduled otherwise
+	Predicted          bool   `json:"predicted"`
+	MinutesAway        int    `json:"minutesAway"`
+}
+
+// DepartureWidgetEntry is the stop departure widget: the next departures from one stop.
+type DepartureWidgetEntry struct {
+	StopID         string            `json:"stopId"`
+	StopName       string            `json:"stopName"`
+	StopCode       string            `json:"stopCode,omitempty"`
+	RefreshSeconds int               `json:"refreshSeconds"` // 0 when the widget does not refresh
+	Departures     []WidgetDeparture `json:"departures"`
+}
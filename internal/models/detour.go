@@ -0,0 +1,13 @@
+package models
+
+// Detour is a temporary change to the path of one or more routes.
+type Detour struct {
+	ID             string     `json:"id"`
+	RouteIDs       []string   `json:"routeIds"`
+	StartTime      int64      `json:"startTime"` // Milliseconds since the epoch; 0 when open ended
+	EndTime        int64      `json:"endTime"`
+	Description    string     `json:"description"`
+	Polylines      []Polyline `json:"polylines"`      // The path driven; empty when only an alert describes the detour
+	SkippedStopIDs []string   `json:"skippedStopIds"` // Stops of the regular route the detour misses
+	SituationIDs   []string   `json:"situationIds"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// HeadwayAdherence summarizes how evenly spaced the archived arrivals of an agency, route or
+// stop were on frequent service over a range of service dates.
+type HeadwayAdherence struct {
+	ID                     string          `json:"id"`
+	StartDate              string          `json:"startDate"` // YYYY-MM-DD
+	EndDate                string          `json:"endDate"`
+	FrequentHeadwaySeconds int             `json:"frequentHeadwaySeconds"`
+	BunchedRatio           float64         `json:"bunchedRatio"`
+	GappedRatio            float64         `json:"gappedRatio"`
+	Summary                HeadwayStats    `json:"summary"`
+	ByDay                  []HeadwayBucket `json:"byDay"`   // Keyed by service date, YYYY-MM-DD
+	ByHour                 []HeadwayBucket `json:"byHour"`  // Keyed by scheduled hour of day, 00 to 23
+	ByRoute                []HeadwayBucket `json:"byRoute"` // Empty for a route
+	ByStop                 []HeadwayBucket `json:"byStop"`  // Only for a route
+}
+
+// HeadwayStats measures the spacing of arrivals against their scheduled headways.
+type HeadwayStats struct {
+	Headways                       int64   `json:"headways"`
+	AverageScheduledHeadwaySeconds float64 `json:"averageScheduledHeadwaySeconds"`
+	AverageObservedHeadwaySeconds  float64 `json:"averageObservedHeadwaySeconds"`
+	HeadwayDeviation               float64 `json:"headwayDeviation"` // Coefficient of variation of the headway deviations
+	Bunched                        int64   `json:"bunched"`
+	BunchedPercent                 float64 `json:"bunchedPercent"`
+	Gapped                         int64   `json:"gapped"`
+	GappedPercent                  float64 `json:"gappedPercent"`
+	ScheduledWaitSeconds           float64 `json:"scheduledWaitSeconds"`
+	ExcessWaitSeconds              float64 `json:"excessWaitSeconds"`
+}
+
+// HeadwayBucket is the headway adherence of one day, hour, route or stop.
+type HeadwayBucket struct {
+	ID string `json:"id"`
+	HeadwayStats
+}
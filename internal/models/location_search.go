@@ -0,0 +1,10 @@
+package models
+
+// LocationSearchResult is a geocoded address or landmark and the stops near it.
+type LocationSearchResult struct {
+	Name     string   `json:"name"`
+	Lat      float64  `json:"lat"`
+	Lon      float64  `json:"lon"`
+	StopIDs  []string `json:"stopIds"`  // Nearest first
+	RouteIDs []string `json:"routeIds"` // Serving those stops
+}
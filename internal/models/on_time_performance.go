@@ -0,0 +1,34 @@
+package models
+
+// OnTimePerformance summarizes how punctual the archived arrivals of an agency, route or stop
+// were over a range of service dates.
+type OnTimePerformance struct {
+	ID                    string         `json:"id"`
+	StartDate             string         `json:"startDate"` // YYYY-MM-DD
+	EndDate               string         `json:"endDate"`
+	EarlyThresholdSeconds int            `json:"earlyThresholdSeconds"`
+	LateThresholdSeconds  int            `json:"lateThresholdSeconds"`
+	Summary               OnTimeStats    `json:"summary"`
+	ByDay                 []OnTimeBucket `json:"byDay"`   // Keyed by service date, YYYY-MM-DD
+	ByHour                []OnTimeBucket `json:"byHour"`  // Keyed by scheduled hour of day, 00 to 23
+	ByRoute               []OnTimeBucket `json:"byRoute"` // Empty for a route
+	ByStop                []OnTimeBucket `json:"byStop"`  // Only for a route
+}
+
+// OnTimeStats counts arrivals by punctuality.
+type OnTimeStats struct {
+	Arrivals                      int64   `json:"arrivals"`
+	Early                         int64   `json:"early"`
+	OnTime                        int64   `json:"onTime"`
+	Late                          int64   `json:"late"`
+	OnTimePercent                 float64 `json:"onTimePercent"`
+	AverageDelaySeconds           float64 `json:"averageDelaySeconds"` // Negative when early on average
+	PredictedArrivals             int64   `json:"predictedArrivals"`
+	AveragePredictionErrorSeconds float64 `json:"averagePredictionErrorSeconds"`
+}
+
+// OnTimeBucket is the punctuality of one day, hour, route or stop.
+type OnTimeBucket struct {
+	ID string `json:"id"`
+	OnTimeStats
+}
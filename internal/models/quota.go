@@ -0,0 +1,9 @@
+package models
+
+// QuotaExceeded is the data section of a 429 response sent when an API key has used up
+// its daily or monthly quota.
+type QuotaExceeded struct {
+	Period    string `json:"period"`
+	Limit     int64  `json:"limit"`
+	ResetTime int64  `json:"resetTime"`
+}
@@ -8,6 +8,8 @@ type ReferencesModel struct {
 	StopTimes  []interface{}     `json:"stopTimes"`
 	Stops      []Stop            `json:"stops"`
 	Trips      []interface{}     `json:"trips"`
+
+	BikeshareStations []BikeshareStation `json:"bikeshareStations,omitempty"` // Only in stops-for-location, when GBFS feeds are configured
 }
 
 // NewEmptyReferences creates a new empty References model with initialized empty slices
@@ -11,6 +11,7 @@ type ResponseModel struct {
 	Data        interface{} `json:"data,omitempty"`
 	Text        string      `json:"text"`
 	Version     int         `json:"version"`
+	RequestID   string      `json:"requestId,omitempty"` // Set on error responses so clients can quote it to support
 }
 
 // NewOKResponse creates a successful response using the provided clock.
@@ -0,0 +1,44 @@
+package models
+
+// RidershipSummary totals the imported passenger counts of a route, stop or trip.
+type RidershipSummary struct {
+	ID        string            `json:"id"`
+	StartDate string            `json:"startDate,omitempty"` // YYYY-MM-DD; empty when every date is included
+	EndDate   string            `json:"endDate,omitempty"`
+	Summary   RidershipStats    `json:"summary"`
+	ByDay     []RidershipBucket `json:"byDay"`   // Keyed by service date, YYYY-MM-DD
+	ByRoute   []RidershipBucket `json:"byRoute"` // Only for a stop
+	ByStop    []RidershipBucket `json:"byStop"`  // Only for a route
+	ByTrip    []RidershipBucket `json:"byTrip"`  // Only for a route
+	Stops     []RidershipStop   `json:"stops"`   // Only for a trip, in stop sequence order
+}
+
+// RidershipStats totals passenger counts.
+type RidershipStats struct {
+	Boardings             int64   `json:"boardings"`
+	Alightings            int64   `json:"alightings"`
+	ServiceDays           int64   `json:"serviceDays"`
+	AverageDailyBoardings float64 `json:"averageDailyBoardings"`
+	MaxLoad               int64   `json:"maxLoad"`
+}
+
+// RidershipBucket is the ridership of one day, route, stop or trip.
+type RidershipBucket struct {
+	ID string `json:"id"`
+	RidershipStats
+}
+
+// RidershipStop is the ridership at one stop of a trip.
+type RidershipStop struct {
+	StopID       string  `json:"stopId"`
+	StopSequence int64   `json:"stopSequence"`
+	AverageLoad  float64 `json:"averageLoad"` // Passengers on leaving the stop
+	RidershipStats
+}
+
+// RidershipImport reports the rows of a GTFS-ride import.
+type RidershipImport struct {
+	Records        int64 `json:"records"`
+	Invalid        int64 `json:"invalid"`
+	UnmatchedTrips int64 `json:"unmatchedTrips"`
+}
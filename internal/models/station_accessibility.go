@@ -0,0 +1,27 @@
+package models
+
+// StationAccessibility lists the elevators, escalators and entrances of a station, and which of
+// them service alerts put out of service.
+type StationAccessibility struct {
+	StationID    string            `json:"stationId"`
+	Name         string            `json:"name"`
+	Elevators    []StationFacility `json:"elevators"`
+	Escalators   []StationFacility `json:"escalators"`
+	Entrances    []StationFacility `json:"entrances"`
+	SituationIDs []string          `json:"situationIds"` // Accessibility alerts about the station as a whole
+	StepFree     bool              `json:"stepFree"`     // At least one wheelchair accessible entrance is in service
+}
+
+// StationFacility is an elevator, escalator or entrance of a station.
+type StationFacility struct {
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	FromStopID         string   `json:"fromStopId,omitempty"` // Elevators and escalators only
+	ToStopID           string   `json:"toStopId,omitempty"`
+	FromLevel          string   `json:"fromLevel,omitempty"`
+	ToLevel            string   `json:"toLevel,omitempty"`
+	IsBidirectional    bool     `json:"isBidirectional,omitempty"`
+	WheelchairBoarding int      `json:"wheelchairBoarding,omitempty"` // Entrances only; 1 accessible, 2 not
+	OutOfService       bool     `json:"outOfService"`
+	SituationIDs       []string `json:"situationIds"`
+}
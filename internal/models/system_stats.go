@@ -0,0 +1,16 @@
+package models
+
+// SystemStats is the entry returned by the public stats endpoint. Service dates are
+// YYYY-MM-DD and empty when the dataset has no service; LastUpdated times are Unix
+// milliseconds, 0 if never.
+type SystemStats struct {
+	Agencies            int    `json:"agencies"`
+	Routes              int    `json:"routes"`
+	Stops               int    `json:"stops"`
+	TripsToday          int    `json:"tripsToday"`
+	Vehicles            int    `json:"vehicles"`
+	ServiceStartDate    string `json:"serviceStartDate"`
+	ServiceEndDate      string `json:"serviceEndDate"`
+	StaticLastUpdated   int64  `json:"staticLastUpdated"`
+	RealtimeLastUpdated int64  `json:"realtimeLastUpdated"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// VersionInfo is the entry returned by the version endpoint.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
@@ -0,0 +1,327 @@
+// Package notify keeps arrival notification subscriptions: a client asks to be told when a
+// trip is some minutes from a stop, and the manager checks each subscription against the
+// realtime predictions and posts a notification to its callback URL once the predicted arrival
+// comes within that lead time. Subscriptions live in memory and are lost on restart; each one
+// fires once and is removed, or is dropped when it expires.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// maxDeliveryAttempts is how many evaluations may fail to deliver a notification before its
+// subscription is dropped.
+const maxDeliveryAttempts = 3
+
+// deliveryTimeout bounds one callback request.
+const deliveryTimeout = 10 * time.Second
+
+// ErrLimitReached is returned by Add when the API key already holds its maximum number of
+// active subscriptions.
+var ErrLimitReached = errors.New("subscription limit reached for this API key")
+
+// Subscription asks for a notification when TripID is Lead from StopID on ServiceDate.
+type Subscription struct {
+	ID           string
+	APIKey       string
+	StopID       string // Combined agency_stop ID
+	TripID       string // Combined agency_trip ID
+	ServiceDate  time.Time
+	StopSequence int64
+	Scheduled    time.Time // Scheduled arrival of the trip at the stop
+	Lead         time.Duration
+	CallbackURL  string
+	Created      time.Time
+	Expires      time.Time // Dropped unnotified after this, e.g. when the trip never runs
+}
+
+// Estimate is the expected arrival of a subscription's trip at its stop.
+type Estimate struct {
+	ArrivalTime time.Time
+	Predicted   bool // False when ArrivalTime is the schedule
+	VehicleID   string
+}
+
+// EstimateFunc returns the expected arrival for a subscription. ok is false when the arrival
+// cannot be estimated yet; the subscription is then checked again on the next evaluation.
+type EstimateFunc func(ctx context.Context, sub Subscription) (estimate Estimate, ok bool)
+
+// Notification is the JSON body posted to a callback URL.
+type Notification struct {
+	SubscriptionID string `json:"subscriptionId"`
+	StopID         string `json:"stopId"`
+	TripID         string `json:"tripId"`
+	ServiceDate    int64  `json:"serviceDate"`
+	ArrivalTime    int64  `json:"arrivalTime"`
+	Predicted      bool   `json:"predicted"`
+	VehicleID      string `json:"vehicleId,omitempty"`
+	MinutesAway    int    `json:"minutesAway"`
+	SentTime       int64  `json:"sentTime"`
+}
+
+type entry struct {
+	sub      Subscription
+	attempts int
+}
+
+// Manager holds the subscriptions and evaluates them periodically.
+type Manager struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	estimate  EstimateFunc
+	maxPerKey int
+	interval  time.Duration
+	client    *http.Client
+	clock     clock.Clock
+	logger    *slog.Logger
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewManager creates a manager for cfg. A nil client uses one with a 10 second timeout.
+func NewManager(cfg appconf.NotificationsConfig, client *http.Client, c clock.Clock, logger *slog.Logger) *Manager {
+	if client == nil {
+		client = &http.Client{Timeout: deliveryTimeout}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Manager{
+		entries:   make(map[string]*entry),
+		maxPerKey: cfg.MaxPerKey,
+		interval:  time.Duration(cfg.EvaluationInterval) * time.Second,
+		client:    client,
+		clock:     c,
+		logger:    logger.With(slog.String("component", "notifications")),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// SetEstimator sets how arrivals are estimated. Subscriptions are not evaluated until it is set.
+func (m *Manager) SetEstimator(estimate EstimateFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.estimate = estimate
+}
+
+// Start evaluates the subscriptions every evaluation interval until Shutdown.
+func (m *Manager) Start() {
+	if m.interval <= 0 {
+		return
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stopChan:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), m.interval)
+				m.Evaluate(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Shutdown stops the evaluation loop and waits for it to exit.
+func (m *Manager) Shutdown() {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+	})
+	m.wg.Wait()
+}
+
+// Add stores sub under a new ID and returns it with the ID and creation time set.
+func (m *Manager) Add(sub Subscription) (Subscription, error) {
+	id, err := newID()
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.maxPerKey > 0 {
+		count := 0
+		for _, e := range m.entries {
+			if e.sub.APIKey == sub.APIKey {
+				count++
+			}
+		}
+		if count >= m.maxPerKey {
+			return Subscription{}, ErrLimitReached
+		}
+	}
+
+	sub.ID = id
+	sub.Created = m.clock.Now()
+	m.entries[id] = &entry{sub: sub}
+	return sub, nil
+}
+
+// Get returns the subscription with id if it belongs to apiKey.
+func (m *Manager) Get(id, apiKey string) (Subscription, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok || e.sub.APIKey != apiKey {
+		return Subscription{}, false
+	}
+	return e.sub, true
+}
+
+// List returns the active subscriptions of apiKey, oldest first.
+func (m *Manager) List(apiKey string) []Subscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	subs := make([]Subscription, 0)
+	for _, e := range m.entries {
+		if e.sub.APIKey == apiKey {
+			subs = append(subs, e.sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Created.Equal(subs[j].Created) {
+			return subs[i].ID < subs[j].ID
+		}
+		return subs[i].Created.Before(subs[j].Created)
+	})
+	return subs
+}
+
+// Remove deletes the subscription with id if it belongs to apiKey, and reports whether it did.
+func (m *Manager) Remove(id, apiKey string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok || e.sub.APIKey != apiKey {
+		return false
+	}
+	delete(m.entries, id)
+	return true
+}
+
+// Len returns the number of active subscriptions.
+func (m *Manager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// Evaluate checks every subscription once: expired ones are dropped, and those whose estimated
+// arrival is within their lead time are notified and removed.
+func (m *Manager) Evaluate(ctx context.Context) {
+	m.mu.Lock()
+	estimate := m.estimate
+	now := m.clock.Now()
+	var due []Subscription
+	for id, e := range m.entries {
+		if !e.sub.Expires.IsZero() && now.After(e.sub.Expires) {
+			delete(m.entries, id)
+			continue
+		}
+		due = append(due, e.sub)
+	}
+	m.mu.Unlock()
+
+	if estimate == nil {
+		return
+	}
+
+	for _, sub := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		est, ok := estimate(ctx, sub)
+		if !ok || est.ArrivalTime.Sub(now) > sub.Lead {
+			continue
+		}
+
+		err := m.deliver(ctx, sub, est, now)
+		m.finish(sub.ID, err)
+	}
+}
+
+// finish removes a subscription after a delivery, or after its last failed attempt.
+func (m *Manager) finish(id string, deliveryErr error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok {
+		return
+	}
+	if deliveryErr == nil {
+		delete(m.entries, id)
+		return
+	}
+	e.attempts++
+	if e.attempts >= maxDeliveryAttempts {
+		delete(m.entries, id)
+	}
+	logging.LogError(m.logger, "failed to deliver arrival notification", deliveryErr,
+		slog.String("subscription_id", id),
+		slog.Int("attempt", e.attempts))
+}
+
+// deliver posts the notification for sub to its callback URL.
+func (m *Manager) deliver(ctx context.Context, sub Subscription, est Estimate, now time.Time) error {
+	minutesAway := max(int(est.ArrivalTime.Sub(now).Round(time.Minute)/time.Minute), 0)
+	body, err := json.Marshal(Notification{
+		SubscriptionID: sub.ID,
+		StopID:         sub.StopID,
+		TripID:         sub.TripID,
+		ServiceDate:    sub.ServiceDate.UnixMilli(),
+		ArrivalTime:    est.ArrivalTime.UnixMilli(),
+		Predicted:      est.Predicted,
+		VehicleID:      est.VehicleID,
+		MinutesAway:    minutesAway,
+		SentTime:       now.UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer logging.SafeCloseWithLogging(resp.Body, m.logger, "http_response_body")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
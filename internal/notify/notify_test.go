@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+// callbackServer records the notifications posted to it and answers with status.
+type callbackServer struct {
+	*httptest.Server
+	mu            sync.Mutex
+	status        int
+	notifications []Notification
+}
+
+func newCallbackServer(t *testing.T) *callbackServer {
+	t.Helper()
+	cb := &callbackServer{status: http.StatusNoContent}
+	cb.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+		cb.notifications = append(cb.notifications, n)
+		w.WriteHeader(cb.status)
+	}))
+	t.Cleanup(cb.Close)
+	return cb
+}
+
+func (cb *callbackServer) received() []Notification {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return append([]Notification(nil), cb.notifications...)
+}
+
+func newTestManager(cfg appconf.NotificationsConfig, arrival *time.Time) (*Manager, *clock.MockClock) {
+	c := clock.NewMockClock(time.Date(2025, 6, 12, 8, 0, 0, 0, time.UTC))
+	m := NewManager(cfg, nil, c, nil)
+	m.SetEstimator(func(ctx context.Context, sub Subscription) (Estimate, bool) {
+		if arrival == nil {
+			return Estimate{}, false
+		}
+		return Estimate{ArrivalTime: *arrival, Predicted: true, VehicleID: "v1"}, true
+	})
+	return m, c
+}
+
+func TestEvaluate_NotifiesWithinLeadTime(t *testing.T) {
+	cb := newCallbackServer(t)
+	arrival := time.Date(2025, 6, 12, 8, 12, 0, 0, time.UTC)
+	m, c := newTestManager(appconf.NotificationsConfig{}, &arrival)
+
+	sub, err := m.Add(Subscription{
+		APIKey:      "key",
+		StopID:      "25_1001",
+		TripID:      "25_t1",
+		ServiceDate: time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC),
+		Lead:        5 * time.Minute,
+		CallbackURL: cb.URL,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, sub.ID)
+
+	m.Evaluate(context.Background())
+	assert.Empty(t, cb.received(), "12 minutes away is outside the lead time")
+	assert.Equal(t, 1, m.Len())
+
+	c.Advance(8 * time.Minute)
+	m.Evaluate(context.Background())
+	notifications := cb.received()
+	require.Len(t, notifications, 1)
+	assert.Equal(t, Notification{
+		SubscriptionID: sub.ID,
+		StopID:         "25_1001",
+		TripID:         "25_t1",
+		ServiceDate:    sub.ServiceDate.UnixMilli(),
+		ArrivalTime:    arrival.UnixMilli(),
+		Predicted:      true,
+		VehicleID:      "v1",
+		MinutesAway:    4,
+		SentTime:       c.Now().UnixMilli(),
+	}, notifications[0])
+	assert.Equal(t, 0, m.Len(), "a subscription fires once")
+
+	m.Evaluate(context.Background())
+	assert.Len(t, cb.received(), 1)
+}
+
+func TestEvaluate_DropsAfterFailedDeliveries(t *testing.T) {
+	cb := newCallbackServer(t)
+	cb.status = http.StatusInternalServerError
+	arrival := time.Date(2025, 6, 12, 8, 2, 0, 0, time.UTC)
+	m, _ := newTestManager(appconf.NotificationsConfig{}, &arrival)
+
+	_, err := m.Add(Subscription{APIKey: "key", Lead: 5 * time.Minute, CallbackURL: cb.URL})
+	require.NoError(t, err)
+
+	for i := 1; i < maxDeliveryAttempts; i++ {
+		m.Evaluate(context.Background())
+		assert.Equal(t, 1, m.Len(), "kept for another attempt")
+	}
+	m.Evaluate(context.Background())
+	assert.Equal(t, 0, m.Len())
+	assert.Len(t, cb.received(), maxDeliveryAttempts)
+}
+
+func TestEvaluate_DropsExpiredSubscriptions(t *testing.T) {
+	m, c := newTestManager(appconf.NotificationsConfig{}, nil)
+	_, err := m.Add(Subscription{APIKey: "key", Lead: 5 * time.Minute, CallbackURL: "http://127.0.0.1:1", Expires: c.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	m.Evaluate(context.Background())
+	assert.Equal(t, 1, m.Len(), "kept while the arrival cannot be estimated")
+
+	c.Advance(2 * time.Hour)
+	m.Evaluate(context.Background())
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestAddListRemove_ScopedToAPIKey(t *testing.T) {
+	m, _ := newTestManager(appconf.NotificationsConfig{MaxPerKey: 2}, nil)
+
+	first, err := m.Add(Subscription{APIKey: "a"})
+	require.NoError(t, err)
+	_, err = m.Add(Subscription{APIKey: "a"})
+	require.NoError(t, err)
+	_, err = m.Add(Subscription{APIKey: "a"})
+	assert.ErrorIs(t, err, ErrLimitReached)
+	other, err := m.Add(Subscription{APIKey: "b"})
+	require.NoError(t, err)
+
+	assert.Len(t, m.List("a"), 2)
+	assert.Len(t, m.List("b"), 1)
+
+	_, ok := m.Get(other.ID, "a")
+	assert.False(t, ok, "another key's subscription is not visible")
+	assert.False(t, m.Remove(other.ID, "a"))
+	assert.True(t, m.Remove(first.ID, "a"))
+	assert.False(t, m.Remove(first.ID, "a"))
+	assert.Len(t, m.List("a"), 1)
+}
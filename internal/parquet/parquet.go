@@ -0,0 +1,364 @@
+// Package parquet writes flat tables as Apache Parquet files that analytics tools (pandas,
+// DuckDB, Spark, BigQuery) load directly. It covers what the data exports need: required and
+// optional columns of booleans, 64-bit integers, doubles, UTF-8 strings and millisecond
+// timestamps, PLAIN encoded in Snappy-compressed data pages, one page per column chunk.
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+// Type is the logical type of a column.
+type Type int
+
+const (
+	Boolean Type = iota
+	Int64
+	Double
+	String
+	TimestampMillis // Unix milliseconds, written from int64 or time.Time
+)
+
+// Column describes one column of a table.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool // Values may be nil
+}
+
+// rowGroupSize is how many rows are buffered before they are written as a row group.
+const rowGroupSize = 100_000
+
+const magic = "PAR1"
+
+// Parquet enum values
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecSnappy = 1
+
+	pageTypeData = 0
+)
+
+type columnBuffer struct {
+	values  []byte // PLAIN-encoded values, except booleans
+	bools   []bool
+	present []bool // One per row, for optional columns
+}
+
+type chunkMeta struct {
+	offset       int64
+	uncompressed int64
+	compressed   int64
+	numValues    int64
+}
+
+type rowGroupMeta struct {
+	chunks   []chunkMeta
+	numRows  int64
+	byteSize int64
+}
+
+// Writer writes rows to a Parquet file. Call Close to write the footer.
+type Writer struct {
+	w         io.Writer
+	offset    int64
+	columns   []Column
+	buffers   []columnBuffer
+	rows      int // Rows buffered in the current row group
+	totalRows int64
+	rowGroups []rowGroupMeta
+	err       error
+}
+
+// NewWriter starts a Parquet file with the given columns on w.
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+	pw := &Writer{w: w, columns: columns, buffers: make([]columnBuffer, len(columns))}
+	pw.write([]byte(magic))
+	return pw, pw.err
+}
+
+// Write appends a row with one value per column: bool, int64 (or int), float64, string, or
+// time.Time or int64 for timestamps. nil is accepted in optional columns.
+func (pw *Writer) Write(values ...any) error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if len(values) != len(pw.columns) {
+		return fmt.Errorf("parquet: row has %d values for %d columns", len(values), len(pw.columns))
+	}
+
+	for i, value := range values {
+		col := pw.columns[i]
+		buf := &pw.buffers[i]
+		if value == nil {
+			if !col.Optional {
+				return fmt.Errorf("parquet: column %s is required", col.Name)
+			}
+			buf.present = append(buf.present, false)
+			continue
+		}
+		if err := buf.append(col, value); err != nil {
+			return err
+		}
+		if col.Optional {
+			buf.present = append(buf.present, true)
+		}
+	}
+
+	pw.rows++
+	if pw.rows >= rowGroupSize {
+		pw.flushRowGroup()
+	}
+	return pw.err
+}
+
+// Close writes any buffered rows and the file footer. It does not close the underlying writer.
+func (pw *Writer) Close() error {
+	if pw.rows > 0 {
+		pw.flushRowGroup()
+	}
+	if pw.err != nil {
+		return pw.err
+	}
+
+	footer := pw.footer()
+	pw.write(footer)
+	pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	pw.write([]byte(magic))
+	return pw.err
+}
+
+func (buf *columnBuffer) append(col Column, value any) error {
+	switch col.Type {
+	case Boolean:
+		v, ok := value.(bool)
+		if !ok {
+			return typeError(col, value)
+		}
+		buf.bools = append(buf.bools, v)
+	case Int64, TimestampMillis:
+		var v int64
+		switch n := value.(type) {
+		case int64:
+			v = n
+		case int:
+			v = int64(n)
+		case time.Time:
+			if col.Type != TimestampMillis {
+				return typeError(col, value)
+			}
+			v = n.UnixMilli()
+		default:
+			return typeError(col, value)
+		}
+		buf.values = binary.LittleEndian.AppendUint64(buf.values, uint64(v))
+	case Double:
+		v, ok := value.(float64)
+		if !ok {
+			return typeError(col, value)
+		}
+		buf.values = binary.LittleEndian.AppendUint64(buf.values, math.Float64bits(v))
+	case String:
+		v, ok := value.(string)
+		if !ok {
+			return typeError(col, value)
+		}
+		buf.values = binary.LittleEndian.AppendUint32(buf.values, uint32(len(v)))
+		buf.values = append(buf.values, v...)
+	}
+	return nil
+}
+
+func typeError(col Column, value any) error {
+	return fmt.Errorf("parquet: column %s cannot hold a %T", col.Name, value)
+}
+
+func (pw *Writer) write(p []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+	pw.err = err
+}
+
+// flushRowGroup writes each buffered column as a single data page.
+func (pw *Writer) flushRowGroup() {
+	group := rowGroupMeta{numRows: int64(pw.rows)}
+	for i, col := range pw.columns {
+		buf := &pw.buffers[i]
+
+		var body []byte
+		if col.Optional {
+			levels := encodeLevels(buf.present)
+			body = binary.LittleEndian.AppendUint32(body, uint32(len(levels)))
+			body = append(body, levels...)
+		}
+		if col.Type == Boolean {
+			body = append(body, packBools(buf.bools)...)
+		} else {
+			body = append(body, buf.values...)
+		}
+		compressed := snappy.Encode(nil, body)
+
+		var header thriftWriter
+		header.beginStruct()
+		header.i32(1, pageTypeData)
+		header.i32(2, int32(len(body)))
+		header.i32(3, int32(len(compressed)))
+		header.structField(5)
+		header.i32(1, int32(pw.rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		header.endStruct()
+
+		chunk := chunkMeta{
+			offset:       pw.offset,
+			uncompressed: int64(len(header.buf) + len(body)),
+			compressed:   int64(len(header.buf) + len(compressed)),
+			numValues:    int64(pw.rows),
+		}
+		pw.write(header.buf)
+		pw.write(compressed)
+		group.chunks = append(group.chunks, chunk)
+		group.byteSize += chunk.uncompressed
+
+		*buf = columnBuffer{}
+	}
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.totalRows += int64(pw.rows)
+	pw.rows = 0
+}
+
+// encodeLevels encodes definition levels (1 present, 0 null) with the RLE/bit-packing hybrid
+// encoding at bit width 1, as one RLE run per run of equal levels.
+func encodeLevels(present []bool) []byte {
+	var out []byte
+	for i := 0; i < len(present); {
+		j := i
+		for j < len(present) && present[j] == present[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if present[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+// packBools PLAIN-encodes booleans one bit each, least significant bit first.
+func packBools(values []bool) []byte {
+	out := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+func physicalType(t Type) int32 {
+	switch t {
+	case Boolean:
+		return physicalBoolean
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	default:
+		return physicalInt64
+	}
+}
+
+// footer encodes the FileMetaData structure.
+func (pw *Writer) footer() []byte {
+	var t thriftWriter
+	t.beginStruct()
+	t.i32(1, 1)
+
+	t.listField(2, thriftStruct, len(pw.columns)+1)
+	t.beginStruct()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.endStruct()
+	for _, col := range pw.columns {
+		t.beginStruct()
+		t.i32(1, physicalType(col.Type))
+		if col.Optional {
+			t.i32(3, repetitionOptional)
+		} else {
+			t.i32(3, repetitionRequired)
+		}
+		t.binary(4, col.Name)
+		switch col.Type {
+		case String:
+			t.i32(6, convertedUTF8)
+		case TimestampMillis:
+			t.i32(6, convertedTimestampMillis)
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, pw.totalRows)
+
+	t.listField(4, thriftStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		t.beginStruct()
+		t.listField(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			col := pw.columns[i]
+			t.beginStruct()
+			t.i64(2, chunk.offset)
+			t.structField(3)
+			t.i32(1, physicalType(col.Type))
+			t.listField(2, thriftI32, 2)
+			t.listI32(encodingPlain)
+			t.listI32(encodingRLE)
+			t.listField(3, thriftBinary, 1)
+			t.listBinary(col.Name)
+			t.i32(4, codecSnappy)
+			t.i64(5, chunk.numValues)
+			t.i64(6, chunk.uncompressed)
+			t.i64(7, chunk.compressed)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, group.byteSize)
+		t.i64(3, group.numRows)
+		t.endStruct()
+	}
+
+	t.binary(6, "maglev")
+	t.endStruct()
+	return t.buf
+}
@@ -0,0 +1,234 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes the Thrift compact protocol into structs (map of field ID to value),
+// lists ([]any), integers (int64) and binaries ([]byte).
+type thriftReader struct {
+	t   *testing.T
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	require.Positive(r.t, n)
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.uvarint())
+		v := r.buf[r.pos : r.pos+n]
+		r.pos += n
+		return v
+	case thriftList:
+		header := r.buf[r.pos]
+		r.pos++
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		fields := make(map[int16]any)
+		var last int16
+		for {
+			header := r.buf[r.pos]
+			r.pos++
+			if header == 0 {
+				return fields
+			}
+			id := last + int16(header>>4)
+			if header>>4 == 0 {
+				id = int16(r.zigzag())
+			}
+			fields[id] = r.value(header & 0x0f)
+			last = id
+		}
+	}
+	r.t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+// readTable decodes a file written by Writer into rows of Go values.
+func readTable(t *testing.T, data []byte) (names []string, rows [][]any) {
+	t.Helper()
+	require.Equal(t, magic, string(data[:4]))
+	require.Equal(t, magic, string(data[len(data)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - footerLen
+	meta := (&thriftReader{t: t, buf: data[footerStart : len(data)-8]}).value(thriftStruct).(map[int16]any)
+
+	schema := meta[2].([]any)
+	require.Equal(t, int64(len(schema)-1), schema[0].(map[int16]any)[5])
+	var optional []bool
+	var types []int64
+	var converted []any
+	for _, el := range schema[1:] {
+		field := el.(map[int16]any)
+		names = append(names, string(field[4].([]byte)))
+		optional = append(optional, field[3] == int64(repetitionOptional))
+		types = append(types, field[1].(int64))
+		converted = append(converted, field[6])
+	}
+
+	for _, g := range meta[4].([]any) {
+		group := g.(map[int16]any)
+		numRows := int(group[3].(int64))
+		columns := make([][]any, len(names))
+		for i, c := range group[1].([]any) {
+			chunk := c.(map[int16]any)[3].(map[int16]any)
+			require.Equal(t, int64(codecSnappy), chunk[4])
+			reader := &thriftReader{t: t, buf: data, pos: int(chunk[9].(int64))}
+			header := reader.value(thriftStruct).(map[int16]any)
+			compressed := data[reader.pos : reader.pos+int(header[3].(int64))]
+			page, err := snappy.Decode(nil, compressed)
+			require.NoError(t, err)
+			require.Len(t, page, int(header[2].(int64)))
+
+			present := make([]bool, numRows)
+			for j := range present {
+				present[j] = true
+			}
+			if optional[i] {
+				n := int(binary.LittleEndian.Uint32(page))
+				levels := &thriftReader{t: t, buf: page[4 : 4+n]}
+				present = present[:0]
+				for levels.pos < len(levels.buf) {
+					run := int(levels.uvarint() >> 1)
+					v := levels.buf[levels.pos] == 1
+					levels.pos++
+					for k := 0; k < run; k++ {
+						present = append(present, v)
+					}
+				}
+				page = page[4+n:]
+			}
+
+			bit := 0
+			for _, ok := range present {
+				if !ok {
+					columns[i] = append(columns[i], nil)
+					continue
+				}
+				switch types[i] {
+				case physicalBoolean:
+					columns[i] = append(columns[i], page[bit/8]&(1<<(bit%8)) != 0)
+					bit++
+				case physicalInt64:
+					v := int64(binary.LittleEndian.Uint64(page))
+					if converted[i] == int64(convertedTimestampMillis) {
+						columns[i] = append(columns[i], time.UnixMilli(v).UTC())
+					} else {
+						columns[i] = append(columns[i], v)
+					}
+					page = page[8:]
+				case physicalDouble:
+					columns[i] = append(columns[i], math.Float64frombits(binary.LittleEndian.Uint64(page)))
+					page = page[8:]
+				case physicalByteArray:
+					n := int(binary.LittleEndian.Uint32(page))
+					columns[i] = append(columns[i], string(page[4:4+n]))
+					page = page[4+n:]
+				}
+			}
+		}
+		for r := 0; r < numRows; r++ {
+			row := make([]any, len(names))
+			for i := range names {
+				row[i] = columns[i][r]
+			}
+			rows = append(rows, row)
+		}
+	}
+	assert.Equal(t, int64(len(rows)), meta[3])
+	return names, rows
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{
+		{Name: "id", Type: String},
+		{Name: "lat", Type: Double},
+		{Name: "sequence", Type: Int64},
+		{Name: "code", Type: String, Optional: true},
+		{Name: "accessible", Type: Boolean, Optional: true},
+		{Name: "timestamp", Type: TimestampMillis, Optional: true},
+	})
+	require.NoError(t, err)
+
+	reported := time.Date(2025, 6, 12, 15, 4, 5, 0, time.UTC)
+	require.NoError(t, w.Write("1_100", 47.6, int64(1), "100", true, reported))
+	require.NoError(t, w.Write("1_200", -12.5, 2, nil, false, nil))
+	require.NoError(t, w.Write("1_300", 0.0, int64(3), nil, nil, reported.UnixMilli()))
+	require.NoError(t, w.Close())
+
+	names, rows := readTable(t, buf.Bytes())
+	assert.Equal(t, []string{"id", "lat", "sequence", "code", "accessible", "timestamp"}, names)
+	assert.Equal(t, [][]any{
+		{"1_100", 47.6, int64(1), "100", true, reported},
+		{"1_200", -12.5, int64(2), nil, false, nil},
+		{"1_300", 0.0, int64(3), nil, nil, reported},
+	}, rows)
+}
+
+func TestWriter_SplitsRowGroups(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{{Name: "n", Type: Int64}, {Name: "even", Type: Boolean}})
+	require.NoError(t, err)
+	total := rowGroupSize + 10
+	for i := 0; i < total; i++ {
+		require.NoError(t, w.Write(i, i%2 == 0))
+	}
+	require.NoError(t, w.Close())
+	assert.Len(t, w.rowGroups, 2)
+
+	_, rows := readTable(t, buf.Bytes())
+	require.Len(t, rows, total)
+	assert.Equal(t, []any{int64(rowGroupSize + 9), false}, rows[total-1])
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{{Name: "id", Type: String}})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	names, rows := readTable(t, buf.Bytes())
+	assert.Equal(t, []string{"id"}, names)
+	assert.Empty(t, rows)
+}
+
+func TestWriter_RejectsInvalidRows(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{}, []Column{{Name: "id", Type: String}, {Name: "n", Type: Int64}})
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, w.Write("a"), "row has 1 values for 2 columns")
+	assert.ErrorContains(t, w.Write(nil, int64(1)), "column id is required")
+	assert.ErrorContains(t, w.Write("a", 1.5), "column n cannot hold a float64")
+	assert.ErrorContains(t, w.Write("a", time.Now()), "column n cannot hold a time.Time")
+}
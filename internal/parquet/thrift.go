@@ -0,0 +1,87 @@
+package parquet
+
+import "encoding/binary"
+
+// Thrift compact protocol type codes
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol, which Parquet uses for page headers and the
+// file footer. Only the field types those structures use are supported.
+type thriftWriter struct {
+	buf     []byte
+	lastIDs []int16 // Last field ID written, per open struct
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := t.lastIDs[len(t.lastIDs)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.lastIDs[len(t.lastIDs)-1] = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.buf = binary.AppendUvarint(t.buf, uint64((v<<1)^(v>>63)))
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.lastIDs = append(t.lastIDs, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf = append(t.buf, 0)
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, v string) {
+	t.fieldHeader(id, thriftBinary)
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(v)))
+	t.buf = append(t.buf, v...)
+}
+
+// structField starts a nested struct field; close it with endStruct.
+func (t *thriftWriter) structField(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.beginStruct()
+}
+
+// listField starts a list field of n elements of type elem.
+func (t *thriftWriter) listField(id int16, elem byte, n int) {
+	t.fieldHeader(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+	} else {
+		t.buf = append(t.buf, 0xf0|elem)
+		t.buf = binary.AppendUvarint(t.buf, uint64(n))
+	}
+}
+
+// listI32 writes an element of an i32 list.
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(int64(v))
+}
+
+// listBinary writes an element of a binary list.
+func (t *thriftWriter) listBinary(v string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(v)))
+	t.buf = append(t.buf, v...)
+}
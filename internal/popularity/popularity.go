@@ -0,0 +1,128 @@
+// Package popularity tracks how often stops and routes are requested, so that searches can
+// rank the ones riders actually use above others with similar names. Counts decay with a
+// half-life, so a stop that closed or a route that was cut stops dominating after a while.
+// Counts are kept in memory only.
+package popularity
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kind is the type of entity a count is for.
+type Kind string
+
+const (
+	Stop  Kind = "stop"
+	Route Kind = "route"
+)
+
+// HalfLife is how long it takes a request to count for half as much.
+const HalfLife = 7 * 24 * time.Hour
+
+// maxExponent bounds the growth of stored weights before they are rescaled.
+const maxExponent = 500
+
+type key struct {
+	kind Kind
+	id   string
+}
+
+// Tracker counts requests per stop and route. Instead of decaying every count as time passes,
+// each new request is weighted by 2^(elapsed half-lives since origin), which keeps the counts
+// comparable at any moment.
+type Tracker struct {
+	now func() time.Time
+
+	mu      sync.RWMutex
+	origin  time.Time
+	weights map[key]float64
+	max     map[Kind]float64
+}
+
+// New returns an empty tracker. now is the clock; nil means time.Now.
+func New(now func() time.Time) *Tracker {
+	if now == nil {
+		now = time.Now
+	}
+	return &Tracker{
+		now:     now,
+		origin:  now(),
+		weights: make(map[key]float64),
+		max:     make(map[Kind]float64),
+	}
+}
+
+// exponent is the number of half-lives between the origin and t.
+func (t *Tracker) exponent(at time.Time) float64 {
+	return float64(at.Sub(t.origin)) / float64(HalfLife)
+}
+
+// Record counts one request for the entity.
+func (t *Tracker) Record(kind Kind, id string) {
+	t.Add(kind, id, 1)
+}
+
+// Add counts n requests for the entity at once, such as counts loaded from elsewhere at startup.
+func (t *Tracker) Add(kind Kind, id string, n float64) {
+	if id == "" || n <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	exp := t.exponent(t.now())
+	if exp > maxExponent {
+		t.rescale(exp)
+		exp = 0
+	}
+	k := key{kind, id}
+	t.weights[k] += n * math.Exp2(exp)
+	if t.weights[k] > t.max[kind] {
+		t.max[kind] = t.weights[k]
+	}
+}
+
+// rescale moves the origin forward by exp half-lives. The caller must hold t.mu.
+func (t *Tracker) rescale(exp float64) {
+	factor := math.Exp2(-exp)
+	for k, w := range t.weights {
+		t.weights[k] = w * factor
+	}
+	for kind, m := range t.max {
+		t.max[kind] = m * factor
+	}
+	t.origin = t.origin.Add(time.Duration(exp * float64(HalfLife)))
+}
+
+// Score returns the entity's popularity from 0, never requested, to 1, the most requested of
+// its kind. It grows with the logarithm of the count, so a stop with ten times the requests of
+// another is not ten times as popular.
+func (t *Tracker) Score(kind Kind, id string) float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	scale := math.Exp2(-t.exponent(t.now()))
+	highest := t.max[kind] * scale
+	if highest <= 0 {
+		return 0
+	}
+	return math.Log1p(t.weights[key{kind, id}]*scale) / math.Log1p(highest)
+}
+
+// Rank orders n search results, given best match first, by their position blended with their
+// popularity: result i scores 1 - i/n plus weight times its popularity score. Results that
+// score the same keep their order. It returns the indices of the results in their new order.
+func (t *Tracker) Rank(kind Kind, n int, id func(i int) string, weight float64) []int {
+	order := make([]int, n)
+	scores := make([]float64, n)
+	for i := range order {
+		order[i] = i
+		scores[i] = 1 - float64(i)/float64(n)
+		if t != nil && weight > 0 {
+			scores[i] += weight * t.Score(kind, id(i))
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	return order
+}
@@ -0,0 +1,61 @@
+package popularity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	tracker := New(func() time.Time { return now })
+
+	assert.Zero(t, tracker.Score(Stop, "1_100"), "nothing recorded")
+
+	tracker.Add(Stop, "1_100", 99)
+	tracker.Record(Stop, "1_200")
+	tracker.Record(Route, "1_10")
+	tracker.Record(Stop, "")
+
+	assert.InDelta(t, 1, tracker.Score(Stop, "1_100"), 1e-9)
+	assert.InDelta(t, 0.15, tracker.Score(Stop, "1_200"), 0.01, "grows with the logarithm of the count")
+	assert.Zero(t, tracker.Score(Stop, "1_300"))
+	assert.InDelta(t, 1, tracker.Score(Route, "1_10"), 1e-9, "kinds are ranked apart")
+}
+
+func TestScore_Decay(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	tracker := New(func() time.Time { return now })
+
+	tracker.Add(Stop, "old", 8)
+	now = now.Add(3 * HalfLife)
+	tracker.Add(Stop, "new", 2)
+
+	// 8 requests three half-lives ago count as 1 now
+	assert.InDelta(t, 1, tracker.Score(Stop, "new"), 1e-9)
+	assert.InDelta(t, 0.63, tracker.Score(Stop, "old"), 0.01)
+
+	// Weights are rescaled long before they overflow
+	now = now.Add(1000 * HalfLife)
+	tracker.Record(Stop, "newest")
+	assert.InDelta(t, 1, tracker.Score(Stop, "newest"), 1e-9)
+	assert.InDelta(t, 0, tracker.Score(Stop, "new"), 1e-9)
+}
+
+func TestRank(t *testing.T) {
+	tracker := New(nil)
+	ids := []string{"a", "b", "c", "d"}
+	id := func(i int) string { return ids[i] }
+
+	assert.Equal(t, []int{0, 1, 2, 3}, tracker.Rank(Stop, len(ids), id, 1), "ties keep their order")
+
+	tracker.Add(Stop, "d", 50)
+	tracker.Add(Stop, "c", 1)
+	assert.Equal(t, []int{3, 0, 1, 2}, tracker.Rank(Stop, len(ids), id, 1))
+	assert.Equal(t, []int{0, 1, 2, 3}, tracker.Rank(Stop, len(ids), id, 0.1), "a low weight only breaks near ties")
+	assert.Equal(t, []int{0, 1, 2, 3}, tracker.Rank(Stop, len(ids), id, 0))
+
+	var disabled *Tracker
+	assert.Equal(t, []int{0, 1}, disabled.Rank(Stop, 2, id, 1))
+}
@@ -0,0 +1,340 @@
+// Package quota enforces long-horizon (daily and monthly) request quotas per API key.
+// Counters are kept in memory for speed and periodically flushed to a Store so that
+// usage survives restarts.
+package quota
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// Period identifies the calendar window a quota applies to.
+type Period string
+
+const (
+	Daily   Period = "daily"
+	Monthly Period = "monthly"
+)
+
+// Counter identifies the request count for one API key in one calendar window,
+// e.g. {"partner-key", "day:2025-01-31"}.
+type Counter struct {
+	APIKey string
+	Bucket string
+}
+
+// Decision is the outcome of a quota check. When Allowed is false, Period, Limit and
+// Reset describe the quota that was exceeded; otherwise they describe the quota with
+// the fewest remaining requests.
+type Decision struct {
+	Allowed   bool
+	Limited   bool // false when the key has no quota at all
+	Period    Period
+	Limit     int64
+	Remaining int64
+	Reset     time.Time
+}
+
+// Manager tracks request counts and enforces the configured limits.
+type Manager struct {
+	mu       sync.Mutex
+	defaults appconf.QuotaLimits
+	perKey   map[string]appconf.QuotaLimits
+	counts   map[Counter]int64
+	dirty    map[Counter]bool
+	store    Store
+	clock    clock.Clock
+	logger   *slog.Logger
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewManager creates a quota manager for the given configuration. When store is non-nil,
+// the counters for the current day and month are loaded from it and changes are flushed
+// back every flushInterval and on Shutdown.
+func NewManager(cfg appconf.QuotaConfig, store Store, c clock.Clock, logger *slog.Logger, flushInterval time.Duration) (*Manager, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	m := &Manager{
+		defaults: cfg.Default,
+		perKey:   cfg.Keys,
+		counts:   make(map[Counter]int64),
+		dirty:    make(map[Counter]bool),
+		store:    store,
+		clock:    c,
+		logger:   logger.With(slog.String("component", "quota")),
+		stopChan: make(chan struct{}),
+	}
+
+	if store != nil {
+		now := c.Now()
+		counts, err := store.Load(context.Background(), []string{dayBucket(now), monthBucket(now)})
+		if err != nil {
+			return nil, err
+		}
+		for counter, count := range counts {
+			m.counts[counter] = count
+		}
+
+		if flushInterval > 0 {
+			m.wg.Add(1)
+			go m.flushPeriodically(flushInterval)
+		}
+	}
+
+	return m, nil
+}
+
+// SetLimits applies new default and per-key limits. Counts are kept, so a key that is already
+// past a lowered limit is rejected from its next request.
+func (m *Manager) SetLimits(cfg appconf.QuotaConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaults = cfg.Default
+	m.perKey = cfg.Keys
+}
+
+// limitsFor returns the quota for a key, falling back to the default.
+func (m *Manager) limitsFor(apiKey string) appconf.QuotaLimits {
+	if limits, ok := m.perKey[apiKey]; ok {
+		return limits
+	}
+	return m.defaults
+}
+
+// Allow records a request for apiKey if it is within quota. Requests of keys without a
+// quota are counted too, so Snapshot covers every key. Requests that are rejected are not
+// counted against the quota.
+func (m *Manager) Allow(apiKey string) Decision {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limits := m.limitsFor(apiKey)
+
+	type window struct {
+		period  Period
+		limit   int64
+		counter Counter
+		reset   time.Time
+	}
+	windows := []window{
+		{Daily, limits.Daily, Counter{apiKey, dayBucket(now)}, nextDay(now)},
+		{Monthly, limits.Monthly, Counter{apiKey, monthBucket(now)}, nextMonth(now)},
+	}
+
+	// Reject without counting if any window is already exhausted
+	for _, w := range windows {
+		if w.limit > 0 && m.counts[w.counter] >= w.limit {
+			return Decision{
+				Allowed:   false,
+				Limited:   true,
+				Period:    w.period,
+				Limit:     w.limit,
+				Remaining: 0,
+				Reset:     w.reset,
+			}
+		}
+	}
+
+	decision := Decision{Allowed: true, Limited: true, Remaining: -1}
+	for _, w := range windows {
+		m.counts[w.counter]++
+		m.dirty[w.counter] = true
+		if w.limit <= 0 {
+			continue
+		}
+
+		remaining := w.limit - m.counts[w.counter]
+		if decision.Remaining < 0 || remaining < decision.Remaining {
+			decision.Period = w.period
+			decision.Limit = w.limit
+			decision.Remaining = remaining
+			decision.Reset = w.reset
+		}
+	}
+	if limits.IsZero() {
+		return Decision{Allowed: true}
+	}
+
+	return decision
+}
+
+// Record counts a request for apiKey without checking its quota, for requests that are
+// exempt from quotas but still belong in the usage report.
+func (m *Manager) Record(apiKey string) {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, counter := range []Counter{{apiKey, dayBucket(now)}, {apiKey, monthBucket(now)}} {
+		m.counts[counter]++
+		m.dirty[counter] = true
+	}
+}
+
+// Usage returns the current day and month counts for apiKey.
+func (m *Manager) Usage(apiKey string) (daily, monthly int64) {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.counts[Counter{apiKey, dayBucket(now)}], m.counts[Counter{apiKey, monthBucket(now)}]
+}
+
+// KeyUsage is an API key's request count in the current UTC day and month, and its quota.
+type KeyUsage struct {
+	APIKey  string
+	Daily   int64
+	Monthly int64
+	Limits  appconf.QuotaLimits
+}
+
+// Snapshot returns the usage of every key that made requests this month or has a quota of
+// its own, ordered by key, along with when the current day and month end.
+func (m *Manager) Snapshot() (usage []KeyUsage, dayReset, monthReset time.Time) {
+	now := m.clock.Now()
+	day, month := dayBucket(now), monthBucket(now)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byKey := make(map[string]*KeyUsage)
+	entry := func(apiKey string) *KeyUsage {
+		u := byKey[apiKey]
+		if u == nil {
+			u = &KeyUsage{APIKey: apiKey, Limits: m.limitsFor(apiKey)}
+			byKey[apiKey] = u
+		}
+		return u
+	}
+	for apiKey := range m.perKey {
+		entry(apiKey)
+	}
+	for counter, count := range m.counts {
+		switch counter.Bucket {
+		case day:
+			entry(counter.APIKey).Daily = count
+		case month:
+			entry(counter.APIKey).Monthly = count
+		}
+	}
+
+	usage = make([]KeyUsage, 0, len(byKey))
+	for _, u := range byKey {
+		usage = append(usage, *u)
+	}
+	slices.SortFunc(usage, func(a, b KeyUsage) int { return strings.Compare(a.APIKey, b.APIKey) })
+	return usage, nextDay(now), nextMonth(now)
+}
+
+// Flush writes changed counters to the store and drops counters for past periods.
+func (m *Manager) Flush(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+
+	now := m.clock.Now()
+	current := map[string]bool{dayBucket(now): true, monthBucket(now): true}
+
+	m.mu.Lock()
+	changed := make(map[Counter]int64, len(m.dirty))
+	for counter := range m.dirty {
+		changed[counter] = m.counts[counter]
+	}
+	m.dirty = make(map[Counter]bool)
+	for counter := range m.counts {
+		if !current[counter.Bucket] {
+			delete(m.counts, counter)
+		}
+	}
+	m.mu.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if err := m.store.Save(ctx, changed); err != nil {
+		// Mark the counters dirty again so the next flush retries them
+		m.mu.Lock()
+		for counter := range changed {
+			m.dirty[counter] = true
+		}
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (m *Manager) flushPeriodically(interval time.Duration) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Flush(context.Background()); err != nil {
+				logging.LogError(m.logger, "failed to flush quota counters", err)
+			}
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// Shutdown stops the background flusher, writes any pending counters and closes the store.
+// It is safe to call multiple times.
+func (m *Manager) Shutdown() {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		m.wg.Wait()
+
+		if m.store == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := m.Flush(ctx); err != nil {
+			logging.LogError(m.logger, "failed to flush quota counters on shutdown", err)
+		}
+		if err := m.store.Close(); err != nil {
+			logging.LogError(m.logger, "failed to close quota store", err)
+		}
+	})
+}
+
+// Quota periods follow UTC calendar days and months so that reset times are the same
+// for every client regardless of the agency timezone.
+func dayBucket(t time.Time) string {
+	return "day:" + t.UTC().Format("2006-01-02")
+}
+
+func monthBucket(t time.Time) string {
+	return "month:" + t.UTC().Format("2006-01")
+}
+
+func nextDay(t time.Time) time.Time {
+	u := t.UTC()
+	return time.Date(u.Year(), u.Month(), u.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextMonth(t time.Time) time.Time {
+	u := t.UTC()
+	return time.Date(u.Year(), u.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
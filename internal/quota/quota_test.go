@@ -0,0 +1,190 @@
+package quota
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func newTestManager(t *testing.T, cfg appconf.QuotaConfig, store Store, c clock.Clock) *Manager {
+	t.Helper()
+	m, err := NewManager(cfg, store, c, nil, 0)
+	require.NoError(t, err)
+	t.Cleanup(m.Shutdown)
+	return m
+}
+
+func TestAllow_DailyLimit(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 3, 14, 22, 0, 0, 0, time.UTC))
+	m := newTestManager(t, appconf.QuotaConfig{Default: appconf.QuotaLimits{Daily: 2}}, nil, mockClock)
+
+	first := m.Allow("key")
+	assert.True(t, first.Allowed)
+	assert.Equal(t, Daily, first.Period)
+	assert.Equal(t, int64(1), first.Remaining)
+	assert.Equal(t, time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC), first.Reset)
+
+	assert.True(t, m.Allow("key").Allowed)
+
+	rejected := m.Allow("key")
+	assert.False(t, rejected.Allowed)
+	assert.Equal(t, Daily, rejected.Period)
+	assert.Equal(t, int64(2), rejected.Limit)
+	assert.Equal(t, int64(0), rejected.Remaining)
+
+	// Rejected requests are not counted
+	daily, _ := m.Usage("key")
+	assert.Equal(t, int64(2), daily)
+
+	// Other keys have their own counters
+	assert.True(t, m.Allow("other").Allowed)
+
+	// The quota resets at midnight UTC
+	mockClock.Advance(2 * time.Hour)
+	assert.True(t, m.Allow("key").Allowed)
+}
+
+func TestAllow_MonthlyLimit(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC))
+	m := newTestManager(t, appconf.QuotaConfig{Default: appconf.QuotaLimits{Daily: 10, Monthly: 3}}, nil, mockClock)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, m.Allow("key").Allowed)
+	}
+
+	rejected := m.Allow("key")
+	assert.False(t, rejected.Allowed)
+	assert.Equal(t, Monthly, rejected.Period)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), rejected.Reset)
+
+	mockClock.Advance(12 * time.Hour)
+	assert.True(t, m.Allow("key").Allowed)
+}
+
+func TestAllow_PerKeyOverride(t *testing.T) {
+	cfg := appconf.QuotaConfig{
+		Default: appconf.QuotaLimits{Daily: 1},
+		Keys: map[string]appconf.QuotaLimits{
+			"partner":   {Daily: 3},
+			"unlimited": {},
+		},
+	}
+	m := newTestManager(t, cfg, nil, clock.NewMockClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	assert.True(t, m.Allow("default").Allowed)
+	assert.False(t, m.Allow("default").Allowed)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, m.Allow("partner").Allowed)
+	}
+	assert.False(t, m.Allow("partner").Allowed)
+
+	for i := 0; i < 5; i++ {
+		decision := m.Allow("unlimited")
+		assert.True(t, decision.Allowed)
+		assert.False(t, decision.Limited)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC))
+	m := newTestManager(t, appconf.QuotaConfig{Default: appconf.QuotaLimits{Daily: 5}}, nil, mockClock)
+
+	for range 3 {
+		require.True(t, m.Allow("key").Allowed)
+	}
+
+	// Counts carry over, so lowering the limit below them rejects at once
+	m.SetLimits(appconf.QuotaConfig{Default: appconf.QuotaLimits{Daily: 3}})
+	rejected := m.Allow("key")
+	assert.False(t, rejected.Allowed)
+	assert.Equal(t, int64(3), rejected.Limit)
+
+	m.SetLimits(appconf.QuotaConfig{
+		Default: appconf.QuotaLimits{Daily: 3},
+		Keys:    map[string]appconf.QuotaLimits{"key": {Monthly: 10}},
+	})
+	allowed := m.Allow("key")
+	assert.True(t, allowed.Allowed)
+	assert.Equal(t, Monthly, allowed.Period)
+	assert.Equal(t, int64(6), allowed.Remaining)
+}
+
+func TestFlush_PersistsAcrossRestarts(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "quota.db")
+	mockClock := clock.NewMockClock(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))
+	cfg := appconf.QuotaConfig{Default: appconf.QuotaLimits{Daily: 3, Monthly: 100}}
+
+	store, err := NewSQLiteStore(dbPath)
+	require.NoError(t, err)
+	m, err := NewManager(cfg, store, mockClock, nil, 0)
+	require.NoError(t, err)
+
+	assert.True(t, m.Allow("key").Allowed)
+	assert.True(t, m.Allow("key").Allowed)
+	m.Shutdown()
+
+	store, err = NewSQLiteStore(dbPath)
+	require.NoError(t, err)
+	restarted := newTestManager(t, cfg, store, mockClock)
+
+	daily, monthly := restarted.Usage("key")
+	assert.Equal(t, int64(2), daily)
+	assert.Equal(t, int64(2), monthly)
+
+	assert.True(t, restarted.Allow("key").Allowed)
+	assert.False(t, restarted.Allow("key").Allowed)
+}
+
+func TestFlush_DropsPastPeriods(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "quota.db")
+	store, err := NewSQLiteStore(dbPath)
+	require.NoError(t, err)
+
+	mockClock := clock.NewMockClock(time.Date(2025, 6, 30, 23, 0, 0, 0, time.UTC))
+	m := newTestManager(t, appconf.QuotaConfig{Default: appconf.QuotaLimits{Daily: 5, Monthly: 5}}, store, mockClock)
+
+	m.Allow("key")
+	mockClock.Advance(2 * time.Hour)
+	require.NoError(t, m.Flush(context.Background()))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Empty(t, m.counts)
+}
+
+func TestSnapshot(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 3, 14, 22, 0, 0, 0, time.UTC))
+	cfg := appconf.QuotaConfig{
+		Default: appconf.QuotaLimits{Daily: 100},
+		Keys: map[string]appconf.QuotaLimits{
+			"idle-partner": {Monthly: 5000},
+			"unlimited":    {},
+		},
+	}
+	m := newTestManager(t, cfg, nil, mockClock)
+
+	m.Allow("key")
+	mockClock.Advance(3 * time.Hour)
+	m.Allow("key")
+	m.Allow("unlimited")
+	// Recorded requests count without being checked against the quota
+	m.Record("exempt")
+
+	usage, dayReset, monthReset := m.Snapshot()
+	assert.Equal(t, []KeyUsage{
+		{APIKey: "exempt", Daily: 1, Monthly: 1, Limits: appconf.QuotaLimits{Daily: 100}},
+		{APIKey: "idle-partner", Limits: appconf.QuotaLimits{Monthly: 5000}},
+		{APIKey: "key", Daily: 1, Monthly: 2, Limits: appconf.QuotaLimits{Daily: 100}},
+		// Keys without a quota are counted too
+		{APIKey: "unlimited", Daily: 1, Monthly: 1},
+	}, usage)
+	assert.Equal(t, time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC), dayReset)
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), monthReset)
+}
@@ -0,0 +1,101 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"maglev.onebusaway.org/internal/sqlitedb"
+)
+
+// Store persists quota counters between restarts.
+type Store interface {
+	// Load returns the stored counters for the given buckets.
+	Load(ctx context.Context, buckets []string) (map[Counter]int64, error)
+	// Save writes the absolute value of each counter.
+	Save(ctx context.Context, counts map[Counter]int64) error
+	Close() error
+}
+
+const quotaSchema = `
+CREATE TABLE IF NOT EXISTS quota_usage (
+    api_key TEXT NOT NULL,
+    bucket TEXT NOT NULL,
+    request_count INTEGER NOT NULL,
+    PRIMARY KEY (api_key, bucket)
+);`
+
+// SQLiteStore keeps quota counters in a standalone SQLite database. It is deliberately
+// separate from the GTFS database, which is rebuilt whenever the static feed changes.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the counter database at path.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sqlitedb.Open(path, quotaSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open quota database: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Load(ctx context.Context, buckets []string) (map[Counter]int64, error) {
+	counts := make(map[Counter]int64)
+	if len(buckets) == 0 {
+		return counts, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(buckets)), ",")
+	args := make([]any, len(buckets))
+	for i, bucket := range buckets {
+		args[i] = bucket
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT api_key, bucket, request_count FROM quota_usage WHERE bucket IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load quota counters: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var counter Counter
+		var count int64
+		if err := rows.Scan(&counter.APIKey, &counter.Bucket, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan quota counter: %w", err)
+		}
+		counts[counter] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *SQLiteStore) Save(ctx context.Context, counts map[Counter]int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin quota transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO quota_usage (api_key, bucket, request_count) VALUES (?, ?, ?)
+		ON CONFLICT (api_key, bucket) DO UPDATE SET request_count = excluded.request_count`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare quota upsert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for counter, count := range counts {
+		if _, err := stmt.ExecContext(ctx, counter.APIKey, counter.Bucket, count); err != nil {
+			return fmt.Errorf("failed to save quota counter: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
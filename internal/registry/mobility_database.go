@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+const mobilityDatabaseURL = "https://api.mobilitydatabase.org"
+
+// maxRedirects bounds how many deprecated feeds are followed to their replacements.
+const maxRedirects = 5
+
+// mobilityDatabase resolves feeds with the Mobility Database catalog API. The configured token
+// is a refresh token, exchanged for a short-lived access token on every resolution.
+type mobilityDatabase struct {
+	api     apiClient
+	baseURL string
+	cfg     appconf.FeedRegistryConfig
+}
+
+type mdbSourceInfo struct {
+	ProducerURL string `json:"producer_url"`
+}
+
+type mdbFeed struct {
+	ID         string        `json:"id"`
+	Status     string        `json:"status"`
+	SourceInfo mdbSourceInfo `json:"source_info"`
+	Redirects  []struct {
+		TargetID string `json:"target_id"`
+	} `json:"redirects"`
+	LatestDataset *struct {
+		HostedURL string `json:"hosted_url"`
+	} `json:"latest_dataset"`
+	EntityTypes []string `json:"entity_types"` // GTFS-RT feeds only: "tu", "vp" or "sa"
+}
+
+func (m *mobilityDatabase) resolve(ctx context.Context) (Feeds, error) {
+	header, err := m.authorize(ctx)
+	if err != nil {
+		return Feeds{}, err
+	}
+
+	// A deprecated feed names its replacement
+	id := m.cfg.StaticFeedID
+	var static mdbFeed
+	for hops := 0; ; hops++ {
+		if err := m.api.get(ctx, m.baseURL, "/v1/gtfs_feeds/%s", header, &static, id); err != nil {
+			return Feeds{}, err
+		}
+		if static.Status != "deprecated" || len(static.Redirects) == 0 {
+			break
+		}
+		if hops == maxRedirects {
+			return Feeds{}, fmt.Errorf("feed %s redirects more than %d times", m.cfg.StaticFeedID, maxRedirects)
+		}
+		m.api.logger.Warn("registry feed is deprecated; following its replacement", "feed", id, "replacement", static.Redirects[0].TargetID)
+		id = static.Redirects[0].TargetID
+		static = mdbFeed{}
+	}
+
+	// The producer's own URL, or the registry's copy of the latest dataset
+	feeds := Feeds{StaticURL: static.SourceInfo.ProducerURL}
+	if feeds.StaticURL == "" && static.LatestDataset != nil {
+		feeds.StaticURL = static.LatestDataset.HostedURL
+	}
+	if feeds.StaticURL == "" {
+		return Feeds{}, fmt.Errorf("feed %s has no URL", id)
+	}
+
+	var realtime []mdbFeed
+	if len(m.cfg.RealtimeFeedIDs) == 0 {
+		if err := m.api.get(ctx, m.baseURL, "/v1/gtfs_feeds/%s/gtfs_rt_feeds", header, &realtime, id); err != nil {
+			return Feeds{}, err
+		}
+	}
+	for _, rtID := range m.cfg.RealtimeFeedIDs {
+		var feed mdbFeed
+		if err := m.api.get(ctx, m.baseURL, "/v1/gtfs_rt_feeds/%s", header, &feed, rtID); err != nil {
+			return Feeds{}, err
+		}
+		realtime = append(realtime, feed)
+	}
+	for _, feed := range realtime {
+		if feed.Status == "deprecated" || feed.Status == "inactive" {
+			continue
+		}
+		for _, entityType := range feed.EntityTypes {
+			var target *string
+			switch entityType {
+			case "tu":
+				target = &feeds.TripUpdatesURL
+			case "vp":
+				target = &feeds.VehiclePositionsURL
+			case "sa":
+				target = &feeds.ServiceAlertsURL
+			default:
+				continue
+			}
+			// The first active feed of each kind is used
+			if *target == "" {
+				*target = feed.SourceInfo.ProducerURL
+			}
+		}
+	}
+	return feeds, nil
+}
+
+// authorize exchanges the refresh token for an access token.
+func (m *mobilityDatabase) authorize(ctx context.Context) (http.Header, error) {
+	body, err := json.Marshal(map[string]string{"refresh_token": m.cfg.Token})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v1/tokens", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := m.api.do(req, &token); err != nil {
+		return nil, fmt.Errorf("failed to get an access token: %w", err)
+	}
+	return http.Header{"Authorization": {"Bearer " + token.AccessToken}}, nil
+}
@@ -0,0 +1,255 @@
+// Package registry resolves feed URLs from a feed registry, so an operator can name a feed by
+// its registry ID instead of copying URLs that an agency may later move. The Mobility Database
+// (mobilitydatabase.org) and Transitland (transit.land) are supported. A Watcher re-resolves
+// the feeds periodically and reports when their URLs change.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// resolveTimeout bounds one resolution, including every registry request it makes.
+const resolveTimeout = 30 * time.Second
+
+// Feeds are the URLs a registry gives for a static feed and its realtime feeds. A realtime
+// URL is empty when the registry lists none of that kind.
+type Feeds struct {
+	StaticURL           string
+	TripUpdatesURL      string
+	VehiclePositionsURL string
+	ServiceAlertsURL    string
+}
+
+// FromConfig returns the feed URLs cfg currently uses.
+func FromConfig(cfg gtfs.Config) Feeds {
+	return Feeds{
+		StaticURL:           cfg.GtfsURL,
+		TripUpdatesURL:      cfg.TripUpdatesURL,
+		VehiclePositionsURL: cfg.VehiclePositionsURL,
+		ServiceAlertsURL:    cfg.ServiceAlertsURL,
+	}
+}
+
+// Apply returns cfg with its feed URLs replaced by those the registry gave. When the registry
+// lists no realtime feeds, the configured ones are kept.
+func (f Feeds) Apply(cfg gtfs.Config) gtfs.Config {
+	if f.StaticURL != "" {
+		cfg.GtfsURL = f.StaticURL
+	}
+	if f.HasRealtime() {
+		cfg.TripUpdatesURL = f.TripUpdatesURL
+		cfg.VehiclePositionsURL = f.VehiclePositionsURL
+		cfg.ServiceAlertsURL = f.ServiceAlertsURL
+	}
+	return cfg
+}
+
+// HasRealtime reports whether any realtime URL is set.
+func (f Feeds) HasRealtime() bool {
+	return f.TripUpdatesURL != "" || f.VehiclePositionsURL != "" || f.ServiceAlertsURL != ""
+}
+
+// provider looks feeds up in one registry.
+type provider interface {
+	resolve(ctx context.Context) (Feeds, error)
+}
+
+// Resolver looks up the configured feeds.
+type Resolver struct {
+	provider provider
+	name     string
+}
+
+// NewResolver returns a resolver for cfg. A nil client uses one with a 30 second timeout.
+func NewResolver(cfg appconf.FeedRegistryConfig, client *http.Client, logger *slog.Logger) (*Resolver, error) {
+	if client == nil {
+		client = &http.Client{Timeout: resolveTimeout}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	api := apiClient{client: client, logger: logger.With(slog.String("component", "feed_registry"))}
+	var p provider
+	switch cfg.Provider {
+	case "mobility-database":
+		p = &mobilityDatabase{api: api, baseURL: baseURL(cfg.APIURL, mobilityDatabaseURL), cfg: cfg}
+	case "transitland":
+		p = &transitland{api: api, baseURL: baseURL(cfg.APIURL, transitlandURL), cfg: cfg}
+	default:
+		return nil, fmt.Errorf("unknown feed registry %q", cfg.Provider)
+	}
+	return &Resolver{provider: p, name: cfg.Provider + " " + cfg.StaticFeedID}, nil
+}
+
+// Resolve returns the current URLs of the configured feeds.
+func (r *Resolver) Resolve(ctx context.Context) (Feeds, error) {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	feeds, err := r.provider.resolve(ctx)
+	if err != nil {
+		return Feeds{}, fmt.Errorf("failed to resolve %s: %w", r.name, err)
+	}
+	return feeds, nil
+}
+
+// Watcher re-resolves the feeds on an interval and calls a function when their URLs change.
+type Watcher struct {
+	resolver *Resolver
+	interval time.Duration
+	onChange func(previous, current Feeds)
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	current Feeds
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewWatcher returns a watcher that starts from the feeds in use. onChange runs on the
+// watcher's goroutine.
+func NewWatcher(resolver *Resolver, interval time.Duration, current Feeds, onChange func(previous, current Feeds), logger *slog.Logger) *Watcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Watcher{
+		resolver: resolver,
+		interval: interval,
+		onChange: onChange,
+		logger:   logger.With(slog.String("component", "feed_registry")),
+		current:  current,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start checks the registry every interval until Shutdown.
+func (w *Watcher) Start() {
+	if w.interval <= 0 {
+		return
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stopChan:
+				return
+			case <-ticker.C:
+				w.Check(context.Background())
+			}
+		}
+	}()
+}
+
+// Shutdown stops the checks and waits for one in progress.
+func (w *Watcher) Shutdown() {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+	})
+	w.wg.Wait()
+}
+
+// Current returns the feeds in use.
+func (w *Watcher) Current() Feeds {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Check resolves the feeds once and reports a change. Lookup errors are logged and the feeds
+// in use are kept. A registry that lists no realtime feeds leaves the configured ones alone.
+func (w *Watcher) Check(ctx context.Context) {
+	feeds, err := w.resolver.Resolve(ctx)
+	if err != nil {
+		logging.LogError(w.logger, "feed registry check failed", err)
+		return
+	}
+
+	w.mu.Lock()
+	previous := w.current
+	if !feeds.HasRealtime() {
+		feeds.TripUpdatesURL, feeds.VehiclePositionsURL, feeds.ServiceAlertsURL =
+			previous.TripUpdatesURL, previous.VehiclePositionsURL, previous.ServiceAlertsURL
+	}
+	w.current = feeds
+	w.mu.Unlock()
+
+	if feeds == previous {
+		return
+	}
+	w.logger.Warn("feed registry URLs changed",
+		"static", redact(feeds.StaticURL),
+		"trip_updates", redact(feeds.TripUpdatesURL),
+		"vehicle_positions", redact(feeds.VehiclePositionsURL),
+		"service_alerts", redact(feeds.ServiceAlertsURL))
+	w.onChange(previous, feeds)
+}
+
+// redact drops the query string, which may hold an agency's API key, from a URL for logging.
+func redact(source string) string {
+	source, _, _ = strings.Cut(source, "?")
+	return source
+}
+
+func baseURL(configured, fallback string) string {
+	if configured != "" {
+		return strings.TrimSuffix(configured, "/")
+	}
+	return fallback
+}
+
+// apiClient makes JSON requests to a registry.
+type apiClient struct {
+	client *http.Client
+	logger *slog.Logger
+}
+
+// do sends req and decodes a 200 response's JSON body into out.
+func (a apiClient) do(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer logging.SafeCloseWithLogging(resp.Body, a.logger, "http_response_body")
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", req.URL.Path, err)
+	}
+	return nil
+}
+
+// get requests base + path, with each of segments path-escaped in place of a %s in path.
+func (a apiClient) get(ctx context.Context, base, path string, header http.Header, out any, segments ...string) error {
+	escaped := make([]any, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+fmt.Sprintf(path, escaped...), nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	return a.do(req, out)
+}
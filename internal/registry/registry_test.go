@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/gtfs"
+)
+
+// fakeMobilityDatabase serves the catalog endpoints for a deprecated feed mdb-1, replaced by
+// mdb-2, which has a trip updates and a vehicle positions feed.
+func fakeMobilityDatabase(t *testing.T, staticURL *string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/tokens", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["refresh_token"] != "refresh-secret" {
+			http.Error(w, `{"detail":"invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"access-secret","token_type":"Bearer"}`))
+	})
+	authorized := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer access-secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	mux.HandleFunc("GET /v1/gtfs_feeds/mdb-1", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"mdb-1","status":"deprecated","source_info":{"producer_url":"https://old.example.com/gtfs.zip"},"redirects":[{"target_id":"mdb-2","comment":""}]}`))
+	}))
+	mux.HandleFunc("GET /v1/gtfs_feeds/mdb-2", authorized(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":             "mdb-2",
+			"status":         "active",
+			"source_info":    map[string]string{"producer_url": *staticURL},
+			"latest_dataset": map[string]string{"hosted_url": "https://files.example.com/mdb-2/latest.zip"},
+			"redirects":      []any{},
+		})
+	}))
+	mux.HandleFunc("GET /v1/gtfs_feeds/mdb-2/gtfs_rt_feeds", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"id":"mdb-10","status":"inactive","entity_types":["tu"],"source_info":{"producer_url":"https://old.example.com/tu.pb"}},
+			{"id":"mdb-11","status":"active","entity_types":["tu"],"source_info":{"producer_url":"https://rt.example.com/tu.pb"}},
+			{"id":"mdb-12","status":"active","entity_types":["vp"],"source_info":{"producer_url":"https://rt.example.com/vp.pb"}}
+		]`))
+	}))
+	mux.HandleFunc("GET /v1/gtfs_rt_feeds/mdb-13", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"mdb-13","status":"active","entity_types":["sa"],"source_info":{"producer_url":"https://rt.example.com/alerts.pb"}}`))
+	}))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMobilityDatabaseResolve(t *testing.T) {
+	staticURL := "https://agency.example.com/gtfs.zip"
+	server := fakeMobilityDatabase(t, &staticURL)
+	cfg := appconf.FeedRegistryConfig{Provider: "mobility-database", StaticFeedID: "mdb-1", Token: "refresh-secret", APIURL: server.URL + "/"}
+
+	resolver, err := NewResolver(cfg, server.Client(), nil)
+	require.NoError(t, err)
+	feeds, err := resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Feeds{
+		StaticURL:           "https://agency.example.com/gtfs.zip",
+		TripUpdatesURL:      "https://rt.example.com/tu.pb",
+		VehiclePositionsURL: "https://rt.example.com/vp.pb",
+	}, feeds)
+
+	// Configured realtime feeds replace the linked ones
+	cfg.RealtimeFeedIDs = []string{"mdb-13"}
+	resolver, err = NewResolver(cfg, server.Client(), nil)
+	require.NoError(t, err)
+	feeds, err = resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Feeds{StaticURL: "https://agency.example.com/gtfs.zip", ServiceAlertsURL: "https://rt.example.com/alerts.pb"}, feeds)
+
+	// Without a producer URL, the registry's copy is used
+	staticURL = ""
+	feeds, err = resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "https://files.example.com/mdb-2/latest.zip", feeds.StaticURL)
+
+	cfg.Token = "wrong"
+	resolver, err = NewResolver(cfg, server.Client(), nil)
+	require.NoError(t, err)
+	_, err = resolver.Resolve(context.Background())
+	assert.ErrorContains(t, err, "failed to resolve mobility-database mdb-1: failed to get an access token")
+}
+
+func TestTransitlandResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("apikey") != "tl-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/feeds/f-9q9-example":
+			_, _ = w.Write([]byte(`{"feeds":[{"onestop_id":"f-9q9-example","spec":"GTFS","urls":{"static_current":"https://agency.example.com/gtfs.zip"}}]}`))
+		case "/feeds/f-9q9-example~rt":
+			_, _ = w.Write([]byte(`{"feeds":[{"onestop_id":"f-9q9-example~rt","spec":"GTFS_RT","urls":{"realtime_trip_updates":"https://rt.example.com/tu.pb","realtime_vehicle_positions":"https://rt.example.com/vp.pb"}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"feeds":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := appconf.FeedRegistryConfig{Provider: "transitland", StaticFeedID: "f-9q9-example", RealtimeFeedIDs: []string{"f-9q9-example~rt"}, Token: "tl-key", APIURL: server.URL}
+	resolver, err := NewResolver(cfg, server.Client(), nil)
+	require.NoError(t, err)
+	feeds, err := resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Feeds{
+		StaticURL:           "https://agency.example.com/gtfs.zip",
+		TripUpdatesURL:      "https://rt.example.com/tu.pb",
+		VehiclePositionsURL: "https://rt.example.com/vp.pb",
+	}, feeds)
+
+	cfg.StaticFeedID = "f-missing"
+	resolver, err = NewResolver(cfg, server.Client(), nil)
+	require.NoError(t, err)
+	_, err = resolver.Resolve(context.Background())
+	assert.ErrorContains(t, err, "feed f-missing not found")
+}
+
+func TestWatcherReportsMovedFeeds(t *testing.T) {
+	staticURL := "https://agency.example.com/gtfs.zip"
+	server := fakeMobilityDatabase(t, &staticURL)
+	// Only alerts are resolved, so the configured vehicle positions feed is kept
+	cfg := appconf.FeedRegistryConfig{Provider: "mobility-database", StaticFeedID: "mdb-2", RealtimeFeedIDs: []string{"mdb-13"}, Token: "refresh-secret", APIURL: server.URL}
+	resolver, err := NewResolver(cfg, server.Client(), nil)
+	require.NoError(t, err)
+
+	configured := gtfs.Config{GtfsURL: "https://agency.example.com/gtfs.zip", ServiceAlertsURL: "https://rt.example.com/alerts.pb", RealTimeAuthHeaderKey: "X-Key"}
+	var changes [][2]Feeds
+	watcher := NewWatcher(resolver, 0, FromConfig(configured), func(previous, current Feeds) {
+		changes = append(changes, [2]Feeds{previous, current})
+	}, nil)
+
+	watcher.Check(context.Background())
+	assert.Empty(t, changes)
+
+	staticURL = "https://new.example.com/feed/gtfs.zip"
+	watcher.Check(context.Background())
+	require.Len(t, changes, 1)
+	assert.Equal(t, "https://agency.example.com/gtfs.zip", changes[0][0].StaticURL)
+	assert.Equal(t, "https://new.example.com/feed/gtfs.zip", changes[0][1].StaticURL)
+	assert.Equal(t, changes[0][1], watcher.Current())
+
+	applied := changes[0][1].Apply(configured)
+	assert.Equal(t, "https://new.example.com/feed/gtfs.zip", applied.GtfsURL)
+	assert.Equal(t, "https://rt.example.com/alerts.pb", applied.ServiceAlertsURL)
+	assert.Equal(t, "X-Key", applied.RealTimeAuthHeaderKey)
+
+	// A failed check keeps the feeds in use
+	server.Close()
+	watcher.Check(context.Background())
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "https://new.example.com/feed/gtfs.zip", watcher.Current().StaticURL)
+}
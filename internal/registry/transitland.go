@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+const transitlandURL = "https://transit.land/api/v2/rest"
+
+// transitland resolves feeds with the Transitland REST API, by Onestop ID. Transitland does not
+// link a static feed to its realtime feeds, so those are only resolved when configured.
+type transitland struct {
+	api     apiClient
+	baseURL string
+	cfg     appconf.FeedRegistryConfig
+}
+
+type transitlandFeed struct {
+	OnestopID string `json:"onestop_id"`
+	URLs      struct {
+		StaticCurrent            string `json:"static_current"`
+		RealtimeTripUpdates      string `json:"realtime_trip_updates"`
+		RealtimeVehiclePositions string `json:"realtime_vehicle_positions"`
+		RealtimeAlerts           string `json:"realtime_alerts"`
+	} `json:"urls"`
+}
+
+func (t *transitland) resolve(ctx context.Context) (Feeds, error) {
+	static, err := t.feed(ctx, t.cfg.StaticFeedID)
+	if err != nil {
+		return Feeds{}, err
+	}
+	if static.URLs.StaticCurrent == "" {
+		return Feeds{}, fmt.Errorf("feed %s has no static_current URL", t.cfg.StaticFeedID)
+	}
+	feeds := Feeds{StaticURL: static.URLs.StaticCurrent}
+
+	for _, id := range t.cfg.RealtimeFeedIDs {
+		realtime, err := t.feed(ctx, id)
+		if err != nil {
+			return Feeds{}, err
+		}
+		if feeds.TripUpdatesURL == "" {
+			feeds.TripUpdatesURL = realtime.URLs.RealtimeTripUpdates
+		}
+		if feeds.VehiclePositionsURL == "" {
+			feeds.VehiclePositionsURL = realtime.URLs.RealtimeVehiclePositions
+		}
+		if feeds.ServiceAlertsURL == "" {
+			feeds.ServiceAlertsURL = realtime.URLs.RealtimeAlerts
+		}
+	}
+	return feeds, nil
+}
+
+func (t *transitland) feed(ctx context.Context, id string) (transitlandFeed, error) {
+	var response struct {
+		Feeds []transitlandFeed `json:"feeds"`
+	}
+	if err := t.api.get(ctx, t.baseURL, "/feeds/%s", http.Header{"Apikey": {t.cfg.Token}}, &response, id); err != nil {
+		return transitlandFeed{}, err
+	}
+	if len(response.Feeds) == 0 {
+		return transitlandFeed{}, fmt.Errorf("feed %s not found", id)
+	}
+	return response.Feeds[0], nil
+}
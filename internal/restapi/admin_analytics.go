@@ -0,0 +1,104 @@
+package restapi
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"maglev.onebusaway.org/internal/analytics"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// Defaults for the analytics endpoints
+const (
+	defaultAnalyticsDays     = 7
+	defaultAnalyticsTopStops = 20
+)
+
+// analyticsSince reads the days parameter and returns the start of the requested window.
+func (api *RestAPI) analyticsSince(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	days := defaultAnalyticsDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			api.validationErrorResponse(w, r, map[string][]string{"days": {"must be a positive number of days"}})
+			return time.Time{}, false
+		}
+		days = parsed
+	}
+	return api.Clock.Now().AddDate(0, 0, -days), true
+}
+
+func requestCounts(counts []analytics.Count) []models.RequestCount {
+	result := make([]models.RequestCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, models.RequestCount{ID: count.Value, Requests: count.Requests})
+	}
+	return result
+}
+
+// adminAnalyticsHandler reports hourly traffic, the endpoint mix and the most requested stops
+// over the last days (default 7). maxCount limits the number of stops (default 20).
+func (api *RestAPI) adminAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Analytics == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "analytics not enabled")
+		return
+	}
+	since, ok := api.analyticsSince(w, r)
+	if !ok {
+		return
+	}
+	_, topStops := utils.ParsePaginationParams(r, defaultAnalyticsTopStops, models.MaxPageSize)
+
+	report, err := api.Analytics.Report(r.Context(), since, topStops)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	entry := models.AnalyticsReport{
+		Since:     report.Since.UnixMilli(),
+		Hourly:    make([]models.HourlyTraffic, 0, len(report.Hourly)),
+		Endpoints: requestCounts(report.Endpoints),
+		TopStops:  requestCounts(report.TopStops),
+	}
+	for _, hour := range report.Hourly {
+		entry.Hourly = append(entry.Hourly, models.HourlyTraffic{Hour: hour.Hour.UnixMilli(), Requests: hour.Requests})
+	}
+
+	response := models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// adminAnalyticsExportHandler streams every stored count over the last days as CSV, one row per
+// hour, dimension and value, for loading into a spreadsheet or warehouse.
+func (api *RestAPI) adminAnalyticsExportHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Analytics == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "analytics not enabled")
+		return
+	}
+	since, ok := api.analyticsSince(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := api.Analytics.Rows(r.Context(), since)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="analytics.csv"`)
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"hour", "dimension", "value", "requests"})
+	for _, row := range rows {
+		_ = out.Write([]string{row.Hour.Format(time.RFC3339), row.Dimension, row.Value, strconv.FormatInt(row.Requests, 10)})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		api.requestLogger(r).Error("failed to write analytics export", "error", err)
+	}
+}
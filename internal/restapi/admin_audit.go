@@ -0,0 +1,109 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/audit"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditActionStaticRefresh   = "gtfs.refresh"
+	AuditActionRealtimeRefresh = "realtime.refresh"
+	AuditActionCacheFlush      = "cache.flush"
+	AuditActionRateLimitFlush  = "ratelimit.flush"
+	AuditActionConfigReload    = "config.reload"
+	AuditActionRidershipImport = "ridership.import"
+)
+
+// defaultAuditListSize is the number of entries returned when maxCount isn't given.
+const defaultAuditListSize = 100
+
+// audited records every call of an admin action with the caller's key, the request
+// parameters (without the key) and the status the action completed with.
+func (api *RestAPI) audited(action string, next handlerFunc) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := &responseWriter{wrappedWriter: wrappedWriter{w}, statusCode: http.StatusOK}
+		next(recorder, r)
+
+		params := map[string]string{}
+		for name, values := range r.URL.Query() {
+			if name != "key" && len(values) > 0 {
+				params[name] = values[0]
+			}
+		}
+		// The action has happened even if the client has gone away
+		ctx := context.WithoutCancel(r.Context())
+		api.RecordAudit(ctx, app.APIKeyFromRequest(r), action, params, recorder.statusCode)
+	}
+}
+
+// RecordAudit appends an entry to the audit log. Failures are logged rather than returned:
+// an action that already happened shouldn't be reported as failed.
+func (api *RestAPI) RecordAudit(ctx context.Context, actor, action string, params map[string]string, status int) {
+	if api.AuditLog == nil {
+		return
+	}
+	err := api.AuditLog.Record(ctx, audit.Entry{
+		Time:   api.Clock.Now(),
+		Actor:  actor,
+		Action: action,
+		Params: params,
+		Status: status,
+	})
+	if err != nil {
+		logging.LogError(logging.FromContext(ctx), "failed to record audit entry", err)
+	}
+}
+
+// adminAuditLogHandler lists recorded admin actions, newest first. Use maxCount to set the
+// page size and before=<id> to fetch older entries.
+func (api *RestAPI) adminAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	if api.AuditLog == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "audit log not available")
+		return
+	}
+
+	_, limit := utils.ParsePaginationParams(r, defaultAuditListSize, models.MaxPageSize)
+	var beforeID int64
+	if before := r.URL.Query().Get("before"); before != "" {
+		parsed, err := strconv.ParseInt(before, 10, 64)
+		if err != nil || parsed <= 0 {
+			api.validationErrorResponse(w, r, map[string][]string{"before": {"must be a positive entry id"}})
+			return
+		}
+		beforeID = parsed
+	}
+
+	// Fetch one extra entry to tell whether more remain
+	entries, err := api.AuditLog.List(r.Context(), limit+1, beforeID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	limitExceeded := len(entries) > limit
+	if limitExceeded {
+		entries = entries[:limit]
+	}
+
+	list := make([]models.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, models.AuditEntry{
+			ID:     entry.ID,
+			Time:   entry.Time.UnixMilli(),
+			Actor:  entry.Actor,
+			Action: entry.Action,
+			Params: entry.Params,
+			Status: entry.Status,
+		})
+	}
+
+	response := models.NewListResponse(list, models.NewEmptyReferences(), limitExceeded, api.Clock)
+	api.sendResponse(w, r, response)
+}
@@ -0,0 +1,116 @@
+package restapi
+
+import (
+	"errors"
+	"net/http"
+
+	"maglev.onebusaway.org/internal/blocklist"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// Blocklist actions recorded in the audit log
+const (
+	AuditActionBlocklistAdd    = "blocklist.add"
+	AuditActionBlocklistRemove = "blocklist.remove"
+)
+
+// blocklistTarget reads the entry named by the apiKey or cidr parameter; exactly one must be given.
+func (api *RestAPI) blocklistTarget(w http.ResponseWriter, r *http.Request) (kind, value string, ok bool) {
+	query := r.URL.Query()
+	apiKey, cidr := query.Get("apiKey"), query.Get("cidr")
+	switch {
+	case apiKey != "" && cidr == "":
+		return blocklist.KindKey, apiKey, true
+	case cidr != "" && apiKey == "":
+		return blocklist.KindCIDR, cidr, true
+	default:
+		api.validationErrorResponse(w, r, map[string][]string{
+			"apiKey": {"exactly one of apiKey or cidr is required"},
+		})
+		return "", "", false
+	}
+}
+
+func blocklistEntryModel(entry blocklist.Entry) models.BlocklistEntry {
+	return models.BlocklistEntry{
+		Type:    entry.Kind,
+		Value:   entry.Value,
+		Reason:  entry.Reason,
+		Created: entry.Created.UnixMilli(),
+	}
+}
+
+// adminBlocklistHandler lists the blocked keys and networks.
+func (api *RestAPI) adminBlocklistHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Blocklist == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "blocklist not available")
+		return
+	}
+
+	list := []models.BlocklistEntry{}
+	for _, entry := range api.Blocklist.Entries() {
+		list = append(list, blocklistEntryModel(entry))
+	}
+	response := models.NewListResponse(list, models.NewEmptyReferences(), false, api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// adminBlocklistAddHandler blocks the key or network given by apiKey or cidr, with an optional reason.
+// The block applies to the next request.
+func (api *RestAPI) adminBlocklistAddHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Blocklist == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "blocklist not available")
+		return
+	}
+	kind, value, ok := api.blocklistTarget(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := api.Blocklist.Add(r.Context(), blocklist.Entry{
+		Kind:    kind,
+		Value:   value,
+		Reason:  r.URL.Query().Get("reason"),
+		Created: api.Clock.Now(),
+	})
+	if errors.Is(err, blocklist.ErrInvalidEntry) {
+		api.validationErrorResponse(w, r, map[string][]string{kind: {err.Error()}})
+		return
+	}
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	response := models.NewEntryResponse(blocklistEntryModel(entry), models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// adminBlocklistRemoveHandler unblocks the key or network given by apiKey or cidr.
+func (api *RestAPI) adminBlocklistRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Blocklist == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "blocklist not available")
+		return
+	}
+	kind, value, ok := api.blocklistTarget(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := api.Blocklist.Remove(r.Context(), kind, value)
+	if errors.Is(err, blocklist.ErrInvalidEntry) {
+		api.validationErrorResponse(w, r, map[string][]string{kind: {err.Error()}})
+		return
+	}
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if !removed {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	response := models.NewEntryResponse(map[string]bool{"removed": true}, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
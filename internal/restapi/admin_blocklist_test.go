@@ -0,0 +1,71 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/blocklist"
+)
+
+func TestAdminBlocklist(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	blocked, err := blocklist.Open("")
+	require.NoError(t, err)
+	defer func() { _ = blocked.Close() }()
+	api.Blocklist = blocked
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	code, model := serveAdmin(t, api, http.MethodPost, "/api/admin/blocklist/add?key=admin-secret&apiKey=TEST&reason=scraping")
+	require.Equal(t, http.StatusOK, code)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "key", entry["type"])
+	assert.Equal(t, "TEST", entry["value"])
+	assert.Equal(t, "scraping", entry["reason"])
+
+	resp, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, http.StatusForbidden, model.Code)
+
+	code, model = serveAdmin(t, api, http.MethodGet, "/api/admin/blocklist.json?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, model.Data.(map[string]interface{})["list"], 1)
+
+	code, _ = serveAdmin(t, api, http.MethodPost, "/api/admin/blocklist/remove?key=admin-secret&apiKey=TEST")
+	require.Equal(t, http.StatusOK, code)
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	code, _ = serveAdmin(t, api, http.MethodPost, "/api/admin/blocklist/remove?key=admin-secret&apiKey=TEST")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestAdminBlocklist_Networks(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	blocked, err := blocklist.Open("")
+	require.NoError(t, err)
+	defer func() { _ = blocked.Close() }()
+	api.Blocklist = blocked
+
+	// The test server's clients connect from loopback
+	code, model := serveAdmin(t, api, http.MethodPost, "/api/admin/blocklist/add?key=admin-secret&cidr=127.0.0.1/8")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "127.0.0.0/8", model.Data.(map[string]interface{})["entry"].(map[string]interface{})["value"])
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	code, _ = serveAdmin(t, api, http.MethodPost, "/api/admin/blocklist/add?key=admin-secret&cidr=not-a-network")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = serveAdmin(t, api, http.MethodPost, "/api/admin/blocklist/add?key=admin-secret&cidr=10.0.0.0/8&apiKey=x")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
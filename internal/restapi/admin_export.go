@@ -0,0 +1,30 @@
+package restapi
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"maglev.onebusaway.org/internal/gtfs"
+)
+
+// adminExportParquetHandler streams a GTFS table, or the current vehicle positions, as a
+// Parquet file for loading into an analytics stack.
+func (api *RestAPI) adminExportParquetHandler(w http.ResponseWriter, r *http.Request) {
+	table := strings.TrimSuffix(r.PathValue("table"), ".parquet")
+	if !slices.Contains(gtfs.ParquetTables, table) {
+		api.sendError(w, r, http.StatusNotFound, "unknown table; expected one of "+strings.Join(gtfs.ParquetTables, ", "))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+table+`.parquet"`)
+
+	api.GtfsManager.RLock()
+	err := api.GtfsManager.ExportParquet(r.Context(), table, w)
+	api.GtfsManager.RUnlock()
+	if err != nil {
+		// The status line has already been sent
+		api.requestLogger(r).Error("failed to write parquet export", "table", table, "error", err)
+	}
+}
@@ -0,0 +1,167 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// realtimeRefreshTimeout matches the timeout of the periodic GTFS-RT refresh.
+const realtimeRefreshTimeout = 15 * time.Second
+
+// adminStatusHandler reports the state of the static dataset, the realtime feeds and the response cache.
+func (api *RestAPI) adminStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if api.GtfsManager == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "GTFS data not loaded")
+		return
+	}
+
+	response := models.NewEntryResponse(api.adminStatus(), models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// adminStatus gathers the entry reported by the status endpoint and status page.
+// The caller must check that GtfsManager is set.
+func (api *RestAPI) adminStatus() models.AdminStatus {
+	status := api.GtfsManager.Status()
+	entry := models.AdminStatus{
+		Static: models.StaticDatasetStatus{
+			LastUpdated:  unixMilliOrZero(status.StaticUpdated),
+			Updating:     status.StaticUpdating || api.staticRefreshRunning.Load(),
+			Healthy:      status.Healthy,
+			Agencies:     status.Agencies,
+			Routes:       status.Routes,
+			Stops:        status.Stops,
+			Trips:        status.Trips,
+			Degradations: []models.FeedDegradation{},
+		},
+		Realtime: models.RealtimeFeedStatus{
+			Enabled:     status.RealtimeEnabled,
+			LastUpdated: unixMilliOrZero(status.RealtimeUpdated),
+			Updating:    api.realtimeRefreshRunning.Load(),
+			Trips:       status.RealtimeTrips,
+			Vehicles:    status.RealtimeVehicles,
+			Alerts:      status.RealtimeAlerts,
+		},
+		Draining: api.Draining(),
+	}
+	for _, degradation := range status.Degradations {
+		entry.Static.Degradations = append(entry.Static.Degradations, models.FeedDegradation(degradation))
+	}
+	if api.responseCache != nil {
+		entry.ResponseCacheEntries = api.responseCache.Len()
+	}
+	return entry
+}
+
+// adminRefreshStaticHandler starts reloading the static GTFS feed in the background. Progress
+// is visible in the status endpoint; the response cache is cleared once the new dataset is live.
+func (api *RestAPI) adminRefreshStaticHandler(w http.ResponseWriter, r *http.Request) {
+	if api.GtfsManager == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "GTFS data not loaded")
+		return
+	}
+	if !api.staticRefreshRunning.CompareAndSwap(false, true) {
+		api.sendError(w, r, http.StatusConflict, "static GTFS refresh already in progress")
+		return
+	}
+
+	logger := logging.FromContext(r.Context())
+	go func() {
+		defer api.staticRefreshRunning.Store(false)
+		// Detached from the request: a refresh can outlive the admin's HTTP connection
+		if err := api.GtfsManager.ForceUpdate(context.Background()); err != nil {
+			logging.LogError(logger, "admin static GTFS refresh failed", err)
+			return
+		}
+		logging.LogOperation(logger, "admin_static_gtfs_refresh_completed")
+	}()
+
+	api.sendAccepted(w, r, "static GTFS refresh started")
+}
+
+// adminRefreshRealtimeHandler refetches the GTFS-RT feeds in the background.
+func (api *RestAPI) adminRefreshRealtimeHandler(w http.ResponseWriter, r *http.Request) {
+	if api.GtfsManager == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "GTFS data not loaded")
+		return
+	}
+	if !api.GtfsManager.RealtimeEnabled() {
+		api.sendError(w, r, http.StatusConflict, gtfs.ErrRealtimeDisabled.Error())
+		return
+	}
+	if !api.realtimeRefreshRunning.CompareAndSwap(false, true) {
+		api.sendError(w, r, http.StatusConflict, "realtime refresh already in progress")
+		return
+	}
+
+	logger := logging.FromContext(r.Context())
+	go func() {
+		defer api.realtimeRefreshRunning.Store(false)
+		ctx, cancel := context.WithTimeout(logging.WithLogger(context.Background(), logger), realtimeRefreshTimeout)
+		defer cancel()
+		if err := api.GtfsManager.RefreshRealtime(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logging.LogError(logger, "admin realtime refresh failed", err)
+			return
+		}
+		logging.LogOperation(logger, "admin_realtime_refresh_completed")
+	}()
+
+	api.sendAccepted(w, r, "realtime refresh started")
+}
+
+// adminFlushCacheHandler empties the response cache and reports how many entries were dropped.
+func (api *RestAPI) adminFlushCacheHandler(w http.ResponseWriter, r *http.Request) {
+	flushed := 0
+	if api.responseCache != nil {
+		flushed = api.responseCache.Flush()
+	}
+
+	response := models.NewEntryResponse(map[string]int{"flushed": flushed}, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// adminFlushRateLimitsHandler drops every client's rate limiter, so clients that were being
+// throttled get a full burst again, and reports how many limiters were dropped.
+func (api *RestAPI) adminFlushRateLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	flushed := 0
+	if api.rateLimiter != nil {
+		flushed = api.rateLimiter.Flush()
+	}
+
+	response := models.NewEntryResponse(map[string]int{"flushed": flushed}, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// adminConfigHandler reports the running configuration, reloads included, as --dump-config
+// prints it.
+func (api *RestAPI) adminConfigHandler(w http.ResponseWriter, r *http.Request) {
+	// A reload replaces these fields while holding reloadMu
+	api.reloadMu.Lock()
+	config := app.ConfigJSON(api.Config, api.GtfsConfig)
+	api.reloadMu.Unlock()
+
+	response := models.NewEntryResponse(config, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// sendAccepted responds with 202 for operations that continue in the background.
+func (api *RestAPI) sendAccepted(w http.ResponseWriter, r *http.Request, message string) {
+	setJSONResponseType(&w)
+	w.WriteHeader(http.StatusAccepted)
+	api.sendResponse(w, r, models.NewResponse(http.StatusAccepted, nil, message, api.Clock))
+}
+
+// unixMilliOrZero converts t to epoch milliseconds, mapping the zero time to 0.
+func unixMilliOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
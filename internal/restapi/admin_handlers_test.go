@@ -0,0 +1,316 @@
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/analytics"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/audit"
+	"maglev.onebusaway.org/internal/errorreport"
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// serveAdmin performs an admin request against a fresh mux and decodes the response.
+func serveAdmin(t *testing.T, api *RestAPI, method, target string) (int, models.ResponseModel) {
+	t.Helper()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+
+	var response models.ResponseModel
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	return rec.Code, response
+}
+
+func TestAdminStatusHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	code, _ := serveAdmin(t, api, http.MethodGet, "/api/admin/status.json?key=TEST")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, model := serveAdmin(t, api, http.MethodGet, "/api/admin/status.json?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	entry, ok := data["entry"].(map[string]interface{})
+	require.True(t, ok)
+	static, ok := entry["static"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, true, static["healthy"])
+	assert.Greater(t, static["routes"], float64(0))
+	assert.Greater(t, static["lastUpdated"], float64(0))
+
+	realtime, ok := entry["realtime"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, false, realtime["enabled"])
+}
+
+func TestAdminStatusPage(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+	api.RecentErrors = errorreport.NewRecent(10)
+	api.RecentErrors.Report(errorreport.Event{
+		Err:        errors.New("database is locked"),
+		Method:     http.MethodGet,
+		Path:       "/api/where/stop/1_75403.json",
+		StatusCode: http.StatusInternalServerError,
+		Timestamp:  time.Now(),
+	})
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/status.html?key=TEST", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/status.html?key=admin-secret", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	page := rec.Body.String()
+	assert.Contains(t, page, "Healthy")
+	assert.Contains(t, page, "No GTFS-RT feeds are configured.")
+	assert.Contains(t, page, "/api/where/stop/1_75403.json")
+	assert.Contains(t, page, "database is locked")
+}
+
+func TestAdminActionsRequirePOST(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/gtfs/refresh?key=admin-secret", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestAdminFlushCacheHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+	api.responseCache = NewResponseCache(appconf.ResponseCacheConfig{MaxEntries: 10}, api.Clock, api.staticDatasetVersion)
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/agencies-with-coverage.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, api.responseCache.Len())
+
+	code, model := serveAdmin(t, api, http.MethodPost, "/api/admin/cache/flush?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, float64(1), entry["flushed"])
+	assert.Equal(t, 0, api.responseCache.Len())
+}
+
+func TestAdminFlushRateLimitsHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+	api.rateLimiter.getLimiter("client-a")
+	api.rateLimiter.getLimiter("client-b")
+
+	code, model := serveAdmin(t, api, http.MethodPost, "/api/admin/ratelimits/flush?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	// The admin's own request has a limiter too
+	assert.GreaterOrEqual(t, entry["flushed"], float64(2))
+	assert.Empty(t, api.rateLimiter.limiters)
+}
+
+func TestAdminConfigHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+	api.GtfsConfig.StaticAuthHeaderKey = "X-Key"
+	api.GtfsConfig.StaticAuthHeaderValue = "feed-secret"
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/config.json?key=admin-secret", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "feed-secret")
+
+	var response struct {
+		Data struct {
+			Entry struct {
+				Port           int               `json:"port"`
+				AdminAPIKeys   []string          `json:"admin-api-keys"`
+				GtfsStaticFeed map[string]string `json:"gtfs-static-feed"`
+			} `json:"entry"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, api.Config.Port, response.Data.Entry.Port)
+	assert.Equal(t, []string{"admin-secret"}, response.Data.Entry.AdminAPIKeys)
+	assert.Equal(t, "X-Key", response.Data.Entry.GtfsStaticFeed["auth-header-name"])
+	assert.Equal(t, "***REDACTED***", response.Data.Entry.GtfsStaticFeed["auth-header-value"])
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/config.json?key=TEST", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAdminRefreshRealtimeHandler_Disabled(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	code, model := serveAdmin(t, api, http.MethodPost, "/api/admin/realtime/refresh?key=admin-secret")
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, gtfs.ErrRealtimeDisabled.Error(), model.Text)
+}
+
+func TestAdminRefreshStaticHandler(t *testing.T) {
+	// Uses its own manager: the refresh swaps the database out from under the shared test manager
+	manager, err := gtfs.InitGTFSManager(gtfs.Config{
+		GtfsURL:      filepath.Join("../../testdata", "raba.zip"),
+		GTFSDataPath: filepath.Join(t.TempDir(), "gtfs.db"),
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.GtfsManager = manager
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+	before := manager.LastStaticUpdate()
+
+	// Only one refresh at a time
+	api.staticRefreshRunning.Store(true)
+	code, _ := serveAdmin(t, api, http.MethodPost, "/api/admin/gtfs/refresh?key=admin-secret")
+	assert.Equal(t, http.StatusConflict, code)
+	api.staticRefreshRunning.Store(false)
+
+	code, model := serveAdmin(t, api, http.MethodPost, "/api/admin/gtfs/refresh?key=admin-secret")
+	require.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "static GTFS refresh started", model.Text)
+
+	require.Eventually(t, func() bool {
+		return !api.staticRefreshRunning.Load() && manager.LastStaticUpdate().After(before)
+	}, 30*time.Second, 50*time.Millisecond)
+	assert.True(t, manager.IsHealthy())
+}
+
+func TestAdminAuditLog(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+	api.responseCache = NewResponseCache(appconf.ResponseCacheConfig{MaxEntries: 10}, api.Clock, api.staticDatasetVersion)
+
+	auditLog, err := audit.Open("")
+	require.NoError(t, err)
+	defer func() { _ = auditLog.Close() }()
+	api.AuditLog = auditLog
+
+	code, _ := serveAdmin(t, api, http.MethodPost, "/api/admin/cache/flush?key=admin-secret&reason=deploy")
+	require.Equal(t, http.StatusOK, code)
+
+	// Rejected callers never reach the action and aren't recorded
+	code, _ = serveAdmin(t, api, http.MethodPost, "/api/admin/cache/flush?key=TEST")
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	code, model := serveAdmin(t, api, http.MethodGet, "/api/admin/audit.json?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+
+	list := model.Data.(map[string]interface{})["list"].([]interface{})
+	require.Len(t, list, 1)
+	entry := list[0].(map[string]interface{})
+	assert.Equal(t, "admin-secret", entry["actor"])
+	assert.Equal(t, AuditActionCacheFlush, entry["action"])
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+	assert.Equal(t, map[string]interface{}{"reason": "deploy"}, entry["params"])
+
+	code, _ = serveAdmin(t, api, http.MethodGet, "/api/admin/audit.json?key=admin-secret&before=abc")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestAdminAnalytics(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	collector, err := analytics.NewCollector(appconf.AnalyticsConfig{DataPath: ":memory:"}, api.Clock, nil, 0)
+	require.NoError(t, err)
+	defer collector.Shutdown()
+	api.Analytics = collector
+
+	stopID := utils.FormCombinedID(api.GtfsManager.GetAgencies()[0].Id, api.GtfsManager.GetStops()[0].Id)
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/"+stopID+".json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/1_does-not-exist.json?key=TEST")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	// Rejected requests aren't counted
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=invalid")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	code, model := serveAdmin(t, api, http.MethodGet, "/api/admin/analytics.json?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+
+	endpoints := entry["endpoints"].([]interface{})
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "/api/where/stop/{id}", endpoints[0].(map[string]interface{})["id"])
+	assert.Equal(t, float64(2), endpoints[0].(map[string]interface{})["requests"])
+
+	stops := entry["topStops"].([]interface{})
+	require.Len(t, stops, 1)
+	assert.Equal(t, stopID, stops[0].(map[string]interface{})["id"])
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/analytics.csv?key=admin-secret", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "hour,dimension,value,requests\n")
+	assert.Contains(t, rec.Body.String(), ",stop,"+stopID+",1\n")
+}
+
+func TestAdminExportParquet(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("/api/admin/export/stops.parquet?key=TEST").Code)
+	assert.Equal(t, http.StatusNotFound, get("/api/admin/export/agencies.parquet?key=admin-secret").Code)
+
+	for _, table := range gtfs.ParquetTables {
+		rec := get("/api/admin/export/" + table + ".parquet?key=admin-secret")
+		require.Equal(t, http.StatusOK, rec.Code, table)
+		assert.Equal(t, "application/vnd.apache.parquet", rec.Header().Get("Content-Type"))
+		body := rec.Body.Bytes()
+		require.Greater(t, len(body), 8, table)
+		assert.Equal(t, "PAR1", string(body[:4]), table)
+		assert.Equal(t, "PAR1", string(body[len(body)-4:]), table)
+	}
+
+	// The footer, which lists the column names, is not compressed
+	assert.Contains(t, get("/api/admin/export/stop_times.parquet?key=admin-secret").Body.String(), "departure_time")
+}
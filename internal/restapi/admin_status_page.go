@@ -0,0 +1,87 @@
+package restapi
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+)
+
+//go:embed admin_status_page.html
+var adminStatusPageFS embed.FS
+
+var adminStatusPageTemplate = template.Must(template.ParseFS(adminStatusPageFS, "admin_status_page.html"))
+
+type adminStatusPageData struct {
+	GeneratedAt     string
+	Status          models.AdminStatus
+	StaticUpdated   string
+	RealtimeUpdated string
+	Errors          []adminStatusPageError
+}
+
+type adminStatusPageError struct {
+	Time       string
+	StatusCode int
+	Panic      bool
+	Method     string
+	Path       string
+	RequestID  string
+	Message    string
+}
+
+// adminStatusPageHandler renders the admin status as an HTML page, with the most recent
+// server errors, so an instance can be checked from a browser.
+func (api *RestAPI) adminStatusPageHandler(w http.ResponseWriter, r *http.Request) {
+	if api.GtfsManager == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "GTFS data not loaded")
+		return
+	}
+
+	now := api.Clock.Now()
+	status := api.adminStatus()
+	data := adminStatusPageData{
+		GeneratedAt:     now.UTC().Format(time.RFC3339),
+		Status:          status,
+		StaticUpdated:   formatStatusTime(status.Static.LastUpdated, now),
+		RealtimeUpdated: formatStatusTime(status.Realtime.LastUpdated, now),
+		Errors:          []adminStatusPageError{},
+	}
+	if api.RecentErrors != nil {
+		for _, event := range api.RecentErrors.Events() {
+			data.Errors = append(data.Errors, adminStatusPageError{
+				Time:       event.Timestamp.UTC().Format(time.RFC3339),
+				StatusCode: event.StatusCode,
+				Panic:      event.Panic,
+				Method:     event.Method,
+				Path:       event.Path,
+				RequestID:  event.RequestID,
+				Message:    event.Err.Error(),
+			})
+		}
+	}
+
+	// Render into a buffer so a template error can still become a 500
+	var page bytes.Buffer
+	if err := adminStatusPageTemplate.Execute(&page, data); err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write(page.Bytes()); err != nil {
+		logging.LogError(api.requestLogger(r), "failed to write status page", err)
+	}
+}
+
+// formatStatusTime renders an epoch-millisecond timestamp with its age, or "never" for 0.
+func formatStatusTime(millis int64, now time.Time) string {
+	if millis == 0 {
+		return "never"
+	}
+	t := time.UnixMilli(millis)
+	return t.UTC().Format(time.RFC3339) + " (" + now.Sub(t).Truncate(time.Second).String() + " ago)"
+}
@@ -0,0 +1,188 @@
+package restapi
+
+import (
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+)
+
+//go:embed admin_vehicle_map.html
+var adminVehicleMapFS embed.FS
+
+var adminVehicleMapTemplate = template.Must(template.ParseFS(adminVehicleMapFS, "admin_vehicle_map.html"))
+
+const (
+	// staleVehicleAge is how old a vehicle's last report may be before the map marks it stale.
+	staleVehicleAge = 2 * time.Minute
+
+	// vehicleStreamPollInterval is how often the vehicle stream checks for a new realtime
+	// snapshot, and for the server draining.
+	vehicleStreamPollInterval = time.Second
+
+	// vehicleStreamResendInterval bounds the time between events, so markers age into
+	// staleness and proxies keep the connection open while the feed is quiet.
+	vehicleStreamResendInterval = 15 * time.Second
+)
+
+// adminVehicleMapPolicy lets the map page load Leaflet and OpenStreetMap tiles and connect
+// back to the vehicle stream; the inline script is allowed by nonce.
+const adminVehicleMapPolicy = "default-src 'none'; script-src 'nonce-%s' https://unpkg.com; style-src 'unsafe-inline' https://unpkg.com; " +
+	"img-src 'self' data: https://tile.openstreetmap.org; connect-src 'self'; frame-ancestors 'none';"
+
+type adminVehicleMapData struct {
+	Nonce     string
+	CenterLat float64
+	CenterLon float64
+}
+
+// adminVehicleMapHandler serves a map of the live vehicles, fed by the admin vehicle stream.
+func (api *RestAPI) adminVehicleMapHandler(w http.ResponseWriter, r *http.Request) {
+	if api.GtfsManager == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "GTFS data not loaded")
+		return
+	}
+
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	// URL-safe, so the template leaves the nonce attribute unescaped
+	data := adminVehicleMapData{Nonce: base64.RawURLEncoding.EncodeToString(nonce)}
+	api.GtfsManager.RLock()
+	data.CenterLat, data.CenterLon, _, _ = api.GtfsManager.GetRegionBounds()
+	api.GtfsManager.RUnlock()
+
+	var page bytes.Buffer
+	if err := adminVehicleMapTemplate.Execute(&page, data); err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", fmt.Sprintf(adminVehicleMapPolicy, data.Nonce))
+	if _, err := w.Write(page.Bytes()); err != nil {
+		logging.LogError(api.requestLogger(r), "failed to write vehicle map page", err)
+	}
+}
+
+// adminVehicleStreamHandler streams the live vehicles as server-sent events: a "vehicles"
+// event with the full snapshot after every realtime refresh, and at least every
+// vehicleStreamResendInterval. The stream ends when the client disconnects or the server
+// starts draining.
+func (api *RestAPI) adminVehicleStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if api.GtfsManager == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "GTFS data not loaded")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(vehicleStreamPollInterval)
+	defer ticker.Stop()
+
+	var sentUpdate, sentAt time.Time
+	for {
+		updated := api.GtfsManager.LastRealtimeUpdate()
+		if sentAt.IsZero() || !updated.Equal(sentUpdate) || time.Since(sentAt) >= vehicleStreamResendInterval {
+			event, err := json.Marshal(api.adminVehicleSnapshot())
+			if err != nil {
+				logging.LogError(api.requestLogger(r), "failed to encode vehicle snapshot", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: vehicles\ndata: %s\n\n", event); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			sentUpdate, sentAt = updated, time.Now()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if api.Draining() {
+				return
+			}
+		}
+	}
+}
+
+// adminVehicleSnapshot lists the vehicles with a position, flagging those whose trip is not
+// in the static dataset or whose last report is older than staleVehicleAge.
+func (api *RestAPI) adminVehicleSnapshot() models.AdminVehicleSnapshot {
+	now := api.Clock.Now()
+	lastUpdate := api.GtfsManager.LastRealtimeUpdate()
+	enabled := api.GtfsManager.RealtimeEnabled()
+	snapshot := models.AdminVehicleSnapshot{
+		CurrentTime:         now.UnixMilli(),
+		RealtimeEnabled:     enabled,
+		RealtimeStale:       realtimeReadiness(enabled, lastUpdate, time.Now(), api.realtimeStalenessBudget()) == "stale",
+		Vehicles:            []models.AdminMapVehicle{},
+		StaleVehicleSeconds: int(staleVehicleAge / time.Second),
+	}
+	if !lastUpdate.IsZero() {
+		snapshot.RealtimeUpdated = lastUpdate.UnixMilli()
+	}
+
+	vehicles := api.GtfsManager.GetRealTimeVehicles()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+	tripRoutes := make(map[string]string)
+	for _, trip := range api.GtfsManager.GetTrips() {
+		if trip.Route != nil {
+			tripRoutes[trip.ID] = trip.Route.Id
+		}
+	}
+
+	for _, vehicle := range vehicles {
+		if vehicle.ID == nil || vehicle.Position == nil || vehicle.Position.Latitude == nil || vehicle.Position.Longitude == nil {
+			snapshot.VehiclesWithoutPosition++
+			continue
+		}
+		entry := models.AdminMapVehicle{
+			ID:  vehicle.ID.ID,
+			Lat: float64(*vehicle.Position.Latitude),
+			Lon: float64(*vehicle.Position.Longitude),
+		}
+		if bearing := vehicle.Position.Bearing; bearing != nil {
+			b := float64(*bearing)
+			entry.Bearing = &b
+		}
+		if vehicle.Timestamp != nil {
+			entry.Timestamp = vehicle.Timestamp.UnixMilli()
+			entry.Stale = now.Sub(*vehicle.Timestamp) > staleVehicleAge
+		}
+		if vehicle.Trip != nil {
+			entry.TripID = vehicle.Trip.ID.ID
+			entry.RouteID = vehicle.Trip.ID.RouteID
+			var routeID string
+			routeID, entry.TripMatched = tripRoutes[entry.TripID]
+			if entry.RouteID == "" {
+				entry.RouteID = routeID
+			}
+		}
+		if route := api.GtfsManager.FindRoute(entry.RouteID); route != nil {
+			entry.RouteShortName = route.ShortName
+		}
+		snapshot.Vehicles = append(snapshot.Vehicles, entry)
+	}
+	return snapshot
+}
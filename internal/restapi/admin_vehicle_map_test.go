@@ -0,0 +1,83 @@
+package restapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestAdminVehicleMapHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("/api/admin/vehicles.html?key=TEST").Code)
+	assert.Equal(t, http.StatusUnauthorized, get("/api/admin/vehicles/stream?key=TEST").Code)
+
+	rec := get("/api/admin/vehicles.html?key=admin-secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	policy := rec.Header().Get("Content-Security-Policy")
+	_, rest, found := strings.Cut(policy, "'nonce-")
+	require.True(t, found, policy)
+	nonce, _, _ := strings.Cut(rest, "'")
+	assert.Contains(t, rec.Body.String(), `<script nonce="`+nonce+`">`)
+	assert.Contains(t, rec.Body.String(), "EventSource")
+}
+
+func TestAdminVehicleStream(t *testing.T) {
+	api, cleanup := createTestApiWithRealTimeData(t)
+	defer cleanup()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/admin/vehicles/stream?key=admin-secret", nil)
+	require.NoError(t, err)
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: vehicles\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, "data: "), line)
+
+	var snapshot models.AdminVehicleSnapshot
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &snapshot))
+	assert.True(t, snapshot.RealtimeEnabled)
+	assert.NotZero(t, snapshot.RealtimeUpdated)
+	assert.Equal(t, 120, snapshot.StaleVehicleSeconds)
+	require.NotEmpty(t, snapshot.Vehicles)
+	for _, vehicle := range snapshot.Vehicles {
+		assert.NotEmpty(t, vehicle.ID)
+		assert.NotZero(t, vehicle.Lat)
+		// The fixture's reports are years old
+		assert.True(t, vehicle.Stale, vehicle.ID)
+	}
+}
@@ -3,6 +3,7 @@ package restapi
 import (
 	"net/http"
 
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -26,7 +27,8 @@ func (api *RestAPI) agenciesWithCoverageHandler(w http.ResponseWriter, r *http.R
 	}
 
 	// Apply pagination
-	offset, limit := utils.ParsePaginationParams(r)
+	defaultLimit, maxLimit := api.pageLimits(r, appconf.PaginationClassList, -1, models.MaxPageSize)
+	offset, limit := utils.ParsePaginationParams(r, defaultLimit, maxLimit)
 	agencies, limitExceeded := utils.PaginateSlice(agencies, offset, limit)
 
 	lat, lon, latSpan, lonSpan := api.GtfsManager.GetRegionBounds()
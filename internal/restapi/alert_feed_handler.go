@@ -0,0 +1,251 @@
+package restapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/siri"
+	"maglev.onebusaway.org/internal/syndication"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// alertFeedTagPrefix starts the tag: URIs identifying alert feeds and their entries
+const alertFeedTagPrefix = "tag:maglev.onebusaway.org,2025:"
+
+// alertsForAgencyFeedHandler serves alerts-for-agency/{id}.atom and .rss: the active service
+// alerts affecting any part of the agency, newest first.
+func (api *RestAPI) alertsForAgencyFeedHandler(w http.ResponseWriter, r *http.Request) {
+	agencyID, format, ok := alertFeedID(r)
+	if !ok {
+		api.sendNotFound(w, r)
+		return
+	}
+	now := api.Clock.Now()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(r.Context(), agencyID)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	feed := &syndication.Feed{
+		ID:     alertFeedTagPrefix + "alerts-for-agency/" + url.PathEscape(agencyID),
+		Title:  agency.Name + " service alerts",
+		Link:   agency.Url,
+		Author: agency.Name,
+	}
+	api.addAlertFeedEntries(r, feed, now, alertLanguages(w, r), func(situation siri.PtSituationElement) bool {
+		return siriSituationAffectsAgency(situation, agencyID)
+	})
+	api.sendAlertFeed(w, r, feed, format)
+}
+
+// alertsForRouteFeedHandler serves alerts-for-route/{id}.atom and .rss: the active service
+// alerts affecting the route, its trips, or its whole agency, newest first.
+func (api *RestAPI) alertsForRouteFeedHandler(w http.ResponseWriter, r *http.Request) {
+	id, format, ok := alertFeedID(r)
+	if !ok {
+		api.sendNotFound(w, r)
+		return
+	}
+	agencyID, routeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	now := api.Clock.Now()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(r.Context(), routeID)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(r.Context(), route.AgencyID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	routeLink := route.Url.String
+	if routeLink == "" {
+		routeLink = agency.Url
+	}
+	feed := &syndication.Feed{
+		ID:     alertFeedTagPrefix + "alerts-for-route/" + url.PathEscape(id),
+		Title:  icalRouteName(route) + " service alerts",
+		Link:   routeLink,
+		Author: agency.Name,
+	}
+	api.addAlertFeedEntries(r, feed, now, alertLanguages(w, r), func(situation siri.PtSituationElement) bool {
+		return siriSituationAffectsLine(situation, id, agencyID)
+	})
+	api.sendAlertFeed(w, r, feed, format)
+}
+
+// alertFeedID returns the {id} path value without its extension, and the feed format the
+// extension names: "atom" or "rss".
+func alertFeedID(r *http.Request) (id, format string, ok bool) {
+	value := r.PathValue("id")
+	for _, format := range []string{"atom", "rss"} {
+		if id, found := strings.CutSuffix(value, "."+format); found && utils.ValidateID(id) == nil {
+			return id, format, true
+		}
+	}
+	return "", "", false
+}
+
+// addAlertFeedEntries adds an entry for each alert that is active now and that include keeps,
+// in the first of langs each alert is translated to.
+// The caller must hold the manager's read lock.
+func (api *RestAPI) addAlertFeedEntries(r *http.Request, feed *syndication.Feed, now time.Time, langs []string, include func(siri.PtSituationElement) bool) {
+	alerts := api.GtfsManager.GetRealTimeAlerts()
+	firstSeen := api.alertFirstSeen(alerts, now)
+	resolver := newSiriAgencyResolver(r.Context(), api)
+	for _, alert := range alerts {
+		situation := api.siriSituation(alert, resolver, langs, now)
+		if !siriSituationActive(situation, now) || !include(situation) {
+			continue
+		}
+		feed.Entries = append(feed.Entries, alertFeedEntry(alert, situation, firstSeen[alert.ID]))
+	}
+	sort.SliceStable(feed.Entries, func(i, j int) bool {
+		return feed.Entries[i].Published.After(feed.Entries[j].Published)
+	})
+
+	feed.Updated = api.GtfsManager.LastRealtimeUpdate()
+	if feed.Updated.IsZero() {
+		feed.Updated = now
+	}
+	for _, entry := range feed.Entries {
+		if entry.Updated.After(feed.Updated) {
+			feed.Updated = entry.Updated
+		}
+	}
+}
+
+// alertFeedEntry turns an alert into a feed entry. It is published at the start of its first
+// active period, or when this server first served it when the alert gives no start.
+func alertFeedEntry(alert gtfs.Alert, situation siri.PtSituationElement, firstSeen time.Time) syndication.Entry {
+	published := firstSeen
+	if len(alert.ActivePeriods) > 0 && alert.ActivePeriods[0].StartsAt != nil {
+		published = *alert.ActivePeriods[0].StartsAt
+	}
+	entry := syndication.Entry{
+		ID:        alertFeedTagPrefix + "alert/" + url.PathEscape(alert.ID),
+		Title:     situation.Summary,
+		Content:   situation.Description,
+		Published: published,
+		Updated:   published,
+	}
+	if entry.Title == "" {
+		entry.Title = "Service alert"
+	}
+	if entry.Content == "" {
+		entry.Content = entry.Title
+	}
+	if situation.InfoLinks != nil {
+		entry.Link = situation.InfoLinks.InfoLink[0].Uri
+	}
+	if alert.Effect != gtfs.UnknownEffect {
+		entry.Categories = append(entry.Categories, alert.Effect.String())
+	}
+	if alert.Cause != gtfs.UnknownCause {
+		entry.Categories = append(entry.Categories, alert.Cause.String())
+	}
+	return entry
+}
+
+// siriSituationActive reports whether one of a situation's validity periods contains now.
+func siriSituationActive(situation siri.PtSituationElement, now time.Time) bool {
+	for _, period := range situation.ValidityPeriod {
+		if !period.StartTime.After(now) && (period.EndTime == nil || period.EndTime.After(now)) {
+			return true
+		}
+	}
+	return false
+}
+
+// siriSituationAffectsAgency reports whether a situation affects the agency's network or any
+// line, stop point or vehicle journey with the agency's prefix.
+func siriSituationAffectsAgency(situation siri.PtSituationElement, agencyID string) bool {
+	prefix := agencyID + "_"
+	if networks := situation.Affects.Networks; networks != nil {
+		for _, network := range networks.AffectedNetwork {
+			if network.AffectedOperator != nil && network.AffectedOperator.OperatorRef == agencyID {
+				return true
+			}
+		}
+	}
+	if stops := situation.Affects.StopPoints; stops != nil {
+		for _, stop := range stops.AffectedStopPoint {
+			if strings.HasPrefix(stop.StopPointRef, prefix) {
+				return true
+			}
+		}
+	}
+	if journeys := situation.Affects.VehicleJourneys; journeys != nil {
+		for _, journey := range journeys.AffectedVehicleJourney {
+			if strings.HasPrefix(journey.DatedVehicleJourneyRef, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// alertFirstSeen returns when each of alerts was first served in an alert feed, recording now
+// for new ones. GTFS-RT alerts carry no timestamp, so this keeps an entry's date stable across
+// fetches. Alerts no longer in the realtime data are forgotten.
+func (api *RestAPI) alertFirstSeen(alerts []gtfs.Alert, now time.Time) map[string]time.Time {
+	api.alertsFirstSeenMu.Lock()
+	defer api.alertsFirstSeenMu.Unlock()
+
+	current := make(map[string]time.Time, len(alerts))
+	for _, alert := range alerts {
+		seen, ok := api.alertsFirstSeen[alert.ID]
+		if !ok {
+			seen = now
+		}
+		current[alert.ID] = seen
+	}
+	api.alertsFirstSeen = current
+
+	result := make(map[string]time.Time, len(current))
+	for id, seen := range current {
+		result[id] = seen
+	}
+	return result
+}
+
+// sendAlertFeed writes the feed as Atom or RSS. The feed is encoded before the status is
+// written so an encoding failure still becomes a 500.
+func (api *RestAPI) sendAlertFeed(w http.ResponseWriter, r *http.Request, feed *syndication.Feed, format string) {
+	var buf bytes.Buffer
+	contentType := syndication.AtomMediaType
+	var err error
+	if format == "rss" {
+		contentType = syndication.RSSMediaType
+		err = feed.WriteRSS(&buf)
+	} else {
+		err = feed.WriteAtom(&buf)
+	}
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,139 @@
+package restapi
+
+import (
+	"encoding/xml"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/syndication"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestAlertFeedHandlers(t *testing.T) {
+	now := time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)
+	mockClock := clock.NewMockClock(now)
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	static := api.GtfsManager.GetStaticData()
+	require.GreaterOrEqual(t, len(static.Routes), 2)
+	routeID, otherRouteID := static.Routes[0].Id, static.Routes[1].Id
+	start := now.Add(-time.Hour)
+	end := now.Add(time.Hour)
+	expired := now.Add(-time.Minute)
+
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "FEED_DETOUR",
+		Effect:           gtfs.Detour,
+		Cause:            gtfs.Construction,
+		ActivePeriods:    []gtfs.AlertActivePeriod{{StartsAt: &start, EndsAt: &end}},
+		InformedEntities: []gtfs.AlertInformedEntity{{RouteID: &routeID}},
+		Header:           []gtfs.AlertText{{Text: "Detour on Main St"}},
+		Description:      []gtfs.AlertText{{Text: "Buses detour via 2nd St"}},
+		URL:              []gtfs.AlertText{{Text: "https://example.com/detour"}},
+	})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "FEED_OTHER_ROUTE",
+		InformedEntities: []gtfs.AlertInformedEntity{{RouteID: &otherRouteID}},
+		Header:           []gtfs.AlertText{{Text: "Stop closed"}},
+	})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "FEED_EXPIRED",
+		ActivePeriods:    []gtfs.AlertActivePeriod{{StartsAt: &start, EndsAt: &expired}},
+		InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: &agencyID}},
+		Header:           []gtfs.AlertText{{Text: "Yesterday's closure"}},
+	})
+	t.Cleanup(func() {
+		for _, id := range []string{"FEED_DETOUR", "FEED_OTHER_ROUTE", "FEED_EXPIRED"} {
+			api.GtfsManager.MockRemoveAlert(id)
+		}
+	})
+
+	type atomEntry struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Link      struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+	}
+	atomEntries := func(t *testing.T, target string) map[string]atomEntry {
+		rec := serveSiri(t, api, target)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, syndication.AtomMediaType, rec.Header().Get("Content-Type"))
+		var feed struct {
+			Title   string      `xml:"title"`
+			Entries []atomEntry `xml:"entry"`
+		}
+		require.NoError(t, xml.NewDecoder(rec.Body).Decode(&feed))
+		entries := make(map[string]atomEntry)
+		for _, entry := range feed.Entries {
+			entries[entry.Title] = entry
+		}
+		return entries
+	}
+
+	t.Run("agency", func(t *testing.T) {
+		entries := atomEntries(t, "/api/where/alerts-for-agency/"+agencyID+".atom?key="+siriTestKey)
+		assert.Contains(t, entries, "Detour on Main St")
+		assert.Contains(t, entries, "Stop closed")
+		assert.NotContains(t, entries, "Yesterday's closure", "expired alerts are left out")
+
+		detour := entries["Detour on Main St"]
+		assert.Equal(t, "tag:maglev.onebusaway.org,2025:alert/FEED_DETOUR", detour.ID)
+		assert.Equal(t, "2025-06-12T14:00:00Z", detour.Published)
+		assert.Equal(t, "https://example.com/detour", detour.Link.Href)
+		require.Len(t, detour.Categories, 2)
+		assert.Equal(t, "DETOUR", detour.Categories[0].Term)
+		assert.Equal(t, "CONSTRUCTION", detour.Categories[1].Term)
+
+		// An alert without an active period keeps the time it was first served
+		assert.Equal(t, "2025-06-12T15:00:00Z", entries["Stop closed"].Published)
+		mockClock.Advance(10 * time.Minute)
+		entries = atomEntries(t, "/api/where/alerts-for-agency/"+agencyID+".atom?key="+siriTestKey)
+		assert.Equal(t, "2025-06-12T15:00:00Z", entries["Stop closed"].Published)
+	})
+
+	t.Run("route", func(t *testing.T) {
+		entries := atomEntries(t, "/api/where/alerts-for-route/"+utils.FormCombinedID(agencyID, routeID)+".atom?key="+siriTestKey)
+		assert.Contains(t, entries, "Detour on Main St")
+		assert.NotContains(t, entries, "Stop closed")
+	})
+
+	t.Run("rss", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/where/alerts-for-route/"+utils.FormCombinedID(agencyID, routeID)+".rss?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, syndication.RSSMediaType, rec.Header().Get("Content-Type"))
+		var feed struct {
+			Channel struct {
+				Items []struct {
+					Title string `xml:"title"`
+					GUID  string `xml:"guid"`
+				} `xml:"item"`
+			} `xml:"channel"`
+		}
+		require.NoError(t, xml.NewDecoder(rec.Body).Decode(&feed))
+		require.Len(t, feed.Channel.Items, 1)
+		assert.Equal(t, "Detour on Main St", feed.Channel.Items[0].Title)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, target := range []string{
+			"/api/where/alerts-for-agency/" + agencyID + ".json?key=" + siriTestKey,
+			"/api/where/alerts-for-agency/nonexistent.atom?key=" + siriTestKey,
+			"/api/where/alerts-for-route/" + agencyID + "_nonexistent.rss?key=" + siriTestKey,
+		} {
+			rec := serveSiri(t, api, target)
+			assert.Equal(t, http.StatusNotFound, rec.Code, target)
+		}
+	})
+}
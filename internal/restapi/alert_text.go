@@ -0,0 +1,58 @@
+package restapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// alertLanguages returns the languages to show alert text in for the request, most preferred
+// first, and marks the response as depending on Accept-Language.
+func alertLanguages(w http.ResponseWriter, r *http.Request) []string {
+	w.Header().Add("Vary", "Accept-Language")
+	return utils.PreferredLanguages(r)
+}
+
+// alertText picks the translation of an alert's text to show: the first of langs that a
+// translation is in, matching the base language too (a "fr-CA" request gets "fr" text and the
+// other way round), then the untagged translation, which GTFS-RT uses for the feed's own
+// language, then the first translation. Empty translations are skipped; ok is false if all are.
+func alertText(texts []gtfs.AlertText, langs []string) (text gtfs.AlertText, ok bool) {
+	candidates := make([]gtfs.AlertText, 0, len(texts))
+	for _, t := range texts {
+		if t.Text != "" {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) == 0 {
+		return gtfs.AlertText{}, false
+	}
+
+	for _, lang := range langs {
+		for _, t := range candidates {
+			if strings.EqualFold(t.Language, lang) {
+				return t, true
+			}
+		}
+		base := baseLanguage(lang)
+		for _, t := range candidates {
+			if t.Language != "" && baseLanguage(strings.ToLower(t.Language)) == base {
+				return t, true
+			}
+		}
+	}
+	for _, t := range candidates {
+		if t.Language == "" {
+			return t, true
+		}
+	}
+	return candidates[0], true
+}
+
+// baseLanguage returns the primary subtag of a lower case language tag, such as "fr" for "fr-ca".
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return base
+}
@@ -0,0 +1,91 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/siri"
+)
+
+func TestAlertText(t *testing.T) {
+	texts := []gtfs.AlertText{
+		{Text: "Detour", Language: "en"},
+		{Text: "", Language: "de"},
+		{Text: "Desvío", Language: "es-MX"},
+		{Text: "Déviation", Language: "fr-CA"},
+		{Text: "Detour (default)"},
+	}
+
+	testCases := []struct {
+		name  string
+		langs []string
+		want  string
+	}{
+		{name: "exact", langs: []string{"es-mx"}, want: "Desvío"},
+		{name: "base language of request", langs: []string{"fr-fr"}, want: "Déviation"},
+		{name: "base language of translation", langs: []string{"es"}, want: "Desvío"},
+		{name: "order of preference", langs: []string{"ja", "en", "es"}, want: "Detour"},
+		{name: "empty translation skipped", langs: []string{"de"}, want: "Detour (default)"},
+		{name: "untagged by default", want: "Detour (default)"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			text, ok := alertText(texts, tc.langs)
+			require.True(t, ok)
+			assert.Equal(t, tc.want, text.Text)
+		})
+	}
+
+	text, ok := alertText(texts[:3], []string{"ja"})
+	require.True(t, ok)
+	assert.Equal(t, "Detour", text.Text, "first translation without an untagged one")
+	_, ok = alertText([]gtfs.AlertText{{Language: "en"}}, nil)
+	assert.False(t, ok)
+}
+
+func TestAlertTextLanguageNegotiation(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID: "TRANSLATED",
+		Header: []gtfs.AlertText{
+			{Text: "Stop closed", Language: "en"},
+			{Text: "Parada cerrada", Language: "es"},
+		},
+	})
+	t.Cleanup(func() { api.GtfsManager.MockRemoveAlert("TRANSLATED") })
+
+	summary := func(t *testing.T, query, acceptLanguage string) string {
+		mux := http.NewServeMux()
+		api.SetRoutes(mux)
+		req := httptest.NewRequest(http.MethodGet, "/api/siri/situation-exchange.json?key="+siriTestKey+query, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Values("Vary"), "Accept-Language")
+		var body struct {
+			Siri siri.Siri `json:"Siri"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		for _, situation := range body.Siri.ServiceDelivery.SituationExchangeDelivery[0].Situations.PtSituationElement {
+			if situation.SituationNumber == "TRANSLATED" {
+				return situation.Summary
+			}
+		}
+		t.Fatal("situation not found")
+		return ""
+	}
+
+	assert.Equal(t, "Stop closed", summary(t, "", ""))
+	assert.Equal(t, "Parada cerrada", summary(t, "", "es-US,en;q=0.5"))
+	assert.Equal(t, "Stop closed", summary(t, "&lang=en", "es"))
+}
@@ -0,0 +1,62 @@
+package restapi
+
+import (
+	"net/http"
+	"strings"
+
+	"maglev.onebusaway.org/internal/popularity"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// analyticsStopRoutes are the route patterns whose {id} is a stop ID.
+var analyticsStopRoutes = map[string]bool{
+	"GET /api/where/stop/{id}":                             true,
+	"GET /api/where/schedule-for-stop/{id}":                true,
+	"GET /api/where/arrivals-and-departures-for-stop/{id}": true,
+	"GET /api/where/arrival-and-departure-for-stop/{id}":   true,
+}
+
+// popularityRouteRoutes are the route patterns whose {id} is a route ID.
+var popularityRouteRoutes = map[string]bool{
+	"GET /api/where/route/{id}":              true,
+	"GET /api/where/stops-for-route/{id}":    true,
+	"GET /api/where/schedule-for-route/{id}": true,
+	"GET /api/where/trips-for-route/{id}":    true,
+}
+
+// recordAnalytics counts each served request by route pattern, and by stop ID for stop lookups
+// that succeeded, so that made-up IDs don't fill the table. Successful stop and route lookups
+// also count towards search popularity. Returns next unchanged if analytics and search
+// popularity are both disabled.
+func (api *RestAPI) recordAnalytics(next http.Handler) http.Handler {
+	if api.Analytics == nil && api.Popularity == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &responseWriter{wrappedWriter: wrappedWriter{w}, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		var stopID string
+		if analyticsStopRoutes[r.Pattern] && recorder.statusCode == http.StatusOK {
+			stopID = utils.ExtractIDFromParams(r)
+		}
+		if api.Popularity != nil && recorder.statusCode == http.StatusOK {
+			if stopID != "" {
+				api.Popularity.Record(popularity.Stop, stopID)
+			} else if popularityRouteRoutes[r.Pattern] {
+				api.Popularity.Record(popularity.Route, utils.ExtractIDFromParams(r))
+			}
+		}
+		if api.Analytics == nil {
+			return
+		}
+
+		// Drop the method from patterns like "GET /api/where/stop/{id}"
+		endpoint := r.Pattern
+		if _, path, found := strings.Cut(endpoint, " "); found {
+			endpoint = path
+		}
+		api.Analytics.Record(endpoint, stopID)
+	})
+}
@@ -0,0 +1,60 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// apiKeyUsageHandler reports per-key request counts recorded since startup.
+func (api *RestAPI) apiKeyUsageHandler(w http.ResponseWriter, r *http.Request) {
+	report := models.APIKeyUsageReport{Keys: []models.APIKeyUsage{}}
+	if api.usageTracker != nil {
+		report = api.usageTracker.Snapshot()
+	}
+
+	response := models.NewEntryResponse(report, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// quotaUsageHandler reports each key's requests in the current day and month against its
+// quota. apiKey limits the report to one key.
+func (api *RestAPI) quotaUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Quotas == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "request counting not enabled")
+		return
+	}
+
+	usage, dayReset, monthReset := api.Quotas.Snapshot()
+	report := models.QuotaUsageReport{
+		DailyReset:   dayReset.UnixMilli(),
+		MonthlyReset: monthReset.UnixMilli(),
+		Keys:         make([]models.QuotaUsage, 0, len(usage)),
+	}
+	apiKey := r.URL.Query().Get("apiKey")
+	for _, u := range usage {
+		if apiKey != "" && u.APIKey != apiKey {
+			continue
+		}
+		report.Keys = append(report.Keys, models.QuotaUsage{
+			Key:             u.APIKey,
+			DailyRequests:   u.Daily,
+			DailyLimit:      u.Limits.Daily,
+			MonthlyRequests: u.Monthly,
+			MonthlyLimit:    u.Limits.Monthly,
+		})
+	}
+
+	response := models.NewEntryResponse(report, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// usageKeyForRequest returns the usage bucket for a request: the API key itself when it
+// is valid, or a shared bucket for missing and unknown keys.
+func (api *RestAPI) usageKeyForRequest(r *http.Request) string {
+	if api.RequestHasInvalidAPIKey(r) {
+		return invalidKeyUsageBucket
+	}
+	return app.APIKeyFromRequest(r)
+}
@@ -0,0 +1,143 @@
+package restapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// invalidKeyUsageBucket groups requests made with unknown keys so that
+// arbitrary client-supplied strings can't grow the usage table without bound.
+const invalidKeyUsageBucket = "__invalid__"
+
+// keyUsage holds the counters for a single API key.
+type keyUsage struct {
+	total     int64
+	firstSeen time.Time
+	lastSeen  time.Time
+	endpoints map[string]map[int]int // endpoint pattern -> status code -> count
+}
+
+// APIKeyUsageTracker records per-key request counts by endpoint and status code.
+type APIKeyUsageTracker struct {
+	mu    sync.Mutex
+	keys  map[string]*keyUsage
+	since time.Time
+	clock clock.Clock
+}
+
+// NewAPIKeyUsageTracker creates an empty usage tracker. A nil clock falls back to the system clock.
+func NewAPIKeyUsageTracker(c clock.Clock) *APIKeyUsageTracker {
+	if c == nil {
+		c = clock.RealClock{}
+	}
+	return &APIKeyUsageTracker{
+		keys:  make(map[string]*keyUsage),
+		since: c.Now(),
+		clock: c,
+	}
+}
+
+// Record counts a single request for the given key, endpoint pattern and response status.
+func (t *APIKeyUsageTracker) Record(apiKey, endpoint string, status int) {
+	now := t.clock.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage, exists := t.keys[apiKey]
+	if !exists {
+		usage = &keyUsage{
+			firstSeen: now,
+			endpoints: make(map[string]map[int]int),
+		}
+		t.keys[apiKey] = usage
+	}
+
+	usage.total++
+	usage.lastSeen = now
+
+	statuses, exists := usage.endpoints[endpoint]
+	if !exists {
+		statuses = make(map[int]int)
+		usage.endpoints[endpoint] = statuses
+	}
+	statuses[status]++
+}
+
+// Snapshot returns a copy of the recorded usage, busiest keys first.
+func (t *APIKeyUsageTracker) Snapshot() models.APIKeyUsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]models.APIKeyUsage, 0, len(t.keys))
+	for apiKey, usage := range t.keys {
+		endpoints := make([]models.EndpointUsage, 0, len(usage.endpoints))
+		for endpoint, statuses := range usage.endpoints {
+			endpointUsage := models.EndpointUsage{
+				Endpoint:    endpoint,
+				StatusCodes: make(map[string]int, len(statuses)),
+			}
+			for status, count := range statuses {
+				endpointUsage.StatusCodes[strconv.Itoa(status)] = count
+				endpointUsage.TotalRequests += int64(count)
+			}
+			endpoints = append(endpoints, endpointUsage)
+		}
+		sort.Slice(endpoints, func(i, j int) bool {
+			if endpoints[i].TotalRequests != endpoints[j].TotalRequests {
+				return endpoints[i].TotalRequests > endpoints[j].TotalRequests
+			}
+			return endpoints[i].Endpoint < endpoints[j].Endpoint
+		})
+
+		keys = append(keys, models.APIKeyUsage{
+			Key:           apiKey,
+			TotalRequests: usage.total,
+			FirstSeen:     usage.firstSeen.UnixMilli(),
+			LastSeen:      usage.lastSeen.UnixMilli(),
+			Endpoints:     endpoints,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].TotalRequests != keys[j].TotalRequests {
+			return keys[i].TotalRequests > keys[j].TotalRequests
+		}
+		return keys[i].Key < keys[j].Key
+	})
+
+	return models.APIKeyUsageReport{
+		Since: t.since.UnixMilli(),
+		Keys:  keys,
+	}
+}
+
+// usageKeyFunc decides which usage bucket a request belongs to.
+type usageKeyFunc func(r *http.Request) string
+
+// Handler returns middleware that records the final status of every request
+// under the key chosen by keyFor.
+func (t *APIKeyUsageTracker) Handler(keyFor usageKeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped := &responseWriter{
+				wrappedWriter: wrappedWriter{w},
+				statusCode:    http.StatusOK,
+			}
+
+			next.ServeHTTP(wrapped, r)
+
+			// Use r.Pattern rather than the raw path to keep the number of endpoints bounded
+			endpoint := r.Pattern
+			if endpoint == "" {
+				endpoint = "unmatched"
+			}
+			t.Record(keyFor(r), endpoint, wrapped.statusCode)
+		})
+	}
+}
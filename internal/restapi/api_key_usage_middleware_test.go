@@ -0,0 +1,103 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestAPIKeyUsageTracker_RecordAndSnapshot(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	tracker := NewAPIKeyUsageTracker(mockClock)
+
+	tracker.Record("key-a", "GET /api/where/stop/{id}", http.StatusOK)
+	tracker.Record("key-a", "GET /api/where/stop/{id}", http.StatusNotFound)
+	mockClock.Advance(time.Minute)
+	tracker.Record("key-a", "GET /api/where/agency/{id}", http.StatusOK)
+	tracker.Record("key-b", "GET /api/where/agency/{id}", http.StatusOK)
+
+	report := tracker.Snapshot()
+	require.Len(t, report.Keys, 2)
+
+	// Busiest key first
+	keyA := report.Keys[0]
+	assert.Equal(t, "key-a", keyA.Key)
+	assert.Equal(t, int64(3), keyA.TotalRequests)
+	assert.Equal(t, mockClock.Now().Add(-time.Minute).UnixMilli(), keyA.FirstSeen)
+	assert.Equal(t, mockClock.Now().UnixMilli(), keyA.LastSeen)
+
+	require.Len(t, keyA.Endpoints, 2)
+	assert.Equal(t, "GET /api/where/stop/{id}", keyA.Endpoints[0].Endpoint)
+	assert.Equal(t, int64(2), keyA.Endpoints[0].TotalRequests)
+	assert.Equal(t, map[string]int{"200": 1, "404": 1}, keyA.Endpoints[0].StatusCodes)
+
+	assert.Equal(t, "key-b", report.Keys[1].Key)
+	assert.Equal(t, int64(1), report.Keys[1].TotalRequests)
+}
+
+func TestAPIKeyUsageTracker_HandlerRecordsStatus(t *testing.T) {
+	tracker := NewAPIKeyUsageTracker(clock.RealClock{})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /things/{id}", tracker.Handler(func(r *http.Request) string {
+		return r.URL.Query().Get("key")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/things/1?key=abc", nil)
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	report := tracker.Snapshot()
+	require.Len(t, report.Keys, 1)
+	assert.Equal(t, "abc", report.Keys[0].Key)
+	require.Len(t, report.Keys[0].Endpoints, 1)
+	assert.Equal(t, "GET /things/{id}", report.Keys[0].Endpoints[0].Endpoint)
+	assert.Equal(t, map[string]int{"418": 1}, report.Keys[0].Endpoints[0].StatusCodes)
+}
+
+func TestAPIKeyUsageHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	// Generate some traffic, including an invalid key
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=not-a-key")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	t.Run("requires admin key", func(t *testing.T) {
+		resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/admin/usage.json?key=TEST")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "permission denied", model.Text)
+	})
+
+	t.Run("reports usage per key", func(t *testing.T) {
+		resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/admin/usage.json?key=admin-secret")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		data, ok := model.Data.(map[string]interface{})
+		require.True(t, ok)
+		entry, ok := data["entry"].(map[string]interface{})
+		require.True(t, ok)
+		keys, ok := entry["keys"].([]interface{})
+		require.True(t, ok)
+
+		usageByKey := make(map[string]map[string]interface{})
+		for _, k := range keys {
+			keyUsage := k.(map[string]interface{})
+			usageByKey[keyUsage["key"].(string)] = keyUsage
+		}
+
+		require.Contains(t, usageByKey, "TEST")
+		assert.Equal(t, float64(1), usageByKey["TEST"]["totalRequests"])
+		require.Contains(t, usageByKey, invalidKeyUsageBucket)
+		assert.NotContains(t, usageByKey, "not-a-key")
+	})
+}
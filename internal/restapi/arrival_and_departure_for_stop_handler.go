@@ -8,6 +8,7 @@ import (
 
 	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/clock"
 	GTFS "maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
@@ -21,6 +22,7 @@ type ArrivalAndDepartureParams struct {
 	ServiceDate   *time.Time
 	VehicleID     string
 	StopSequence  *int
+	Clock         clock.Clock // Server clock, or the admin debugTime clock
 }
 
 // parseArrivalAndDepartureParams parses and validates request parameters.
@@ -34,6 +36,13 @@ func (api *RestAPI) parseArrivalAndDepartureParams(r *http.Request) (ArrivalAndD
 	// Initialize errors map
 	fieldErrors := make(map[string][]string)
 
+	// Validate debugTime
+	if requestClock, err := api.requestClock(r); err == nil {
+		params.Clock = requestClock
+	} else {
+		fieldErrors[debugTimeParam] = []string{err.Error()}
+	}
+
 	// Validate minutesAfter
 	if minutesAfterStr := r.URL.Query().Get("minutesAfter"); minutesAfterStr != "" {
 		if minutesAfter, err := strconv.Atoi(minutesAfterStr); err == nil {
@@ -110,7 +119,7 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	agencyID, stopCode, err := utils.ExtractAgencyIDAndCodeID(stopID)
+	agencyID, stopCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(stopID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -147,7 +156,7 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	_, tripID, err := utils.ExtractAgencyIDAndCodeID(params.TripID)
+	_, tripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(params.TripID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -224,30 +233,20 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 	if params.Time != nil {
 		currentTime = params.Time.In(loc)
 	} else {
-		currentTime = api.Clock.Now().In(loc)
+		currentTime = params.Clock.Now().In(loc)
 	}
 
 	// Use the provided service date
 	serviceDate := *params.ServiceDate
 	serviceDateMillis := serviceDate.Unix() * 1000
 
-	// Service date is a "date" only, so get midnight in agency's TZ
-	serviceMidnight := time.Date(
-		serviceDate.Year(),
-		serviceDate.Month(),
-		serviceDate.Day(),
-		0, 0, 0, 0,
-		loc,
-	)
-
-	// Arrival time is stored in nanoseconds since midnight → convert to duration
-	// arrival and departure time is stored in nanoseconds (sqlite)
-	arrivalOffset := time.Duration(targetStopTime.ArrivalTime)
-	departureOffset := time.Duration(targetStopTime.DepartureTime)
+	// Service date is a "date" only; stop times count from its start in the agency's TZ, which
+	// is an hour off midnight on the days clocks change
+	serviceDay := time.Date(serviceDate.Year(), serviceDate.Month(), serviceDate.Day(), 0, 0, 0, 0, loc)
 
-	// Add offsets to midnight
-	scheduledArrivalTime := serviceMidnight.Add(arrivalOffset)
-	scheduledDepartureTime := serviceMidnight.Add(departureOffset)
+	// Arrival and departure times are stored in nanoseconds (sqlite)
+	scheduledArrivalTime := utils.ServiceTime(serviceDay, time.Duration(targetStopTime.ArrivalTime))
+	scheduledDepartureTime := utils.ServiceTime(serviceDay, time.Duration(targetStopTime.DepartureTime))
 
 	// Convert to ms since epoch
 	scheduledArrivalTimeMs := scheduledArrivalTime.UnixMilli()
@@ -266,9 +265,9 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 	// If vehicleId is provided, validate it matches the trip
 	var vehicle *gtfs.Vehicle
 	if params.VehicleID != "" {
-		_, providedVehicleID, err := utils.ExtractAgencyIDAndCodeID(params.VehicleID)
+		providedAgencyID, providedVehicleID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(params.VehicleID)
 		if err == nil {
-			v, err := api.GtfsManager.GetVehicleByID(providedVehicleID)
+			v, err := api.GtfsManager.GetVehicleForAgency(providedAgencyID, providedVehicleID)
 			// If vehicle is found, validate it matches the trip
 			if err == nil && v != nil && v.Trip != nil && v.Trip.ID.ID == tripID {
 				vehicle = v
@@ -325,6 +324,12 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 
 	situationIDs := api.GetSituationIDsForTrip(r.Context(), tripID)
 
+	expectedArrivalTime := scheduledArrivalTime
+	if predicted && predictedArrivalTime != 0 {
+		expectedArrivalTime = time.UnixMilli(predictedArrivalTime)
+	}
+	occupancyStatus, predictedOccupancy, historicalOccupancy := api.occupancy(ctx, route.ID, tripID, stopCode, expectedArrivalTime, currentTime, vehicle)
+
 	arrival := models.NewArrivalAndDeparture(
 		utils.FormCombinedID(agencyID, route.ID),
 		route.ShortName.String,
@@ -348,9 +353,9 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		blockTripSequence,
 		distanceFromStop,
 		"default", // status
-		"",        // occupancyStatus
-		"",        // predictedOccupancy
-		"",        // historicalOccupancy
+		occupancyStatus,
+		predictedOccupancy,
+		historicalOccupancy,
 		tripStatus,
 		situationIDs,
 	)
@@ -384,7 +389,7 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 
 	// Include active trip if it's different from the parameter trip and trip status is not null
 	if tripStatus != nil && tripStatus.ActiveTripID != "" {
-		_, activeTripID, err := utils.ExtractAgencyIDAndCodeID(tripStatus.ActiveTripID)
+		_, activeTripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(tripStatus.ActiveTripID)
 		if err == nil && activeTripID != tripID {
 			activeTrip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, activeTripID)
 			if err == nil {
@@ -412,7 +417,7 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 	// Include the next and closest stops if trip status is not null to stops reference
 	if tripStatus != nil {
 		if tripStatus.NextStop != "" {
-			_, nextStopID, err := utils.ExtractAgencyIDAndCodeID(tripStatus.NextStop)
+			_, nextStopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(tripStatus.NextStop)
 
 			if err != nil {
 				api.serverErrorResponse(w, r, err)
@@ -422,7 +427,7 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 			stopIDSet[nextStopID] = true
 		}
 		if tripStatus.ClosestStop != "" {
-			_, closestStopID, err := utils.ExtractAgencyIDAndCodeID(tripStatus.ClosestStop)
+			_, closestStopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(tripStatus.ClosestStop)
 
 			if err != nil {
 				api.serverErrorResponse(w, r, err)
@@ -488,17 +493,9 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		references.Routes = append(references.Routes, routeRef)
 	}
 
-	if len(situationIDs) > 0 {
-		alerts := api.GtfsManager.GetAlertsForTrip(r.Context(), tripID)
-		if len(alerts) > 0 {
-			situations := api.BuildSituationReferences(alerts, agencyID)
-			for _, situation := range situations {
-				references.Situations = append(references.Situations, situation)
-			}
-		}
-	}
+	api.addSituationReferences(&references, situationIDs, alertLanguages(w, r))
 
-	response := models.NewEntryResponse(arrival, references, api.Clock)
+	response := models.NewEntryResponse(arrival, references, params.Clock)
 	api.sendResponse(w, r, response)
 }
 
@@ -0,0 +1,243 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/notify"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	maxNotificationMinutesBefore = 120
+	// notificationExpiry is how long after the scheduled arrival a subscription waits for a
+	// late trip before it is dropped unnotified.
+	notificationExpiry = time.Hour
+)
+
+// createArrivalNotificationHandler subscribes the caller's API key to a notification posted to
+// callbackUrl when tripId is predicted to be minutesBefore minutes from stopId. serviceDate
+// (Unix milliseconds) defaults to today in the agency's time zone.
+func (api *RestAPI) createArrivalNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Notifications == nil {
+		api.sendError(w, r, http.StatusNotFound, "arrival notifications are not enabled")
+		return
+	}
+
+	fieldErrors := make(map[string][]string)
+	stopID := r.FormValue("stopId")
+	tripID := r.FormValue("tripId")
+	_, stopCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(stopID)
+	if err != nil {
+		fieldErrors["stopId"] = []string{err.Error()}
+	}
+	agencyID, tripCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(tripID)
+	if err != nil {
+		fieldErrors["tripId"] = []string{err.Error()}
+	}
+
+	minutesBefore, err := strconv.Atoi(r.FormValue("minutesBefore"))
+	if err != nil || minutesBefore < 0 || minutesBefore > maxNotificationMinutesBefore {
+		fieldErrors["minutesBefore"] = []string{"must be a whole number of minutes from 0 to " + strconv.Itoa(maxNotificationMinutesBefore)}
+	}
+
+	callbackURL := r.FormValue("callbackUrl")
+	if u, err := url.Parse(callbackURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fieldErrors["callbackUrl"] = []string{"must be an http(s) URL"}
+	}
+
+	var serviceDateMillis int64
+	if val := r.FormValue("serviceDate"); val != "" {
+		serviceDateMillis, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			fieldErrors["serviceDate"] = []string{"must be a valid Unix timestamp in milliseconds"}
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripCode)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agencyID)
+	now := api.Clock.Now().In(loc)
+
+	day := now
+	if serviceDateMillis != 0 {
+		day = time.UnixMilli(serviceDateMillis).In(loc)
+	}
+	serviceDate := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	active, err := api.GtfsManager.IsServiceActiveOnDate(ctx, trip.ServiceID, serviceDate)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if active == 0 {
+		api.validationErrorResponse(w, r, map[string][]string{"serviceDate": {"trip does not run on this service date"}})
+		return
+	}
+
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, tripCode)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	var scheduled time.Time
+	var stopSequence int64
+	found := false
+	for _, st := range stopTimes {
+		if st.StopID == stopCode {
+			scheduled = utils.ServiceTime(serviceDate, time.Duration(st.ArrivalTime))
+			stopSequence = st.StopSequence
+			found = true
+			break
+		}
+	}
+	if !found {
+		api.validationErrorResponse(w, r, map[string][]string{"stopId": {"trip does not serve this stop"}})
+		return
+	}
+	expires := scheduled.Add(notificationExpiry)
+	if !now.Before(expires) {
+		api.validationErrorResponse(w, r, map[string][]string{"tripId": {"trip has already served this stop"}})
+		return
+	}
+
+	sub, err := api.Notifications.Add(notify.Subscription{
+		APIKey:       app.APIKeyFromRequest(r),
+		StopID:       stopID,
+		TripID:       tripID,
+		ServiceDate:  serviceDate,
+		StopSequence: stopSequence,
+		Scheduled:    scheduled,
+		Lead:         time.Duration(minutesBefore) * time.Minute,
+		CallbackURL:  callbackURL,
+		Expires:      expires,
+	})
+	if errors.Is(err, notify.ErrLimitReached) {
+		api.sendError(w, r, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	response := models.NewEntryResponse(arrivalNotificationModel(sub), models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// arrivalNotificationsHandler lists the caller's active subscriptions, oldest first.
+func (api *RestAPI) arrivalNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Notifications == nil {
+		api.sendError(w, r, http.StatusNotFound, "arrival notifications are not enabled")
+		return
+	}
+
+	subs := api.Notifications.List(app.APIKeyFromRequest(r))
+	list := make([]models.ArrivalNotification, 0, len(subs))
+	for _, sub := range subs {
+		list = append(list, arrivalNotificationModel(sub))
+	}
+	response := models.NewListResponse(list, models.NewEmptyReferences(), false, api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// arrivalNotificationHandler returns one of the caller's active subscriptions. Subscriptions
+// that fired, expired or belong to another key are not found.
+func (api *RestAPI) arrivalNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Notifications == nil {
+		api.sendError(w, r, http.StatusNotFound, "arrival notifications are not enabled")
+		return
+	}
+
+	sub, ok := api.Notifications.Get(utils.ExtractIDFromParams(r), app.APIKeyFromRequest(r))
+	if !ok {
+		api.sendNotFound(w, r)
+		return
+	}
+	response := models.NewEntryResponse(arrivalNotificationModel(sub), models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// deleteArrivalNotificationHandler cancels one of the caller's subscriptions.
+func (api *RestAPI) deleteArrivalNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Notifications == nil {
+		api.sendError(w, r, http.StatusNotFound, "arrival notifications are not enabled")
+		return
+	}
+
+	if !api.Notifications.Remove(utils.ExtractIDFromParams(r), app.APIKeyFromRequest(r)) {
+		api.sendNotFound(w, r)
+		return
+	}
+	response := models.NewEntryResponse(map[string]bool{"removed": true}, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// estimateArrival predicts when a subscription's trip reaches its stop from the same realtime
+// state as arrivals-and-departures-for-stop, falling back to the schedule.
+func (api *RestAPI) estimateArrival(ctx context.Context, sub notify.Subscription) (notify.Estimate, bool) {
+	_, tripCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(sub.TripID)
+	if err != nil {
+		return notify.Estimate{}, false
+	}
+	_, stopCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(sub.StopID)
+	if err != nil {
+		return notify.Estimate{}, false
+	}
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	scheduled := sub.Scheduled.UnixMilli()
+	prediction := api.predictStopTime(tripCode, stopCode, sub.StopSequence, scheduled, scheduled)
+	// A vehicle on the same trip on another service date says nothing about this one
+	if vehicle := prediction.Vehicle; vehicle != nil && vehicle.Trip != nil && vehicle.Trip.ID.HasStartDate &&
+		vehicle.Trip.ID.StartDate.Format("20060102") != sub.ServiceDate.Format("20060102") {
+		return notify.Estimate{ArrivalTime: sub.Scheduled}, true
+	}
+	return notify.Estimate{
+		ArrivalTime: time.UnixMilli(prediction.ArrivalTime),
+		Predicted:   prediction.Predicted,
+		VehicleID:   prediction.VehicleID,
+	}, true
+}
+
+func arrivalNotificationModel(sub notify.Subscription) models.ArrivalNotification {
+	return models.ArrivalNotification{
+		ID:                   sub.ID,
+		StopID:               sub.StopID,
+		TripID:               sub.TripID,
+		ServiceDate:          sub.ServiceDate.UnixMilli(),
+		StopSequence:         sub.StopSequence,
+		ScheduledArrivalTime: sub.Scheduled.UnixMilli(),
+		MinutesBefore:        int(sub.Lead / time.Minute),
+		CallbackURL:          sub.CallbackURL,
+		CreatedTime:          sub.Created.UnixMilli(),
+		ExpiresTime:          sub.Expires.UnixMilli(),
+	}
+}
@@ -0,0 +1,214 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/notify"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// serveNotificationRequest sends a request with an optional form body through api's routes.
+func serveNotificationRequest(t *testing.T, api *RestAPI, method, target string, form url.Values) (int, models.ResponseModel) {
+	t.Helper()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var model models.ResponseModel
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&model))
+	return rec.Code, model
+}
+
+// notificationTestTrip finds a RABA trip running on 2025-12-26 and its second stop.
+func notificationTestTrip(t *testing.T, api *RestAPI) (agencyID string, trip gtfsdb.Trip, stopTime gtfsdb.StopTime, loc *time.Location) {
+	t.Helper()
+	ctx := context.Background()
+	queries := api.GtfsManager.GtfsDB.Queries
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	loc = utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.Id)
+
+	serviceIDs, err := queries.GetActiveServiceIDsForDate(ctx, "20251226")
+	require.NoError(t, err)
+	active := make(map[string]bool, len(serviceIDs))
+	for _, id := range serviceIDs {
+		active[id] = true
+	}
+
+	for _, scheduled := range api.GtfsManager.GetTrips() {
+		if !active[scheduled.Service.Id] {
+			continue
+		}
+		trip, err := queries.GetTrip(ctx, scheduled.ID)
+		require.NoError(t, err)
+		stopTimes, err := queries.GetStopTimesForTrip(ctx, trip.ID)
+		require.NoError(t, err)
+		if len(stopTimes) > 1 {
+			return agency.Id, trip, stopTimes[1], loc
+		}
+	}
+	t.Fatal("no trip runs on 2025-12-26")
+	return
+}
+
+func TestArrivalNotifications(t *testing.T) {
+	var mu sync.Mutex
+	var received []notify.Notification
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notify.Notification
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	t.Cleanup(callback.Close)
+
+	mockClock := clock.NewMockClock(time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	agencyID, trip, stopTime, loc := notificationTestTrip(t, api)
+	serviceDate := time.Date(2025, 12, 26, 0, 0, 0, 0, loc)
+	scheduled := serviceDate.Add(time.Duration(stopTime.ArrivalTime))
+	mockClock.Set(scheduled.Add(-30 * time.Minute))
+
+	api.Notifications = notify.NewManager(appconf.NotificationsConfig{Enabled: true, MaxPerKey: 10}, nil, mockClock, nil)
+	api.Notifications.SetEstimator(api.estimateArrival)
+
+	tripID := utils.FormCombinedID(agencyID, trip.ID)
+	stopID := utils.FormCombinedID(agencyID, stopTime.StopID)
+	status, model := serveNotificationRequest(t, api, http.MethodPost, "/api/where/arrival-notifications.json?key=TEST", url.Values{
+		"tripId":        {tripID},
+		"stopId":        {stopID},
+		"minutesBefore": {"10"},
+		"callbackUrl":   {callback.URL},
+	})
+	require.Equal(t, http.StatusOK, status, model.Text)
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	id := entry["id"].(string)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, tripID, entry["tripId"])
+	assert.Equal(t, stopID, entry["stopId"])
+	assert.Equal(t, float64(serviceDate.UnixMilli()), entry["serviceDate"], "defaults to today")
+	assert.Equal(t, float64(scheduled.UnixMilli()), entry["scheduledArrivalTime"])
+	assert.Equal(t, float64(scheduled.Add(time.Hour).UnixMilli()), entry["expiresTime"])
+	assert.Equal(t, 10.0, entry["minutesBefore"])
+
+	_, model = serveNotificationRequest(t, api, http.MethodGet, "/api/where/arrival-notifications.json?key=TEST", nil)
+	assert.Len(t, model.Data.(map[string]interface{})["list"], 1)
+	_, model = serveNotificationRequest(t, api, http.MethodGet, "/api/where/arrival-notifications.json?key=test", nil)
+	assert.Empty(t, model.Data.(map[string]interface{})["list"], "subscriptions are scoped to their API key")
+	status, _ = serveNotificationRequest(t, api, http.MethodGet, "/api/where/arrival-notification/"+id+".json?key=TEST", nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	api.Notifications.Evaluate(context.Background())
+	mu.Lock()
+	assert.Empty(t, received, "30 minutes away is outside the lead time")
+	mu.Unlock()
+
+	mockClock.Set(scheduled.Add(-8 * time.Minute))
+	api.Notifications.Evaluate(context.Background())
+	mu.Lock()
+	require.Len(t, received, 1)
+	assert.Equal(t, id, received[0].SubscriptionID)
+	assert.Equal(t, scheduled.UnixMilli(), received[0].ArrivalTime)
+	assert.False(t, received[0].Predicted, "no realtime data serves the trip")
+	assert.Equal(t, 8, received[0].MinutesAway)
+	mu.Unlock()
+
+	status, _ = serveNotificationRequest(t, api, http.MethodGet, "/api/where/arrival-notification/"+id+".json?key=TEST", nil)
+	assert.Equal(t, http.StatusNotFound, status, "a subscription fires once")
+}
+
+func TestArrivalNotificationsDelete(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	agencyID, trip, stopTime, loc := notificationTestTrip(t, api)
+	mockClock.Set(time.Date(2025, 12, 26, 0, 0, 0, 0, loc).Add(time.Duration(stopTime.ArrivalTime) - 30*time.Minute))
+	api.Notifications = notify.NewManager(appconf.NotificationsConfig{Enabled: true}, nil, mockClock, nil)
+
+	status, model := serveNotificationRequest(t, api, http.MethodPost, "/api/where/arrival-notifications.json?key=TEST", url.Values{
+		"tripId":        {utils.FormCombinedID(agencyID, trip.ID)},
+		"stopId":        {utils.FormCombinedID(agencyID, stopTime.StopID)},
+		"minutesBefore": {"5"},
+		"callbackUrl":   {"https://example.com/hook"},
+	})
+	require.Equal(t, http.StatusOK, status, model.Text)
+	id := model.Data.(map[string]interface{})["entry"].(map[string]interface{})["id"].(string)
+
+	status, _ = serveNotificationRequest(t, api, http.MethodDelete, "/api/where/arrival-notification/"+id+".json?key=test", nil)
+	assert.Equal(t, http.StatusNotFound, status, "another key cannot cancel the subscription")
+	status, _ = serveNotificationRequest(t, api, http.MethodDelete, "/api/where/arrival-notification/"+id+".json?key=TEST", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 0, api.Notifications.Len())
+}
+
+func TestArrivalNotificationsValidation(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	agencyID, trip, stopTime, loc := notificationTestTrip(t, api)
+	mockClock.Set(time.Date(2025, 12, 26, 0, 0, 0, 0, loc).Add(time.Duration(stopTime.ArrivalTime) - 30*time.Minute))
+	api.Notifications = notify.NewManager(appconf.NotificationsConfig{Enabled: true}, nil, mockClock, nil)
+
+	valid := func() url.Values {
+		return url.Values{
+			"tripId":        {utils.FormCombinedID(agencyID, trip.ID)},
+			"stopId":        {utils.FormCombinedID(agencyID, stopTime.StopID)},
+			"minutesBefore": {"5"},
+			"callbackUrl":   {"https://example.com/hook"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		field  string
+		value  string
+		status int
+	}{
+		{"callback not http", "callbackUrl", "ftp://example.com/hook", http.StatusBadRequest},
+		{"minutes out of range", "minutesBefore", "500", http.StatusBadRequest},
+		{"malformed trip", "tripId", "nounderscore", http.StatusBadRequest},
+		{"unknown trip", "tripId", agencyID + "_missing", http.StatusNotFound},
+		{"stop not on trip", "stopId", agencyID + "_missing", http.StatusBadRequest},
+		{"trip not running", "serviceDate", strconv.FormatInt(time.Date(2000, 1, 1, 0, 0, 0, 0, loc).UnixMilli(), 10), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := valid()
+			form.Set(tt.field, tt.value)
+			status, _ := serveNotificationRequest(t, api, http.MethodPost, "/api/where/arrival-notifications.json?key="+siriTestKey, form)
+			assert.Equal(t, tt.status, status)
+		})
+	}
+	assert.Equal(t, 0, api.Notifications.Len())
+}
+
+func TestArrivalNotificationsDisabled(t *testing.T) {
+	api := createTestApi(t)
+	status, model := serveNotificationRequest(t, api, http.MethodGet, "/api/where/arrival-notifications.json?key=TEST", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "arrival notifications are not enabled", model.Text)
+}
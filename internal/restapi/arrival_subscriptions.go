@@ -0,0 +1,234 @@
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	// arrivalSubscriptionWindow is how far ahead pushed arrivals look, the default of
+	// arrivals-and-departures-for-stop.
+	arrivalSubscriptionWindow = 35 * time.Minute
+
+	// arrivalSubscriptionRefreshInterval bounds the time between recomputing subscribed stops,
+	// so departed trips drop out and new ones come into the window without realtime refreshes.
+	arrivalSubscriptionRefreshInterval = time.Minute
+)
+
+// errUnknownStop reports a subscription to a stop the static feed does not have.
+var errUnknownStop = errors.New("stop not found")
+
+// arrivalSubscriptions pushes the upcoming arrivals at stops to the WebSocket clients subscribed
+// to them. Each subscribed stop is recomputed once per GTFS-RT refresh, however many clients
+// follow it, and pushed only when its arrivals changed. The refresh loop runs while any stop is
+// subscribed.
+type arrivalSubscriptions struct {
+	api   *RestAPI
+	mu    sync.Mutex
+	stops map[string]*subscribedStop // By combined stop ID
+	done  chan struct{}              // Ends the refresh loop; nil while it is not running
+}
+
+// subscribedStop is a stop's subscribers and the arrivals message they were last sent.
+type subscribedStop struct {
+	subscribers map[*arrivalSubscriber]struct{}
+	message     []byte
+}
+
+func newArrivalSubscriptions(api *RestAPI) *arrivalSubscriptions {
+	return &arrivalSubscriptions{api: api, stops: make(map[string]*subscribedStop)}
+}
+
+// subscribe adds sub to stopID's subscribers and queues the stop's current arrivals for it.
+// Returns errUnknownStop for a stop the static feed does not have.
+func (s *arrivalSubscriptions) subscribe(ctx context.Context, sub *arrivalSubscriber, stopID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stop := s.stops[stopID]
+	if stop == nil {
+		message, err := s.api.arrivalsMessage(ctx, stopID)
+		if err != nil {
+			return err
+		}
+		stop = &subscribedStop{subscribers: make(map[*arrivalSubscriber]struct{}), message: message}
+		s.stops[stopID] = stop
+	}
+	stop.subscribers[sub] = struct{}{}
+	sub.push(stopID, stop.message)
+
+	if s.done == nil {
+		s.done = make(chan struct{})
+		go s.run(s.done)
+	}
+	return nil
+}
+
+// unsubscribe removes sub from the subscribers of stopIDs, forgetting stops nobody follows any more.
+func (s *arrivalSubscriptions) unsubscribe(sub *arrivalSubscriber, stopIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stopID := range stopIDs {
+		stop := s.stops[stopID]
+		if stop == nil {
+			continue
+		}
+		delete(stop.subscribers, sub)
+		if len(stop.subscribers) == 0 {
+			delete(s.stops, stopID)
+		}
+	}
+	if len(s.stops) == 0 && s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
+}
+
+// run recomputes the subscribed stops after each GTFS-RT refresh, and at least every
+// arrivalSubscriptionRefreshInterval, until done is closed.
+func (s *arrivalSubscriptions) run(done chan struct{}) {
+	updates, unsubscribe := s.api.GtfsManager.SubscribeRealtime()
+	defer unsubscribe()
+	ticker := time.NewTicker(arrivalSubscriptionRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case update := <-updates:
+			if update.Trips == nil {
+				// Only trip updates move predictions
+				continue
+			}
+		case <-ticker.C:
+		}
+		s.refresh(context.Background())
+	}
+}
+
+// refresh recomputes every subscribed stop and pushes those whose arrivals changed.
+func (s *arrivalSubscriptions) refresh(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for stopID, stop := range s.stops {
+		message, err := s.api.arrivalsMessage(ctx, stopID)
+		if err != nil {
+			if !errors.Is(err, errUnknownStop) {
+				logging.LogError(s.api.Logger, "failed to compute subscribed arrivals", err, slog.String("stop_id", stopID))
+			}
+			continue
+		}
+		if bytes.Equal(message, stop.message) {
+			continue
+		}
+		stop.message = message
+		for sub := range stop.subscribers {
+			sub.push(stopID, message)
+		}
+	}
+}
+
+// arrivalsMessage renders the arrivals message of stopID, a combined stop ID.
+func (api *RestAPI) arrivalsMessage(ctx context.Context, stopID string) ([]byte, error) {
+	agencyID, stopCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(stopID)
+	if err != nil {
+		return nil, errUnknownStop
+	}
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	agency := api.GtfsManager.FindAgency(agencyID)
+	if agency == nil {
+		return nil, errUnknownStop
+	}
+	if _, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopCode); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errUnknownStop
+		}
+		return nil, err
+	}
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agencyID)
+	departures, err := api.upcomingDepartures(ctx, stopCode, api.Clock.Now().In(loc), arrivalSubscriptionWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	message := models.ArrivalsSubscriptionMessage{
+		Type:     "arrivals",
+		StopID:   stopID,
+		Arrivals: make([]models.SubscribedArrival, len(departures)),
+	}
+	for i, d := range departures {
+		message.Arrivals[i] = models.SubscribedArrival{
+			RouteID:                utils.FormCombinedID(d.Route.AgencyID, d.Route.ID),
+			RouteShortName:         utils.NullStringOrEmpty(d.Route.ShortName),
+			TripID:                 utils.FormCombinedID(d.Route.AgencyID, d.Trip.ID),
+			TripHeadsign:           utils.NullStringOrEmpty(d.Trip.TripHeadsign),
+			ServiceDate:            d.ServiceDate.UnixMilli(),
+			StopSequence:           d.StopTime.StopSequence,
+			ScheduledArrivalTime:   d.AimedArrival.UnixMilli(),
+			ScheduledDepartureTime: d.AimedDeparture.UnixMilli(),
+			Predicted:              d.Prediction.Predicted,
+			PredictedArrivalTime:   d.Prediction.ArrivalTime,
+			PredictedDepartureTime: d.Prediction.DepartureTime,
+			VehicleID:              d.Prediction.VehicleID,
+		}
+	}
+	return json.Marshal(message)
+}
+
+// arrivalSubscriber is one WebSocket client's queue of arrivals messages. Only the latest
+// message for each stop is kept, so a slow client skips intermediate updates instead of
+// holding up the others.
+type arrivalSubscriber struct {
+	mu      sync.Mutex
+	pending map[string][]byte // By stop ID
+	ready   chan struct{}
+}
+
+func newArrivalSubscriber() *arrivalSubscriber {
+	return &arrivalSubscriber{pending: make(map[string][]byte), ready: make(chan struct{}, 1)}
+}
+
+// push queues message for stopID, replacing any not yet taken.
+func (sub *arrivalSubscriber) push(stopID string, message []byte) {
+	sub.mu.Lock()
+	sub.pending[stopID] = message
+	sub.mu.Unlock()
+	select {
+	case sub.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the queued messages, ordered by stop ID, and empties the queue.
+func (sub *arrivalSubscriber) take() [][]byte {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	stopIDs := make([]string, 0, len(sub.pending))
+	for stopID := range sub.pending {
+		stopIDs = append(stopIDs, stopID)
+	}
+	slices.Sort(stopIDs)
+	messages := make([][]byte, len(stopIDs))
+	for i, stopID := range stopIDs {
+		messages[i] = sub.pending[stopID]
+	}
+	clear(sub.pending)
+	return messages
+}
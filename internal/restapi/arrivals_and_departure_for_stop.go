@@ -7,7 +7,9 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/clock"
 	GTFS "maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
@@ -18,6 +20,7 @@ type ArrivalsStopParams struct {
 	MinutesAfter  int
 	MinutesBefore int
 	Time          time.Time
+	Clock         clock.Clock // Server clock, or the admin debugTime clock
 }
 
 // parseArrivalsAndDeparturesParams parses and validates parameters.
@@ -26,9 +29,9 @@ func (api *RestAPI) parseArrivalsAndDeparturesParams(r *http.Request) (ArrivalsS
 	const maxMinutesAfter = 240
 
 	params := ArrivalsStopParams{
-		MinutesAfter:  35,              // Default
-		MinutesBefore: 5,               // Default
-		Time:          api.Clock.Now(), // Default to current time
+		MinutesAfter:  35, // Default
+		MinutesBefore: 5,  // Default
+		Clock:         api.Clock,
 	}
 
 	var fieldErrors map[string][]string
@@ -40,6 +43,13 @@ func (api *RestAPI) parseArrivalsAndDeparturesParams(r *http.Request) (ArrivalsS
 		fieldErrors[field] = append(fieldErrors[field], msg)
 	}
 
+	if requestClock, err := api.requestClock(r); err == nil {
+		params.Clock = requestClock
+	} else {
+		addError(debugTimeParam, err.Error())
+	}
+	params.Time = params.Clock.Now() // Default to current time
+
 	query := r.URL.Query()
 
 	if val := query.Get("minutesAfter"); val != "" {
@@ -92,7 +102,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		return
 	}
 
-	agencyID, stopCode, err := utils.ExtractAgencyIDAndCodeID(stopID)
+	agencyID, stopCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(stopID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -101,8 +111,6 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		return
 	}
 
-	ctx := r.Context()
-
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
@@ -113,6 +121,15 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		return
 	}
 
+	api.sendArrivalsAndDeparturesForStop(w, r, params, agencyID, stopCode)
+}
+
+// sendArrivalsAndDeparturesForStop answers with the arrivals and departures at a stop, or a
+// not-found error when the stop does not exist. The caller must hold the manager's read lock.
+func (api *RestAPI) sendArrivalsAndDeparturesForStop(w http.ResponseWriter, r *http.Request, params ArrivalsStopParams, agencyID, stopCode string) {
+	ctx := r.Context()
+	stopID := utils.FormCombinedID(agencyID, stopCode)
+
 	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopCode)
 	if err != nil {
 		api.sendNotFound(w, r)
@@ -149,7 +166,8 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 	))
 
 	if len(activeServiceIDs) == 0 {
-		response := models.NewArrivalsAndDepartureResponse(arrivals, references, []string{}, []string{}, stopID, api.Clock)
+		nearbyStopIDs := getNearbyStopIDs(api, ctx, stop.Lat, stop.Lon, stopCode, agencyID, params.Time)
+		response := models.NewArrivalsAndDepartureResponse(arrivals, references, nearbyStopIDs, []string{}, stopID, params.Clock)
 		api.sendResponse(w, r, response)
 		return
 	}
@@ -178,6 +196,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			stopTimes = append(stopTimes, st)
 		}
 	}
+	stopTimes = api.applyTripModifications(ctx, stopCode, serviceDate, activeServiceIDSet, windowStartNanos, windowEndNanos, stopTimes)
 
 	// Maps for Caching and References
 	tripIDSet := make(map[string]*gtfsdb.Trip)
@@ -262,74 +281,34 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		tCopy := trip
 		tripIDSet[trip.ID] = &tCopy
 
-		scheduledArrivalTime := serviceMidnight.Add(time.Duration(st.ArrivalTime)).UnixMilli()
-		scheduledDepartureTime := serviceMidnight.Add(time.Duration(st.DepartureTime)).UnixMilli()
+		scheduledArrivalTime := utils.ServiceTime(serviceMidnight, time.Duration(st.ArrivalTime)).UnixMilli()
+		scheduledDepartureTime := utils.ServiceTime(serviceMidnight, time.Duration(st.DepartureTime)).UnixMilli()
 
+		prediction := api.predictStopTime(st.TripID, stopCode, st.StopSequence, scheduledArrivalTime, scheduledDepartureTime)
 		var (
-			predictedArrivalTime   = scheduledArrivalTime
-			predictedDepartureTime = scheduledDepartureTime
-			predicted              = false
-			vehicleID              string
+			predictedArrivalTime   = prediction.ArrivalTime
+			predictedDepartureTime = prediction.DepartureTime
+			predicted              = prediction.Predicted
+			vehicleID              = prediction.VehicleID
+			vehicle                = prediction.Vehicle
 			tripStatus             *models.TripStatusForTripDetails
 			distanceFromStop       = 0.0
 			numberOfStopsAway      = 0
 		)
 
-		// Get real-time updates from GTFS-RT
-		vehicle := api.GtfsManager.GetVehicleForTrip(st.TripID)
-		if vehicle != nil && vehicle.Trip != nil {
-			vehicleID = vehicle.ID.ID
-
-			// Fetch the Trip Update separately
-			tripUpdate, _ := api.GtfsManager.GetTripUpdateByID(st.TripID)
-
-			// Use the tripUpdate for predictions
-			if tripUpdate != nil && len(tripUpdate.StopTimeUpdates) > 0 {
-				// Look for StopTimeUpdate that matches this stop
-				for _, stopTimeUpdate := range tripUpdate.StopTimeUpdates {
-					// Match by stop sequence or stop ID
-					if (stopTimeUpdate.StopSequence != nil && int64(*stopTimeUpdate.StopSequence) == st.StopSequence) ||
-						(stopTimeUpdate.StopID != nil && *stopTimeUpdate.StopID == stopCode) {
-
-						predicted = true
-
-						// Update predicted times from GTFS-RT
-						if stopTimeUpdate.Arrival != nil && stopTimeUpdate.Arrival.Time != nil {
-							predictedArrivalTime = stopTimeUpdate.Arrival.Time.Unix() * 1000
-						} else if stopTimeUpdate.Arrival != nil && stopTimeUpdate.Arrival.Delay != nil {
-							predictedArrivalTime = scheduledArrivalTime + (stopTimeUpdate.Arrival.Delay.Nanoseconds() / 1e6)
-						}
-
-						if stopTimeUpdate.Departure != nil && stopTimeUpdate.Departure.Time != nil {
-							predictedDepartureTime = stopTimeUpdate.Departure.Time.Unix() * 1000
-						} else if stopTimeUpdate.Departure != nil && stopTimeUpdate.Departure.Delay != nil {
-							predictedDepartureTime = scheduledDepartureTime + (stopTimeUpdate.Departure.Delay.Nanoseconds() / 1e6)
-						}
-						break
-					}
-				}
-			}
-
-			if !predicted && vehicle.Position != nil {
-				predicted = true
-				predictedArrivalTime = scheduledArrivalTime
-				predictedDepartureTime = scheduledDepartureTime
-			}
-		}
-
 		if vehicle != nil {
 			status, _ := api.BuildTripStatus(ctx, agencyID, st.TripID, params.Time, params.Time)
 			if status != nil {
 				tripStatus = status
 
 				if status.NextStop != "" {
-					_, nextStopID, err := utils.ExtractAgencyIDAndCodeID(status.NextStop)
+					_, nextStopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(status.NextStop)
 					if err == nil {
 						stopIDSet[nextStopID] = true
 					}
 				}
 				if status.ClosestStop != "" {
-					_, closestStopID, err := utils.ExtractAgencyIDAndCodeID(status.ClosestStop)
+					_, closestStopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(status.ClosestStop)
 					if err == nil {
 						stopIDSet[closestStopID] = true
 					}
@@ -348,7 +327,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 
 				// If there's an active trip that's different from the current trip, add it to references
 				if status.ActiveTripID != "" {
-					_, activeTripID, err := utils.ExtractAgencyIDAndCodeID(status.ActiveTripID)
+					_, activeTripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(status.ActiveTripID)
 					if err == nil && activeTripID != st.TripID {
 						// Check cache for active trip
 						if _, exists := tripIDSet[activeTripID]; !exists {
@@ -372,6 +351,12 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			predictedDepartureTime = 0
 		}
 
+		expectedArrivalTime := scheduledArrivalTime
+		if predicted {
+			expectedArrivalTime = predictedArrivalTime
+		}
+		occupancyStatus, predictedOccupancy, historicalOccupancy := api.occupancy(ctx, route.ID, st.TripID, stopCode, time.UnixMilli(expectedArrivalTime), params.Time, vehicle)
+
 		tripStopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, st.TripID)
 		var totalStopsInTrip int
 		if err != nil {
@@ -408,9 +393,9 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			blockTripSequence,                         // blockTripSequence
 			distanceFromStop,                          // distanceFromStop
 			"default",                                 // status
-			"",                                        // occupancyStatus
-			"",                                        // predictedOccupancy
-			"",                                        // historicalOccupancy
+			occupancyStatus,                           // occupancyStatus
+			predictedOccupancy,                        // predictedOccupancy
+			historicalOccupancy,                       // historicalOccupancy
 			tripStatus,                                // tripStatus
 			api.GetSituationIDsForTrip(r.Context(), st.TripID), // situationIDs
 		)
@@ -427,7 +412,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			"",
 			trip.DirectionID.Int64,
 			utils.FormCombinedID(agencyID, trip.BlockID.String),
-			utils.FormCombinedID(agencyID, trip.ShapeID.String),
+			utils.FormCombinedID(agencyID, api.tripShapeID(trip.ID, trip.ShapeID.String, serviceDate)),
 		)
 		references.Trips = append(references.Trips, tripRef)
 	}
@@ -499,22 +484,96 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		references.Routes = append(references.Routes, routeRef)
 	}
 
-	nearbyStopIDs := getNearbyStopIDs(api, ctx, stop.Lat, stop.Lon, stopCode, agencyID)
-	response := models.NewArrivalsAndDepartureResponse(arrivals, references, nearbyStopIDs, []string{}, stopID, api.Clock)
+	var situationIDs []string
+	for _, arrival := range arrivals {
+		situationIDs = append(situationIDs, arrival.SituationIDs...)
+	}
+	api.addSituationReferences(&references, situationIDs, alertLanguages(w, r))
+
+	nearbyStopIDs := getNearbyStopIDs(api, ctx, stop.Lat, stop.Lon, stopCode, agencyID, params.Time)
+	response := models.NewArrivalsAndDepartureResponse(arrivals, references, nearbyStopIDs, []string{}, stopID, params.Clock)
 	api.sendResponse(w, r, response)
 }
 
+// stopTimePrediction is the realtime estimate for one scheduled stop time.
+type stopTimePrediction struct {
+	ArrivalTime   int64 // Unix milliseconds; the scheduled time when not predicted
+	DepartureTime int64
+	Predicted     bool
+	VehicleID     string        // Set when a vehicle is serving the trip
+	Vehicle       *gtfs.Vehicle // nil when no vehicle reports the trip
+}
+
+// predictStopTime applies the GTFS-RT trip updates and vehicle positions for tripID to its
+// scheduled stop at stopCode. A stop time update matching the stop sequence or stop ID gives
+// the prediction; a vehicle on the trip without one counts as running on schedule.
+func (api *RestAPI) predictStopTime(tripID, stopCode string, stopSequence, scheduledArrivalTime, scheduledDepartureTime int64) stopTimePrediction {
+	prediction := stopTimePrediction{ArrivalTime: scheduledArrivalTime, DepartureTime: scheduledDepartureTime}
+
+	vehicle := api.GtfsManager.GetVehicleForTrip(tripID)
+	prediction.Vehicle = vehicle
+	if vehicle == nil || vehicle.Trip == nil {
+		return prediction
+	}
+	prediction.VehicleID = vehicle.ID.ID
+
+	// Fetch the Trip Update separately
+	tripUpdate, _ := api.GtfsManager.GetTripUpdateByID(tripID)
+
+	// Use the tripUpdate for predictions
+	if tripUpdate != nil && len(tripUpdate.StopTimeUpdates) > 0 {
+		// Look for StopTimeUpdate that matches this stop
+		for _, stopTimeUpdate := range tripUpdate.StopTimeUpdates {
+			// Match by stop sequence or stop ID
+			if (stopTimeUpdate.StopSequence != nil && int64(*stopTimeUpdate.StopSequence) == stopSequence) ||
+				(stopTimeUpdate.StopID != nil && *stopTimeUpdate.StopID == stopCode) {
+
+				prediction.Predicted = true
+
+				// Update predicted times from GTFS-RT
+				if stopTimeUpdate.Arrival != nil && stopTimeUpdate.Arrival.Time != nil {
+					prediction.ArrivalTime = stopTimeUpdate.Arrival.Time.Unix() * 1000
+				} else if stopTimeUpdate.Arrival != nil && stopTimeUpdate.Arrival.Delay != nil {
+					prediction.ArrivalTime = scheduledArrivalTime + (stopTimeUpdate.Arrival.Delay.Nanoseconds() / 1e6)
+				}
+
+				if stopTimeUpdate.Departure != nil && stopTimeUpdate.Departure.Time != nil {
+					prediction.DepartureTime = stopTimeUpdate.Departure.Time.Unix() * 1000
+				} else if stopTimeUpdate.Departure != nil && stopTimeUpdate.Departure.Delay != nil {
+					prediction.DepartureTime = scheduledDepartureTime + (stopTimeUpdate.Departure.Delay.Nanoseconds() / 1e6)
+				}
+				break
+			}
+		}
+	}
+
+	if !prediction.Predicted && vehicle.Position != nil {
+		prediction.Predicted = true
+	}
+	return prediction
+}
+
+// convertToNanosSinceMidnight returns t as a stop time, in nanoseconds, on the service date of
+// its own day. Stop times count from noon minus 12 hours, which is midnight except on the days
+// clocks change.
 func convertToNanosSinceMidnight(t time.Time) int64 {
-	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
-	duration := t.Sub(midnight)
-	return duration.Nanoseconds()
+	return utils.SinceServiceDayStart(t).Nanoseconds()
 }
 
-func getNearbyStopIDs(api *RestAPI, ctx context.Context, lat, lon float64, stopID, agencyID string) []string {
-	nearbyStops := api.GtfsManager.GetStopsForLocation(ctx, lat, lon, 10000, 100, 100, "", 5, false, []int{}, api.Clock.Now())
-	var nearbyStopIDs []string
+const (
+	// nearbyStopsRadius is how far from a stop, in meters, its nearby stops may be, as in OneBusAway.
+	nearbyStopsRadius = 100
+	// maxNearbyStops caps the nearby stops listed with a stop's arrivals.
+	maxNearbyStops = 10
+)
+
+// getNearbyStopIDs returns the other stops with service within nearbyStopsRadius of a stop,
+// nearest first, such as the stop across the street. The caller must hold the manager's read lock.
+func getNearbyStopIDs(api *RestAPI, ctx context.Context, lat, lon float64, stopID, agencyID string, queryTime time.Time) []string {
+	nearbyStops := api.GtfsManager.GetStopsForLocation(ctx, lat, lon, nearbyStopsRadius, 0, 0, "", maxNearbyStops+1, false, nil, queryTime)
+	nearbyStopIDs := make([]string, 0, len(nearbyStops))
 	for _, s := range nearbyStops {
-		if s.ID != stopID {
+		if s.ID != stopID && len(nearbyStopIDs) < maxNearbyStops {
 			nearbyStopIDs = append(nearbyStopIDs, utils.FormCombinedID(agencyID, s.ID))
 		}
 	}
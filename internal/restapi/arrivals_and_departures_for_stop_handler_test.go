@@ -1,13 +1,16 @@
 package restapi
 
 import (
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/OneBusAway/go-gtfs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"maglev.onebusaway.org/internal/utils"
 )
 
@@ -446,3 +449,49 @@ func TestArrivalsAndDeparturesForStopHandlerWithInvalidParams(t *testing.T) {
 	resp, _ = serveApiAndRetrieveEndpoint(t, api, endpoint)
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
+
+func TestArrivalsAndDeparturesForStopHandlerNearbyStops(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	stops := api.GtfsManager.GetStops()
+	_, day := scheduledTrip(t, api)
+
+	// A stop with another one across the street
+	var target gtfs.Stop
+	closest := math.Inf(1)
+	for _, a := range stops {
+		for _, b := range stops {
+			if d := utils.Distance(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude); a.Id != b.Id && d < closest {
+				target, closest = a, d
+			}
+		}
+	}
+	require.Less(t, closest, float64(nearbyStopsRadius))
+
+	stopID := utils.FormCombinedID(agency.Id, target.Id)
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/arrivals-and-departures-for-stop/"+stopID+".json?key=TEST&time="+strconv.FormatInt(day.Add(12*time.Hour).UnixMilli(), 10))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	nearby, ok := entry["nearbyStopIds"].([]interface{})
+	require.True(t, ok)
+	require.NotEmpty(t, nearby)
+	assert.NotContains(t, nearby, stopID)
+
+	byID := make(map[string]gtfs.Stop, len(stops))
+	for _, stop := range stops {
+		byID[utils.FormCombinedID(agency.Id, stop.Id)] = stop
+	}
+	previous := 0.0
+	for _, id := range nearby {
+		stop, ok := byID[id.(string)]
+		require.True(t, ok, id)
+		d := utils.Distance(*target.Latitude, *target.Longitude, *stop.Latitude, *stop.Longitude)
+		assert.LessOrEqual(t, d, float64(nearbyStopsRadius))
+		assert.GreaterOrEqual(t, d, previous, "nearest first")
+		previous = d
+	}
+}
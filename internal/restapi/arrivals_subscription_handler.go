@@ -0,0 +1,169 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+)
+
+const (
+	// arrivalSubscriptionMaxStops is how many stops one WebSocket connection may follow.
+	arrivalSubscriptionMaxStops = 20
+
+	// arrivalSubscriptionPingInterval is how often an idle connection is pinged, so proxies keep
+	// it open and dead clients are noticed.
+	arrivalSubscriptionPingInterval = 30 * time.Second
+
+	// arrivalSubscriptionWriteTimeout bounds each write to a client.
+	arrivalSubscriptionWriteTimeout = 10 * time.Second
+
+	// arrivalSubscriptionReadLimit is the largest message a client may send.
+	arrivalSubscriptionReadLimit = 64 << 10
+)
+
+// arrivalsSubscriptionHandler upgrades to a WebSocket on which the client subscribes to stops
+// with ArrivalsSubscriptionRequest messages and is pushed each stop's upcoming arrivals, when it
+// subscribes and whenever a refresh changes them. The connection is closed when the server
+// starts draining. Browsers may only connect from the server's own host or the origins CORS
+// allows; clients that send no Origin are not browsers and may connect from anywhere.
+func (api *RestAPI) arrivalsSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		api.sendError(w, r, http.StatusUpgradeRequired, "expected a WebSocket upgrade")
+		return
+	}
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		// Patterns with a scheme, as CORS origins have, match the Origin's scheme and host
+		OriginPatterns: api.Config.CORS.AllowedOrigins,
+	})
+	if err != nil {
+		// Accept has answered the request
+		return
+	}
+	conn.SetReadLimit(arrivalSubscriptionReadLimit)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	sub := newArrivalSubscriber()
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		api.writeArrivalSubscriptions(ctx, conn, sub)
+	}()
+
+	stopIDs := make(map[string]bool)
+	defer func() {
+		cancel()
+		<-writerDone
+		for stopID := range stopIDs {
+			api.arrivalSubscriptions.unsubscribe(sub, stopID)
+		}
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+	}()
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var request models.ArrivalsSubscriptionRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			api.sendSubscriptionError(ctx, conn, "", "invalid message: "+err.Error())
+			continue
+		}
+
+		switch request.Action {
+		case "subscribe":
+			for _, stopID := range request.StopIDs {
+				if stopIDs[stopID] {
+					continue
+				}
+				if len(stopIDs) >= arrivalSubscriptionMaxStops {
+					api.sendSubscriptionError(ctx, conn, stopID, fmt.Sprintf("at most %d stops may be subscribed", arrivalSubscriptionMaxStops))
+					continue
+				}
+				if err := api.arrivalSubscriptions.subscribe(ctx, sub, stopID); err != nil {
+					if !errors.Is(err, errUnknownStop) {
+						logging.LogError(api.requestLogger(r), "failed to subscribe to arrivals", err)
+						err = errors.New("internal server error")
+					}
+					api.sendSubscriptionError(ctx, conn, stopID, err.Error())
+					continue
+				}
+				stopIDs[stopID] = true
+			}
+		case "unsubscribe":
+			for _, stopID := range request.StopIDs {
+				if stopIDs[stopID] {
+					api.arrivalSubscriptions.unsubscribe(sub, stopID)
+					delete(stopIDs, stopID)
+				}
+			}
+		default:
+			api.sendSubscriptionError(ctx, conn, "", fmt.Sprintf("unknown action %q", request.Action))
+		}
+	}
+}
+
+// writeArrivalSubscriptions sends sub's queued messages to conn and pings it while idle, until
+// ctx is done, a write fails or the server starts draining. The connection is closed on the way
+// out, which ends the handler's read loop too.
+func (api *RestAPI) writeArrivalSubscriptions(ctx context.Context, conn *websocket.Conn, sub *arrivalSubscriber) {
+	ticker := time.NewTicker(vehicleStreamPollInterval)
+	defer ticker.Stop()
+
+	closeCode := websocket.StatusNormalClosure
+	defer func() { _ = conn.Close(closeCode, "") }()
+
+	lastWrite := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.ready:
+			for _, message := range sub.take() {
+				if err := writeSubscriptionMessage(ctx, conn, message); err != nil {
+					return
+				}
+			}
+			lastWrite = time.Now()
+		case <-ticker.C:
+			if api.Draining() {
+				closeCode = websocket.StatusGoingAway
+				return
+			}
+			if time.Since(lastWrite) >= arrivalSubscriptionPingInterval {
+				pingCtx, cancel := context.WithTimeout(ctx, arrivalSubscriptionWriteTimeout)
+				err := conn.Ping(pingCtx)
+				cancel()
+				if err != nil {
+					return
+				}
+				lastWrite = time.Now()
+			}
+		}
+	}
+}
+
+// sendSubscriptionError tells the client a request could not be followed.
+func (api *RestAPI) sendSubscriptionError(ctx context.Context, conn *websocket.Conn, stopID, message string) {
+	data, err := json.Marshal(models.ArrivalsSubscriptionError{Type: "error", StopID: stopID, Message: message})
+	if err != nil {
+		return
+	}
+	_ = writeSubscriptionMessage(ctx, conn, data)
+}
+
+// writeSubscriptionMessage sends one text message, giving up after the write timeout.
+func writeSubscriptionMessage(ctx context.Context, conn *websocket.Conn, message []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, arrivalSubscriptionWriteTimeout)
+	defer cancel()
+	return conn.Write(ctx, websocket.MessageText, message)
+}
@@ -0,0 +1,154 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialArrivalsSubscription opens the arrivals WebSocket on server and returns a function
+// that sends a message and one that reads the next message.
+func dialArrivalsSubscription(t *testing.T, server *httptest.Server) (send func(string), receive func() map[string]any) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"/api/where/arrivals-and-departures/subscribe?key=TEST", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.CloseNow() })
+
+	send = func(message string) {
+		require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(message)))
+	}
+	receive = func() map[string]any {
+		messageType, payload, err := conn.Read(ctx)
+		require.NoError(t, err)
+		require.Equal(t, websocket.MessageText, messageType)
+		var message map[string]any
+		require.NoError(t, json.Unmarshal(payload, &message))
+		return message
+	}
+	return send, receive
+}
+
+func TestArrivalsSubscriptionHandler(t *testing.T) {
+	api, cleanup := createTestApiWithRealTimeData(t)
+	defer cleanup()
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	send, receive := dialArrivalsSubscription(t, server)
+
+	send(`{"action": "subscribe", "stopIds": ["25_327", "25_nope"]}`)
+	// Errors are answered straight away, so they may overtake queued arrivals
+	byType := make(map[any]map[string]any)
+	for range 2 {
+		message := receive()
+		byType[message["type"]] = message
+	}
+	arrivals := byType["arrivals"]
+	require.NotNil(t, arrivals)
+	assert.Equal(t, "25_327", arrivals["stopId"])
+	assert.IsType(t, []any{}, arrivals["arrivals"])
+	assert.Equal(t, map[string]any{"type": "error", "stopId": "25_nope", "message": "stop not found"}, byType["error"])
+
+	send(`{"action": "watch"}`)
+	assert.Equal(t, map[string]any{"type": "error", "message": `unknown action "watch"`}, receive())
+
+	require.Eventually(t, func() bool {
+		api.arrivalSubscriptions.mu.Lock()
+		defer api.arrivalSubscriptions.mu.Unlock()
+		return len(api.arrivalSubscriptions.stops) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	send(`{"action": "unsubscribe", "stopIds": ["25_327"]}`)
+	require.Eventually(t, func() bool {
+		api.arrivalSubscriptions.mu.Lock()
+		defer api.arrivalSubscriptions.mu.Unlock()
+		return len(api.arrivalSubscriptions.stops) == 0 && api.arrivalSubscriptions.done == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestArrivalsSubscriptionHandler_NotAnUpgrade(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/where/arrivals-and-departures/subscribe?key=TEST", nil))
+	assert.Equal(t, http.StatusUpgradeRequired, rec.Code)
+	assert.Equal(t, "websocket", rec.Header().Get("Upgrade"))
+}
+
+func TestArrivalsSubscriptionHandler_ChecksOrigin(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.CORS.AllowedOrigins = []string{"https://*.example.com"}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/where/arrivals-and-departures/subscribe?key=TEST"
+	dial := func(origin string) (*http.Response, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn, resp, err := websocket.Dial(ctx, url, &websocket.DialOptions{HTTPHeader: http.Header{"Origin": {origin}}})
+		if err == nil {
+			_ = conn.CloseNow()
+		}
+		return resp, err
+	}
+
+	_, err := dial("https://app.example.com")
+	assert.NoError(t, err)
+	_, err = dial(server.URL)
+	assert.NoError(t, err, "the server's own origin is always allowed")
+
+	resp, err := dial("https://evil.test")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, err = dial("http://app.example.com")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "the scheme must match too")
+}
+
+func TestArrivalSubscriptions_PushesOnlyChanges(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	subscriptions := api.arrivalSubscriptions
+
+	first, second := newArrivalSubscriber(), newArrivalSubscriber()
+	require.NoError(t, subscriptions.subscribe(context.Background(), first, "25_327"))
+	require.NoError(t, subscriptions.subscribe(context.Background(), second, "25_327"))
+	defer subscriptions.unsubscribe(first, "25_327")
+	defer subscriptions.unsubscribe(second, "25_327")
+	initial := first.take()
+	require.Len(t, initial, 1)
+	assert.Equal(t, initial, second.take())
+	assert.True(t, strings.HasPrefix(string(initial[0]), `{"type":"arrivals","stopId":"25_327"`))
+
+	// Nothing changed, so nothing is pushed
+	subscriptions.refresh(context.Background())
+	assert.Empty(t, first.take())
+
+	subscriptions.mu.Lock()
+	subscriptions.stops["25_327"].message = []byte("stale")
+	subscriptions.mu.Unlock()
+	subscriptions.refresh(context.Background())
+	assert.Equal(t, initial, first.take())
+	assert.Equal(t, initial, second.take())
+
+	assert.ErrorIs(t, subscriptions.subscribe(context.Background(), first, "25_nope"), errUnknownStop)
+}
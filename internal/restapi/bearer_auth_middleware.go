@@ -0,0 +1,42 @@
+package restapi
+
+import (
+	"net/http"
+	"strings"
+
+	"maglev.onebusaway.org/internal/app"
+)
+
+// BearerAuthMiddleware authenticates requests carrying an "Authorization: Bearer <jwt>" header.
+// The token's identity claim is attached to the request as its API key, so rate limits, quotas
+// and usage reports apply per identity. Requests without a bearer token fall through to the
+// regular API key checks, as do bearer tokens that are not JWTs, which are API keys. If
+// bearer-auth is not configured, returns next unchanged.
+func (api *RestAPI) BearerAuthMiddleware(next http.Handler) http.Handler {
+	if api.BearerAuth == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := app.BearerToken(r)
+		if !ok || !isJWT(token) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, err := api.BearerAuth.Verify(token)
+		if err != nil {
+			api.Logger.Debug("rejected bearer token", "error", err)
+			api.invalidAPIKeyResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, app.WithAPIKey(r, identity, true))
+	})
+}
+
+// isJWT reports whether a bearer token has the three dot-separated parts of a JWT. Other
+// bearer tokens are API keys.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
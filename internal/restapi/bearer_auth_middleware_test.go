@@ -0,0 +1,75 @@
+package restapi
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/auth"
+)
+
+func TestBearerAuthMiddleware(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "alg": "RS256", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer jwksServer.Close()
+
+	verifier, err := auth.NewBearerVerifier(appconf.BearerAuthConfig{JWKSURL: jwksServer.URL, Audience: "maglev"})
+	require.NoError(t, err)
+	defer verifier.Close()
+
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.BearerAuth = verifier
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	request := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"aud": "maglev",
+		"sub": "partner-app",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, request("Bearer "+signed), "valid token should not need an API key")
+	assert.Equal(t, http.StatusUnauthorized, request("Bearer not.a.jwt"))
+	assert.Equal(t, http.StatusUnauthorized, request("Bearer not-a-key"))
+	assert.Equal(t, http.StatusOK, request("Bearer TEST"), "bearer tokens that are not JWTs are API keys")
+	assert.Equal(t, http.StatusUnauthorized, request(""), "requests without credentials still need a key")
+
+	report := api.usageTracker.Snapshot()
+	keys := make([]string, 0, len(report.Keys))
+	for _, usage := range report.Keys {
+		keys = append(keys, usage.Key)
+	}
+	assert.Contains(t, keys, "partner-app", "usage should be attributed to the token subject")
+}
@@ -0,0 +1,81 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// defaultBikeshareRadius is the search radius in meters when neither radius nor spans are given,
+// as for stops.
+const defaultBikeshareRadius = 500
+
+func (api *RestAPI) bikeshareStationsForLocationHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	lat, fieldErrors := utils.ParseFloatParam(queryParams, "lat", nil)
+	lon, _ := utils.ParseFloatParam(queryParams, "lon", fieldErrors)
+	radius, _ := utils.ParseFloatParam(queryParams, "radius", fieldErrors)
+	latSpan, _ := utils.ParseFloatParam(queryParams, "latSpan", fieldErrors)
+	lonSpan, _ := utils.ParseFloatParam(queryParams, "lonSpan", fieldErrors)
+	defaultCount, maxAllowed := api.pageLimits(r, appconf.PaginationClassLocation, models.DefaultMaxCountForStops, models.MaxAllowedCount)
+	maxCount, _ := utils.ParseMaxCount(queryParams, defaultCount, maxAllowed, fieldErrors)
+
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	locationErrors := utils.ValidateLocationParams(lat, lon, radius, latSpan, lonSpan)
+	if len(locationErrors) > 0 {
+		api.validationErrorResponse(w, r, locationErrors)
+		return
+	}
+
+	stations, limitExceeded := api.bikeshareStationsForLocation(lat, lon, radius, latSpan, lonSpan, maxCount)
+	response := models.NewListResponse(stations, models.NewEmptyReferences(), limitExceeded, api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// bikeshareStationsForLocation returns the bikeshare stations in the area searched for stops,
+// nearest first, and whether more than maxCount were found. It returns an empty list when no
+// GBFS feed is configured.
+func (api *RestAPI) bikeshareStationsForLocation(lat, lon, radius, latSpan, lonSpan float64, maxCount int) ([]models.BikeshareStation, bool) {
+	stations := make([]models.BikeshareStation, 0)
+	if api.Bikeshare == nil {
+		return stations, false
+	}
+
+	var bounds utils.CoordinateBounds
+	if latSpan > 0 && lonSpan > 0 {
+		bounds = utils.CalculateBoundsFromSpan(lat, lon, latSpan/2, lonSpan/2)
+	} else {
+		if radius == 0 {
+			radius = defaultBikeshareRadius
+		}
+		bounds = utils.CalculateBounds(lat, lon, radius)
+	}
+
+	found, limitExceeded := api.Bikeshare.StationsInBounds(bounds, lat, lon, maxCount)
+	for _, station := range found {
+		var lastReported int64
+		if !station.LastReported.IsZero() {
+			lastReported = station.LastReported.UnixMilli()
+		}
+		stations = append(stations, models.BikeshareStation{
+			ID:               utils.FormCombinedID(station.SystemID, station.ID),
+			Name:             station.Name,
+			Lat:              station.Lat,
+			Lon:              station.Lon,
+			Capacity:         station.Capacity,
+			BikesAvailable:   station.BikesAvailable,
+			DocksAvailable:   station.DocksAvailable,
+			IsRenting:        station.IsRenting,
+			IsReturning:      station.IsReturning,
+			LastReportedTime: lastReported,
+		})
+	}
+	return stations, limitExceeded
+}
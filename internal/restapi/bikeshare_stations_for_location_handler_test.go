@@ -0,0 +1,117 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/gbfs"
+)
+
+// attachTestBikeshare serves a GBFS system with two stations near 40.583321,-122.426966 and
+// one far away, and gives api a poller that has read it.
+func attachTestBikeshare(t *testing.T, api *RestAPI) {
+	t.Helper()
+	files := map[string]string{
+		"/gbfs.json": `{"data": {"en": {"feeds": [
+			{"name": "station_information", "url": "{base}/station_information.json"},
+			{"name": "station_status", "url": "{base}/station_status.json"}
+		]}}}`,
+		"/station_information.json": `{"data": {"stations": [
+			{"station_id": "near", "name": "Market & Yuba", "lat": 40.5834, "lon": -122.4270, "capacity": 12},
+			{"station_id": "block", "name": "Placer & Court", "lat": 40.5850, "lon": -122.4290, "capacity": 8},
+			{"station_id": "far", "name": "Airport", "lat": 40.5090, "lon": -122.2934, "capacity": 20}
+		]}}`,
+		"/station_status.json": `{"data": {"stations": [
+			{"station_id": "near", "num_bikes_available": 5, "num_docks_available": 7, "is_renting": true, "is_returning": true, "last_reported": 1766757600},
+			{"station_id": "block", "num_bikes_available": 0, "num_docks_available": 8, "is_renting": true, "is_returning": true, "last_reported": 1766757600},
+			{"station_id": "far", "num_bikes_available": 9, "num_docks_available": 11, "is_renting": true, "is_returning": true, "last_reported": 1766757600}
+		]}}`,
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(strings.ReplaceAll(body, "{base}", server.URL)))
+	}))
+	t.Cleanup(server.Close)
+
+	poller := gbfs.NewPoller(appconf.GBFSConfig{Feeds: []appconf.GBFSFeed{{ID: "redding", URL: server.URL + "/gbfs.json"}}}, server.Client(), nil)
+	require.NoError(t, poller.Refresh(context.Background()))
+	api.Bikeshare = poller
+}
+
+func TestBikeshareStationsForLocationHandler(t *testing.T) {
+	api := createTestApi(t)
+	attachTestBikeshare(t, api)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/bikeshare-stations-for-location.json?key=TEST&lat=40.583321&lon=-122.426966")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, false, data["limitExceeded"])
+	list, ok := data["list"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, list, 2, "the airport station is outside the default radius")
+
+	nearest := list[0].(map[string]interface{})
+	assert.Equal(t, "redding_near", nearest["id"])
+	assert.Equal(t, "Market & Yuba", nearest["name"])
+	assert.Equal(t, 12.0, nearest["capacity"])
+	assert.Equal(t, 5.0, nearest["bikesAvailable"])
+	assert.Equal(t, 7.0, nearest["docksAvailable"])
+	assert.Equal(t, true, nearest["isRenting"])
+	assert.Equal(t, float64(time.Unix(1766757600, 0).UnixMilli()), nearest["lastReportedTime"])
+	assert.Equal(t, "redding_block", list[1].(map[string]interface{})["id"])
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/bikeshare-stations-for-location.json?key=TEST&lat=40.583321&lon=-122.426966&maxCount=1")
+	data = model.Data.(map[string]interface{})
+	assert.Equal(t, true, data["limitExceeded"])
+	assert.Len(t, data["list"], 1)
+}
+
+func TestBikeshareStationsForLocationHandlerWithoutFeeds(t *testing.T) {
+	api := createTestApi(t)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/bikeshare-stations-for-location.json?key=TEST&lat=40.583321&lon=-122.426966")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Empty(t, data["list"])
+}
+
+func TestBikeshareStationsForLocationHandlerValidatesLatLon(t *testing.T) {
+	api := createTestApi(t)
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/bikeshare-stations-for-location.json?key=TEST&lat=91&lon=-122.426966")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStopsForLocationIncludesBikeshareStations(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 12, 26, 14, 0, 0, 0, time.UTC)))
+	attachTestBikeshare(t, api)
+
+	_, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stops-for-location.json?key=TEST&lat=40.583321&lon=-122.426966&radius=2500")
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	refs, ok := data["references"].(map[string]interface{})
+	require.True(t, ok)
+	stations, ok := refs["bikeshareStations"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, stations, 2)
+	assert.Equal(t, "redding_near", stations[0].(map[string]interface{})["id"])
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/stops-for-location.json?key=TEST&lat=40.583321&lon=-122.426966&radius=2500&query=1")
+	refs = model.Data.(map[string]interface{})["references"].(map[string]interface{})
+	assert.NotContains(t, refs, "bikeshareStations", "stations are left out of stop code searches")
+}
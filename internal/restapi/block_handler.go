@@ -29,7 +29,7 @@ func (api *RestAPI) blockHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	agencyID, blockID, err := utils.ExtractAgencyIDAndCodeID(id)
+	agencyID, blockID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
 
 	//  Return JSON 400 response for invalid block IDs
 	// We use an explicit struct here to ensure the text is exactly "invalid block id"
@@ -0,0 +1,20 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/internal/app"
+)
+
+// BlocklistMiddleware answers requests from blocked API keys or client networks with a 403.
+// It runs after authentication so keys supplied by signed requests and bearer tokens are checked too.
+func (api *RestAPI) BlocklistMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if api.Blocklist != nil &&
+			(api.Blocklist.BlocksKey(app.APIKeyFromRequest(r)) || api.Blocklist.BlocksAddr(app.ClientAddr(r))) {
+			api.sendError(w, r, http.StatusForbidden, "access denied")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
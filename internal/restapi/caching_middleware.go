@@ -16,15 +16,15 @@ func CacheControlMiddleware(durationSeconds int, next http.Handler) http.Handler
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		wrapped := &cacheControlWriter{
-			ResponseWriter: w,
-			headerValue:    headerValue,
+			wrappedWriter: wrappedWriter{w},
+			headerValue:   headerValue,
 		}
 		next.ServeHTTP(wrapped, r)
 	})
 }
 
 type cacheControlWriter struct {
-	http.ResponseWriter
+	wrappedWriter
 	headerValue   string
 	headerWritten bool
 }
@@ -32,7 +32,7 @@ type cacheControlWriter struct {
 func (w *cacheControlWriter) WriteHeader(code int) {
 	if !w.headerWritten {
 		w.headerWritten = true
-		if code >= 200 && code < 300 {
+		if (code >= 200 && code < 300) || code == http.StatusNotModified {
 			w.ResponseWriter.Header().Set("Cache-Control", w.headerValue)
 		} else {
 			w.ResponseWriter.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -41,6 +41,14 @@ func (w *cacheControlWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// Flush sets Cache-Control first, if nothing has been written yet.
+func (w *cacheControlWriter) Flush() {
+	if !w.headerWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.wrappedWriter.Flush()
+}
+
 func (w *cacheControlWriter) Write(b []byte) (int, error) {
 	if !w.headerWritten {
 		w.WriteHeader(http.StatusOK)
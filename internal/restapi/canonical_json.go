@@ -0,0 +1,119 @@
+package restapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// sendCanonicalResponse writes a successful response with canonical-json set: its data is
+// serialized canonically and identified by an ETag, and a request whose If-None-Match already
+// names that ETag is answered 304 Not Modified without a body.
+func (api *RestAPI) sendCanonicalResponse(w http.ResponseWriter, r *http.Request, response models.ResponseModel) {
+	data, err := canonicalJSON(response.Data)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := hex.EncodeToString(sum[:16])
+	if wantsXML(r) {
+		// The XML of the same data is a different representation, with its own tag
+		etag += "-xml"
+	}
+	etag = `"` + etag + `"`
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	original := response.Data
+	response.Data = json.RawMessage(data)
+	if wantsXML(r) {
+		err = writeCanonicalXML(w, response, original)
+	} else {
+		err = json.NewEncoder(w).Encode(response)
+	}
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+	}
+}
+
+// writeCanonicalXML writes a response whose data is already canonical JSON as XML. original
+// is the data before serialization, which names the list items.
+func writeCanonicalXML(w io.Writer, response models.ResponseModel, original interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(response); err != nil {
+		return err
+	}
+	return writeXMLResponse(w, buf.Bytes(), original)
+}
+
+// canonicalJSON serializes v so that the same data always gives the same bytes: object keys
+// are sorted, numbers are kept as written, and the lists in every references section, which
+// are often gathered from maps, are ordered by ID.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	sortReferenceLists(value)
+	return json.Marshal(value)
+}
+
+// sortReferenceLists orders by ID the lists in every "references" object within value.
+func sortReferenceLists(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if references, ok := child.(map[string]interface{}); ok && key == "references" {
+				for _, list := range references {
+					if items, ok := list.([]interface{}); ok {
+						sort.SliceStable(items, func(a, b int) bool { return jsonID(items[a]) < jsonID(items[b]) })
+					}
+				}
+			}
+			sortReferenceLists(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			sortReferenceLists(child)
+		}
+	}
+}
+
+func jsonID(item interface{}) string {
+	if object, ok := item.(map[string]interface{}); ok {
+		if id, ok := object["id"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// etagMatches reports whether an If-None-Match header names etag, or is "*".
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	references := models.NewEmptyReferences()
+	references.Routes = []interface{}{
+		map[string]interface{}{"id": "25_b", "shortName": "B"},
+		map[string]interface{}{"id": "25_a", "shortName": "A"},
+	}
+	data := map[string]interface{}{
+		"list":       []interface{}{map[string]interface{}{"id": "25_2", "lat": 47.1}, map[string]interface{}{"id": "25_1"}},
+		"references": references,
+	}
+
+	got, err := canonicalJSON(data)
+	require.NoError(t, err)
+	// The references are ordered by ID; the list keeps the order it was built in
+	assert.JSONEq(t, `{
+		"list": [{"id": "25_2", "lat": 47.1}, {"id": "25_1"}],
+		"references": {"agencies": [], "routes": [{"id": "25_a", "shortName": "A"}, {"id": "25_b", "shortName": "B"}],
+			"situations": [], "stopTimes": [], "stops": [], "trips": []}
+	}`, string(got))
+	assert.Regexp(t, `^\{"list":\[\{"id":"25_2","lat":47.1\}`, string(got))
+}
+
+func TestCanonicalJSONResponses(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)))
+	defer api.Shutdown()
+	api.Config.CanonicalJSON = true
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	serve := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/stops-for-location.json?lat=40.583321&lon=-122.426966&key="+siriTestKey, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	currentTime := regexp.MustCompile(`"currentTime":\d+`)
+	first, second := serve(""), serve("")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, etag, second.Header().Get("ETag"))
+	assert.Equal(t, currentTime.ReplaceAllString(first.Body.String(), ""), currentTime.ReplaceAllString(second.Body.String(), ""))
+
+	notModified := serve(`"other", ` + etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+
+	assert.Equal(t, http.StatusOK, serve(`"other"`).Code)
+}
@@ -0,0 +1,76 @@
+package restapi
+
+import (
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// ConcurrencyLimiter bounds how many requests of a route group are served at once, so one
+// expensive endpoint can't take every database connection and CPU from the rest of the API.
+type ConcurrencyLimiter struct {
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+// NewConcurrencyLimiter creates a limiter admitting limit.MaxInFlight requests at a time.
+func NewConcurrencyLimiter(limit appconf.ConcurrencyLimit) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		slots:   make(chan struct{}, limit.MaxInFlight),
+		maxWait: time.Duration(limit.MaxWait) * time.Millisecond,
+	}
+}
+
+// acquire takes a slot, waiting up to maxWait for one to free up. It returns false if no slot
+// became available or the request was cancelled while waiting.
+func (l *ConcurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if l.maxWait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.maxWait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	<-l.slots
+}
+
+// InFlight returns the number of requests currently holding a slot.
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+// limitConcurrency applies the concurrency limit of a route group to next. Requests that don't
+// get a slot receive a 503 with Retry-After. Returns next unchanged if the group is unlimited.
+func (api *RestAPI) limitConcurrency(group string, next handlerFunc) handlerFunc {
+	limiter := api.concurrencyLimiters[group]
+	if limiter == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !limiter.acquire(r) {
+			api.requestLogger(r).Warn("concurrency limit reached", "group", group, "path", r.URL.Path)
+			w.Header().Set("Retry-After", "1")
+			api.sendError(w, r, http.StatusServiceUnavailable, "too many concurrent requests for this endpoint, try again shortly")
+			return
+		}
+		defer limiter.release()
+		next(w, r)
+	}
+}
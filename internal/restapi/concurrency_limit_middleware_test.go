@@ -0,0 +1,71 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func TestLimitConcurrency(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.concurrencyLimiters = map[string]*ConcurrencyLimiter{
+		appconf.ConcurrencyGroupSearch: NewConcurrencyLimiter(appconf.ConcurrencyLimit{MaxInFlight: 1, MaxWait: 20}),
+	}
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	handler := api.limitConcurrency(appconf.ConcurrencyGroupSearch, func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	})
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler(first, httptest.NewRequest(http.MethodGet, "/api/where/search/stop.json", nil))
+		close(done)
+	}()
+	<-entered
+	assert.Equal(t, 1, api.concurrencyLimiters[appconf.ConcurrencyGroupSearch].InFlight())
+
+	// The slot is taken; the second request waits MaxWait and is turned away
+	second := httptest.NewRecorder()
+	handler(second, httptest.NewRequest(http.MethodGet, "/api/where/search/stop.json", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, second.Code)
+	assert.Equal(t, "1", second.Header().Get("Retry-After"))
+
+	close(unblock)
+	<-done
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, 0, api.concurrencyLimiters[appconf.ConcurrencyGroupSearch].InFlight())
+}
+
+func TestLimitConcurrency_WaitsForSlot(t *testing.T) {
+	limiter := NewConcurrencyLimiter(appconf.ConcurrencyLimit{MaxInFlight: 1, MaxWait: 1000})
+	require.True(t, limiter.acquire(httptest.NewRequest(http.MethodGet, "/", nil)))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		limiter.release()
+	}()
+	assert.True(t, limiter.acquire(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+func TestLimitConcurrency_Unlimited(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	called := false
+	handler := api.limitConcurrency(appconf.ConcurrencyGroupTrips, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, called)
+}
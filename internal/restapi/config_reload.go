@@ -0,0 +1,247 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// ErrConfigNotReloadable is returned when the server was configured with command-line flags.
+var ErrConfigNotReloadable = errors.New("configuration was not loaded from a file and cannot be reloaded")
+
+// reloadableSettings are the Config fields applied by ReloadConfig. Changes to any other field
+// are reported as needing a restart.
+var reloadableSettings = map[string]bool{
+	"ApiKeys":              true,
+	"ExemptApiKeys":        true,
+	"AdminApiKeys":         true,
+	"BulkApiKeys":          true,
+	"KeyRestrictions":      true,
+	"RateLimit":            true,
+	"RateLimitExemptPaths": true,
+	"Quotas":               true, // Except DataPath; see restartRequiredSettings
+	"SignedRequests":       true,
+	"Verbose":              true, // Not read from the file
+}
+
+// ReloadResult describes what a configuration reload changed.
+type ReloadResult struct {
+	// Changed describes the settings that were applied and differ from the running configuration
+	Changed []string `json:"changed"`
+	// RestartRequired lists changed settings that only take effect after a restart
+	RestartRequired []string `json:"restartRequired"`
+}
+
+// ReloadConfig re-reads the configuration files and applies the settings that can change while
+// serving: API keys, exempt, admin and bulk keys, key restrictions, the rate limit, quota limits, signing secrets, the log level, and
+// the GTFS feed URLs, unless they come from a feed registry. The detours file is re-read. In-flight requests and open connections are unaffected. A new static feed URL is used
+// from the next refresh; if a refresh is running, ReloadConfig waits for it to finish.
+func (api *RestAPI) ReloadConfig() (ReloadResult, error) {
+	api.reloadMu.Lock()
+	defer api.reloadMu.Unlock()
+
+	if len(api.ConfigFiles) == 0 {
+		return ReloadResult{}, ErrConfigNotReloadable
+	}
+	jsonConfig, err := appconf.LoadFromFiles(api.ConfigFiles...)
+	if err != nil {
+		return ReloadResult{}, fmt.Errorf("failed to reload config: %w", err)
+	}
+	if api.Detours != nil {
+		if err := api.Detours.Reload(); err != nil {
+			return ReloadResult{}, fmt.Errorf("failed to reload config: %w", err)
+		}
+	}
+	cfg := jsonConfig.ToAppConfig()
+	result := ReloadResult{
+		Changed:         api.changedSettings(cfg),
+		RestartRequired: api.restartRequiredSettings(cfg),
+	}
+
+	api.SetAccessConfig(cfg)
+	api.rateLimiter.Update(cfg.RateLimit, time.Second, cfg.ExemptApiKeys)
+	if api.Quotas != nil {
+		api.Quotas.SetLimits(cfg.Quotas)
+	}
+	if api.LogLevel != nil {
+		// Validated when the file was loaded
+		level, _ := appconf.ParseLogLevel(cfg.Logging.Level)
+		api.LogLevel.Set(level)
+	}
+	api.Config.Logging.Level = cfg.Logging.Level
+
+	// The refresh ticker is started with the manager
+	refreshInterval := api.GtfsConfig.StaticRefreshInterval
+	if refreshInterval == 0 {
+		refreshInterval = gtfs.DefaultStaticRefreshInterval
+	}
+	if api.GtfsManager != nil && time.Duration(jsonConfig.StaticRefreshInterval)*time.Second != refreshInterval {
+		result.RestartRequired = append(result.RestartRequired, "StaticRefreshInterval")
+	}
+
+	// While a feed registry is configured, the feed URLs come from it
+	if api.GtfsManager != nil && !api.Config.FeedRegistry.Enabled() {
+		feeds := jsonConfig.ToGtfsConfigData()
+		if feeds.GtfsURL != api.GtfsConfig.GtfsURL {
+			result.Changed = append(result.Changed, "GtfsStaticFeed.URL")
+			api.GtfsManager.SetGtfsURL(feeds.GtfsURL)
+			api.GtfsConfig.GtfsURL = feeds.GtfsURL
+		}
+		if feeds.StaticAuthHeaderKey != api.GtfsConfig.StaticAuthHeaderKey ||
+			feeds.StaticAuthHeaderValue != api.GtfsConfig.StaticAuthHeaderValue ||
+			feeds.GTFSDataPath != api.GtfsConfig.GTFSDataPath ||
+			feeds.EnableGTFSTidy != api.GtfsConfig.EnableGTFSTidy ||
+			feeds.StaticIDPrefix != api.GtfsConfig.StaticIDPrefix ||
+			!slices.Equal(gtfs.StaticFeedsFromJSON(feeds.ExtraStaticFeeds), api.GtfsConfig.ExtraStaticFeeds) {
+			result.RestartRequired = append(result.RestartRequired, "GtfsStaticFeed")
+		}
+
+		realtime := gtfs.Config{
+			TripUpdatesURL:          feeds.TripUpdatesURL,
+			VehiclePositionsURL:     feeds.VehiclePositionsURL,
+			ServiceAlertsURL:        feeds.ServiceAlertsURL,
+			RealTimeAuthHeaderKey:   feeds.RealTimeAuthHeaderKey,
+			RealTimeAuthHeaderValue: feeds.RealTimeAuthHeaderValue,
+			RealTimeAgencyID:        feeds.RealTimeAgencyID,
+			RealTimeIDPrefix:        feeds.RealTimeIDPrefix,
+			ExtraRealtimeFeeds:      gtfs.RealtimeFeedsFromJSON(feeds.ExtraRealtimeFeeds),
+		}
+		if err := api.GtfsManager.SetRealtimeFeeds(realtime); errors.Is(err, gtfs.ErrRealtimeToggle) {
+			result.RestartRequired = append(result.RestartRequired, "GtfsRtFeeds")
+		}
+	}
+
+	api.Logger.Info("configuration reloaded",
+		slog.Any("files", api.ConfigFiles),
+		slog.Any("changed", result.Changed),
+		slog.Any("restart_required", result.RestartRequired))
+	return result, nil
+}
+
+// restartRequiredSettings returns the names of settings that differ between cfg and the running
+// configuration but cannot be applied without a restart.
+func (api *RestAPI) restartRequiredSettings(cfg appconf.Config) []string {
+	// Of the logging settings, only the level can change while serving
+	cfg.Logging.Level = api.Config.Logging.Level
+
+	current := reflect.ValueOf(api.Config)
+	next := reflect.ValueOf(cfg)
+
+	changed := []string{}
+	for i := 0; i < current.NumField(); i++ {
+		name := current.Type().Field(i).Name
+		if reloadableSettings[name] {
+			continue
+		}
+		if !reflect.DeepEqual(current.Field(i).Interface(), next.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	// The quota store is opened at startup, and only when quotas are configured
+	if cfg.Quotas.DataPath != api.Config.Quotas.DataPath ||
+		(cfg.Quotas.DataPath != "" && cfg.Quotas.Enabled() != api.Config.Quotas.Enabled()) {
+		changed = append(changed, "Quotas.DataPath")
+	}
+	return changed
+}
+
+// changedSettings describes how the reloadable settings in cfg differ from the running
+// configuration. Key lists and secrets are summarized by count so that they never reach the logs.
+func (api *RestAPI) changedSettings(cfg appconf.Config) []string {
+	current := reflect.ValueOf(api.Config)
+	next := reflect.ValueOf(cfg)
+
+	changed := []string{}
+	for i := 0; i < current.NumField(); i++ {
+		name := current.Type().Field(i).Name
+		if !reloadableSettings[name] || name == "Verbose" {
+			continue
+		}
+		if description := describeChange(name, current.Field(i).Interface(), next.Field(i).Interface()); description != "" {
+			changed = append(changed, description)
+		}
+	}
+	if description := describeChange("Logging.Level", api.Config.Logging.Level, cfg.Logging.Level); description != "" {
+		changed = append(changed, description)
+	}
+	return changed
+}
+
+// describeChange returns a summary of a setting going from old to new, or "" if it is unchanged.
+func describeChange(name string, old, new any) string {
+	if reflect.DeepEqual(old, new) {
+		return ""
+	}
+	switch old := old.(type) {
+	case []string:
+		added, removed := diffStrings(old, new.([]string))
+		if added == 0 && removed == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%s: %d added, %d removed", name, added, removed)
+	case map[string]appconf.KeyRestriction:
+		restricted := len(new.(map[string]appconf.KeyRestriction))
+		if len(old) == 0 && restricted == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%s: updated, %d keys restricted", name, restricted)
+	case appconf.QuotaConfig:
+		next := new.(appconf.QuotaConfig)
+		next.DataPath = old.DataPath
+		if reflect.DeepEqual(old, next) {
+			return ""
+		}
+		return fmt.Sprintf("%s: default %d daily, %d monthly; %d keys with their own quota",
+			name, next.Default.Daily, next.Default.Monthly, len(next.Keys))
+	case appconf.SignedRequestConfig:
+		return fmt.Sprintf("%s: updated, %d keys with secrets", name, len(new.(appconf.SignedRequestConfig).Secrets))
+	default:
+		return fmt.Sprintf("%s: %v -> %v", name, old, new)
+	}
+}
+
+// diffStrings counts the values of next missing from prev (added) and the reverse (removed).
+func diffStrings(prev, next []string) (added, removed int) {
+	inPrev := make(map[string]bool, len(prev))
+	for _, value := range prev {
+		inPrev[value] = true
+	}
+	inNext := make(map[string]bool, len(next))
+	for _, value := range next {
+		inNext[value] = true
+		if !inPrev[value] {
+			added++
+		}
+	}
+	for value := range inPrev {
+		if !inNext[value] {
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// adminReloadConfigHandler reloads the configuration files, like sending the process SIGHUP.
+func (api *RestAPI) adminReloadConfigHandler(w http.ResponseWriter, r *http.Request) {
+	result, err := api.ReloadConfig()
+	if errors.Is(err, ErrConfigNotReloadable) {
+		api.sendError(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		// The running configuration is kept when the file is unreadable or invalid
+		api.sendError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	response := models.NewEntryResponse(result, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
@@ -0,0 +1,143 @@
+package restapi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/quota"
+)
+
+// writeReloadConfig writes a minimal config file with the given overrides.
+func writeReloadConfig(t *testing.T, path string, overrides map[string]interface{}) {
+	t.Helper()
+	config := map[string]interface{}{
+		"env":              "test",
+		"gtfs-static-feed": map[string]interface{}{"url": "https://example.com/gtfs.zip"},
+	}
+	for key, value := range overrides {
+		config[key] = value
+	}
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestAdminReloadConfigHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	// Feed changes are covered by the gtfs package; keep the shared manager untouched
+	api.GtfsManager = nil
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeReloadConfig(t, path, map[string]interface{}{
+		"api-keys":       []string{"old-key"},
+		"admin-api-keys": []string{"admin-secret"},
+	})
+	initial, err := appconf.LoadFromFile(path)
+	require.NoError(t, err)
+	api.Config = initial.ToAppConfig()
+	api.ConfigFiles = []string{path}
+	api.LogLevel = new(slog.LevelVar)
+
+	writeReloadConfig(t, path, map[string]interface{}{
+		"port":            4001,
+		"api-keys":        []string{"new-key"},
+		"exempt-api-keys": []string{"new-key"},
+		"admin-api-keys":  []string{"admin-secret"},
+		"rate-limit":      50,
+		"logging":         map[string]interface{}{"level": "debug"},
+	})
+
+	code, model := serveAdmin(t, api, http.MethodPost, "/api/admin/config/reload?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"Port"}, entry["restartRequired"])
+	assert.ElementsMatch(t, []interface{}{
+		"ApiKeys: 1 added, 1 removed",
+		"ExemptApiKeys: 1 added, 1 removed",
+		"RateLimit: 100 -> 50",
+		"Logging.Level: info -> debug",
+	}, entry["changed"])
+
+	assert.False(t, api.IsInvalidAPIKey("new-key"))
+	assert.True(t, api.IsInvalidAPIKey("old-key"))
+	assert.Equal(t, []string{"new-key"}, api.ExemptAPIKeys())
+	assert.Equal(t, 50, api.Config.RateLimit)
+	assert.Equal(t, 4000, api.Config.Port)
+	assert.Equal(t, slog.LevelDebug, api.LogLevel.Level())
+
+	// An invalid file leaves the running configuration in place
+	require.NoError(t, os.WriteFile(path, []byte(`{"port": -1}`), 0o600))
+	code, _ = serveAdmin(t, api, http.MethodPost, "/api/admin/config/reload?key=admin-secret")
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.False(t, api.IsInvalidAPIKey("new-key"))
+}
+
+func TestAdminReloadConfigHandler_FlagConfig(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	code, model := serveAdmin(t, api, http.MethodPost, "/api/admin/config/reload?key=admin-secret")
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, ErrConfigNotReloadable.Error(), model.Text)
+}
+
+func TestReloadConfig_Quotas(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.GtfsManager = nil
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeReloadConfig(t, path, map[string]interface{}{
+		"quotas": map[string]interface{}{"default": map[string]int64{"daily": 5}},
+	})
+	initial, err := appconf.LoadFromFile(path)
+	require.NoError(t, err)
+	api.Config = initial.ToAppConfig()
+	api.ConfigFiles = []string{path}
+	manager, err := quota.NewManager(api.Config.Quotas, nil, api.WallClock, nil, 0)
+	require.NoError(t, err)
+	defer manager.Shutdown()
+	api.Quotas = manager
+
+	for range 2 {
+		require.True(t, manager.Allow("partner").Allowed)
+	}
+
+	writeReloadConfig(t, path, map[string]interface{}{
+		"quotas": map[string]interface{}{
+			"default": map[string]int64{"daily": 2},
+			"keys":    map[string]interface{}{"partner-key": map[string]int64{"monthly": 100}},
+		},
+	})
+	result, err := api.ReloadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Quotas: default 2 daily, 0 monthly; 1 keys with their own quota"}, result.Changed)
+	assert.Empty(t, result.RestartRequired)
+
+	// The lowered limit applies to the requests already counted
+	decision := manager.Allow("partner")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, int64(2), decision.Limit)
+	assert.Equal(t, int64(2), api.Config.Quotas.Default.Daily)
+
+	writeReloadConfig(t, path, map[string]interface{}{
+		"quotas": map[string]interface{}{
+			"default":   map[string]int64{"daily": 2},
+			"keys":      map[string]interface{}{"partner-key": map[string]int64{"monthly": 100}},
+			"data-path": filepath.Join(t.TempDir(), "quota.db"),
+		},
+	})
+	result, err = api.ReloadConfig()
+	require.NoError(t, err)
+	assert.Empty(t, result.Changed)
+	assert.Equal(t, []string{"Quotas.DataPath"}, result.RestartRequired)
+}
@@ -0,0 +1,38 @@
+package restapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/crowding"
+)
+
+// occupancy returns, as GTFS-RT OccupancyStatus names, how full the trip's vehicle reports
+// it is now and how full it is predicted to be at the stop, from the vehicle's report and
+// from history alone. Each is empty when unknown. Only a vehicle serving the trip counts,
+// not one still on an earlier trip of the block.
+func (api *RestAPI) occupancy(ctx context.Context, routeID, tripID, stopID string, arrival, now time.Time, vehicle *gtfs.Vehicle) (current, predicted, historical string) {
+	if vehicle == nil || vehicle.Trip == nil || vehicle.Trip.ID.ID != tripID {
+		vehicle = nil
+	}
+	if vehicle != nil && vehicle.OccupancyStatus != nil {
+		current = vehicle.OccupancyStatus.String()
+	}
+	if api.Crowding == nil {
+		return current, "", ""
+	}
+
+	query := crowding.Query{RouteID: routeID, TripID: tripID, StopID: stopID, ArrivalTime: arrival, Now: now}
+	if status, ok := api.Crowding.Predict(ctx, query); ok {
+		historical = status.String()
+	}
+	if vehicle == nil {
+		return current, historical, historical
+	}
+	query.Vehicle = vehicle
+	if status, ok := api.Crowding.Predict(ctx, query); ok {
+		predicted = status.String()
+	}
+	return current, predicted, historical
+}
@@ -0,0 +1,74 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/crowding"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+type fixedPredictor struct {
+	queries []crowding.Query
+}
+
+func (p *fixedPredictor) Predict(_ context.Context, q crowding.Query) (gtfs.OccupancyStatus, bool) {
+	p.queries = append(p.queries, q)
+	return gtfsrt.VehiclePosition_STANDING_ROOM_ONLY, true
+}
+
+func TestArrivalsPredictedOccupancy(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	trip, serviceDay := scheduledTrip(t, api)
+	stopID := trip.StopTimes[1].Stop.Id
+	at := serviceDay.Add(trip.StopTimes[1].ArrivalTime)
+
+	fetch := func(t *testing.T) models.ArrivalAndDeparture {
+		rec := serveSiri(t, api, "/api/where/arrivals-and-departures-for-stop/"+utils.FormCombinedID(agency.Id, stopID)+".json?key="+siriTestKey+"&time="+strconv.FormatInt(at.UnixMilli(), 10))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var response struct {
+			Data struct {
+				Entry struct {
+					ArrivalsAndDepartures []models.ArrivalAndDeparture `json:"arrivalsAndDepartures"`
+				} `json:"entry"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		for _, arrival := range response.Data.Entry.ArrivalsAndDepartures {
+			if arrival.TripID == utils.FormCombinedID(agency.Id, trip.ID) {
+				return arrival
+			}
+		}
+		t.Fatalf("trip %s not listed", trip.ID)
+		return models.ArrivalAndDeparture{}
+	}
+
+	arrival := fetch(t)
+	assert.Empty(t, arrival.PredictedOccupancy, "no predictor configured")
+	assert.Empty(t, arrival.HistoricalOccupancy)
+
+	predictor := &fixedPredictor{}
+	api.Crowding = predictor
+	defer func() { api.Crowding = nil }()
+	arrival = fetch(t)
+	assert.Equal(t, "STANDING_ROOM_ONLY", arrival.HistoricalOccupancy)
+	assert.Equal(t, "STANDING_ROOM_ONLY", arrival.PredictedOccupancy, "without a vehicle the prediction is the historical one")
+	index := slices.IndexFunc(predictor.queries, func(q crowding.Query) bool { return q.TripID == trip.ID })
+	require.GreaterOrEqual(t, index, 0)
+	query := predictor.queries[index]
+	assert.Equal(t, trip.Route.Id, query.RouteID)
+	assert.Equal(t, stopID, query.StopID)
+	assert.True(t, at.Equal(query.ArrivalTime))
+}
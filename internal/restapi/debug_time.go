@@ -0,0 +1,37 @@
+package restapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"maglev.onebusaway.org/internal/clock"
+)
+
+// debugTimeParam lets an admin evaluate arrivals and schedule endpoints as if the current time
+// were another instant, to reproduce what a rider saw at a specific moment.
+const debugTimeParam = "debugTime"
+
+// requestClock returns the clock a request is evaluated against: the server clock, or one
+// starting at the debugTime parameter when the request carries an admin key. debugTime is Unix
+// milliseconds or an RFC3339 time.
+func (api *RestAPI) requestClock(r *http.Request) (clock.Clock, error) {
+	value := r.URL.Query().Get(debugTimeParam)
+	if value == "" {
+		return api.Clock, nil
+	}
+	if !api.RequestHasAdminAPIKey(r) {
+		return nil, errors.New("requires an admin API key")
+	}
+
+	var start time.Time
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		start = time.UnixMilli(ms)
+	} else if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		start = parsed
+	} else {
+		return nil, errors.New("must be a Unix timestamp in milliseconds or an RFC3339 time")
+	}
+	return clock.NewSimulatedClock(start, 1), nil
+}
@@ -0,0 +1,63 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestDebugTime(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 12, 26, 12, 0, 0, 0, time.UTC)))
+	defer api.Shutdown()
+	api.Config.ApiKeys = append(api.Config.ApiKeys, "admin-secret")
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	stopID := utils.FormCombinedID(agency.Id, api.GtfsManager.GetStops()[0].Id)
+	debugTime := time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)
+
+	t.Run("admin key evaluates the schedule at debugTime", func(t *testing.T) {
+		endpoint := "/api/where/schedule-for-stop/" + stopID + ".json?key=admin-secret&debugTime=2025-06-12T15:00:00Z"
+		resp, model := serveApiAndRetrieveEndpoint(t, api, endpoint)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		assert.InDelta(t, debugTime.UnixMilli(), model.CurrentTime, 1000)
+		entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+		loc, err := time.LoadLocation(agency.Timezone)
+		require.NoError(t, err)
+		y, m, d := debugTime.In(loc).Date()
+		assert.Equal(t, float64(time.Date(y, m, d, 0, 0, 0, 0, loc).UnixMilli()), entry["date"])
+	})
+
+	t.Run("admin key accepts Unix milliseconds", func(t *testing.T) {
+		endpoint := "/api/where/arrivals-and-departures-for-stop/" + stopID + ".json?key=admin-secret&debugTime=1749740400000"
+		resp, model := serveApiAndRetrieveEndpoint(t, api, endpoint)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.InDelta(t, debugTime.UnixMilli(), model.CurrentTime, 1000)
+	})
+
+	tests := []struct {
+		name     string
+		endpoint string
+	}{
+		{"non-admin key", "/api/where/arrivals-and-departures-for-stop/" + stopID + ".json?key=TEST&debugTime=1749740400000"},
+		{"non-admin key on schedules", "/api/where/schedule-for-stop/" + stopID + ".json?key=TEST&debugTime=1749740400000"},
+		{"invalid value", "/api/where/schedule-for-stop/" + stopID + ".json?key=admin-secret&debugTime=yesterday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, model := serveApiAndRetrieveEndpoint(t, api, tt.endpoint)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+			data, ok := model.Data.(map[string]interface{})
+			require.True(t, ok)
+			fieldErrors := data["fieldErrors"].(map[string]interface{})
+			assert.Contains(t, fieldErrors, "debugTime")
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package restapi
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+//go:embed departure_widget.html
+var departureWidgetFS embed.FS
+
+var departureWidgetTemplate = template.Must(template.ParseFS(departureWidgetFS, "departure_widget.html"))
+
+const (
+	widgetDefaultMinutesAfter = 60
+	widgetMaxMinutesAfter     = 240
+	widgetDefaultCount        = 6
+	widgetMaxCount            = 20
+
+	// widgetDefaultRefresh is how often the HTML widget reloads itself, in seconds. Shorter
+	// intervals than widgetMinRefresh would outpace the realtime feed.
+	widgetDefaultRefresh = 30
+	widgetMinRefresh     = 15
+	widgetMaxRefresh     = 3600
+)
+
+// departureWidgetPolicy lets any site frame the widget. The page has no script; its only
+// active content is the inline stylesheet.
+const departureWidgetPolicy = "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors *;"
+
+// gtfsColor matches a GTFS route color: six hex digits without the '#'.
+var gtfsColor = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
+
+type departureWidgetRow struct {
+	RouteShortName string
+	Color          string
+	TextColor      string
+	Headsign       string
+	When           string
+	Predicted      bool
+}
+
+type departureWidgetPage struct {
+	Entry        models.DepartureWidgetEntry
+	Departures   []departureWidgetRow
+	MinutesAfter int
+	Updated      string
+}
+
+// departureWidgetHandler serves departures-widget/{id}.html and .json: the next departures from
+// a stop, for agency websites to embed. The HTML form is a self-contained page meant for an
+// iframe that reloads itself every refresh seconds; the JSON form carries the same entry for
+// sites that render it themselves. minutesAfter sets how far ahead to look and maxCount caps
+// the departures.
+func (api *RestAPI) departureWidgetHandler(w http.ResponseWriter, r *http.Request) {
+	value := r.PathValue("id")
+	id, isHTML := strings.CutSuffix(value, ".html")
+	if !isHTML {
+		var isJSON bool
+		if id, isJSON = strings.CutSuffix(value, ".json"); !isJSON {
+			api.sendNotFound(w, r)
+			return
+		}
+	}
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	agencyID, stopCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+
+	query := r.URL.Query()
+	maxCount, fieldErrors := utils.ParseMaxCount(query, widgetDefaultCount, widgetMaxCount, nil)
+	minutesAfter := widgetDefaultMinutesAfter
+	if val := query.Get("minutesAfter"); val != "" {
+		if minutes, err := strconv.Atoi(val); err != nil || minutes <= 0 {
+			fieldErrors["minutesAfter"] = []string{"must be a positive integer"}
+		} else {
+			minutesAfter = min(minutes, widgetMaxMinutesAfter)
+		}
+	}
+	refresh := widgetDefaultRefresh
+	if val := query.Get("refresh"); val != "" {
+		seconds, err := strconv.Atoi(val)
+		if err != nil || (seconds != 0 && (seconds < widgetMinRefresh || seconds > widgetMaxRefresh)) {
+			fieldErrors["refresh"] = []string{fmt.Sprintf("must be 0 or between %d and %d seconds", widgetMinRefresh, widgetMaxRefresh)}
+		}
+		refresh = seconds
+	}
+	requestClock, err := api.requestClock(r)
+	if err != nil {
+		fieldErrors[debugTimeParam] = []string{err.Error()}
+	}
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopCode)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agencyID)
+	now := requestClock.Now().In(loc)
+
+	departures, err := api.upcomingDepartures(ctx, stopCode, now, time.Duration(minutesAfter)*time.Minute)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(departures) > maxCount {
+		departures = departures[:maxCount]
+	}
+
+	entry := models.DepartureWidgetEntry{
+		StopID:         id,
+		StopName:       stop.Name.String,
+		StopCode:       stop.Code.String,
+		RefreshSeconds: refresh,
+		Departures:     make([]models.WidgetDeparture, 0, len(departures)),
+	}
+	for _, departure := range departures {
+		headsign := departure.StopTime.StopHeadsign.String
+		if headsign == "" {
+			headsign = departure.StopTime.TripHeadsign.String
+		}
+		expected := departure.ExpectedDeparture()
+		entry.Departures = append(entry.Departures, models.WidgetDeparture{
+			RouteID:            utils.FormCombinedID(agencyID, departure.Route.ID),
+			RouteShortName:     icalRouteName(departure.Route),
+			RouteColor:         departure.Route.Color.String,
+			RouteTextColor:     departure.Route.TextColor.String,
+			TripID:             utils.FormCombinedID(agencyID, departure.Trip.ID),
+			Headsign:           headsign,
+			ScheduledDeparture: departure.AimedDeparture.UnixMilli(),
+			DepartureTime:      expected.UnixMilli(),
+			Predicted:          departure.Prediction.Predicted,
+			MinutesAway:        max(int(expected.Sub(now)/time.Minute), 0),
+		})
+	}
+
+	if !isHTML {
+		api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), requestClock))
+		return
+	}
+
+	page := departureWidgetPage{Entry: entry, MinutesAfter: minutesAfter, Updated: now.Format("15:04")}
+	for _, departure := range entry.Departures {
+		row := departureWidgetRow{
+			RouteShortName: departure.RouteShortName,
+			Headsign:       departure.Headsign,
+			When:           widgetWhen(departure, loc),
+			Predicted:      departure.Predicted,
+		}
+		// Only well-formed colors reach the style attribute
+		if gtfsColor.MatchString(departure.RouteColor) {
+			row.Color, row.TextColor = departure.RouteColor, "FFFFFF"
+			if gtfsColor.MatchString(departure.RouteTextColor) {
+				row.TextColor = departure.RouteTextColor
+			}
+		}
+		page.Departures = append(page.Departures, row)
+	}
+
+	var body bytes.Buffer
+	if err := departureWidgetTemplate.Execute(&body, page); err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", departureWidgetPolicy)
+	w.Header().Del("X-Frame-Options")
+	if _, err := w.Write(body.Bytes()); err != nil {
+		logging.LogError(api.requestLogger(r), "failed to write departure widget", err)
+	}
+}
+
+// widgetWhen is how the widget shows a departure: "Now", minutes away within the hour, and
+// the clock time after that.
+func widgetWhen(departure models.WidgetDeparture, loc *time.Location) string {
+	switch {
+	case departure.MinutesAway == 0:
+		return "Now"
+	case departure.MinutesAway < 60:
+		return strconv.Itoa(departure.MinutesAway) + " min"
+	default:
+		return time.UnixMilli(departure.DepartureTime).In(loc).Format("15:04")
+	}
+}
@@ -0,0 +1,90 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestDepartureWidgetHandler(t *testing.T) {
+	now := time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)
+	api := createTestApiWithClock(t, clock.NewMockClock(now))
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	stop := api.GtfsManager.GetStops()[0]
+	stopID := utils.FormCombinedID(agency.Id, stop.Id)
+	target := "/api/where/departures-widget/" + stopID
+
+	t.Run("json", func(t *testing.T) {
+		rec := serveSiri(t, api, target+".json?key="+siriTestKey+"&maxCount=3")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var body struct {
+			Data struct {
+				Entry models.DepartureWidgetEntry `json:"entry"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		entry := body.Data.Entry
+		assert.Equal(t, stopID, entry.StopID)
+		assert.Equal(t, stop.Name, entry.StopName)
+		assert.Equal(t, widgetDefaultRefresh, entry.RefreshSeconds)
+		require.NotEmpty(t, entry.Departures)
+		assert.LessOrEqual(t, len(entry.Departures), 3)
+		for i, departure := range entry.Departures {
+			assert.GreaterOrEqual(t, departure.DepartureTime, now.UnixMilli())
+			assert.LessOrEqual(t, departure.MinutesAway, widgetDefaultMinutesAfter)
+			if i > 0 {
+				assert.GreaterOrEqual(t, departure.DepartureTime, entry.Departures[i-1].DepartureTime)
+			}
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		rec := serveSiri(t, api, target+".html?key="+siriTestKey+"&refresh=60")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Empty(t, rec.Header().Get("X-Frame-Options"), "the widget is meant to be framed")
+		assert.Contains(t, rec.Header().Get("Content-Security-Policy"), "frame-ancestors *")
+		body := rec.Body.String()
+		assert.Contains(t, body, `<meta http-equiv="refresh" content="60" />`)
+		assert.Contains(t, body, stop.Name)
+		assert.NotContains(t, body, "<script")
+	})
+
+	t.Run("refresh disabled", func(t *testing.T) {
+		rec := serveSiri(t, api, target+".html?key="+siriTestKey+"&refresh=0")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), `http-equiv="refresh"`)
+	})
+
+	t.Run("no departures", func(t *testing.T) {
+		late := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 12, 10, 0, 0, 0, time.UTC)))
+		defer late.Shutdown()
+		rec := serveSiri(t, late, target+".html?key="+siriTestKey+"&minutesAfter=1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "No departures in the next 1 minutes.")
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"&refresh=5", "&refresh=abc", "&minutesAfter=0", "&maxCount=-1"} {
+			rec := serveSiri(t, api, target+".json?key="+siriTestKey+query)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, path := range []string{target + ".xml", "/api/where/departures-widget/" + agency.Id + "_nonexistent.html"} {
+			rec := serveSiri(t, api, path+"?key="+siriTestKey)
+			assert.Equal(t, http.StatusNotFound, rec.Code, path)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/twpayne/go-polyline"
+	"maglev.onebusaway.org/internal/detours"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// detoursForAgencyHandler lists the detours in effect on an agency's routes.
+func (api *RestAPI) detoursForAgencyHandler(w http.ResponseWriter, r *http.Request) {
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+
+	api.GtfsManager.RLock()
+	found := api.GtfsManager.FindAgency(id) != nil
+	api.GtfsManager.RUnlock()
+	if !found {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	api.sendDetours(w, r, id, func(routeID string) bool {
+		route := api.GtfsManager.FindRoute(routeID)
+		return route != nil && route.Agency != nil && route.Agency.Id == id
+	})
+}
+
+// detoursForRouteHandler lists the detours in effect on a route.
+func (api *RestAPI) detoursForRouteHandler(w http.ResponseWriter, r *http.Request) {
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	agencyID, routeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+
+	api.GtfsManager.RLock()
+	route := api.GtfsManager.FindRoute(routeID)
+	api.GtfsManager.RUnlock()
+	if route == nil {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	api.sendDetours(w, r, agencyID, func(id string) bool { return id == routeID })
+}
+
+// sendDetours answers with the detours in effect now on the routes include accepts, called under
+// the manager's read lock. Each detour lists only those routes, with the agency's IDs.
+func (api *RestAPI) sendDetours(w http.ResponseWriter, r *http.Request, agencyID string, include func(routeID string) bool) {
+	alerts := api.GtfsManager.GetRealTimeAlerts()
+	active := detours.Active(api.Detours.Detours(), alerts, api.Clock.Now())
+
+	list := []models.Detour{}
+	alertIDs := make(map[string]bool)
+	api.GtfsManager.RLock()
+	for _, d := range active {
+		entry := models.Detour{
+			ID:             d.ID,
+			RouteIDs:       []string{},
+			Description:    d.Description,
+			Polylines:      []models.Polyline{},
+			SkippedStopIDs: make([]string, 0, len(d.SkippedStopIDs)),
+			SituationIDs:   []string{},
+		}
+		for _, routeID := range d.RouteIDs {
+			if include(routeID) {
+				entry.RouteIDs = append(entry.RouteIDs, utils.FormCombinedID(agencyID, routeID))
+			}
+		}
+		if len(entry.RouteIDs) == 0 {
+			continue
+		}
+		if !d.Start.IsZero() {
+			entry.StartTime = d.Start.UnixMilli()
+		}
+		if !d.End.IsZero() {
+			entry.EndTime = d.End.UnixMilli()
+		}
+		if len(d.Path) > 0 {
+			entry.Polylines = append(entry.Polylines, models.Polyline{
+				Length: len(d.Path),
+				Points: string(polyline.EncodeCoords(d.Path)),
+			})
+		}
+		for _, stopID := range d.SkippedStopIDs {
+			entry.SkippedStopIDs = append(entry.SkippedStopIDs, utils.FormCombinedID(agencyID, stopID))
+		}
+		if d.AlertID != "" {
+			entry.SituationIDs = append(entry.SituationIDs, d.AlertID)
+			alertIDs[d.AlertID] = true
+		}
+		list = append(list, entry)
+	}
+	api.GtfsManager.RUnlock()
+
+	var named []gtfs.Alert
+	for _, alert := range alerts {
+		if alertIDs[alert.ID] {
+			named = append(named, alert)
+		}
+	}
+	references := models.NewEmptyReferences()
+	for _, situation := range api.BuildSituationReferences(named, agencyID, alertLanguages(w, r)) {
+		references.Situations = append(references.Situations, situation)
+	}
+
+	api.sendResponse(w, r, models.NewListResponse(list, references, false, api.Clock))
+}
@@ -0,0 +1,82 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/detours"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestDetoursHandlers(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	routes := api.GtfsManager.GetStaticData().Routes
+	require.GreaterOrEqual(t, len(routes), 2)
+	drawn, alerted := routes[0].Id, routes[1].Id
+
+	path := filepath.Join(t.TempDir(), "detours.json")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(`{"detours": [
+		{"id": "bridge", "route-ids": [%q, "other-agency-route"], "skipped-stop-ids": ["S1"],
+		 "path": [[38.5, -121.5], [38.6, -121.6]], "end": "2099-01-01T00:00:00Z"}
+	]}`, drawn)), 0o600))
+	store, err := detours.New(path)
+	require.NoError(t, err)
+	api.Detours = store
+
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "fair-detour",
+		Effect:           gtfs.Detour,
+		Header:           []gtfs.AlertText{{Text: "County fair"}},
+		InformedEntities: []gtfs.AlertInformedEntity{{RouteID: &alerted}},
+	})
+	defer api.GtfsManager.MockRemoveAlert("fair-detour")
+
+	serve := func(t *testing.T, target string) (int, []models.Detour, []json.RawMessage) {
+		rec := serveSiri(t, api, target)
+		var response struct {
+			Data struct {
+				List       []models.Detour `json:"list"`
+				References struct {
+					Situations []json.RawMessage `json:"situations"`
+				} `json:"references"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		return rec.Code, response.Data.List, response.Data.References.Situations
+	}
+
+	code, list, situations := serve(t, "/api/where/detours-for-agency/"+agencyID+".json?key="+siriTestKey)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, list, 2)
+	assert.Equal(t, "bridge", list[0].ID)
+	assert.Equal(t, []string{utils.FormCombinedID(agencyID, drawn)}, list[0].RouteIDs)
+	assert.Equal(t, []string{utils.FormCombinedID(agencyID, "S1")}, list[0].SkippedStopIDs)
+	require.Len(t, list[0].Polylines, 1)
+	assert.Equal(t, 2, list[0].Polylines[0].Length)
+	assert.Equal(t, int64(4070908800000), list[0].EndTime)
+	assert.Equal(t, "County fair", list[1].Description)
+	assert.Equal(t, []string{"fair-detour"}, list[1].SituationIDs)
+	assert.Len(t, situations, 1)
+
+	code, list, _ = serve(t, "/api/where/detours-for-route/"+utils.FormCombinedID(agencyID, alerted)+".json?key="+siriTestKey)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, list, 1)
+	assert.Equal(t, "fair-detour", list[0].ID)
+	assert.Empty(t, list[0].Polylines)
+
+	code, _, _ = serve(t, "/api/where/detours-for-route/"+agencyID+"_missing.json?key="+siriTestKey)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _, _ = serve(t, "/api/where/detours-for-agency/missing.json?key="+siriTestKey)
+	assert.Equal(t, http.StatusNotFound, code)
+}
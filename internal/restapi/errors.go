@@ -1,7 +1,6 @@
 package restapi
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"maglev.onebusaway.org/internal/models"
@@ -11,46 +10,43 @@ import (
 // for invalid API key errors
 func (api *RestAPI) invalidAPIKeyResponse(w http.ResponseWriter, r *http.Request) {
 	// Create response with the specific format required
-	response := struct {
-		Code        int    `json:"code"`
-		CurrentTime int64  `json:"currentTime"`
-		Text        string `json:"text"`
-		Version     int    `json:"version"`
-	}{
+	response := models.ResponseModel{
 		Code:        http.StatusUnauthorized,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
 		Text:        "permission denied",
 		Version:     1, // Note: This is version 1, not 2 as in a successful response. Probably a mistake, but back-compat.
+		RequestID:   RequestIDFromContext(r.Context()),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	setResponseType(w, r)
 	w.WriteHeader(http.StatusUnauthorized)
-	err := json.NewEncoder(w).Encode(response)
+	err := api.encodeResponse(w, r, response)
 	if err != nil {
-		api.Logger.Error("failed to encode invalid API key response", "error", err)
+		api.requestLogger(r).Error("failed to encode invalid API key response", "error", err)
 	}
 }
 
 func (api *RestAPI) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	api.Logger.Error("internal server error", "error", err, "path", r.URL.Path)
-	// Send a 500 Internal Server Error response
-	response := struct {
-		Code        int    `json:"code"`
-		CurrentTime int64  `json:"currentTime"`
-		Text        string `json:"text"`
-		Version     int    `json:"version"`
-	}{
+	api.requestLogger(r).Error("internal server error", "error", err, "path", r.URL.Path)
+	api.reportError(r, err, http.StatusInternalServerError)
+	api.writeServerError(w, r)
+}
+
+// writeServerError sends a 500 Internal Server Error response
+func (api *RestAPI) writeServerError(w http.ResponseWriter, r *http.Request) {
+	response := models.ResponseModel{
 		Code:        http.StatusInternalServerError,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
 		Text:        "internal server error",
 		Version:     1,
+		RequestID:   RequestIDFromContext(r.Context()),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	setResponseType(w, r)
 	w.WriteHeader(http.StatusInternalServerError)
-	encoderErr := json.NewEncoder(w).Encode(response)
+	encoderErr := api.encodeResponse(w, r, response)
 	if encoderErr != nil {
-		api.Logger.Error("failed to encode server error response", "error", encoderErr)
+		api.requestLogger(r).Error("failed to encode server error response", "error", encoderErr)
 	}
 }
 
@@ -64,13 +60,7 @@ func (api *RestAPI) validationErrorResponse(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	response := struct {
-		Code        int         `json:"code"`
-		CurrentTime int64       `json:"currentTime"`
-		Text        string      `json:"text"`
-		Version     int         `json:"version"`
-		Data        interface{} `json:"data"`
-	}{
+	response := models.ResponseModel{
 		Code:        http.StatusBadRequest,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
 		Text:        errorText,
@@ -80,12 +70,13 @@ func (api *RestAPI) validationErrorResponse(w http.ResponseWriter, r *http.Reque
 		}{
 			FieldErrors: fieldErrors,
 		},
+		RequestID: RequestIDFromContext(r.Context()),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	setResponseType(w, r)
 	w.WriteHeader(http.StatusBadRequest)
-	err := json.NewEncoder(w).Encode(response)
+	err := api.encodeResponse(w, r, response)
 	if err != nil {
-		api.Logger.Error("failed to encode validation error response", "error", err)
+		api.requestLogger(r).Error("failed to encode validation error response", "error", err)
 	}
 }
@@ -0,0 +1,96 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// maxGraphQLRequestSize caps the JSON body of a GraphQL POST.
+const maxGraphQLRequestSize = 1 << 20
+
+// graphqlRequest is a GraphQL query with its operation name and variables.
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphqlHandler answers GraphQL queries against schema, sent as the query, operationName and
+// variables parameters of a GET or as a JSON body of a POST. Responses follow the GraphQL over
+// HTTP conventions rather than the OneBusAway envelope: 400 when the query could not run at all
+// and 200 otherwise, with any field errors listed next to the data.
+func (api *RestAPI) graphqlHandler(schema *graphql.Schema) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := parseGraphQLRequest(w, r)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				api.sendGraphQL(w, r, http.StatusRequestEntityTooLarge, graphqlErrorResponse("request body too large"))
+				return
+			}
+			api.sendGraphQL(w, r, http.StatusBadRequest, graphqlErrorResponse(err.Error()))
+			return
+		}
+
+		api.GtfsManager.RLock()
+		response := schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+		api.GtfsManager.RUnlock()
+
+		// A response without data is one whose query could not be parsed, validated or run
+		status := http.StatusOK
+		if response.Data == nil {
+			status = http.StatusBadRequest
+		}
+		api.sendGraphQL(w, r, status, response)
+	}
+}
+
+func parseGraphQLRequest(w http.ResponseWriter, r *http.Request) (graphqlRequest, error) {
+	var req graphqlRequest
+	if r.Method == http.MethodGet {
+		params := r.URL.Query()
+		req.Query = params.Get("query")
+		req.OperationName = params.Get("operationName")
+		if variables := params.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return req, errors.New("variables must be a JSON object")
+			}
+		}
+	} else {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGraphQLRequestSize))
+		if err != nil {
+			return req, err
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return req, errors.New("body must be a JSON object with a query")
+		}
+	}
+	if req.Query == "" {
+		return req, errors.New("must provide a query")
+	}
+	return req, nil
+}
+
+func graphqlErrorResponse(message string) *graphql.Response {
+	return &graphql.Response{Errors: []*gqlerrors.QueryError{{Message: message}}}
+}
+
+// sendGraphQL writes a GraphQL response. It is encoded before the status is written so an
+// encoding failure still becomes a 500.
+func (api *RestAPI) sendGraphQL(w http.ResponseWriter, r *http.Request, status int, response *graphql.Response) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(response); err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
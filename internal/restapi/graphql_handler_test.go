@@ -0,0 +1,152 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.GraphQL = true
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+		return rec, body
+	}
+
+	t.Run("GET", func(t *testing.T) {
+		query := url.QueryEscape(`{ stop(id: "25_327") { id name lat routes { id agency { id } } } missing: stop(id: "25_nope") { id } }`)
+		rec, body := serve(httptest.NewRequest(http.MethodGet, "/graphql?key="+siriTestKey+"&query="+query, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Nil(t, body["errors"])
+
+		data := body["data"].(map[string]any)
+		assert.Nil(t, data["missing"])
+		stop := data["stop"].(map[string]any)
+		assert.Equal(t, "25_327", stop["id"])
+		assert.NotEmpty(t, stop["name"])
+		routes := stop["routes"].([]any)
+		require.NotEmpty(t, routes)
+		for _, r := range routes {
+			route := r.(map[string]any)
+			assert.True(t, strings.HasPrefix(route["id"].(string), "25_"), route["id"])
+			assert.Equal(t, map[string]any{"id": "25"}, route["agency"])
+		}
+	})
+
+	t.Run("POST with variables", func(t *testing.T) {
+		payload := `{"query": "query Trips($id: ID!) { route(id: $id) { trips { id route { id } stopTimes { stopSequence arrivalTime stop { id } } } } }", "variables": {"id": "25_151"}}`
+		rec, body := serve(httptest.NewRequest(http.MethodPost, "/graphql?key="+siriTestKey, strings.NewReader(payload)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Nil(t, body["errors"])
+
+		trips := body["data"].(map[string]any)["route"].(map[string]any)["trips"].([]any)
+		require.NotEmpty(t, trips)
+		trip := trips[0].(map[string]any)
+		assert.Equal(t, map[string]any{"id": "25_151"}, trip["route"])
+		stopTimes := trip["stopTimes"].([]any)
+		require.NotEmpty(t, stopTimes)
+		first := stopTimes[0].(map[string]any)
+		assert.Greater(t, first["arrivalTime"], 0.0)
+		assert.True(t, strings.HasPrefix(first["stop"].(map[string]any)["id"].(string), "25_"))
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		query := url.QueryEscape(`{ stop(id: "25_327") { nope } }`)
+		rec, body := serve(httptest.NewRequest(http.MethodGet, "/graphql?key="+siriTestKey+"&query="+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.NotContains(t, body, "data")
+		errors := body["errors"].([]any)
+		require.Len(t, errors, 1)
+		assert.Equal(t, `Cannot query field "nope" on type "Stop".`, errors[0].(map[string]any)["message"])
+	})
+
+	t.Run("stops for location", func(t *testing.T) {
+		query := url.QueryEscape(`{ stopsForLocation(lat: 40.583321, lon: -122.426966, limit: 3) { id distance wheelchairBoarding } }`)
+		rec, body := serve(httptest.NewRequest(http.MethodGet, "/graphql?key="+siriTestKey+"&query="+query, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		stops := body["data"].(map[string]any)["stopsForLocation"].([]any)
+		require.NotEmpty(t, stops)
+		assert.LessOrEqual(t, len(stops), 3)
+		previous := 0.0
+		for _, s := range stops {
+			stop := s.(map[string]any)
+			assert.GreaterOrEqual(t, stop["distance"], previous, "closest first")
+			previous = stop["distance"].(float64)
+			assert.Contains(t, []any{"ACCESSIBLE", "NOT_ACCESSIBLE", "UNKNOWN"}, stop["wheelchairBoarding"])
+		}
+	})
+
+	t.Run("field errors keep the rest of the data", func(t *testing.T) {
+		query := url.QueryEscape(`{ agency(id: "25") { id } route(id: "no-agency") { id } }`)
+		rec, body := serve(httptest.NewRequest(http.MethodGet, "/graphql?key="+siriTestKey+"&query="+query, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		data := body["data"].(map[string]any)
+		assert.Equal(t, map[string]any{"id": "25"}, data["agency"])
+		assert.Nil(t, data["route"])
+		errors := body["errors"].([]any)
+		require.Len(t, errors, 1)
+		assert.Equal(t, []any{"route"}, errors[0].(map[string]any)["path"])
+	})
+
+	t.Run("introspection", func(t *testing.T) {
+		query := url.QueryEscape(`{ __schema { queryType { name } } __type(name: "Stop") { fields { name } } }`)
+		rec, body := serve(httptest.NewRequest(http.MethodGet, "/graphql?key="+siriTestKey+"&query="+query, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		data := body["data"].(map[string]any)
+		assert.Equal(t, map[string]any{"name": "Query"}, data["__schema"].(map[string]any)["queryType"])
+		assert.Contains(t, data["__type"].(map[string]any)["fields"], map[string]any{"name": "wheelchairBoarding"})
+	})
+
+	t.Run("too deep", func(t *testing.T) {
+		selection := "id"
+		for range graphqlMaxDepth / 2 {
+			selection = "trips { route { " + selection + " } }"
+		}
+		query := `{ route(id: "25_151") { ` + selection + ` } }`
+		rec, body := serve(httptest.NewRequest(http.MethodPost, "/graphql?key="+siriTestKey, strings.NewReader(`{"query": `+strconv.Quote(query)+`}`)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		assert.NotContains(t, body, "data")
+		require.Len(t, body["errors"], 1)
+		assert.Contains(t, body["errors"].([]any)[0].(map[string]any)["message"], "exceeds max depth")
+	})
+
+	t.Run("mutation", func(t *testing.T) {
+		query := url.QueryEscape(`mutation { stop(id: "25_327") { id } }`)
+		rec, body := serve(httptest.NewRequest(http.MethodGet, "/graphql?key="+siriTestKey+"&query="+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.NotEmpty(t, body["errors"])
+	})
+
+	t.Run("missing query", func(t *testing.T) {
+		rec, body := serve(httptest.NewRequest(http.MethodPost, "/graphql?key="+siriTestKey, strings.NewReader(`{}`)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, []any{map[string]any{"message": "must provide a query"}}, body["errors"])
+	})
+}
+
+func TestGraphQLHandler_Disabled(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?key="+siriTestKey+"&query=%7B__typename%7D", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,497 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/graph-gophers/graphql-go"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// graphqlSchemaSource is the schema served at /graphql. Its fields resolve to the tagged fields
+// and the methods of the graphql* types below.
+//
+//go:embed graphql_schema.graphql
+var graphqlSchemaSource string
+
+// graphqlMaxDepth is how deeply the fields of a query may nest; the fields of Query are at depth 1.
+const graphqlMaxDepth = 15
+
+// graphqlResolver resolves the fields of Query, and is shared by the types it returns for their
+// relations. Static data is read through the database and realtime data through the manager, so
+// resolvers must run under the manager's read lock.
+type graphqlResolver struct {
+	api *RestAPI
+}
+
+func (res *graphqlResolver) queries() *gtfsdb.Queries {
+	return res.api.GtfsManager.GtfsDB.Queries
+}
+
+// The types the GraphQL resolvers pass between each other. Tagged fields resolve the schema
+// field of that name; the untagged raw IDs let relations query the database, which stores IDs
+// without their agency prefix.
+type (
+	graphqlAgency struct {
+		ID       graphql.ID `graphql:"id"`
+		Name     string     `graphql:"name"`
+		URL      string     `graphql:"url"`
+		Timezone string     `graphql:"timezone"`
+		Lang     *string    `graphql:"lang"`
+		Phone    *string    `graphql:"phone"`
+		FareURL  *string    `graphql:"fareUrl"`
+		Email    *string    `graphql:"email"`
+
+		res *graphqlResolver
+	}
+
+	graphqlRoute struct {
+		ID          graphql.ID `graphql:"id"`
+		ShortName   *string    `graphql:"shortName"`
+		LongName    *string    `graphql:"longName"`
+		Description *string    `graphql:"description"`
+		Type        int32      `graphql:"type"`
+		URL         *string    `graphql:"url"`
+		Color       *string    `graphql:"color"`
+		TextColor   *string    `graphql:"textColor"`
+
+		res      *graphqlResolver
+		agencyID string
+		routeID  string
+	}
+
+	graphqlStop struct {
+		ID                 graphql.ID `graphql:"id"`
+		Code               *string    `graphql:"code"`
+		Name               *string    `graphql:"name"`
+		Description        *string    `graphql:"description"`
+		Lat                float64    `graphql:"lat"`
+		Lon                float64    `graphql:"lon"`
+		Direction          *string    `graphql:"direction"`
+		LocationType       *int32     `graphql:"locationType"`
+		WheelchairBoarding string     `graphql:"wheelchairBoarding"`
+		Distance           *float64   `graphql:"distance"`
+
+		res    *graphqlResolver
+		stopID string
+	}
+
+	graphqlTrip struct {
+		ID          graphql.ID `graphql:"id"`
+		ServiceID   string     `graphql:"serviceId"`
+		Headsign    *string    `graphql:"headsign"`
+		ShortName   *string    `graphql:"shortName"`
+		DirectionID *int32     `graphql:"directionId"`
+		BlockID     *string    `graphql:"blockId"`
+		ShapeID     *string    `graphql:"shapeId"`
+
+		res      *graphqlResolver
+		agencyID string
+		tripID   string
+		routeID  string
+	}
+
+	graphqlStopTime struct {
+		StopSequence      int32    `graphql:"stopSequence"`
+		ArrivalTime       int32    `graphql:"arrivalTime"`
+		DepartureTime     int32    `graphql:"departureTime"`
+		StopHeadsign      *string  `graphql:"stopHeadsign"`
+		PickupType        *int32   `graphql:"pickupType"`
+		DropOffType       *int32   `graphql:"dropOffType"`
+		ShapeDistTraveled *float64 `graphql:"shapeDistTraveled"`
+
+		res      *graphqlResolver
+		agencyID string
+		tripID   string
+		stopID   string
+	}
+
+	graphqlVehicle struct {
+		ID        *graphql.ID `graphql:"id"`
+		Label     *string     `graphql:"label"`
+		Lat       *float64    `graphql:"lat"`
+		Lon       *float64    `graphql:"lon"`
+		Bearing   *float64    `graphql:"bearing"`
+		Speed     *float64    `graphql:"speed"`
+		Timestamp *float64    `graphql:"timestamp"`
+
+		res      *graphqlResolver
+		agencyID string
+		tripID   string
+	}
+)
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullInt32(i sql.NullInt64) *int32 {
+	if !i.Valid {
+		return nil
+	}
+	v := int32(i.Int64)
+	return &v
+}
+
+func nullFloat64(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
+
+func float32Ptr(f *float32) *float64 {
+	if f == nil {
+		return nil
+	}
+	v := float64(*f)
+	return &v
+}
+
+func (res *graphqlResolver) newAgency(a gtfsdb.Agency) *graphqlAgency {
+	return &graphqlAgency{
+		ID:       graphql.ID(a.ID),
+		Name:     a.Name,
+		URL:      a.Url,
+		Timezone: a.Timezone,
+		Lang:     nullString(a.Lang),
+		Phone:    nullString(a.Phone),
+		FareURL:  nullString(a.FareUrl),
+		Email:    nullString(a.Email),
+		res:      res,
+	}
+}
+
+func (res *graphqlResolver) newRoute(r gtfsdb.Route) *graphqlRoute {
+	return &graphqlRoute{
+		ID:          graphql.ID(utils.FormCombinedID(r.AgencyID, r.ID)),
+		ShortName:   nullString(r.ShortName),
+		LongName:    nullString(r.LongName),
+		Description: nullString(r.Desc),
+		Type:        int32(r.Type),
+		URL:         nullString(r.Url),
+		Color:       nullString(r.Color),
+		TextColor:   nullString(r.TextColor),
+		res:         res,
+		agencyID:    r.AgencyID,
+		routeID:     r.ID,
+	}
+}
+
+func (res *graphqlResolver) newStop(agencyID string, s gtfsdb.Stop) *graphqlStop {
+	return &graphqlStop{
+		ID:                 graphql.ID(utils.FormCombinedID(agencyID, s.ID)),
+		Code:               nullString(s.Code),
+		Name:               nullString(s.Name),
+		Description:        nullString(s.Desc),
+		Lat:                s.Lat,
+		Lon:                s.Lon,
+		Direction:          nullString(s.Direction),
+		LocationType:       nullInt32(s.LocationType),
+		WheelchairBoarding: utils.MapWheelchairBoarding(utils.NullWheelchairBoardingOrUnknown(s.WheelchairBoarding)),
+		res:                res,
+		stopID:             s.ID,
+	}
+}
+
+func (res *graphqlResolver) newTrip(agencyID string, t gtfsdb.Trip) *graphqlTrip {
+	return &graphqlTrip{
+		ID:          graphql.ID(utils.FormCombinedID(agencyID, t.ID)),
+		ServiceID:   t.ServiceID,
+		Headsign:    nullString(t.TripHeadsign),
+		ShortName:   nullString(t.TripShortName),
+		DirectionID: nullInt32(t.DirectionID),
+		BlockID:     nullString(t.BlockID),
+		ShapeID:     nullString(t.ShapeID),
+		res:         res,
+		agencyID:    agencyID,
+		tripID:      t.ID,
+		routeID:     t.RouteID,
+	}
+}
+
+// newVehicle converts a realtime vehicle, whose trip belongs to agencyID.
+func (res *graphqlResolver) newVehicle(agencyID string, v gtfs.Vehicle) *graphqlVehicle {
+	vehicle := &graphqlVehicle{res: res, agencyID: agencyID}
+	if v.ID != nil {
+		id := graphql.ID(v.ID.ID)
+		vehicle.ID = &id
+		if v.ID.Label != "" {
+			vehicle.Label = &v.ID.Label
+		}
+	}
+	if v.Position != nil {
+		vehicle.Lat = float32Ptr(v.Position.Latitude)
+		vehicle.Lon = float32Ptr(v.Position.Longitude)
+		vehicle.Bearing = float32Ptr(v.Position.Bearing)
+		vehicle.Speed = float32Ptr(v.Position.Speed)
+	}
+	if v.Timestamp != nil {
+		timestamp := float64(v.Timestamp.UnixMilli())
+		vehicle.Timestamp = &timestamp
+	}
+	if v.Trip != nil {
+		vehicle.tripID = v.Trip.ID.ID
+	}
+	return vehicle
+}
+
+// notFoundAsNull drops the error of a missing row, so that looking up an unknown ID gives null
+// rather than an error.
+func notFoundAsNull(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// graphqlID splits the combined ID argument of a GraphQL field.
+func (res *graphqlResolver) graphqlID(id graphql.ID, name string) (agencyID, codeID string, err error) {
+	agencyID, codeID, err = res.api.GtfsManager.ExtractAgencyIDAndCodeID(string(id))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return agencyID, codeID, nil
+}
+
+// newGraphQLSchema builds the schema served at /graphql.
+func (api *RestAPI) newGraphQLSchema() *graphql.Schema {
+	return graphql.MustParseSchema(graphqlSchemaSource, &graphqlResolver{api: api},
+		graphql.UseFieldResolvers(),
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(graphqlMaxDepth))
+}
+
+func (res *graphqlResolver) Agencies(ctx context.Context) ([]*graphqlAgency, error) {
+	rows, err := res.queries().ListAgencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	agencies := make([]*graphqlAgency, len(rows))
+	for i, row := range rows {
+		agencies[i] = res.newAgency(row)
+	}
+	return agencies, nil
+}
+
+func (res *graphqlResolver) Agency(ctx context.Context, args struct{ ID graphql.ID }) (*graphqlAgency, error) {
+	a, err := res.queries().GetAgency(ctx, string(args.ID))
+	if err != nil {
+		return nil, notFoundAsNull(err)
+	}
+	return res.newAgency(a), nil
+}
+
+func (res *graphqlResolver) Route(ctx context.Context, args struct{ ID graphql.ID }) (*graphqlRoute, error) {
+	_, routeID, err := res.graphqlID(args.ID, "id")
+	if err != nil {
+		return nil, err
+	}
+	r, err := res.queries().GetRoute(ctx, routeID)
+	if err != nil {
+		return nil, notFoundAsNull(err)
+	}
+	return res.newRoute(r), nil
+}
+
+func (res *graphqlResolver) Stop(ctx context.Context, args struct{ ID graphql.ID }) (*graphqlStop, error) {
+	agencyID, stopID, err := res.graphqlID(args.ID, "id")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := res.queries().GetStopsByIDs(ctx, []string{stopID})
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return res.newStop(agencyID, rows[0]), nil
+}
+
+func (res *graphqlResolver) Trip(ctx context.Context, args struct{ ID graphql.ID }) (*graphqlTrip, error) {
+	agencyID, tripID, err := res.graphqlID(args.ID, "id")
+	if err != nil {
+		return nil, err
+	}
+	t, err := res.queries().GetTrip(ctx, tripID)
+	if err != nil {
+		return nil, notFoundAsNull(err)
+	}
+	return res.newTrip(agencyID, t), nil
+}
+
+func (res *graphqlResolver) StopsForLocation(ctx context.Context, args struct {
+	Lat, Lon, Radius float64
+	Limit            int32
+}) ([]*graphqlStop, error) {
+	for field, errs := range utils.ValidateLocationParams(args.Lat, args.Lon, args.Radius, 0, 0) {
+		return nil, fmt.Errorf("invalid %s: %s", field, errs[0])
+	}
+	if args.Limit < 1 || args.Limit > models.MaxAllowedCount {
+		return nil, fmt.Errorf("invalid limit: must be between 1 and %d", models.MaxAllowedCount)
+	}
+	rows := res.api.GtfsManager.GetStopsForLocation(ctx, args.Lat, args.Lon, args.Radius, 0, 0, "", int(args.Limit), false, nil, res.api.Clock.Now())
+	stops := make([]*graphqlStop, 0, len(rows))
+	for _, row := range rows {
+		a, err := res.queries().GetAgencyForStop(ctx, row.ID)
+		if err != nil {
+			if err = notFoundAsNull(err); err != nil {
+				return nil, err
+			}
+			continue // Stops no route serves have no agency
+		}
+		s := res.newStop(a.ID, row)
+		distance := utils.Distance(args.Lat, args.Lon, row.Lat, row.Lon)
+		s.Distance = &distance
+		stops = append(stops, s)
+	}
+	return stops, nil
+}
+
+func (res *graphqlResolver) Vehicles(args struct{ AgencyID graphql.ID }) []*graphqlVehicle {
+	agencyID := string(args.AgencyID)
+	rows := res.api.GtfsManager.VehiclesForAgencyID(agencyID)
+	vehicles := make([]*graphqlVehicle, len(rows))
+	for i, row := range rows {
+		vehicles[i] = res.newVehicle(agencyID, row)
+	}
+	return vehicles
+}
+
+func (a *graphqlAgency) Routes(ctx context.Context) ([]*graphqlRoute, error) {
+	routeIDs, err := a.res.queries().GetRouteIDsForAgency(ctx, string(a.ID))
+	if err != nil {
+		return nil, err
+	}
+	routes := make([]*graphqlRoute, 0, len(routeIDs))
+	for _, routeID := range routeIDs {
+		r, err := a.res.queries().GetRoute(ctx, routeID)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, a.res.newRoute(r))
+	}
+	return routes, nil
+}
+
+func (r *graphqlRoute) Agency(ctx context.Context) (*graphqlAgency, error) {
+	a, err := r.res.queries().GetAgency(ctx, r.agencyID)
+	if err != nil {
+		return nil, err
+	}
+	return r.res.newAgency(a), nil
+}
+
+func (r *graphqlRoute) Stops(ctx context.Context) ([]*graphqlStop, error) {
+	rows, err := r.res.queries().GetStopsForRoute(ctx, r.routeID)
+	if err != nil {
+		return nil, err
+	}
+	stops := make([]*graphqlStop, len(rows))
+	for i, row := range rows {
+		stops[i] = r.res.newStop(r.agencyID, row)
+	}
+	return stops, nil
+}
+
+func (r *graphqlRoute) Trips(ctx context.Context) ([]*graphqlTrip, error) {
+	rows, err := r.res.queries().GetAllTripsForRoute(ctx, r.routeID)
+	if err != nil {
+		return nil, err
+	}
+	trips := make([]*graphqlTrip, len(rows))
+	for i, row := range rows {
+		trips[i] = r.res.newTrip(r.agencyID, row)
+	}
+	return trips, nil
+}
+
+func (s *graphqlStop) Routes(ctx context.Context) ([]*graphqlRoute, error) {
+	rows, err := s.res.queries().GetRoutesForStop(ctx, s.stopID)
+	if err != nil {
+		return nil, err
+	}
+	routes := make([]*graphqlRoute, len(rows))
+	for i, row := range rows {
+		routes[i] = s.res.newRoute(row)
+	}
+	return routes, nil
+}
+
+func (t *graphqlTrip) Route(ctx context.Context) (*graphqlRoute, error) {
+	r, err := t.res.queries().GetRoute(ctx, t.routeID)
+	if err != nil {
+		return nil, err
+	}
+	return t.res.newRoute(r), nil
+}
+
+func (t *graphqlTrip) StopTimes(ctx context.Context) ([]*graphqlStopTime, error) {
+	rows, err := t.res.queries().GetStopTimesForTrip(ctx, t.tripID)
+	if err != nil {
+		return nil, err
+	}
+	stopTimes := make([]*graphqlStopTime, len(rows))
+	for i, row := range rows {
+		stopTimes[i] = &graphqlStopTime{
+			StopSequence:      int32(row.StopSequence),
+			ArrivalTime:       int32(time.Duration(row.ArrivalTime) / time.Second),
+			DepartureTime:     int32(time.Duration(row.DepartureTime) / time.Second),
+			StopHeadsign:      nullString(row.StopHeadsign),
+			PickupType:        nullInt32(row.PickupType),
+			DropOffType:       nullInt32(row.DropOffType),
+			ShapeDistTraveled: nullFloat64(row.ShapeDistTraveled),
+			res:               t.res,
+			agencyID:          t.agencyID,
+			tripID:            t.tripID,
+			stopID:            row.StopID,
+		}
+	}
+	return stopTimes, nil
+}
+
+func (t *graphqlTrip) Vehicle() *graphqlVehicle {
+	v := t.res.api.GtfsManager.GetVehicleForTrip(t.tripID)
+	if v == nil {
+		return nil
+	}
+	return t.res.newVehicle(t.agencyID, *v)
+}
+
+func (st *graphqlStopTime) Stop(ctx context.Context) (*graphqlStop, error) {
+	rows, err := st.res.queries().GetStopsByIDs(ctx, []string{st.stopID})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("stop %s not found", st.stopID)
+	}
+	return st.res.newStop(st.agencyID, rows[0]), nil
+}
+
+func (st *graphqlStopTime) Trip(ctx context.Context) (*graphqlTrip, error) {
+	t, err := st.res.queries().GetTrip(ctx, st.tripID)
+	if err != nil {
+		return nil, err
+	}
+	return st.res.newTrip(st.agencyID, t), nil
+}
+
+func (v *graphqlVehicle) Trip(ctx context.Context) (*graphqlTrip, error) {
+	if v.tripID == "" {
+		return nil, nil
+	}
+	t, err := v.res.queries().GetTrip(ctx, v.tripID)
+	if err != nil {
+		return nil, notFoundAsNull(err)
+	}
+	return v.res.newTrip(v.agencyID, t), nil
+}
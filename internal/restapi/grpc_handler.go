@@ -0,0 +1,48 @@
+package restapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/pkg/maglevpb"
+)
+
+// grpcAPIKeyHeader is the metadata key gRPC calls carry their API key in.
+const grpcAPIKeyHeader = "x-api-key"
+
+// GRPCHandler serves the gRPC TransitService over HTTP/2. Each call goes through the same API
+// key, key restriction, blocklist and rate limit checks as a REST request, answered with gRPC
+// status codes instead of JSON errors.
+func (api *RestAPI) GRPCHandler() http.Handler {
+	server := grpc.NewServer()
+	maglevpb.RegisterTransitServiceServer(server, &grpcService{api: api})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(grpcAPIKeyHeader)
+		r = app.WithAPIKey(r, key, false)
+		switch {
+		case api.RequestHasInvalidAPIKey(r):
+			writeGRPCStatus(w, codes.Unauthenticated, "invalid API key")
+		case api.RequestViolatesKeyRestrictions(r):
+			writeGRPCStatus(w, codes.PermissionDenied, "API key not allowed from this origin or address")
+		case api.Blocklist != nil && (api.Blocklist.BlocksKey(key) || api.Blocklist.BlocksAddr(app.ClientAddr(r))):
+			writeGRPCStatus(w, codes.PermissionDenied, "access denied")
+		case api.rateLimiter != nil && !api.rateLimiter.Allow(key):
+			writeGRPCStatus(w, codes.ResourceExhausted, "rate limit exceeded")
+		default:
+			server.ServeHTTP(w, r)
+		}
+	})
+}
+
+// writeGRPCStatus refuses a call with a trailers-only gRPC response. message must not need
+// percent-encoding.
+func writeGRPCStatus(w http.ResponseWriter, code codes.Code, message string) {
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+	w.Header().Set("Grpc-Message", message)
+	w.WriteHeader(http.StatusOK)
+}
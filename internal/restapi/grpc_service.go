@@ -0,0 +1,352 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+	"maglev.onebusaway.org/pkg/maglevpb"
+)
+
+const (
+	// grpcDefaultMinutesAfter and grpcMaxMinutesAfter match the arrivals-and-departures-for-stop
+	// defaults.
+	grpcDefaultMinutesAfter = 35
+	grpcMaxMinutesAfter     = 240
+)
+
+// grpcService implements the gRPC TransitService on the same data as the REST handlers.
+type grpcService struct {
+	maglevpb.UnimplementedTransitServiceServer
+	api *RestAPI
+}
+
+// grpcInternalError logs err and hides it from the caller, as serverErrorResponse does.
+func (s *grpcService) grpcInternalError(ctx context.Context, method string, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	logging.LogError(s.api.Logger, "gRPC call failed", err, slog.String("method", method))
+	return status.Error(codes.Internal, "internal server error")
+}
+
+// grpcID splits a combined ID argument, answering InvalidArgument when it is malformed.
+func (s *grpcService) grpcID(field, id string) (agencyID, codeID string, err error) {
+	agencyID, codeID, err = s.api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		return "", "", status.Errorf(codes.InvalidArgument, "%s: %v", field, err)
+	}
+	return agencyID, codeID, nil
+}
+
+func newPBAgency(agency *gtfs.Agency) *maglevpb.Agency {
+	return &maglevpb.Agency{
+		Id:       agency.Id,
+		Name:     agency.Name,
+		Url:      agency.Url,
+		Timezone: agency.Timezone,
+		Lang:     agency.Language,
+		Phone:    agency.Phone,
+		FareUrl:  agency.FareUrl,
+		Email:    agency.Email,
+	}
+}
+
+func newPBRoute(route gtfsdb.Route) *maglevpb.Route {
+	return &maglevpb.Route{
+		Id:          utils.FormCombinedID(route.AgencyID, route.ID),
+		AgencyId:    route.AgencyID,
+		ShortName:   utils.NullStringOrEmpty(route.ShortName),
+		LongName:    utils.NullStringOrEmpty(route.LongName),
+		Description: utils.NullStringOrEmpty(route.Desc),
+		Type:        int32(route.Type),
+		Url:         utils.NullStringOrEmpty(route.Url),
+		Color:       utils.NullStringOrEmpty(route.Color),
+		TextColor:   utils.NullStringOrEmpty(route.TextColor),
+	}
+}
+
+// newPBStop converts a stop of agencyID with the routes serving it.
+func newPBStop(agencyID string, stop gtfsdb.Stop, routes []gtfsdb.Route) *maglevpb.Stop {
+	routeIDs := make([]string, len(routes))
+	for i, route := range routes {
+		// A stop can be served by routes from other agencies
+		routeIDs[i] = utils.FormCombinedID(route.AgencyID, route.ID)
+	}
+	return &maglevpb.Stop{
+		Id:                 utils.FormCombinedID(agencyID, stop.ID),
+		Code:               utils.NullStringOrEmpty(stop.Code),
+		Name:               utils.NullStringOrEmpty(stop.Name),
+		Lat:                stop.Lat,
+		Lon:                stop.Lon,
+		Direction:          utils.NullStringOrEmpty(stop.Direction),
+		LocationType:       int32(stop.LocationType.Int64),
+		WheelchairBoarding: utils.MapWheelchairBoarding(utils.NullWheelchairBoardingOrUnknown(stop.WheelchairBoarding)),
+		RouteIds:           routeIDs,
+	}
+}
+
+// newPBVehicle converts a realtime vehicle whose trip belongs to agencyID.
+func newPBVehicle(agencyID string, vehicle gtfs.Vehicle) *maglevpb.Vehicle {
+	pb := &maglevpb.Vehicle{}
+	if vehicle.ID != nil {
+		pb.Id = vehicle.ID.ID
+		pb.Label = vehicle.ID.Label
+	}
+	if vehicle.Trip != nil {
+		pb.TripId = utils.FormCombinedID(agencyID, vehicle.Trip.ID.ID)
+		pb.RouteId = utils.FormCombinedID(agencyID, vehicle.Trip.ID.RouteID)
+	}
+	if p := vehicle.Position; p != nil && p.Latitude != nil && p.Longitude != nil {
+		pb.Position = &maglevpb.Position{Lat: *p.Latitude, Lon: *p.Longitude}
+		if p.Bearing != nil {
+			pb.Position.Bearing = *p.Bearing
+		}
+		if p.Speed != nil {
+			pb.Position.Speed = *p.Speed
+		}
+	}
+	if vehicle.Timestamp != nil {
+		pb.Timestamp = vehicle.Timestamp.UnixMilli()
+	}
+	return pb
+}
+
+func (s *grpcService) ListAgencies(context.Context, *maglevpb.ListAgenciesRequest) (*maglevpb.ListAgenciesResponse, error) {
+	s.api.GtfsManager.RLock()
+	defer s.api.GtfsManager.RUnlock()
+
+	agencies := s.api.GtfsManager.GetAgencies()
+	response := &maglevpb.ListAgenciesResponse{Agencies: make([]*maglevpb.Agency, len(agencies))}
+	for i := range agencies {
+		response.Agencies[i] = newPBAgency(&agencies[i])
+	}
+	return response, nil
+}
+
+func (s *grpcService) GetAgency(_ context.Context, req *maglevpb.GetAgencyRequest) (*maglevpb.Agency, error) {
+	s.api.GtfsManager.RLock()
+	defer s.api.GtfsManager.RUnlock()
+
+	agency := s.api.GtfsManager.FindAgency(req.GetId())
+	if agency == nil {
+		return nil, status.Errorf(codes.NotFound, "agency %q not found", req.GetId())
+	}
+	return newPBAgency(agency), nil
+}
+
+func (s *grpcService) ListRoutesForAgency(ctx context.Context, req *maglevpb.ListRoutesForAgencyRequest) (*maglevpb.ListRoutesForAgencyResponse, error) {
+	s.api.GtfsManager.RLock()
+	defer s.api.GtfsManager.RUnlock()
+
+	if s.api.GtfsManager.FindAgency(req.GetAgencyId()) == nil {
+		return nil, status.Errorf(codes.NotFound, "agency %q not found", req.GetAgencyId())
+	}
+	routeIDs, err := s.api.GtfsManager.GtfsDB.Queries.GetRouteIDsForAgency(ctx, req.GetAgencyId())
+	if err != nil {
+		return nil, s.grpcInternalError(ctx, "ListRoutesForAgency", err)
+	}
+	routes, err := s.api.GtfsManager.GtfsDB.Queries.GetRoutesByIDs(ctx, routeIDs)
+	if err != nil {
+		return nil, s.grpcInternalError(ctx, "ListRoutesForAgency", err)
+	}
+	response := &maglevpb.ListRoutesForAgencyResponse{Routes: make([]*maglevpb.Route, len(routes))}
+	for i, route := range routes {
+		response.Routes[i] = newPBRoute(route)
+	}
+	return response, nil
+}
+
+func (s *grpcService) GetRoute(ctx context.Context, req *maglevpb.GetRouteRequest) (*maglevpb.Route, error) {
+	_, routeID, err := s.grpcID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	s.api.GtfsManager.RLock()
+	defer s.api.GtfsManager.RUnlock()
+
+	route, err := s.api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, routeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "route %q not found", req.GetId())
+	}
+	if err != nil {
+		return nil, s.grpcInternalError(ctx, "GetRoute", err)
+	}
+	return newPBRoute(route), nil
+}
+
+func (s *grpcService) GetStop(ctx context.Context, req *maglevpb.GetStopRequest) (*maglevpb.Stop, error) {
+	agencyID, stopID, err := s.grpcID("id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	s.api.GtfsManager.RLock()
+	defer s.api.GtfsManager.RUnlock()
+
+	stops, err := s.api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, []string{stopID})
+	if err != nil {
+		return nil, s.grpcInternalError(ctx, "GetStop", err)
+	}
+	if len(stops) == 0 {
+		return nil, status.Errorf(codes.NotFound, "stop %q not found", req.GetId())
+	}
+	routes, err := s.api.GtfsManager.GtfsDB.Queries.GetRoutesForStop(ctx, stopID)
+	if err != nil {
+		return nil, s.grpcInternalError(ctx, "GetStop", err)
+	}
+	return newPBStop(agencyID, stops[0], routes), nil
+}
+
+func (s *grpcService) ListStopsForLocation(ctx context.Context, req *maglevpb.ListStopsForLocationRequest) (*maglevpb.ListStopsForLocationResponse, error) {
+	for field, errs := range utils.ValidateLocationParams(req.GetLat(), req.GetLon(), req.GetRadius(), 0, 0) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: %s", field, errs[0])
+	}
+	maxCount := int(req.GetMaxCount())
+	if maxCount == 0 {
+		maxCount = models.DefaultMaxCountForStops
+	}
+	if maxCount < 0 || maxCount > models.MaxAllowedCount {
+		return nil, status.Errorf(codes.InvalidArgument, "max_count: must be between 1 and %d", models.MaxAllowedCount)
+	}
+
+	s.api.GtfsManager.RLock()
+	defer s.api.GtfsManager.RUnlock()
+
+	stops := s.api.GtfsManager.GetStopsForLocation(ctx, req.GetLat(), req.GetLon(), req.GetRadius(), 0, 0, "", maxCount, false, nil, s.api.Clock.Now())
+	response := &maglevpb.ListStopsForLocationResponse{Stops: make([]*maglevpb.Stop, 0, len(stops))}
+	for _, stop := range stops {
+		routes, err := s.api.GtfsManager.GtfsDB.Queries.GetRoutesForStop(ctx, stop.ID)
+		if err != nil {
+			return nil, s.grpcInternalError(ctx, "ListStopsForLocation", err)
+		}
+		// Stops no route serves have no agency, and are left out as in stops-for-location
+		if len(routes) == 0 {
+			continue
+		}
+		response.Stops = append(response.Stops, newPBStop(routes[0].AgencyID, stop, routes))
+	}
+	return response, nil
+}
+
+func (s *grpcService) ListArrivalsForStop(ctx context.Context, req *maglevpb.ListArrivalsForStopRequest) (*maglevpb.ListArrivalsForStopResponse, error) {
+	agencyID, stopCode, err := s.grpcID("stop_id", req.GetStopId())
+	if err != nil {
+		return nil, err
+	}
+	minutesAfter := int(req.GetMinutesAfter())
+	switch {
+	case minutesAfter < 0:
+		return nil, status.Error(codes.InvalidArgument, "minutes_after: must be a non-negative integer")
+	case minutesAfter == 0:
+		minutesAfter = grpcDefaultMinutesAfter
+	case minutesAfter > grpcMaxMinutesAfter:
+		minutesAfter = grpcMaxMinutesAfter
+	}
+
+	s.api.GtfsManager.RLock()
+	defer s.api.GtfsManager.RUnlock()
+
+	if _, err := s.api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopCode); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "stop %q not found", req.GetStopId())
+		}
+		return nil, s.grpcInternalError(ctx, "ListArrivalsForStop", err)
+	}
+	agency := s.api.GtfsManager.FindAgency(agencyID)
+	if agency == nil {
+		return nil, status.Errorf(codes.NotFound, "agency %q not found", agencyID)
+	}
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agencyID)
+	now := s.api.Clock.Now().In(loc)
+
+	departures, err := s.api.upcomingDepartures(ctx, stopCode, now, time.Duration(minutesAfter)*time.Minute)
+	if err != nil {
+		return nil, s.grpcInternalError(ctx, "ListArrivalsForStop", err)
+	}
+	response := &maglevpb.ListArrivalsForStopResponse{Arrivals: make([]*maglevpb.Arrival, len(departures))}
+	for i, d := range departures {
+		response.Arrivals[i] = &maglevpb.Arrival{
+			RouteId:                utils.FormCombinedID(d.Route.AgencyID, d.Route.ID),
+			RouteShortName:         utils.NullStringOrEmpty(d.Route.ShortName),
+			TripId:                 utils.FormCombinedID(d.Route.AgencyID, d.Trip.ID),
+			TripHeadsign:           utils.NullStringOrEmpty(d.Trip.TripHeadsign),
+			ServiceDate:            d.ServiceDate.UnixMilli(),
+			StopSequence:           int32(d.StopTime.StopSequence),
+			ScheduledArrivalTime:   d.AimedArrival.UnixMilli(),
+			ScheduledDepartureTime: d.AimedDeparture.UnixMilli(),
+			Predicted:              d.Prediction.Predicted,
+			PredictedArrivalTime:   d.Prediction.ArrivalTime,
+			PredictedDepartureTime: d.Prediction.DepartureTime,
+			VehicleId:              d.Prediction.VehicleID,
+		}
+	}
+	return response, nil
+}
+
+// vehiclesForAgency lists the vehicles of agencyID with the time of the realtime refresh they
+// come from, or a NotFound error for an unknown agency.
+func (s *grpcService) vehiclesForAgency(agencyID string) (*maglevpb.ListVehiclesForAgencyResponse, error) {
+	s.api.GtfsManager.RLock()
+	defer s.api.GtfsManager.RUnlock()
+
+	if s.api.GtfsManager.FindAgency(agencyID) == nil {
+		return nil, status.Errorf(codes.NotFound, "agency %q not found", agencyID)
+	}
+	vehicles := s.api.GtfsManager.VehiclesForAgencyID(agencyID)
+	response := &maglevpb.ListVehiclesForAgencyResponse{
+		Vehicles: make([]*maglevpb.Vehicle, len(vehicles)),
+		Updated:  s.api.GtfsManager.LastRealtimeUpdate().UnixMilli(),
+	}
+	for i, vehicle := range vehicles {
+		response.Vehicles[i] = newPBVehicle(agencyID, vehicle)
+	}
+	return response, nil
+}
+
+func (s *grpcService) ListVehiclesForAgency(_ context.Context, req *maglevpb.ListVehiclesForAgencyRequest) (*maglevpb.ListVehiclesForAgencyResponse, error) {
+	return s.vehiclesForAgency(req.GetAgencyId())
+}
+
+// StreamVehiclePositions polls for realtime refreshes as the admin vehicle stream does, sending
+// the agency's vehicles after each one. It ends when the client goes away or the server starts
+// draining, so it does not hold up shutdown.
+func (s *grpcService) StreamVehiclePositions(req *maglevpb.StreamVehiclePositionsRequest, stream grpc.ServerStreamingServer[maglevpb.ListVehiclesForAgencyResponse]) error {
+	ticker := time.NewTicker(vehicleStreamPollInterval)
+	defer ticker.Stop()
+
+	var sent bool
+	var sentUpdate time.Time
+	for {
+		if updated := s.api.GtfsManager.LastRealtimeUpdate(); !sent || !updated.Equal(sentUpdate) {
+			response, err := s.vehiclesForAgency(req.GetAgencyId())
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(response); err != nil {
+				return err
+			}
+			sent, sentUpdate = true, updated
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+			if s.api.Draining() {
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"maglev.onebusaway.org/pkg/maglevpb"
+)
+
+// newTestGRPCClient serves api's gRPC handler over h2c and returns a client connected to it.
+func newTestGRPCClient(t *testing.T, api *RestAPI) maglevpb.TransitServiceClient {
+	srv := httptest.NewUnstartedServer(api.GRPCHandler())
+	srv.Config.Protocols = &http.Protocols{}
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	conn, err := grpc.NewClient("passthrough:///"+srv.Listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return maglevpb.NewTransitServiceClient(conn)
+}
+
+func grpcContextWithKey(t *testing.T, key string) context.Context {
+	return metadata.AppendToOutgoingContext(t.Context(), grpcAPIKeyHeader, key)
+}
+
+func TestGRPCService(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	client := newTestGRPCClient(t, api)
+	ctx := grpcContextWithKey(t, siriTestKey)
+
+	t.Run("GetStop", func(t *testing.T) {
+		stop, err := client.GetStop(ctx, &maglevpb.GetStopRequest{Id: "25_327"})
+		require.NoError(t, err)
+		assert.Equal(t, "25_327", stop.GetId())
+		assert.NotEmpty(t, stop.GetName())
+		assert.NotZero(t, stop.GetLat())
+		require.NotEmpty(t, stop.GetRouteIds())
+		for _, routeID := range stop.GetRouteIds() {
+			assert.True(t, strings.HasPrefix(routeID, "25_"), routeID)
+		}
+	})
+
+	t.Run("ListRoutesForAgency", func(t *testing.T) {
+		response, err := client.ListRoutesForAgency(ctx, &maglevpb.ListRoutesForAgencyRequest{AgencyId: "25"})
+		require.NoError(t, err)
+		require.NotEmpty(t, response.GetRoutes())
+		for _, route := range response.GetRoutes() {
+			assert.Equal(t, "25", route.GetAgencyId())
+			assert.True(t, strings.HasPrefix(route.GetId(), "25_"), route.GetId())
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.GetStop(ctx, &maglevpb.GetStopRequest{Id: "25_nope"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		_, err := client.GetRoute(ctx, &maglevpb.GetRouteRequest{Id: "nope"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("StreamVehiclePositions", func(t *testing.T) {
+		stream, err := client.StreamVehiclePositions(ctx, &maglevpb.StreamVehiclePositionsRequest{AgencyId: "25"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.NoError(t, err)
+	})
+}
+
+func TestGRPCService_APIKeys(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	client := newTestGRPCClient(t, api)
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := client.ListAgencies(t.Context(), &maglevpb.ListAgenciesRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		ctx := grpcContextWithKey(t, "test-rate-limit")
+		var err error
+		for range api.Config.RateLimit + 1 {
+			if _, err = client.ListAgencies(ctx, &maglevpb.ListAgenciesRequest{}); err != nil {
+				break
+			}
+		}
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+}
@@ -0,0 +1,98 @@
+package restapi
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/archive"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// headwaysForAgencyHandler reports how evenly spaced an agency's frequent service was, broken
+// down by day, hour of day and route.
+func (api *RestAPI) headwaysForAgencyHandler(w http.ResponseWriter, r *http.Request) {
+	if scope, ok := api.agencyArchiveScope(w, r); ok {
+		api.sendHeadwayAdherence(w, r, scope)
+	}
+}
+
+// headwaysForRouteHandler reports how evenly spaced a route's frequent service was, broken down
+// by day, hour of day and stop.
+func (api *RestAPI) headwaysForRouteHandler(w http.ResponseWriter, r *http.Request) {
+	if scope, ok := api.routeArchiveScope(w, r); ok {
+		api.sendHeadwayAdherence(w, r, scope)
+	}
+}
+
+// headwaysForStopHandler reports how evenly spaced the frequent service at a stop was, on the
+// routes of the agency in its ID, broken down by day, hour of day and route.
+func (api *RestAPI) headwaysForStopHandler(w http.ResponseWriter, r *http.Request) {
+	if scope, ok := api.stopArchiveScope(w, r); ok {
+		api.sendHeadwayAdherence(w, r, scope)
+	}
+}
+
+// sendHeadwayAdherence answers with the headway adherence of the arrivals in scope over the
+// requested dates, broken down by day, hour of day and the scope's breakdown.
+func (api *RestAPI) sendHeadwayAdherence(w http.ResponseWriter, r *http.Request, scope archiveScope) {
+	start, end, ok := api.dateRange(w, r, &scope)
+	if !ok {
+		return
+	}
+
+	cfg := api.Config.ArrivalArchive
+	frequent := time.Duration(cfg.FrequentHeadway) * time.Second
+	groups := make(map[string][]models.HeadwayBucket)
+	for _, group := range []string{archive.GroupNone, archive.GroupDay, archive.GroupHour, scope.breakdown} {
+		buckets, err := api.ArrivalArchive.Headways(r.Context(), scope.filter, group, frequent)
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		groups[group] = make([]models.HeadwayBucket, 0, len(buckets))
+		for _, bucket := range buckets {
+			groups[group] = append(groups[group], models.HeadwayBucket{ID: scope.bucketID(group, bucket.Key), HeadwayStats: headwayStats(bucket)})
+		}
+	}
+
+	entry := models.HeadwayAdherence{
+		ID:                     scope.id,
+		StartDate:              start.Format("2006-01-02"),
+		EndDate:                end.Format("2006-01-02"),
+		FrequentHeadwaySeconds: cfg.FrequentHeadway,
+		BunchedRatio:           archive.BunchedRatio,
+		GappedRatio:            archive.GappedRatio,
+		Summary:                groups[archive.GroupNone][0].HeadwayStats,
+		ByDay:                  groups[archive.GroupDay],
+		ByHour:                 groups[archive.GroupHour],
+		ByRoute:                []models.HeadwayBucket{},
+		ByStop:                 []models.HeadwayBucket{},
+	}
+	if scope.breakdown == archive.GroupRoute {
+		entry.ByRoute = groups[archive.GroupRoute]
+	} else {
+		entry.ByStop = groups[archive.GroupStop]
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock))
+}
+
+func headwayStats(bucket archive.HeadwayBucket) models.HeadwayStats {
+	round := func(d time.Duration) float64 { return math.Round(d.Seconds()*10) / 10 }
+	stats := models.HeadwayStats{
+		Headways:                       bucket.Headways,
+		AverageScheduledHeadwaySeconds: round(bucket.ScheduledHeadway),
+		AverageObservedHeadwaySeconds:  round(bucket.ObservedHeadway),
+		HeadwayDeviation:               math.Round(bucket.Deviation*1000) / 1000,
+		Bunched:                        bucket.Bunched,
+		Gapped:                         bucket.Gapped,
+		ScheduledWaitSeconds:           round(bucket.ScheduledWait),
+		ExcessWaitSeconds:              round(bucket.ExcessWait),
+	}
+	if bucket.Headways > 0 {
+		stats.BunchedPercent = math.Round(float64(bucket.Bunched)/float64(bucket.Headways)*1000) / 10
+		stats.GappedPercent = math.Round(float64(bucket.Gapped)/float64(bucket.Headways)*1000) / 10
+	}
+	return stats
+}
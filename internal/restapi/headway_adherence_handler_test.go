@@ -0,0 +1,117 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/archive"
+	"maglev.onebusaway.org/internal/clock"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestHeadwayHandlers(t *testing.T) {
+	// 03:00 in Redding, after the whole of June 12's service
+	mockClock := clock.NewMockClock(time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+
+	serve := func(t *testing.T, target string) (int, models.HeadwayAdherence) {
+		rec := serveSiri(t, api, target)
+		var body struct {
+			Data struct {
+				Entry models.HeadwayAdherence `json:"entry"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		return rec.Code, body.Data.Entry
+	}
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	t.Run("not enabled", func(t *testing.T) {
+		code, _ := serve(t, "/api/where/headways-for-agency/"+agencyID+".json?key="+siriTestKey)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	// RABA has no frequent service, so every headway of the day counts
+	cfg := appconf.ArrivalArchiveConfig{DataPath: ":memory:", RetentionDays: 365, EarlyThreshold: 60, LateThreshold: 300, FrequentHeadway: 24 * 60 * 60}
+	arrivals, err := archive.New(cfg, archive.ManagerSchedule(api.GtfsManager), mockClock, nil)
+	require.NoError(t, err)
+	defer arrivals.Shutdown()
+	api.ArrivalArchive = arrivals
+	api.Config.ArrivalArchive = cfg
+
+	// The first two trips of a route to start from the same stop, both reported there on time
+	var first, second gtfs.ScheduledTrip
+	trips := api.GtfsManager.GetStaticData().Trips
+	for i := 0; i < len(trips) && second.ID == ""; i++ {
+		for j := i + 1; j < len(trips); j++ {
+			if trips[i].Route.Id == trips[j].Route.Id && len(trips[i].StopTimes) > 0 && len(trips[j].StopTimes) > 0 &&
+				trips[i].StopTimes[0].Stop.Id == trips[j].StopTimes[0].Stop.Id &&
+				trips[i].StopTimes[0].ArrivalTime != trips[j].StopTimes[0].ArrivalTime {
+				first, second = trips[i], trips[j]
+				break
+			}
+		}
+	}
+	require.NotEmpty(t, second.ID)
+	stopID := first.StopTimes[0].Stop.Id
+	onTime := time.Duration(0)
+	var updates []gtfs.Trip
+	for _, trip := range []gtfs.ScheduledTrip{first, second} {
+		updates = append(updates, gtfs.Trip{
+			ID:              gtfs.TripID{ID: trip.ID, HasStartDate: true, StartDate: time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)},
+			StopTimeUpdates: []gtfs.StopTimeUpdate{{StopID: &stopID, Arrival: &gtfs.StopTimeEvent{Delay: &onTime}}},
+		})
+	}
+	require.NoError(t, arrivals.Process(context.Background(), GTFS.RealtimeUpdate{Trips: updates}))
+
+	routeID := utils.FormCombinedID(agencyID, first.Route.Id)
+	headway := first.StopTimes[0].ArrivalTime - second.StopTimes[0].ArrivalTime
+	if headway < 0 {
+		headway = -headway
+	}
+
+	t.Run("agency", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/headways-for-agency/"+agencyID+".json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 24*60*60, entry.FrequentHeadwaySeconds)
+		assert.Equal(t, archive.BunchedRatio, entry.BunchedRatio)
+		assert.Equal(t, models.HeadwayStats{
+			Headways:                       1,
+			AverageScheduledHeadwaySeconds: headway.Seconds(),
+			AverageObservedHeadwaySeconds:  headway.Seconds(),
+			ScheduledWaitSeconds:           headway.Seconds() / 2,
+		}, entry.Summary)
+		require.Len(t, entry.ByRoute, 1)
+		assert.Equal(t, routeID, entry.ByRoute[0].ID)
+		require.Len(t, entry.ByDay, 1)
+		assert.Equal(t, "2025-06-12", entry.ByDay[0].ID)
+	})
+
+	t.Run("route", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/headways-for-route/"+routeID+".json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, entry.ByStop, 1)
+		assert.Equal(t, utils.FormCombinedID(agencyID, stopID), entry.ByStop[0].ID)
+		assert.Empty(t, entry.ByRoute)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/headways-for-stop/"+utils.FormCombinedID(agencyID, stopID)+".json?key="+siriTestKey+"&endDate=2025-06-11")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(0), entry.Summary.Headways)
+		assert.Empty(t, entry.ByRoute)
+
+		code, _ = serve(t, "/api/where/headways-for-stop/"+agencyID+"_unknown.json?key="+siriTestKey)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+}
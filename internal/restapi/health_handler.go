@@ -3,21 +3,45 @@ package restapi
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/logging"
 )
 
-// JSON response from the health endpoint.
+// JSON response from the health and readiness endpoints.
 type HealthResponse struct {
-	Status string `json:"status"`
-	Detail string `json:"detail,omitempty"`
+	Status string            `json:"status"`
+	Detail string            `json:"detail,omitempty"`
+	Checks map[string]string `json:"checks,omitempty"`
+	Feeds  []FeedFreshness   `json:"feeds,omitempty"`
 }
 
-// verifies database connectivity.
+// FeedFreshness is when one GTFS-RT feed last loaded, in the readiness response.
+type FeedFreshness struct {
+	AgencyID    string `json:"agencyId,omitempty"`
+	Feed        string `json:"feed"`                  // tripUpdates, vehiclePositions or serviceAlerts
+	LastFetched string `json:"lastFetched,omitempty"` // RFC 3339; left out until the feed has loaded
+	Status      string `json:"status"`                // ok or stale
+}
+
+const defaultRealtimeStalenessBudget = 5 * time.Minute
+
+// healthHandler is the liveness probe: it only reports that the process is serving requests.
+// Dependency checks belong in readyHandler so a slow feed doesn't get the process restarted.
 func (api *RestAPI) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(HealthResponse{
+		Status: "ok",
+	})
+}
+
+// readyHandler is the readiness probe: it verifies that GTFS data is loaded, the database is
+// reachable, realtime data is within the staleness budget and no startup check failed.
+func (api *RestAPI) readyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// Check database connectivity
 	if api.Application == nil || api.GtfsManager == nil || api.GtfsManager.GtfsDB == nil || api.GtfsManager.GtfsDB.DB == nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		_ = json.NewEncoder(w).Encode(HealthResponse{
@@ -27,18 +51,102 @@ func (api *RestAPI) healthHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := api.GtfsManager.GtfsDB.DB.PingContext(r.Context()); err != nil {
-		logging.LogError(api.Logger, "GTFS DB ping failed", err)
+	// Fail readiness while shutting down so load balancers move traffic elsewhere
+	if api.Draining() {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		_ = json.NewEncoder(w).Encode(HealthResponse{
-			Status: "unavailable",
-			Detail: "database connection failed",
+			Status: "draining",
+			Detail: "server is shutting down",
 		})
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	checks := make(map[string]string)
+	ready := true
+
+	if api.GtfsManager.IsHealthy() {
+		checks["gtfs"] = "ok"
+	} else {
+		checks["gtfs"] = "not loaded"
+		ready = false
+	}
+
+	if err := api.GtfsManager.GtfsDB.DB.PingContext(r.Context()); err != nil {
+		logging.LogError(api.Logger, "GTFS DB ping failed", err)
+		checks["database"] = "connection failed"
+		ready = false
+	} else {
+		checks["database"] = "ok"
+	}
+
+	// Startup self-check results are reported by status only; details stay in the log
+	for _, check := range api.StartupChecks {
+		checks["startup."+check.Name] = check.Status
+	}
+	if !api.StartupChecksPassed() {
+		ready = false
+	}
+
+	realtimeStatus := realtimeReadiness(api.GtfsManager.RealtimeEnabled(), api.GtfsManager.LastRealtimeUpdate(), time.Now(), api.realtimeStalenessBudget())
+	checks["realtime"] = realtimeStatus
+	if realtimeStatus == "stale" {
+		ready = false
+	}
+	// Feeds are listed for diagnosis only. Readiness follows the combined refresh above, so a
+	// failing service alerts feed alone doesn't take the server out of rotation.
+	var feeds []FeedFreshness
+	if realtimeStatus != "disabled" {
+		feeds = realtimeFeedFreshness(api.GtfsManager.RealtimeFeedStatuses(), time.Now(), api.realtimeStalenessBudget())
+	}
+
+	status := "ready"
+	code := http.StatusOK
+	if !ready {
+		status = "unavailable"
+		code = http.StatusServiceUnavailable
+	}
+
+	w.WriteHeader(code)
 	_ = json.NewEncoder(w).Encode(HealthResponse{
-		Status: "ok",
+		Status: status,
+		Checks: checks,
+		Feeds:  feeds,
 	})
 }
+
+// realtimeStalenessBudget is how long realtime data may go without a successful refresh.
+func (api *RestAPI) realtimeStalenessBudget() time.Duration {
+	if api.Config.RealtimeStalenessBudget > 0 {
+		return time.Duration(api.Config.RealtimeStalenessBudget) * time.Second
+	}
+	return defaultRealtimeStalenessBudget
+}
+
+// realtimeReadiness classifies realtime data freshness as "disabled", "ok" or "stale".
+// A feed that has never been fetched successfully counts as stale.
+func realtimeReadiness(enabled bool, lastUpdate, now time.Time, budget time.Duration) string {
+	if !enabled {
+		return "disabled"
+	}
+	if lastUpdate.IsZero() || now.Sub(lastUpdate) > budget {
+		return "stale"
+	}
+	return "ok"
+}
+
+// realtimeFeedFreshness classifies each GTFS-RT feed by when it last loaded.
+func realtimeFeedFreshness(statuses []gtfs.RealtimeFeedStatus, now time.Time, budget time.Duration) []FeedFreshness {
+	feeds := make([]FeedFreshness, 0, len(statuses))
+	for _, status := range statuses {
+		feed := FeedFreshness{
+			AgencyID: status.AgencyID,
+			Feed:     status.Feed,
+			Status:   realtimeReadiness(true, status.LastFetched, now, budget),
+		}
+		if !status.LastFetched.IsZero() {
+			feed.LastFetched = status.LastFetched.UTC().Format(time.RFC3339)
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds
+}
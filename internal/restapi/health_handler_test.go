@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,7 +17,7 @@ import (
 )
 
 func TestHealthHandlerWithNilApplication(t *testing.T) {
-	// Create a minimal RestAPI with nil Application to simulate DB unavailable
+	// Liveness doesn't depend on the database
 	api := &RestAPI{
 		Application: nil,
 	}
@@ -26,6 +27,20 @@ func TestHealthHandlerWithNilApplication(t *testing.T) {
 
 	api.healthHandler(w, req)
 
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestReadyHandlerWithNilApplication(t *testing.T) {
+	// Create a minimal RestAPI with nil Application to simulate DB unavailable
+	api := &RestAPI{
+		Application: nil,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+
+	api.readyHandler(w, req)
+
 	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
 
 	var resp HealthResponse
@@ -70,3 +85,113 @@ func TestHealthHandlerReturnsOK(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "ok", healthResp.Status)
 }
+
+func TestReadyHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "ready", resp.Status)
+	assert.Equal(t, map[string]string{"gtfs": "ok", "database": "ok", "realtime": "disabled"}, resp.Checks)
+}
+
+func TestReadyHandlerDraining(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.StartDraining()
+
+	w := httptest.NewRecorder()
+	api.readyHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var resp HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "draining", resp.Status)
+
+	// Liveness is unaffected
+	w = httptest.NewRecorder()
+	api.healthHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestReadyHandlerGtfsNotLoaded(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	api := NewRestAPI(&app.Application{
+		GtfsManager: &gtfs.Manager{GtfsDB: &gtfsdb.Client{DB: db}},
+	})
+
+	w := httptest.NewRecorder()
+	api.readyHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var resp HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.Equal(t, "not loaded", resp.Checks["gtfs"])
+	assert.Equal(t, "ok", resp.Checks["database"])
+}
+
+func TestReadyHandlerStartupChecks(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	api.StartupChecks = []app.StartupCheck{
+		{Name: "database", Status: app.StartupCheckOK},
+		{Name: "trip-updates-feed", Status: app.StartupCheckWarn, Detail: "responded with status 503"},
+	}
+	w := httptest.NewRecorder()
+	api.readyHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "warnings don't affect readiness")
+
+	var resp HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "ok", resp.Checks["startup.database"])
+	assert.Equal(t, "warn", resp.Checks["startup.trip-updates-feed"], "details stay in the log")
+
+	api.StartupChecks = append(api.StartupChecks, app.StartupCheck{Name: "timezones", Status: app.StartupCheckFailed, Detail: "cannot load America/Nowhere"})
+	w = httptest.NewRecorder()
+	api.readyHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.Equal(t, "failed", resp.Checks["startup.timezones"])
+}
+
+func TestRealtimeReadiness(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	budget := 5 * time.Minute
+
+	assert.Equal(t, "disabled", realtimeReadiness(false, time.Time{}, now, budget))
+	assert.Equal(t, "stale", realtimeReadiness(true, time.Time{}, now, budget), "never fetched")
+	assert.Equal(t, "ok", realtimeReadiness(true, now.Add(-time.Minute), now, budget))
+	assert.Equal(t, "stale", realtimeReadiness(true, now.Add(-10*time.Minute), now, budget))
+}
+
+func TestRealtimeFeedFreshness(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []gtfs.RealtimeFeedStatus{
+		{AgencyID: "25", Feed: "tripUpdates", LastFetched: now.Add(-time.Minute)},
+		{AgencyID: "25", Feed: "serviceAlerts", LastFetched: now.Add(-time.Hour)},
+		{Feed: "vehiclePositions"},
+	}
+
+	assert.Equal(t, []FeedFreshness{
+		{AgencyID: "25", Feed: "tripUpdates", LastFetched: "2025-01-01T11:59:00Z", Status: "ok"},
+		{AgencyID: "25", Feed: "serviceAlerts", LastFetched: "2025-01-01T11:00:00Z", Status: "stale"},
+		{Feed: "vehiclePositions", Status: "stale"},
+	}, realtimeFeedFreshness(statuses, now, 5*time.Minute))
+}
@@ -0,0 +1,290 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// javaParityDroppedFields are fields only maglev sends, left out of responses in Java parity mode.
+var javaParityDroppedFields = map[string]bool{
+	"requestId":         true,
+	"bikeshareStations": true,
+}
+
+var (
+	referencesType = reflect.TypeOf(models.ReferencesModel{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// encodeResponse writes the response as JSON, or XML for a .xml request, as the Java
+// OneBusAway server writes it when java-parity is set.
+func (api *RestAPI) encodeResponse(w io.Writer, r *http.Request, response models.ResponseModel) error {
+	if wantsXML(r) {
+		return api.encodeXMLResponse(w, response)
+	}
+	if !api.Config.JavaParity {
+		return json.NewEncoder(w).Encode(response)
+	}
+	return encodeJavaParity(w, response)
+}
+
+// encodeJavaParity writes the response the way the Java server's serializer does: every field
+// of a struct is present, zero or null, in declaration order; absent lists are empty; numbers
+// with a fraction are written as Java writes doubles, so whole coordinates keep their ".0";
+// references are ordered by ID; and errors carry only their code, time, text and version.
+func encodeJavaParity(w io.Writer, response models.ResponseModel) error {
+	var buf bytes.Buffer
+	var err error
+	if response.Code != 200 {
+		err = writeJavaValue(&buf, reflect.ValueOf(struct {
+			Code        int    `json:"code"`
+			CurrentTime int64  `json:"currentTime"`
+			Text        string `json:"text"`
+			Version     int    `json:"version"`
+		}{response.Code, response.CurrentTime, response.Text, response.Version}))
+	} else {
+		err = writeJavaValue(&buf, reflect.ValueOf(response))
+	}
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func writeJavaValue(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	if v.Type().Implements(marshalerType) && (v.Kind() != reflect.Pointer || !v.IsNil()) {
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJavaValue(buf, v.Elem())
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32:
+		buf.WriteString(javaDouble(v.Float(), 32))
+	case reflect.Float64:
+		buf.WriteString(javaDouble(v.Float(), 64))
+	case reflect.String:
+		writeJavaString(buf, v.String())
+	case reflect.Slice, reflect.Array:
+		return writeJavaList(buf, v, nil)
+	case reflect.Map:
+		return writeJavaMap(buf, v)
+	case reflect.Struct:
+		return writeJavaStruct(buf, v)
+	default:
+		return fmt.Errorf("java parity: cannot encode %s", v.Type())
+	}
+	return nil
+}
+
+func writeJavaList(buf *bytes.Buffer, v reflect.Value, order []int) error {
+	buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		j := i
+		if order != nil {
+			j = order[i]
+		}
+		if err := writeJavaValue(buf, v.Index(j)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func writeJavaMap(buf *bytes.Buffer, v reflect.Value) error {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		key := fmt.Sprint(iter.Key().Interface())
+		if javaParityDroppedFields[key] {
+			continue
+		}
+		keys = append(keys, key)
+		values[key] = iter.Value()
+	}
+	sort.Strings(keys)
+
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJavaString(buf, key)
+		buf.WriteByte(':')
+		if err := writeJavaValue(buf, values[key]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeJavaStruct(buf *bytes.Buffer, v reflect.Value) error {
+	buf.WriteByte('{')
+	first := true
+	err := writeJavaFields(buf, v, &first, v.Type() == referencesType)
+	buf.WriteByte('}')
+	return err
+}
+
+// writeJavaFields writes the fields of a struct, and of the structs embedded in it, ignoring
+// omitempty. Lists in references are ordered by ID.
+func writeJavaFields(buf *bytes.Buffer, v reflect.Value, first *bool, sortByID bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := writeJavaFields(buf, fv, first, false); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if javaParityDroppedFields[name] {
+			continue
+		}
+
+		if !*first {
+			buf.WriteByte(',')
+		}
+		*first = false
+		writeJavaString(buf, name)
+		buf.WriteByte(':')
+
+		var err error
+		switch {
+		case fv.Kind() == reflect.Slice && fv.IsNil():
+			buf.WriteString("[]")
+		case fv.Kind() == reflect.Map && fv.IsNil():
+			buf.WriteString("{}")
+		case fv.Kind() == reflect.Slice && sortByID:
+			err = writeJavaList(buf, fv, orderByID(fv))
+		default:
+			err = writeJavaValue(buf, fv)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderByID returns the indices of the list's elements sorted by their "id" field.
+func orderByID(list reflect.Value) []int {
+	order := make([]int, list.Len())
+	ids := make([]string, list.Len())
+	for i := range order {
+		order[i] = i
+		ids[i] = elementID(list.Index(i))
+	}
+	sort.SliceStable(order, func(a, b int) bool { return ids[order[a]] < ids[order[b]] })
+	return order
+}
+
+func elementID(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if id := v.MapIndex(reflect.ValueOf("id")); id.IsValid() {
+			return fmt.Sprint(id.Interface())
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name == "id" {
+				return fmt.Sprint(v.Field(i).Interface())
+			}
+		}
+	}
+	return ""
+}
+
+// writeJavaString writes s as a JSON string, leaving <, > and & unescaped as the Java server does.
+func writeJavaString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1) // The encoder's newline
+}
+
+// javaDouble formats f as Java's Double.toString (or Float.toString, for 32 bits) does:
+// always with a fraction, and in computerized scientific notation below 10^-3 and from 10^7.
+// Java has no JSON form for NaN or infinities, so those are null.
+func javaDouble(f float64, bits int) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "null"
+	}
+	abs := math.Abs(f)
+	if abs == 0 || (abs >= 1e-3 && abs < 1e7) {
+		s := strconv.FormatFloat(f, 'f', -1, bits)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	}
+	s := strconv.FormatFloat(f, 'e', -1, bits)
+	mantissa, exponent, _ := strings.Cut(s, "e")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	exp, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(exp)
+}
@@ -0,0 +1,160 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// javaParityTestKey is exempt from rate limiting.
+const javaParityTestKey = "org.onebusaway.iphone"
+
+// javaParityCurrentTime matches the currentTime of a response, which differs between a golden
+// captured from the Java server and the mock clock.
+var javaParityCurrentTime = regexp.MustCompile(`"currentTime":\d+`)
+
+// serveJavaParity performs a request against a fresh mux.
+func serveJavaParity(t *testing.T, api *RestAPI, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+// TestJavaParity compares java-parity responses with goldens captured from the Java server
+// against the same fixture, as the README's Java parity section describes; sources.json
+// records where each one came from. Goldens not captured yet are skipped.
+func TestJavaParity(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)))
+	defer api.Shutdown()
+	api.Config.JavaParity = true
+
+	data, err := os.ReadFile(filepath.Join("testdata", "java_parity", "sources.json"))
+	require.NoError(t, err)
+	var sources map[string]struct {
+		Server   string `json:"server"`
+		Captured string `json:"captured"`
+	}
+	require.NoError(t, json.Unmarshal(data, &sources))
+
+	testCases := []struct {
+		golden string
+		target string
+		code   int
+	}{
+		{"agency.json", "/api/where/agency/25.json", 200},
+		{"stop.json", "/api/where/stop/25_327.json", 200},
+		{"route.json", "/api/where/route/25_151.json", 200},
+		{"stop_not_found.json", "/api/where/stop/25_nonexistent.json", 404},
+		{"stops_for_unknown_agency.json", "/api/where/stops-for-agency/99.json", 200},
+		{"stops_for_location_invalid.json", "/api/where/stops-for-location.json?lat=100&lon=-122.39", 400},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.golden, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", "java_parity", tc.golden))
+			if errors.Is(err, fs.ErrNotExist) {
+				t.Skipf("%s has not been captured from the Java server yet", tc.golden)
+			}
+			require.NoError(t, err)
+			source := sources[tc.golden]
+			require.True(t, source.Server != "" && source.Captured != "", "sources.json must record the Java server and date %s was captured from", tc.golden)
+
+			separator := "?"
+			if strings.Contains(tc.target, "?") {
+				separator = "&"
+			}
+			rec := serveJavaParity(t, api, tc.target+separator+"key="+javaParityTestKey)
+			assert.Equal(t, tc.code, rec.Code)
+			normalize := func(body []byte) string {
+				return javaParityCurrentTime.ReplaceAllString(strings.TrimSpace(string(body)), `"currentTime":0`)
+			}
+			assert.Equal(t, normalize(want), normalize(rec.Body.Bytes()), "captured from %s on %s", source.Server, source.Captured)
+		})
+	}
+}
+
+func TestEncodeJavaParity(t *testing.T) {
+	type embedded struct {
+		Kind string `json:"kind,omitempty"`
+	}
+	type entry struct {
+		embedded
+		ID        string   `json:"id"`
+		Lat       float64  `json:"lat"`
+		Direction string   `json:"direction,omitempty"`
+		Stops     []string `json:"stopIds,omitempty"`
+		Next      *entry   `json:"next,omitempty"`
+		Hidden    string   `json:"-"`
+	}
+	references := models.NewEmptyReferences()
+	references.Routes = []interface{}{entry{ID: "25_b"}, map[string]interface{}{"id": "25_a"}}
+	references.Stops = nil
+
+	var buf bytes.Buffer
+	err := encodeJavaParity(&buf, models.ResponseModel{
+		Code:        200,
+		CurrentTime: 1,
+		Data:        models.NewEntryResponse(entry{ID: "25_1", Lat: 47, Hidden: "x"}, references, clock.NewMockClock(time.Unix(0, 0))).Data,
+		Text:        "OK <&>",
+		Version:     2,
+		RequestID:   "abc",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"code":200,"currentTime":1,"data":{`+
+		`"entry":{"kind":"","id":"25_1","lat":47.0,"direction":"","stopIds":[],"next":null},`+
+		`"references":{"agencies":[],"routes":[{"id":"25_a"},{"kind":"","id":"25_b","lat":0.0,"direction":"","stopIds":[],"next":null}],`+
+		`"situations":[],"stopTimes":[],"stops":[],"trips":[]}},"text":"OK <&>","version":2}`+"\n", buf.String())
+
+	buf.Reset()
+	err = encodeJavaParity(&buf, models.ResponseModel{
+		Code:        400,
+		CurrentTime: 1,
+		Data:        map[string]interface{}{"fieldErrors": map[string][]string{"lat": {"invalid"}}},
+		Text:        "invalid",
+		Version:     2,
+		RequestID:   "abc",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"code":400,"currentTime":1,"text":"invalid","version":2}`+"\n", buf.String())
+}
+
+func TestJavaDouble(t *testing.T) {
+	testCases := []struct {
+		value float64
+		bits  int
+		want  string
+	}{
+		{0, 64, "0.0"},
+		{47, 64, "47.0"},
+		{-122.39, 64, "-122.39"},
+		{47.6097, 64, "47.6097"},
+		{0.001, 64, "0.001"},
+		{0.0001, 64, "1.0E-4"},
+		{0.00012345, 64, "1.2345E-4"},
+		{1e7, 64, "1.0E7"},
+		{12345678.9, 64, "1.23456789E7"},
+		{float64(float32(0.1)), 32, "0.1"},
+		{float64(float32(271.5)), 32, "271.5"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, javaDouble(tc.value, tc.bits), "%v", tc.value)
+	}
+}
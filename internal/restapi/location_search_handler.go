@@ -0,0 +1,167 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"maglev.onebusaway.org/internal/geocode"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	// stopsPerPlace caps the stops listed near each place.
+	stopsPerPlace = 10
+	// maxLocationSearchRadius caps the radius parameter of location search, in meters.
+	maxLocationSearchRadius = 5000
+)
+
+// locationSearchHandler geocodes the input with the configured geocoder and lists the stops,
+// and the routes serving them, near each place it finds. Results are limited to the feed's
+// region; lat and lon, when given, prefer places near them.
+func (api *RestAPI) locationSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Geocoder == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "geocoder not configured")
+		return
+	}
+
+	queryParams := r.URL.Query()
+	input, err := utils.ValidateAndSanitizeQuery(queryParams.Get("input"))
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"input": {err.Error()}})
+		return
+	}
+	if strings.TrimSpace(input) == "" {
+		api.validationErrorResponse(w, r, map[string][]string{"input": {"input is required"}})
+		return
+	}
+
+	fieldErrors := map[string][]string{}
+	maxCount, _ := utils.ParseMaxCount(queryParams, 5, 20, fieldErrors)
+	radius := float64(api.Config.Geocoder.Radius)
+	if queryParams.Has("radius") {
+		radius, _ = utils.ParseFloatParam(queryParams, "radius", fieldErrors)
+		if radius <= 0 || radius > maxLocationSearchRadius {
+			fieldErrors["radius"] = append(fieldErrors["radius"], fmt.Sprintf("must be greater than zero and at most %d", maxLocationSearchRadius))
+		}
+	}
+	var focus *geocode.Place
+	if queryParams.Has("lat") || queryParams.Has("lon") {
+		lat, _ := utils.ParseFloatParam(queryParams, "lat", fieldErrors)
+		lon, _ := utils.ParseFloatParam(queryParams, "lon", fieldErrors)
+		for field, errs := range utils.ValidateLocationParams(lat, lon, 0, 0, 0) {
+			fieldErrors[field] = append(fieldErrors[field], errs...)
+		}
+		focus = &geocode.Place{Lat: lat, Lon: lon}
+	}
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	// The geocoder is called without the static data lock held
+	api.GtfsManager.RLock()
+	regionLat, regionLon, regionLatSpan, regionLonSpan := api.GtfsManager.GetRegionBounds()
+	api.GtfsManager.RUnlock()
+	request := geocode.Request{Text: input, Limit: maxCount, Focus: focus}
+	if regionLatSpan > 0 && regionLonSpan > 0 {
+		bounds := utils.CalculateBoundsFromSpan(regionLat, regionLon, regionLatSpan/2, regionLonSpan/2)
+		request.Bounds = &bounds
+	}
+
+	ctx := r.Context()
+	places, err := api.Geocoder.Search(ctx, request)
+	if err != nil {
+		logging.LogError(api.Logger, "geocoder search failed", err)
+		api.sendError(w, r, http.StatusBadGateway, "geocoder unavailable")
+		return
+	}
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	queryTime := api.Clock.Now()
+	results := make([]models.LocationSearchResult, 0, len(places))
+	stops := map[string]models.Stop{}
+	routeIDs := map[string]bool{}
+	agencyIDs := map[string]bool{}
+	for _, place := range places {
+		nearby := api.GtfsManager.GetStopsForLocation(ctx, place.Lat, place.Lon, radius, 0, 0, "", stopsPerPlace, false, nil, queryTime)
+		ids := make([]string, 0, len(nearby))
+		for _, stop := range nearby {
+			ids = append(ids, stop.ID)
+		}
+		routeRows, err := api.GtfsManager.GtfsDB.Queries.GetRouteIDsForStops(ctx, ids)
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		stopRouteIDs := make(map[string][]string)
+		for _, row := range routeRows {
+			if routeID, ok := row.RouteID.(string); ok {
+				stopRouteIDs[row.StopID] = append(stopRouteIDs[row.StopID], routeID)
+			}
+		}
+
+		result := models.LocationSearchResult{Name: place.Name, Lat: place.Lat, Lon: place.Lon, StopIDs: []string{}, RouteIDs: []string{}}
+		placeRoutes := map[string]bool{}
+		for _, stop := range nearby {
+			rids := stopRouteIDs[stop.ID]
+			if len(rids) == 0 {
+				continue
+			}
+			agencyID, _, err := api.GtfsManager.ExtractAgencyIDAndCodeID(rids[0])
+			if err != nil {
+				continue
+			}
+			stopID := utils.FormCombinedID(agencyID, stop.ID)
+			result.StopIDs = append(result.StopIDs, stopID)
+			if _, seen := stops[stopID]; !seen {
+				direction := models.UnknownValue
+				if stop.Direction.Valid && stop.Direction.String != "" {
+					direction = stop.Direction.String
+				}
+				stops[stopID] = models.NewStop(
+					utils.NullStringOrEmpty(stop.Code),
+					direction,
+					stopID,
+					utils.NullStringOrEmpty(stop.Name),
+					"",
+					utils.MapWheelchairBoarding(utils.NullWheelchairBoardingOrUnknown(stop.WheelchairBoarding)),
+					stop.Lat,
+					stop.Lon,
+					0,
+					rids,
+					rids,
+				)
+			}
+			for _, routeID := range rids {
+				if !placeRoutes[routeID] {
+					placeRoutes[routeID] = true
+					result.RouteIDs = append(result.RouteIDs, routeID)
+				}
+				routeIDs[routeID] = true
+				if agency, _, err := api.GtfsManager.ExtractAgencyIDAndCodeID(routeID); err == nil {
+					agencyIDs[agency] = true
+				}
+			}
+		}
+		results = append(results, result)
+	}
+
+	references := models.NewEmptyReferences()
+	references.Agencies = append(references.Agencies, utils.FilterAgencies(api.GtfsManager.GetAgencies(), agencyIDs)...)
+	references.Routes = append(references.Routes, utils.FilterRoutes(api.GtfsManager.GtfsDB.Queries, ctx, routeIDs)...)
+	for _, result := range results {
+		for _, stopID := range result.StopIDs {
+			if stop, ok := stops[stopID]; ok {
+				references.Stops = append(references.Stops, stop)
+				delete(stops, stopID)
+			}
+		}
+	}
+
+	api.sendResponse(w, r, models.NewListResponse(results, references, len(places) >= maxCount, api.Clock))
+}
@@ -0,0 +1,68 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/geocode"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestLocationSearchHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	rec := serveSiri(t, api, "/api/where/search/location.json?key="+siriTestKey+"&input=downtown")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "no geocoder configured")
+
+	stop := api.GtfsManager.GetStops()[0]
+	var geocoderQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		geocoderQuery = r.URL.RawQuery
+		if r.URL.Query().Get("text") == "nowhere" {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"features": [{"geometry": {"coordinates": [%f, %f]}, "properties": {"label": "Transit Center"}}]}`, *stop.Longitude, *stop.Latitude)
+	}))
+	defer server.Close()
+	geocoder, err := geocode.New(appconf.GeocoderConfig{Provider: appconf.GeocoderPelias, URL: server.URL}, nil)
+	require.NoError(t, err)
+	api.Geocoder = geocoder
+	api.Config.Geocoder.Radius = 400
+	defer func() { api.Geocoder = nil }()
+
+	rec = serveSiri(t, api, "/api/where/search/location.json?key="+siriTestKey+"&input=transit%20center&lat=40.58&lon=-122.39")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response struct {
+		Data struct {
+			List       []models.LocationSearchResult `json:"list"`
+			References models.ReferencesModel        `json:"references"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Data.List, 1)
+	result := response.Data.List[0]
+	assert.Equal(t, "Transit Center", result.Name)
+	require.NotEmpty(t, result.StopIDs)
+	assert.Equal(t, "25_"+stop.Id, result.StopIDs[0], "nearest stop first")
+	assert.NotEmpty(t, result.RouteIDs)
+	assert.Len(t, response.Data.References.Stops, len(result.StopIDs))
+	assert.NotEmpty(t, response.Data.References.Routes)
+	assert.Contains(t, geocoderQuery, "boundary.rect.min_lat=")
+	assert.Contains(t, geocoderQuery, "focus.point.lat=40.580000")
+
+	rec = serveSiri(t, api, "/api/where/search/location.json?key="+siriTestKey+"&input=nowhere")
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+
+	rec = serveSiri(t, api, "/api/where/search/location.json?key="+siriTestKey+"&input=transit&radius=99999")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = serveSiri(t, api, "/api/where/search/location.json?key="+siriTestKey)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -23,9 +23,9 @@ func MetricsHandler(m *metrics.Metrics) func(http.Handler) http.Handler {
 			start := time.Now()
 
 			// Wrap response writer to capture status code
-			wrapped := &metricsResponseWriter{
-				ResponseWriter: w,
-				statusCode:     http.StatusOK,
+			wrapped := &responseWriter{
+				wrappedWriter: wrappedWriter{w},
+				statusCode:    http.StatusOK,
 			}
 
 			next.ServeHTTP(wrapped, r)
@@ -44,14 +44,3 @@ func MetricsHandler(m *metrics.Metrics) func(http.Handler) http.Handler {
 		})
 	}
 }
-
-// metricsResponseWriter wraps http.ResponseWriter to capture status code.
-type metricsResponseWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func (w *metricsResponseWriter) WriteHeader(code int) {
-	w.statusCode = code
-	w.ResponseWriter.WriteHeader(code)
-}
@@ -91,9 +91,9 @@ func TestMetricsHandler_UnmatchedPath(t *testing.T) {
 
 func TestMetricsResponseWriter_WriteHeader(t *testing.T) {
 	rec := httptest.NewRecorder()
-	w := &metricsResponseWriter{
-		ResponseWriter: rec,
-		statusCode:     http.StatusOK,
+	w := &responseWriter{
+		wrappedWriter: wrappedWriter{rec},
+		statusCode:    http.StatusOK,
 	}
 
 	w.WriteHeader(http.StatusNotFound)
@@ -104,9 +104,9 @@ func TestMetricsResponseWriter_WriteHeader(t *testing.T) {
 
 func TestMetricsResponseWriter_InitialStatusCode(t *testing.T) {
 	rec := httptest.NewRecorder()
-	w := &metricsResponseWriter{
-		ResponseWriter: rec,
-		statusCode:     http.StatusOK,
+	w := &responseWriter{
+		wrappedWriter: wrappedWriter{rec},
+		statusCode:    http.StatusOK,
 	}
 
 	// Without calling WriteHeader, statusCode should be 200
@@ -0,0 +1,333 @@
+package restapi
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"maglev.onebusaway.org/internal/archive"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// Date range limits for the on-time performance endpoints
+const (
+	defaultPerformanceDays = 7
+	maxPerformanceDays     = 92
+)
+
+// parsePerformanceDateRange reads startDate and endDate (YYYY-MM-DD) in loc. The range defaults
+// to the week ending today.
+func parsePerformanceDateRange(query url.Values, loc *time.Location, now time.Time) (time.Time, time.Time, map[string][]string) {
+	y, m, d := now.In(loc).Date()
+	end := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if value := query.Get("endDate"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, map[string][]string{"endDate": {"invalid date format, use YYYY-MM-DD"}}
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, -(defaultPerformanceDays - 1))
+	if value := query.Get("startDate"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, map[string][]string{"startDate": {"invalid date format, use YYYY-MM-DD"}}
+		}
+		start = parsed
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, map[string][]string{"endDate": {"endDate must not be before startDate"}}
+	}
+	if !end.Before(start.AddDate(0, 0, maxPerformanceDays)) {
+		return time.Time{}, time.Time{}, map[string][]string{"endDate": {fmt.Sprintf("the range may span at most %d days", maxPerformanceDays)}}
+	}
+	return start, end, nil
+}
+
+// archiveScope is the agency, route or stop an arrival archive report covers.
+type archiveScope struct {
+	id        string
+	filter    archive.Filter
+	loc       *time.Location
+	breakdown string // GroupRoute or GroupStop
+}
+
+// bucketID is how a report shows the key of a bucket in group: service dates as YYYY-MM-DD and
+// routes and stops as combined IDs.
+func (s archiveScope) bucketID(group, key string) string {
+	switch group {
+	case archive.GroupDay:
+		if day, err := time.Parse("20060102", key); err == nil {
+			return day.Format("2006-01-02")
+		}
+	case archive.GroupRoute, archive.GroupStop:
+		return utils.FormCombinedID(s.filter.AgencyID, key)
+	}
+	return key
+}
+
+// dateRange reads the request's date range into the scope's filter, answering the request when
+// it is invalid.
+func (api *RestAPI) dateRange(w http.ResponseWriter, r *http.Request, scope *archiveScope) (time.Time, time.Time, bool) {
+	start, end, fieldErrors := parsePerformanceDateRange(r.URL.Query(), scope.loc, api.Clock.Now())
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return time.Time{}, time.Time{}, false
+	}
+	scope.filter.StartDate, scope.filter.EndDate = start.Format("20060102"), end.Format("20060102")
+	return start, end, true
+}
+
+// agencyArchiveScope resolves the agency in the request's ID. Like the route and stop
+// variants, it answers the request itself and returns false when the archive is disabled or
+// the ID does not resolve.
+func (api *RestAPI) agencyArchiveScope(w http.ResponseWriter, r *http.Request) (archiveScope, bool) {
+	if api.ArrivalArchive == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "arrival archive not enabled")
+		return archiveScope{}, false
+	}
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return archiveScope{}, false
+	}
+
+	api.GtfsManager.RLock()
+	agency := api.GtfsManager.FindAgency(id)
+	api.GtfsManager.RUnlock()
+	if agency == nil {
+		api.sendNotFound(w, r)
+		return archiveScope{}, false
+	}
+
+	return archiveScope{
+		id:        id,
+		filter:    archive.Filter{AgencyID: agency.Id},
+		loc:       utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.Id),
+		breakdown: archive.GroupRoute,
+	}, true
+}
+
+// routeArchiveScope resolves the route in the request's ID, broken down by stop.
+func (api *RestAPI) routeArchiveScope(w http.ResponseWriter, r *http.Request) (archiveScope, bool) {
+	if api.ArrivalArchive == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "arrival archive not enabled")
+		return archiveScope{}, false
+	}
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return archiveScope{}, false
+	}
+	_, routeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return archiveScope{}, false
+	}
+
+	api.GtfsManager.RLock()
+	route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(r.Context(), routeID)
+	var timezone string
+	if err == nil {
+		if agency := api.GtfsManager.FindAgency(route.AgencyID); agency != nil {
+			timezone = agency.Timezone
+		}
+	}
+	api.GtfsManager.RUnlock()
+	if err != nil {
+		api.sendNotFound(w, r)
+		return archiveScope{}, false
+	}
+
+	return archiveScope{
+		id:        id,
+		filter:    archive.Filter{AgencyID: route.AgencyID, RouteID: route.ID},
+		loc:       utils.LoadLocationWithUTCFallBack(timezone, route.AgencyID),
+		breakdown: archive.GroupStop,
+	}, true
+}
+
+// stopArchiveScope resolves the stop in the request's ID, on the routes of the agency in the
+// ID, broken down by route.
+func (api *RestAPI) stopArchiveScope(w http.ResponseWriter, r *http.Request) (archiveScope, bool) {
+	if api.ArrivalArchive == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "arrival archive not enabled")
+		return archiveScope{}, false
+	}
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return archiveScope{}, false
+	}
+	agencyID, stopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return archiveScope{}, false
+	}
+
+	api.GtfsManager.RLock()
+	_, err = api.GtfsManager.GtfsDB.Queries.GetStop(r.Context(), stopID)
+	agency := api.GtfsManager.FindAgency(agencyID)
+	api.GtfsManager.RUnlock()
+	if err != nil || agency == nil {
+		api.sendNotFound(w, r)
+		return archiveScope{}, false
+	}
+
+	return archiveScope{
+		id:        id,
+		filter:    archive.Filter{AgencyID: agency.Id, StopID: stopID},
+		loc:       utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.Id),
+		breakdown: archive.GroupRoute,
+	}, true
+}
+
+// onTimePerformanceForAgencyHandler reports the punctuality of an agency's archived arrivals,
+// broken down by day, hour of day and route.
+func (api *RestAPI) onTimePerformanceForAgencyHandler(w http.ResponseWriter, r *http.Request) {
+	if scope, ok := api.agencyArchiveScope(w, r); ok {
+		api.sendOnTimePerformance(w, r, scope)
+	}
+}
+
+// onTimePerformanceForRouteHandler reports the punctuality of a route's archived arrivals,
+// broken down by day, hour of day and stop.
+func (api *RestAPI) onTimePerformanceForRouteHandler(w http.ResponseWriter, r *http.Request) {
+	if scope, ok := api.routeArchiveScope(w, r); ok {
+		api.sendOnTimePerformance(w, r, scope)
+	}
+}
+
+// onTimePerformanceForStopHandler reports the punctuality of the archived arrivals at a stop on
+// the routes of the agency in its ID, broken down by day, hour of day and route.
+func (api *RestAPI) onTimePerformanceForStopHandler(w http.ResponseWriter, r *http.Request) {
+	if scope, ok := api.stopArchiveScope(w, r); ok {
+		api.sendOnTimePerformance(w, r, scope)
+	}
+}
+
+// sendOnTimePerformance answers with the punctuality of the arrivals in scope over the
+// requested dates, broken down by day, hour of day and the scope's breakdown.
+func (api *RestAPI) sendOnTimePerformance(w http.ResponseWriter, r *http.Request, scope archiveScope) {
+	start, end, ok := api.dateRange(w, r, &scope)
+	if !ok {
+		return
+	}
+
+	cfg := api.Config.ArrivalArchive
+	early := time.Duration(cfg.EarlyThreshold) * time.Second
+	late := time.Duration(cfg.LateThreshold) * time.Second
+	groups := make(map[string][]models.OnTimeBucket)
+	for _, group := range []string{archive.GroupNone, archive.GroupDay, archive.GroupHour, scope.breakdown} {
+		buckets, err := api.ArrivalArchive.Performance(r.Context(), scope.filter, group, early, late)
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		groups[group] = make([]models.OnTimeBucket, 0, len(buckets))
+		for _, bucket := range buckets {
+			groups[group] = append(groups[group], models.OnTimeBucket{ID: scope.bucketID(group, bucket.Key), OnTimeStats: onTimeStats(bucket)})
+		}
+	}
+
+	entry := models.OnTimePerformance{
+		ID:                    scope.id,
+		StartDate:             start.Format("2006-01-02"),
+		EndDate:               end.Format("2006-01-02"),
+		EarlyThresholdSeconds: cfg.EarlyThreshold,
+		LateThresholdSeconds:  cfg.LateThreshold,
+		Summary:               groups[archive.GroupNone][0].OnTimeStats,
+		ByDay:                 groups[archive.GroupDay],
+		ByHour:                groups[archive.GroupHour],
+		ByRoute:               []models.OnTimeBucket{},
+		ByStop:                []models.OnTimeBucket{},
+	}
+	if scope.breakdown == archive.GroupRoute {
+		entry.ByRoute = groups[archive.GroupRoute]
+	} else {
+		entry.ByStop = groups[archive.GroupStop]
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock))
+}
+
+func onTimeStats(bucket archive.Bucket) models.OnTimeStats {
+	stats := models.OnTimeStats{
+		Arrivals:                      bucket.Arrivals,
+		Early:                         bucket.Early,
+		OnTime:                        bucket.OnTime,
+		Late:                          bucket.Late,
+		AverageDelaySeconds:           math.Round(bucket.AverageDelay.Seconds()*10) / 10,
+		PredictedArrivals:             bucket.Predicted,
+		AveragePredictionErrorSeconds: math.Round(bucket.AveragePrediction.Seconds()*10) / 10,
+	}
+	if bucket.Arrivals > 0 {
+		stats.OnTimePercent = math.Round(float64(bucket.OnTime)/float64(bucket.Arrivals)*1000) / 10
+	}
+	return stats
+}
+
+// adminArrivalArchiveExportHandler streams an agency's archived arrivals over a date range as
+// CSV, one row per trip and stop, for loading into a spreadsheet or warehouse.
+func (api *RestAPI) adminArrivalArchiveExportHandler(w http.ResponseWriter, r *http.Request) {
+	if api.ArrivalArchive == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "arrival archive not enabled")
+		return
+	}
+	agencyID := r.URL.Query().Get("agencyId")
+	if agencyID == "" {
+		api.validationErrorResponse(w, r, map[string][]string{"agencyId": {"required"}})
+		return
+	}
+	api.GtfsManager.RLock()
+	agency := api.GtfsManager.FindAgency(agencyID)
+	api.GtfsManager.RUnlock()
+	if agency == nil {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.Id)
+	start, end, fieldErrors := parsePerformanceDateRange(r.URL.Query(), loc, api.Clock.Now())
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+	arrivals, err := api.ArrivalArchive.Arrivals(r.Context(), archive.Filter{
+		AgencyID:  agency.Id,
+		StartDate: start.Format("20060102"),
+		EndDate:   end.Format("20060102"),
+	})
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="arrivals.csv"`)
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"service_date", "route_id", "trip_id", "stop_id", "stop_sequence", "vehicle_id",
+		"scheduled_arrival", "predicted_arrival", "predicted_at", "actual_arrival", "delay_seconds"})
+	for _, arrival := range arrivals {
+		var predicted, predictedAt string
+		if !arrival.Predicted.IsZero() {
+			predicted = arrival.Predicted.Format(time.RFC3339)
+			predictedAt = arrival.PredictedAt.Format(time.RFC3339)
+		}
+		_ = out.Write([]string{
+			arrival.ServiceDate, arrival.RouteID, arrival.TripID, arrival.StopID,
+			strconv.FormatInt(arrival.StopSequence, 10), arrival.VehicleID,
+			arrival.Scheduled.Format(time.RFC3339), predicted, predictedAt, arrival.Actual.Format(time.RFC3339),
+			strconv.FormatInt(int64(arrival.Actual.Sub(arrival.Scheduled)/time.Second), 10),
+		})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		api.requestLogger(r).Error("failed to write arrival archive export", "error", err)
+	}
+}
@@ -0,0 +1,142 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/archive"
+	"maglev.onebusaway.org/internal/clock"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestOnTimePerformanceHandlers(t *testing.T) {
+	// 03:00 in Redding, after the whole of June 12's service
+	mockClock := clock.NewMockClock(time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	serve := func(t *testing.T, target string) (int, models.OnTimePerformance) {
+		rec := serveSiri(t, api, target)
+		var body struct {
+			Data struct {
+				Entry models.OnTimePerformance `json:"entry"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		return rec.Code, body.Data.Entry
+	}
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	t.Run("not enabled", func(t *testing.T) {
+		code, _ := serve(t, "/api/where/on-time-performance-for-agency/"+agencyID+".json?key="+siriTestKey)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	cfg := appconf.ArrivalArchiveConfig{DataPath: ":memory:", RetentionDays: 365, EarlyThreshold: 60, LateThreshold: 300}
+	arrivals, err := archive.New(cfg, archive.ManagerSchedule(api.GtfsManager), mockClock, nil)
+	require.NoError(t, err)
+	defer arrivals.Shutdown()
+	api.ArrivalArchive = arrivals
+	api.Config.ArrivalArchive = cfg
+
+	// One stop reached 30 seconds late and the next ten minutes late
+	tripID := api.GtfsManager.GetStaticData().Trips[0].ID
+	api.GtfsManager.RLock()
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(context.Background(), tripID)
+	api.GtfsManager.RUnlock()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(stopTimes), 2)
+	onTime, late := 30*time.Second, 10*time.Minute
+	require.NoError(t, arrivals.Process(context.Background(), GTFS.RealtimeUpdate{Trips: []gtfs.Trip{{
+		ID: gtfs.TripID{ID: tripID, HasStartDate: true, StartDate: time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)},
+		StopTimeUpdates: []gtfs.StopTimeUpdate{
+			{StopID: &stopTimes[0].StopID, Arrival: &gtfs.StopTimeEvent{Delay: &onTime}},
+			{StopID: &stopTimes[1].StopID, Arrival: &gtfs.StopTimeEvent{Delay: &late}},
+		},
+	}}}))
+
+	route := api.GtfsManager.GetStaticData().Trips[0].Route
+	routeID := utils.FormCombinedID(agencyID, route.Id)
+	firstStopID := utils.FormCombinedID(agencyID, stopTimes[0].StopID)
+
+	t.Run("agency", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/on-time-performance-for-agency/"+agencyID+".json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "2025-06-07", entry.StartDate)
+		assert.Equal(t, "2025-06-13", entry.EndDate)
+		assert.Equal(t, 300, entry.LateThresholdSeconds)
+		assert.Equal(t, models.OnTimeStats{
+			Arrivals: 2, OnTime: 1, Late: 1, OnTimePercent: 50, AverageDelaySeconds: 315,
+		}, entry.Summary)
+		require.Len(t, entry.ByDay, 1)
+		assert.Equal(t, "2025-06-12", entry.ByDay[0].ID)
+		require.Len(t, entry.ByRoute, 1)
+		assert.Equal(t, routeID, entry.ByRoute[0].ID)
+		assert.Empty(t, entry.ByStop)
+		assert.NotEmpty(t, entry.ByHour)
+	})
+
+	t.Run("route", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/on-time-performance-for-route/"+routeID+".json?key="+siriTestKey+"&startDate=2025-06-12&endDate=2025-06-12")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(2), entry.Summary.Arrivals)
+		require.Len(t, entry.ByStop, 2)
+		assert.Empty(t, entry.ByRoute)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/on-time-performance-for-stop/"+firstStopID+".json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, models.OnTimeStats{Arrivals: 1, OnTime: 1, OnTimePercent: 100, AverageDelaySeconds: 30}, entry.Summary)
+
+		code, entry = serve(t, "/api/where/on-time-performance-for-stop/"+firstStopID+".json?key="+siriTestKey+"&endDate=2025-06-11")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(0), entry.Summary.Arrivals)
+		assert.Empty(t, entry.ByDay)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			target string
+			status int
+		}{
+			{"/api/where/on-time-performance-for-agency/unknown.json", http.StatusNotFound},
+			{"/api/where/on-time-performance-for-route/" + agencyID + "_unknown.json", http.StatusNotFound},
+			{"/api/where/on-time-performance-for-stop/" + agencyID + "_unknown.json", http.StatusNotFound},
+			{"/api/where/on-time-performance-for-agency/" + agencyID + ".json?startDate=June", http.StatusBadRequest},
+			{"/api/where/on-time-performance-for-agency/" + agencyID + ".json?startDate=2025-06-12&endDate=2025-06-11", http.StatusBadRequest},
+			{"/api/where/on-time-performance-for-agency/" + agencyID + ".json?startDate=2025-01-01&endDate=2025-06-11", http.StatusBadRequest},
+		} {
+			separator := "?"
+			if strings.Contains(tc.target, "?") {
+				separator = "&"
+			}
+			rec := serveSiri(t, api, tc.target+separator+"key="+siriTestKey)
+			assert.Equal(t, tc.status, rec.Code, tc.target)
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		mux := http.NewServeMux()
+		api.SetRoutes(mux)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/arrivals.csv?key=admin-secret&agencyId="+agencyID, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[0], "service_date,route_id,trip_id,stop_id,"))
+		assert.True(t, strings.HasSuffix(lines[2], ",600"), lines[2])
+	})
+}
@@ -0,0 +1,24 @@
+package restapi
+
+import "net/http"
+
+// pageLimits returns the default and maximum page size for an endpoint in class, preferring
+// the configured pagination limits over the endpoint's built-in defaultCount and maxCount.
+// A maxCount of 0 means the endpoint has no maximum; a defaultCount of -1 returns every result.
+// Requests with a bulk API key may ask for up to the class's bulk-max-count when it is larger.
+func (api *RestAPI) pageLimits(r *http.Request, class string, defaultCount, maxCount int) (int, int) {
+	limits := api.Config.Pagination[class]
+	if limits.DefaultCount > 0 {
+		defaultCount = limits.DefaultCount
+	}
+	if limits.MaxCount > 0 {
+		maxCount = limits.MaxCount
+	}
+	if maxCount > 0 && defaultCount > maxCount {
+		defaultCount = maxCount
+	}
+	if maxCount > 0 && limits.BulkMaxCount > maxCount && api.RequestHasBulkAPIKey(r) {
+		maxCount = limits.BulkMaxCount
+	}
+	return defaultCount, maxCount
+}
@@ -0,0 +1,49 @@
+package restapi
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func TestPageLimits(t *testing.T) {
+	configured := func(pagination appconf.PaginationConfig) *RestAPI {
+		return &RestAPI{Application: &app.Application{Config: appconf.Config{Pagination: pagination, BulkApiKeys: []string{"sync"}}}}
+	}
+	r := httptest.NewRequest("GET", "/api/where/stops-for-agency/25.json?key=test", nil)
+
+	defaultCount, maxCount := configured(nil).pageLimits(r, appconf.PaginationClassLocation, 100, 250)
+	assert.Equal(t, 100, defaultCount)
+	assert.Equal(t, 250, maxCount)
+
+	custom := configured(appconf.PaginationConfig{appconf.PaginationClassLocation: {MaxCount: 500}})
+	defaultCount, maxCount = custom.pageLimits(r, appconf.PaginationClassLocation, 100, 250)
+	assert.Equal(t, 100, defaultCount)
+	assert.Equal(t, 500, maxCount)
+
+	// A maximum below the built-in default lowers the default too
+	small := configured(appconf.PaginationConfig{appconf.PaginationClassSearch: {MaxCount: 10}})
+	defaultCount, maxCount = small.pageLimits(r, appconf.PaginationClassSearch, 20, 100)
+	assert.Equal(t, 10, defaultCount)
+	assert.Equal(t, 10, maxCount)
+
+	// Lists still return every result by default unless a default-count is configured
+	defaultCount, _ = small.pageLimits(r, appconf.PaginationClassList, -1, 1000)
+	assert.Equal(t, -1, defaultCount)
+
+	// Bulk keys may page up to the bulk maximum; other keys keep the usual one
+	bulk := configured(appconf.PaginationConfig{appconf.PaginationClassList: {BulkMaxCount: 50000}})
+	_, maxCount = bulk.pageLimits(r, appconf.PaginationClassList, -1, 1000)
+	assert.Equal(t, 1000, maxCount)
+	bulkRequest := httptest.NewRequest("GET", "/api/where/stops-for-agency/25.json?key=sync", nil)
+	defaultCount, maxCount = bulk.pageLimits(bulkRequest, appconf.PaginationClassList, -1, 1000)
+	assert.Equal(t, -1, defaultCount)
+	assert.Equal(t, 50000, maxCount)
+
+	// Without a bulk-max-count bulk keys are held to the usual maximum
+	_, maxCount = custom.pageLimits(bulkRequest, appconf.PaginationClassLocation, 100, 250)
+	assert.Equal(t, 500, maxCount)
+}
@@ -0,0 +1,35 @@
+package restapi
+
+import "maglev.onebusaway.org/internal/popularity"
+
+// maxPopularityCandidates caps the search matches fetched for popularity ranking.
+const maxPopularityCandidates = 200
+
+// popularityEnabled reports whether search results are ranked by popularity.
+func (api *RestAPI) popularityEnabled() bool {
+	return api.Popularity != nil && api.Config.SearchPopularityWeight > 0
+}
+
+// searchCandidates returns how many matches to fetch for a search returning limit results.
+// With popularity ranking on, more are fetched so that popular matches just past the limit can
+// move into the results.
+func (api *RestAPI) searchCandidates(limit int) int {
+	if !api.popularityEnabled() {
+		return limit
+	}
+	return max(limit, min(limit*4, maxPopularityCandidates))
+}
+
+// rankByPopularity reorders search matches, best match first, by popularity and keeps the first
+// limit of them. Matches are returned as they are when popularity ranking is off.
+func rankByPopularity[T any](api *RestAPI, kind popularity.Kind, matches []T, id func(T) string, limit int) []T {
+	if !api.popularityEnabled() {
+		return matches
+	}
+	order := api.Popularity.Rank(kind, len(matches), func(i int) string { return id(matches[i]) }, api.Config.SearchPopularityWeight)
+	ranked := make([]T, 0, min(len(matches), limit))
+	for _, i := range order[:min(len(order), limit)] {
+		ranked = append(ranked, matches[i])
+	}
+	return ranked
+}
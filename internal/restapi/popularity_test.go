@@ -0,0 +1,95 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/popularity"
+)
+
+// searchIDs returns the IDs listed by a stop or route search, and the routes of the stops listed.
+func searchIDs(t *testing.T, api *RestAPI, endpoint, input string, maxCount int) ([]string, map[string]bool, models.ReferencesModel) {
+	t.Helper()
+	rec := serveSiri(t, api, endpoint+"?key="+siriTestKey+"&input="+url.QueryEscape(input)+"&maxCount="+strconv.Itoa(maxCount))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data struct {
+			List []struct {
+				ID       string   `json:"id"`
+				RouteIDs []string `json:"routeIds"`
+			} `json:"list"`
+			References models.ReferencesModel `json:"references"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	ids := make([]string, 0, len(response.Data.List))
+	routeIDs := map[string]bool{}
+	for _, item := range response.Data.List {
+		ids = append(ids, item.ID)
+		for _, routeID := range item.RouteIDs {
+			routeIDs[routeID] = true
+		}
+	}
+	return ids, routeIDs, response.Data.References
+}
+
+func TestSearchPopularity(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	// The word most stop names share
+	words := map[string]int{}
+	for _, stop := range api.GtfsManager.GetStops() {
+		for _, word := range strings.Fields(stop.Name) {
+			if len(word) > 2 {
+				words[word]++
+			}
+		}
+	}
+	common := make([]string, 0, len(words))
+	for word := range words {
+		common = append(common, word)
+	}
+	sort.Slice(common, func(i, j int) bool { return words[common[i]] > words[common[j]] })
+	require.NotEmpty(t, common)
+	input := common[0]
+
+	all, _, _ := searchIDs(t, api, "/api/where/search/stop.json", input, 10)
+	require.Greater(t, len(all), 3, "search for %q", input)
+	unranked, _, _ := searchIDs(t, api, "/api/where/search/stop.json", input, 2)
+	assert.Equal(t, all[:2], unranked, "popularity ranking is off")
+
+	api.Popularity = popularity.New(api.Clock.Now)
+	api.Config.SearchPopularityWeight = 1
+	defer func() { api.Popularity, api.Config.SearchPopularityWeight = nil, 0 }()
+
+	popular := all[3]
+	for range 3 {
+		rec := serveSiri(t, api, "/api/where/stop/"+popular+".json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	rec := serveSiri(t, api, "/api/where/stop/25_nonexistent.json?key="+siriTestKey)
+	require.NotEqual(t, http.StatusOK, rec.Code)
+	assert.Zero(t, api.Popularity.Score(popularity.Stop, "25_nonexistent"), "failed lookups are not counted")
+
+	ranked, stopRoutes, references := searchIDs(t, api, "/api/where/search/stop.json", input, 2)
+	assert.Equal(t, []string{popular, all[0]}, ranked)
+	assert.Len(t, references.Routes, len(stopRoutes), "only the routes of the stops listed are referenced")
+
+	routes, _, _ := searchIDs(t, api, "/api/where/search/route.json", "shasta", 20)
+	require.NotEmpty(t, routes)
+	last := routes[len(routes)-1]
+	rec = serveSiri(t, api, "/api/where/trips-for-route/"+last+".json?key="+siriTestKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Positive(t, api.Popularity.Score(popularity.Route, last))
+	routes, _, _ = searchIDs(t, api, "/api/where/search/route.json", "shasta", 1)
+	assert.Equal(t, []string{last}, routes)
+}
@@ -0,0 +1,52 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func servePprof(mux *http.ServeMux, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPprofRequiresAdminKey(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	rec := servePprof(mux, "/api/admin/debug/pprof/?key=TEST")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = servePprof(mux, "/api/admin/debug/pprof/?key=admin-secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine")
+
+	rec = servePprof(mux, "/api/admin/debug/pprof/cmdline?key=admin-secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdminRoutesMoveToAdminPort(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+	api.Config.AdminPort = 4001
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	rec := servePprof(mux, "/api/admin/debug/pprof/?key=admin-secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	adminMux := http.NewServeMux()
+	api.SetAdminRoutes(adminMux)
+	rec = servePprof(adminMux, "/api/admin/debug/pprof/?key=admin-secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
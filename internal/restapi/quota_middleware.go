@@ -0,0 +1,88 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/quota"
+)
+
+// QuotaMiddleware counts requests per API key and enforces daily and monthly quotas on keys
+// that have one. Exempt keys are counted but never limited.
+// If manager is nil, returns a pass-through middleware that does nothing.
+func (api *RestAPI) QuotaMiddleware(manager *quota.Manager) func(http.Handler) http.Handler {
+	if manager == nil {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := app.APIKeyFromRequest(r)
+
+			// Exempt keys are first-party clients and bypass quotas as well as rate limits
+			if api.isExemptAPIKey(apiKey) {
+				manager.Record(apiKey)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision := manager.Allow(apiKey)
+			if decision.Limited {
+				setQuotaHeaders(w, decision)
+			}
+
+			if !decision.Allowed {
+				api.sendQuotaExceeded(w, r, decision)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (api *RestAPI) isExemptAPIKey(apiKey string) bool {
+	for _, exemptKey := range api.ExemptAPIKeys() {
+		if apiKey == exemptKey {
+			return true
+		}
+	}
+	return false
+}
+
+func setQuotaHeaders(w http.ResponseWriter, decision quota.Decision) {
+	w.Header().Set("X-Quota-Period", string(decision.Period))
+	w.Header().Set("X-Quota-Limit", strconv.FormatInt(decision.Limit, 10))
+	w.Header().Set("X-Quota-Remaining", strconv.FormatInt(decision.Remaining, 10))
+	w.Header().Set("X-Quota-Reset", strconv.FormatInt(decision.Reset.Unix(), 10))
+}
+
+// sendQuotaExceeded sends a 429 Too Many Requests response describing the exhausted quota
+func (api *RestAPI) sendQuotaExceeded(w http.ResponseWriter, r *http.Request, decision quota.Decision) {
+	retryAfter := int(decision.Reset.Sub(api.Clock.Now()).Seconds())
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	setResponseType(w, r)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	data := models.QuotaExceeded{
+		Period:    string(decision.Period),
+		Limit:     decision.Limit,
+		ResetTime: decision.Reset.UnixMilli(),
+	}
+	text := fmt.Sprintf("%s quota of %d requests exceeded", decision.Period, decision.Limit)
+	response := models.NewResponse(http.StatusTooManyRequests, data, text, api.Clock)
+	response.RequestID = RequestIDFromContext(r.Context())
+
+	if err := api.encodeResponse(w, r, response); err != nil {
+		api.requestLogger(r).Error("failed to encode quota exceeded response", "error", err)
+	}
+}
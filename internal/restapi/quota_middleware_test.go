@@ -0,0 +1,123 @@
+package restapi
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/quota"
+)
+
+func TestQuotaMiddleware(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 5, 20, 18, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+
+	manager, err := quota.NewManager(appconf.QuotaConfig{Default: appconf.QuotaLimits{Daily: 1}}, nil, mockClock, nil, 0)
+	require.NoError(t, err)
+	api.Quotas = manager
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "daily", resp.Header.Get("X-Quota-Period"))
+	assert.Equal(t, "1", resp.Header.Get("X-Quota-Limit"))
+	assert.Equal(t, "0", resp.Header.Get("X-Quota-Remaining"))
+
+	t.Run("exceeded quota returns 429 with reset time", func(t *testing.T) {
+		resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, http.StatusTooManyRequests, model.Code)
+		assert.Equal(t, "daily quota of 1 requests exceeded", model.Text)
+
+		reset := time.Date(2025, 5, 21, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, strconv.FormatInt(reset.Unix(), 10), resp.Header.Get("X-Quota-Reset"))
+		assert.Equal(t, strconv.Itoa(6*60*60), resp.Header.Get("Retry-After"))
+
+		data, ok := model.Data.(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "daily", data["period"])
+		assert.Equal(t, float64(1), data["limit"])
+		assert.Equal(t, float64(reset.UnixMilli()), data["resetTime"])
+	})
+
+	t.Run("exempt keys bypass quotas", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=org.onebusaway.iphone")
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Empty(t, resp.Header.Get("X-Quota-Limit"))
+		}
+	})
+}
+
+func TestQuotaUsageHandler(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 5, 20, 18, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	cfg := appconf.QuotaConfig{Keys: map[string]appconf.QuotaLimits{"TEST": {Daily: 10, Monthly: 200}}}
+	manager, err := quota.NewManager(cfg, nil, mockClock, nil, 0)
+	require.NoError(t, err)
+	api.Quotas = manager
+
+	for range 2 {
+		resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=test-rate-limit")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	code, model := serveAdmin(t, api, http.MethodGet, "/api/admin/quotas.json?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, float64(time.Date(2025, 5, 21, 0, 0, 0, 0, time.UTC).UnixMilli()), entry["dailyReset"])
+	assert.Equal(t, float64(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli()), entry["monthlyReset"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "TEST", "dailyRequests": float64(2), "dailyLimit": float64(10), "monthlyRequests": float64(2), "monthlyLimit": float64(200)},
+		// Keys without a quota are counted too
+		map[string]interface{}{"key": "test-rate-limit", "dailyRequests": float64(1), "dailyLimit": float64(0), "monthlyRequests": float64(1), "monthlyLimit": float64(0)},
+	}, entry["keys"])
+
+	code, model = serveAdmin(t, api, http.MethodGet, "/api/admin/quotas.json?key=admin-secret&apiKey=test-rate-limit")
+	require.Equal(t, http.StatusOK, code)
+	keys := model.Data.(map[string]interface{})["entry"].(map[string]interface{})["keys"].([]interface{})
+	require.Len(t, keys, 1)
+	assert.Equal(t, "test-rate-limit", keys[0].(map[string]interface{})["key"])
+}
+
+func TestQuotaUsageWithoutQuotas(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 5, 20, 18, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+	api.Config.RateLimitExemptPaths = []string{"/api/where/agency/*"}
+
+	manager, err := quota.NewManager(appconf.QuotaConfig{}, nil, mockClock, nil, 0)
+	require.NoError(t, err)
+	api.Quotas = manager
+
+	for range 3 {
+		resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("X-Quota-Limit"))
+	}
+	// Exempt keys and exempt paths are counted, though never limited
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=org.onebusaway.iphone")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/agency/40.json?key=test-rate-limit")
+	require.NotEqual(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	code, model := serveAdmin(t, api, http.MethodGet, "/api/admin/quotas.json?key=admin-secret")
+	require.Equal(t, http.StatusOK, code)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "TEST", "dailyRequests": float64(3), "dailyLimit": float64(0), "monthlyRequests": float64(3), "monthlyLimit": float64(0)},
+		map[string]interface{}{"key": "org.onebusaway.iphone", "dailyRequests": float64(1), "dailyLimit": float64(0), "monthlyRequests": float64(1), "monthlyLimit": float64(0)},
+		map[string]interface{}{"key": "test-rate-limit", "dailyRequests": float64(1), "dailyLimit": float64(0), "monthlyRequests": float64(1), "monthlyLimit": float64(0)},
+	}, entry["keys"])
+}
@@ -7,9 +7,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/metrics"
 )
 
 // TestRateLimitMiddleware_CleanupKeepsActiveClients verifies active users are not deleted.
@@ -296,3 +298,37 @@ func TestRateLimitMiddleware_LastSeenUpdateOnEveryRequest(t *testing.T) {
 	assert.True(t, secondSeen.After(firstSeen), "lastSeen should be updated on subsequent requests")
 	assert.Equal(t, 2*time.Minute, secondSeen.Sub(firstSeen), "lastSeen should reflect the 2 minute advancement")
 }
+
+// TestRateLimitMiddleware_Metrics verifies the limiter publishes its map size, evictions,
+// throttled and exempt requests.
+func TestRateLimitMiddleware_Metrics(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Now())
+	middleware := NewRateLimitMiddleware(1, time.Second, []string{"exempt-key"}, mockClock)
+	defer middleware.Stop()
+	m := metrics.New()
+	middleware.SetMetrics(m)
+
+	limitedHandler := middleware.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(key string) int {
+		w := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(w, httptest.NewRequest("GET", "/test?key="+key, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("busy-key"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("busy-key"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("busy-key"))
+	assert.Equal(t, http.StatusOK, serve("exempt-key"))
+	assert.Equal(t, http.StatusOK, serve("quiet-key"))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.RateLimitClients))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.RateLimitThrottledTotal.WithLabelValues(metrics.KeyFingerprint("busy-key"))))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RateLimitExemptRequestsTotal.WithLabelValues(metrics.KeyFingerprint("exempt-key"))))
+
+	mockClock.Advance(11 * time.Minute)
+	assert.Equal(t, 2, middleware.evictIdle())
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.RateLimitClients))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.RateLimitEvictionsTotal))
+}
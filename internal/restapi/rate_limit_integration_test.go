@@ -2,6 +2,7 @@ package restapi
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -115,6 +116,38 @@ func TestRateLimitingPerAPIKey(t *testing.T) {
 		"Different endpoint with same key should also be rate limited")
 }
 
+func TestRateLimitingKeyInHeader(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	serve := func(setKey func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json", nil)
+		setKey(req)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(func(r *http.Request) { r.Header.Set("X-API-Key", "TEST") })
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Authorization, X-API-Key", rec.Header().Get("Vary"))
+
+	rec = serve(func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") })
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The key is limited the same wherever it is sent
+	hitLimit := false
+	for i := 0; i < 10 && !hitLimit; i++ {
+		rec = serve(func(r *http.Request) { r.Header.Set("Authorization", "Bearer TEST") })
+		hitLimit = rec.Code == http.StatusTooManyRequests
+	}
+	assert.True(t, hitLimit, "TEST key should hit rate limit within 10 requests")
+	rec = serve(func(r *http.Request) { r.URL.RawQuery = "key=TEST" })
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
 func TestRateLimitingExemption(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
@@ -130,6 +163,33 @@ func TestRateLimitingExemption(t *testing.T) {
 	}
 }
 
+func TestRateLimitingExemptPath(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.RateLimitExemptPaths = []string{"/api/where/current-time.json"}
+
+	// Probes on an exempt path are never throttled
+	for i := 0; i < 20; i++ {
+		response, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=test-rate-limit")
+		assert.Equal(t, http.StatusOK, response.StatusCode,
+			"Exempt path request %d should always succeed", i+1)
+	}
+
+	// Other paths still count against the key
+	blocked := 0
+	for i := 0; i < 10; i++ {
+		response, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/agency/raba.json?key=test-rate-limit")
+		if response.StatusCode == http.StatusTooManyRequests {
+			blocked++
+		}
+	}
+	assert.Positive(t, blocked)
+
+	// The key is still validated
+	response, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=invalid")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
 func TestRateLimitingHeaders(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
@@ -3,6 +3,7 @@ package restapi
 import (
 	"encoding/json"
 	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,7 +11,9 @@ import (
 	"time"
 
 	"golang.org/x/time/rate"
+	"maglev.onebusaway.org/internal/app"
 	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/metrics"
 )
 
 // rateLimitClient tracks the limiter and its last usage time.
@@ -31,23 +34,42 @@ type RateLimitMiddleware struct {
 	stopChan    chan struct{}
 	stopOnce    sync.Once
 	clock       clock.Clock
+	metrics     *metrics.Metrics // Optional; set with SetMetrics
 }
 
 // NewRateLimitMiddleware creates a new rate limiting middleware
 // ratePerSecond: number of requests allowed per second per API key
 // burstSize: number of requests allowed in a burst per API key
 func NewRateLimitMiddleware(ratePerSecond int, interval time.Duration, exemptKeys []string, clock clock.Clock) *RateLimitMiddleware {
+	middleware := &RateLimitMiddleware{
+		limiters:    make(map[string]*rateLimitClient),
+		rateLimit:   rateLimitFor(ratePerSecond, interval),
+		burstSize:   ratePerSecond,
+		cleanupTick: time.NewTicker(5 * time.Minute), // Cleanup old limiters every 5 minutes
+		exemptKeys:  exemptKeySet(exemptKeys),
+		stopChan:    make(chan struct{}),
+		clock:       clock,
+	}
+
+	// Start cleanup goroutine
+	go middleware.cleanup()
+
+	return middleware
+}
+
+// rateLimitFor converts a per-interval request count into a limiter rate
+func rateLimitFor(ratePerSecond int, interval time.Duration) rate.Limit {
 	// Handle zero rate limit case
-	var rateLimit rate.Limit
 	if ratePerSecond <= 0 {
-		rateLimit = rate.Inf // Infinite rate limit (no limiting)
 		if ratePerSecond == 0 {
-			rateLimit = 0 // No requests allowed
+			return 0 // No requests allowed
 		}
-	} else {
-		rateLimit = rate.Every(interval / time.Duration(ratePerSecond))
+		return rate.Inf // Infinite rate limit (no limiting)
 	}
+	return rate.Every(interval / time.Duration(ratePerSecond))
+}
 
+func exemptKeySet(exemptKeys []string) map[string]bool {
 	exemptMap := make(map[string]bool)
 	for _, key := range exemptKeys {
 		trimmedKey := strings.TrimSpace(key)
@@ -55,21 +77,34 @@ func NewRateLimitMiddleware(ratePerSecond int, interval time.Duration, exemptKey
 			exemptMap[trimmedKey] = true
 		}
 	}
+	return exemptMap
+}
 
-	middleware := &RateLimitMiddleware{
-		limiters:    make(map[string]*rateLimitClient),
-		rateLimit:   rateLimit,
-		burstSize:   ratePerSecond,
-		cleanupTick: time.NewTicker(5 * time.Minute), // Cleanup old limiters every 5 minutes
-		exemptKeys:  exemptMap,
-		stopChan:    make(chan struct{}),
-		clock:       clock,
+// SetMetrics publishes the limiter's internals through m. Call it before serving requests.
+func (rl *RateLimitMiddleware) SetMetrics(m *metrics.Metrics) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.metrics = m
+	if m != nil {
+		m.RateLimitClients.Set(float64(len(rl.limiters)))
 	}
+}
 
-	// Start cleanup goroutine
-	go middleware.cleanup()
+// Update applies a new rate limit and set of exempt keys. Existing limiters are
+// adjusted in place, so clients keep their remaining tokens.
+func (rl *RateLimitMiddleware) Update(ratePerSecond int, interval time.Duration, exemptKeys []string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 
-	return middleware
+	rl.rateLimit = rateLimitFor(ratePerSecond, interval)
+	rl.burstSize = ratePerSecond
+	rl.exemptKeys = exemptKeySet(exemptKeys)
+
+	now := rl.clock.Now()
+	for _, client := range rl.limiters {
+		client.limiter.SetLimitAt(now, rl.rateLimit)
+		client.limiter.SetBurstAt(now, rl.burstSize)
+	}
 }
 
 // Handler returns the HTTP middleware handler function
@@ -95,6 +130,9 @@ func (rl *RateLimitMiddleware) getLimiter(apiKey string) *rate.Limiter {
 		limiter:  limiter,
 		lastSeen: rl.clock.Now(),
 	}
+	if rl.metrics != nil {
+		rl.metrics.RateLimitClients.Set(float64(len(rl.limiters)))
+	}
 
 	return limiter
 }
@@ -102,25 +140,13 @@ func (rl *RateLimitMiddleware) getLimiter(apiKey string) *rate.Limiter {
 // rateLimitHandler is the HTTP middleware function
 func (rl *RateLimitMiddleware) rateLimitHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract API key from query parameters
-		apiKey := r.URL.Query().Get("key")
-
-		// Use a default key for requests without an API key
-		if apiKey == "" {
-			apiKey = "__no_key__"
-		}
-
-		// Check if this API key is exempted from rate limiting
-		if rl.exemptKeys[apiKey] {
-			next.ServeHTTP(w, r)
-			return
+		// Limit by the API key the request is authenticated as
+		allowed, status := rl.take(app.APIKeyFromRequest(r))
+		if status != nil {
+			// Sent on every response, so clients can slow down before they are throttled
+			setRateLimitHeaders(w, status)
 		}
-
-		// Get the rate limiter for this API key
-		limiter := rl.getLimiter(apiKey)
-
-		// Check if request is allowed
-		if !limiter.Allow() {
+		if !allowed {
 			rl.sendRateLimitExceeded(w, r)
 			return
 		}
@@ -130,27 +156,105 @@ func (rl *RateLimitMiddleware) rateLimitHandler(next http.Handler) http.Handler
 	})
 }
 
+// Allow reports whether a request authenticated as apiKey may go ahead, taking a token from
+// the key's limiter if so. It is the check behind Handler, for callers outside HTTP.
+func (rl *RateLimitMiddleware) Allow(apiKey string) bool {
+	allowed, _ := rl.take(apiKey)
+	return allowed
+}
+
+// rateLimitStatus is the state of a key's limiter after a request, as reported in the
+// X-RateLimit-* headers.
+type rateLimitStatus struct {
+	limit     int       // Burst size
+	remaining int       // Whole tokens left
+	reset     time.Time // When the burst is full again; zero when it never refills
+}
+
+// take is Allow, also returning the key's limiter state after the request. The state is nil
+// for exempt keys and unlimited rates, which have none to report.
+func (rl *RateLimitMiddleware) take(apiKey string) (bool, *rateLimitStatus) {
+	// Use a default key for requests without an API key
+	if apiKey == "" {
+		apiKey = "__no_key__"
+	}
+
+	// Check if this API key is exempted from rate limiting
+	rl.mu.RLock()
+	exempt := rl.exemptKeys[apiKey]
+	m := rl.metrics
+	rl.mu.RUnlock()
+	if exempt {
+		if m != nil {
+			m.RateLimitExemptRequestsTotal.WithLabelValues(metrics.KeyFingerprint(apiKey)).Inc()
+		}
+		return true, nil
+	}
+
+	// Get the rate limiter for this API key
+	limiter := rl.getLimiter(apiKey)
+
+	// Check if request is allowed, on the same clock as the limiter's other timestamps
+	now := rl.clock.Now()
+	allowed := limiter.AllowN(now, 1)
+	if !allowed && m != nil {
+		m.RateLimitThrottledTotal.WithLabelValues(metrics.KeyFingerprint(apiKey)).Inc()
+	}
+
+	limit := limiter.Limit()
+	if limit == rate.Inf {
+		return allowed, nil
+	}
+	status := &rateLimitStatus{limit: limiter.Burst()}
+	tokens := limiter.TokensAt(now)
+	if tokens > 0 {
+		status.remaining = int(tokens)
+	}
+	status.reset = now
+	if missing := float64(status.limit) - tokens; missing > 0 {
+		status.reset = time.Time{}
+		if limit > 0 {
+			status.reset = now.Add(time.Duration(missing / float64(limit) * float64(time.Second)))
+		}
+	}
+	return allowed, status
+}
+
+// setRateLimitHeaders reports status in the X-RateLimit-* headers. X-RateLimit-Reset is a
+// Unix time in seconds, rounded up, like X-Quota-Reset, and left out when the limiter never
+// refills.
+func setRateLimitHeaders(w http.ResponseWriter, status *rateLimitStatus) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(status.limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(status.remaining))
+	if !status.reset.IsZero() {
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(status.reset.Add(time.Second-time.Nanosecond).Unix(), 10))
+	}
+}
+
 // sendRateLimitExceeded sends a 429 Too Many Requests response
 func (rl *RateLimitMiddleware) sendRateLimitExceeded(w http.ResponseWriter, r *http.Request) {
+	rl.mu.RLock()
+	rateLimit := rl.rateLimit
+	rl.mu.RUnlock()
+
 	// Calculate retry-after based on rate limit
 	var retryAfter time.Duration
-	switch rl.rateLimit {
+	switch rateLimit {
 	case 0:
 		retryAfter = time.Hour // For zero rate limit, suggest retrying much later
 	case rate.Inf:
 		retryAfter = time.Second // Should not happen, but fallback
 	default:
-		retryAfter = time.Duration(1) / time.Duration(rl.rateLimit)
+		// The time until the next token, rounded up to whole seconds
+		retryAfter = time.Duration(math.Ceil(1/float64(rateLimit))) * time.Second
 	}
 
 	// Set headers
-	w.Header().Set("Content-Type", "application/json")
+	setResponseType(w, r)
 	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
-	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.burstSize))
-	w.Header().Set("X-RateLimit-Remaining", "0")
 	w.WriteHeader(http.StatusTooManyRequests)
 
-	// Send JSON error response consistent with OneBusAway API format
+	// Send an error response consistent with OneBusAway API format
 	errorResponse := map[string]interface{}{
 		"code": http.StatusTooManyRequests,
 		"text": "Rate limit exceeded. Please try again later.",
@@ -167,41 +271,79 @@ func (rl *RateLimitMiddleware) sendRateLimitExceeded(w http.ResponseWriter, r *h
 		"currentTime": rl.clock.Now().UnixMilli(),
 		"version":     2,
 	}
+	if reqID := RequestIDFromContext(r.Context()); reqID != "" {
+		errorResponse["requestId"] = reqID
+	}
 
-	if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
+	var err error
+	if wantsXML(r) {
+		var body []byte
+		if body, err = json.Marshal(errorResponse); err == nil {
+			err = writeXMLResponse(w, body, errorResponse["data"])
+		}
+	} else {
+		err = json.NewEncoder(w).Encode(errorResponse)
+	}
+	if err != nil {
 		slog.Error("failed to encode rate limit response", "error", err)
 	}
 }
 
+// rateLimitIdleThreshold is how long a client must be idle before its limiter is evicted
+const rateLimitIdleThreshold = 10 * time.Minute
+
 // cleanup periodically removes old, unused limiters to prevent memory leaks
 func (rl *RateLimitMiddleware) cleanup() {
-	// Define how long a client must be idle before eviction
-	threshold := 10 * time.Minute
-
 	for {
 		select {
 		case <-rl.cleanupTick.C:
-			rl.mu.Lock()
-			now := rl.clock.Now()
-
-			for key, client := range rl.limiters {
-				// Skip exempted keys
-				if !rl.exemptKeys[key] {
-					// using Time-Based Eviction (LRU)
-					// only delete if the client hasn't been seen in 10 minutes.
-					if now.Sub(client.lastSeen) > threshold {
-						delete(rl.limiters, key)
-					}
-				}
-			}
-
-			rl.mu.Unlock()
+			rl.evictIdle()
 		case <-rl.stopChan:
 			return
 		}
 	}
 }
 
+// evictIdle removes the limiters of clients idle for longer than rateLimitIdleThreshold
+// and returns how many were removed.
+func (rl *RateLimitMiddleware) evictIdle() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := rl.clock.Now()
+
+	evicted := 0
+	for key, client := range rl.limiters {
+		// Skip exempted keys
+		if !rl.exemptKeys[key] {
+			// using Time-Based Eviction (LRU)
+			// only delete if the client hasn't been seen in 10 minutes.
+			if now.Sub(client.lastSeen) > rateLimitIdleThreshold {
+				delete(rl.limiters, key)
+				evicted++
+			}
+		}
+	}
+
+	if rl.metrics != nil {
+		rl.metrics.RateLimitClients.Set(float64(len(rl.limiters)))
+		rl.metrics.RateLimitEvictionsTotal.Add(float64(evicted))
+	}
+	return evicted
+}
+
+// Flush removes every client's limiter, so each starts again with a full burst, and returns
+// how many were removed.
+func (rl *RateLimitMiddleware) Flush() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	flushed := len(rl.limiters)
+	clear(rl.limiters)
+	if rl.metrics != nil {
+		rl.metrics.RateLimitClients.Set(0)
+	}
+	return flushed
+}
+
 // Stop stops the cleanup goroutine. It is safe to call multiple times.
 // Note: This does not affect in-flight requests - it only stops the
 // background cleanup goroutine.
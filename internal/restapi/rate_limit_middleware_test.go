@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"maglev.onebusaway.org/internal/clock"
 )
 
@@ -309,6 +311,21 @@ func TestRateLimitMiddleware_ConcurrentRequests(t *testing.T) {
 	assert.Equal(t, 5, rateLimitedCount, "Should have exactly 5 rate limited requests")
 }
 
+func TestRateLimitMiddleware_RefillsOnItsClock(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	middleware := NewRateLimitMiddleware(2, time.Second, nil, mockClock)
+	defer middleware.Stop()
+
+	assert.True(t, middleware.Allow("test-key"))
+	assert.True(t, middleware.Allow("test-key"))
+	assert.False(t, middleware.Allow("test-key"))
+
+	mockClock.Advance(time.Second)
+	assert.True(t, middleware.Allow("test-key"))
+	assert.True(t, middleware.Allow("test-key"))
+	assert.False(t, middleware.Allow("test-key"))
+}
+
 func TestRateLimitMiddleware_RateLimitedResponseFormat(t *testing.T) {
 	mockClock := clock.NewMockClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
 	middleware := NewRateLimitMiddleware(1, time.Second, nil, mockClock)
@@ -435,3 +452,68 @@ func TestRateLimitMiddleware_EdgeCases(t *testing.T) {
 			"Empty API key should be handled gracefully")
 	})
 }
+
+func TestRateLimitMiddleware_Update(t *testing.T) {
+	middleware := initRateLimitMiddleware(1, time.Second)
+	defer middleware.Stop()
+
+	limitedHandler := middleware.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(w, httptest.NewRequest("GET", "/test?key="+key, nil))
+		return w
+	}
+
+	for _, key := range []string{"reloaded-key", "other-key"} {
+		assert.Equal(t, http.StatusOK, serve(key).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(key).Code)
+	}
+
+	middleware.Update(5, time.Second, []string{"reloaded-key"})
+
+	// Newly exempt keys are let through immediately
+	assert.Equal(t, http.StatusOK, serve("reloaded-key").Code)
+
+	// Existing limiters keep their spent tokens but report the new limit
+	w := serve("other-key")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "5", w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestRateLimitMiddleware_HeadersOnEveryResponse(t *testing.T) {
+	// One request every 10 seconds, with a burst of 3
+	middleware := initRateLimitMiddleware(3, 30*time.Second)
+	defer middleware.Stop()
+	middleware.Update(3, 30*time.Second, []string{"exempt-key"})
+
+	limitedHandler := middleware.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(w, httptest.NewRequest("GET", "/test?key="+key, nil))
+		return w
+	}
+
+	for i, remaining := range []string{"2", "1", "0"} {
+		w := serve("client-key")
+		assert.Equal(t, http.StatusOK, w.Code, "request %d", i)
+		assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, remaining, w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	// Throttled responses carry them too
+	w := serve("client-key")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(30*time.Second).Unix(), reset, 1, "three tokens take 30 seconds to refill")
+
+	// Exempt keys have no limit to report
+	w = serve("exempt-key")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
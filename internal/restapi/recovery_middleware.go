@@ -0,0 +1,95 @@
+package restapi
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+
+	"maglev.onebusaway.org/internal/errorreport"
+)
+
+// RecoverPanics turns a panicking handler into a 500 response with the standard JSON error
+// envelope instead of a dropped connection. The panic is logged with its stack and forwarded
+// to the error reporter, if one is configured. A handler that panics after it started writing
+// has its connection aborted, since the envelope can no longer be sent.
+func (api *RestAPI) RecoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracked := &startTrackingWriter{wrappedWriter: wrappedWriter{w}}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// net/http uses this sentinel to abort a response on purpose
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			err, ok := recovered.(error)
+			if !ok {
+				err = fmt.Errorf("%v", recovered)
+			}
+			stack := debug.Stack()
+			api.requestLogger(r).Error("panic serving request", "error", err, "path", r.URL.Path, "stack", string(stack))
+
+			event := errorreport.NewRequestEvent(r, err, http.StatusInternalServerError, RequestIDFromContext(r.Context()))
+			event.Panic = true
+			event.Stack = stack
+			api.reportEvent(event)
+
+			// Once the status line is out, an error body would corrupt the response the
+			// client is reading; abort the connection so it sees a failure instead
+			if tracked.started {
+				panic(http.ErrAbortHandler)
+			}
+			api.writeServerError(w, r)
+		}()
+
+		next.ServeHTTP(tracked, r)
+	})
+}
+
+// startTrackingWriter records whether the handler has begun sending its response.
+type startTrackingWriter struct {
+	wrappedWriter
+	started bool
+}
+
+func (w *startTrackingWriter) WriteHeader(code int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *startTrackingWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *startTrackingWriter) Flush() {
+	w.started = true
+	w.wrappedWriter.Flush()
+}
+
+// Hijack hands the connection to a WebSocket handler. After that a panic can no longer be
+// answered with a 500.
+func (w *startTrackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.started = true
+	return w.wrappedWriter.Hijack()
+}
+
+// reportError forwards a 5xx error to the error reporter, if one is configured.
+func (api *RestAPI) reportError(r *http.Request, err error, statusCode int) {
+	api.reportEvent(errorreport.NewRequestEvent(r, err, statusCode, RequestIDFromContext(r.Context())))
+}
+
+// reportEvent keeps event for the admin status page and forwards it to the error reporter.
+func (api *RestAPI) reportEvent(event errorreport.Event) {
+	if api.RecentErrors != nil {
+		api.RecentErrors.Report(event)
+	}
+	if api.ErrorReporter != nil {
+		api.ErrorReporter.Report(event)
+	}
+}
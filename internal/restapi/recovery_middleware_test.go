@@ -0,0 +1,113 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/errorreport"
+)
+
+// recordingReporter keeps reported events for inspection.
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []errorreport.Event
+}
+
+func (r *recordingReporter) Report(event errorreport.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) Close(context.Context) error { return nil }
+
+func TestRecoverPanics(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	reporter := &recordingReporter{}
+	api.ErrorReporter = reporter
+
+	handler := RequestIDMiddleware(api.RecoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map write")
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/api/where/stop/1.json?key=TEST", nil)
+	req.Header.Set("X-Request-ID", "panic-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "internal server error", body["text"])
+	assert.Equal(t, float64(http.StatusInternalServerError), body["code"])
+	assert.Equal(t, float64(1), body["version"])
+	assert.NotZero(t, body["currentTime"])
+	assert.Equal(t, "panic-1", body["requestId"])
+
+	require.Len(t, reporter.events, 1)
+	event := reporter.events[0]
+	assert.True(t, event.Panic)
+	assert.EqualError(t, event.Err, "nil map write")
+	assert.NotEmpty(t, event.Stack)
+	assert.Equal(t, "/api/where/stop/1.json", event.Path)
+	assert.Equal(t, "panic-1", event.RequestID)
+}
+
+func TestRecoverPanics_AbortHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	reporter := &recordingReporter{}
+	api.ErrorReporter = reporter
+
+	handler := api.RecoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Empty(t, reporter.events)
+}
+
+func TestRecoverPanics_AfterResponseStarted(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	reporter := &recordingReporter{}
+	api.ErrorReporter = reporter
+
+	handler := api.RecoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"partial":`))
+		panic("encoder failed")
+	}))
+	rec := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	// No error envelope is appended to the partial body, but the panic is still reported
+	assert.Equal(t, `{"partial":`, rec.Body.String())
+	require.Len(t, reporter.events, 1)
+	assert.True(t, reporter.events[0].Panic)
+}
+
+func TestServerErrorResponseReportsError(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	reporter := &recordingReporter{}
+	api.ErrorReporter = reporter
+
+	req := httptest.NewRequest(http.MethodGet, "/api/where/route/1.json?key=TEST", nil)
+	api.serverErrorResponse(httptest.NewRecorder(), req, errors.New("query failed"))
+
+	require.Len(t, reporter.events, 1)
+	assert.False(t, reporter.events[0].Panic)
+	assert.Equal(t, http.StatusInternalServerError, reporter.events[0].StatusCode)
+	assert.EqualError(t, reporter.events[0].Err, "query failed")
+}
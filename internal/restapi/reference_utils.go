@@ -15,7 +15,7 @@ func (api *RestAPI) BuildRouteReferences(ctx context.Context, agencyID string, s
 
 	for _, stop := range stops {
 		for _, routeID := range stop.StaticRouteIDs {
-			_, originalRouteID, err := utils.ExtractAgencyIDAndCodeID(routeID)
+			_, originalRouteID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(routeID)
 			if err != nil {
 				continue
 			}
@@ -71,7 +71,9 @@ func (api *RestAPI) BuildRouteReferencesAsInterface(ctx context.Context, agencyI
 	return routeRefs, nil
 }
 
-func (api *RestAPI) BuildSituationReferences(alerts []gtfs.Alert, agencyID string) []models.Situation {
+// BuildSituationReferences maps service alerts to situations, with their text in the first of
+// langs the alert is translated to.
+func (api *RestAPI) BuildSituationReferences(alerts []gtfs.Alert, agencyID string, langs []string) []models.Situation {
 	situations := make([]models.Situation, 0, len(alerts))
 
 	for _, alert := range alerts {
@@ -115,25 +117,16 @@ func (api *RestAPI) BuildSituationReferences(alerts []gtfs.Alert, agencyID strin
 			situation.AllAffects = append(situation.AllAffects, affectedEntity)
 		}
 
-		if len(alert.Header) > 0 && alert.Header[0].Text != "" {
-			situation.Summary = &models.TranslatedString{
-				Value: alert.Header[0].Text,
-				Lang:  alert.Header[0].Language,
-			}
+		if text, ok := alertText(alert.Header, langs); ok {
+			situation.Summary = &models.TranslatedString{Value: text.Text, Lang: text.Language}
 		}
 
-		if len(alert.Description) > 0 && alert.Description[0].Text != "" {
-			situation.Description = &models.TranslatedString{
-				Value: alert.Description[0].Text,
-				Lang:  alert.Description[0].Language,
-			}
+		if text, ok := alertText(alert.Description, langs); ok {
+			situation.Description = &models.TranslatedString{Value: text.Text, Lang: text.Language}
 		}
 
-		if len(alert.URL) > 0 && alert.URL[0].Text != "" {
-			situation.URL = &models.TranslatedString{
-				Value: alert.URL[0].Text,
-				Lang:  alert.URL[0].Language,
-			}
+		if text, ok := alertText(alert.URL, langs); ok {
+			situation.URL = &models.TranslatedString{Value: text.Text, Lang: text.Language}
 		}
 
 		situations = append(situations, situation)
@@ -204,3 +197,45 @@ func mapAlertEffectToSeverity(effect gtfs.AlertEffect) string {
 		return "noImpact"
 	}
 }
+
+// addSituationReferences adds the situations named by situationIDs to references, each once and
+// under the ID it was named by: an alert's own ID or the combined ID of its agency and its ID.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) addSituationReferences(references *models.ReferencesModel, situationIDs []string, langs []string) {
+	if len(situationIDs) == 0 {
+		return
+	}
+	seen := make(map[string]bool, len(references.Situations))
+	for _, ref := range references.Situations {
+		if situation, ok := ref.(models.Situation); ok {
+			seen[situation.ID] = true
+		}
+	}
+
+	alerts := make(map[string]gtfs.Alert)
+	for _, alert := range api.GtfsManager.GetRealTimeAlerts() {
+		if alert.ID != "" {
+			alerts[alert.ID] = alert
+		}
+	}
+
+	for _, situationID := range situationIDs {
+		if seen[situationID] {
+			continue
+		}
+		alert, ok := alerts[situationID]
+		if !ok {
+			if _, alertID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(situationID); err == nil {
+				alert, ok = alerts[alertID]
+			}
+		}
+		if !ok {
+			continue
+		}
+		seen[situationID] = true
+		for _, situation := range api.BuildSituationReferences([]gtfs.Alert{alert}, "", langs) {
+			situation.ID = situationID
+			references.Situations = append(references.Situations, situation)
+		}
+	}
+}
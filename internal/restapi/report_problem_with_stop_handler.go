@@ -27,7 +27,7 @@ func (api *RestAPI) reportProblemWithStopHandler(w http.ResponseWriter, r *http.
 	}
 
 	// Extract agency ID and stop ID from composite ID
-	_, stopID, err := utils.ExtractAgencyIDAndCodeID(compositeID)
+	_, stopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(compositeID)
 	if err != nil {
 		logger.Warn("report problem with stop failed: invalid stopID format",
 			slog.String("stopID", compositeID),
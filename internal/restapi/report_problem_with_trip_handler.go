@@ -27,7 +27,7 @@ func (api *RestAPI) reportProblemWithTripHandler(w http.ResponseWriter, r *http.
 	}
 
 	// Extract agency ID and trip ID from composite ID
-	_, tripID, err := utils.ExtractAgencyIDAndCodeID(compositeID)
+	_, tripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(compositeID)
 	if err != nil {
 		logger.Warn("report problem with trip failed: invalid tripID format",
 			slog.String("tripID", compositeID),
@@ -0,0 +1,58 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// RequestGuardMiddleware rejects requests exceeding the configured URL length, query parameter
+// count or ID length with a 400 validation error. It runs after routing, so the {id} path value
+// is available, and before authentication so oversized input is turned away as cheaply as possible.
+func (api *RestAPI) RequestGuardMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fieldErrors := api.checkRequestLimits(r); fieldErrors != nil {
+			api.validationErrorResponse(w, r, fieldErrors)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkRequestLimits returns the first limit r exceeds as field errors, or nil.
+func (api *RestAPI) checkRequestLimits(r *http.Request) map[string][]string {
+	limits := api.Config.RequestLimits
+
+	// Checked first so the query string below is bounded before it is parsed
+	if limits.MaxURLLength > 0 && len(r.URL.RequestURI()) > limits.MaxURLLength {
+		return map[string][]string{
+			"url": {fmt.Sprintf("url too long (max %d characters)", limits.MaxURLLength)},
+		}
+	}
+
+	if limits.MaxQueryParams > 0 && r.URL.RawQuery != "" {
+		// Malformed pairs are skipped here; handlers report them
+		query, _ := url.ParseQuery(r.URL.RawQuery)
+		count := 0
+		for _, values := range query {
+			count += len(values)
+		}
+		if count > limits.MaxQueryParams {
+			return map[string][]string{
+				"query": {fmt.Sprintf("too many query parameters (max %d)", limits.MaxQueryParams)},
+			}
+		}
+	}
+
+	if limits.MaxIDLength > 0 {
+		if id := utils.ExtractIDFromParams(r); len(id) > limits.MaxIDLength {
+			return map[string][]string{
+				"id": {fmt.Sprintf("id too long (max %d characters)", limits.MaxIDLength)},
+			}
+		}
+	}
+
+	return nil
+}
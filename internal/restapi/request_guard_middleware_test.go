@@ -0,0 +1,43 @@
+package restapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func TestRequestGuardMiddleware(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.RequestLimits = appconf.RequestLimitsConfig{MaxURLLength: 200, MaxQueryParams: 3, MaxIDLength: 20}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		field    string
+	}{
+		{"long url", "/api/where/current-time.json?key=TEST&pad=" + strings.Repeat("x", 200), "url"},
+		{"too many params", "/api/where/current-time.json?key=TEST&a=1&b=2&b=3", "query"},
+		{"long id", "/api/where/stop/" + strings.Repeat("1", 21) + ".json?key=TEST", "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, model := serveApiAndRetrieveEndpoint(t, api, tt.endpoint)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, http.StatusBadRequest, model.Code)
+
+			data, ok := model.Data.(map[string]interface{})
+			require.True(t, ok)
+			fieldErrors := data["fieldErrors"].(map[string]interface{})
+			assert.Contains(t, fieldErrors, tt.field)
+		})
+	}
+
+	// Requests within every limit reach the handler
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST&a=1&b=2")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -14,6 +14,12 @@ const RequestIDKey contextKey = "request_id"
 
 var validRequestIDRegex = regexp.MustCompile(`^[a-zA-Z0-9-._:]+$`)
 
+// RequestIDFromContext returns the ID assigned by RequestIDMiddleware, or "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	reqID, _ := ctx.Value(RequestIDKey).(string)
+	return reqID
+}
+
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		reqID := r.Header.Get("X-Request-ID")
@@ -1,12 +1,17 @@
 package restapi
 
 import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/logging"
 )
 
 func TestRequestIDMiddleware(t *testing.T) {
@@ -84,3 +89,49 @@ func TestRequestIDMiddleware(t *testing.T) {
 		}
 	})
 }
+
+func TestRequestIDCorrelation(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	var logs bytes.Buffer
+	api.Logger = logging.NewStructuredLogger(&logs, slog.LevelInfo)
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	handler := RequestIDMiddleware(NewRequestLoggingMiddleware(api.Logger)(mux))
+
+	t.Run("error responses echo the request ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/where/current-time.json?key=not-a-key", nil)
+		req.Header.Set("X-Request-ID", "support-ticket-42")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, "support-ticket-42", body["requestId"])
+	})
+
+	t.Run("successful responses do not", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/where/current-time.json?key=TEST", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "requestId")
+		assert.NotEmpty(t, rec.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("request-scoped logger carries the request ID", func(t *testing.T) {
+		logs.Reset()
+		inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logging.FromContext(r.Context()).Info("handler_log")
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-ID", "trace-7")
+		RequestIDMiddleware(NewRequestLoggingMiddleware(api.Logger)(inner)).ServeHTTP(httptest.NewRecorder(), req)
+
+		for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+			assert.Contains(t, line, `"request_id":"trace-7"`)
+		}
+	})
+}
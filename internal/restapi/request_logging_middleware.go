@@ -1,7 +1,9 @@
 package restapi
 
 import (
+	"bufio"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
@@ -10,7 +12,7 @@ import (
 
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
-	http.ResponseWriter
+	wrappedWriter
 	statusCode int
 }
 
@@ -19,20 +21,34 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Hijack records a hijacked connection as switching protocols.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := rw.wrappedWriter.Hijack()
+	if err == nil {
+		rw.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
 // NewRequestLoggingMiddleware creates middleware that logs HTTP requests
 func NewRequestLoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			reqID := RequestIDFromContext(r.Context())
 
-			// Add logger to context for downstream handlers
-			ctx := logging.WithLogger(r.Context(), logger)
+			// Add logger to context for downstream handlers, tagged so their entries can be correlated
+			requestLogger := logger
+			if reqID != "" {
+				requestLogger = logger.With(slog.String("request_id", reqID))
+			}
+			ctx := logging.WithLogger(r.Context(), requestLogger)
 			r = r.WithContext(ctx)
 
 			// Wrap response writer to capture status code
 			wrapped := &responseWriter{
-				ResponseWriter: w,
-				statusCode:     http.StatusOK, // Default status
+				wrappedWriter: wrappedWriter{w},
+				statusCode:    http.StatusOK, // Default status
 			}
 
 			// Call next handler
@@ -41,8 +57,6 @@ func NewRequestLoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Ha
 			// Log the request
 			duration := time.Since(start)
 
-			reqID, _ := r.Context().Value(RequestIDKey).(string)
-
 			logging.LogHTTPRequest(logger,
 				r.Method,
 				r.URL.Path,
@@ -0,0 +1,214 @@
+package restapi
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// responseCacheHeader reports whether a response was served from the response cache.
+const responseCacheHeader = "X-Cache"
+
+// ResponseCache keeps rendered responses of static-data endpoints in memory, keyed by
+// normalized URL. The whole cache is dropped when datasetVersion changes, so responses
+// never outlive the GTFS dataset they were rendered from.
+type ResponseCache struct {
+	mu             sync.Mutex
+	entries        map[string]cachedResponse
+	maxEntries     int
+	ttls           map[string]time.Duration
+	clock          clock.Clock
+	datasetVersion func() time.Time
+	version        time.Time
+}
+
+type cachedResponse struct {
+	contentType string
+	etag        string // Set with canonical-json
+	body        []byte
+	expires     time.Time
+}
+
+// NewResponseCache creates a cache holding at most cfg.MaxEntries responses. datasetVersion
+// returns the load time of the current static dataset.
+func NewResponseCache(cfg appconf.ResponseCacheConfig, c clock.Clock, datasetVersion func() time.Time) *ResponseCache {
+	ttls := make(map[string]time.Duration, len(appconf.ResponseCacheGroups))
+	for _, group := range appconf.ResponseCacheGroups {
+		ttls[group] = time.Duration(models.CacheDurationLong) * time.Second
+	}
+	for group, seconds := range cfg.TTLs {
+		ttls[group] = time.Duration(seconds) * time.Second
+	}
+
+	return &ResponseCache{
+		entries:        make(map[string]cachedResponse),
+		maxEntries:     cfg.MaxEntries,
+		ttls:           ttls,
+		clock:          c,
+		datasetVersion: datasetVersion,
+	}
+}
+
+// get returns the cached response for key, discarding every entry first if the dataset changed.
+func (c *ResponseCache) get(key string) (cachedResponse, bool) {
+	version := c.datasetVersion()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !version.Equal(c.version) {
+		clear(c.entries)
+		c.version = version
+	}
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return cachedResponse{}, false
+	}
+	if !c.clock.Now().Before(entry.expires) {
+		delete(c.entries, key)
+		return cachedResponse{}, false
+	}
+	return entry, true
+}
+
+// put stores a response rendered under the given dataset version. Responses rendered
+// from a dataset that has since been replaced are dropped.
+func (c *ResponseCache) put(key string, entry cachedResponse, version time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !version.Equal(c.version) {
+		return
+	}
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evictLocked()
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+	c.entries[key] = entry
+}
+
+// evictLocked removes expired entries, or one arbitrary entry if none have expired.
+func (c *ResponseCache) evictLocked() {
+	now := c.clock.Now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
+
+// Flush removes every cached response and returns how many were dropped.
+func (c *ResponseCache) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.entries)
+	clear(c.entries)
+	return n
+}
+
+// Len returns the number of cached responses.
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// responseCacheKey normalizes the request URL: query parameters are sorted and the
+// API key is dropped, since responses do not depend on the caller beyond whether it has a
+// bulk key, which raises page size limits. Accept-Language is kept, since alert text is
+// translated by it.
+func responseCacheKey(r *http.Request, bulk bool) string {
+	query := r.URL.Query()
+	query.Del("key")
+	key := r.URL.Path + "?" + query.Encode()
+	if lang := r.Header.Get("Accept-Language"); lang != "" {
+		key += "\n" + lang
+	}
+	if bulk {
+		key += "\nbulk"
+	}
+	return key
+}
+
+// cacheResponses serves successful responses of a route group from the response cache.
+// Returns next unchanged if the cache is disabled or the group's TTL is zero.
+func (api *RestAPI) cacheResponses(group string, next handlerFunc) handlerFunc {
+	cache := api.responseCache
+	if cache == nil || cache.ttls[group] <= 0 {
+		return next
+	}
+	ttl := cache.ttls[group]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := responseCacheKey(r, api.RequestHasBulkAPIKey(r))
+		if entry, ok := cache.get(key); ok {
+			w.Header().Set(responseCacheHeader, "HIT")
+			if entry.etag != "" {
+				w.Header().Set("ETag", entry.etag)
+				if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+			w.Header().Set("Content-Type", entry.contentType)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(entry.body)
+			return
+		}
+
+		// Capture the version before rendering so a swap during the request isn't cached as new data
+		version := cache.datasetVersion()
+		w.Header().Set(responseCacheHeader, "MISS")
+		recorder := &responseCacheWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(recorder, r)
+
+		if recorder.statusCode == http.StatusOK {
+			cache.put(key, cachedResponse{
+				contentType: w.Header().Get("Content-Type"),
+				etag:        w.Header().Get("ETag"),
+				body:        recorder.body.Bytes(),
+				expires:     cache.clock.Now().Add(ttl),
+			}, version)
+		}
+	}
+}
+
+// responseCacheWriter passes the response through while keeping a copy of the body.
+type responseCacheWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *responseCacheWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.statusCode = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseCacheWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
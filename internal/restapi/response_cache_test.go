@@ -0,0 +1,233 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+// newResponseCacheTestAPI returns an API whose cache reads the dataset version from *version.
+func newResponseCacheTestAPI(cfg appconf.ResponseCacheConfig, c clock.Clock, version *time.Time) *RestAPI {
+	api := &RestAPI{Application: &app.Application{Clock: c}}
+	api.responseCache = NewResponseCache(cfg, c, func() time.Time { return *version })
+	return api
+}
+
+func serveCached(handler handlerFunc, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestResponseCache_HitMissAndExpiry(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	version := mockClock.Now()
+	api := newResponseCacheTestAPI(appconf.ResponseCacheConfig{
+		MaxEntries: 10,
+		TTLs:       map[string]int{appconf.ResponseCacheGroupRoutes: 60},
+	}, mockClock, &version)
+
+	calls := 0
+	handler := api.cacheResponses(appconf.ResponseCacheGroupRoutes, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200}`))
+	})
+
+	rec := serveCached(handler, "/api/where/routes-for-agency/1.json?key=a")
+	assert.Equal(t, "MISS", rec.Header().Get(responseCacheHeader))
+
+	// Same URL with another key and reordered parameters is served from the cache
+	rec = serveCached(handler, "/api/where/routes-for-agency/1.json?version=2&key=b")
+	assert.Equal(t, "MISS", rec.Header().Get(responseCacheHeader))
+	rec = serveCached(handler, "/api/where/routes-for-agency/1.json?key=c&version=2")
+	assert.Equal(t, "HIT", rec.Header().Get(responseCacheHeader))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `{"code":200}`, rec.Body.String())
+	assert.Equal(t, 2, calls)
+
+	mockClock.Advance(61 * time.Second)
+	rec = serveCached(handler, "/api/where/routes-for-agency/1.json?key=a")
+	assert.Equal(t, "MISS", rec.Header().Get(responseCacheHeader))
+	assert.Equal(t, 3, calls)
+}
+
+func TestResponseCache_InvalidatedOnDatasetSwap(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	version := mockClock.Now()
+	api := newResponseCacheTestAPI(appconf.ResponseCacheConfig{MaxEntries: 10}, mockClock, &version)
+
+	body := "old"
+	handler := api.cacheResponses(appconf.ResponseCacheGroupStops, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})
+
+	serveCached(handler, "/api/where/stop/1.json")
+	require.Equal(t, 1, api.responseCache.Len())
+
+	version = version.Add(time.Hour)
+	body = "new"
+	rec := serveCached(handler, "/api/where/stop/1.json")
+	assert.Equal(t, "MISS", rec.Header().Get(responseCacheHeader))
+	assert.Equal(t, "new", rec.Body.String())
+
+	rec = serveCached(handler, "/api/where/stop/1.json")
+	assert.Equal(t, "HIT", rec.Header().Get(responseCacheHeader))
+	assert.Equal(t, "new", rec.Body.String())
+}
+
+func TestResponseCache_SkipsErrorsAndRespectsMaxEntries(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	version := mockClock.Now()
+	api := newResponseCacheTestAPI(appconf.ResponseCacheConfig{MaxEntries: 2}, mockClock, &version)
+
+	handler := api.cacheResponses(appconf.ResponseCacheGroupShapes, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("shape"))
+	})
+
+	serveCached(handler, "/missing")
+	assert.Equal(t, 0, api.responseCache.Len())
+
+	serveCached(handler, "/a")
+	serveCached(handler, "/b")
+	serveCached(handler, "/c")
+	assert.Equal(t, 2, api.responseCache.Len())
+}
+
+func TestResponseCache_DisabledGroup(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	version := mockClock.Now()
+	api := newResponseCacheTestAPI(appconf.ResponseCacheConfig{
+		MaxEntries: 10,
+		TTLs:       map[string]int{appconf.ResponseCacheGroupAgencies: 0},
+	}, mockClock, &version)
+
+	handler := api.cacheResponses(appconf.ResponseCacheGroupAgencies, func(w http.ResponseWriter, r *http.Request) {})
+	rec := serveCached(handler, "/api/where/agency/1.json")
+	assert.Empty(t, rec.Header().Get(responseCacheHeader))
+}
+
+func TestResponseCache_Routes(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.responseCache = NewResponseCache(appconf.ResponseCacheConfig{MaxEntries: 100}, api.Clock, api.staticDatasetVersion)
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	first := httptest.NewRecorder()
+	mux.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/api/where/agencies-with-coverage.json?key=TEST", nil))
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get(responseCacheHeader))
+
+	second := httptest.NewRecorder()
+	mux.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/api/where/agencies-with-coverage.json?key=test", nil))
+	require.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "HIT", second.Header().Get(responseCacheHeader))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+
+	// Invalid keys are still rejected before the cache is consulted
+	rejected := httptest.NewRecorder()
+	mux.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "/api/where/agencies-with-coverage.json?key=not-a-key", nil))
+	assert.Equal(t, http.StatusUnauthorized, rejected.Code)
+
+	// Realtime endpoints are never cached
+	current := httptest.NewRecorder()
+	mux.ServeHTTP(current, httptest.NewRequest(http.MethodGet, "/api/where/current-time.json?key=TEST", nil))
+	assert.Empty(t, current.Header().Get(responseCacheHeader))
+}
+
+func TestResponseCache_BulkKeysCachedSeparately(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.responseCache = NewResponseCache(appconf.ResponseCacheConfig{MaxEntries: 100}, api.Clock, api.staticDatasetVersion)
+	api.Config.BulkApiKeys = []string{"TEST"}
+	api.Config.Pagination = appconf.PaginationConfig{
+		appconf.PaginationClassList: {MaxCount: 2, BulkMaxCount: 6},
+	}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	serve := func(key string) (*httptest.ResponseRecorder, int) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/where/routes-for-agency/"+agencyID+".json?maxCount=100&key="+key, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var model struct {
+			Data struct {
+				List []any `json:"list"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &model))
+		return rec, len(model.Data.List)
+	}
+
+	// A bulk key's larger page is not served to ordinary keys
+	rec, count := serve("TEST")
+	assert.Equal(t, "MISS", rec.Header().Get(responseCacheHeader))
+	assert.Equal(t, 6, count)
+	rec, count = serve("test")
+	assert.Equal(t, "MISS", rec.Header().Get(responseCacheHeader))
+	assert.Equal(t, 2, count)
+
+	// Each is served from its own entry
+	rec, count = serve("TEST")
+	assert.Equal(t, "HIT", rec.Header().Get(responseCacheHeader))
+	assert.Equal(t, 6, count)
+	rec, count = serve("test")
+	assert.Equal(t, "HIT", rec.Header().Get(responseCacheHeader))
+	assert.Equal(t, 2, count)
+}
+
+func TestResponseCache_ConditionalRequests(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.responseCache = NewResponseCache(appconf.ResponseCacheConfig{MaxEntries: 100}, api.Clock, api.staticDatasetVersion)
+	api.Config.CanonicalJSON = true
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	serve := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/agencies-with-coverage.json?key=TEST", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := serve("")
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get(responseCacheHeader))
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	hit := serve("")
+	assert.Equal(t, http.StatusOK, hit.Code)
+	assert.Equal(t, "HIT", hit.Header().Get(responseCacheHeader))
+	assert.Equal(t, etag, hit.Header().Get("ETag"))
+	assert.Equal(t, first.Body.String(), hit.Body.String())
+
+	notModified := serve(etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Equal(t, "HIT", notModified.Header().Get(responseCacheHeader))
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+	assert.Empty(t, notModified.Body.String())
+
+	stale := serve(`"stale"`)
+	assert.Equal(t, http.StatusOK, stale.Code)
+	assert.Equal(t, first.Body.String(), stale.Body.String())
+}
@@ -0,0 +1,27 @@
+package restapi
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// wrappedWriter is embedded by the middleware writers that watch or adjust a response. Unwrap
+// lets http.ResponseController reach the writer it wraps, and Flush and Hijack pass on to it
+// for writers that check for http.Flusher and http.Hijacker instead, like gzhttp's.
+type wrappedWriter struct {
+	http.ResponseWriter
+}
+
+func (w wrappedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w wrappedWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection to a WebSocket handler.
+func (w wrappedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
@@ -1,24 +1,48 @@
 package restapi
 
 import (
-	"encoding/json"
+	"encoding/xml"
+	"log/slog"
 	"net/http"
 
 	"maglev.onebusaway.org/internal/models"
 )
 
 func (api *RestAPI) sendResponse(w http.ResponseWriter, r *http.Request, response models.ResponseModel) {
-	setJSONResponseType(&w)
-	err := json.NewEncoder(w).Encode(response)
+	setResponseType(w, r)
+	if r.URL.Query().Get("includeReferences") == "false" {
+		omitReferences(response)
+	}
+	if api.Config.CanonicalJSON && !api.Config.JavaParity {
+		api.sendCanonicalResponse(w, r, response)
+		return
+	}
+	err := api.encodeResponse(w, r, response)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
-func (api *RestAPI) sendNull(w http.ResponseWriter, r *http.Request) { // nolint:unused
-	setJSONResponseType(&w)
-	_, err := w.Write([]byte("null"))
+// omitReferences empties the references of a list or entry response, for clients that pass
+// includeReferences=false because they look up agencies, routes, stops and situations elsewhere.
+func omitReferences(response models.ResponseModel) {
+	if data, ok := response.Data.(map[string]interface{}); ok {
+		if _, ok := data["references"]; ok {
+			data["references"] = models.NewEmptyReferences()
+		}
+	}
+}
+
+// sendNull answers with a bare null, as the Java server does for some unknown IDs: the JSON
+// literal, or on .xml routes the <null/> element its XML serializer writes for one.
+func (api *RestAPI) sendNull(w http.ResponseWriter, r *http.Request) {
+	setResponseType(w, r)
+	body := "null"
+	if wantsXML(r) {
+		body = xml.Header + "<null/>"
+	}
+	_, err := w.Write([]byte(body))
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -26,7 +50,7 @@ func (api *RestAPI) sendNull(w http.ResponseWriter, r *http.Request) { // nolint
 }
 
 func (api *RestAPI) sendNotFound(w http.ResponseWriter, r *http.Request) {
-	setJSONResponseType(&w)
+	setResponseType(w, r)
 	w.WriteHeader(http.StatusNotFound)
 
 	response := models.ResponseModel{
@@ -34,17 +58,29 @@ func (api *RestAPI) sendNotFound(w http.ResponseWriter, r *http.Request) {
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
 		Text:        "resource not found",
 		Version:     2,
+		RequestID:   RequestIDFromContext(r.Context()),
 	}
 
-	err := json.NewEncoder(w).Encode(response)
+	err := api.encodeResponse(w, r, response)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
+// sendUnknownID answers a request for an agency, route, stop or trip the feed does not have.
+// That is a 404 from every endpoint, unless the config's JavaNotFound holds, when javaResponse,
+// if there is one, answers as the Java server does for the endpoint instead.
+func (api *RestAPI) sendUnknownID(w http.ResponseWriter, r *http.Request, javaResponse http.HandlerFunc) {
+	if javaResponse != nil && api.Config.JavaNotFound() {
+		javaResponse(w, r)
+		return
+	}
+	api.sendNotFound(w, r)
+}
+
 func (api *RestAPI) sendUnauthorized(w http.ResponseWriter, r *http.Request) { // nolint:unused
-	setJSONResponseType(&w)
+	setResponseType(w, r)
 	w.WriteHeader(http.StatusUnauthorized)
 
 	response := models.ResponseModel{
@@ -52,21 +88,40 @@ func (api *RestAPI) sendUnauthorized(w http.ResponseWriter, r *http.Request) { /
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
 		Text:        "permission denied",
 		Version:     1,
+		RequestID:   RequestIDFromContext(r.Context()),
 	}
 
-	err := json.NewEncoder(w).Encode(response)
+	err := api.encodeResponse(w, r, response)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
+// requestLogger returns api.Logger tagged with the request's ID, if it has one.
+func (api *RestAPI) requestLogger(r *http.Request) *slog.Logger {
+	if reqID := RequestIDFromContext(r.Context()); reqID != "" {
+		return api.Logger.With(slog.String("request_id", reqID))
+	}
+	return api.Logger
+}
+
 func setJSONResponseType(w *http.ResponseWriter) {
 	(*w).Header().Set("Content-Type", "application/json")
 }
 
+// setResponseType sets the content type of a response envelope to XML or JSON, as the request
+// asked for.
+func setResponseType(w http.ResponseWriter, r *http.Request) {
+	if wantsXML(r) {
+		w.Header().Set("Content-Type", "application/xml")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+}
+
 func (api *RestAPI) sendError(w http.ResponseWriter, r *http.Request, code int, message string) {
-	setJSONResponseType(&w)
+	setResponseType(w, r)
 	w.WriteHeader(code)
 
 	response := models.ResponseModel{
@@ -74,9 +129,10 @@ func (api *RestAPI) sendError(w http.ResponseWriter, r *http.Request, code int,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
 		Text:        message,
 		Version:     2,
+		RequestID:   RequestIDFromContext(r.Context()),
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	if err := api.encodeResponse(w, r, response); err != nil {
 		api.serverErrorResponse(w, r, err)
 	}
 }
@@ -2,12 +2,14 @@ package restapi
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 )
 
@@ -81,6 +83,17 @@ func TestSendNull(t *testing.T) {
 		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 		assert.Equal(t, "null", w.Body.String())
 	})
+
+	t.Run("sends XML null on .xml routes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/test.xml", nil)
+
+		api.sendNull(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+		assert.Equal(t, xml.Header+"<null/>", w.Body.String())
+	})
 }
 
 func TestSendNotFound(t *testing.T) {
@@ -120,6 +133,56 @@ func TestSendNotFound(t *testing.T) {
 	})
 }
 
+func TestSendUnknownID(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	defer func() { api.Config.NotFoundResponses = "" }()
+
+	endpoints := []struct {
+		path string
+		java string // What the Java server answers instead of a 404
+	}{
+		{"/api/where/agency/unknown.json", ""},
+		{"/api/where/routes-for-agency/unknown.json", "null"},
+		{"/api/where/route-ids-for-agency/unknown.json", "null"},
+		{"/api/where/stops-for-agency/unknown.json", "null"},
+		{"/api/where/stop-ids-for-agency/unknown.json", "null"},
+		{"/api/where/vehicles-for-agency/unknown.json", "list"},
+		{"/api/where/trips-for-route/25_unknown.json", "list"},
+		{"/api/where/route/25_unknown.json", ""},
+		{"/api/where/stop/25_unknown.json", ""},
+		{"/api/where/trip/25_unknown.json", ""},
+	}
+
+	for _, mode := range []string{appconf.NotFoundConsistent, appconf.NotFoundJava} {
+		api.Config.NotFoundResponses = mode
+		for _, endpoint := range endpoints {
+			t.Run(mode+endpoint.path, func(t *testing.T) {
+				rec := serveSiri(t, api, endpoint.path+"?key="+siriTestKey)
+				want := endpoint.java
+				if mode == appconf.NotFoundConsistent {
+					want = ""
+				}
+				switch want {
+				case "null":
+					assert.Equal(t, http.StatusOK, rec.Code)
+					assert.Equal(t, "null", rec.Body.String())
+				case "list":
+					assert.Equal(t, http.StatusOK, rec.Code)
+					var response models.ResponseModel
+					require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+					assert.Empty(t, response.Data.(map[string]interface{})["list"])
+				default:
+					assert.Equal(t, http.StatusNotFound, rec.Code)
+					var response models.ResponseModel
+					require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+					assert.Equal(t, "resource not found", response.Text)
+				}
+			})
+		}
+	}
+}
+
 func TestSendUnauthorized(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
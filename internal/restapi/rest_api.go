@@ -1,22 +1,83 @@
 package restapi
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
 )
 
 type RestAPI struct {
 	*app.Application
-	rateLimiter *RateLimitMiddleware
+	rateLimiter   *RateLimitMiddleware
+	usageTracker  *APIKeyUsageTracker
+	responseCache *ResponseCache
+
+	// Keyed by route group; groups without a configured limit are absent
+	concurrencyLimiters map[string]*ConcurrencyLimiter
+
+	// Set while an admin-triggered refresh runs in the background
+	staticRefreshRunning   atomic.Bool
+	realtimeRefreshRunning atomic.Bool
+
+	// Serializes configuration reloads
+	reloadMu sync.Mutex
+
+	// When each current service alert was first served in an alert feed
+	alertsFirstSeen   map[string]time.Time
+	alertsFirstSeenMu sync.Mutex
+
+	// Signatures already accepted, until their timestamp leaves the clock skew window
+	usedSignatures          map[string]time.Time
+	usedSignaturesMu        sync.Mutex
+	usedSignaturesNextPrune time.Time
+
+	// The stops followed over the arrivals WebSocket
+	arrivalSubscriptions *arrivalSubscriptions
+
+	// The public stats, reused until they expire
+	systemStatsCached  models.SystemStats
+	systemStatsExpires time.Time
+	systemStatsMu      sync.Mutex
 }
 
 // NewRestAPI creates a new RestAPI instance with initialized rate limiter
 func NewRestAPI(app *app.Application) *RestAPI {
-	return &RestAPI{
+	if app.WallClock == nil {
+		app.WallClock = clock.RealClock{}
+	}
+	api := &RestAPI{
 		Application: app,
-		rateLimiter: NewRateLimitMiddleware(app.Config.RateLimit, time.Second, app.Config.ExemptApiKeys, app.Clock),
+		// Rate limits meter real traffic, so they run on wall-clock time even with fake-time set
+		rateLimiter:  NewRateLimitMiddleware(app.Config.RateLimit, time.Second, app.Config.ExemptApiKeys, app.WallClock),
+		usageTracker: NewAPIKeyUsageTracker(app.Clock),
+	}
+	api.arrivalSubscriptions = newArrivalSubscriptions(api)
+	if app.Config.ResponseCache.Enabled() {
+		api.responseCache = NewResponseCache(app.Config.ResponseCache, app.Clock, api.staticDatasetVersion)
+	}
+	if app.Notifications != nil {
+		app.Notifications.SetEstimator(api.estimateArrival)
+	}
+	if app.Metrics != nil {
+		api.rateLimiter.SetMetrics(app.Metrics)
+	}
+	api.concurrencyLimiters = make(map[string]*ConcurrencyLimiter, len(app.Config.ConcurrencyLimits))
+	for group, limit := range app.Config.ConcurrencyLimits {
+		api.concurrencyLimiters[group] = NewConcurrencyLimiter(limit)
+	}
+	return api
+}
+
+// staticDatasetVersion identifies the static GTFS dataset currently being served.
+func (api *RestAPI) staticDatasetVersion() time.Time {
+	if api.GtfsManager == nil {
+		return time.Time{}
 	}
+	return api.GtfsManager.LastStaticUpdate()
 }
 
 // Shutdown gracefully stops the RestAPI resources
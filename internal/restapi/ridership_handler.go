@@ -0,0 +1,223 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/ridership"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// maxRidershipImportSize caps the body of a GTFS-ride import.
+const maxRidershipImportSize = 256 << 20
+
+// adminRidershipImportHandler stores the counts in a GTFS-ride zip, or a board_alight.txt file,
+// sent as the request body. The trips are matched to routes in the current static schedule.
+func (api *RestAPI) adminRidershipImportHandler(w http.ResponseWriter, r *http.Request) {
+	if api.Ridership == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "ridership not enabled")
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRidershipImportSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			api.sendError(w, r, http.StatusRequestEntityTooLarge, "GTFS-ride file too large")
+			return
+		}
+		api.sendError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	result, err := api.Ridership.Import(r.Context(), data, func(ctx context.Context, tripID string) (string, string, bool) {
+		api.GtfsManager.RLock()
+		defer api.GtfsManager.RUnlock()
+		trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripID)
+		if err != nil {
+			return "", "", false
+		}
+		route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, trip.RouteID)
+		if err != nil {
+			return "", "", false
+		}
+		return route.AgencyID, route.ID, true
+	})
+	if err != nil {
+		if r.Context().Err() != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		api.validationErrorResponse(w, r, map[string][]string{"body": {err.Error()}})
+		return
+	}
+
+	api.requestLogger(r).Info("imported ridership",
+		"records", result.Records, "invalid", result.Invalid, "unmatchedTrips", result.UnmatchedTrips)
+	entry := models.RidershipImport{Records: result.Records, Invalid: result.Invalid, UnmatchedTrips: result.UnmatchedTrips}
+	api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock))
+}
+
+// parseRidershipDates reads the optional startDate and endDate (YYYY-MM-DD) into filter.
+func parseRidershipDates(query url.Values, filter *ridership.Filter) map[string][]string {
+	for _, p := range []struct {
+		name string
+		into *string
+	}{{"startDate", &filter.StartDate}, {"endDate", &filter.EndDate}} {
+		value := query.Get(p.name)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return map[string][]string{p.name: {"invalid date format, use YYYY-MM-DD"}}
+		}
+		*p.into = date.Format("20060102")
+	}
+	if filter.StartDate != "" && filter.EndDate != "" && filter.EndDate < filter.StartDate {
+		return map[string][]string{"endDate": {"endDate must not be before startDate"}}
+	}
+	return nil
+}
+
+// ridershipForRouteHandler totals a route's imported counts, by day, stop and trip.
+func (api *RestAPI) ridershipForRouteHandler(w http.ResponseWriter, r *http.Request) {
+	api.serveRidership(w, r, func(ctx context.Context, codeID string) (ridership.Filter, bool) {
+		_, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, codeID)
+		return ridership.Filter{RouteID: codeID}, err == nil
+	}, ridership.GroupStop, ridership.GroupTrip)
+}
+
+// ridershipForStopHandler totals a stop's imported counts, by day and route.
+func (api *RestAPI) ridershipForStopHandler(w http.ResponseWriter, r *http.Request) {
+	api.serveRidership(w, r, func(ctx context.Context, codeID string) (ridership.Filter, bool) {
+		_, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, codeID)
+		return ridership.Filter{StopID: codeID}, err == nil
+	}, ridership.GroupRoute)
+}
+
+// ridershipForTripHandler totals a trip's imported counts, by day and at each of its stops.
+func (api *RestAPI) ridershipForTripHandler(w http.ResponseWriter, r *http.Request) {
+	api.serveRidership(w, r, func(ctx context.Context, codeID string) (ridership.Filter, bool) {
+		_, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, codeID)
+		return ridership.Filter{TripID: codeID}, err == nil
+	})
+}
+
+// serveRidership answers with the ridership the request's ID selects. lookup, called under the
+// manager's read lock, builds the filter for the ID without its agency and reports whether the
+// static schedule has it. The entry is broken down by day and by each of breakdowns; without
+// breakdowns, it lists the stops of a trip.
+func (api *RestAPI) serveRidership(w http.ResponseWriter, r *http.Request, lookup func(context.Context, string) (ridership.Filter, bool), breakdowns ...string) {
+	if api.Ridership == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "ridership not enabled")
+		return
+	}
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	agencyID, codeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+
+	ctx := r.Context()
+	api.GtfsManager.RLock()
+	filter, found := lookup(ctx, codeID)
+	api.GtfsManager.RUnlock()
+	if !found {
+		api.sendNotFound(w, r)
+		return
+	}
+	if fieldErrors := parseRidershipDates(r.URL.Query(), &filter); len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	groups := make(map[string][]models.RidershipBucket)
+	for _, group := range append([]string{ridership.GroupNone, ridership.GroupDay}, breakdowns...) {
+		buckets, err := api.Ridership.Summary(ctx, filter, group)
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		groups[group] = make([]models.RidershipBucket, 0, len(buckets))
+		for _, bucket := range buckets {
+			key := bucket.Key
+			switch group {
+			case ridership.GroupDay:
+				if day, err := time.Parse("20060102", key); err == nil {
+					key = day.Format("2006-01-02")
+				}
+			case ridership.GroupRoute, ridership.GroupStop, ridership.GroupTrip:
+				// Trips missing from the schedule at import have no route
+				if key != "" {
+					key = utils.FormCombinedID(agencyID, key)
+				}
+			}
+			groups[group] = append(groups[group], models.RidershipBucket{
+				ID:             key,
+				RidershipStats: ridershipStats(bucket.Boardings, bucket.Alightings, bucket.ServiceDays, bucket.MaxLoad),
+			})
+		}
+	}
+
+	entry := models.RidershipSummary{
+		ID:        id,
+		StartDate: r.URL.Query().Get("startDate"),
+		EndDate:   r.URL.Query().Get("endDate"),
+		Summary:   groups[ridership.GroupNone][0].RidershipStats,
+		ByDay:     groups[ridership.GroupDay],
+		ByRoute:   []models.RidershipBucket{},
+		ByStop:    []models.RidershipBucket{},
+		ByTrip:    []models.RidershipBucket{},
+		Stops:     []models.RidershipStop{},
+	}
+	if buckets, ok := groups[ridership.GroupRoute]; ok {
+		entry.ByRoute = buckets
+	}
+	if buckets, ok := groups[ridership.GroupStop]; ok {
+		entry.ByStop = buckets
+	}
+	if buckets, ok := groups[ridership.GroupTrip]; ok {
+		entry.ByTrip = buckets
+	}
+	if len(breakdowns) == 0 {
+		stops, err := api.Ridership.LoadProfile(ctx, filter)
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		for _, stop := range stops {
+			entry.Stops = append(entry.Stops, models.RidershipStop{
+				StopID:         utils.FormCombinedID(agencyID, stop.StopID),
+				StopSequence:   stop.StopSequence,
+				AverageLoad:    math.Round(stop.AverageLoad*10) / 10,
+				RidershipStats: ridershipStats(stop.Boardings, stop.Alightings, stop.ServiceDays, stop.MaxLoad),
+			})
+		}
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock))
+}
+
+func ridershipStats(boardings, alightings, serviceDays, maxLoad int64) models.RidershipStats {
+	stats := models.RidershipStats{
+		Boardings:   boardings,
+		Alightings:  alightings,
+		ServiceDays: serviceDays,
+		MaxLoad:     maxLoad,
+	}
+	if serviceDays > 0 {
+		stats.AverageDailyBoardings = math.Round(float64(boardings)/float64(serviceDays)*10) / 10
+	}
+	return stats
+}
@@ -0,0 +1,115 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/ridership"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestRidershipHandlers(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"admin-secret"}
+
+	importCounts := func(t *testing.T, body string) (int, models.RidershipImport) {
+		mux := http.NewServeMux()
+		api.SetRoutes(mux)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/admin/ridership/import?key=admin-secret", strings.NewReader(body)))
+		var response struct {
+			Data struct {
+				Entry models.RidershipImport `json:"entry"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		return rec.Code, response.Data.Entry
+	}
+	serve := func(t *testing.T, target string) (int, models.RidershipSummary) {
+		rec := serveSiri(t, api, target)
+		var response struct {
+			Data struct {
+				Entry models.RidershipSummary `json:"entry"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		return rec.Code, response.Data.Entry
+	}
+
+	t.Run("not enabled", func(t *testing.T) {
+		code, _ := importCounts(t, "")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	store, err := ridership.New(appconf.RidershipConfig{DataPath: ":memory:"}, nil)
+	require.NoError(t, err)
+	defer store.Shutdown()
+	api.Ridership = store
+
+	// Four passengers board at the first stop of a trip on two days, and get off at the second
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	trip := api.GtfsManager.GetStaticData().Trips[0]
+	require.GreaterOrEqual(t, len(trip.StopTimes), 2)
+	first, second := trip.StopTimes[0], trip.StopTimes[1]
+	var counts strings.Builder
+	counts.WriteString("trip_id,stop_id,stop_sequence,record_use,boardings,alightings,current_load,service_date\n")
+	for _, date := range []string{"20250610", "20250611"} {
+		fmt.Fprintf(&counts, "%s,%s,%d,0,4,0,4,%s\n", trip.ID, first.Stop.Id, first.StopSequence, date)
+		fmt.Fprintf(&counts, "%s,%s,%d,0,0,4,0,%s\n", trip.ID, second.Stop.Id, second.StopSequence, date)
+	}
+	counts.WriteString("unknown-trip,S1,1,0,1,0,1,20250610\n")
+
+	code, result := importCounts(t, counts.String())
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.RidershipImport{Records: 5, UnmatchedTrips: 1}, result)
+
+	code, _ = importCounts(t, "stop_id\nS1\n")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	routeID := utils.FormCombinedID(agencyID, trip.Route.Id)
+	t.Run("route", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/ridership-for-route/"+routeID+".json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, models.RidershipStats{Boardings: 8, Alightings: 8, ServiceDays: 2, AverageDailyBoardings: 4, MaxLoad: 4}, entry.Summary)
+		require.Len(t, entry.ByDay, 2)
+		assert.Equal(t, "2025-06-10", entry.ByDay[0].ID)
+		assert.Len(t, entry.ByStop, 2)
+		require.Len(t, entry.ByTrip, 1)
+		assert.Equal(t, utils.FormCombinedID(agencyID, trip.ID), entry.ByTrip[0].ID)
+		assert.Empty(t, entry.Stops)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/ridership-for-stop/"+utils.FormCombinedID(agencyID, first.Stop.Id)+".json?key="+siriTestKey+"&startDate=2025-06-11")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "2025-06-11", entry.StartDate)
+		assert.Equal(t, int64(4), entry.Summary.Boardings)
+		require.Len(t, entry.ByRoute, 1)
+		assert.Equal(t, routeID, entry.ByRoute[0].ID)
+	})
+
+	t.Run("trip", func(t *testing.T) {
+		code, entry := serve(t, "/api/where/ridership-for-trip/"+utils.FormCombinedID(agencyID, trip.ID)+".json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, entry.Stops, 2)
+		assert.Equal(t, utils.FormCombinedID(agencyID, first.Stop.Id), entry.Stops[0].StopID)
+		assert.Equal(t, 4.0, entry.Stops[0].AverageLoad)
+		assert.Equal(t, int64(8), entry.Stops[1].Alightings)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		code, _ := serve(t, "/api/where/ridership-for-trip/"+agencyID+"_unknown-trip.json?key="+siriTestKey)
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = serve(t, "/api/where/ridership-for-route/"+routeID+".json?key="+siriTestKey+"&startDate=2025-06-11&endDate=2025-06-10")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
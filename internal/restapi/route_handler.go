@@ -19,7 +19,7 @@ func (api *RestAPI) routeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	agencyID, routeID, err := utils.ExtractAgencyIDAndCodeID(queryParamID)
+	agencyID, routeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
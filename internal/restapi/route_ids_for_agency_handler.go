@@ -24,7 +24,7 @@ func (api *RestAPI) routeIDsForAgencyHandler(w http.ResponseWriter, r *http.Requ
 	agency := api.GtfsManager.FindAgency(id)
 
 	if agency == nil {
-		api.sendNull(w, r)
+		api.sendUnknownID(w, r, api.sendNull)
 		return
 	}
 
@@ -51,6 +51,6 @@ func TestRouteIdsForAgencyEndToEnd(t *testing.T) {
 
 func TestInvalidAgencyIdForRouteIds(t *testing.T) {
 	_, resp, model := serveAndRetrieveEndpoint(t, "/api/where/route-ids-for-agency/invalid.json?key=TEST")
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.Equal(t, "", model.Text)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "resource not found", model.Text)
 }
@@ -1,10 +1,14 @@
 package restapi
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/popularity"
 	"maglev.onebusaway.org/internal/utils"
 )
 
@@ -32,8 +36,8 @@ func (api *RestAPI) routeSearchHandler(w http.ResponseWriter, r *http.Request) {
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
-	// maxCount defaults to 20
-	maxCount := 20
+	// maxCount defaults to 20, up to 100
+	maxCount, maxAllowed := api.pageLimits(r, appconf.PaginationClassSearch, 20, 100)
 	var fieldErrors map[string][]string
 	if maxCountStr := queryParams.Get("maxCount"); maxCountStr != "" {
 		parsedMaxCount, fe := utils.ParseFloatParam(queryParams, "maxCount", fieldErrors)
@@ -42,8 +46,8 @@ func (api *RestAPI) routeSearchHandler(w http.ResponseWriter, r *http.Request) {
 			fieldErrors["maxCount"] = append(fieldErrors["maxCount"], "must be greater than zero")
 		} else {
 			maxCount = int(parsedMaxCount)
-			if maxCount > 100 {
-				fieldErrors["maxCount"] = append(fieldErrors["maxCount"], "must not exceed 100")
+			if maxCount > maxAllowed {
+				fieldErrors["maxCount"] = append(fieldErrors["maxCount"], fmt.Sprintf("must not exceed %d", maxAllowed))
 			}
 		}
 	}
@@ -59,11 +63,14 @@ func (api *RestAPI) routeSearchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	routes, err := api.GtfsManager.SearchRoutes(ctx, sanitizedInput, maxCount)
+	routes, err := api.GtfsManager.SearchRoutes(ctx, sanitizedInput, api.searchCandidates(maxCount))
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
 	}
+	routes = rankByPopularity(api, popularity.Route, routes, func(route gtfsdb.Route) string {
+		return utils.FormCombinedID(route.AgencyID, route.ID)
+	}, maxCount)
 
 	results := make([]models.Route, 0, len(routes))
 	agencyIDs := make(map[string]bool)
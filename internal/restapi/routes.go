@@ -3,85 +3,231 @@ package restapi
 import (
 	"net/http"
 	"net/http/pprof"
+	"strings"
 
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 )
 
 type handlerFunc func(w http.ResponseWriter, r *http.Request)
 
-// rateLimitAndValidateAPIKey combines rate limiting, API key validation, and compression
+// rateLimitAndValidateAPIKey combines rate limiting, quotas, API key validation, and compression
 func rateLimitAndValidateAPIKey(api *RestAPI, finalHandler handlerFunc) http.Handler {
-	// Create the handler chain: API key validation -> rate limiting -> compression -> final handler
+	// Create the handler chain: request limits -> bearer token / signature verification -> usage tracking -> blocklist -> API key validation -> rate limiting -> quotas -> compression -> analytics -> final handler
 	finalHandlerHttp := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		finalHandler(w, r)
 	})
 
-	// Apply compression first (innermost)
-	compressedHandler := CompressionMiddleware(finalHandlerHttp)
+	// Count served requests for usage analytics
+	countedHandler := api.recordAnalytics(finalHandlerHttp)
+
+	// Then compression
+	compressedHandler := CompressionMiddleware(countedHandler)
+
+	// Then quotas, inside rate limiting so that throttled requests don't use up quota
+	quotaHandler := api.QuotaMiddleware(api.Quotas)(compressedHandler)
 
 	// Then rate limiting - use the shared rate limiter instance
 	var rateLimitedHandler http.Handler
 	if api.rateLimiter != nil {
-		rateLimitedHandler = api.rateLimiter.Handler()(compressedHandler)
+		rateLimitedHandler = api.rateLimiter.Handler()(quotaHandler)
 	} else {
 		// Fallback for tests that don't use NewRestAPI constructor
-		rateLimitedHandler = compressedHandler
+		rateLimitedHandler = quotaHandler
 	}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	validatedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A shared cache must not answer other clients with a response to a key sent in a header
+		if app.APIKeyInHeader(r) {
+			w.Header().Add("Vary", "Authorization, "+app.APIKeyHeader)
+		}
 		// First validate API key
 		if api.RequestHasInvalidAPIKey(r) {
 			api.invalidAPIKeyResponse(w, r)
 			return
 		}
+		// Then check the key is used from where it was issued for
+		if api.RequestViolatesKeyRestrictions(r) {
+			api.sendError(w, r, http.StatusForbidden, "API key not allowed from this origin or address")
+			return
+		}
+		// Infrastructure probes on exempt paths don't count against the key's limits, but do
+		// show in its usage
+		if api.IsRateLimitExemptPath(r.URL.Path) {
+			if api.Quotas != nil {
+				api.Quotas.Record(app.APIKeyFromRequest(r))
+			}
+			compressedHandler.ServeHTTP(w, r)
+			return
+		}
 		// Then apply rate limiting and compression
 		rateLimitedHandler.ServeHTTP(w, r)
 	})
+
+	// Refuse blocked keys and networks before any other check
+	blockedHandler := api.BlocklistMiddleware(validatedHandler)
+
+	// Record per-key usage so rejected and throttled requests are counted too
+	var trackedHandler http.Handler = blockedHandler
+	if api.usageTracker != nil {
+		trackedHandler = api.usageTracker.Handler(api.usageKeyForRequest)(blockedHandler)
+	}
+
+	// Authenticate signed requests and bearer tokens so every inner layer, and the trace, sees
+	// the caller's key
+	authenticatedHandler := api.BearerAuthMiddleware(api.SignedRequestMiddleware(api.annotateSpan(trackedHandler)))
+
+	// Reject oversized input outermost, before any key lookup or database work
+	return api.RequestGuardMiddleware(authenticatedHandler)
+}
+
+// requireAdminAPIKey restricts a handler to requests carrying one of the configured admin keys
+func requireAdminAPIKey(api *RestAPI, finalHandler handlerFunc) http.Handler {
+	return api.SignedRequestMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !api.RequestHasAdminAPIKey(r) {
+			api.invalidAPIKeyResponse(w, r)
+			return
+		}
+		finalHandler(w, r)
+	}))
+}
+
+// registerPprofHandlers mounts net/http/pprof under /api/admin/debug/pprof/ behind the admin key check.
+// The /api/admin prefix is stripped because pprof.Index derives profile names from a /debug/pprof/ path.
+func registerPprofHandlers(api *RestAPI, mux *http.ServeMux) {
+	pprofMux := http.NewServeMux()
+	pprofMux.HandleFunc("/debug/pprof/", pprof.Index)
+	pprofMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	pprofMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	pprofMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	pprofMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	handler := http.StripPrefix("/api/admin", pprofMux)
+	mux.Handle("GET /api/admin/debug/pprof/", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, handler.ServeHTTP)))
 }
 
-func registerPprofHandlers(mux *http.ServeMux) { // nolint:unused
-	// Register pprof handlers
-	// import "net/http/pprof"
-	// Tutorial: https://medium.com/@rahul.fiem/application-performance-optimization-how-to-effectively-analyze-and-optimize-pprof-cpu-profiles-95280b2f5bfb
-	mux.HandleFunc("/debug/pprof/", pprof.Index)
-	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
-	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
-	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+// handleWithXML registers handler for a .json pattern and for its .xml variant, which the
+// handler answers in XML.
+func handleWithXML(mux *http.ServeMux, pattern string, handler http.Handler) {
+	mux.Handle(pattern, handler)
+	mux.Handle(strings.TrimSuffix(pattern, ".json")+".xml", handler)
 }
 
 // SetRoutes registers all API endpoints with compression applied per route
 func (api *RestAPI) SetRoutes(mux *http.ServeMux) {
-	// Health check endpoint - no authentication required
+	// Liveness and readiness probes - no authentication required
 	mux.HandleFunc("GET /healthz", api.healthHandler)
-	mux.Handle("GET /api/where/agencies-with-coverage.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.agenciesWithCoverageHandler)))
-	mux.Handle("GET /api/where/agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.agencyHandler)))
-	mux.Handle("GET /api/where/routes-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.routesForAgencyHandler)))
-	mux.Handle("GET /api/where/stop-ids-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.stopIDsForAgencyHandler)))
-	mux.Handle("GET /api/where/stops-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.stopsForAgencyHandler)))
-	mux.Handle("GET /api/where/route-ids-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.routeIDsForAgencyHandler)))
-	mux.Handle("GET /api/where/route/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.routeHandler)))
-	mux.Handle("GET /api/where/stop/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.stopHandler)))
-	mux.Handle("GET /api/where/shape/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.shapesHandler)))
-	mux.Handle("GET /api/where/stops-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.stopsForRouteHandler)))
-	mux.Handle("GET /api/where/schedule-for-stop/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.scheduleForStopHandler)))
-	mux.Handle("GET /api/where/schedule-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.scheduleForRouteHandler)))
+	mux.HandleFunc("GET /readyz", api.readyHandler)
+	mux.Handle("GET /api/status/version.json", CacheControlMiddleware(models.CacheDurationNone, http.HandlerFunc(api.versionHandler)))
+	mux.Handle("GET /api/status/stats.json", CacheControlMiddleware(models.CacheDurationShort, http.HandlerFunc(api.systemStatsHandler)))
+	handleWithXML(mux, "GET /api/where/agencies-with-coverage.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupAgencies, api.agenciesWithCoverageHandler))))
+	mux.Handle("GET /api/where/agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupAgencies, api.agencyHandler))))
+	mux.Handle("GET /api/where/routes-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupRoutes, api.routesForAgencyHandler))))
+	mux.Handle("GET /api/where/stop-ids-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupStops, api.stopIDsForAgencyHandler))))
+	mux.Handle("GET /api/where/stops-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupStops, api.stopsForAgencyHandler))))
+	mux.Handle("GET /api/where/route-ids-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupRoutes, api.routeIDsForAgencyHandler))))
+	mux.Handle("GET /api/where/route/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupRoutes, api.routeHandler))))
+	mux.Handle("GET /api/where/stop/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupStops, api.stopHandler))))
+	mux.Handle("GET /api/where/stop-for-code/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.stopForCodeHandler)))
+	mux.Handle("GET /api/where/shape/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupShapes, api.shapesHandler))))
+	mux.Handle("GET /api/where/stops-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.cacheResponses(appconf.ResponseCacheGroupRoutes, api.stopsForRouteHandler))))
+	mux.Handle("GET /api/where/schedule-for-stop/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.limitConcurrency(appconf.ConcurrencyGroupSchedules, api.scheduleForStopHandler))))
+	mux.Handle("GET /api/where/schedule-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.limitConcurrency(appconf.ConcurrencyGroupSchedules, api.scheduleForRouteHandler))))
 	mux.Handle("GET /api/where/block/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.blockHandler)))
-	mux.Handle("GET /api/where/search/stop.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.searchStopsHandler)))
-	mux.Handle("GET /api/where/search/route.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.routeSearchHandler)))
-	mux.Handle("GET /api/where/current-time.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.currentTimeHandler)))
+	handleWithXML(mux, "GET /api/where/search/stop.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.limitConcurrency(appconf.ConcurrencyGroupSearch, api.searchStopsHandler))))
+	handleWithXML(mux, "GET /api/where/search/location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.limitConcurrency(appconf.ConcurrencyGroupSearch, api.locationSearchHandler))))
+	handleWithXML(mux, "GET /api/where/search/route.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.limitConcurrency(appconf.ConcurrencyGroupSearch, api.routeSearchHandler))))
+	handleWithXML(mux, "GET /api/where/current-time.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.currentTimeHandler)))
 	mux.Handle("GET /api/where/vehicles-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.vehiclesForAgencyHandler)))
-	mux.Handle("GET /api/where/stops-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.stopsForLocationHandler)))
+	mux.Handle("GET /api/where/arrivals-and-departures/subscribe", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, api.arrivalsSubscriptionHandler)))
+	mux.Handle("GET /api/where/vehicles-for-agency/{id}/stream", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, api.vehiclesForAgencyStreamHandler)))
+	handleWithXML(mux, "GET /api/where/stops-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.stopsForLocationHandler)))
+	handleWithXML(mux, "GET /api/where/bikeshare-stations-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.bikeshareStationsForLocationHandler)))
 	mux.Handle("GET /api/where/trip/{id}", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, api.tripHandler)))
-	mux.Handle("GET /api/where/routes-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.routesForLocationHandler)))
+	handleWithXML(mux, "GET /api/where/routes-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.routesForLocationHandler)))
 	mux.Handle("GET /api/where/trip-details/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.tripDetailsHandler)))
 	mux.Handle("GET /api/where/trip-for-vehicle/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.tripForVehicleHandler)))
-	mux.Handle("GET /api/where/trips-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.tripsForLocationHandler)))
+	handleWithXML(mux, "GET /api/where/trips-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.limitConcurrency(appconf.ConcurrencyGroupTrips, api.tripsForLocationHandler))))
 	mux.Handle("GET /api/where/arrival-and-departure-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.arrivalAndDepartureForStopHandler)))
-	mux.Handle("GET /api/where/trips-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.tripsForRouteHandler)))
+	mux.Handle("GET /api/where/trips-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.limitConcurrency(appconf.ConcurrencyGroupTrips, api.tripsForRouteHandler))))
 	mux.Handle("GET /api/where/arrivals-and-departures-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.arrivalsAndDeparturesForStopHandler)))
 	mux.Handle("GET /api/where/report-problem-with-trip/{id}", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, api.reportProblemWithTripHandler)))
 	mux.Handle("GET /api/where/report-problem-with-stop/{id}", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, api.reportProblemWithStopHandler)))
+
+	// Arrival notification subscriptions of the caller's API key
+	handleWithXML(mux, "POST /api/where/arrival-notifications.json", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, api.createArrivalNotificationHandler)))
+	handleWithXML(mux, "GET /api/where/arrival-notifications.json", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, api.arrivalNotificationsHandler)))
+	mux.Handle("GET /api/where/arrival-notification/{id}", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, api.arrivalNotificationHandler)))
+	mux.Handle("DELETE /api/where/arrival-notification/{id}", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, api.deleteArrivalNotificationHandler)))
+
+	// Active service alerts as Atom or RSS feeds, by extension
+	mux.Handle("GET /api/where/alerts-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.alertsForAgencyFeedHandler)))
+	mux.Handle("GET /api/where/alerts-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.alertsForRouteFeedHandler)))
+
+	// On-time performance of the arrivals in the arrival archive
+	mux.Handle("GET /api/where/on-time-performance-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.onTimePerformanceForAgencyHandler)))
+	mux.Handle("GET /api/where/on-time-performance-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.onTimePerformanceForRouteHandler)))
+	mux.Handle("GET /api/where/on-time-performance-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.onTimePerformanceForStopHandler)))
+	mux.Handle("GET /api/where/headways-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.headwaysForAgencyHandler)))
+	mux.Handle("GET /api/where/headways-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.headwaysForRouteHandler)))
+	mux.Handle("GET /api/where/headways-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.headwaysForStopHandler)))
+	mux.Handle("GET /api/where/ridership-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.ridershipForRouteHandler)))
+	mux.Handle("GET /api/where/ridership-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.ridershipForStopHandler)))
+	mux.Handle("GET /api/where/ridership-for-trip/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.ridershipForTripHandler)))
+	mux.Handle("GET /api/where/detours-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.detoursForAgencyHandler)))
+	mux.Handle("GET /api/where/detours-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.detoursForRouteHandler)))
+	mux.Handle("GET /api/where/station-accessibility/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.stationAccessibilityHandler)))
+
+	// Embeddable stop departure widget, as an HTML page or JSON by extension
+	mux.Handle("GET /api/where/departures-widget/{id}", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.departureWidgetHandler)))
+
+	// SIRI services, in XML or SIRI-JSON by extension
+	mux.Handle("GET /api/siri/stop-monitoring.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.siriStopMonitoringHandler)))
+	mux.Handle("GET /api/siri/stop-monitoring.xml", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.siriStopMonitoringHandler)))
+	mux.Handle("GET /api/siri/vehicle-monitoring.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.siriVehicleMonitoringHandler)))
+	mux.Handle("GET /api/siri/vehicle-monitoring.xml", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.siriVehicleMonitoringHandler)))
+	mux.Handle("GET /api/siri/situation-exchange.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.siriSituationExchangeHandler)))
+	mux.Handle("GET /api/siri/situation-exchange.xml", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.siriSituationExchangeHandler)))
+
+	// GraphQL queries over the GTFS data, when enabled
+	if api.Config.GraphQL {
+		graphqlHandler := api.graphqlHandler(api.newGraphQLSchema())
+		mux.Handle("GET /graphql", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, graphqlHandler)))
+		mux.Handle("POST /graphql", CacheControlMiddleware(models.CacheDurationNone, rateLimitAndValidateAPIKey(api, graphqlHandler)))
+	}
+
+	// Admin endpoints live on the admin listener instead when one is configured
+	if api.Config.AdminPort == 0 {
+		api.SetAdminRoutes(mux)
+	}
+}
+
+// SetAdminRoutes registers the /api/admin endpoints. All of them require an admin API key.
+// Operational actions use POST so they can't be triggered by a crawler or a cached link.
+func (api *RestAPI) SetAdminRoutes(mux *http.ServeMux) {
+	mux.Handle("GET /api/admin/usage.json", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.apiKeyUsageHandler)))
+	mux.Handle("GET /api/admin/quotas.json", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.quotaUsageHandler)))
+	mux.Handle("GET /api/admin/status.json", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminStatusHandler)))
+	mux.Handle("GET /api/admin/status.html", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminStatusPageHandler)))
+	mux.Handle("GET /api/admin/vehicles.html", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminVehicleMapHandler)))
+	mux.Handle("GET /api/admin/vehicles/stream", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminVehicleStreamHandler)))
+	mux.Handle("GET /api/admin/audit.json", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminAuditLogHandler)))
+	mux.Handle("GET /api/admin/analytics.json", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminAnalyticsHandler)))
+	mux.Handle("GET /api/admin/analytics.csv", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminAnalyticsExportHandler)))
+	mux.Handle("GET /api/admin/arrivals.csv", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminArrivalArchiveExportHandler)))
+	mux.Handle("GET /api/admin/export/{table}", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminExportParquetHandler)))
+	mux.Handle("GET /api/admin/blocklist.json", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminBlocklistHandler)))
+	mux.Handle("POST /api/admin/blocklist/add", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.audited(AuditActionBlocklistAdd, api.adminBlocklistAddHandler))))
+	mux.Handle("POST /api/admin/blocklist/remove", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.audited(AuditActionBlocklistRemove, api.adminBlocklistRemoveHandler))))
+	mux.Handle("POST /api/admin/gtfs/refresh", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.audited(AuditActionStaticRefresh, api.adminRefreshStaticHandler))))
+	mux.Handle("POST /api/admin/realtime/refresh", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.audited(AuditActionRealtimeRefresh, api.adminRefreshRealtimeHandler))))
+	mux.Handle("POST /api/admin/cache/flush", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.audited(AuditActionCacheFlush, api.adminFlushCacheHandler))))
+	mux.Handle("POST /api/admin/ratelimits/flush", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.audited(AuditActionRateLimitFlush, api.adminFlushRateLimitsHandler))))
+	mux.Handle("GET /api/admin/config.json", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.adminConfigHandler)))
+	mux.Handle("POST /api/admin/config/reload", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.audited(AuditActionConfigReload, api.adminReloadConfigHandler))))
+	mux.Handle("POST /api/admin/ridership/import", CacheControlMiddleware(models.CacheDurationNone, requireAdminAPIKey(api, api.audited(AuditActionRidershipImport, api.adminRidershipImportHandler))))
+	registerPprofHandlers(api, mux)
 }
 
 // SetupAPIRoutes creates and configures the API router with all middleware applied globally
@@ -3,6 +3,7 @@ package restapi
 import (
 	"net/http"
 
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -21,14 +22,15 @@ func (api *RestAPI) routesForAgencyHandler(w http.ResponseWriter, r *http.Reques
 	agency := api.GtfsManager.FindAgency(id)
 
 	if agency == nil {
-		api.sendNull(w, r)
+		api.sendUnknownID(w, r, api.sendNull)
 		return
 	}
 
 	routesForAgency := api.GtfsManager.RoutesForAgencyID(id)
 
 	// Apply pagination
-	offset, limit := utils.ParsePaginationParams(r)
+	defaultLimit, maxLimit := api.pageLimits(r, appconf.PaginationClassList, -1, models.MaxPageSize)
+	offset, limit := utils.ParsePaginationParams(r, defaultLimit, maxLimit)
 	routesForAgency, limitExceeded := utils.PaginateSlice(routesForAgency, offset, limit)
 	// Safe allocation logic
 	routesList := make([]models.Route, 0, len(routesForAgency))
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
 )
 
 func TestRoutesForAgencyHandlerRequiresValidApiKey(t *testing.T) {
@@ -71,8 +72,8 @@ func TestRoutesForAgencyHandlerNonExistentAgency(t *testing.T) {
 
 	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/routes-for-agency/non-existent-agency.json?key=TEST")
 
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.Equal(t, "", model.Text)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "resource not found", model.Text)
 	assert.Nil(t, model.Data)
 }
 
@@ -153,3 +154,22 @@ func TestRoutesForAgencyHandlerPagination(t *testing.T) {
 	assert.Len(t, list3, 13)
 	assert.False(t, data3["limitExceeded"].(bool), "limitExceeded should be false when all items returned")
 }
+
+func TestRoutesForAgencyHandlerConfiguredPagination(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.Pagination = appconf.PaginationConfig{
+		appconf.PaginationClassList: {DefaultCount: 4, MaxCount: 6},
+	}
+
+	agencyId := api.GtfsManager.GetAgencies()[0].Id
+
+	_, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/routes-for-agency/"+agencyId+".json?key=TEST")
+	data := model.Data.(map[string]interface{})
+	assert.Len(t, data["list"], 4, "default-count applies without maxCount")
+	assert.True(t, data["limitExceeded"].(bool))
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/routes-for-agency/"+agencyId+".json?key=TEST&maxCount=100")
+	data = model.Data.(map[string]interface{})
+	assert.Len(t, data["list"], 6, "max-count caps larger requests")
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -17,7 +18,8 @@ func (api *RestAPI) routesForLocationHandler(w http.ResponseWriter, r *http.Requ
 	radius, _ := utils.ParseFloatParam(queryParams, "radius", fieldErrors)
 	latSpan, _ := utils.ParseFloatParam(queryParams, "latSpan", fieldErrors)
 	lonSpan, _ := utils.ParseFloatParam(queryParams, "lonSpan", fieldErrors)
-	maxCount, _ := utils.ParseMaxCount(queryParams, models.DefaultMaxCountForRoutes, fieldErrors)
+	defaultCount, maxAllowed := api.pageLimits(r, appconf.PaginationClassLocation, models.DefaultMaxCountForRoutes, models.MaxAllowedCount)
+	maxCount, _ := utils.ParseMaxCount(queryParams, defaultCount, maxAllowed, fieldErrors)
 	query := queryParams.Get("query")
 
 	if len(fieldErrors) > 0 {
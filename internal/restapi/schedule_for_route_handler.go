@@ -2,6 +2,7 @@ package restapi
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"maglev.onebusaway.org/gtfsdb"
@@ -11,6 +12,12 @@ import (
 )
 
 func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Request) {
+	// The .ics form is an iCalendar feed of the schedule over a date range
+	if strings.HasSuffix(r.PathValue("id"), ".ics") {
+		api.scheduleForRouteICalHandler(w, r)
+		return
+	}
+
 	queryParamID := utils.ExtractIDFromParams(r)
 	if err := utils.ValidateID(queryParamID); err != nil {
 		fieldErrors := map[string][]string{
@@ -19,7 +26,7 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 		api.validationErrorResponse(w, r, fieldErrors)
 		return
 	}
-	agencyID, routeID, err := utils.ExtractAgencyIDAndCodeID(queryParamID)
+	agencyID, routeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -35,6 +42,14 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 		api.validationErrorResponse(w, r, fieldErrors)
 		return
 	}
+	requestClock, err := api.requestClock(r)
+	if err != nil {
+		fieldErrors := map[string][]string{
+			debugTimeParam: {err.Error()},
+		}
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
 	ctx := r.Context()
 
 	api.GtfsManager.RLock()
@@ -57,7 +72,7 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 		}
 		targetDate = parsedDate.Format("20060102")
 	} else {
-		now := api.Clock.Now()
+		now := requestClock.Now()
 		targetDate = now.Format("20060102")
 	}
 
@@ -76,7 +91,7 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 			ServiceIDs:        []string{},
 			StopTripGroupings: []models.StopTripGrouping{},
 		}
-		api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock))
+		api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), requestClock))
 		return
 	}
 
@@ -103,7 +118,7 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 			ServiceIDs:        combinedServiceIDs,
 			StopTripGroupings: []models.StopTripGrouping{},
 		}
-		api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock))
+		api.sendResponse(w, r, models.NewEntryResponse(entry, models.NewEmptyReferences(), requestClock))
 		return
 	}
 
@@ -274,5 +289,5 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 		ServiceIDs:        combinedServiceIDs,
 		StopTripGroupings: stopTripGroupings,
 	}
-	api.sendResponse(w, r, models.NewEntryResponse(entry, references, api.Clock))
+	api.sendResponse(w, r, models.NewEntryResponse(entry, references, requestClock))
 }
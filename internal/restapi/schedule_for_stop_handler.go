@@ -2,6 +2,7 @@ package restapi
 
 import (
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,6 +12,12 @@ import (
 )
 
 func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Request) {
+	// The .ics form is an iCalendar feed of the schedule over a date range
+	if strings.HasSuffix(r.PathValue("id"), ".ics") {
+		api.scheduleForStopICalHandler(w, r)
+		return
+	}
+
 	queryParamID := utils.ExtractIDFromParams(r)
 
 	// Validate ID
@@ -22,7 +29,7 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	agencyID, stopID, err := utils.ExtractAgencyIDAndCodeID(queryParamID)
+	agencyID, stopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -48,6 +55,15 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	requestClock, err := api.requestClock(r)
+	if err != nil {
+		fieldErrors := map[string][]string{
+			debugTimeParam: {err.Error()},
+		}
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
 	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
 
 	if err != nil {
@@ -73,7 +89,7 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		targetDate = parsedDate.Format("20060102")
 		weekday = strings.ToLower(parsedDate.Weekday().String())
 	} else {
-		now := api.Clock.Now().In(loc)
+		now := requestClock.Now().In(loc)
 		y, m, d := now.Date()
 		startOfDay := time.Date(y, m, d, 0, 0, 0, 0, loc)
 		date = startOfDay.UnixMilli()
@@ -103,7 +119,7 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		api.sendResponse(w, r, models.NewEntryResponse(
 			models.NewScheduleForStopEntry(utils.FormCombinedID(agencyID, stopID), date, nil),
 			models.NewEmptyReferences(),
-			api.Clock,
+			requestClock,
 		))
 		return
 	}
@@ -157,8 +173,8 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		startOfDay := time.UnixMilli(date).In(loc)
 		arrivalDuration := time.Duration(row.ArrivalTime)
 		departureDuration := time.Duration(row.DepartureTime)
-		arrivalTimeMs := startOfDay.Add(arrivalDuration).UnixMilli()
-		departureTimeMs := startOfDay.Add(departureDuration).UnixMilli()
+		arrivalTimeMs := utils.ServiceTime(startOfDay, arrivalDuration).UnixMilli()
+		departureTimeMs := utils.ServiceTime(startOfDay, departureDuration).UnixMilli()
 
 		stopTime := models.NewScheduleStopTime(
 			arrivalTimeMs,
@@ -240,7 +256,7 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		maxCount := 0
 		if headsigns, exists := routeHeadsignCounts[routeID]; exists {
 			for headsign, count := range headsigns {
-				if count > maxCount {
+				if count > maxCount || (count == maxCount && headsign < tripHeadsign) {
 					maxCount = count
 					tripHeadsign = headsign
 				}
@@ -251,6 +267,7 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		routeSchedule := models.NewStopRouteSchedule(routeID, []models.StopRouteDirectionSchedule{directionSchedule})
 		routeSchedules = append(routeSchedules, routeSchedule)
 	}
+	sort.Slice(routeSchedules, func(i, j int) bool { return routeSchedules[i].RouteID < routeSchedules[j].RouteID })
 
 	// Create the entry
 	combinedStopID := utils.FormCombinedID(agencyID, stopID)
@@ -301,6 +318,6 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 
 	references.Stops = append(references.Stops, stopRef)
 	// Create and send response
-	response := models.NewEntryResponse(entry, references, api.Clock)
+	response := models.NewEntryResponse(entry, references, requestClock)
 	api.sendResponse(w, r, response)
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"maglev.onebusaway.org/internal/clock"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -129,6 +130,48 @@ func TestScheduleForStopHandlerAgencyTimeZone(t *testing.T) {
 	assert.Equal(t, float64(expected), entry["date"])
 }
 
+func TestScheduleForStopHandlerDaylightSaving(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	loc, err := time.LoadLocation(agency.Timezone)
+	require.NoError(t, err)
+
+	// wallClock maps each trip's arrival at the stop to its local time of day
+	wallClock := func(stopID, date string) map[string]string {
+		_, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/schedule-for-stop/"+stopID+".json?key=TEST&date="+date)
+		entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+		times := map[string]string{}
+		for _, routeSchedule := range entry["stopRouteSchedules"].([]interface{}) {
+			for _, direction := range routeSchedule.(map[string]interface{})["stopRouteDirectionSchedules"].([]interface{}) {
+				for _, stopTime := range direction.(map[string]interface{})["scheduleStopTimes"].([]interface{}) {
+					st := stopTime.(map[string]interface{})
+					arrival := time.UnixMilli(int64(st["arrivalTime"].(float64))).In(loc)
+					times[st["tripId"].(string)] = arrival.Format("15:04:05")
+				}
+			}
+		}
+		return times
+	}
+
+	// Chico Transit Center is served every day by route 99X
+	stopID := utils.FormCombinedID(agency.Id, "327")
+	ordinary := wallClock(stopID, "2025-03-16")
+	require.NotEmpty(t, ordinary)
+	for _, date := range []string{"2025-03-09", "2025-11-02"} {
+		shifted := wallClock(stopID, date)
+		compared := 0
+		for tripID, want := range ordinary {
+			if got, ok := shifted[tripID]; ok {
+				assert.Equal(t, want, got, "trip %s on %s", tripID, date)
+				compared++
+			}
+		}
+		assert.NotZero(t, compared, "no trips run on both %s and an ordinary Sunday", date)
+	}
+}
+
 func TestScheduleForStopHandlerWithDateFiltering(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
@@ -0,0 +1,365 @@
+package restapi
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/ical"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	// defaultICalDays is the length of an iCalendar schedule without an endDate
+	defaultICalDays = 7
+	// maxICalDays bounds the date range of one iCalendar schedule
+	maxICalDays = 31
+
+	icalProdID = "-//OneBusAway//Maglev//EN"
+	// icalUIDDomain makes event UIDs globally unique, as RFC 5545 asks
+	icalUIDDomain = "maglev.onebusaway.org"
+	// icalDepartureLength is the length of a stop departure event. Calendars expect an event
+	// to end after it starts.
+	icalDepartureLength = time.Minute
+	// icalRefreshInterval is how often subscribed calendars fetch the schedule again
+	icalRefreshInterval = 24 * time.Hour
+)
+
+// scheduleForStopICalHandler serves schedule-for-stop/{id}.ics: every scheduled departure from
+// the stop between startDate and endDate, as one event each.
+func (api *RestAPI) scheduleForStopICalHandler(w http.ResponseWriter, r *http.Request) {
+	queryParamID := strings.TrimSuffix(r.PathValue("id"), ".ics")
+	if err := utils.ValidateID(queryParamID); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	agencyID, stopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	requestClock, err := api.requestClock(r)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{debugTimeParam: {err.Error()}})
+		return
+	}
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID)
+	dates, fieldErrors := parseICalDateRange(r.URL.Query(), loc, requestClock.Now())
+	if fieldErrors != nil {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+	routesForStop, err := api.GtfsManager.GtfsDB.Queries.GetRoutesForStop(ctx, stopID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	routeIDs := make([]string, 0, len(routesForStop))
+	routeNames := make(map[string]string, len(routesForStop))
+	for _, route := range routesForStop {
+		routeIDs = append(routeIDs, route.ID)
+		routeNames[route.ID] = icalRouteName(route)
+	}
+
+	combinedStopID := utils.FormCombinedID(agencyID, stopID)
+	stopName := stop.Name.String
+	if stopName == "" {
+		stopName = "Stop " + combinedStopID
+	}
+	calendar := &ical.Calendar{
+		ProdID:          icalProdID,
+		Name:            stopName + " departures",
+		RefreshInterval: icalRefreshInterval,
+	}
+
+	for _, day := range dates {
+		if len(routeIDs) == 0 {
+			break
+		}
+		rows, err := api.GtfsManager.GtfsDB.Queries.GetScheduleForStopOnDate(ctx, gtfsdb.GetScheduleForStopOnDateParams{
+			StopID:     stopID,
+			TargetDate: day.Format("20060102"),
+			Weekday:    strings.ToLower(day.Weekday().String()),
+			RouteIds:   routeIDs,
+		})
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		for _, row := range rows {
+			combinedTripID := utils.FormCombinedID(agencyID, row.TripID)
+			headsign := row.StopHeadsign.String
+			if headsign == "" {
+				headsign = row.TripHeadsign.String
+			}
+			departure := utils.ServiceTime(day, time.Duration(row.DepartureTime))
+			calendar.Events = append(calendar.Events, ical.Event{
+				UID:         icalUID(day, combinedTripID, combinedStopID),
+				Start:       departure,
+				End:         departure.Add(icalDepartureLength),
+				Summary:     icalSummary(routeNames[row.RouteID], headsign),
+				Description: "Scheduled departure of trip " + combinedTripID,
+				Location:    stopName,
+				Latitude:    stop.Lat,
+				Longitude:   stop.Lon,
+				HasGeo:      true,
+			})
+		}
+	}
+
+	api.sendICal(w, r, calendar, requestClock.Now(), "stop-"+combinedStopID)
+}
+
+// scheduleForRouteICalHandler serves schedule-for-route/{id}.ics: every scheduled trip of the
+// route between startDate and endDate, as one event from its first departure to its last arrival.
+func (api *RestAPI) scheduleForRouteICalHandler(w http.ResponseWriter, r *http.Request) {
+	queryParamID := strings.TrimSuffix(r.PathValue("id"), ".ics")
+	if err := utils.ValidateID(queryParamID); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	agencyID, routeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	requestClock, err := api.requestClock(r)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{debugTimeParam: {err.Error()}})
+		return
+	}
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, routeID)
+	if err != nil {
+		api.sendNotFound(w, r)
+		return
+	}
+	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, route.AgencyID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID)
+	dates, fieldErrors := parseICalDateRange(r.URL.Query(), loc, requestClock.Now())
+	if fieldErrors != nil {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	routeName := icalRouteName(route)
+	calendar := &ical.Calendar{
+		ProdID:          icalProdID,
+		Name:            routeName + " schedule",
+		RefreshInterval: icalRefreshInterval,
+	}
+
+	// Most trips run on many days of the range, so their stop times and stops are loaded once
+	stopTimesByTrip := make(map[string][]gtfsdb.StopTime)
+	stops := make(map[string]gtfsdb.Stop)
+	for _, day := range dates {
+		serviceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, day.Format("20060102"))
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		if len(serviceIDs) == 0 {
+			continue
+		}
+		trips, err := api.GtfsManager.GtfsDB.Queries.GetTripsForRouteInActiveServiceIDs(ctx, gtfsdb.GetTripsForRouteInActiveServiceIDsParams{
+			RouteID:    routeID,
+			ServiceIds: serviceIDs,
+		})
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		if err := api.loadICalTripStops(r, trips, stopTimesByTrip, stops); err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+
+		for _, trip := range trips {
+			stopTimes := stopTimesByTrip[trip.ID]
+			if len(stopTimes) == 0 {
+				continue
+			}
+			first, last := stops[stopTimes[0].StopID], stops[stopTimes[len(stopTimes)-1].StopID]
+			headsign := trip.TripHeadsign.String
+			if headsign == "" {
+				headsign = last.Name.String
+			}
+			start := utils.ServiceTime(day, time.Duration(stopTimes[0].DepartureTime))
+			end := utils.ServiceTime(day, time.Duration(stopTimes[len(stopTimes)-1].ArrivalTime))
+			if !end.After(start) {
+				end = start.Add(icalDepartureLength)
+			}
+			combinedTripID := utils.FormCombinedID(agencyID, trip.ID)
+			calendar.Events = append(calendar.Events, ical.Event{
+				UID:         icalUID(day, combinedTripID, ""),
+				Start:       start,
+				End:         end,
+				Summary:     icalSummary(routeName, headsign),
+				Description: fmt.Sprintf("From %s to %s\nScheduled trip %s", first.Name.String, last.Name.String, combinedTripID),
+				Location:    first.Name.String,
+				Latitude:    first.Lat,
+				Longitude:   first.Lon,
+				HasGeo:      first.ID != "",
+			})
+		}
+	}
+	sort.SliceStable(calendar.Events, func(i, j int) bool {
+		return calendar.Events[i].Start.Before(calendar.Events[j].Start)
+	})
+
+	api.sendICal(w, r, calendar, requestClock.Now(), "route-"+utils.FormCombinedID(agencyID, routeID))
+}
+
+// loadICalTripStops adds the stop times of trips not yet in stopTimesByTrip, and the first and
+// last stop of each, to the maps.
+func (api *RestAPI) loadICalTripStops(r *http.Request, trips []gtfsdb.Trip, stopTimesByTrip map[string][]gtfsdb.StopTime, stops map[string]gtfsdb.Stop) error {
+	var missing []string
+	for _, trip := range trips {
+		if _, ok := stopTimesByTrip[trip.ID]; !ok {
+			missing = append(missing, trip.ID)
+			stopTimesByTrip[trip.ID] = nil
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTripIDs(r.Context(), missing)
+	if err != nil {
+		return err
+	}
+	for _, st := range stopTimes {
+		stopTimesByTrip[st.TripID] = append(stopTimesByTrip[st.TripID], st)
+	}
+
+	var stopIDs []string
+	for _, tripID := range missing {
+		tripStopTimes := stopTimesByTrip[tripID]
+		if len(tripStopTimes) == 0 {
+			continue
+		}
+		for _, stopID := range []string{tripStopTimes[0].StopID, tripStopTimes[len(tripStopTimes)-1].StopID} {
+			if _, ok := stops[stopID]; !ok {
+				stops[stopID] = gtfsdb.Stop{}
+				stopIDs = append(stopIDs, stopID)
+			}
+		}
+	}
+	if len(stopIDs) == 0 {
+		return nil
+	}
+	rows, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(r.Context(), stopIDs)
+	if err != nil {
+		return err
+	}
+	for _, stop := range rows {
+		stops[stop.ID] = stop
+	}
+	return nil
+}
+
+// parseICalDateRange returns the service dates, as midnight in loc, from the startDate query
+// parameter (default today) through endDate (default six days later), or the field errors.
+func parseICalDateRange(query url.Values, loc *time.Location, now time.Time) ([]time.Time, map[string][]string) {
+	y, m, d := now.In(loc).Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if value := query.Get("startDate"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return nil, map[string][]string{"startDate": {"invalid date format, use YYYY-MM-DD"}}
+		}
+		start = parsed
+	}
+	end := start.AddDate(0, 0, defaultICalDays-1)
+	if value := query.Get("endDate"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return nil, map[string][]string{"endDate": {"invalid date format, use YYYY-MM-DD"}}
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		return nil, map[string][]string{"endDate": {"endDate must not be before startDate"}}
+	}
+
+	var dates []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if len(dates) == maxICalDays {
+			return nil, map[string][]string{"endDate": {fmt.Sprintf("the range may span at most %d days", maxICalDays)}}
+		}
+		dates = append(dates, day)
+	}
+	return dates, nil
+}
+
+// icalRouteName is the name riders know a route by.
+func icalRouteName(route gtfsdb.Route) string {
+	switch {
+	case route.ShortName.String != "":
+		return route.ShortName.String
+	case route.LongName.String != "":
+		return route.LongName.String
+	default:
+		return "Route " + route.ID
+	}
+}
+
+func icalSummary(routeName, headsign string) string {
+	if headsign == "" {
+		return routeName
+	}
+	return routeName + " to " + headsign
+}
+
+// icalUID identifies one trip's event on one service date, so a re-fetched feed updates events
+// in place instead of duplicating them.
+func icalUID(serviceDate time.Time, tripID, stopID string) string {
+	uid := serviceDate.Format("20060102") + "-" + tripID
+	if stopID != "" {
+		uid += "-" + stopID
+	}
+	return uid + "@" + icalUIDDomain
+}
+
+// sendICal writes the calendar as an iCalendar feed named filename.ics. The feed is encoded
+// before the status is written so an encoding failure still becomes a 500.
+func (api *RestAPI) sendICal(w http.ResponseWriter, r *http.Request, calendar *ical.Calendar, now time.Time, filename string) {
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf, now); err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", ical.MediaType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename + ".ics"}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
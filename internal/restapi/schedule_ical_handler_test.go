@@ -0,0 +1,112 @@
+package restapi
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/ical"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestScheduleICalHandlers(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	agencies := api.GtfsManager.GetAgencies()
+	require.NotEmpty(t, agencies)
+	static := api.GtfsManager.GetStaticData()
+	require.NotEmpty(t, static.Routes)
+	stopID := utils.FormCombinedID(agencies[0].Id, api.GtfsManager.GetStops()[0].Id)
+	routeID := utils.FormCombinedID(agencies[0].Id, static.Routes[0].Id)
+
+	t.Run("stop", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/where/schedule-for-stop/"+stopID+".ics?key="+siriTestKey+"&startDate=2025-06-12&endDate=2025-06-13")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ical.MediaType, rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), "stop-"+stopID+".ics")
+
+		body := rec.Body.String()
+		assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n"))
+		events := strings.Count(body, "BEGIN:VEVENT")
+		require.Positive(t, events, "the stop has departures on a weekday")
+		assert.Contains(t, body, "-"+stopID+"@maglev.onebusaway.org")
+		assert.Contains(t, body, "UID:20250612-")
+		assert.Contains(t, body, "UID:20250613-")
+
+		// A one-day range holds fewer departures
+		rec = serveSiri(t, api, "/api/where/schedule-for-stop/"+stopID+".ics?key="+siriTestKey+"&startDate=2025-06-12&endDate=2025-06-12")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Less(t, strings.Count(rec.Body.String(), "BEGIN:VEVENT"), events)
+	})
+
+	t.Run("route", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/where/schedule-for-route/"+routeID+".ics?key="+siriTestKey+"&startDate=2025-06-12&endDate=2025-06-12")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ical.MediaType, rec.Header().Get("Content-Type"))
+
+		body := rec.Body.String()
+		require.Contains(t, body, "BEGIN:VEVENT")
+		assert.Contains(t, body, "UID:20250612-")
+		assert.Contains(t, body, "DESCRIPTION:From ")
+
+		// Events are ordered by start time
+		var starts []string
+		for _, line := range strings.Split(body, "\r\n") {
+			if start, ok := strings.CutPrefix(line, "DTSTART:"); ok {
+				starts = append(starts, start)
+			}
+		}
+		assert.IsNonDecreasing(t, starts)
+	})
+
+	t.Run("the JSON form is unchanged", func(t *testing.T) {
+		resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/schedule-for-stop/"+stopID+".json?key=TEST&date=2025-06-12")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "OK", model.Text)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/where/schedule-for-route/"+routeID+"notexist.ics?key="+siriTestKey)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		for _, query := range []string{
+			"startDate=2025/06/12",
+			"startDate=2025-06-12&endDate=2025-06-11",
+			"startDate=2025-06-01&endDate=2025-07-15",
+		} {
+			rec := serveSiri(t, api, "/api/where/schedule-for-stop/"+stopID+".ics?key="+siriTestKey+"&"+query)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+}
+
+func TestParseICalDateRange(t *testing.T) {
+	pacific, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	// 03:00 UTC on the 12th is still the 11th in Pacific time
+	now := time.Date(2025, 6, 12, 3, 0, 0, 0, time.UTC)
+
+	dates, fieldErrors := parseICalDateRange(url.Values{}, pacific, now)
+	require.Nil(t, fieldErrors)
+	require.Len(t, dates, defaultICalDays)
+	assert.Equal(t, time.Date(2025, 6, 11, 0, 0, 0, 0, pacific), dates[0])
+	assert.Equal(t, time.Date(2025, 6, 17, 0, 0, 0, 0, pacific), dates[6])
+
+	// Days across a DST change still start at local midnight
+	dates, fieldErrors = parseICalDateRange(url.Values{"startDate": {"2025-03-08"}, "endDate": {"2025-03-10"}}, pacific, now)
+	require.Nil(t, fieldErrors)
+	require.Len(t, dates, 3)
+	assert.Equal(t, 0, dates[2].Hour())
+
+	_, fieldErrors = parseICalDateRange(url.Values{"startDate": {"2025-06-01"}, "endDate": {"2025-07-01"}}, pacific, now)
+	assert.Nil(t, fieldErrors, "31 days are allowed")
+	_, fieldErrors = parseICalDateRange(url.Values{"startDate": {"2025-06-01"}, "endDate": {"2025-07-02"}}, pacific, now)
+	assert.Contains(t, fieldErrors["endDate"][0], "at most 31 days")
+}
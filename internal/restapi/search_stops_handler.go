@@ -9,7 +9,9 @@ import (
 
 	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/popularity"
 	"maglev.onebusaway.org/internal/utils"
 )
 
@@ -48,12 +50,16 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
-	limit := 50
+	// Stop search has no built-in maximum; a configured one caps maxCount
+	limit, maxAllowed := api.pageLimits(r, appconf.PaginationClassSearch, 50, 0)
 	if maxCountStr := r.URL.Query().Get("maxCount"); maxCountStr != "" {
 		if parsed, err := strconv.Atoi(maxCountStr); err == nil && parsed > 0 {
 			limit = parsed
 		}
 	}
+	if maxAllowed > 0 && limit > maxAllowed {
+		limit = maxAllowed
+	}
 
 	// 2. Sanitize and construct FTS5 query
 	sanitizedQuery := sanitizeFTS5Query(query)
@@ -87,7 +93,7 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 
 	searchParams := gtfsdb.SearchStopsByNameParams{
 		SearchQuery: searchQuery,
-		Limit:       int64(limit),
+		Limit:       int64(api.searchCandidates(limit)),
 	}
 
 	// 3. Perform Full Text Search (with logged fallback)
@@ -225,7 +231,7 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 		var agencyID string
 
 		if rts, ok := routesByStopID[s.ID]; ok && len(rts) > 0 {
-			agencyID, _, _ = utils.ExtractAgencyIDAndCodeID(rts[0])
+			agencyID, _, _ = api.GtfsManager.ExtractAgencyIDAndCodeID(rts[0])
 		} else if len(agenciesMap) == 1 {
 			for id := range agenciesMap {
 				agencyID = id
@@ -282,7 +288,25 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 		stopModels = append(stopModels, stopModel)
 	}
 
-	// 7. Build References
+	// 7. Rank popular stops first, dropping the references of the extra matches fetched for it
+	if len(stopModels) > limit {
+		stopModels = rankByPopularity(api, popularity.Stop, stopModels, func(stop models.Stop) string { return stop.ID }, limit)
+		keptRoutes := make(map[string]models.Route)
+		keptAgencies := make(map[string]models.AgencyReference)
+		for _, stop := range stopModels {
+			for _, routeID := range stop.RouteIDs {
+				keptRoutes[routeID] = routesMap[routeID]
+			}
+			if agencyID, _, err := api.GtfsManager.ExtractAgencyIDAndCodeID(stop.ID); err == nil {
+				if agency, ok := agenciesMap[agencyID]; ok {
+					keptAgencies[agencyID] = agency
+				}
+			}
+		}
+		routesMap, agenciesMap = keptRoutes, keptAgencies
+	}
+
+	// 8. Build References
 	references := models.NewEmptyReferences()
 	for _, r := range routesMap {
 		references.Routes = append(references.Routes, r)
@@ -2,15 +2,27 @@ package restapi
 
 import (
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
 )
 
-// WithSecurityHeaders wraps the given handler with security headers middleware
+// WithSecurityHeaders wraps the given handler with security headers middleware, including the
+// configured CORS headers
 func (api *RestAPI) WithSecurityHeaders(handler http.Handler) http.Handler {
-	return securityHeaders(handler)
+	return securityHeaders(api.Config.CORS, handler)
 }
 
-// securityHeaders adds essential security headers to all HTTP responses
-func securityHeaders(next http.Handler) http.Handler {
+// securityHeaders adds essential security headers to all HTTP responses, and CORS headers to
+// responses to allowed origins
+func securityHeaders(cors appconf.CORSConfig, next http.Handler) http.Handler {
+	allowAnyOrigin := slices.Contains(cors.AllowedOrigins, "*")
+	allowMethods := strings.Join(cors.AllowedMethods, ", ")
+	maxAge := strconv.Itoa(cors.MaxAge)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Prevent MIME type sniffing
 		w.Header().Set("X-Content-Type-Options", "nosniff")
@@ -38,11 +50,22 @@ func securityHeaders(next http.Handler) http.Handler {
 		// CORS headers for API access
 		origin := r.Header.Get("Origin")
 		if origin != "" {
-			// Allow all origins for public transit API, but be explicit about it
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
+			allowOrigin := ""
+			if allowAnyOrigin {
+				allowOrigin = "*"
+			} else {
+				// The answer depends on the origin, so caches must keep one per origin
+				w.Header().Add("Vary", "Origin")
+				if app.OriginAllowed(origin, cors.AllowedOrigins) {
+					allowOrigin = origin
+				}
+			}
+			if allowOrigin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+				w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
 		}
 
 		// Handle preflight OPTIONS requests
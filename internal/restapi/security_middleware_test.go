@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
 )
 
 func TestSecurityHeaders(t *testing.T) {
@@ -17,7 +18,7 @@ func TestSecurityHeaders(t *testing.T) {
 	})
 
 	// Wrap with security headers
-	secureHandler := securityHeaders(handler)
+	secureHandler := securityHeaders(appconf.DefaultCORSConfig(), handler)
 
 	// Create test request
 	req := httptest.NewRequest("GET", "/test", nil)
@@ -48,7 +49,7 @@ func TestSecurityHeadersWithCORS(t *testing.T) {
 	})
 
 	// Wrap with security headers
-	secureHandler := securityHeaders(handler)
+	secureHandler := securityHeaders(appconf.DefaultCORSConfig(), handler)
 
 	// Create test request with Origin header
 	req := httptest.NewRequest("GET", "/test", nil)
@@ -65,10 +66,45 @@ func TestSecurityHeadersWithCORS(t *testing.T) {
 	headers := rec.Header()
 	assert.Equal(t, "*", headers.Get("Access-Control-Allow-Origin"))
 	assert.Equal(t, "GET, OPTIONS", headers.Get("Access-Control-Allow-Methods"))
-	assert.Equal(t, "Content-Type, Authorization", headers.Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "Content-Type, Authorization, X-API-Key", headers.Get("Access-Control-Allow-Headers"))
 	assert.Equal(t, "86400", headers.Get("Access-Control-Max-Age"))
 }
 
+func TestSecurityHeadersConfiguredCORS(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	secureHandler := securityHeaders(appconf.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.transit.example"},
+		AllowedMethods: []string{"GET", "POST", "DELETE", "OPTIONS"},
+		MaxAge:         600,
+	}, handler)
+
+	serve := func(origin string) http.Header {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		secureHandler.ServeHTTP(rec, req)
+		return rec.Header()
+	}
+
+	headers := serve("https://app.example.com")
+	assert.Equal(t, "https://app.example.com", headers.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, DELETE, OPTIONS", headers.Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "600", headers.Get("Access-Control-Max-Age"))
+	assert.Equal(t, "Origin", headers.Get("Vary"))
+
+	headers = serve("https://maps.transit.example")
+	assert.Equal(t, "https://maps.transit.example", headers.Get("Access-Control-Allow-Origin"))
+
+	for _, origin := range []string{"https://evil.example", "http://app.example.com", "https://transit.example"} {
+		headers = serve(origin)
+		assert.Empty(t, headers.Get("Access-Control-Allow-Origin"), origin)
+		assert.Empty(t, headers.Get("Access-Control-Allow-Methods"), origin)
+		assert.Equal(t, "Origin", headers.Get("Vary"), origin)
+	}
+}
+
 func TestSecurityHeadersOPTIONSRequest(t *testing.T) {
 	// Create a handler that should not be called for OPTIONS
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -76,7 +112,7 @@ func TestSecurityHeadersOPTIONSRequest(t *testing.T) {
 	})
 
 	// Wrap with security headers
-	secureHandler := securityHeaders(handler)
+	secureHandler := securityHeaders(appconf.DefaultCORSConfig(), handler)
 
 	// Create OPTIONS request
 	req := httptest.NewRequest("OPTIONS", "/test", nil)
@@ -129,7 +165,7 @@ func TestSecurityHeadersNoCORSWithoutOrigin(t *testing.T) {
 	})
 
 	// Wrap with security headers
-	secureHandler := securityHeaders(handler)
+	secureHandler := securityHeaders(appconf.DefaultCORSConfig(), handler)
 
 	// Create test request without Origin header
 	req := httptest.NewRequest("GET", "/test", nil)
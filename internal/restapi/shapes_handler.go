@@ -18,7 +18,7 @@ func (api *RestAPI) shapesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	agencyID, shapeID, err := utils.ExtractAgencyIDAndCodeID(id)
+	agencyID, shapeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -45,6 +45,16 @@ func (api *RestAPI) shapesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(shapes) == 0 {
+		// A detour shape published with GTFS-RT trip modifications
+		if points, ok := api.GtfsManager.GetRealtimeShape(shapeID); ok {
+			shapeEntry := models.ShapeEntry{
+				Length: len(points),
+				Levels: "",
+				Points: string(polyline.EncodeCoords(points)),
+			}
+			api.sendResponse(w, r, models.NewEntryResponse(shapeEntry, models.NewEmptyReferences(), api.Clock))
+			return
+		}
 		api.sendNotFound(w, r)
 		return
 	}
@@ -0,0 +1,121 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestSignedRequestMiddleware(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	// The app clock is simulated hours away; signatures are checked against the wall clock
+	api := createTestApiWithClock(t, clock.NewMockClock(now.Add(-6*time.Hour)))
+	defer api.Shutdown()
+	api.WallClock = clock.NewMockClock(now)
+	api.Config.SignedRequests = appconf.SignedRequestConfig{
+		Secrets:      map[string]string{"TEST": "s3cret"},
+		MaxClockSkew: 60,
+		Required:     true,
+	}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	serve := func(r *http.Request) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	t.Run("valid signature authenticates without key in URL", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json", nil)
+		SignRequest(req, "TEST", "s3cret", now)
+		assert.Equal(t, http.StatusOK, serve(req))
+	})
+
+	t.Run("a signature is accepted once", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json?replay=1", nil)
+		SignRequest(req, "TEST", "s3cret", now)
+		replay := req.Clone(req.Context())
+		assert.Equal(t, http.StatusOK, serve(req))
+		assert.Equal(t, http.StatusUnauthorized, serve(replay))
+	})
+
+	t.Run("query parameters are covered by the signature", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json?a=1", nil)
+		SignRequest(req, "TEST", "s3cret", now)
+		req.URL.RawQuery = "a=2"
+		assert.Equal(t, http.StatusUnauthorized, serve(req))
+	})
+
+	t.Run("wrong secret is rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json", nil)
+		SignRequest(req, "TEST", "wrong", now)
+		assert.Equal(t, http.StatusUnauthorized, serve(req))
+	})
+
+	t.Run("stale timestamp is rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json", nil)
+		SignRequest(req, "TEST", "s3cret", now.Add(-2*time.Minute))
+		assert.Equal(t, http.StatusUnauthorized, serve(req))
+	})
+
+	t.Run("unsigned request from a signing key is rejected when required", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json?key=TEST", nil)
+		assert.Equal(t, http.StatusUnauthorized, serve(req))
+	})
+
+	t.Run("keys without a secret keep using the query string", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json?key=test", nil)
+		assert.Equal(t, http.StatusOK, serve(req))
+	})
+}
+
+func TestSignedRequestMiddleware_ReloadRotatesSecrets(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeReloadConfig(t, path, map[string]interface{}{
+		"api-keys":        []string{"TEST"},
+		"signed-requests": map[string]interface{}{"secrets": map[string]string{"TEST": "old"}},
+	})
+	initial, err := appconf.LoadFromFile(path)
+	require.NoError(t, err)
+	api.Config = initial.ToAppConfig()
+	api.ConfigFiles = []string{path}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	serve := func(secret string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/where/current-time.json", nil)
+		SignRequest(req, "TEST", secret, time.Now())
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, serve("old"))
+
+	writeReloadConfig(t, path, map[string]interface{}{
+		"api-keys":        []string{"TEST"},
+		"signed-requests": map[string]interface{}{"secrets": map[string]string{"TEST": "new"}},
+	})
+	// Feed changes are covered by the gtfs package; keep the shared manager untouched
+	manager := api.GtfsManager
+	api.GtfsManager = nil
+	result, err := api.ReloadConfig()
+	api.GtfsManager = manager
+	require.NoError(t, err)
+	assert.Contains(t, result.Changed, "SignedRequests: updated, 1 keys with secrets")
+	assert.Empty(t, result.RestartRequired)
+
+	assert.Equal(t, http.StatusUnauthorized, serve("old"))
+	assert.Equal(t, http.StatusOK, serve("new"))
+}
@@ -0,0 +1,31 @@
+package restapi
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"maglev.onebusaway.org/internal/siri"
+)
+
+// sendSiri writes a SIRI response as XML for .xml endpoints and as SIRI-JSON otherwise. The
+// document is encoded before the status is written so an encoding failure still becomes a 500.
+func (api *RestAPI) sendSiri(w http.ResponseWriter, r *http.Request, status int, response *siri.Siri) {
+	var buf bytes.Buffer
+	contentType := "application/json"
+	var err error
+	if strings.HasSuffix(r.URL.Path, ".xml") {
+		contentType = "application/xml"
+		err = response.WriteXML(&buf)
+	} else {
+		err = response.WriteJSON(&buf)
+	}
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
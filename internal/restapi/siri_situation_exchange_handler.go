@@ -0,0 +1,299 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/siri"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// siriSituationExchangeHandler serves SIRI Situation Exchange: each GTFS-RT service alert as a
+// PtSituationElement with its active periods as validity periods and its informed entities as
+// affected networks, lines, stops and vehicle journeys. LineRef and StopPointRef keep only the
+// situations affecting that line or stop.
+func (api *RestAPI) siriSituationExchangeHandler(w http.ResponseWriter, r *http.Request) {
+	now := api.Clock.Now()
+
+	query := r.URL.Query()
+	lineRef := query.Get("LineRef")
+	stopPointRef := query.Get("StopPointRef")
+	lineAgencyID := ""
+	if lineRef != "" {
+		var err error
+		lineAgencyID, _, err = api.GtfsManager.ExtractAgencyIDAndCodeID(lineRef)
+		if err != nil {
+			api.siriSituationExchangeError(w, r, now, http.StatusBadRequest, "LineRef "+err.Error())
+			return
+		}
+	}
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	resolver := newSiriAgencyResolver(r.Context(), api)
+	langs := alertLanguages(w, r)
+	situations := make([]siri.PtSituationElement, 0)
+	for _, alert := range api.GtfsManager.GetRealTimeAlerts() {
+		situation := api.siriSituation(alert, resolver, langs, now)
+		if lineRef != "" && !siriSituationAffectsLine(situation, lineRef, lineAgencyID) {
+			continue
+		}
+		if stopPointRef != "" && !siriSituationAffectsStop(situation, stopPointRef) {
+			continue
+		}
+		situations = append(situations, situation)
+	}
+
+	response := siri.New(now)
+	response.ServiceDelivery.SituationExchangeDelivery = []siri.SituationExchangeDelivery{{
+		Version:           siri.Version,
+		ResponseTimestamp: now,
+		Status:            true,
+		Situations:        siri.Situations{PtSituationElement: situations},
+	}}
+	api.sendSiri(w, r, http.StatusOK, response)
+}
+
+// siriSituation maps a service alert to a situation, with its text in the first of langs the
+// alert is translated to. GTFS-RT has no creation time, so it is the start of the first active
+// period; an alert without active periods is valid from now on.
+func (api *RestAPI) siriSituation(alert gtfs.Alert, resolver *siriAgencyResolver, langs []string, now time.Time) siri.PtSituationElement {
+	situation := siri.PtSituationElement{
+		CreationTime:    now,
+		SituationNumber: alert.ID,
+		Progress:        "open",
+		ValidityPeriod:  make([]siri.ValidityPeriod, 0, len(alert.ActivePeriods)),
+		Severity:        mapAlertEffectToSeverity(alert.Effect),
+	}
+
+	for _, period := range alert.ActivePeriods {
+		validity := siri.ValidityPeriod{StartTime: now, EndTime: period.EndsAt}
+		if period.StartsAt != nil {
+			validity.StartTime = *period.StartsAt
+		}
+		situation.ValidityPeriod = append(situation.ValidityPeriod, validity)
+	}
+	if len(situation.ValidityPeriod) > 0 {
+		situation.CreationTime = situation.ValidityPeriod[0].StartTime
+	} else {
+		situation.ValidityPeriod = append(situation.ValidityPeriod, siri.ValidityPeriod{StartTime: now})
+	}
+
+	if text, ok := alertText(alert.Header, langs); ok {
+		situation.Summary = text.Text
+	}
+	if text, ok := alertText(alert.Description, langs); ok {
+		situation.Description = text.Text
+	}
+	if text, ok := alertText(alert.URL, langs); ok {
+		situation.InfoLinks = &siri.InfoLinks{InfoLink: []siri.InfoLink{{Uri: text.Text}}}
+	}
+
+	situation.Affects = api.siriAffects(alert.InformedEntities, resolver)
+	return situation
+}
+
+// siriAffects groups the informed entities of an alert: an agency alone affects its whole
+// network, a route one of its lines (in one direction when given), a stop a stop point, and a
+// trip a vehicle journey.
+func (api *RestAPI) siriAffects(entities []gtfs.AlertInformedEntity, resolver *siriAgencyResolver) siri.Affects {
+	var affects siri.Affects
+	networks := make(map[string]*siri.AffectedNetwork)
+	var networkOrder []string
+	network := func(agencyID string) *siri.AffectedNetwork {
+		if n, ok := networks[agencyID]; ok {
+			return n
+		}
+		n := &siri.AffectedNetwork{AffectedOperator: &siri.AffectedOperator{OperatorRef: agencyID}}
+		networks[agencyID] = n
+		networkOrder = append(networkOrder, agencyID)
+		return n
+	}
+	seen := make(map[string]bool)
+
+	for _, entity := range entities {
+		routeID := getStringValue(entity.RouteID)
+		if entity.TripID != nil && entity.TripID.ID != "" {
+			agencyID, tripRouteID := resolver.trip(entity.TripID.ID)
+			if routeID == "" {
+				routeID = tripRouteID
+			}
+			journey := siri.AffectedVehicleJourney{DatedVehicleJourneyRef: utils.FormCombinedID(agencyID, entity.TripID.ID)}
+			if routeID != "" {
+				journey.LineRef = utils.FormCombinedID(agencyID, routeID)
+			}
+			if key := "trip:" + journey.DatedVehicleJourneyRef; !seen[key] {
+				seen[key] = true
+				if affects.VehicleJourneys == nil {
+					affects.VehicleJourneys = &siri.AffectedVehicleJourneys{}
+				}
+				affects.VehicleJourneys.AffectedVehicleJourney = append(affects.VehicleJourneys.AffectedVehicleJourney, journey)
+			}
+			continue
+		}
+
+		agencyID := getStringValue(entity.AgencyID)
+		if agencyID == "" {
+			agencyID = resolver.route(routeID)
+		}
+
+		if routeID != "" {
+			line := siri.AffectedLine{LineRef: utils.FormCombinedID(agencyID, routeID)}
+			switch entity.DirectionID {
+			case gtfs.DirectionID_False:
+				line.DirectionRef = "0"
+			case gtfs.DirectionID_True:
+				line.DirectionRef = "1"
+			}
+			if key := "line:" + line.LineRef + ":" + line.DirectionRef; !seen[key] {
+				seen[key] = true
+				n := network(agencyID)
+				n.AffectedLine = append(n.AffectedLine, line)
+			}
+		}
+
+		if entity.StopID != nil {
+			stopPoint := siri.AffectedStopPoint{
+				StopPointRef:  utils.FormCombinedID(agencyID, *entity.StopID),
+				StopPointName: resolver.stopName(*entity.StopID),
+			}
+			if key := "stop:" + stopPoint.StopPointRef; !seen[key] {
+				seen[key] = true
+				if affects.StopPoints == nil {
+					affects.StopPoints = &siri.AffectedStopPoints{}
+				}
+				affects.StopPoints.AffectedStopPoint = append(affects.StopPoints.AffectedStopPoint, stopPoint)
+			}
+		}
+
+		if routeID == "" && entity.StopID == nil && entity.AgencyID != nil {
+			network(agencyID).AllLines = &struct{}{}
+		}
+	}
+
+	if len(networkOrder) > 0 {
+		affects.Networks = &siri.AffectedNetworks{}
+		for _, agencyID := range networkOrder {
+			n := networks[agencyID]
+			if n.AllLines != nil {
+				n.AffectedLine = nil
+			}
+			affects.Networks.AffectedNetwork = append(affects.Networks.AffectedNetwork, *n)
+		}
+	}
+	return affects
+}
+
+// siriSituationAffectsLine reports whether a situation affects lineRef, directly, through one
+// of its vehicle journeys, or as part of a whole network.
+func siriSituationAffectsLine(situation siri.PtSituationElement, lineRef, agencyID string) bool {
+	if networks := situation.Affects.Networks; networks != nil {
+		for _, network := range networks.AffectedNetwork {
+			if network.AllLines != nil && network.AffectedOperator != nil && network.AffectedOperator.OperatorRef == agencyID {
+				return true
+			}
+			for _, line := range network.AffectedLine {
+				if line.LineRef == lineRef {
+					return true
+				}
+			}
+		}
+	}
+	if journeys := situation.Affects.VehicleJourneys; journeys != nil {
+		for _, journey := range journeys.AffectedVehicleJourney {
+			if journey.LineRef == lineRef {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// siriSituationAffectsStop reports whether a situation names stopPointRef.
+func siriSituationAffectsStop(situation siri.PtSituationElement, stopPointRef string) bool {
+	if stopPoints := situation.Affects.StopPoints; stopPoints != nil {
+		for _, stopPoint := range stopPoints.AffectedStopPoint {
+			if stopPoint.StopPointRef == stopPointRef {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// siriSituationExchangeError sends a Situation Exchange delivery with Status false.
+func (api *RestAPI) siriSituationExchangeError(w http.ResponseWriter, r *http.Request, now time.Time, status int, description string) {
+	response := siri.New(now)
+	response.ServiceDelivery.SituationExchangeDelivery = []siri.SituationExchangeDelivery{{
+		Version:           siri.Version,
+		ResponseTimestamp: now,
+		ErrorCondition:    &siri.ErrorCondition{Description: description},
+		Situations:        siri.Situations{PtSituationElement: []siri.PtSituationElement{}},
+	}}
+	api.sendSiri(w, r, status, response)
+}
+
+// siriAgencyResolver finds the agency owning the routes, trips and stops named by alerts, so
+// they can be given combined IDs, caching lookups for one request. Entities it cannot resolve,
+// including stops, which have no agency in GTFS, belong to the first agency of the feed.
+type siriAgencyResolver struct {
+	ctx           context.Context
+	api           *RestAPI
+	defaultAgency string
+	routes        map[string]string
+	stopNames     map[string]string
+}
+
+func newSiriAgencyResolver(ctx context.Context, api *RestAPI) *siriAgencyResolver {
+	resolver := &siriAgencyResolver{
+		ctx:       ctx,
+		api:       api,
+		routes:    make(map[string]string),
+		stopNames: make(map[string]string),
+	}
+	if agencies := api.GtfsManager.GetAgencies(); len(agencies) > 0 {
+		resolver.defaultAgency = agencies[0].Id
+	}
+	return resolver
+}
+
+// route returns the agency of a route.
+func (resolver *siriAgencyResolver) route(routeID string) string {
+	if routeID == "" {
+		return resolver.defaultAgency
+	}
+	if agencyID, ok := resolver.routes[routeID]; ok {
+		return agencyID
+	}
+	agencyID := resolver.defaultAgency
+	if route, err := resolver.api.GtfsManager.GtfsDB.Queries.GetRoute(resolver.ctx, routeID); err == nil {
+		agencyID = route.AgencyID
+	}
+	resolver.routes[routeID] = agencyID
+	return agencyID
+}
+
+// trip returns the agency and route of a trip.
+func (resolver *siriAgencyResolver) trip(tripID string) (agencyID, routeID string) {
+	trip, err := resolver.api.GtfsManager.GtfsDB.Queries.GetTrip(resolver.ctx, tripID)
+	if err != nil {
+		return resolver.defaultAgency, ""
+	}
+	return resolver.route(trip.RouteID), trip.RouteID
+}
+
+// stopName returns the name of a stop, or "" when it is not in the static feed.
+func (resolver *siriAgencyResolver) stopName(stopID string) string {
+	if name, ok := resolver.stopNames[stopID]; ok {
+		return name
+	}
+	name := ""
+	if stop, err := resolver.api.GtfsManager.GtfsDB.Queries.GetStop(resolver.ctx, stopID); err == nil {
+		name = stop.Name.String
+	}
+	resolver.stopNames[stopID] = name
+	return name
+}
@@ -0,0 +1,131 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/siri"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestSiriSituationExchangeHandler(t *testing.T) {
+	now := time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)
+	api := createTestApiWithClock(t, clock.NewMockClock(now))
+	defer api.Shutdown()
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	trip := api.GtfsManager.GetTrips()[0]
+	routeID := trip.Route.Id
+	stop := api.GtfsManager.GetStops()[0]
+	start := now.Add(-time.Hour)
+	end := now.Add(time.Hour)
+
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:            "SIRI_SX_DETOUR",
+		Effect:        gtfs.Detour,
+		ActivePeriods: []gtfs.AlertActivePeriod{{StartsAt: &start, EndsAt: &end}},
+		InformedEntities: []gtfs.AlertInformedEntity{
+			{RouteID: &routeID, DirectionID: gtfs.DirectionID_True},
+			{StopID: &stop.Id},
+			{TripID: &gtfs.TripID{ID: trip.ID}},
+		},
+		Header:      []gtfs.AlertText{{Text: "Detour on Main St", Language: "en"}},
+		Description: []gtfs.AlertText{{Text: "Buses detour via 2nd St", Language: "en"}},
+		URL:         []gtfs.AlertText{{Text: "https://example.com/detour"}},
+	})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "SIRI_SX_NETWORK",
+		InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: &agencyID}},
+		Header:           []gtfs.AlertText{{Text: "Holiday service"}},
+	})
+	t.Cleanup(func() {
+		api.GtfsManager.MockRemoveAlert("SIRI_SX_DETOUR")
+		api.GtfsManager.MockRemoveAlert("SIRI_SX_NETWORK")
+	})
+
+	situations := func(t *testing.T, query string) map[string]siri.PtSituationElement {
+		rec := serveSiri(t, api, "/api/siri/situation-exchange.json?key="+siriTestKey+query)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			Siri siri.Siri `json:"Siri"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		require.Len(t, body.Siri.ServiceDelivery.SituationExchangeDelivery, 1)
+		delivery := body.Siri.ServiceDelivery.SituationExchangeDelivery[0]
+		assert.True(t, delivery.Status)
+
+		byNumber := make(map[string]siri.PtSituationElement)
+		for _, situation := range delivery.Situations.PtSituationElement {
+			byNumber[situation.SituationNumber] = situation
+		}
+		return byNumber
+	}
+
+	t.Run("alerts become situations", func(t *testing.T) {
+		all := situations(t, "")
+		require.Contains(t, all, "SIRI_SX_DETOUR")
+		require.Contains(t, all, "SIRI_SX_NETWORK")
+
+		detour := all["SIRI_SX_DETOUR"]
+		assert.Equal(t, "open", detour.Progress)
+		assert.Equal(t, "normal", detour.Severity)
+		assert.Equal(t, "Detour on Main St", detour.Summary)
+		assert.Equal(t, "Buses detour via 2nd St", detour.Description)
+		require.NotNil(t, detour.InfoLinks)
+		assert.Equal(t, "https://example.com/detour", detour.InfoLinks.InfoLink[0].Uri)
+		require.Len(t, detour.ValidityPeriod, 1)
+		assert.True(t, start.Equal(detour.ValidityPeriod[0].StartTime))
+		require.NotNil(t, detour.ValidityPeriod[0].EndTime)
+		assert.True(t, end.Equal(*detour.ValidityPeriod[0].EndTime))
+
+		lineRef := utils.FormCombinedID(agencyID, routeID)
+		require.NotNil(t, detour.Affects.Networks)
+		network := detour.Affects.Networks.AffectedNetwork[0]
+		assert.Equal(t, agencyID, network.AffectedOperator.OperatorRef)
+		assert.Nil(t, network.AllLines)
+		assert.Equal(t, []siri.AffectedLine{{LineRef: lineRef, DirectionRef: "1"}}, network.AffectedLine)
+		require.NotNil(t, detour.Affects.StopPoints)
+		assert.Equal(t, utils.FormCombinedID(agencyID, stop.Id), detour.Affects.StopPoints.AffectedStopPoint[0].StopPointRef)
+		assert.Equal(t, stop.Name, detour.Affects.StopPoints.AffectedStopPoint[0].StopPointName)
+		require.NotNil(t, detour.Affects.VehicleJourneys)
+		assert.Equal(t, siri.AffectedVehicleJourney{
+			DatedVehicleJourneyRef: utils.FormCombinedID(agencyID, trip.ID),
+			LineRef:                lineRef,
+		}, detour.Affects.VehicleJourneys.AffectedVehicleJourney[0])
+
+		holiday := all["SIRI_SX_NETWORK"]
+		require.Len(t, holiday.ValidityPeriod, 1, "an alert without active periods is valid from now")
+		assert.True(t, now.Equal(holiday.ValidityPeriod[0].StartTime))
+		require.NotNil(t, holiday.Affects.Networks)
+		assert.NotNil(t, holiday.Affects.Networks.AffectedNetwork[0].AllLines)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		byStop := situations(t, "&StopPointRef="+utils.FormCombinedID(agencyID, stop.Id))
+		assert.Contains(t, byStop, "SIRI_SX_DETOUR")
+		assert.NotContains(t, byStop, "SIRI_SX_NETWORK")
+
+		byLine := situations(t, "&LineRef="+utils.FormCombinedID(agencyID, routeID))
+		assert.Contains(t, byLine, "SIRI_SX_DETOUR")
+		assert.Contains(t, byLine, "SIRI_SX_NETWORK", "a whole-network situation affects every line")
+
+		assert.Empty(t, situations(t, "&LineRef=other_agency_route"))
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/siri/situation-exchange.xml?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "<SituationNumber>SIRI_SX_DETOUR</SituationNumber>")
+		assert.Contains(t, body, "<Direction>\n")
+		assert.Contains(t, body, "<AllLines></AllLines>")
+		assert.Equal(t, 1, strings.Count(body, "<Direction>"), "lines without a direction omit it")
+	})
+}
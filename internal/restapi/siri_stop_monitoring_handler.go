@@ -0,0 +1,198 @@
+package restapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/internal/siri"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	siriDefaultPreviewInterval = 90 * time.Minute
+	siriMaxPreviewInterval     = 240 * time.Minute
+)
+
+// siriStopMonitoringHandler serves SIRI Stop Monitoring: the upcoming departures from the stop
+// named by MonitoringRef, from the same schedule and realtime state as
+// arrivals-and-departures-for-stop. LineRef and DirectionRef filter the visits,
+// MaximumStopVisits caps them, and PreviewInterval (an ISO 8601 duration such as PT90M) sets
+// how far ahead to look.
+func (api *RestAPI) siriStopMonitoringHandler(w http.ResponseWriter, r *http.Request) {
+	requestClock, err := api.requestClock(r)
+	if err != nil {
+		api.siriStopMonitoringError(w, r, time.Now(), http.StatusBadRequest, debugTimeParam+" "+err.Error())
+		return
+	}
+	now := requestClock.Now()
+
+	query := r.URL.Query()
+	monitoringRef := query.Get("MonitoringRef")
+	if monitoringRef == "" {
+		api.siriStopMonitoringError(w, r, now, http.StatusBadRequest, "MonitoringRef is required")
+		return
+	}
+	agencyID, stopCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(monitoringRef)
+	if err != nil {
+		api.siriStopMonitoringError(w, r, now, http.StatusBadRequest, "MonitoringRef "+err.Error())
+		return
+	}
+
+	maxVisits := 0
+	if val := query.Get("MaximumStopVisits"); val != "" {
+		maxVisits, err = strconv.Atoi(val)
+		if err != nil || maxVisits < 1 {
+			api.siriStopMonitoringError(w, r, now, http.StatusBadRequest, "MaximumStopVisits must be a positive integer")
+			return
+		}
+	}
+
+	preview := siriDefaultPreviewInterval
+	if val := query.Get("PreviewInterval"); val != "" {
+		preview, err = parseSiriDuration(val)
+		if err != nil || preview <= 0 {
+			api.siriStopMonitoringError(w, r, now, http.StatusBadRequest, "PreviewInterval must be a positive ISO 8601 duration such as PT90M")
+			return
+		}
+		preview = min(preview, siriMaxPreviewInterval)
+	}
+
+	lineRef := query.Get("LineRef")
+	directionRef := query.Get("DirectionRef")
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopCode)
+	if err != nil {
+		api.siriStopMonitoringError(w, r, now, http.StatusNotFound, "unknown stop "+monitoringRef)
+		return
+	}
+
+	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
+	if err != nil {
+		api.siriStopMonitoringError(w, r, now, http.StatusNotFound, "unknown agency "+agencyID)
+		return
+	}
+
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agencyID)
+	now = now.In(loc)
+
+	departures, err := api.upcomingDepartures(ctx, stopCode, now, preview)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	visits := make([]siri.MonitoredStopVisit, 0, len(departures))
+	for _, departure := range departures {
+		route, trip, prediction := departure.Route, departure.Trip, departure.Prediction
+		if lineRef != "" && utils.FormCombinedID(agencyID, route.ID) != lineRef {
+			continue
+		}
+		direction := ""
+		if trip.DirectionID.Valid {
+			direction = strconv.FormatInt(trip.DirectionID.Int64, 10)
+		}
+		if directionRef != "" && direction != directionRef {
+			continue
+		}
+
+		call := &siri.MonitoredCall{
+			StopPointRef:       monitoringRef,
+			StopPointName:      stop.Name.String,
+			Order:              int(departure.StopTime.StopSequence),
+			AimedArrivalTime:   departure.AimedArrival,
+			AimedDepartureTime: departure.AimedDeparture,
+		}
+		if prediction.Predicted {
+			expectedArrival := time.UnixMilli(prediction.ArrivalTime).In(loc)
+			expectedDeparture := time.UnixMilli(prediction.DepartureTime).In(loc)
+			call.ExpectedArrivalTime = &expectedArrival
+			call.ExpectedDepartureTime = &expectedDeparture
+		}
+
+		journey := siri.MonitoredVehicleJourney{
+			LineRef:      utils.FormCombinedID(agencyID, route.ID),
+			DirectionRef: direction,
+			FramedVehicleJourneyRef: siri.FramedVehicleJourneyRef{
+				DataFrameRef:           departure.ServiceDate.Format("2006-01-02"),
+				DatedVehicleJourneyRef: utils.FormCombinedID(agencyID, trip.ID),
+			},
+			PublishedLineName: route.ShortName.String,
+			OperatorRef:       agencyID,
+			DestinationName:   departure.StopTime.TripHeadsign.String,
+			Monitored:         prediction.Predicted,
+			MonitoredCall:     call,
+		}
+
+		recordedAt := now
+		if vehicle := prediction.Vehicle; vehicle != nil {
+			journey.VehicleRef = utils.FormCombinedID(agencyID, prediction.VehicleID)
+			if vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
+				journey.VehicleLocation = &siri.Location{
+					Longitude: float64(*vehicle.Position.Longitude),
+					Latitude:  float64(*vehicle.Position.Latitude),
+				}
+				journey.Bearing = vehicle.Position.Bearing
+			}
+			if vehicle.Timestamp != nil {
+				recordedAt = vehicle.Timestamp.In(loc)
+			}
+		}
+
+		visits = append(visits, siri.MonitoredStopVisit{
+			RecordedAtTime:          recordedAt,
+			MonitoringRef:           monitoringRef,
+			MonitoredVehicleJourney: journey,
+		})
+	}
+
+	if maxVisits > 0 && len(visits) > maxVisits {
+		visits = visits[:maxVisits]
+	}
+
+	response := siri.New(now)
+	response.ServiceDelivery.StopMonitoringDelivery = []siri.StopMonitoringDelivery{{
+		Version:            siri.Version,
+		ResponseTimestamp:  now,
+		Status:             true,
+		MonitoredStopVisit: visits,
+	}}
+	api.sendSiri(w, r, http.StatusOK, response)
+}
+
+// siriStopMonitoringError sends a Stop Monitoring delivery with Status false.
+func (api *RestAPI) siriStopMonitoringError(w http.ResponseWriter, r *http.Request, now time.Time, status int, description string) {
+	response := siri.New(now)
+	response.ServiceDelivery.StopMonitoringDelivery = []siri.StopMonitoringDelivery{{
+		Version:            siri.Version,
+		ResponseTimestamp:  now,
+		ErrorCondition:     &siri.ErrorCondition{Description: description},
+		MonitoredStopVisit: []siri.MonitoredStopVisit{},
+	}}
+	api.sendSiri(w, r, status, response)
+}
+
+// parseSiriDuration parses the hour, minute and second parts of an ISO 8601 duration, such
+// as PT1H30M.
+func parseSiriDuration(value string) (time.Duration, error) {
+	rest, found := strings.CutPrefix(strings.ToUpper(value), "PT")
+	if !found || rest == "" {
+		return 0, strconv.ErrSyntax
+	}
+	return time.ParseDuration(strings.ToLower(rest))
+}
+
+// mapKeys returns the keys of a set in no particular order.
+func mapKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	return keys
+}
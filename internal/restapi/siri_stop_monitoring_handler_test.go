@@ -0,0 +1,129 @@
+package restapi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/siri"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// siriTestKey is exempt from rate limiting, as the SIRI tests make many requests.
+const siriTestKey = "org.onebusaway.iphone"
+
+// serveSiri performs a SIRI request against a fresh mux.
+func serveSiri(t *testing.T, api *RestAPI, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestSiriStopMonitoringHandler(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)))
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	stopID := utils.FormCombinedID(agency.Id, api.GtfsManager.GetStops()[0].Id)
+
+	t.Run("json", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/siri/stop-monitoring.json?key="+siriTestKey+"&MonitoringRef="+stopID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var body struct {
+			Siri siri.Siri `json:"Siri"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		require.Len(t, body.Siri.ServiceDelivery.StopMonitoringDelivery, 1)
+		delivery := body.Siri.ServiceDelivery.StopMonitoringDelivery[0]
+		assert.True(t, delivery.Status)
+		require.NotEmpty(t, delivery.MonitoredStopVisit)
+
+		var previous time.Time
+		for _, visit := range delivery.MonitoredStopVisit {
+			assert.Equal(t, stopID, visit.MonitoringRef)
+			journey := visit.MonitoredVehicleJourney
+			assert.Equal(t, agency.Id, journey.OperatorRef)
+			assert.Equal(t, "2025-06-12", journey.FramedVehicleJourneyRef.DataFrameRef)
+			require.NotNil(t, journey.MonitoredCall)
+			assert.Equal(t, stopID, journey.MonitoredCall.StopPointRef)
+			assert.False(t, journey.MonitoredCall.AimedDepartureTime.Before(previous), "visits are in departure order")
+			previous = journey.MonitoredCall.AimedDepartureTime
+		}
+
+		lineRef := delivery.MonitoredStopVisit[0].MonitoredVehicleJourney.LineRef
+		rec = serveSiri(t, api, "/api/siri/stop-monitoring.json?key="+siriTestKey+"&MaximumStopVisits=1&LineRef="+lineRef+"&MonitoringRef="+stopID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		visits := body.Siri.ServiceDelivery.StopMonitoringDelivery[0].MonitoredStopVisit
+		require.Len(t, visits, 1)
+		assert.Equal(t, lineRef, visits[0].MonitoredVehicleJourney.LineRef)
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/siri/stop-monitoring.xml?key="+siriTestKey+"&PreviewInterval=PT30M&MonitoringRef="+stopID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+
+		var body siri.Siri
+		require.NoError(t, xml.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, siri.Version, body.Version)
+		require.Len(t, body.ServiceDelivery.StopMonitoringDelivery, 1)
+		delivery := body.ServiceDelivery.StopMonitoringDelivery[0]
+		assert.True(t, delivery.Status)
+		for _, visit := range delivery.MonitoredStopVisit {
+			assert.True(t, visit.MonitoredVehicleJourney.MonitoredCall.AimedDepartureTime.Before(time.Date(2025, 6, 12, 15, 30, 1, 0, time.UTC)))
+		}
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"missing MonitoringRef", "", http.StatusBadRequest},
+		{"malformed MonitoringRef", "&MonitoringRef=nounderscore", http.StatusBadRequest},
+		{"unknown stop", "&MonitoringRef=" + utils.FormCombinedID(agency.Id, "no-such-stop"), http.StatusNotFound},
+		{"invalid MaximumStopVisits", "&MonitoringRef=" + stopID + "&MaximumStopVisits=0", http.StatusBadRequest},
+		{"invalid PreviewInterval", "&MonitoringRef=" + stopID + "&PreviewInterval=90", http.StatusBadRequest},
+		{"debugTime without an admin key", "&MonitoringRef=" + stopID + "&debugTime=1749740400000", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveSiri(t, api, "/api/siri/stop-monitoring.json?key="+siriTestKey+tt.query)
+			assert.Equal(t, tt.status, rec.Code)
+
+			var body struct {
+				Siri siri.Siri `json:"Siri"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			require.Len(t, body.Siri.ServiceDelivery.StopMonitoringDelivery, 1)
+			delivery := body.Siri.ServiceDelivery.StopMonitoringDelivery[0]
+			assert.False(t, delivery.Status)
+			require.NotNil(t, delivery.ErrorCondition)
+			assert.NotEmpty(t, delivery.ErrorCondition.Description)
+		})
+	}
+}
+
+func TestParseSiriDuration(t *testing.T) {
+	d, err := parseSiriDuration("PT1H30M")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	for _, value := range []string{"90", "PT", "P1D", "PTxM"} {
+		_, err := parseSiriDuration(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,180 @@
+package restapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/siri"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// siriVehicleActivityValidity is how long a vehicle activity stays valid after it is recorded:
+// two GTFS-RT refreshes, so one missed fetch does not expire it.
+const siriVehicleActivityValidity = time.Minute
+
+// siriVehicleMonitoringHandler serves SIRI Vehicle Monitoring: one VehicleActivity per vehicle
+// in the GTFS-RT vehicle positions that is assigned to a trip in the static feed. Line,
+// direction, operator and destination come from that trip. VehicleRef, LineRef, DirectionRef
+// and OperatorRef filter the vehicles and MaximumVehicles caps them.
+func (api *RestAPI) siriVehicleMonitoringHandler(w http.ResponseWriter, r *http.Request) {
+	now := api.Clock.Now()
+
+	query := r.URL.Query()
+	vehicleRef := query.Get("VehicleRef")
+	lineRef := query.Get("LineRef")
+	directionRef := query.Get("DirectionRef")
+	operatorRef := query.Get("OperatorRef")
+
+	maxVehicles := 0
+	if val := query.Get("MaximumVehicles"); val != "" {
+		var err error
+		maxVehicles, err = strconv.Atoi(val)
+		if err != nil || maxVehicles < 1 {
+			api.siriVehicleMonitoringError(w, r, now, http.StatusBadRequest, "MaximumVehicles must be a positive integer")
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	vehicles := api.GtfsManager.GetRealTimeVehicles()
+
+	tripIDs := make(map[string]bool)
+	for _, vehicle := range vehicles {
+		if vehicle.Trip != nil && vehicle.Trip.ID.ID != "" {
+			tripIDs[vehicle.Trip.ID.ID] = true
+		}
+	}
+
+	trips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByIDs(ctx, mapKeys(tripIDs))
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	tripsLookup := make(map[string]gtfsdb.Trip, len(trips))
+	routeIDs := make(map[string]bool)
+	for _, trip := range trips {
+		tripsLookup[trip.ID] = trip
+		routeIDs[trip.RouteID] = true
+	}
+
+	routes, err := api.GtfsManager.GtfsDB.Queries.GetRoutesByIDs(ctx, mapKeys(routeIDs))
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	routesLookup := make(map[string]gtfsdb.Route, len(routes))
+	for _, route := range routes {
+		routesLookup[route.ID] = route
+	}
+
+	locations := make(map[string]*time.Location)
+	activities := make([]siri.VehicleActivity, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		if vehicle.ID == nil || vehicle.Trip == nil {
+			continue
+		}
+		trip, tripExists := tripsLookup[vehicle.Trip.ID.ID]
+		if !tripExists {
+			continue
+		}
+		route, routeExists := routesLookup[trip.RouteID]
+		if !routeExists {
+			continue
+		}
+		agencyID := route.AgencyID
+
+		direction := ""
+		if trip.DirectionID.Valid {
+			direction = strconv.FormatInt(trip.DirectionID.Int64, 10)
+		}
+		journeyVehicleRef := utils.FormCombinedID(agencyID, vehicle.ID.ID)
+		journeyLineRef := utils.FormCombinedID(agencyID, route.ID)
+		if (vehicleRef != "" && journeyVehicleRef != vehicleRef) ||
+			(lineRef != "" && journeyLineRef != lineRef) ||
+			(directionRef != "" && direction != directionRef) ||
+			(operatorRef != "" && agencyID != operatorRef) {
+			continue
+		}
+
+		loc, ok := locations[agencyID]
+		if !ok {
+			loc = time.UTC
+			if agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID); err == nil {
+				loc = utils.LoadLocationWithUTCFallBack(agency.Timezone, agencyID)
+			}
+			locations[agencyID] = loc
+		}
+
+		serviceDate := now.In(loc)
+		if vehicle.Trip.ID.HasStartDate {
+			serviceDate = vehicle.Trip.ID.StartDate
+		}
+
+		journey := siri.MonitoredVehicleJourney{
+			LineRef:      journeyLineRef,
+			DirectionRef: direction,
+			FramedVehicleJourneyRef: siri.FramedVehicleJourneyRef{
+				DataFrameRef:           serviceDate.Format("2006-01-02"),
+				DatedVehicleJourneyRef: utils.FormCombinedID(agencyID, trip.ID),
+			},
+			PublishedLineName: route.ShortName.String,
+			OperatorRef:       agencyID,
+			DestinationName:   trip.TripHeadsign.String,
+			Monitored:         true,
+			VehicleRef:        journeyVehicleRef,
+		}
+		if vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
+			journey.VehicleLocation = &siri.Location{
+				Longitude: float64(*vehicle.Position.Longitude),
+				Latitude:  float64(*vehicle.Position.Latitude),
+			}
+			journey.Bearing = vehicle.Position.Bearing
+		}
+
+		recordedAt := now.In(loc)
+		if vehicle.Timestamp != nil {
+			recordedAt = vehicle.Timestamp.In(loc)
+		}
+
+		activities = append(activities, siri.VehicleActivity{
+			RecordedAtTime:          recordedAt,
+			ValidUntilTime:          recordedAt.Add(siriVehicleActivityValidity),
+			MonitoredVehicleJourney: journey,
+		})
+	}
+
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].MonitoredVehicleJourney.VehicleRef < activities[j].MonitoredVehicleJourney.VehicleRef
+	})
+	if maxVehicles > 0 && len(activities) > maxVehicles {
+		activities = activities[:maxVehicles]
+	}
+
+	response := siri.New(now)
+	response.ServiceDelivery.VehicleMonitoringDelivery = []siri.VehicleMonitoringDelivery{{
+		Version:           siri.Version,
+		ResponseTimestamp: now,
+		Status:            true,
+		VehicleActivity:   activities,
+	}}
+	api.sendSiri(w, r, http.StatusOK, response)
+}
+
+// siriVehicleMonitoringError sends a Vehicle Monitoring delivery with Status false.
+func (api *RestAPI) siriVehicleMonitoringError(w http.ResponseWriter, r *http.Request, now time.Time, status int, description string) {
+	response := siri.New(now)
+	response.ServiceDelivery.VehicleMonitoringDelivery = []siri.VehicleMonitoringDelivery{{
+		Version:           siri.Version,
+		ResponseTimestamp: now,
+		ErrorCondition:    &siri.ErrorCondition{Description: description},
+		VehicleActivity:   []siri.VehicleActivity{},
+	}}
+	api.sendSiri(w, r, status, response)
+}
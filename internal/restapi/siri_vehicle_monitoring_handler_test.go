@@ -0,0 +1,89 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/siri"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestSiriVehicleMonitoringHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	trip := api.GtfsManager.GetTrips()[0]
+	api.GtfsManager.MockAddVehicle("SIRI_VM_VEHICLE", trip.ID, trip.Route.Id)
+	api.GtfsManager.MockAddVehicle("SIRI_VM_UNKNOWN_TRIP", "no-such-trip", trip.Route.Id)
+	t.Cleanup(func() {
+		api.GtfsManager.MockRemoveVehicle("SIRI_VM_VEHICLE")
+		api.GtfsManager.MockRemoveVehicle("SIRI_VM_UNKNOWN_TRIP")
+	})
+	vehicleRef := utils.FormCombinedID(agencyID, "SIRI_VM_VEHICLE")
+
+	decode := func(t *testing.T, target string) (int, siri.VehicleMonitoringDelivery) {
+		rec := serveSiri(t, api, target)
+		var body struct {
+			Siri siri.Siri `json:"Siri"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		require.Len(t, body.Siri.ServiceDelivery.VehicleMonitoringDelivery, 1)
+		return rec.Code, body.Siri.ServiceDelivery.VehicleMonitoringDelivery[0]
+	}
+
+	t.Run("line and direction come from the static trip", func(t *testing.T) {
+		code, delivery := decode(t, "/api/siri/vehicle-monitoring.json?key="+siriTestKey+"&VehicleRef="+vehicleRef)
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, delivery.Status)
+		require.Len(t, delivery.VehicleActivity, 1)
+
+		activity := delivery.VehicleActivity[0]
+		assert.True(t, activity.ValidUntilTime.After(activity.RecordedAtTime))
+		journey := activity.MonitoredVehicleJourney
+		assert.Equal(t, vehicleRef, journey.VehicleRef)
+		assert.Equal(t, utils.FormCombinedID(agencyID, trip.Route.Id), journey.LineRef)
+		assert.Equal(t, utils.FormCombinedID(agencyID, trip.ID), journey.FramedVehicleJourneyRef.DatedVehicleJourneyRef)
+		assert.Equal(t, agencyID, journey.OperatorRef)
+		assert.Equal(t, trip.Headsign, journey.DestinationName)
+
+		dbTrip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(context.Background(), trip.ID)
+		require.NoError(t, err)
+		require.True(t, dbTrip.DirectionID.Valid)
+		assert.Equal(t, strconv.FormatInt(dbTrip.DirectionID.Int64, 10), journey.DirectionRef)
+	})
+
+	t.Run("vehicles without a static trip are left out", func(t *testing.T) {
+		_, delivery := decode(t, "/api/siri/vehicle-monitoring.json?key="+siriTestKey+"&VehicleRef="+utils.FormCombinedID(agencyID, "SIRI_VM_UNKNOWN_TRIP"))
+		assert.Empty(t, delivery.VehicleActivity)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		_, delivery := decode(t, "/api/siri/vehicle-monitoring.json?key="+siriTestKey+"&OperatorRef=no-such-agency")
+		assert.Empty(t, delivery.VehicleActivity)
+
+		_, delivery = decode(t, "/api/siri/vehicle-monitoring.json?key="+siriTestKey+"&MaximumVehicles=1&LineRef="+utils.FormCombinedID(agencyID, trip.Route.Id))
+		require.Len(t, delivery.VehicleActivity, 1)
+
+		code, delivery := decode(t, "/api/siri/vehicle-monitoring.json?key="+siriTestKey+"&MaximumVehicles=none")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.False(t, delivery.Status)
+		require.NotNil(t, delivery.ErrorCondition)
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/siri/vehicle-monitoring.xml?key="+siriTestKey+"&VehicleRef="+vehicleRef)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var body siri.Siri
+		require.NoError(t, xml.NewDecoder(rec.Body).Decode(&body))
+		require.Len(t, body.ServiceDelivery.VehicleMonitoringDelivery, 1)
+		assert.Len(t, body.ServiceDelivery.VehicleMonitoringDelivery[0].VehicleActivity, 1)
+	})
+}
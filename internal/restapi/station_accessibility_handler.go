@@ -0,0 +1,127 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/OneBusAway/go-gtfs"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// stationAccessibilityHandler lists the elevators, escalators and entrances of the station in
+// the request's ID, which may also name a platform or other location inside it, with the ones
+// that active service alerts put out of service.
+func (api *RestAPI) stationAccessibilityHandler(w http.ResponseWriter, r *http.Request) {
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	agencyID, codeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+
+	api.GtfsManager.RLock()
+	station, found := api.GtfsManager.GetStation(codeID)
+	api.GtfsManager.RUnlock()
+	if !found {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	alerts := api.GtfsManager.GetRealTimeAlerts()
+	outages, stationAlerts := station.FacilityOutages(alerts, api.Clock.Now())
+
+	nodes := make(map[string]GTFS.StationNode, len(station.Nodes))
+	for _, node := range station.Nodes {
+		nodes[node.ID] = node
+	}
+	levelName := func(nodeID string) string {
+		level, ok := station.Levels[nodes[nodeID].LevelID]
+		if !ok || level.Name != "" {
+			return level.Name
+		}
+		return level.ID
+	}
+	facility := func(id, name string) models.StationFacility {
+		situationIDs := outages[id]
+		if situationIDs == nil {
+			situationIDs = []string{}
+		}
+		return models.StationFacility{
+			ID:           utils.FormCombinedID(agencyID, id),
+			Name:         name,
+			OutOfService: len(outages[id]) > 0,
+			SituationIDs: situationIDs,
+		}
+	}
+
+	entry := models.StationAccessibility{
+		StationID:    utils.FormCombinedID(agencyID, station.ID),
+		Name:         station.Name,
+		Elevators:    []models.StationFacility{},
+		Escalators:   []models.StationFacility{},
+		Entrances:    []models.StationFacility{},
+		SituationIDs: stationAlerts,
+	}
+	if entry.SituationIDs == nil {
+		entry.SituationIDs = []string{}
+	}
+	for _, pathway := range station.Pathways {
+		if pathway.Mode != GTFS.PathwayModeElevator && pathway.Mode != GTFS.PathwayModeEscalator {
+			continue
+		}
+		name := pathway.SignpostedAs
+		if name == "" {
+			name = nodes[pathway.ToStopID].Name
+		}
+		f := facility(pathway.ID, name)
+		f.FromStopID = utils.FormCombinedID(agencyID, pathway.FromStopID)
+		f.ToStopID = utils.FormCombinedID(agencyID, pathway.ToStopID)
+		f.FromLevel = levelName(pathway.FromStopID)
+		f.ToLevel = levelName(pathway.ToStopID)
+		f.IsBidirectional = pathway.IsBidirectional
+		if pathway.Mode == GTFS.PathwayModeElevator {
+			entry.Elevators = append(entry.Elevators, f)
+		} else {
+			entry.Escalators = append(entry.Escalators, f)
+		}
+	}
+	for _, node := range station.Nodes {
+		if node.LocationType != GTFS.LocationTypeEntrance {
+			continue
+		}
+		f := facility(node.ID, node.Name)
+		f.WheelchairBoarding = node.WheelchairBoarding
+		entry.Entrances = append(entry.Entrances, f)
+		if node.WheelchairBoarding == 1 && !f.OutOfService {
+			entry.StepFree = true
+		}
+	}
+
+	// Reference every alert the entry names
+	namedIDs := make(map[string]bool)
+	for _, ids := range outages {
+		for _, alertID := range ids {
+			namedIDs[alertID] = true
+		}
+	}
+	for _, alertID := range stationAlerts {
+		namedIDs[alertID] = true
+	}
+	var named []gtfs.Alert
+	for _, alert := range alerts {
+		if namedIDs[alert.ID] {
+			named = append(named, alert)
+		}
+	}
+	references := models.NewEmptyReferences()
+	for _, situation := range api.BuildSituationReferences(named, agencyID, alertLanguages(w, r)) {
+		references.Situations = append(references.Situations, situation)
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(entry, references, api.Clock))
+}
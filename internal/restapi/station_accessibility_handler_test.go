@@ -0,0 +1,89 @@
+package restapi
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestStationAccessibilityHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	var feed bytes.Buffer
+	w := zip.NewWriter(&feed)
+	for name, content := range map[string]string{
+		"stops.txt": "stop_id,stop_name,location_type,parent_station,level_id,wheelchair_boarding\n" +
+			"STN,Central,1,,,\n" +
+			"P1,Platform,0,STN,L1,\n" +
+			"E1,North Entrance,2,STN,L0,1\n" +
+			"E2,South Entrance,2,STN,L0,1\n",
+		"levels.txt": "level_id,level_index,level_name\nL0,0,Street\nL1,-1,\n",
+		"pathways.txt": "pathway_id,from_stop_id,to_stop_id,pathway_mode,is_bidirectional,signposted_as\n" +
+			"EL1,E1,P1,5,1,Platform elevator\n" +
+			"ES1,E2,P1,4,0,\n",
+	} {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, api.GtfsManager.MockLoadStations(feed.Bytes()))
+
+	elevatorID := "EL1"
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "elevator-out",
+		Effect:           gtfs.AccessibilityIssue,
+		InformedEntities: []gtfs.AlertInformedEntity{{StopID: &elevatorID}},
+	})
+	defer api.GtfsManager.MockRemoveAlert("elevator-out")
+
+	serve := func(t *testing.T, target string) (int, models.StationAccessibility, []json.RawMessage) {
+		rec := serveSiri(t, api, target)
+		var response struct {
+			Data struct {
+				Entry      models.StationAccessibility `json:"entry"`
+				References struct {
+					Situations []json.RawMessage `json:"situations"`
+				} `json:"references"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		return rec.Code, response.Data.Entry, response.Data.References.Situations
+	}
+
+	// A platform resolves to its station
+	code, entry, situations := serve(t, "/api/where/station-accessibility/25_P1.json?key="+siriTestKey)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "25_STN", entry.StationID)
+	require.Len(t, entry.Elevators, 1)
+	assert.Equal(t, models.StationFacility{
+		ID:              "25_EL1",
+		Name:            "Platform elevator",
+		FromStopID:      "25_E1",
+		ToStopID:        "25_P1",
+		FromLevel:       "Street",
+		ToLevel:         "L1",
+		IsBidirectional: true,
+		OutOfService:    true,
+		SituationIDs:    []string{"elevator-out"},
+	}, entry.Elevators[0])
+	require.Len(t, entry.Escalators, 1)
+	assert.False(t, entry.Escalators[0].OutOfService)
+	assert.Equal(t, "Platform", entry.Escalators[0].Name)
+	assert.Len(t, entry.Entrances, 2)
+	assert.True(t, entry.StepFree)
+	assert.Empty(t, entry.SituationIDs)
+	assert.Len(t, situations, 1)
+
+	code, _, _ = serve(t, "/api/where/station-accessibility/25_missing.json?key="+siriTestKey)
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -25,7 +25,7 @@ func (api *RestAPI) stopIDsForAgencyHandler(w http.ResponseWriter, r *http.Reque
 	agency := api.GtfsManager.FindAgency(id)
 
 	if agency == nil {
-		api.sendNull(w, r)
+		api.sendUnknownID(w, r, api.sendNull)
 		return
 	}
 
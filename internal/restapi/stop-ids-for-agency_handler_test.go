@@ -54,6 +54,6 @@ func TestStopIdsForAgencyEndToEnd(t *testing.T) {
 
 func TestInvalidAgencyId(t *testing.T) {
 	_, resp, model := serveAndRetrieveEndpoint(t, "/api/where/stop-ids-for-agency/invalid.json?key=TEST")
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.Equal(t, "", model.Text)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "resource not found", model.Text)
 }
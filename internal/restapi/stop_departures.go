@@ -0,0 +1,123 @@
+package restapi
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// lateDepartureWindow is how far back scheduled stop times are fetched, so that a late vehicle
+// whose expected departure is still ahead is not dropped.
+const lateDepartureWindow = 30 * time.Minute
+
+// stopDeparture is a scheduled departure from a stop with its realtime prediction.
+type stopDeparture struct {
+	StopTime       gtfsdb.GetStopTimesForStopInWindowRow
+	Route          gtfsdb.Route
+	Trip           gtfsdb.Trip
+	ServiceDate    time.Time // Midnight of the service day, in the agency's time zone
+	AimedArrival   time.Time
+	AimedDeparture time.Time
+	Prediction     stopTimePrediction
+}
+
+// ExpectedDeparture is the predicted departure, or the scheduled one.
+func (d stopDeparture) ExpectedDeparture() time.Time {
+	if d.Prediction.Predicted {
+		return time.UnixMilli(d.Prediction.DepartureTime).In(d.AimedDeparture.Location())
+	}
+	return d.AimedDeparture
+}
+
+// upcomingDepartures returns the departures from stopCode on today's active services that are
+// scheduled within preview of now and not yet gone, ordered by expected departure. now must be
+// in the agency's time zone. The caller must hold the manager's read lock.
+func (api *RestAPI) upcomingDepartures(ctx context.Context, stopCode string, now time.Time, preview time.Duration) ([]stopDeparture, error) {
+	serviceMidnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	activeServiceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, now.Format("20060102"))
+	if err != nil {
+		return nil, err
+	}
+	activeServiceIDSet := make(map[string]bool, len(activeServiceIDs))
+	for _, sid := range activeServiceIDs {
+		activeServiceIDSet[sid] = true
+	}
+
+	allStopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForStopInWindow(ctx, gtfsdb.GetStopTimesForStopInWindowParams{
+		StopID:           stopCode,
+		WindowStartNanos: convertToNanosSinceMidnight(now.Add(-lateDepartureWindow)),
+		WindowEndNanos:   convertToNanosSinceMidnight(now.Add(preview)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var stopTimes []gtfsdb.GetStopTimesForStopInWindowRow
+	routeIDs := make(map[string]bool)
+	tripIDs := make(map[string]bool)
+	for _, st := range allStopTimes {
+		if !activeServiceIDSet[st.ServiceID] {
+			continue
+		}
+		stopTimes = append(stopTimes, st)
+		routeIDs[st.RouteID] = true
+		tripIDs[st.TripID] = true
+	}
+
+	routes, err := api.GtfsManager.GtfsDB.Queries.GetRoutesByIDs(ctx, mapKeys(routeIDs))
+	if err != nil {
+		return nil, err
+	}
+	routesLookup := make(map[string]gtfsdb.Route, len(routes))
+	for _, route := range routes {
+		routesLookup[route.ID] = route
+	}
+
+	trips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByIDs(ctx, mapKeys(tripIDs))
+	if err != nil {
+		return nil, err
+	}
+	tripsLookup := make(map[string]gtfsdb.Trip, len(trips))
+	for _, trip := range trips {
+		tripsLookup[trip.ID] = trip
+	}
+
+	departures := make([]stopDeparture, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		route, routeExists := routesLookup[st.RouteID]
+		trip, tripExists := tripsLookup[st.TripID]
+		if !routeExists || !tripExists {
+			api.Logger.Debug("skipping stop time: route or trip not found",
+				slog.String("routeID", st.RouteID),
+				slog.String("tripID", st.TripID))
+			continue
+		}
+
+		aimedArrival := utils.ServiceTime(serviceMidnight, time.Duration(st.ArrivalTime))
+		aimedDeparture := utils.ServiceTime(serviceMidnight, time.Duration(st.DepartureTime))
+		prediction := api.predictStopTime(st.TripID, stopCode, st.StopSequence, aimedArrival.UnixMilli(), aimedDeparture.UnixMilli())
+		if time.UnixMilli(prediction.DepartureTime).Before(now) {
+			continue
+		}
+
+		departures = append(departures, stopDeparture{
+			StopTime:       st,
+			Route:          route,
+			Trip:           trip,
+			ServiceDate:    serviceMidnight,
+			AimedArrival:   aimedArrival,
+			AimedDeparture: aimedDeparture,
+			Prediction:     prediction,
+		})
+	}
+
+	sort.SliceStable(departures, func(i, j int) bool {
+		return departures[i].ExpectedDeparture().Before(departures[j].ExpectedDeparture())
+	})
+	return departures, nil
+}
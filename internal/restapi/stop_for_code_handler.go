@@ -0,0 +1,112 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// stopForCodeHandler resolves a rider-facing stop code, the number printed on the stop sign,
+// within an agency. The ID is the agency ID and the stop code joined like a combined ID. It
+// answers with the stop entry, or with the stop's arrivals and departures when includeArrivals
+// is true, taking the same parameters as arrivals-and-departures-for-stop. Stop codes are not
+// always unique, often being shared by the stops on either side of a street; an ambiguous code
+// answers with the list of its stops, so that the caller can ask the rider to choose.
+func (api *RestAPI) stopForCodeHandler(w http.ResponseWriter, r *http.Request) {
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+	agencyID, stopCode, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+
+	includeArrivals := false
+	if val := r.URL.Query().Get("includeArrivals"); val != "" {
+		if includeArrivals, err = strconv.ParseBool(val); err != nil {
+			api.validationErrorResponse(w, r, map[string][]string{"includeArrivals": {"must be true or false"}})
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	var params ArrivalsStopParams
+	if includeArrivals {
+		var fieldErrors map[string][]string
+		if params, fieldErrors = api.parseArrivalsAndDeparturesParams(r); len(fieldErrors) > 0 {
+			api.validationErrorResponse(w, r, fieldErrors)
+			return
+		}
+	}
+
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByCodeForAgency(ctx, gtfsdb.GetStopsByCodeForAgencyParams{
+		Code:     sql.NullString{String: stopCode, Valid: true},
+		AgencyID: agencyID,
+	})
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	switch {
+	case len(stops) == 0:
+		api.sendNotFound(w, r)
+	case len(stops) > 1:
+		api.sendStopsForCode(w, r, agencyID, stops)
+	case includeArrivals:
+		api.sendArrivalsAndDeparturesForStop(w, r, params, agencyID, stops[0].ID)
+	default:
+		api.sendStop(w, r, agencyID, stops[0].ID)
+	}
+}
+
+// sendStopsForCode answers with the stops sharing an ambiguous stop code. The caller must hold
+// the manager's read lock.
+func (api *RestAPI) sendStopsForCode(w http.ResponseWriter, r *http.Request, agencyID string, stops []gtfsdb.Stop) {
+	ctx := r.Context()
+
+	stopIDs := make([]string, len(stops))
+	for i, stop := range stops {
+		stopIDs[i] = stop.ID
+	}
+	stopsList, err := api.buildStopsListForAgency(ctx, agencyID, stopIDs)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	routeRefs, err := api.BuildRouteReferencesAsInterface(ctx, agencyID, stopsList)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	references := models.NewEmptyReferences()
+	references.Routes = routeRefs
+	if agency := api.GtfsManager.FindAgency(agencyID); agency != nil {
+		references.Agencies = append(references.Agencies, models.NewAgencyReference(
+			agency.Id,
+			agency.Name,
+			agency.Url,
+			agency.Timezone,
+			agency.Language,
+			agency.Phone,
+			agency.Email,
+			agency.FareUrl,
+			"",
+			false,
+		))
+	}
+
+	api.sendResponse(w, r, models.NewListResponse(stopsList, references, false, api.Clock))
+}
@@ -0,0 +1,73 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestStopForCodeHandler(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)))
+	defer api.Shutdown()
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+
+	serve := func(t *testing.T, target string) (int, map[string]any) {
+		rec := serveSiri(t, api, target)
+		var body struct {
+			Data map[string]any `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		return rec.Code, body.Data
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		code, data := serve(t, "/api/where/stop-for-code/"+agencyID+"_1001.json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, code)
+		entry, ok := data["entry"].(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, agencyID+"_1001", entry["id"])
+		assert.Equal(t, "1001", entry["code"])
+		assert.Equal(t, "Northpoint Dr at Lake Blvd", entry["name"])
+	})
+
+	t.Run("arrivals", func(t *testing.T) {
+		code, data := serve(t, "/api/where/stop-for-code/"+agencyID+"_1001.json?key="+siriTestKey+"&includeArrivals=true&minutesAfter=120")
+		require.Equal(t, http.StatusOK, code)
+		entry, ok := data["entry"].(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, agencyID+"_1001", entry["stopId"])
+		assert.Contains(t, entry, "arrivalsAndDepartures")
+	})
+
+	t.Run("ambiguous code", func(t *testing.T) {
+		// Both sides of Shasta View Dr at Tarmac Rd are signed 8009
+		code, data := serve(t, "/api/where/stop-for-code/"+agencyID+"_8009.json?key="+siriTestKey+"&includeArrivals=true")
+		require.Equal(t, http.StatusOK, code)
+		list, ok := data["list"].([]any)
+		require.True(t, ok)
+		require.Len(t, list, 2)
+		assert.Equal(t, agencyID+"_8009", list[0].(map[string]any)["id"])
+		assert.Equal(t, agencyID+"_8009-west", list[1].(map[string]any)["id"])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			target string
+			status int
+		}{
+			{"/api/where/stop-for-code/" + agencyID + "_99999.json?key=" + siriTestKey, http.StatusNotFound},
+			{"/api/where/stop-for-code/unknown_1001.json?key=" + siriTestKey, http.StatusNotFound},
+			{"/api/where/stop-for-code/" + agencyID + "_1001.json?key=" + siriTestKey + "&includeArrivals=maybe", http.StatusBadRequest},
+			{"/api/where/stop-for-code/" + agencyID + "_1001.json?key=" + siriTestKey + "&includeArrivals=true&minutesAfter=-1", http.StatusBadRequest},
+		} {
+			rec := serveSiri(t, api, tc.target)
+			assert.Equal(t, tc.status, rec.Code, tc.target)
+		}
+	})
+}
@@ -21,7 +21,7 @@ func (api *RestAPI) stopHandler(w http.ResponseWriter, r *http.Request) {
 
 	// agencyID here is specifically the *Stop's* agency.
 	// Routes serving this stop might belong to different agencies.
-	agencyID, stopID, err := utils.ExtractAgencyIDAndCodeID(queryParamID)
+	agencyID, stopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -33,6 +33,12 @@ func (api *RestAPI) stopHandler(w http.ResponseWriter, r *http.Request) {
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
+	api.sendStop(w, r, agencyID, stopID)
+}
+
+// sendStop answers with a stop entry and its routes and agencies as references, or a not-found
+// error. The caller must hold the manager's read lock.
+func (api *RestAPI) sendStop(w http.ResponseWriter, r *http.Request, agencyID, stopID string) {
 	ctx := r.Context()
 
 	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
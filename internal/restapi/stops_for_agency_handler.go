@@ -33,7 +33,7 @@ func (api *RestAPI) stopsForAgencyHandler(w http.ResponseWriter, r *http.Request
 	// Validate agency exists
 	agency := api.GtfsManager.FindAgency(id)
 	if agency == nil {
-		api.sendNull(w, r)
+		api.sendUnknownID(w, r, api.sendNull)
 		return
 	}
 
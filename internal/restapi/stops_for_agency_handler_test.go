@@ -125,6 +125,6 @@ func TestStopsForAgencyEndToEnd(t *testing.T) {
 
 func TestStopsForAgencyInvalidAgency(t *testing.T) {
 	_, resp, model := serveAndRetrieveEndpoint(t, "/api/where/stops-for-agency/invalid.json?key=TEST")
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.Equal(t, "", model.Text)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "resource not found", model.Text)
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -20,7 +21,8 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 	radius, _ := utils.ParseFloatParam(queryParams, "radius", fieldErrors)
 	latSpan, _ := utils.ParseFloatParam(queryParams, "latSpan", fieldErrors)
 	lonSpan, _ := utils.ParseFloatParam(queryParams, "lonSpan", fieldErrors)
-	maxCount, _ := utils.ParseMaxCount(queryParams, models.DefaultMaxCountForStops, fieldErrors)
+	defaultCount, maxAllowed := api.pageLimits(r, appconf.PaginationClassLocation, models.DefaultMaxCountForStops, models.MaxAllowedCount)
+	maxCount, _ := utils.ParseMaxCount(queryParams, defaultCount, maxAllowed, fieldErrors)
 	query := queryParams.Get("query")
 
 	var routeTypes []int
@@ -116,6 +118,9 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 			Stops:      []models.Stop{},
 			Trips:      []interface{}{},
 		}
+		if query == "" {
+			references.BikeshareStations, _ = api.bikeshareStationsForLocation(lat, lon, radius, latSpan, lonSpan, maxCount)
+		}
 		response := models.NewListResponseWithRange(results, references, checkIfOutOfBounds(api, lat, lon, latSpan, lonSpan, radius), api.Clock, false)
 		api.sendResponse(w, r, response)
 		return
@@ -146,7 +151,7 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 			continue
 		}
 
-		agencyId, _, err := utils.ExtractAgencyIDAndCodeID(routeIDStr)
+		agencyId, _, err := api.GtfsManager.ExtractAgencyIDAndCodeID(routeIDStr)
 		if err != nil {
 			continue // Skip malformed route IDs
 		}
@@ -209,6 +214,9 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 		Stops:      []models.Stop{},
 		Trips:      []interface{}{},
 	}
+	if query == "" {
+		references.BikeshareStations, _ = api.bikeshareStationsForLocation(lat, lon, radius, latSpan, lonSpan, maxCount)
+	}
 
 	response := models.NewListResponseWithRange(results, references, checkIfOutOfBounds(api, lat, lon, latSpan, lonSpan, radius), api.Clock, isLimitExceeded)
 	api.sendResponse(w, r, response)
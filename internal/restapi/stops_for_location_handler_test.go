@@ -185,3 +185,24 @@ func TestStopsForLocationHandlerValidatesMaxCount(t *testing.T) {
 	_, resp, _ := serveAndRetrieveEndpoint(t, "/api/where/stops-for-location.json?key=TEST&lat=40.583321&lon=-122.426966&maxCount=invalid")
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
+
+func TestStopsForLocationHandlerRejectsNaN(t *testing.T) {
+	_, resp, _ := serveAndRetrieveEndpoint(t, "/api/where/stops-for-location.json?key=TEST&lat=NaN&lon=-122.426966")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_, resp, _ = serveAndRetrieveEndpoint(t, "/api/where/stops-for-location.json?key=TEST&lat=40.583321&lon=-122.426966&radius=NaN")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStopsForLocationOutOfRange(t *testing.T) {
+	_, resp, model := serveAndRetrieveEndpoint(t, "/api/where/stops-for-location.json?key=TEST&lat=47.6097&lon=-122.3331&radius=1000")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, true, data["outOfRange"], "Seattle is outside the RABA feed")
+	assert.Empty(t, data["list"])
+
+	_, _, model = serveAndRetrieveEndpoint(t, "/api/where/stops-for-location.json?key=TEST&lat=40.583321&lon=-122.362535&radius=1000")
+	data, ok = model.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, false, data["outOfRange"])
+}
@@ -60,7 +60,7 @@ func (api *RestAPI) stopsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	agencyID, routeID, err := utils.ExtractAgencyIDAndCodeID(id)
+	agencyID, routeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -166,7 +166,7 @@ func (api *RestAPI) stopsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	result, stopsList, err := api.processRouteStops(ctx, agencyID, routeID, serviceIDs, params.IncludePolylines, adc)
+	result, stopsList, err := api.processRouteStops(ctx, agencyID, routeID, formattedDate, serviceIDs, params.IncludePolylines, adc)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -174,7 +174,7 @@ func (api *RestAPI) stopsForRouteHandler(w http.ResponseWriter, r *http.Request)
 
 	api.buildAndSendResponse(w, r, ctx, result, stopsList, currentAgency)
 }
-func (api *RestAPI) processRouteStops(ctx context.Context, agencyID string, routeID string, serviceIDs []string, includePolylines bool, adc *GTFS.AdvancedDirectionCalculator) (models.RouteEntry, []models.Stop, error) {
+func (api *RestAPI) processRouteStops(ctx context.Context, agencyID string, routeID string, serviceDate string, serviceIDs []string, includePolylines bool, adc *GTFS.AdvancedDirectionCalculator) (models.RouteEntry, []models.Stop, error) {
 	allStops := make(map[string]bool)
 	allPolylines := make([]models.Polyline, 0, 100)
 	var stopGroupings []models.StopGrouping
@@ -195,10 +195,10 @@ func (api *RestAPI) processRouteStops(ctx context.Context, agencyID string, rout
 		if err != nil {
 			return models.RouteEntry{}, nil, err
 		}
-		processTripGroups(ctx, api, agencyID, routeID, allTrips, &stopGroupings, allStops, &allPolylines)
+		processTripGroups(ctx, api, agencyID, routeID, serviceDate, allTrips, &stopGroupings, allStops, &allPolylines)
 	} else {
 		// Process trips for the current service date
-		processTripGroups(ctx, api, agencyID, routeID, trips, &stopGroupings, allStops, &allPolylines)
+		processTripGroups(ctx, api, agencyID, routeID, serviceDate, trips, &stopGroupings, allStops, &allPolylines)
 	}
 
 	if !includePolylines {
@@ -311,6 +311,7 @@ func processTripGroups(
 	api *RestAPI,
 	agencyID string,
 	routeID string,
+	serviceDate string,
 	trips []gtfsdb.Trip,
 	stopGroupings *[]models.StopGrouping,
 	allStops map[string]bool,
@@ -372,6 +373,7 @@ func processTripGroups(
 		}
 
 		polylines := generatePolylines(shape)
+		polylines = append(polylines, addTripModifications(ctx, api, tripsInGroup, serviceDate, stopIDs, allStops)...)
 		*allPolylines = append(*allPolylines, polylines...)
 
 		formattedStopIDs := formatStopIDs(agencyID, stopIDs)
@@ -401,6 +403,44 @@ func processTripGroups(
 	}
 }
 
+// addTripModifications adds the replacement stops of the trips' GTFS-RT trip modifications on the
+// service date, YYYYMMDD, to stopIDs and allStops, and returns the polylines of the realtime
+// shapes the modified trips follow.
+func addTripModifications(ctx context.Context, api *RestAPI, trips []gtfsdb.Trip, serviceDate string, stopIDs, allStops map[string]bool) []models.Polyline {
+	var polylines []models.Polyline
+	seenShapes := make(map[string]bool)
+	for _, trip := range trips {
+		modification, ok := api.GtfsManager.GetTripModification(trip.ID, serviceDate)
+		if !ok {
+			continue
+		}
+		for _, mod := range modification.Modifications {
+			for _, replacement := range mod.ReplacementStops {
+				if stopIDs[replacement.StopID] {
+					continue
+				}
+				// Stops added by a GTFS-RT Stop entity are not in the schedule
+				if _, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, replacement.StopID); err != nil {
+					continue
+				}
+				stopIDs[replacement.StopID] = true
+				allStops[replacement.StopID] = true
+			}
+		}
+		if modification.ShapeID == "" || seenShapes[modification.ShapeID] {
+			continue
+		}
+		seenShapes[modification.ShapeID] = true
+		if points, ok := api.GtfsManager.GetRealtimeShape(modification.ShapeID); ok {
+			polylines = append(polylines, models.Polyline{
+				Length: len(points),
+				Points: string(polyline.EncodeCoords(points)),
+			})
+		}
+	}
+	return polylines
+}
+
 func generatePolylines(shapes []gtfsdb.GetShapesGroupedByTripHeadSignRow) []models.Polyline {
 	var polylines []models.Polyline
 	// This prevents repeated memory re-allocation during the loop.
@@ -0,0 +1,79 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// systemStatsTTL is how long the public stats are reused. The endpoint takes no API key, so
+// this bounds the work a flood of requests can cause.
+const systemStatsTTL = 30 * time.Second
+
+// systemStatsHandler reports what the server covers, for open-data portals and uptime pages.
+// It needs no API key.
+func (api *RestAPI) systemStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if api.GtfsManager == nil {
+		api.sendError(w, r, http.StatusServiceUnavailable, "GTFS data not loaded")
+		return
+	}
+
+	api.systemStatsMu.Lock()
+	defer api.systemStatsMu.Unlock()
+	now := api.Clock.Now()
+	if now.After(api.systemStatsExpires) {
+		stats, err := api.systemStats(r, now)
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		api.systemStatsCached = stats
+		api.systemStatsExpires = now.Add(systemStatsTTL)
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(api.systemStatsCached, models.NewEmptyReferences(), api.Clock))
+}
+
+// systemStats counts the dataset's contents and the trips scheduled on today's service date,
+// in the first agency's time zone.
+func (api *RestAPI) systemStats(r *http.Request, now time.Time) (models.SystemStats, error) {
+	status := api.GtfsManager.Status()
+	stats := models.SystemStats{
+		Agencies:            status.Agencies,
+		Routes:              status.Routes,
+		Stops:               status.Stops,
+		Vehicles:            status.RealtimeVehicles,
+		StaticLastUpdated:   unixMilliOrZero(status.StaticUpdated),
+		RealtimeLastUpdated: unixMilliOrZero(status.RealtimeUpdated),
+	}
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	if agencies := api.GtfsManager.GetAgencies(); len(agencies) > 0 {
+		if loc, err := time.LoadLocation(agencies[0].Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+	serviceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(r.Context(), now.Format("20060102"))
+	if err != nil {
+		return models.SystemStats{}, fmt.Errorf("failed to get active services: %w", err)
+	}
+	active := make(map[string]bool, len(serviceIDs))
+	for _, id := range serviceIDs {
+		active[id] = true
+	}
+	for _, trip := range api.GtfsManager.GetTrips() {
+		if trip.Service != nil && active[trip.Service.Id] {
+			stats.TripsToday++
+		}
+	}
+
+	if first, last := api.GtfsManager.ServiceDateRange(); !first.IsZero() {
+		stats.ServiceStartDate = first.Format(time.DateOnly)
+		stats.ServiceEndDate = last.Format(time.DateOnly)
+	}
+	return stats, nil
+}
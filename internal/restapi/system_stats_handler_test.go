@@ -0,0 +1,50 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestSystemStatsHandler(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Now())
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+
+	_, day := scheduledTrip(t, api)
+	mockClock.Set(day.Add(12 * time.Hour))
+
+	// No API key needed
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/status/stats.json")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Cache-Control"), "max-age=30")
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.EqualValues(t, len(api.GtfsManager.GetAgencies()), entry["agencies"])
+	assert.EqualValues(t, len(api.GtfsManager.GetStops()), entry["stops"])
+	assert.Positive(t, entry["routes"])
+	tripsToday := entry["tripsToday"].(float64)
+	assert.Positive(t, tripsToday)
+	assert.Less(t, tripsToday, float64(len(api.GtfsManager.GetTrips())+1))
+	assert.Zero(t, entry["vehicles"])
+
+	start, err := time.Parse(time.DateOnly, entry["serviceStartDate"].(string))
+	require.NoError(t, err)
+	end, err := time.Parse(time.DateOnly, entry["serviceEndDate"].(string))
+	require.NoError(t, err)
+	serviceDay, _ := time.Parse(time.DateOnly, day.Format(time.DateOnly))
+	assert.False(t, serviceDay.Before(start) || serviceDay.After(end), "%s is within %s to %s", serviceDay, start, end)
+
+	// Reused until they expire
+	api.GtfsManager.MockAddVehicle("STATS_VEHICLE", "", "")
+	t.Cleanup(func() { api.GtfsManager.MockRemoveVehicle("STATS_VEHICLE") })
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/status/stats.json")
+	assert.Zero(t, model.Data.(map[string]interface{})["entry"].(map[string]interface{})["vehicles"])
+	mockClock.Advance(systemStatsTTL + time.Second)
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/status/stats.json")
+	assert.EqualValues(t, 1, model.Data.(map[string]interface{})["entry"].(map[string]interface{})["vehicles"])
+}
@@ -0,0 +1,44 @@
+package restapi
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"maglev.onebusaway.org/internal/metrics"
+)
+
+// TracingMiddleware starts an OpenTelemetry server span for each request, continuing any trace
+// propagated by the caller. It must wrap the mux directly: spans are renamed after the matched
+// route pattern, which the mux only sets on the request it receives.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.request",
+		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+			// Use r.Pattern rather than the raw path to keep span names low-cardinality
+			if r.Pattern != "" {
+				return r.Pattern
+			}
+			return operation
+		}))
+}
+
+// annotateSpan adds the fingerprint of the caller's API key and the matched endpoint to the
+// request's span, so traces can be filtered by client without sending keys to the collector.
+// The fingerprint is the one per-key metrics are labelled with. Unknown keys are recorded as one
+// value, as in the usage report, so clients cannot write arbitrary strings into traces.
+func (api *RestAPI) annotateSpan(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if span := trace.SpanFromContext(r.Context()); span.IsRecording() {
+			key := api.usageKeyForRequest(r)
+			if key != invalidKeyUsageBucket {
+				key = metrics.KeyFingerprint(key)
+			}
+			span.SetAttributes(
+				attribute.String("maglev.api_key_fingerprint", key),
+				attribute.String("maglev.endpoint", r.Pattern),
+			)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,75 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"maglev.onebusaway.org/internal/metrics"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/where/stop/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := TracingMiddleware(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/where/stop/1_75403.json", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /api/where/stop/{id}", spans[0].Name())
+}
+
+func TestTracingMiddleware_APIKeyAndEndpoint(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	api := createTestApi(t)
+	defer api.Shutdown()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	handler := TracingMiddleware(mux)
+
+	attributes := func(target string) map[attribute.Key]string {
+		recorder.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		for _, span := range recorder.Ended() {
+			if span.SpanKind() != trace.SpanKindServer {
+				continue
+			}
+			values := map[attribute.Key]string{}
+			for _, kv := range span.Attributes() {
+				values[kv.Key] = kv.Value.Emit()
+			}
+			return values
+		}
+		t.Fatal("no server span")
+		return nil
+	}
+
+	values := attributes("/api/where/agency/25.json?key=TEST")
+	assert.Equal(t, metrics.KeyFingerprint("TEST"), values["maglev.api_key_fingerprint"])
+	assert.NotContains(t, values, attribute.Key("maglev.api_key"))
+	assert.Equal(t, "GET /api/where/agency/{id}", values["maglev.endpoint"])
+
+	values = attributes("/api/where/agency/25.json?key=made-up")
+	assert.Equal(t, invalidKeyUsageBucket, values["maglev.api_key_fingerprint"])
+}
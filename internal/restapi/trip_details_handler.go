@@ -92,7 +92,7 @@ func (api *RestAPI) tripDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	agencyID, tripID, err := utils.ExtractAgencyIDAndCodeID(queryParamID)
+	agencyID, tripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -246,6 +246,8 @@ func (api *RestAPI) tripDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		references.Routes = routesIface
 	}
 
+	api.addSituationReferences(&references, tripDetails.SituationIDs, alertLanguages(w, r))
+
 	response := models.NewEntryResponse(tripDetails, references, api.Clock)
 	api.sendResponse(w, r, response)
 }
@@ -255,7 +257,7 @@ func (api *RestAPI) buildReferencedTrips(ctx context.Context, agencyID string, t
 	referencedTrips := []*models.Trip{}
 
 	for _, tripID := range tripsToInclude {
-		_, refTripID, err := utils.ExtractAgencyIDAndCodeID(tripID)
+		_, refTripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(tripID)
 		if err != nil {
 			continue
 		}
@@ -305,7 +307,7 @@ func (api *RestAPI) buildStopReferences(ctx context.Context, calc *GTFS.Advanced
 	originalStopIDs := make([]string, 0, len(stopTimes))
 
 	for _, st := range stopTimes {
-		_, originalStopID, err := utils.ExtractAgencyIDAndCodeID(st.StopID)
+		_, originalStopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(st.StopID)
 		if err != nil {
 			continue
 		}
@@ -355,7 +357,7 @@ func (api *RestAPI) buildStopReferences(ctx context.Context, calc *GTFS.Advanced
 	processedStops := make(map[string]bool)
 
 	for _, st := range stopTimes {
-		_, originalStopID, err := utils.ExtractAgencyIDAndCodeID(st.StopID)
+		_, originalStopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(st.StopID)
 		if err != nil {
 			continue
 		}
@@ -402,7 +404,7 @@ func (api *RestAPI) BuildRouteReference(ctx context.Context, agencyID string, st
 
 	for _, stop := range stops {
 		for _, routeID := range stop.StaticRouteIDs {
-			_, originalRouteID, err := utils.ExtractAgencyIDAndCodeID(routeID)
+			_, originalRouteID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(routeID)
 			if err != nil {
 				continue
 			}
@@ -1,13 +1,17 @@
 package restapi
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/OneBusAway/go-gtfs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
 
@@ -403,3 +407,47 @@ func TestParseTripIdDetailsParams_Unit(t *testing.T) {
 	assert.Contains(t, errsInvalid, "serviceDate")
 	assert.Equal(t, "must be a valid Unix timestamp in milliseconds", errsInvalid["time"][0])
 }
+
+func TestTripDetailsHandlerSituationReferences(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	trip := api.GtfsManager.GetTrips()[0]
+	tripID := utils.FormCombinedID(agency.Id, trip.ID)
+	situationID := utils.FormCombinedID(agency.Id, "trip-alert")
+
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "trip-alert",
+		Header:           []gtfs.AlertText{{Text: "Bus replaced by van"}},
+		InformedEntities: []gtfs.AlertInformedEntity{{TripID: &gtfs.TripID{ID: trip.ID}}},
+	})
+	defer api.GtfsManager.MockRemoveAlert("trip-alert")
+
+	serve := func(query string) models.TripDetails {
+		rec := serveSiri(t, api, "/api/where/trip-details/"+tripID+".json?key="+siriTestKey+query)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var response struct {
+			Data struct {
+				Entry      models.TripDetails `json:"entry"`
+				References struct {
+					Situations []models.Situation `json:"situations"`
+				} `json:"references"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		if query == "" {
+			require.Len(t, response.Data.References.Situations, 1)
+			situation := response.Data.References.Situations[0]
+			assert.Equal(t, situationID, situation.ID, "referenced under the ID the entry names it by")
+			require.NotNil(t, situation.Summary)
+			assert.Equal(t, "Bus replaced by van", situation.Summary.Value)
+		} else {
+			assert.Empty(t, response.Data.References.Situations)
+		}
+		return response.Data.Entry
+	}
+
+	assert.Equal(t, []string{situationID}, serve("").SituationIDs)
+	assert.Equal(t, []string{situationID}, serve("&includeReferences=false").SituationIDs, "the entry keeps its situation IDs")
+}
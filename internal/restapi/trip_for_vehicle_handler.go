@@ -94,7 +94,7 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	agencyID, vehicleID, err := utils.ExtractAgencyIDAndCodeID(queryParamID)
+	agencyID, vehicleID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
 
 	if err != nil {
 		fieldErrors := map[string][]string{
@@ -107,7 +107,7 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
-	vehicle, err := api.GtfsManager.GetVehicleByID(vehicleID)
+	vehicle, err := api.GtfsManager.GetVehicleForAgency(agencyID, vehicleID)
 
 	if err != nil {
 		api.sendNotFound(w, r)
@@ -135,6 +135,10 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 	tripID := vehicle.Trip.ID.ID
 
 	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		api.sendNotFound(w, r)
+		return
+	}
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -242,7 +246,7 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 
 	if status != nil {
 		if status.ClosestStop != "" {
-			_, closestStopID, err := utils.ExtractAgencyIDAndCodeID(status.ClosestStop)
+			_, closestStopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(status.ClosestStop)
 			if err != nil {
 				api.serverErrorResponse(w, r, err)
 				return
@@ -250,7 +254,7 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 			stopIDs = append(stopIDs, closestStopID)
 		}
 		if status.NextStop != "" {
-			_, nextStopID, err := utils.ExtractAgencyIDAndCodeID(status.NextStop)
+			_, nextStopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(status.NextStop)
 			if err != nil {
 				api.serverErrorResponse(w, r, err)
 				return
@@ -298,6 +302,8 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 		references.Trips = append(references.Trips, tripRef)
 	}
 
+	api.addSituationReferences(&references, situationIDs, alertLanguages(w, r))
+
 	response := models.NewEntryResponse(entry, references, api.Clock)
 	api.sendResponse(w, r, response)
 }
@@ -19,7 +19,7 @@ func (api *RestAPI) tripHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	agencyID, id, err := utils.ExtractAgencyIDAndCodeID(queryParamID)
+	agencyID, id, err := api.GtfsManager.ExtractAgencyIDAndCodeID(queryParamID)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -52,7 +52,7 @@ func (api *RestAPI) tripHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if trip.ID == "" {
-		api.sendNull(w, r)
+		api.sendNotFound(w, r)
 		return
 	}
 
@@ -0,0 +1,94 @@
+package restapi
+
+import (
+	"context"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+// modifiedStopTimes returns the trip's stops as its GTFS-RT trip modification on the service
+// date, YYYYMMDD, changes them, or false when the trip is not modified.
+func (api *RestAPI) modifiedStopTimes(ctx context.Context, tripID, serviceDate string) ([]GTFS.ModifiedStop, bool) {
+	modification, ok := api.GtfsManager.GetTripModification(tripID, serviceDate)
+	if !ok {
+		return nil, false
+	}
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, tripID)
+	if err != nil {
+		return nil, false
+	}
+	scheduled := make([]GTFS.ScheduledStop, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		scheduled = append(scheduled, GTFS.ScheduledStop{
+			StopID:        st.StopID,
+			StopSequence:  uint32(st.StopSequence),
+			ArrivalTime:   time.Duration(st.ArrivalTime),
+			DepartureTime: time.Duration(st.DepartureTime),
+		})
+	}
+	return modification.Apply(scheduled), true
+}
+
+// applyTripModifications adjusts the stop times at stopID to the GTFS-RT trip modifications on
+// the service date: stop times a modification removes are dropped, those after one are delayed,
+// and the trips it sends to the stop as a replacement stop are added when they run on one of
+// activeServiceIDs within the window, in nanoseconds since midnight. The caller must hold the
+// manager's read lock.
+func (api *RestAPI) applyTripModifications(ctx context.Context, stopID, serviceDate string, activeServiceIDs map[string]bool, windowStart, windowEnd int64, stopTimes []gtfsdb.GetStopTimesForStopInWindowRow) []gtfsdb.GetStopTimesForStopInWindowRow {
+	adjusted := make([]gtfsdb.GetStopTimesForStopInWindowRow, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		modified, ok := api.modifiedStopTimes(ctx, st.TripID, serviceDate)
+		if !ok {
+			adjusted = append(adjusted, st)
+			continue
+		}
+		for _, stop := range modified {
+			if !stop.Replacement && int64(stop.StopSequence) == st.StopSequence {
+				st.ArrivalTime = int64(stop.ArrivalTime)
+				st.DepartureTime = int64(stop.DepartureTime)
+				adjusted = append(adjusted, st)
+				break
+			}
+		}
+	}
+
+	for _, modification := range api.GtfsManager.GetTripModificationsServingStop(stopID, serviceDate) {
+		trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, modification.TripID)
+		if err != nil || !activeServiceIDs[trip.ServiceID] {
+			continue
+		}
+		modified, ok := api.modifiedStopTimes(ctx, trip.ID, serviceDate)
+		if !ok {
+			continue
+		}
+		for _, stop := range modified {
+			arrival := int64(stop.ArrivalTime)
+			if !stop.Replacement || stop.StopID != stopID || arrival < windowStart || arrival > windowEnd {
+				continue
+			}
+			adjusted = append(adjusted, gtfsdb.GetStopTimesForStopInWindowRow{
+				TripID:        trip.ID,
+				ArrivalTime:   arrival,
+				DepartureTime: int64(stop.DepartureTime),
+				StopID:        stopID,
+				StopSequence:  int64(stop.StopSequence),
+				RouteID:       trip.RouteID,
+				ServiceID:     trip.ServiceID,
+				TripHeadsign:  trip.TripHeadsign,
+				BlockID:       trip.BlockID,
+			})
+		}
+	}
+	return adjusted
+}
+
+// tripShapeID returns the realtime shape the trip follows on the service date under a GTFS-RT trip
+// modification, or its scheduled shape.
+func (api *RestAPI) tripShapeID(tripID, shapeID, serviceDate string) string {
+	if modification, ok := api.GtfsManager.GetTripModification(tripID, serviceDate); ok && modification.ShapeID != "" {
+		return modification.ShapeID
+	}
+	return shapeID
+}
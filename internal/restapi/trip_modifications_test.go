@@ -0,0 +1,155 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestTripModifications(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	trip, serviceDay := scheduledTrip(t, api)
+	tripID, routeID := trip.ID, trip.Route.Id
+	removedStop := trip.StopTimes[1].Stop.Id
+	removedSequence := uint32(trip.StopTimes[1].StopSequence)
+	removedAt := trip.StopTimes[1].ArrivalTime
+	tripStops, err := api.GtfsManager.GtfsDB.Queries.GetOrderedStopIDsForTrip(t.Context(), tripID)
+	require.NoError(t, err)
+	var replacementStop string
+	for _, stop := range api.GtfsManager.GetStops() {
+		if !slices.Contains(tripStops, stop.Id) {
+			replacementStop = stop.Id
+			break
+		}
+	}
+
+	feed := &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{GtfsRealtimeVersion: proto.String("2.0")},
+		Entity: []*gtfsrt.FeedEntity{
+			{
+				Id: proto.String("detour"),
+				TripModifications: &gtfsrt.TripModifications{
+					SelectedTrips: []*gtfsrt.TripModifications_SelectedTrips{{TripIds: []string{tripID}, ShapeId: proto.String("detour-shape")}},
+					ServiceDates:  []string{serviceDay.Format("20060102")},
+					Modifications: []*gtfsrt.TripModifications_Modification{{
+						StartStopSelector: &gtfsrt.StopSelector{StopSequence: proto.Uint32(removedSequence)},
+						EndStopSelector:   &gtfsrt.StopSelector{StopSequence: proto.Uint32(removedSequence)},
+						ReplacementStops:  []*gtfsrt.ReplacementStop{{StopId: proto.String(replacementStop), TravelTimeToStop: proto.Int32(60)}},
+					}},
+				},
+			},
+			{Id: proto.String("shape"), Shape: &gtfsrt.Shape{ShapeId: proto.String("detour-shape"), EncodedPolyline: proto.String("_p~iF~ps|U_ulLnnqC_mqNvxq`@")}},
+		},
+	}
+	body, err := proto.Marshal(feed)
+	require.NoError(t, err)
+	require.NoError(t, api.GtfsManager.MockLoadTripModifications(body))
+	defer func() {
+		empty, err := proto.Marshal(&gtfsrt.FeedMessage{Header: feed.Header})
+		require.NoError(t, err)
+		require.NoError(t, api.GtfsManager.MockLoadTripModifications(empty))
+	}()
+
+	at := strconv.FormatInt(serviceDay.Add(removedAt).UnixMilli(), 10)
+	combinedTripID := utils.FormCombinedID(agency.Id, tripID)
+	arrivalsAt := func(t *testing.T, stopID string) ([]models.ArrivalAndDeparture, []models.Trip) {
+		rec := serveSiri(t, api, "/api/where/arrivals-and-departures-for-stop/"+utils.FormCombinedID(agency.Id, stopID)+".json?key="+siriTestKey+"&time="+at)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var response struct {
+			Data struct {
+				Entry struct {
+					ArrivalsAndDepartures []models.ArrivalAndDeparture `json:"arrivalsAndDepartures"`
+				} `json:"entry"`
+				References struct {
+					Trips []models.Trip `json:"trips"`
+				} `json:"references"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		return response.Data.Entry.ArrivalsAndDepartures, response.Data.References.Trips
+	}
+
+	t.Run("removed stop", func(t *testing.T) {
+		arrivals, _ := arrivalsAt(t, removedStop)
+		for _, arrival := range arrivals {
+			assert.NotEqual(t, combinedTripID, arrival.TripID)
+		}
+	})
+
+	t.Run("replacement stop", func(t *testing.T) {
+		arrivals, trips := arrivalsAt(t, replacementStop)
+		var found bool
+		for _, arrival := range arrivals {
+			if arrival.TripID == combinedTripID {
+				found = true
+			}
+		}
+		require.True(t, found)
+		for _, trip := range trips {
+			if trip.ID == combinedTripID {
+				assert.Equal(t, utils.FormCombinedID(agency.Id, "detour-shape"), trip.ShapeID)
+			}
+		}
+	})
+
+	t.Run("shape", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/where/shape/"+utils.FormCombinedID(agency.Id, "detour-shape")+".json?key="+siriTestKey)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var response struct {
+			Data struct {
+				Entry models.ShapeEntry `json:"entry"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		assert.Equal(t, 3, response.Data.Entry.Length)
+	})
+
+	t.Run("stops for route", func(t *testing.T) {
+		rec := serveSiri(t, api, "/api/where/stops-for-route/"+utils.FormCombinedID(agency.Id, routeID)+".json?key="+siriTestKey+"&time="+serviceDay.Format("2006-01-02"))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var response struct {
+			Data struct {
+				Entry models.RouteEntry `json:"entry"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		assert.Contains(t, response.Data.Entry.StopIds, utils.FormCombinedID(agency.Id, replacementStop))
+	})
+}
+
+// scheduledTrip returns a trip with at least three stops and midnight, in the agency's time
+// zone, of a day it runs.
+func scheduledTrip(t *testing.T, api *RestAPI) (*gtfs.ScheduledTrip, time.Time) {
+	t.Helper()
+	loc, err := time.LoadLocation(api.GtfsManager.GetAgencies()[0].Timezone)
+	require.NoError(t, err)
+	for i := range api.GtfsManager.GetStaticData().Trips {
+		trip := &api.GtfsManager.GetStaticData().Trips[i]
+		if len(trip.StopTimes) < 3 {
+			continue
+		}
+		for day := time.Date(2025, 6, 2, 0, 0, 0, 0, loc); day.Before(time.Date(2026, 6, 2, 0, 0, 0, 0, loc)); day = day.AddDate(0, 0, 1) {
+			serviceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(t.Context(), day.Format("20060102"))
+			require.NoError(t, err)
+			if slices.Contains(serviceIDs, trip.Service.Id) {
+				return trip, day
+			}
+		}
+	}
+	t.Fatal("no trip with three stops runs")
+	return nil, time.Time{}
+}
@@ -96,6 +96,11 @@ func (api *RestAPI) tripsForLocationHandler(w http.ResponseWriter, r *http.Reque
 		Stops:       stops,
 		Trips:       result,
 	})
+	var situationIDs []string
+	for _, entry := range result {
+		situationIDs = append(situationIDs, entry.SituationIds...)
+	}
+	api.addSituationReferences(&references, situationIDs, alertLanguages(w, r))
 	response := models.NewListResponseWithRange(result, references, checkIfOutOfBounds(api, lat, lon, latSpan, lonSpan, 0), api.Clock, false)
 	api.sendResponse(w, r, response)
 }
@@ -120,7 +125,10 @@ func (api *RestAPI) parseAndValidateRequest(w http.ResponseWriter, r *http.Reque
 	timeParam := queryParams.Get("time")
 	currentTime := api.Clock.Now().In(currentLocation)
 	todayMidnight = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, currentLocation)
-	_, serviceDate, fieldErrors, success := utils.ParseTimeParameter(timeParam, currentLocation)
+	_, serviceDate, timeErrors, success := utils.ParseTimeParameter(timeParam, currentLocation)
+	for field, errs := range timeErrors {
+		fieldErrors[field] = append(fieldErrors[field], errs...)
+	}
 
 	ctx := r.Context()
 	if ctx.Err() != nil {
@@ -455,16 +463,16 @@ func (rb *referenceBuilder) build(params ReferenceParams) error {
 
 func (rb *referenceBuilder) collectTripIDs(trips []models.TripsForLocationListEntry) {
 	for _, trip := range trips {
-		_, tripID, err := utils.ExtractAgencyIDAndCodeID(trip.TripId)
+		_, tripID, err := rb.api.GtfsManager.ExtractAgencyIDAndCodeID(trip.TripId)
 		if err != nil {
 			rb.presentTrips[tripID] = models.Trip{}
 		}
 
 		if trip.Schedule != nil {
-			if _, nextID, err := utils.ExtractAgencyIDAndCodeID(trip.Schedule.NextTripId); err == nil {
+			if _, nextID, err := rb.api.GtfsManager.ExtractAgencyIDAndCodeID(trip.Schedule.NextTripId); err == nil {
 				rb.presentTrips[nextID] = models.Trip{}
 			}
-			if _, prevID, err := utils.ExtractAgencyIDAndCodeID(trip.Schedule.PreviousTripId); err == nil {
+			if _, prevID, err := rb.api.GtfsManager.ExtractAgencyIDAndCodeID(trip.Schedule.PreviousTripId); err == nil {
 				rb.presentTrips[prevID] = models.Trip{}
 			}
 		}
@@ -157,3 +157,10 @@ func TestTripsForLocationHandler_ScheduleInclusion(t *testing.T) {
 		})
 	}
 }
+
+func TestTripsForLocationHandler_InvalidCoordinates(t *testing.T) {
+	for _, query := range []string{"lat=north&lon=-122.39", "lat=NaN&lon=-122.39", "lat=40.58&lon=-122.39&latSpan=Inf&lonSpan=0.1"} {
+		_, resp, _ := serveAndRetrieveEndpoint(t, "/api/where/trips-for-location.json?key=TEST&"+query)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
@@ -29,7 +29,7 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	agencyID, routeID, err := utils.ExtractAgencyIDAndCodeID(id)
+	agencyID, routeID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(id)
 	if err != nil {
 		fieldErrors := map[string][]string{
 			"id": {err.Error()},
@@ -47,6 +47,17 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	sendNoTrips := func(w http.ResponseWriter, r *http.Request) {
+		references := buildTripReferences(api, w, r, ctx, includeSchedule, []models.TripsForRouteListEntry{}, []gtfsdb.Stop{})
+		response := models.NewListResponseWithRange([]models.TripsForRouteListEntry{}, references, false, api.Clock, false)
+		api.sendResponse(w, r, response)
+	}
+
+	if _, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, routeID); err != nil {
+		api.sendUnknownID(w, r, sendNoTrips)
+		return
+	}
+
 	currentLocation, err := time.LoadLocation(currentAgency.Timezone)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
@@ -66,9 +77,8 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Calculate nanoseconds since midnight of the service day
-	serviceDayMidnight := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, currentTime.Location())
-	nanosSinceMidnight := currentTime.Sub(serviceDayMidnight).Nanoseconds()
+	// Calculate nanoseconds since the start of the service day
+	nanosSinceMidnight := convertToNanosSinceMidnight(currentTime)
 	if nanosSinceMidnight < 0 {
 		nanosSinceMidnight = 0
 	}
@@ -91,9 +101,7 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 	layoverBlocks := gtfsInternal.GetBlocksInTimeRange(layoverIndices, timeRangeStart, timeRangeEnd)
 
 	if len(indexIDs) == 0 && len(layoverBlocks) == 0 {
-		references := buildTripReferences(api, w, r, ctx, includeSchedule, []models.TripsForRouteListEntry{}, []gtfsdb.Stop{})
-		response := models.NewListResponseWithRange([]models.TripsForRouteListEntry{}, references, false, api.Clock, false)
-		api.sendResponse(w, r, response)
+		sendNoTrips(w, r)
 		return
 	}
 
@@ -254,7 +262,7 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 			// Collect stop IDs from this trip's schedule
 			if schedule.StopTimes != nil {
 				for _, stopTime := range schedule.StopTimes {
-					_, stopID, err := utils.ExtractAgencyIDAndCodeID(stopTime.StopID)
+					_, stopID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(stopTime.StopID)
 					if err == nil {
 						stopIDsMap[stopID] = true
 					}
@@ -298,6 +306,11 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 
 	// Pass only the result list; references function will fetch what it needs
 	references := buildTripReferences(api, w, r, ctx, includeSchedule, result, stops)
+	var situationIDs []string
+	for _, entry := range result {
+		situationIDs = append(situationIDs, entry.SituationIds...)
+	}
+	api.addSituationReferences(&references, situationIDs, alertLanguages(w, r))
 	response := models.NewListResponseWithRange(result, references, false, api.Clock, false)
 	api.sendResponse(w, r, response)
 }
@@ -317,7 +330,7 @@ func buildTripReferences[T interface{ GetTripId() string }](
 	presentRoutes := make(map[string]models.Route)
 
 	for _, trip := range trips {
-		_, tripID, _ := utils.ExtractAgencyIDAndCodeID(trip.GetTripId())
+		_, tripID, _ := api.GtfsManager.ExtractAgencyIDAndCodeID(trip.GetTripId())
 		presentTrips[tripID] = models.Trip{}
 	}
 
@@ -327,13 +340,13 @@ func buildTripReferences[T interface{ GetTripId() string }](
 		if entry, ok := any(tripEntry).(models.TripsForRouteListEntry); ok {
 			if entry.Schedule != nil {
 				if entry.Schedule.NextTripId != "" {
-					_, nextTripID, err := utils.ExtractAgencyIDAndCodeID(entry.Schedule.NextTripId)
+					_, nextTripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(entry.Schedule.NextTripId)
 					if err == nil {
 						presentTrips[nextTripID] = models.Trip{}
 					}
 				}
 				if entry.Schedule.PreviousTripId != "" {
-					_, prevTripID, err := utils.ExtractAgencyIDAndCodeID(entry.Schedule.PreviousTripId)
+					_, prevTripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(entry.Schedule.PreviousTripId)
 					if err == nil {
 						presentTrips[prevTripID] = models.Trip{}
 					}
@@ -341,7 +354,7 @@ func buildTripReferences[T interface{ GetTripId() string }](
 			}
 
 			if entry.Status != nil && entry.Status.ActiveTripID != "" {
-				_, activeTripID, err := utils.ExtractAgencyIDAndCodeID(entry.Status.ActiveTripID)
+				_, activeTripID, err := api.GtfsManager.ExtractAgencyIDAndCodeID(entry.Status.ActiveTripID)
 				if err == nil {
 					presentTrips[activeTripID] = models.Trip{}
 				}
@@ -24,11 +24,18 @@ func TestTripsForRouteHandler_DifferentRoutes(t *testing.T) {
 	}{
 		{
 			name:         "Main Route",
-			routeID:      "25_1",
+			routeID:      "25_151",
 			minExpected:  0,
 			maxExpected:  50,
 			expectStatus: http.StatusOK,
 		},
+		{
+			name:         "Unknown Route",
+			routeID:      "25_1",
+			minExpected:  0,
+			maxExpected:  0,
+			expectStatus: http.StatusNotFound,
+		},
 		{
 			name:         "Non-existent Route",
 			routeID:      "NONEXISTENT",
@@ -192,7 +199,7 @@ func TestTripsForRouteHandler_ScheduleInclusion(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			url := fmt.Sprintf("/api/where/trips-for-route/25_1.json?key=TEST&includeSchedule=%v", tt.includeSchedule)
+			url := fmt.Sprintf("/api/where/trips-for-route/25_151.json?key=TEST&includeSchedule=%v", tt.includeSchedule)
 
 			resp, model := serveApiAndRetrieveEndpoint(t, api, url)
 			assert.Equal(t, http.StatusOK, resp.StatusCode)
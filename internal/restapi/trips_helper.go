@@ -101,7 +101,7 @@ func (api *RestAPI) BuildTripStatus(
 			closestStopID, closestOffset = findClosestStop(api, ctx, vehicle.Position, stopTimesPtrs)
 			nextStopID, nextOffset = findNextStop(api, stopTimesPtrs, vehicle)
 		} else {
-			currentTimeSeconds := int64(currentTime.Hour()*3600 + currentTime.Minute()*60 + currentTime.Second())
+			currentTimeSeconds := int64(utils.SinceServiceDayStart(currentTime) / time.Second)
 			closestStopID, closestOffset = findClosestStopByTime(currentTimeSeconds, stopTimesPtrs)
 			nextStopID, nextOffset = findNextStopByTime(currentTimeSeconds, stopTimesPtrs)
 		}
@@ -1,9 +1,13 @@
 package restapi
 
 import (
+	"context"
 	"net/http"
 	"time"
 
+	"github.com/OneBusAway/go-gtfs"
+
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -24,16 +28,29 @@ func (api *RestAPI) vehiclesForAgencyHandler(w http.ResponseWriter, r *http.Requ
 
 	agency := api.GtfsManager.FindAgency(id)
 	if agency == nil {
-		// return an empty list response.
-		api.sendResponse(w, r, models.NewListResponse([]interface{}{}, models.ReferencesModel{}, false, api.Clock))
+		api.sendUnknownID(w, r, func(w http.ResponseWriter, r *http.Request) {
+			api.sendResponse(w, r, models.NewListResponse([]interface{}{}, models.ReferencesModel{}, false, api.Clock))
+		})
 		return
 	}
 
 	vehiclesForAgency := api.GtfsManager.VehiclesForAgencyID(id)
 
 	// Apply pagination
-	offset, limit := utils.ParsePaginationParams(r)
+	defaultLimit, maxLimit := api.pageLimits(r, appconf.PaginationClassList, -1, models.MaxPageSize)
+	offset, limit := utils.ParsePaginationParams(r, defaultLimit, maxLimit)
 	vehiclesForAgency, limitExceeded := utils.PaginateSlice(vehiclesForAgency, offset, limit)
+
+	vehiclesList, references := api.vehicleStatusesForAgency(r.Context(), agency, vehiclesForAgency)
+	response := models.NewListResponse(vehiclesList, references, limitExceeded, api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// vehicleStatusesForAgency builds the vehicle statuses of the vehicles-for-agency endpoints and
+// their references. Caller must hold the manager's read lock.
+func (api *RestAPI) vehicleStatusesForAgency(ctx context.Context, agency *gtfs.Agency, vehiclesForAgency []gtfs.Vehicle) ([]models.VehicleStatus, models.ReferencesModel) {
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.Id)
+	serviceDates := api.vehicleServiceDates(ctx, vehiclesForAgency, api.Clock.Now(), loc)
 	vehiclesList := make([]models.VehicleStatus, 0, len(vehiclesForAgency))
 
 	// Maps to build references
@@ -92,8 +109,7 @@ func (api *RestAPI) vehiclesForAgencyHandler(w http.ResponseWriter, r *http.Requ
 				tripStatus.Orientation = float32(obaOrientation)
 			}
 
-			// Set service date (use current date for now)
-			tripStatus.ServiceDate = api.Clock.NowUnixMilli()
+			tripStatus.ServiceDate = serviceDates.forTrip(vehicle.Trip.ID).UnixMilli()
 
 			vehicleStatus.TripStatus = tripStatus
 
@@ -104,7 +120,7 @@ func (api *RestAPI) vehiclesForAgencyHandler(w http.ResponseWriter, r *http.Requ
 			}
 
 			// Find and add route to references
-			if route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(r.Context(), vehicle.Trip.ID.RouteID); err == nil {
+			if route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, vehicle.Trip.ID.RouteID); err == nil {
 				shortName := ""
 				if route.ShortName.Valid {
 					shortName = route.ShortName.String
@@ -172,7 +188,5 @@ func (api *RestAPI) vehiclesForAgencyHandler(w http.ResponseWriter, r *http.Requ
 		Stops:      []models.Stop{},
 		Trips:      tripRefList,
 	}
-
-	response := models.NewListResponse(vehiclesList, references, limitExceeded, api.Clock)
-	api.sendResponse(w, r, response)
+	return vehiclesList, references
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/app"
 	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/clock"
@@ -63,16 +64,9 @@ func TestVehiclesForAgencyHandlerWithNonExistentAgency(t *testing.T) {
 	defer api.Shutdown()
 	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/vehicles-for-agency/nonexistent.json?key=TEST")
 
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.Equal(t, 200, model.Code)
-	assert.Equal(t, "OK", model.Text)
-
-	data, ok := model.Data.(map[string]interface{})
-	require.True(t, ok)
-
-	list, ok := data["list"].([]interface{})
-	require.True(t, ok)
-	assert.Len(t, list, 0)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, http.StatusNotFound, model.Code)
+	assert.Equal(t, "resource not found", model.Text)
 }
 
 func TestVehiclesForAgencyHandlerResponseStructure(t *testing.T) {
@@ -524,3 +518,69 @@ func TestVehiclesForAgencyHandlerWithRealTimeData(t *testing.T) {
 		assert.Len(t, vehiclesList, 0)
 	}
 }
+
+func TestVehiclesForAgencyHandlerServiceDate(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Now())
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+
+	trip, day := scheduledTrip(t, api)
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	ctx := t.Context()
+
+	// A trip that runs past midnight on the same service as trip
+	queries := api.GtfsManager.GtfsDB.Queries
+	_, err := queries.CreateTrip(ctx, gtfsdb.CreateTripParams{ID: "OWL_TRIP", RouteID: trip.Route.Id, ServiceID: trip.Service.Id})
+	require.NoError(t, err)
+	for i, at := range []time.Duration{23*time.Hour + 30*time.Minute, 25*time.Hour + 10*time.Minute} {
+		_, err := queries.CreateStopTime(ctx, gtfsdb.CreateStopTimeParams{
+			TripID: "OWL_TRIP", StopID: trip.StopTimes[i].Stop.Id, StopSequence: int64(i + 1),
+			ArrivalTime: int64(at), DepartureTime: int64(at),
+		})
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() {
+		_, _ = api.GtfsManager.GtfsDB.DB.Exec("DELETE FROM stop_times WHERE trip_id = 'OWL_TRIP'")
+		_, _ = api.GtfsManager.GtfsDB.DB.Exec("DELETE FROM trips WHERE id = 'OWL_TRIP'")
+	})
+
+	api.GtfsManager.MockAddVehicle("OWL_VEHICLE", "OWL_TRIP", trip.Route.Id)
+	api.GtfsManager.MockAddVehicle("OWL_VEHICLE_2", "OWL_TRIP", trip.Route.Id)
+	api.GtfsManager.MockAddVehicle("DAY_VEHICLE", trip.ID, trip.Route.Id)
+	api.GtfsManager.MockAddVehicle("DATED_VEHICLE", trip.ID, trip.Route.Id)
+	t.Cleanup(func() {
+		for _, id := range []string{"OWL_VEHICLE", "OWL_VEHICLE_2", "DAY_VEHICLE", "DATED_VEHICLE"} {
+			api.GtfsManager.MockRemoveVehicle(id)
+		}
+	})
+	for _, vehicle := range api.GtfsManager.GetRealTimeVehicles() {
+		if vehicle.ID.ID == "DATED_VEHICLE" {
+			vehicle.Trip.ID.HasStartDate = true
+			vehicle.Trip.ID.StartDate = time.Date(day.Year(), day.Month(), day.Day()-3, 0, 0, 0, 0, time.UTC)
+		}
+	}
+
+	serviceDates := func(t *testing.T) map[string]int64 {
+		resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/vehicles-for-agency/"+agencyID+".json?key=TEST")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		dates := map[string]int64{}
+		for _, v := range model.Data.(map[string]interface{})["list"].([]interface{}) {
+			vehicle := v.(map[string]interface{})
+			if status, ok := vehicle["tripStatus"].(map[string]interface{}); ok {
+				dates[vehicle["vehicleId"].(string)] = int64(status["serviceDate"].(float64))
+			}
+		}
+		return dates
+	}
+
+	nextDay := day.AddDate(0, 0, 1)
+	mockClock.Set(nextDay.Add(40 * time.Minute))
+	dates := serviceDates(t)
+	assert.Equal(t, day.UnixMilli(), dates["OWL_VEHICLE"], "still running from the previous service day")
+	assert.Equal(t, day.UnixMilli(), dates["OWL_VEHICLE_2"], "a second vehicle on the same trip")
+	assert.Equal(t, nextDay.UnixMilli(), dates["DAY_VEHICLE"])
+	assert.Equal(t, day.AddDate(0, 0, -3).UnixMilli(), dates["DATED_VEHICLE"], "start date from the feed")
+
+	mockClock.Set(nextDay.Add(3 * time.Hour))
+	assert.Equal(t, nextDay.UnixMilli(), serviceDates(t)["OWL_VEHICLE"], "long past the end of yesterday's trip")
+}
@@ -0,0 +1,114 @@
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/klauspost/compress/gzhttp"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// vehiclesForAgencyStreamHandler streams an agency's vehicles as server-sent events: a
+// "vehicles" event with the same list response as vehicles-for-agency, unpaginated, straight
+// away and after every GTFS-RT refresh. A comment goes out when the feed has been quiet for
+// vehicleStreamResendInterval, so proxies keep the connection open. The stream ends when the
+// client disconnects or the server starts draining.
+func (api *RestAPI) vehiclesForAgencyStreamHandler(w http.ResponseWriter, r *http.Request) {
+	id := utils.ExtractIDFromParams(r)
+	if err := utils.ValidateID(id); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"id": {err.Error()}})
+		return
+	}
+
+	api.GtfsManager.RLock()
+	found := api.GtfsManager.FindAgency(id) != nil
+	api.GtfsManager.RUnlock()
+	if !found {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Subscribe before the first event, so a refresh in between is not missed
+	updates, unsubscribe := api.GtfsManager.SubscribeRealtime()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("X-Accel-Buffering", "no")
+	// Compression would hold events back until its buffer fills
+	w.Header().Set(gzhttp.HeaderNoCompression, "1")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(vehicleStreamPollInterval)
+	defer ticker.Stop()
+
+	send := func(event string) bool {
+		if _, err := fmt.Fprint(w, event); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	sendVehicles := func() bool {
+		event, err := api.vehiclesForAgencyEvent(r, id)
+		if err != nil {
+			logging.LogError(api.requestLogger(r), "failed to encode vehicles for agency", err)
+			return false
+		}
+		return send(event)
+	}
+
+	if !sendVehicles() {
+		return
+	}
+	sentAt := time.Now()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates:
+			if !sendVehicles() {
+				return
+			}
+			sentAt = time.Now()
+		case <-ticker.C:
+			if api.Draining() {
+				return
+			}
+			if time.Since(sentAt) >= vehicleStreamResendInterval {
+				if !send(": keepalive\n\n") {
+					return
+				}
+				sentAt = time.Now()
+			}
+		}
+	}
+}
+
+// vehiclesForAgencyEvent renders the "vehicles" event of the vehicle stream for agency id. The
+// agency may have gone with a static feed swap, leaving an empty list.
+func (api *RestAPI) vehiclesForAgencyEvent(r *http.Request, id string) (string, error) {
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	list, references := []models.VehicleStatus{}, models.ReferencesModel{}
+	if agency := api.GtfsManager.FindAgency(id); agency != nil {
+		list, references = api.vehicleStatusesForAgency(r.Context(), agency, api.GtfsManager.VehiclesForAgencyID(id))
+	}
+	data, err := json.Marshal(models.NewListResponse(list, references, false, api.Clock))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("event: vehicles\ndata: %s\n\n", data), nil
+}
@@ -0,0 +1,81 @@
+package restapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readVehiclesEvent reads server-sent events up to the next "vehicles" event and decodes its data.
+func readVehiclesEvent(t *testing.T, events *bufio.Scanner) map[string]any {
+	var name string
+	for events.Scan() {
+		line := events.Text()
+		if event, ok := strings.CutPrefix(line, "event: "); ok {
+			name = event
+		} else if data, ok := strings.CutPrefix(line, "data: "); ok && name == "vehicles" {
+			var response map[string]any
+			require.NoError(t, json.Unmarshal([]byte(data), &response))
+			return response
+		}
+	}
+	require.NoError(t, events.Err())
+	t.Fatal("stream ended without a vehicles event")
+	return nil
+}
+
+func TestVehiclesForAgencyStreamHandler(t *testing.T) {
+	api, cleanup := createTestApiWithRealTimeData(t)
+	defer cleanup()
+
+	agencies := api.GtfsManager.GetAgencies()
+	require.NotEmpty(t, agencies)
+	agencyID := agencies[0].Id
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/where/vehicles-for-agency/"+agencyID+"/stream?key=TEST", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	events := bufio.NewScanner(resp.Body)
+	events.Buffer(nil, 1<<20)
+	first := readVehiclesEvent(t, events)
+	assert.Equal(t, float64(http.StatusOK), first["code"])
+	list := first["data"].(map[string]any)["list"].([]any)
+	assert.Len(t, list, len(api.GtfsManager.VehiclesForAgencyID(agencyID)))
+
+	// Each realtime refresh is pushed without waiting for a poll
+	require.NoError(t, api.GtfsManager.RefreshRealtime(t.Context()))
+	second := readVehiclesEvent(t, events)
+	assert.GreaterOrEqual(t, second["currentTime"], first["currentTime"])
+}
+
+func TestVehiclesForAgencyStreamHandler_UnknownAgency(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/where/vehicles-for-agency/nope/stream?key=TEST", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
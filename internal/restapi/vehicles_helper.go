@@ -2,12 +2,100 @@ package restapi
 
 import (
 	"context"
+	"time"
 
 	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
 
+// lateTripAllowance is how long after its scheduled end a trip is still taken to be running
+// on the previous service day.
+const lateTripAllowance = time.Hour
+
+// vehicleServiceDates holds the service dates of the trips a page of vehicles reports, loaded
+// with one query per table rather than per vehicle.
+type vehicleServiceDates struct {
+	today     time.Time       // Midnight of today's date, only ever used as a date
+	yesterday map[string]bool // Trips running past midnight on yesterday's service
+}
+
+// vehicleServiceDates loads the service dates of the trips vehicles report. A trip's service
+// date is its start date when the feed gives one. Otherwise it is today, unless the trip runs
+// past midnight, ran yesterday and is still within its schedule counted from yesterday, in
+// which case it is yesterday.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) vehicleServiceDates(ctx context.Context, vehicles []gtfs.Vehicle, now time.Time, loc *time.Location) vehicleServiceDates {
+	y, m, d := now.In(loc).Date()
+	dates := vehicleServiceDates{
+		today:     time.Date(y, m, d, 0, 0, 0, 0, loc),
+		yesterday: make(map[string]bool),
+	}
+	yesterday := dates.today.AddDate(0, 0, -1)
+
+	var tripIDs []string
+	seen := make(map[string]bool)
+	for _, vehicle := range vehicles {
+		if vehicle.Trip == nil || vehicle.Trip.ID.HasStartDate || seen[vehicle.Trip.ID.ID] {
+			continue
+		}
+		seen[vehicle.Trip.ID.ID] = true
+		tripIDs = append(tripIDs, vehicle.Trip.ID.ID)
+	}
+	if len(tripIDs) == 0 {
+		return dates
+	}
+
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTripIDs(ctx, tripIDs)
+	if err != nil {
+		return dates
+	}
+	ends := make(map[string]time.Duration)
+	for _, st := range stopTimes {
+		ends[st.TripID] = max(ends[st.TripID], time.Duration(st.ArrivalTime), time.Duration(st.DepartureTime))
+	}
+	var overnight []string
+	for tripID, end := range ends {
+		if end >= 24*time.Hour && !now.After(utils.ServiceTime(yesterday, end+lateTripAllowance)) {
+			overnight = append(overnight, tripID)
+		}
+	}
+	if len(overnight) == 0 {
+		return dates
+	}
+
+	trips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByIDs(ctx, overnight)
+	if err != nil {
+		return dates
+	}
+	activeYesterday := make(map[string]bool)
+	for _, trip := range trips {
+		active, checked := activeYesterday[trip.ServiceID]
+		if !checked {
+			n, err := api.GtfsManager.IsServiceActiveOnDate(ctx, trip.ServiceID, yesterday)
+			active = err == nil && n > 0
+			activeYesterday[trip.ServiceID] = active
+		}
+		if active {
+			dates.yesterday[trip.ID] = true
+		}
+	}
+	return dates
+}
+
+// forTrip returns the start of the service day of a vehicle's trip, noon minus 12 hours in the
+// agency's time zone, as the service dates elsewhere in the API are given.
+func (dates vehicleServiceDates) forTrip(tripID gtfs.TripID) time.Time {
+	if tripID.HasStartDate {
+		y, m, d := tripID.StartDate.Date()
+		return utils.ServiceDayStart(time.Date(y, m, d, 0, 0, 0, 0, dates.today.Location()))
+	}
+	if dates.yesterday[tripID.ID] {
+		return utils.ServiceDayStart(dates.today.AddDate(0, 0, -1))
+	}
+	return utils.ServiceDayStart(dates.today)
+}
+
 // GetVehicleStatusAndPhase returns status and phase based on GTFS-RT CurrentStatus
 func GetVehicleStatusAndPhase(vehicle *gtfs.Vehicle) (status string, phase string) {
 	if vehicle == nil || vehicle.CurrentStatus == nil {
@@ -0,0 +1,22 @@
+package restapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVehicleServiceDatesForTrip_ClockChange(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	// Clocks went forward on 9 March 2025, so its service day started at 11pm the evening before
+	dates := vehicleServiceDates{today: time.Date(2025, 3, 9, 0, 0, 0, 0, loc), yesterday: map[string]bool{"overnight": true}}
+	dstStart := time.Date(2025, 3, 8, 23, 0, 0, 0, loc).UnixMilli()
+
+	assert.Equal(t, dstStart, dates.forTrip(gtfs.TripID{ID: "day"}).UnixMilli())
+	assert.Equal(t, dstStart, dates.forTrip(gtfs.TripID{ID: "started", HasStartDate: true, StartDate: time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC)}).UnixMilli())
+	assert.Equal(t, time.Date(2025, 3, 8, 0, 0, 0, 0, loc).UnixMilli(), dates.forTrip(gtfs.TripID{ID: "overnight"}).UnixMilli())
+}
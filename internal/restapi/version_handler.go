@@ -0,0 +1,22 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/internal/buildinfo"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// versionHandler reports which build is running, so operators can confirm a deploy.
+func (api *RestAPI) versionHandler(w http.ResponseWriter, r *http.Request) {
+	info := buildinfo.Get()
+	entry := models.VersionInfo{
+		Version:   info.Version,
+		Commit:    info.Commit,
+		BuildDate: info.BuildDate,
+		GoVersion: info.GoVersion,
+	}
+
+	response := models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
@@ -0,0 +1,28 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/buildinfo"
+)
+
+func TestVersionHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	buildinfo.Version, buildinfo.Commit = "v1.2.0", "0f3c2a1"
+	t.Cleanup(func() { buildinfo.Version, buildinfo.Commit = "", "" })
+
+	// No API key needed, like the health probes
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/status/version.json")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-cache, no-store, must-revalidate", resp.Header.Get("Cache-Control"))
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "v1.2.0", entry["version"])
+	assert.Equal(t, "0f3c2a1", entry["commit"])
+	assert.NotEmpty(t, entry["goVersion"])
+}
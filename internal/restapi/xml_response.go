@@ -0,0 +1,227 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// wantsXML reports whether the request is for the .xml variant of an endpoint.
+func wantsXML(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.Path, ".xml")
+}
+
+// encodeXMLResponse writes the response as XML, laid out as the Java OneBusAway server lays out
+// its .xml responses: a <response> root, an element per field, and list items named after
+// their type (<stop>, <route>, ...) or <string> for lists of IDs. The fields and their order
+// are those of the JSON response, java-parity included.
+func (api *RestAPI) encodeXMLResponse(w io.Writer, response models.ResponseModel) error {
+	var buf bytes.Buffer
+	var err error
+	if api.Config.JavaParity {
+		err = encodeJavaParity(&buf, response)
+	} else {
+		err = json.NewEncoder(&buf).Encode(response)
+	}
+	if err != nil {
+		return err
+	}
+	return writeXMLResponse(w, buf.Bytes(), response.Data)
+}
+
+// writeXMLResponse converts a JSON response envelope to XML. data is the response's data before
+// serialization, from which the class of the data and the names of the list items are taken.
+func writeXMLResponse(w io.Writer, jsonResponse []byte, data interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonResponse))
+	dec.UseNumber()
+	conv := &xmlConverter{
+		dec:      dec,
+		enc:      xml.NewEncoder(w),
+		listItem: listItemName(data),
+	}
+	if dataMap, ok := data.(map[string]interface{}); ok {
+		if _, ok := dataMap["list"]; ok {
+			conv.dataClass = "listWithReferences"
+		} else if _, ok := dataMap["entry"]; ok {
+			conv.dataClass = "entryWithReferences"
+		}
+	}
+	if err := conv.value("response", 0); err != nil {
+		return err
+	}
+	return conv.enc.Flush()
+}
+
+// xmlConverter streams JSON tokens out as XML elements.
+type xmlConverter struct {
+	dec       *json.Decoder
+	enc       *xml.Encoder
+	listItem  string
+	dataClass string
+}
+
+// value writes the next JSON value as an element called name. A null is left out, as the
+// Java server leaves out null fields.
+func (c *xmlConverter) value(name string, depth int) error {
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	start := xmlStart(name)
+	if depth == 1 && name == "data" && c.dataClass != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "class"}, Value: c.dataClass})
+	}
+
+	switch t := tok.(type) {
+	case nil:
+		return nil
+	case json.Delim:
+		if err := c.enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if t == '{' {
+			for c.dec.More() {
+				keyTok, err := c.dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := keyTok.(string)
+				if err := c.value(key, depth+1); err != nil {
+					return err
+				}
+			}
+		} else {
+			item := c.itemName(name)
+			for c.dec.More() {
+				if err := c.arrayItem(item, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		// The closing delimiter
+		if _, err := c.dec.Token(); err != nil {
+			return err
+		}
+		return c.enc.EncodeToken(start.End())
+	case string:
+		return c.text(start, t)
+	case json.Number:
+		return c.text(start, t.String())
+	case bool:
+		if t {
+			return c.text(start, "true")
+		}
+		return c.text(start, "false")
+	default:
+		return errors.New("unexpected JSON token")
+	}
+}
+
+// arrayItem writes the next item of a list, naming string items <string> as the Java server's
+// serializer does.
+func (c *xmlConverter) arrayItem(name string, depth int) error {
+	var item json.RawMessage
+	if err := c.dec.Decode(&item); err != nil {
+		return err
+	}
+	if len(item) > 0 && item[0] == '"' {
+		name = "string"
+	}
+	dec := json.NewDecoder(bytes.NewReader(item))
+	dec.UseNumber()
+	sub := &xmlConverter{dec: dec, enc: c.enc, listItem: c.listItem}
+	return sub.value(name, depth)
+}
+
+func (c *xmlConverter) text(start xml.StartElement, s string) error {
+	if err := c.enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := c.enc.EncodeToken(xml.CharData(s)); err != nil {
+		return err
+	}
+	return c.enc.EncodeToken(start.End())
+}
+
+// itemName names the items of the list called name: the singular of the name, so that
+// <stops> holds <stop> elements, or for the top-level list, the name of its item type.
+func (c *xmlConverter) itemName(name string) string {
+	switch {
+	case name == "list" && c.listItem != "":
+		return c.listItem
+	case name == "arrivalsAndDepartures":
+		return "arrivalAndDeparture"
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return strings.TrimSuffix(name, "s")
+	}
+	return "element"
+}
+
+// xmlStart starts an element called name. Names that are not valid XML, such as the stop IDs
+// keying some maps, become <entry key="..."> instead.
+func xmlStart(name string) xml.StartElement {
+	if isXMLName(name) {
+		return xml.StartElement{Name: xml.Name{Local: name}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+	}
+}
+
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		if unicode.IsLetter(r) || r == '_' {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// listItemName returns the element name for the items of the list in a list response, taken
+// from their type: []models.Stop gives "stop" and []models.AgencyReference gives "agency".
+// It is empty for a response without a list or with items of no named type.
+func listItemName(data interface{}) string {
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	list := reflect.ValueOf(dataMap["list"])
+	if list.Kind() != reflect.Slice {
+		return ""
+	}
+	itemType := list.Type().Elem()
+	if itemType.Kind() == reflect.Interface && list.Len() > 0 {
+		itemType = reflect.TypeOf(list.Index(0).Interface())
+	}
+	for itemType != nil && itemType.Kind() == reflect.Pointer {
+		itemType = itemType.Elem()
+	}
+	if itemType == nil || itemType.Name() == "" || itemType.Kind() == reflect.String {
+		return ""
+	}
+	name := strings.TrimSuffix(itemType.Name(), "Reference")
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(first)) + name[size:]
+}
@@ -0,0 +1,114 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestWriteXMLResponse(t *testing.T) {
+	data := map[string]interface{}{
+		"list": []models.Stop{{ID: "25_1", Name: "First & Main", RouteIDs: []string{"25_a", "25_b"}}},
+		"references": map[string]interface{}{
+			"agencies": []interface{}{map[string]interface{}{"id": "25", "phone": nil}},
+			"byStop":   map[string]interface{}{"25_1": true},
+		},
+	}
+	body, err := json.Marshal(models.ResponseModel{Code: 200, CurrentTime: 1, Data: data, Text: "OK", Version: 2})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeXMLResponse(&buf, body, data))
+	got := buf.String()
+	assert.Contains(t, got, `<?xml version="1.0" encoding="UTF-8"?>`)
+	assert.Contains(t, got, `<data class="listWithReferences"><list><stop><code></code>`)
+	assert.Contains(t, got, `<id>25_1</id>`)
+	assert.Contains(t, got, `<name>First &amp; Main</name>`)
+	assert.Contains(t, got, `<routeIds><string>25_a</string><string>25_b</string></routeIds>`)
+	assert.Contains(t, got, `<agencies><agency><id>25</id></agency></agencies>`, "null fields are left out")
+	assert.Contains(t, got, `<byStop><entry key="25_1">true</entry></byStop>`)
+	assert.Contains(t, got, `<text>OK</text><version>2</version></response>`)
+}
+
+func TestXMLResponses(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)))
+	defer api.Shutdown()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	type stop struct {
+		ID       string   `xml:"id"`
+		Name     string   `xml:"name"`
+		RouteIDs []string `xml:"routeIds>string"`
+	}
+	var entry struct {
+		XMLName xml.Name `xml:"response"`
+		Code    int      `xml:"code"`
+		Data    struct {
+			Class      string `xml:"class,attr"`
+			Entry      stop   `xml:"entry"`
+			References struct {
+				Routes []struct {
+					ID string `xml:"id"`
+				} `xml:"routes>route"`
+			} `xml:"references"`
+		} `xml:"data"`
+	}
+	rec := serve("/api/where/stop/25_327.xml?key=" + siriTestKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+	require.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &entry))
+	assert.Equal(t, 200, entry.Code)
+	assert.Equal(t, "entryWithReferences", entry.Data.Class)
+	assert.Equal(t, "25_327", entry.Data.Entry.ID)
+	assert.NotEmpty(t, entry.Data.Entry.Name)
+	assert.NotEmpty(t, entry.Data.Entry.RouteIDs)
+	assert.Len(t, entry.Data.References.Routes, len(entry.Data.Entry.RouteIDs))
+
+	// Paths without an ID have a .xml route of their own
+	var list struct {
+		Data struct {
+			Class string `xml:"class,attr"`
+			List  []stop `xml:"list>stop"`
+		} `xml:"data"`
+	}
+	rec = serve("/api/where/stops-for-location.xml?lat=40.583321&lon=-122.426966&key=" + siriTestKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, "listWithReferences", list.Data.Class)
+	assert.NotEmpty(t, list.Data.List)
+
+	// Errors are XML too
+	var failure struct {
+		Code int    `xml:"code"`
+		Text string `xml:"text"`
+	}
+	rec = serve("/api/where/stop/25_nonexistent.xml?key=" + siriTestKey)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &failure))
+	assert.Equal(t, http.StatusNotFound, failure.Code)
+
+	rec = serve("/api/where/stop/25_327.xml?key=wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &failure))
+	assert.Equal(t, "permission denied", failure.Text)
+
+	// The JSON variant is unchanged
+	rec = serve("/api/where/stop/25_327.json?key=" + siriTestKey)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.True(t, json.Valid(rec.Body.Bytes()))
+}
@@ -0,0 +1,145 @@
+package ridership
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Groupings for Summary
+const (
+	GroupNone  = ""      // One bucket with every count; its key is empty
+	GroupDay   = "day"   // By service date, YYYYMMDD
+	GroupRoute = "route" // By route ID; empty for trips that were not in the static schedule
+	GroupStop  = "stop"  // By stop ID
+	GroupTrip  = "trip"  // By trip ID
+)
+
+var groupKeys = map[string]string{
+	GroupNone:  "''",
+	GroupDay:   "service_date",
+	GroupRoute: "route_id",
+	GroupStop:  "stop_id",
+	GroupTrip:  "trip_id",
+}
+
+// Filter selects counts. Every field narrows it when set; dates are YYYYMMDD and inclusive.
+type Filter struct {
+	RouteID   string
+	StopID    string
+	TripID    string
+	StartDate string
+	EndDate   string
+}
+
+// where selects the complete counts matching f. Partial counts (record_use 1) would undercount
+// the trips they cover, so they are left out.
+func (f Filter) where() (string, []any) {
+	conditions := []string{"record_use = 0"}
+	var args []any
+	for _, c := range []struct {
+		condition string
+		value     string
+	}{
+		{"route_id = ?", f.RouteID},
+		{"stop_id = ?", f.StopID},
+		{"trip_id = ?", f.TripID},
+		{"service_date >= ?", f.StartDate},
+		{"service_date <= ?", f.EndDate},
+	} {
+		if c.value != "" {
+			conditions = append(conditions, c.condition)
+			args = append(args, c.value)
+		}
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// Bucket totals the counts of a group.
+type Bucket struct {
+	Key         string
+	Boardings   int64
+	Alightings  int64
+	ServiceDays int64 // Distinct service dates with counts
+	MaxLoad     int64 // Highest load reported on leaving a stop
+}
+
+// Summary totals the counts matching filter, grouped by group and ordered by key. With
+// GroupNone, the single bucket is returned even when nothing matches.
+func (s *Store) Summary(ctx context.Context, filter Filter, group string) ([]Bucket, error) {
+	key, ok := groupKeys[group]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q", group)
+	}
+	where, args := filter.where()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+key+` AS bucket,
+			COALESCE(SUM(boardings), 0),
+			COALESCE(SUM(alightings), 0),
+			COUNT(DISTINCT service_date),
+			COALESCE(MAX(current_load), 0)
+		FROM board_alight WHERE `+where+`
+		GROUP BY bucket ORDER BY bucket`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ridership: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	buckets := []Bucket{}
+	for rows.Next() {
+		var bucket Bucket
+		if err := rows.Scan(&bucket.Key, &bucket.Boardings, &bucket.Alightings, &bucket.ServiceDays, &bucket.MaxLoad); err != nil {
+			return nil, fmt.Errorf("failed to scan ridership: %w", err)
+		}
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if group == GroupNone && len(buckets) == 0 {
+		buckets = append(buckets, Bucket{})
+	}
+	return buckets, nil
+}
+
+// StopLoad is the ridership at one stop of a trip.
+type StopLoad struct {
+	StopSequence int64
+	StopID       string
+	Boardings    int64
+	Alightings   int64
+	ServiceDays  int64
+	AverageLoad  float64 // Mean load on leaving the stop, over the days it was reported
+	MaxLoad      int64
+}
+
+// LoadProfile totals the counts matching filter, which should select a trip, at each of its
+// stops in stop sequence order.
+func (s *Store) LoadProfile(ctx context.Context, filter Filter) ([]StopLoad, error) {
+	where, args := filter.where()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT stop_sequence, stop_id,
+			COALESCE(SUM(boardings), 0),
+			COALESCE(SUM(alightings), 0),
+			COUNT(DISTINCT service_date),
+			COALESCE(AVG(current_load), 0),
+			COALESCE(MAX(current_load), 0)
+		FROM board_alight WHERE `+where+`
+		GROUP BY stop_sequence, stop_id ORDER BY stop_sequence`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ridership: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	stops := []StopLoad{}
+	for rows.Next() {
+		var stop StopLoad
+		if err := rows.Scan(&stop.StopSequence, &stop.StopID, &stop.Boardings, &stop.Alightings,
+			&stop.ServiceDays, &stop.AverageLoad, &stop.MaxLoad); err != nil {
+			return nil, fmt.Errorf("failed to scan ridership: %w", err)
+		}
+		stops = append(stops, stop)
+	}
+	return stops, rows.Err()
+}
@@ -0,0 +1,215 @@
+// Package ridership stores passenger counts imported from GTFS-ride files and summarizes them
+// by stop, route, trip and service date. Only board_alight.txt is read: its boardings,
+// alightings and load at each stop of each counted trip. The trip's route is looked up in the
+// static schedule when the file is imported and stored with the counts, so that later feed
+// updates do not move them. The other GTFS-ride files describe the counts or aggregate them
+// further and are ignored.
+package ridership
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/sqlitedb"
+)
+
+// ErrNoBoardAlight is returned when an imported zip has no board_alight.txt.
+var ErrNoBoardAlight = errors.New("no board_alight.txt in the archive")
+
+const ridershipSchema = `
+CREATE TABLE IF NOT EXISTS board_alight (
+    service_date TEXT NOT NULL,
+    trip_id TEXT NOT NULL,
+    stop_sequence INTEGER NOT NULL,
+    record_use INTEGER NOT NULL,
+    stop_id TEXT NOT NULL,
+    agency_id TEXT NOT NULL,
+    route_id TEXT NOT NULL,
+    boardings INTEGER,
+    alightings INTEGER,
+    current_load INTEGER,
+    PRIMARY KEY (service_date, trip_id, stop_sequence, record_use)
+);
+CREATE INDEX IF NOT EXISTS idx_board_alight_route ON board_alight (route_id, service_date);
+CREATE INDEX IF NOT EXISTS idx_board_alight_stop ON board_alight (stop_id, service_date);
+CREATE INDEX IF NOT EXISTS idx_board_alight_trip ON board_alight (trip_id, service_date);`
+
+// TripResolver returns the agency and route of a trip in the static schedule, and false for
+// trips it does not know.
+type TripResolver func(ctx context.Context, tripID string) (agencyID, routeID string, ok bool)
+
+// ImportResult counts the rows of an import.
+type ImportResult struct {
+	Records        int64 // Rows stored, replacing earlier counts for the same trip, stop and date
+	Invalid        int64 // Rows skipped for a missing service date, ID or stop sequence, or a malformed number
+	UnmatchedTrips int64 // Distinct trips not in the static schedule, stored without a route
+}
+
+// Store holds imported ridership counts.
+type Store struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	closeOnce sync.Once
+}
+
+// New opens (or creates) the ridership database at cfg.DataPath.
+func New(cfg appconf.RidershipConfig, logger *slog.Logger) (*Store, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	db, err := sqlitedb.Open(cfg.DataPath, ridershipSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ridership database: %w", err)
+	}
+
+	return &Store{db: db, logger: logger.With(slog.String("component", "ridership"))}, nil
+}
+
+// Import stores the counts in data, either a GTFS-ride zip or a board_alight.txt file on its
+// own, in one transaction. resolve finds the route of each trip.
+func (s *Store) Import(ctx context.Context, data []byte, resolve TripResolver) (ImportResult, error) {
+	var file io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("failed to read GTFS-ride zip: %w", err)
+		}
+		var entry *zip.File
+		for _, f := range archive.File {
+			if path.Base(f.Name) == "board_alight.txt" {
+				entry = f
+				break
+			}
+		}
+		if entry == nil {
+			return ImportResult{}, ErrNoBoardAlight
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("failed to open board_alight.txt: %w", err)
+		}
+		defer func() { _ = rc.Close() }()
+		file = rc
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to read board_alight.txt header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, required := range []string{"trip_id", "stop_id", "stop_sequence", "record_use"} {
+		if _, ok := columns[required]; !ok {
+			return ImportResult{}, fmt.Errorf("board_alight.txt has no %s column", required)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to begin ridership import: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO board_alight (service_date, trip_id, stop_sequence, record_use, stop_id,
+			agency_id, route_id, boardings, alightings, current_load)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to prepare ridership insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	type route struct{ agencyID, routeID string }
+	routes := make(map[string]*route)
+	var result ImportResult
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("failed to read board_alight.txt: %w", err)
+		}
+
+		tripID, stopID, serviceDate := field(row, "trip_id"), field(row, "stop_id"), field(row, "service_date")
+		sequence, sequenceErr := strconv.ParseInt(field(row, "stop_sequence"), 10, 64)
+		recordUse, recordUseErr := strconv.ParseInt(field(row, "record_use"), 10, 64)
+		boardings, boardingsErr := optionalCount(field(row, "boardings"))
+		alightings, alightingsErr := optionalCount(field(row, "alightings"))
+		load, loadErr := optionalCount(field(row, "current_load"))
+		_, dateErr := time.Parse("20060102", serviceDate)
+		if tripID == "" || stopID == "" ||
+			errors.Join(dateErr, sequenceErr, recordUseErr, boardingsErr, alightingsErr, loadErr) != nil {
+			result.Invalid++
+			continue
+		}
+
+		r, ok := routes[tripID]
+		if !ok {
+			r = &route{}
+			if agencyID, routeID, found := resolve(ctx, tripID); found {
+				r.agencyID, r.routeID = agencyID, routeID
+			} else {
+				result.UnmatchedTrips++
+			}
+			routes[tripID] = r
+		}
+
+		if _, err := stmt.ExecContext(ctx, serviceDate, tripID, sequence, recordUse, stopID,
+			r.agencyID, r.routeID, boardings, alightings, load); err != nil {
+			return ImportResult{}, fmt.Errorf("failed to store ridership: %w", err)
+		}
+		result.Records++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ImportResult{}, fmt.Errorf("failed to commit ridership import: %w", err)
+	}
+	return result, nil
+}
+
+// optionalCount parses a count that may be left empty.
+func optionalCount(value string) (sql.NullInt64, error) {
+	if value == "" {
+		return sql.NullInt64{}, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return sql.NullInt64{}, fmt.Errorf("invalid count %q", value)
+	}
+	return sql.NullInt64{Int64: n, Valid: true}, nil
+}
+
+// Shutdown closes the database. It is safe to call multiple times.
+func (s *Store) Shutdown() {
+	s.closeOnce.Do(func() {
+		if err := s.db.Close(); err != nil {
+			logging.LogError(s.logger, "failed to close ridership database", err)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package ridership
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+const boardAlight = "\ufefftrip_id,stop_id,stop_sequence,record_use,boardings,alightings,current_load,service_date\n" +
+	"T1,S1,1,0,5,0,5,20250601\n" +
+	"T1,S2,2,0,3,2,6,20250601\n" +
+	"T1,S3,3,0,0,6,0,20250601\n" +
+	"T1,S1,1,0,7,0,7,20250602\n" +
+	"T1,S2,2,1,,,4,20250602\n" +
+	"X9,S1,1,0,1,0,1,20250601\n" +
+	"T2,S1,1,0,lots,0,,20250601\n" +
+	"T2,S1,1,0,2,0,2,\n"
+
+func resolveTestTrips(_ context.Context, tripID string) (string, string, bool) {
+	if tripID == "T1" {
+		return "A", "R1", true
+	}
+	return "", "", false
+}
+
+func TestStore_ImportAndSummarize(t *testing.T) {
+	store, err := New(appconf.RidershipConfig{DataPath: ":memory:"}, nil)
+	require.NoError(t, err)
+	defer store.Shutdown()
+	ctx := context.Background()
+
+	result, err := store.Import(ctx, []byte(boardAlight), resolveTestTrips)
+	require.NoError(t, err)
+	assert.Equal(t, ImportResult{Records: 6, Invalid: 2, UnmatchedTrips: 1}, result)
+
+	buckets, err := store.Summary(ctx, Filter{RouteID: "R1"}, GroupNone)
+	require.NoError(t, err)
+	assert.Equal(t, []Bucket{{Boardings: 15, Alightings: 8, ServiceDays: 2, MaxLoad: 7}}, buckets)
+
+	buckets, err = store.Summary(ctx, Filter{StopID: "S1"}, GroupRoute)
+	require.NoError(t, err)
+	assert.Equal(t, []Bucket{
+		{Key: "", Boardings: 1, ServiceDays: 1, MaxLoad: 1},
+		{Key: "R1", Boardings: 12, ServiceDays: 2, MaxLoad: 7},
+	}, buckets)
+
+	buckets, err = store.Summary(ctx, Filter{TripID: "T1", StartDate: "20250602"}, GroupDay)
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	assert.Equal(t, "20250602", buckets[0].Key)
+
+	stops, err := store.LoadProfile(ctx, Filter{TripID: "T1"})
+	require.NoError(t, err)
+	require.Len(t, stops, 3)
+	assert.Equal(t, StopLoad{StopSequence: 1, StopID: "S1", Boardings: 12, ServiceDays: 2, AverageLoad: 6, MaxLoad: 7}, stops[0])
+	assert.Equal(t, int64(1), stops[1].ServiceDays, "partial counts are left out")
+
+	// Importing the same counts again replaces them
+	_, err = store.Import(ctx, []byte(boardAlight), resolveTestTrips)
+	require.NoError(t, err)
+	buckets, err = store.Summary(ctx, Filter{}, GroupNone)
+	require.NoError(t, err)
+	assert.Equal(t, int64(16), buckets[0].Boardings)
+}
+
+func TestStore_ImportZip(t *testing.T) {
+	store, err := New(appconf.RidershipConfig{DataPath: ":memory:"}, nil)
+	require.NoError(t, err)
+	defer store.Shutdown()
+	ctx := context.Background()
+
+	zipped := func(name, content string) []byte {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	result, err := store.Import(ctx, zipped("ride/board_alight.txt", boardAlight), resolveTestTrips)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), result.Records)
+
+	_, err = store.Import(ctx, zipped("ridership.txt", "total_boardings\n1\n"), resolveTestTrips)
+	assert.ErrorIs(t, err, ErrNoBoardAlight)
+
+	_, err = store.Import(ctx, []byte("trip_id,stop_id\nT1,S1\n"), resolveTestTrips)
+	assert.ErrorContains(t, err, "no stop_sequence column")
+}
@@ -0,0 +1,234 @@
+// Package siri defines the subset of the SIRI 2.0 (Service Interface for Real Time
+// Information, CEN/TS 15531) response structures that maglev serves. The same values encode
+// as SIRI XML and as SIRI-JSON, whose keys are the XML element names under a "Siri" root.
+package siri
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Version is the SIRI version of the responses.
+const Version = "2.0"
+
+// Namespace is the SIRI XML namespace.
+const Namespace = "http://www.siri.org.uk/siri"
+
+// Siri is the root element of every response.
+type Siri struct {
+	XMLName         xml.Name        `xml:"Siri" json:"-"`
+	Namespace       string          `xml:"xmlns,attr" json:"-"`
+	Version         string          `xml:"version,attr" json:"-"`
+	ServiceDelivery ServiceDelivery `xml:"ServiceDelivery" json:"ServiceDelivery"`
+}
+
+// ServiceDelivery carries the deliveries answering one request.
+type ServiceDelivery struct {
+	ResponseTimestamp         time.Time                   `xml:"ResponseTimestamp" json:"ResponseTimestamp"`
+	ProducerRef               string                      `xml:"ProducerRef,omitempty" json:"ProducerRef,omitempty"`
+	StopMonitoringDelivery    []StopMonitoringDelivery    `xml:"StopMonitoringDelivery,omitempty" json:"StopMonitoringDelivery,omitempty"`
+	VehicleMonitoringDelivery []VehicleMonitoringDelivery `xml:"VehicleMonitoringDelivery,omitempty" json:"VehicleMonitoringDelivery,omitempty"`
+	SituationExchangeDelivery []SituationExchangeDelivery `xml:"SituationExchangeDelivery,omitempty" json:"SituationExchangeDelivery,omitempty"`
+}
+
+// ErrorCondition explains why a delivery could not be produced.
+type ErrorCondition struct {
+	Description string `xml:"Description" json:"Description"`
+}
+
+// StopMonitoringDelivery lists the upcoming visits of vehicles to a stop.
+type StopMonitoringDelivery struct {
+	Version            string               `xml:"version,attr" json:"-"`
+	ResponseTimestamp  time.Time            `xml:"ResponseTimestamp" json:"ResponseTimestamp"`
+	Status             bool                 `xml:"Status" json:"Status"`
+	ErrorCondition     *ErrorCondition      `xml:"ErrorCondition,omitempty" json:"ErrorCondition,omitempty"`
+	MonitoredStopVisit []MonitoredStopVisit `xml:"MonitoredStopVisit" json:"MonitoredStopVisit"`
+}
+
+// MonitoredStopVisit is one vehicle journey calling at the monitored stop.
+type MonitoredStopVisit struct {
+	RecordedAtTime          time.Time               `xml:"RecordedAtTime" json:"RecordedAtTime"`
+	MonitoringRef           string                  `xml:"MonitoringRef" json:"MonitoringRef"`
+	MonitoredVehicleJourney MonitoredVehicleJourney `xml:"MonitoredVehicleJourney" json:"MonitoredVehicleJourney"`
+}
+
+// VehicleMonitoringDelivery lists the current activity of vehicles.
+type VehicleMonitoringDelivery struct {
+	Version           string            `xml:"version,attr" json:"-"`
+	ResponseTimestamp time.Time         `xml:"ResponseTimestamp" json:"ResponseTimestamp"`
+	Status            bool              `xml:"Status" json:"Status"`
+	ErrorCondition    *ErrorCondition   `xml:"ErrorCondition,omitempty" json:"ErrorCondition,omitempty"`
+	VehicleActivity   []VehicleActivity `xml:"VehicleActivity" json:"VehicleActivity"`
+}
+
+// VehicleActivity is the last reported state of one vehicle.
+type VehicleActivity struct {
+	RecordedAtTime          time.Time               `xml:"RecordedAtTime" json:"RecordedAtTime"`
+	ValidUntilTime          time.Time               `xml:"ValidUntilTime" json:"ValidUntilTime"`
+	MonitoredVehicleJourney MonitoredVehicleJourney `xml:"MonitoredVehicleJourney" json:"MonitoredVehicleJourney"`
+}
+
+// MonitoredVehicleJourney describes a vehicle journey and, when known, the vehicle serving it.
+type MonitoredVehicleJourney struct {
+	LineRef                 string                  `xml:"LineRef" json:"LineRef"`
+	DirectionRef            string                  `xml:"DirectionRef,omitempty" json:"DirectionRef,omitempty"`
+	FramedVehicleJourneyRef FramedVehicleJourneyRef `xml:"FramedVehicleJourneyRef" json:"FramedVehicleJourneyRef"`
+	PublishedLineName       string                  `xml:"PublishedLineName,omitempty" json:"PublishedLineName,omitempty"`
+	OperatorRef             string                  `xml:"OperatorRef" json:"OperatorRef"`
+	DestinationName         string                  `xml:"DestinationName,omitempty" json:"DestinationName,omitempty"`
+	Monitored               bool                    `xml:"Monitored" json:"Monitored"`
+	VehicleLocation         *Location               `xml:"VehicleLocation,omitempty" json:"VehicleLocation,omitempty"`
+	Bearing                 *float32                `xml:"Bearing,omitempty" json:"Bearing,omitempty"`
+	VehicleRef              string                  `xml:"VehicleRef,omitempty" json:"VehicleRef,omitempty"`
+	MonitoredCall           *MonitoredCall          `xml:"MonitoredCall,omitempty" json:"MonitoredCall,omitempty"`
+}
+
+// FramedVehicleJourneyRef identifies a trip on a service date.
+type FramedVehicleJourneyRef struct {
+	DataFrameRef           string `xml:"DataFrameRef" json:"DataFrameRef"` // Service date, YYYY-MM-DD
+	DatedVehicleJourneyRef string `xml:"DatedVehicleJourneyRef" json:"DatedVehicleJourneyRef"`
+}
+
+// Location is a WGS-84 position.
+type Location struct {
+	Longitude float64 `xml:"Longitude" json:"Longitude"`
+	Latitude  float64 `xml:"Latitude" json:"Latitude"`
+}
+
+// MonitoredCall is the call of a journey at the monitored stop. Expected times are present
+// only when realtime data predicts them.
+type MonitoredCall struct {
+	StopPointRef          string     `xml:"StopPointRef" json:"StopPointRef"`
+	StopPointName         string     `xml:"StopPointName,omitempty" json:"StopPointName,omitempty"`
+	Order                 int        `xml:"Order" json:"Order"`
+	AimedArrivalTime      time.Time  `xml:"AimedArrivalTime" json:"AimedArrivalTime"`
+	ExpectedArrivalTime   *time.Time `xml:"ExpectedArrivalTime,omitempty" json:"ExpectedArrivalTime,omitempty"`
+	AimedDepartureTime    time.Time  `xml:"AimedDepartureTime" json:"AimedDepartureTime"`
+	ExpectedDepartureTime *time.Time `xml:"ExpectedDepartureTime,omitempty" json:"ExpectedDepartureTime,omitempty"`
+}
+
+// SituationExchangeDelivery lists the situations, such as disruptions and planned works,
+// affecting the network.
+type SituationExchangeDelivery struct {
+	Version           string          `xml:"version,attr" json:"-"`
+	ResponseTimestamp time.Time       `xml:"ResponseTimestamp" json:"ResponseTimestamp"`
+	Status            bool            `xml:"Status" json:"Status"`
+	ErrorCondition    *ErrorCondition `xml:"ErrorCondition,omitempty" json:"ErrorCondition,omitempty"`
+	Situations        Situations      `xml:"Situations" json:"Situations"`
+}
+
+// Situations wraps the situation elements of a delivery.
+type Situations struct {
+	PtSituationElement []PtSituationElement `xml:"PtSituationElement" json:"PtSituationElement"`
+}
+
+// PtSituationElement is one public transport situation.
+type PtSituationElement struct {
+	CreationTime    time.Time        `xml:"CreationTime" json:"CreationTime"`
+	SituationNumber string           `xml:"SituationNumber" json:"SituationNumber"`
+	Progress        string           `xml:"Progress" json:"Progress"`
+	ValidityPeriod  []ValidityPeriod `xml:"ValidityPeriod" json:"ValidityPeriod"`
+	Severity        string           `xml:"Severity,omitempty" json:"Severity,omitempty"`
+	Summary         string           `xml:"Summary,omitempty" json:"Summary,omitempty"`
+	Description     string           `xml:"Description,omitempty" json:"Description,omitempty"`
+	InfoLinks       *InfoLinks       `xml:"InfoLinks,omitempty" json:"InfoLinks,omitempty"`
+	Affects         Affects          `xml:"Affects" json:"Affects"`
+}
+
+// ValidityPeriod is a period in which a situation applies. A missing EndTime means until
+// further notice.
+type ValidityPeriod struct {
+	StartTime time.Time  `xml:"StartTime" json:"StartTime"`
+	EndTime   *time.Time `xml:"EndTime,omitempty" json:"EndTime,omitempty"`
+}
+
+// InfoLinks lists web pages with more about a situation.
+type InfoLinks struct {
+	InfoLink []InfoLink `xml:"InfoLink" json:"InfoLink"`
+}
+
+// InfoLink is a web page with more about a situation.
+type InfoLink struct {
+	Uri string `xml:"Uri" json:"Uri"`
+}
+
+// Affects lists the parts of the network a situation applies to.
+type Affects struct {
+	Networks        *AffectedNetworks        `xml:"Networks,omitempty" json:"Networks,omitempty"`
+	StopPoints      *AffectedStopPoints      `xml:"StopPoints,omitempty" json:"StopPoints,omitempty"`
+	VehicleJourneys *AffectedVehicleJourneys `xml:"VehicleJourneys,omitempty" json:"VehicleJourneys,omitempty"`
+}
+
+// AffectedNetworks wraps the affected networks.
+type AffectedNetworks struct {
+	AffectedNetwork []AffectedNetwork `xml:"AffectedNetwork" json:"AffectedNetwork"`
+}
+
+// AffectedNetwork is the network of one operator. AllLines is set when the whole network is
+// affected; otherwise AffectedLine lists the affected lines.
+type AffectedNetwork struct {
+	AffectedOperator *AffectedOperator `xml:"AffectedOperator,omitempty" json:"AffectedOperator,omitempty"`
+	AllLines         *struct{}         `xml:"AllLines,omitempty" json:"AllLines,omitempty"`
+	AffectedLine     []AffectedLine    `xml:"AffectedLine,omitempty" json:"AffectedLine,omitempty"`
+}
+
+// AffectedOperator identifies an operator.
+type AffectedOperator struct {
+	OperatorRef string `xml:"OperatorRef" json:"OperatorRef"`
+}
+
+// AffectedLine is an affected line, limited to one direction when DirectionRef is set.
+type AffectedLine struct {
+	LineRef      string `xml:"LineRef" json:"LineRef"`
+	DirectionRef string `xml:"Direction>DirectionRef,omitempty" json:"DirectionRef,omitempty"`
+}
+
+// AffectedStopPoints wraps the affected stops.
+type AffectedStopPoints struct {
+	AffectedStopPoint []AffectedStopPoint `xml:"AffectedStopPoint" json:"AffectedStopPoint"`
+}
+
+// AffectedStopPoint is an affected stop.
+type AffectedStopPoint struct {
+	StopPointRef  string `xml:"StopPointRef" json:"StopPointRef"`
+	StopPointName string `xml:"StopPointName,omitempty" json:"StopPointName,omitempty"`
+}
+
+// AffectedVehicleJourneys wraps the affected vehicle journeys.
+type AffectedVehicleJourneys struct {
+	AffectedVehicleJourney []AffectedVehicleJourney `xml:"AffectedVehicleJourney" json:"AffectedVehicleJourney"`
+}
+
+// AffectedVehicleJourney is an affected trip.
+type AffectedVehicleJourney struct {
+	DatedVehicleJourneyRef string `xml:"DatedVehicleJourneyRef" json:"DatedVehicleJourneyRef"`
+	LineRef                string `xml:"LineRef,omitempty" json:"LineRef,omitempty"`
+}
+
+// New returns a response with an empty service delivery stamped with now.
+func New(now time.Time) *Siri {
+	return &Siri{
+		Namespace:       Namespace,
+		Version:         Version,
+		ServiceDelivery: ServiceDelivery{ResponseTimestamp: now},
+	}
+}
+
+// WriteXML writes s as a SIRI XML document.
+func (s *Siri) WriteXML(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(s)
+}
+
+// WriteJSON writes s as a SIRI-JSON document.
+func (s *Siri) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(struct {
+		Siri *Siri `json:"Siri"`
+	}{s})
+}
@@ -0,0 +1,64 @@
+package siri
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResponse() *Siri {
+	now := time.Date(2025, 6, 12, 8, 0, 0, 0, time.UTC)
+	expected := now.Add(3 * time.Minute)
+	response := New(now)
+	response.ServiceDelivery.StopMonitoringDelivery = []StopMonitoringDelivery{{
+		Version:           Version,
+		ResponseTimestamp: now,
+		Status:            true,
+		MonitoredStopVisit: []MonitoredStopVisit{{
+			RecordedAtTime: now,
+			MonitoringRef:  "1_100",
+			MonitoredVehicleJourney: MonitoredVehicleJourney{
+				LineRef:     "1_10",
+				OperatorRef: "1",
+				Monitored:   true,
+				MonitoredCall: &MonitoredCall{
+					StopPointRef:        "1_100",
+					Order:               4,
+					AimedArrivalTime:    now,
+					ExpectedArrivalTime: &expected,
+					AimedDepartureTime:  now,
+				},
+			},
+		}},
+	}}
+	return response
+}
+
+func TestWriteXML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testResponse().WriteXML(&buf))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, out, `<Siri xmlns="http://www.siri.org.uk/siri" version="2.0">`)
+	assert.Contains(t, out, `<StopMonitoringDelivery version="2.0">`)
+	assert.Contains(t, out, "<ExpectedArrivalTime>2025-06-12T08:03:00Z</ExpectedArrivalTime>")
+	assert.NotContains(t, out, "ExpectedDepartureTime", "unpredicted times are omitted")
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testResponse().WriteJSON(&buf))
+
+	var body map[string]map[string]map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &body))
+	delivery := body["Siri"]["ServiceDelivery"]
+	assert.Equal(t, "2025-06-12T08:00:00Z", delivery["ResponseTimestamp"])
+	assert.Len(t, delivery["StopMonitoringDelivery"], 1)
+	assert.NotContains(t, buf.String(), "xmlns")
+}
@@ -0,0 +1,171 @@
+// Package snapshot publishes the GTFS database to S3-compatible object storage, so read-only
+// replicas can download a pre-built dataset instead of importing the feed themselves. Each
+// publish uploads a compacted copy of the database under a timestamped key, then overwrites
+// latest.json with its metadata. Replicas read latest.json and fetch the database it names;
+// the metadata is written last, so it never points at a partial upload.
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/buildinfo"
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// MetadataKey is the name of the metadata object, under the configured prefix.
+const MetadataKey = "latest.json"
+
+// Metadata describes a published database snapshot.
+type Metadata struct {
+	Key      string    `json:"key"` // Object key of the database
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Built    time.Time `json:"built"`  // When the dataset was loaded
+	Source   string    `json:"source"` // Feed URL, without a query string that could hold credentials
+	Agencies int       `json:"agencies"`
+	Routes   int       `json:"routes"`
+	Stops    int       `json:"stops"`
+	Trips    int       `json:"trips"`
+	Version  string    `json:"version"` // Version of maglev that built the database
+}
+
+// Uploader publishes snapshots to one bucket.
+type Uploader struct {
+	cfg         appconf.SnapshotUploadConfig
+	signer      *v4.Signer
+	credentials aws.Credentials
+	client      *http.Client
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+// NewUploader returns an uploader for cfg. A nil client uses one with a 30 minute timeout,
+// enough for a large database over a slow link.
+func NewUploader(cfg appconf.SnapshotUploadConfig, client *http.Client, logger *slog.Logger) *Uploader {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Minute}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Uploader{
+		cfg: cfg,
+		signer: v4.NewSigner(func(o *v4.SignerOptions) {
+			// S3 signs the path as sent rather than escaping it again
+			o.DisableURIPathEscaping = true
+		}),
+		credentials: aws.Credentials{AccessKeyID: cfg.AccessKeyID, SecretAccessKey: cfg.SecretAccessKey},
+		client:      client,
+		logger:      logger.With(slog.String("component", "snapshot_upload")),
+		now:         time.Now,
+	}
+}
+
+// Publish uploads a snapshot of manager's current database and its metadata.
+func (u *Uploader) Publish(ctx context.Context, manager *gtfs.Manager, source string) (Metadata, error) {
+	dir, err := os.MkdirTemp("", "maglev-snapshot-")
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "gtfs.db")
+	if err := manager.WriteSnapshot(ctx, path); err != nil {
+		return Metadata{}, err
+	}
+	status := manager.Status()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer logging.SafeCloseWithLogging(file, u.logger, "snapshot_file")
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to hash snapshot: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return Metadata{}, err
+	}
+
+	meta := Metadata{
+		Key:      u.cfg.Prefix + "gtfs-" + status.StaticUpdated.UTC().Format("20060102T150405Z") + ".db",
+		Size:     size,
+		SHA256:   hex.EncodeToString(hash.Sum(nil)),
+		Built:    status.StaticUpdated.UTC(),
+		Source:   stripQuery(source),
+		Agencies: status.Agencies,
+		Routes:   status.Routes,
+		Stops:    status.Stops,
+		Trips:    status.Trips,
+		Version:  buildinfo.Get().Version,
+	}
+	if err := u.put(ctx, meta.Key, "application/vnd.sqlite3", file, size, meta.SHA256); err != nil {
+		return Metadata{}, err
+	}
+
+	body, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return Metadata{}, err
+	}
+	if err := u.put(ctx, u.cfg.Prefix+MetadataKey, "application/json", strings.NewReader(string(body)), int64(len(body)), hashHex(body)); err != nil {
+		return Metadata{}, err
+	}
+
+	u.logger.Info("published database snapshot", "key", meta.Key, "bytes", meta.Size)
+	return meta, nil
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func stripQuery(source string) string {
+	source, _, _ = strings.Cut(source, "?")
+	return source
+}
+
+// put uploads one object with a path-style URL, which every S3-compatible service accepts.
+func (u *Uploader) put(ctx context.Context, key, contentType string, body io.Reader, size int64, payloadHash string) error {
+	target := strings.TrimSuffix(u.cfg.Endpoint, "/") + "/" + u.cfg.Bucket + "/" + key
+	// The caller closes the body
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := u.signer.SignHTTP(ctx, u.credentials, req, payloadHash, "s3", u.cfg.Region, u.now()); err != nil {
+		return fmt.Errorf("failed to sign upload of %s: %w", key, err)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer logging.SafeCloseWithLogging(resp.Body, u.logger, "http_response_body")
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to upload %s: %s: %s", key, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// fakeBucket stores the objects PUT to it, checking that each upload is signed and that the
+// signed payload hash matches the body.
+type fakeBucket struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string][]byte
+	status  int
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(b.t, err)
+	assert.Equal(b.t, http.MethodPut, r.Method)
+	assert.True(b.t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+	assert.Contains(b.t, r.Header.Get("Authorization"), "/us-east-1/s3/aws4_request")
+	assert.Equal(b.t, hashHex(body), r.Header.Get("X-Amz-Content-Sha256"))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.status != 0 {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", b.status)
+		return
+	}
+	b.objects[r.URL.Path] = body
+}
+
+func TestPublish(t *testing.T) {
+	bucket := &fakeBucket{t: t, objects: map[string][]byte{}}
+	server := httptest.NewServer(bucket)
+	t.Cleanup(server.Close)
+
+	source := models.GetFixturePath(t, "raba.zip")
+	manager, err := gtfs.InitGTFSManager(gtfs.Config{
+		GtfsURL:      source,
+		GTFSDataPath: filepath.Join(t.TempDir(), "gtfs.db"),
+		Env:          appconf.Development,
+	})
+	require.NoError(t, err)
+	t.Cleanup(manager.Shutdown)
+
+	uploader := NewUploader(appconf.SnapshotUploadConfig{
+		Endpoint:        server.URL + "/",
+		Bucket:          "snapshots",
+		Prefix:          "raba/",
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	}, nil, nil)
+
+	meta, err := uploader.Publish(context.Background(), manager, source)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(meta.Key, "raba/gtfs-"), meta.Key)
+	assert.Equal(t, 1, meta.Agencies)
+	assert.Positive(t, meta.Stops)
+	assert.Equal(t, source, meta.Source)
+
+	db := bucket.objects["/snapshots/"+meta.Key]
+	require.NotEmpty(t, db)
+	assert.Equal(t, "SQLite format 3\x00", string(db[:16]))
+	assert.Equal(t, int64(len(db)), meta.Size)
+	assert.Equal(t, hashHex(db), meta.SHA256)
+
+	var published Metadata
+	require.NoError(t, json.Unmarshal(bucket.objects["/snapshots/raba/latest.json"], &published))
+	assert.Equal(t, meta, published)
+
+	bucket.status = http.StatusForbidden
+	_, err = uploader.Publish(context.Background(), manager, source)
+	assert.ErrorContains(t, err, "403 Forbidden: <Error><Code>AccessDenied</Code></Error>")
+}
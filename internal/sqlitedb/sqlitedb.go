@@ -0,0 +1,27 @@
+// Package sqlitedb opens the small SQLite databases maglev keeps beside the GTFS database, such
+// as the audit log, blocklist and arrival archive.
+package sqlitedb
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3" // CGo-based SQLite driver
+)
+
+// Open opens (or creates) the SQLite database at path, which may be ":memory:", and creates
+// schema in it. The database has a single connection, which serializes writes and keeps an
+// in-memory database alive.
+func Open(path, schema string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	return db, nil
+}
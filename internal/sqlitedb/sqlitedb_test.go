@@ -0,0 +1,42 @@
+package sqlitedb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(path, "CREATE TABLE IF NOT EXISTS things (name TEXT)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO things (name) VALUES ('a')")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = Open(path, "CREATE TABLE IF NOT EXISTS things (name TEXT)")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM things").Scan(&count))
+	assert.Equal(t, 1, count, "reopened with its rows")
+}
+
+func TestOpenInMemory(t *testing.T) {
+	db, err := Open(":memory:", "CREATE TABLE things (name TEXT)")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	// Later statements see the schema, because they share the one connection
+	for range 3 {
+		_, err := db.Exec("INSERT INTO things (name) VALUES ('a')")
+		require.NoError(t, err)
+	}
+}
+
+func TestOpenBadSchema(t *testing.T) {
+	_, err := Open(":memory:", "CREATE NONSENSE")
+	assert.ErrorContains(t, err, "failed to create schema")
+}
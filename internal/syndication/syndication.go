@@ -0,0 +1,181 @@
+// Package syndication writes Atom 1.0 (RFC 4287) and RSS 2.0 feeds, so lists such as the
+// current service alerts can be embedded in web pages or turned into email digests by
+// off-the-shelf feed readers. A Feed is encoded in either format.
+package syndication
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const (
+	// AtomMediaType is the Content-Type of an Atom feed.
+	AtomMediaType = "application/atom+xml; charset=utf-8"
+	// RSSMediaType is the Content-Type of an RSS feed.
+	RSSMediaType = "application/rss+xml; charset=utf-8"
+
+	atomNamespace = "http://www.w3.org/2005/Atom"
+)
+
+// Feed is a list of entries, newest first.
+type Feed struct {
+	ID      string // Permanent, globally unique IRI of the feed, such as a tag: URI
+	Title   string
+	Link    string // Web page the feed belongs to; may be empty
+	Author  string // Publisher name; Atom requires one
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry is one item of a feed.
+type Entry struct {
+	ID         string // Permanent, globally unique IRI of the entry
+	Title      string
+	Content    string // Plain text
+	Link       string // Web page with more; may be empty
+	Published  time.Time
+	Updated    time.Time
+	Categories []string
+}
+
+// WriteAtom encodes the feed as Atom.
+func (f *Feed) WriteAtom(w io.Writer) error {
+	feed := atomFeed{
+		Namespace: atomNamespace,
+		ID:        f.ID,
+		Title:     f.Title,
+		Updated:   atomTime(f.Updated),
+		Author:    atomPerson{Name: f.Author},
+		Entries:   make([]atomEntry, 0, len(f.Entries)),
+	}
+	if f.Link != "" {
+		feed.Links = []atomLink{{Rel: "alternate", Href: f.Link}}
+	}
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Published: atomTime(e.Published),
+			Updated:   atomTime(e.Updated),
+			Content:   atomText{Type: "text", Text: e.Content},
+		}
+		if e.Link != "" {
+			entry.Links = []atomLink{{Rel: "alternate", Href: e.Link}}
+		}
+		for _, category := range e.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return writeXML(w, feed)
+}
+
+// WriteRSS encodes the feed as RSS. The entry IDs become guids that are not permalinks.
+func (f *Feed) WriteRSS(w io.Writer) error {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Title,
+		LastBuildDate: rssTime(f.Updated),
+		Items:         make([]rssItem, 0, len(f.Entries)),
+	}
+	for _, e := range f.Entries {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Content,
+			GUID:        rssGUID{IsPermaLink: "false", Value: e.ID},
+			PubDate:     rssTime(e.Published),
+			Categories:  e.Categories,
+		})
+	}
+	return writeXML(w, rss{Version: "2.0", Channel: channel})
+}
+
+func writeXML(w io.Writer, document any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func rssTime(t time.Time) string {
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	Namespace string      `xml:"xmlns,attr"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Author    atomPerson  `xml:"author"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
@@ -0,0 +1,76 @@
+package syndication
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2025, 6, 12, 8, 0, 0, 0, time.UTC)
+	return &Feed{
+		ID:      "tag:maglev.onebusaway.org,2025:alerts/1",
+		Title:   "Metro service alerts",
+		Link:    "https://metro.example.com",
+		Author:  "Metro",
+		Updated: published.Add(time.Hour),
+		Entries: []Entry{{
+			ID:         "tag:maglev.onebusaway.org,2025:alert/a1",
+			Title:      "Detour on Route 10 & 12",
+			Content:    "Buses use <3rd Ave> instead.",
+			Link:       "https://metro.example.com/alerts/a1",
+			Published:  published,
+			Updated:    published,
+			Categories: []string{"DETOUR", "CONSTRUCTION"},
+		}},
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testFeed().WriteAtom(&buf))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, xml.Header))
+	assert.Contains(t, out, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, out, "<updated>2025-06-12T09:00:00Z</updated>")
+	assert.Contains(t, out, "<author>\n    <name>Metro</name>")
+	assert.Contains(t, out, `<link rel="alternate" href="https://metro.example.com/alerts/a1"></link>`)
+	assert.Contains(t, out, "<title>Detour on Route 10 &amp; 12</title>")
+	assert.Contains(t, out, `<content type="text">Buses use &lt;3rd Ave&gt; instead.</content>`)
+	assert.Contains(t, out, `<category term="DETOUR"></category>`)
+
+	var decoded atomFeed
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded.Entries, 1)
+	assert.Equal(t, "tag:maglev.onebusaway.org,2025:alert/a1", decoded.Entries[0].ID)
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testFeed().WriteRSS(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, `<rss version="2.0">`)
+	assert.Contains(t, out, "<lastBuildDate>Thu, 12 Jun 2025 09:00:00 +0000</lastBuildDate>")
+	assert.Contains(t, out, `<guid isPermaLink="false">tag:maglev.onebusaway.org,2025:alert/a1</guid>`)
+	assert.Contains(t, out, "<pubDate>Thu, 12 Jun 2025 08:00:00 +0000</pubDate>")
+	assert.Contains(t, out, "<category>CONSTRUCTION</category>")
+
+	var decoded rss
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded.Channel.Items, 1)
+	assert.Equal(t, "Buses use <3rd Ave> instead.", decoded.Channel.Items[0].Description)
+
+	// An entry without a link leaves the element out
+	feed := testFeed()
+	feed.Entries[0].Link = ""
+	buf.Reset()
+	require.NoError(t, feed.WriteRSS(&buf))
+	assert.Equal(t, 1, strings.Count(buf.String(), "<link>"), "only the channel link")
+}
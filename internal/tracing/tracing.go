@@ -0,0 +1,59 @@
+// Package tracing configures OpenTelemetry distributed tracing. When tracing is disabled the
+// global tracer provider stays a no-op, so instrumented code pays almost nothing for its spans.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// ShutdownFunc flushes buffered spans and stops the exporter.
+type ShutdownFunc func(context.Context) error
+
+// Setup installs a global tracer provider that exports spans over OTLP/HTTP.
+// If tracing is not configured, it returns a no-op shutdown function.
+func Setup(ctx context.Context, cfg appconf.TracingConfig) (ShutdownFunc, error) {
+	if !cfg.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.Endpoint)}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "maglev"
+	}
+	res := resource.NewSchemaless(attribute.String("service.name", serviceName))
+
+	sampleRatio := cfg.SampleRatio
+	if sampleRatio == 0 {
+		sampleRatio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Honour the caller's sampling decision so traces stay intact across the mesh
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
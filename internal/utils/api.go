@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,39 +14,129 @@ import (
 	"maglev.onebusaway.org/internal/models"
 )
 
+// Combined IDs join an agency ID and an entity ID with the first underscore, as the Java server
+// does. An agency ID that itself contains underscores would split in the wrong place, so in a
+// combined ID its underscores are escaped as "~_" and its tildes as "~~". Agency IDs with
+// neither, which is nearly all of them, form the same combined IDs as before.
+const combinedIDEscape = '~'
+
+// escapeAgencyID escapes the underscores and tildes of an agency ID for a combined ID.
+func escapeAgencyID(agencyID string) string {
+	if !strings.ContainsAny(agencyID, "_~") {
+		return agencyID
+	}
+	var b strings.Builder
+	for i := 0; i < len(agencyID); i++ {
+		if agencyID[i] == '_' || agencyID[i] == combinedIDEscape {
+			b.WriteByte(combinedIDEscape)
+		}
+		b.WriteByte(agencyID[i])
+	}
+	return b.String()
+}
+
+// AgencyIDSplitter splits combined IDs against the agency IDs of a feed. A combined ID whose
+// escaped parse gives an unknown agency, such as "metro_transit_1234" formed before agency IDs
+// were escaped, splits after the longest agency ID it starts with. A nil splitter knows no
+// agencies and splits every ID at its first unescaped underscore.
+type AgencyIDSplitter struct {
+	ids       map[string]bool
+	unescaped []string // IDs with underscores or tildes, longest first
+}
+
+// NewAgencyIDSplitter returns a splitter for a feed with the given agency IDs.
+func NewAgencyIDSplitter(agencyIDs []string) *AgencyIDSplitter {
+	s := &AgencyIDSplitter{ids: make(map[string]bool, len(agencyIDs))}
+	for _, id := range agencyIDs {
+		s.ids[id] = true
+		if strings.ContainsAny(id, "_~") {
+			s.unescaped = append(s.unescaped, id)
+		}
+	}
+	sort.Slice(s.unescaped, func(i, j int) bool { return len(s.unescaped[i]) > len(s.unescaped[j]) })
+	return s
+}
+
+// split splits a combined ID at its first unescaped underscore and unescapes the agency ID. If
+// that agency is not known, an unescaped agency ID the combined ID starts with is taken instead,
+// so combined IDs formed before escaping keep working.
+func (s *AgencyIDSplitter) split(combinedID string) (agencyID, codeID string, ok bool) {
+	agencyID, codeID, ok = splitEscapedCombinedID(combinedID)
+	if s == nil || (ok && s.ids[agencyID]) {
+		return agencyID, codeID, ok
+	}
+	for _, id := range s.unescaped {
+		if strings.HasPrefix(combinedID, id+"_") {
+			return id, combinedID[len(id)+1:], true
+		}
+	}
+	return agencyID, codeID, ok
+}
+
+// ExtractAgencyIDAndCodeID extracts both `agency_id` and `code_id` from a string in the format
+// `{agency_id}_{code_id}`.
+func (s *AgencyIDSplitter) ExtractAgencyIDAndCodeID(combinedID string) (string, string, error) {
+	agencyID, codeID, ok := s.split(combinedID)
+	if !ok {
+		return "", "", fmt.Errorf("invalid format: %s", combinedID)
+	}
+	return agencyID, codeID, nil
+}
+
+// splitEscapedCombinedID splits a combined ID at its first unescaped underscore and unescapes the
+// agency ID. A tilde followed by anything but an underscore or another tilde is kept as it is.
+func splitEscapedCombinedID(combinedID string) (agencyID, codeID string, ok bool) {
+	if !strings.ContainsRune(combinedID, combinedIDEscape) {
+		return strings.Cut(combinedID, "_")
+	}
+	var b strings.Builder
+	for i := 0; i < len(combinedID); i++ {
+		c := combinedID[i]
+		switch {
+		case c == combinedIDEscape && i+1 < len(combinedID) && (combinedID[i+1] == '_' || combinedID[i+1] == combinedIDEscape):
+			i++
+			b.WriteByte(combinedID[i])
+		case c == '_':
+			return b.String(), combinedID[i+1:], true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", false
+}
+
 // ExtractCodeID extracts the `code_id` from a string in the format `{agency_id}_{code_id}`.
 func ExtractCodeID(combinedID string) (string, error) {
-	parts := strings.SplitN(combinedID, "_", 2)
-	if len(parts) != 2 {
+	_, codeID, ok := splitEscapedCombinedID(combinedID)
+	if !ok {
 		return "", fmt.Errorf("invalid format: %s", combinedID)
 	}
-	return parts[1], nil
+	return codeID, nil
 }
 
 // ExtractAgencyID extracts the `agency_id` from a string in the format `{agency_id}_{code_id}`.
 func ExtractAgencyID(combinedID string) (string, error) {
-	parts := strings.SplitN(combinedID, "_", 2)
-	if len(parts) != 2 {
+	agencyID, _, ok := splitEscapedCombinedID(combinedID)
+	if !ok {
 		return "", fmt.Errorf("invalid format: %s", combinedID)
 	}
-	return parts[0], nil
+	return agencyID, nil
 }
 
 // ExtractAgencyIDAndCodeID Extract AgencyIDAndCodeID extracts both `agency_id` and `code_id` from a string in the format `{agency_id}_{code_id}`.
+// It knows no agency IDs; split IDs from requests with the GTFS manager's splitter, which also
+// understands IDs formed before agency IDs were escaped.
 func ExtractAgencyIDAndCodeID(combinedID string) (string, string, error) {
-	parts := strings.SplitN(combinedID, "_", 2)
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid format: %s", combinedID)
-	}
-	return parts[0], parts[1], nil
+	return (*AgencyIDSplitter)(nil).ExtractAgencyIDAndCodeID(combinedID)
 }
 
 // FormCombinedID forms a combined ID in the format `{agency_id}_{code_id}` using the given `agencyID` and `codeID`.
+// Underscores and tildes in the agency ID are escaped so that the ID splits back into the same parts.
 func FormCombinedID(agencyID, codeID string) string {
 	if codeID == "" || agencyID == "" {
 		return ""
 	}
-	return fmt.Sprintf("%s_%s", agencyID, codeID)
+	return escapeAgencyID(agencyID) + "_" + codeID
 }
 
 // MapWheelchairBoarding converts GTFS wheelchair boarding values to our API format
@@ -133,9 +224,9 @@ func LoadLocationWithUTCFallBack(timeZone string, agencyId string) *time.Locatio
 }
 
 // ParseMaxCount parses the maxCount query parameter with validation.
-// It accepts a default value and enforces a maximum of 250 (matching Java's MaxCountSupport).
-// Returns an error in fieldErrors if the value is <= 0 or > 250.
-func ParseMaxCount(queryParams url.Values, defaultCount int, fieldErrors map[string][]string) (int, map[string][]string) {
+// It accepts a default value and a maximum (models.MaxAllowedCount matches Java's MaxCountSupport).
+// Returns an error in fieldErrors if the value is <= 0 or > maxAllowed.
+func ParseMaxCount(queryParams url.Values, defaultCount, maxAllowed int, fieldErrors map[string][]string) (int, map[string][]string) {
 	if fieldErrors == nil {
 		fieldErrors = make(map[string][]string)
 	}
@@ -148,8 +239,8 @@ func ParseMaxCount(queryParams url.Values, defaultCount int, fieldErrors map[str
 			if maxCount <= 0 {
 				fieldErrors["maxCount"] = []string{"must be greater than zero"}
 				maxCount = defaultCount
-			} else if maxCount > models.MaxAllowedCount {
-				fieldErrors["maxCount"] = []string{"must not exceed 250"}
+			} else if maxCount > maxAllowed {
+				fieldErrors["maxCount"] = []string{fmt.Sprintf("must not exceed %d", maxAllowed)}
 				maxCount = defaultCount
 			}
 		} else {
@@ -161,9 +252,9 @@ func ParseMaxCount(queryParams url.Values, defaultCount int, fieldErrors map[str
 
 // ParsePaginationParams parses offset and limit from request parameters.
 // maxCount is the primary parameter for limit, falling back to limit.
-// If neither is present, limit is -1 (return all).
-// Default offset is 0.
-func ParsePaginationParams(r *http.Request) (offset int, limit int) {
+// If neither is present, limit is defaultLimit (-1 returns all).
+// A limit above maxLimit is lowered to it. Default offset is 0.
+func ParsePaginationParams(r *http.Request, defaultLimit, maxLimit int) (offset int, limit int) {
 	queryParams := r.URL.Query()
 
 	offset = 0
@@ -173,7 +264,7 @@ func ParsePaginationParams(r *http.Request) (offset int, limit int) {
 		}
 	}
 
-	limit = -1 // Default to no limit
+	limit = defaultLimit
 
 	// Check maxCount first (OBA convention)
 	if val := queryParams.Get("maxCount"); val != "" {
@@ -187,9 +278,8 @@ func ParsePaginationParams(r *http.Request) (offset int, limit int) {
 		}
 	}
 
-	// Cap limit at 1000 if it's set
-	if limit > 1000 {
-		limit = 1000
+	if limit > maxLimit {
+		limit = maxLimit
 	}
 
 	return offset, limit
@@ -160,6 +160,27 @@ func TestExtractAgencyIDAndCodeID(t *testing.T) {
 			expectedCode:   "",
 			expectError:    true,
 		},
+		{
+			name:           "Escaped agency ID",
+			combinedID:     "metro~_transit_123_a",
+			expectedAgency: "metro_transit",
+			expectedCode:   "123_a",
+			expectError:    false,
+		},
+		{
+			name:           "Escaped tilde and lone tilde",
+			combinedID:     "a~~b~c_d~_e",
+			expectedAgency: "a~b~c",
+			expectedCode:   "d~_e",
+			expectError:    false,
+		},
+		{
+			name:           "Only escaped underscores",
+			combinedID:     "agency~_code",
+			expectedAgency: "",
+			expectedCode:   "",
+			expectError:    true,
+		},
 		{
 			name:           "Empty parts",
 			combinedID:     "_",
@@ -200,7 +221,13 @@ func TestFormCombinedID(t *testing.T) {
 			name:     "IDs with underscores",
 			agencyID: "agency_123",
 			codeID:   "code_456",
-			expected: "agency_123_code_456",
+			expected: "agency~_123_code_456",
+		},
+		{
+			name:     "Agency ID with a tilde",
+			agencyID: "agency~1",
+			codeID:   "code~2",
+			expected: "agency~~1_code~2",
 		},
 		{
 			name:     "Empty agency ID",
@@ -230,6 +257,44 @@ func TestFormCombinedID(t *testing.T) {
 	}
 }
 
+func TestCombinedIDRoundTrip(t *testing.T) {
+	for _, agencyID := range []string{"25", "agency_123", "_", "a__b_", "~", "a~_b", "king-county.metro"} {
+		for _, codeID := range []string{"1", "code_456", "_", "~_x"} {
+			combinedID := FormCombinedID(agencyID, codeID)
+			gotAgency, gotCode, err := ExtractAgencyIDAndCodeID(combinedID)
+			require.NoError(t, err, combinedID)
+			assert.Equal(t, agencyID, gotAgency, combinedID)
+			assert.Equal(t, codeID, gotCode, combinedID)
+		}
+	}
+}
+
+func TestAgencyIDSplitterLegacyIDs(t *testing.T) {
+	splitter := NewAgencyIDSplitter([]string{"25", "metro_transit", "metro_transit_west", "a~b"})
+
+	for combinedID, want := range map[string][2]string{
+		"metro~_transit_1234":   {"metro_transit", "1234"},
+		"metro_transit_1234":    {"metro_transit", "1234"},
+		"metro_transit_west_12": {"metro_transit_west", "12"},
+		"metro_transit_west":    {"metro_transit", "west"},
+		"25_1_2":                {"25", "1_2"},
+		"a~b_7":                 {"a~b", "7"},
+		"other_9":               {"other", "9"},
+	} {
+		agencyID, codeID, err := splitter.ExtractAgencyIDAndCodeID(combinedID)
+		require.NoError(t, err, combinedID)
+		assert.Equal(t, want, [2]string{agencyID, codeID}, combinedID)
+	}
+
+	_, _, err := splitter.ExtractAgencyIDAndCodeID("nounderscore")
+	assert.Error(t, err)
+
+	// Without a feed's agencies, IDs split at the first unescaped underscore
+	agencyID, codeID, err := ExtractAgencyIDAndCodeID("metro_transit_1234")
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{"metro", "transit_1234"}, [2]string{agencyID, codeID})
+}
+
 func TestMapWheelchairBoarding(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -630,7 +695,7 @@ func TestParseMaxCount(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resultedMaxCount, fieldErrors := ParseMaxCount(tt.countQueryParams, tt.defaultCount, nil)
+			resultedMaxCount, fieldErrors := ParseMaxCount(tt.countQueryParams, tt.defaultCount, models.MaxAllowedCount, nil)
 			if tt.expectError {
 				assert.Contains(t, fieldErrors, tt.expectedErrorKey)
 
@@ -642,6 +707,16 @@ func TestParseMaxCount(t *testing.T) {
 	}
 }
 
+func TestParseMaxCount_CustomMaximum(t *testing.T) {
+	maxCount, fieldErrors := ParseMaxCount(url.Values{"maxCount": []string{"600"}}, 100, 500, nil)
+	assert.Equal(t, []string{"must not exceed 500"}, fieldErrors["maxCount"])
+	assert.Equal(t, 100, maxCount)
+
+	maxCount, fieldErrors = ParseMaxCount(url.Values{"maxCount": []string{"500"}}, 100, 500, nil)
+	assert.Empty(t, fieldErrors)
+	assert.Equal(t, 500, maxCount)
+}
+
 func TestParsePaginationParams(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -720,12 +795,22 @@ func TestParsePaginationParams(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req, _ := http.NewRequest("GET", "/test"+tt.urlParams, nil)
-			offset, limit := ParsePaginationParams(req)
+			offset, limit := ParsePaginationParams(req, -1, models.MaxPageSize)
 
 			assert.Equal(t, tt.expectedOffset, offset)
 			assert.Equal(t, tt.expectedLimit, limit)
 		})
 	}
+
+	t.Run("Configured default and maximum", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/test", nil)
+		_, limit := ParsePaginationParams(req, 25, 100)
+		assert.Equal(t, 25, limit)
+
+		req, _ = http.NewRequest("GET", "/test?maxCount=500", nil)
+		_, limit = ParsePaginationParams(req, 25, 100)
+		assert.Equal(t, 100, limit)
+	})
 }
 
 func TestPaginateSlice(t *testing.T) {
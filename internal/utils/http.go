@@ -2,11 +2,55 @@ package utils
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-// ExtractIDFromParams retrieves a parameter value from the request context and removes file extensions like ".json".
+// ExtractIDFromParams retrieves a parameter value from the request context and removes file extensions like ".json" and ".xml".
 func ExtractIDFromParams(r *http.Request) string {
 	id := r.PathValue("id")
+	if trimmed, ok := strings.CutSuffix(id, ".xml"); ok {
+		return trimmed
+	}
 	return strings.Split(id, ".json")[0]
 }
+
+// PreferredLanguages returns the language tags the client asked for, most preferred first: the
+// lang query parameter, then the Accept-Language header by quality. Tags are lower case;
+// wildcards and languages refused with q=0 are left out.
+func PreferredLanguages(r *http.Request) []string {
+	var langs []string
+	if lang := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("lang"))); lang != "" {
+		langs = append(langs, lang)
+	}
+
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			accepted = append(accepted, weighted{tag, quality})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].quality > accepted[j].quality })
+	for _, a := range accepted {
+		langs = append(langs, a.tag)
+	}
+	return langs
+}
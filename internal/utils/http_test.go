@@ -56,3 +56,27 @@ func TestExtractIDFromParams(t *testing.T) {
 		})
 	}
 }
+
+func TestPreferredLanguages(t *testing.T) {
+	testCases := []struct {
+		name           string
+		target         string
+		acceptLanguage string
+		want           []string
+	}{
+		{name: "None", target: "/", want: nil},
+		{name: "Header by quality", target: "/", acceptLanguage: "en;q=0.5, fr-CA, es;q=0.8", want: []string{"fr-ca", "es", "en"}},
+		{name: "Parameter first", target: "/?lang=ES", acceptLanguage: "fr", want: []string{"es", "fr"}},
+		{name: "Wildcards and refusals skipped", target: "/", acceptLanguage: "*, de;q=0, it;q=x, nl", want: []string{"nl"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			assert.Equal(t, tc.want, PreferredLanguages(req))
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "time"
+
+// ServiceDayStart returns the instant the stop times of a service date are counted from:
+// noon minus 12 hours, in the date's location, as the GTFS reference defines it. This is
+// midnight except on the days clocks change, when it is an hour before or after midnight, so
+// that stop times on the 23- and 25-hour days still land on the wall-clock times in the feed.
+func ServiceDayStart(serviceDate time.Time) time.Time {
+	y, m, d := serviceDate.Date()
+	return time.Date(y, m, d, 12, 0, 0, 0, serviceDate.Location()).Add(-12 * time.Hour)
+}
+
+// ServiceTime returns the instant of a stop time, given as its offset from the start of the
+// service date, like the arrival and departure times in stop_times.txt.
+func ServiceTime(serviceDate time.Time, offset time.Duration) time.Time {
+	return ServiceDayStart(serviceDate).Add(offset)
+}
+
+// SinceServiceDayStart returns t as a stop time on the service date of its own day, in its
+// location: the inverse of ServiceTime.
+func SinceServiceDayStart(t time.Time) time.Duration {
+	return t.Sub(ServiceDayStart(t))
+}
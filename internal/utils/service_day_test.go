@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		date      time.Time
+		dayStart  time.Time
+		dayLength time.Duration
+	}{
+		{
+			name:      "ordinary day",
+			date:      time.Date(2025, 6, 10, 0, 0, 0, 0, loc),
+			dayStart:  time.Date(2025, 6, 10, 0, 0, 0, 0, loc),
+			dayLength: 24 * time.Hour,
+		},
+		{
+			name:      "spring forward",
+			date:      time.Date(2025, 3, 9, 0, 0, 0, 0, loc),
+			dayStart:  time.Date(2025, 3, 8, 23, 0, 0, 0, loc),
+			dayLength: 23 * time.Hour,
+		},
+		{
+			name:      "fall back",
+			date:      time.Date(2025, 11, 2, 0, 0, 0, 0, loc),
+			dayStart:  time.Date(2025, 11, 2, 1, 0, 0, 0, time.FixedZone("PDT", -7*3600)),
+			dayLength: 25 * time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, tc.dayStart.Equal(ServiceDayStart(tc.date)), "day start %v", ServiceDayStart(tc.date))
+			y, m, d := tc.date.Date()
+			next := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+			assert.Equal(t, tc.dayLength, next.Sub(time.Date(y, m, d, 0, 0, 0, 0, loc)))
+
+			// Stop times keep their wall-clock time however long the day is
+			morning := ServiceTime(tc.date, 8*time.Hour).In(loc)
+			assert.Equal(t, 8, morning.Hour())
+			assert.Equal(t, d, morning.Day())
+			late := ServiceTime(tc.date, 25*time.Hour+30*time.Minute).In(loc)
+			assert.Equal(t, 1, late.Hour())
+			assert.Equal(t, 30, late.Minute())
+			assert.Equal(t, d+1, late.Day())
+
+			assert.Equal(t, 8*time.Hour, SinceServiceDayStart(morning))
+			// The middle of the service date is noon whatever the clocks did
+			assert.Equal(t, 12*time.Hour, SinceServiceDayStart(time.Date(y, m, d, 12, 0, 0, 0, loc)))
+		})
+	}
+}
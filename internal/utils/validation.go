@@ -2,6 +2,7 @@ package utils
 
 import (
 	"errors"
+	"math"
 	"regexp"
 	"strings"
 	"time"
@@ -9,8 +10,9 @@ import (
 
 // Compiled regular expressions for validation
 var (
-	// Allow alphanumeric, underscore, hyphen, dot - common in transit IDs
-	validIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+	// Allow alphanumeric, underscore, hyphen, dot - common in transit IDs - and the tilde that
+	// escapes underscores in agency IDs
+	validIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_.~-]+$`)
 
 	// Detect potentially dangerous characters - more focused on injection patterns
 	dangerousPattern = regexp.MustCompile(`[<>]|--|\/\*|\*\/|;.*--`)
@@ -55,9 +57,10 @@ func ValidateQuery(query string) error {
 	return nil
 }
 
-// ValidateLatitude validates latitude values
+// ValidateLatitude validates latitude values. Comparisons with NaN, which parses from "NaN", are
+// always false, so it is rejected explicitly here and in the other checks.
 func ValidateLatitude(lat float64) error {
-	if lat < -90.0 || lat > 90.0 {
+	if math.IsNaN(lat) || lat < -90.0 || lat > 90.0 {
 		return errors.New("latitude must be between -90 and 90")
 	}
 	return nil
@@ -65,7 +68,7 @@ func ValidateLatitude(lat float64) error {
 
 // ValidateLongitude validates longitude values
 func ValidateLongitude(lon float64) error {
-	if lon < -180.0 || lon > 180.0 {
+	if math.IsNaN(lon) || lon < -180.0 || lon > 180.0 {
 		return errors.New("longitude must be between -180 and 180")
 	}
 	return nil
@@ -73,7 +76,7 @@ func ValidateLongitude(lon float64) error {
 
 // ValidateRadius validates radius values for location searches
 func ValidateRadius(radius float64) error {
-	if radius < 0 {
+	if math.IsNaN(radius) || radius < 0 {
 		return errors.New("radius must be non-negative")
 	}
 
@@ -87,7 +90,7 @@ func ValidateRadius(radius float64) error {
 
 // ValidateSpan validates latitude/longitude span values
 func ValidateSpan(span float64) error {
-	if span < 0 {
+	if math.IsNaN(span) || span < 0 {
 		return errors.New("span must be non-negative")
 	}
 
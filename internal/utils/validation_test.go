@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -189,6 +190,18 @@ func TestValidateLatitude(t *testing.T) {
 			wantErr: true,
 			errMsg:  "latitude must be between -90 and 90",
 		},
+		{
+			name:    "latitude not a number",
+			lat:     math.NaN(),
+			wantErr: true,
+			errMsg:  "latitude must be between -90 and 90",
+		},
+		{
+			name:    "latitude infinite",
+			lat:     math.Inf(1),
+			wantErr: true,
+			errMsg:  "latitude must be between -90 and 90",
+		},
 	}
 
 	for _, tt := range tests {
@@ -303,6 +316,12 @@ func TestValidateRadius(t *testing.T) {
 			wantErr: true,
 			errMsg:  "radius too large (max 10000 meters)",
 		},
+		{
+			name:    "radius not a number",
+			radius:  math.NaN(),
+			wantErr: true,
+			errMsg:  "radius must be non-negative",
+		},
 	}
 
 	for _, tt := range tests {
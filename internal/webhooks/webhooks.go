@@ -0,0 +1,296 @@
+// Package webhooks posts new and changed service alerts to the endpoints configured as
+// alert-webhooks. After each refresh of the service-alerts feed, the alerts are normalized as
+// the event publisher does and compared with those each endpoint was last sent; the ones that
+// are new or differ are posted in one JSON payload. An endpoint that cannot be reached is
+// retried a few times, and what it missed is sent again with the next refresh.
+//
+// The first refresh after startup only records the alerts already in effect, so a restart does
+// not repeat them. Deliveries are signed with the endpoint's secret, when it has one.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/events"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+// Headers of a signed delivery. The signature is a hex-encoded HMAC-SHA256, keyed by the
+// endpoint's secret, over:
+//
+//	UNIX_TIMESTAMP \n BODY
+const (
+	TimestampHeader = "X-OBA-Timestamp"
+	SignatureHeader = "X-OBA-Signature"
+)
+
+const (
+	// maxDeliveryAttempts is how many times a payload is posted to an endpoint before it
+	// waits for the next refresh.
+	maxDeliveryAttempts = 3
+
+	// deliveryTimeout bounds one request to an endpoint.
+	deliveryTimeout = 10 * time.Second
+
+	// firstRetryDelay is the wait before the second attempt; it doubles for each one after.
+	firstRetryDelay = time.Second
+)
+
+// Change types of an AlertChange.
+const (
+	AlertCreated = "alert.created"
+	AlertUpdated = "alert.updated"
+)
+
+// Payload is the JSON body posted to an endpoint.
+type Payload struct {
+	Changes  []AlertChange `json:"changes"`
+	SentTime int64         `json:"sentTime"` // Unix milliseconds
+}
+
+// AlertChange is an alert that appeared in the feed, or that changed, since the endpoint was
+// last sent it. Type is AlertCreated or AlertUpdated.
+type AlertChange struct {
+	Type  string       `json:"type"`
+	Alert events.Alert `json:"alert"`
+}
+
+// endpoint is a configured webhook and the alerts it last accepted.
+type endpoint struct {
+	url    string
+	secret string
+	sent   map[string]uint64 // Alert ID -> hash of the alert last accepted
+}
+
+// Notifier delivers changed alerts to the configured endpoints.
+type Notifier struct {
+	endpoints  []*endpoint
+	client     *http.Client
+	logger     *slog.Logger
+	now        func() time.Time
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	pending []gtfs.Alert // Alerts of the latest refresh not yet delivered
+	queued  bool
+	seeded  bool // Whether the alerts in effect at startup have been recorded
+	wake    chan struct{}
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewNotifier returns a notifier for the configured webhooks. A nil client uses one with a
+// 10 second timeout.
+func NewNotifier(webhooks []appconf.AlertWebhookConfig, client *http.Client, logger *slog.Logger) *Notifier {
+	if client == nil {
+		client = &http.Client{Timeout: deliveryTimeout}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	n := &Notifier{
+		client:     client,
+		logger:     logger.With(slog.String("component", "alert_webhooks")),
+		now:        time.Now,
+		retryDelay: firstRetryDelay,
+		wake:       make(chan struct{}, 1),
+		stopChan:   make(chan struct{}),
+	}
+	for _, webhook := range webhooks {
+		n.endpoints = append(n.endpoints, &endpoint{url: webhook.URL, secret: webhook.Secret})
+	}
+	return n
+}
+
+// Start delivers ingested refreshes in the background until Shutdown.
+func (n *Notifier) Start() {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		for {
+			select {
+			case <-n.stopChan:
+				return
+			case <-n.wake:
+				n.mu.Lock()
+				alerts, queued := n.pending, n.queued
+				n.pending, n.queued = nil, false
+				n.mu.Unlock()
+				if queued {
+					n.Notify(alerts)
+				}
+			}
+		}
+	}()
+}
+
+// Ingest queues the alerts of a refresh for delivery and returns at once; it is the manager's
+// realtime update hook. Refreshes that did not load the service-alerts feed are ignored, and
+// alerts still waiting from before are replaced.
+func (n *Notifier) Ingest(update GTFS.RealtimeUpdate) {
+	if update.Alerts == nil {
+		return
+	}
+	n.mu.Lock()
+	n.pending, n.queued = update.Alerts, true
+	n.mu.Unlock()
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Shutdown stops delivering and waits for a delivery in progress.
+func (n *Notifier) Shutdown() {
+	n.stopOnce.Do(func() {
+		close(n.stopChan)
+	})
+	n.wg.Wait()
+}
+
+// Notify posts the alerts that are new or changed to each endpoint. The first call only
+// records them. Calls must not overlap; the background loop makes them one at a time.
+func (n *Notifier) Notify(alerts []gtfs.Alert) {
+	current := make(map[string]uint64, len(alerts))
+	normalized := make([]events.Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		event := events.NewAlert(alert)
+		// Hashed before the ingest time is set, which changes on every refresh
+		body, err := json.Marshal(event)
+		if err != nil {
+			n.logger.Error("failed to encode alert", "alert_id", event.ID, "error", err)
+			continue
+		}
+		h := fnv.New64a()
+		_, _ = h.Write(body)
+		current[event.ID] = h.Sum64()
+		normalized = append(normalized, event)
+	}
+
+	n.mu.Lock()
+	seeded := n.seeded
+	n.seeded = true
+	n.mu.Unlock()
+	if !seeded {
+		for _, e := range n.endpoints {
+			e.sent = current
+		}
+		return
+	}
+
+	now := n.now()
+	var wg sync.WaitGroup
+	for _, e := range n.endpoints {
+		payload := Payload{SentTime: now.UnixMilli()}
+		for _, event := range normalized {
+			last, ok := e.sent[event.ID]
+			if ok && last == current[event.ID] {
+				continue
+			}
+			change := AlertChange{Type: AlertCreated, Alert: event}
+			if ok {
+				change.Type = AlertUpdated
+			}
+			change.Alert.IngestedAt = now.UnixMilli()
+			payload.Changes = append(payload.Changes, change)
+		}
+		if len(payload.Changes) == 0 {
+			// Forget alerts that left the feed, so they count as new if they return
+			e.sent = current
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := n.deliver(e, payload); err != nil {
+				n.logger.Error("failed to deliver alert webhook", "url", e.url, "error", err)
+				return
+			}
+			e.sent = current
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver posts payload to e, retrying with a doubling delay while the endpoint cannot be
+// reached, answers 429 or fails with a server error.
+func (n *Notifier) deliver(e *endpoint, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delay := n.retryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(e, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == maxDeliveryAttempts {
+			return err
+		}
+		select {
+		case <-n.stopChan:
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying.
+func (n *Notifier) post(e *endpoint, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.secret != "" {
+		timestamp := strconv.FormatInt(n.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Signature(e.secret, timestamp, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("endpoint answered %s", resp.Status)
+}
+
+// Signature returns the signature of a delivery of body at timestamp, so receivers written in
+// Go can check the SignatureHeader.
+func Signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
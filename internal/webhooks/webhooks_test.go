@@ -0,0 +1,180 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+)
+
+// recordingEndpoint is a webhook receiver that answers with the statuses in order, then 204.
+type recordingEndpoint struct {
+	mu         sync.Mutex
+	statuses   []int
+	deliveries []*http.Request
+	bodies     [][]byte
+}
+
+func (r *recordingEndpoint) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// take returns and clears the payloads received.
+func (r *recordingEndpoint) take(t *testing.T) []Payload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payloads := make([]Payload, len(r.bodies))
+	for i, body := range r.bodies {
+		require.NoError(t, json.Unmarshal(body, &payloads[i]))
+	}
+	r.deliveries, r.bodies = nil, nil
+	return payloads
+}
+
+func testAlert(id, header string) gtfs.Alert {
+	return gtfs.Alert{
+		ID:               id,
+		Header:           []gtfs.AlertText{{Text: header, Language: "en"}},
+		InformedEntities: []gtfs.AlertInformedEntity{{RouteID: ptr("route-1")}},
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func newTestNotifier(webhooks ...appconf.AlertWebhookConfig) *Notifier {
+	n := NewNotifier(webhooks, nil, nil)
+	n.now = func() time.Time { return time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC) }
+	n.retryDelay = time.Millisecond
+	return n
+}
+
+func TestNotify_SendsOnlyNewAndChangedAlerts(t *testing.T) {
+	receiver := &recordingEndpoint{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	n := newTestNotifier(appconf.AlertWebhookConfig{URL: server.URL})
+
+	// The alerts in effect at startup are only recorded
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour")})
+	assert.Empty(t, receiver.take(t))
+
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour"), testAlert("a2", "Elevator out")})
+	payloads := receiver.take(t)
+	require.Len(t, payloads, 1)
+	require.Len(t, payloads[0].Changes, 1)
+	change := payloads[0].Changes[0]
+	assert.Equal(t, AlertCreated, change.Type)
+	assert.Equal(t, "a2", change.Alert.ID)
+	assert.Equal(t, "Elevator out", change.Alert.Header[0].Text)
+	assert.Equal(t, "route-1", change.Alert.InformedEntities[0].RouteID)
+	assert.Equal(t, time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC).UnixMilli(), change.Alert.IngestedAt)
+	assert.Equal(t, change.Alert.IngestedAt, payloads[0].SentTime)
+
+	// Unchanged alerts are not sent again
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour"), testAlert("a2", "Elevator out")})
+	assert.Empty(t, receiver.take(t))
+
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour ended"), testAlert("a2", "Elevator out")})
+	payloads = receiver.take(t)
+	require.Len(t, payloads, 1)
+	require.Len(t, payloads[0].Changes, 1)
+	assert.Equal(t, AlertUpdated, payloads[0].Changes[0].Type)
+	assert.Equal(t, "a1", payloads[0].Changes[0].Alert.ID)
+
+	// An alert that leaves the feed is new again when it returns
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour ended")})
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour ended"), testAlert("a2", "Elevator out")})
+	payloads = receiver.take(t)
+	require.Len(t, payloads, 1)
+	assert.Equal(t, AlertCreated, payloads[0].Changes[0].Type)
+}
+
+func TestNotify_RetriesAndResendsAfterFailure(t *testing.T) {
+	receiver := &recordingEndpoint{statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	n := newTestNotifier(appconf.AlertWebhookConfig{URL: server.URL})
+	n.Notify(nil)
+
+	// Two server errors, then delivered by the third attempt
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour")})
+	assert.Len(t, receiver.take(t), 3)
+
+	// A rejected delivery is not retried, but is sent again with the next refresh
+	receiver.statuses = []int{http.StatusBadRequest}
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour"), testAlert("a2", "Elevator out")})
+	assert.Len(t, receiver.take(t), 1)
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour"), testAlert("a2", "Elevator out")})
+	payloads := receiver.take(t)
+	require.Len(t, payloads, 1)
+	require.Len(t, payloads[0].Changes, 1)
+	assert.Equal(t, "a2", payloads[0].Changes[0].Alert.ID)
+}
+
+func TestNotify_SignsDeliveries(t *testing.T) {
+	signed, unsigned := &recordingEndpoint{}, &recordingEndpoint{}
+	signedServer, unsignedServer := httptest.NewServer(signed), httptest.NewServer(unsigned)
+	defer signedServer.Close()
+	defer unsignedServer.Close()
+	n := newTestNotifier(
+		appconf.AlertWebhookConfig{URL: signedServer.URL, Secret: "s3cret"},
+		appconf.AlertWebhookConfig{URL: unsignedServer.URL},
+	)
+	n.Notify(nil)
+	n.Notify([]gtfs.Alert{testAlert("a1", "Detour")})
+
+	require.Len(t, signed.deliveries, 1)
+	req := signed.deliveries[0]
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	timestamp := req.Header.Get(TimestampHeader)
+	assert.Equal(t, "1772438400", timestamp)
+	assert.Equal(t, Signature("s3cret", timestamp, signed.bodies[0]), req.Header.Get(SignatureHeader))
+	assert.NotEqual(t, Signature("other", timestamp, signed.bodies[0]), req.Header.Get(SignatureHeader))
+
+	require.Len(t, unsigned.deliveries, 1)
+	assert.Empty(t, unsigned.deliveries[0].Header.Get(SignatureHeader))
+}
+
+func TestIngest_DeliversInBackground(t *testing.T) {
+	receiver := &recordingEndpoint{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	n := newTestNotifier(appconf.AlertWebhookConfig{URL: server.URL})
+	n.Start()
+	defer n.Shutdown()
+
+	n.Ingest(GTFS.RealtimeUpdate{Alerts: []gtfs.Alert{}})
+	require.Eventually(t, func() bool {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		return n.seeded
+	}, time.Second, 5*time.Millisecond)
+
+	// Refreshes that did not load the alerts feed are ignored
+	n.Ingest(GTFS.RealtimeUpdate{Trips: []gtfs.Trip{}})
+	n.Ingest(GTFS.RealtimeUpdate{Alerts: []gtfs.Alert{testAlert("a1", "Detour")}})
+	var payloads []Payload
+	require.Eventually(t, func() bool {
+		payloads = append(payloads, receiver.take(t)...)
+		return len(payloads) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "a1", payloads[0].Changes[0].Alert.ID)
+}
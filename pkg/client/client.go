@@ -0,0 +1,279 @@
+// Package client is a Go client for the OneBusAway REST API served by maglev. It adds the API
+// key to every request, retries requests the server turned away for the moment, and decodes
+// responses into the models the server writes them from.
+//
+//	c, err := client.New("https://api.example.org", "my-key")
+//	...
+//	stop, refs, err := c.GetStop(ctx, "1_75403")
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for the retries of a new client.
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 500 * time.Millisecond
+	maxRetryWait      = 30 * time.Second
+)
+
+// Client calls the REST API of one server. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	apiKey     string
+	httpClient *http.Client
+	maxRetries int
+	retryWait  time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient makes the client send its requests with hc instead of http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetries sets how many times a request is retried after a network error, a 429 or a 5xx
+// response, and the wait before the first retry, which doubles with each retry. A Retry-After
+// header from the server takes precedence over the wait. A maxRetries of 0 disables retries.
+func WithRetries(maxRetries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryWait = wait
+	}
+}
+
+// New returns a client for the server at baseURL, such as "https://api.example.org", that
+// authenticates with apiKey.
+func New(baseURL, apiKey string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: the scheme must be http or https", baseURL)
+	}
+	c := &Client{
+		baseURL:    u,
+		apiKey:     apiKey,
+		httpClient: http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		retryWait:  DefaultRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Error is a response from the server other than a 200.
+type Error struct {
+	StatusCode int    // The HTTP status
+	Text       string // The server's explanation
+	RequestID  string // The ID to quote to the server's operators, when the server sent one
+	// FieldErrors lists what was wrong with each invalid parameter of a 400.
+	FieldErrors map[string][]string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("onebusaway: %d %s", e.StatusCode, e.Text)
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// IsNotFound reports whether err is a 404 from the server, for an ID it does not know.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// GetStop returns the stop with the ID, and the routes and agencies serving it.
+func (c *Client) GetStop(ctx context.Context, stopID string) (*Stop, *References, error) {
+	var data entryData[Stop]
+	if err := c.get(ctx, "/api/where/stop/"+url.PathEscape(stopID)+".json", nil, &data); err != nil {
+		return nil, nil, err
+	}
+	return &data.Entry, &data.References, nil
+}
+
+// ArrivalsOptions narrow ArrivalsForStop. The zero value asks for the server's defaults.
+type ArrivalsOptions struct {
+	MinutesBefore int       // How far back to include departed vehicles; 0 keeps the server default
+	MinutesAfter  int       // How far ahead to include arrivals; 0 keeps the server default
+	Time          time.Time // The moment to answer for; zero is now
+}
+
+// ArrivalsForStop returns the arrivals and departures at a stop, with the routes, trips and
+// situations they refer to.
+func (c *Client) ArrivalsForStop(ctx context.Context, stopID string, opts *ArrivalsOptions) (*StopArrivals, *References, error) {
+	query := url.Values{}
+	if opts != nil {
+		if opts.MinutesBefore > 0 {
+			query.Set("minutesBefore", strconv.Itoa(opts.MinutesBefore))
+		}
+		if opts.MinutesAfter > 0 {
+			query.Set("minutesAfter", strconv.Itoa(opts.MinutesAfter))
+		}
+		if !opts.Time.IsZero() {
+			query.Set("time", strconv.FormatInt(opts.Time.UnixMilli(), 10))
+		}
+	}
+	var data entryData[StopArrivals]
+	if err := c.get(ctx, "/api/where/arrivals-and-departures-for-stop/"+url.PathEscape(stopID)+".json", query, &data); err != nil {
+		return nil, nil, err
+	}
+	return &data.Entry, &data.References, nil
+}
+
+// SearchRoutes returns the routes whose names match input, best match first. A maxCount of 0
+// keeps the server's default.
+func (c *Client) SearchRoutes(ctx context.Context, input string, maxCount int) ([]Route, *References, error) {
+	query := url.Values{"input": {input}}
+	if maxCount > 0 {
+		query.Set("maxCount", strconv.Itoa(maxCount))
+	}
+	var data listData[Route]
+	if err := c.get(ctx, "/api/where/search/route.json", query, &data); err != nil {
+		return nil, nil, err
+	}
+	return data.List, &data.References, nil
+}
+
+// VehiclesForAgency returns the vehicles of an agency with a recent position.
+func (c *Client) VehiclesForAgency(ctx context.Context, agencyID string) ([]VehicleStatus, *References, error) {
+	var data listData[VehicleStatus]
+	if err := c.get(ctx, "/api/where/vehicles-for-agency/"+url.PathEscape(agencyID)+".json", nil, &data); err != nil {
+		return nil, nil, err
+	}
+	return data.List, &data.References, nil
+}
+
+// response is the envelope of every response.
+type response struct {
+	Code      int             `json:"code"`
+	Text      string          `json:"text"`
+	Data      json.RawMessage `json:"data"`
+	RequestID string          `json:"requestId"`
+}
+
+// get requests path with the query and the API key and decodes the data of the response into
+// data, retrying as configured.
+func (c *Client) get(ctx context.Context, path string, query url.Values, data interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("key", c.apiKey)
+	target := c.baseURL.String() + path + "?" + query.Encode()
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		resp, retryAfter, err := c.do(ctx, target)
+		if err == nil {
+			if resp.Code != http.StatusOK {
+				return responseError(resp)
+			}
+			return json.Unmarshal(resp.Data, data)
+		}
+		if attempt >= c.maxRetries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := wait
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		if delay > maxRetryWait {
+			delay = maxRetryWait
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// do sends one request. A 429 or 5xx response is returned as an *Error with the wait the
+// server asked for, if any.
+func (c *Client) do(ctx context.Context, target string) (*response, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The error text repeats the URL; keep the API key out of it
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, 0, &networkError{err: urlErr.Err}
+		}
+		return nil, 0, &networkError{err: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, &networkError{err: err}
+	}
+	var decoded response
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		decoded = response{Text: http.StatusText(resp.StatusCode)}
+		if resp.StatusCode == http.StatusOK {
+			return nil, 0, fmt.Errorf("onebusaway: invalid response: %w", err)
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		decoded.Code = resp.StatusCode
+	}
+	if decoded.Code == http.StatusTooManyRequests || decoded.Code >= 500 {
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return nil, time.Duration(retryAfter) * time.Second, responseError(&decoded)
+	}
+	return &decoded, 0, nil
+}
+
+func responseError(resp *response) error {
+	apiErr := &Error{StatusCode: resp.Code, Text: resp.Text, RequestID: resp.RequestID}
+	var data struct {
+		FieldErrors map[string][]string `json:"fieldErrors"`
+	}
+	if len(resp.Data) > 0 && json.Unmarshal(resp.Data, &data) == nil {
+		apiErr.FieldErrors = data.FieldErrors
+	}
+	return apiErr
+}
+
+// networkError is a request that got no response.
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string { return "onebusaway: " + e.err.Error() }
+func (e *networkError) Unwrap() error { return e.err }
+
+// retryable reports whether a failed request may succeed if sent again.
+func retryable(err error) bool {
+	var netErr *networkError
+	if errors.As(err, &netErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	var apiErr *Error
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500)
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/restapi"
+)
+
+// newTestServer serves the REST API over the RABA test feed.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gtfsConfig := gtfs.Config{
+		GtfsURL:      filepath.Join("..", "..", "testdata", "raba.zip"),
+		GTFSDataPath: filepath.Join(t.TempDir(), "gtfs.db"),
+	}
+	manager, err := gtfs.InitGTFSManager(gtfsConfig)
+	require.NoError(t, err)
+
+	api := restapi.NewRestAPI(&app.Application{
+		Config: appconf.Config{
+			Env:           appconf.Test,
+			ApiKeys:       []string{"test"},
+			ExemptApiKeys: []string{"test"},
+		},
+		GtfsConfig:  gtfsConfig,
+		GtfsManager: manager,
+		Clock:       clock.NewMockClock(time.Date(2025, 6, 12, 15, 0, 0, 0, time.UTC)),
+	})
+	api.Logger = slog.Default()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		server.Close()
+		api.Shutdown()
+		manager.Shutdown()
+	})
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := newTestServer(t)
+	c, err := New(server.URL+"/", "test")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	stop, refs, err := c.GetStop(ctx, "25_327")
+	require.NoError(t, err)
+	assert.Equal(t, "25_327", stop.ID)
+	assert.NotEmpty(t, stop.Name)
+	require.NotEmpty(t, stop.RouteIDs)
+	route, ok := refs.Route(stop.RouteIDs[0])
+	require.True(t, ok)
+	_, ok = refs.Agency(route.AgencyID)
+	assert.True(t, ok)
+
+	_, _, err = c.GetStop(ctx, "25_nonexistent")
+	assert.True(t, IsNotFound(err), "%v", err)
+
+	arrivals, _, err := c.ArrivalsForStop(ctx, "25_327", &ArrivalsOptions{MinutesAfter: 60})
+	require.NoError(t, err)
+	assert.Equal(t, "25_327", arrivals.StopID)
+
+	routes, _, err := c.SearchRoutes(ctx, "1", 5)
+	require.NoError(t, err)
+	assert.NotEmpty(t, routes)
+	assert.LessOrEqual(t, len(routes), 5)
+
+	_, _, err = c.SearchRoutes(ctx, "", 0)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Contains(t, apiErr.FieldErrors, "input")
+
+	vehicles, _, err := c.VehiclesForAgency(ctx, "25")
+	require.NoError(t, err)
+	assert.Empty(t, vehicles, "the test server has no realtime feed")
+
+	bad, err := New(server.URL, "wrong")
+	require.NoError(t, err)
+	_, _, err = bad.GetStop(ctx, "25_327")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestClientRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.URL.Query().Get("key"))
+		switch attempts.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"code":429,"text":"Rate limit exceeded. Please try again later."}`))
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+		default:
+			_, _ = w.Write([]byte(`{"code":200,"text":"OK","version":2,"data":{"entry":{"id":"1_75403","name":"Pine St"},"references":{}}}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL, "secret", WithRetries(3, time.Millisecond))
+	require.NoError(t, err)
+	stop, _, err := c.GetStop(context.Background(), "1_75403")
+	require.NoError(t, err)
+	assert.Equal(t, "Pine St", stop.Name)
+	assert.EqualValues(t, 3, attempts.Load())
+
+	// Out of retries, the last error is returned
+	attempts.Store(0)
+	c, err = New(server.URL, "secret", WithRetries(1, time.Millisecond))
+	require.NoError(t, err)
+	_, _, err = c.GetStop(context.Background(), "1_75403")
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.EqualValues(t, 2, attempts.Load())
+}
+
+func TestClientNetworkErrorHidesKey(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	c, err := New(server.URL, "secret", WithRetries(0, 0))
+	require.NoError(t, err)
+	_, _, err = c.GetStop(context.Background(), "1_75403")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+
+	_, err = New("ftp://example.com", "secret")
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package client
+
+import "maglev.onebusaway.org/internal/models"
+
+// The models are the server's own, so a response decodes into exactly the fields the server
+// writes.
+type (
+	Agency              = models.AgencyReference
+	ArrivalAndDeparture = models.ArrivalAndDeparture
+	Route               = models.Route
+	Situation           = models.Situation
+	Stop                = models.Stop
+	Trip                = models.Trip
+	VehicleStatus       = models.VehicleStatus
+)
+
+// References are the agencies, routes, stops, trips and situations a response refers to by ID.
+type References struct {
+	Agencies   []Agency    `json:"agencies"`
+	Routes     []Route     `json:"routes"`
+	Situations []Situation `json:"situations"`
+	Stops      []Stop      `json:"stops"`
+	Trips      []Trip      `json:"trips"`
+}
+
+// Agency returns the referenced agency with the ID, if there is one.
+func (r *References) Agency(id string) (Agency, bool) {
+	for _, agency := range r.Agencies {
+		if agency.ID == id {
+			return agency, true
+		}
+	}
+	return Agency{}, false
+}
+
+// Route returns the referenced route with the ID, if there is one.
+func (r *References) Route(id string) (Route, bool) {
+	for _, route := range r.Routes {
+		if route.ID == id {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// Stop returns the referenced stop with the ID, if there is one.
+func (r *References) Stop(id string) (Stop, bool) {
+	for _, stop := range r.Stops {
+		if stop.ID == id {
+			return stop, true
+		}
+	}
+	return Stop{}, false
+}
+
+// Trip returns the referenced trip with the ID, if there is one.
+func (r *References) Trip(id string) (Trip, bool) {
+	for _, trip := range r.Trips {
+		if trip.ID == id {
+			return trip, true
+		}
+	}
+	return Trip{}, false
+}
+
+// StopArrivals are the arrivals and departures at a stop.
+type StopArrivals struct {
+	StopID                string                `json:"stopId"`
+	ArrivalsAndDepartures []ArrivalAndDeparture `json:"arrivalsAndDepartures"`
+	NearbyStopIDs         []string              `json:"nearbyStopIds"`
+	SituationIDs          []string              `json:"situationIds"`
+}
+
+// entryData is the data of a response with a single entry.
+type entryData[T any] struct {
+	Entry      T          `json:"entry"`
+	References References `json:"references"`
+}
+
+// listData is the data of a response with a list.
+type listData[T any] struct {
+	List          []T        `json:"list"`
+	LimitExceeded bool       `json:"limitExceeded"`
+	OutOfRange    bool       `json:"outOfRange"`
+	References    References `json:"references"`
+}
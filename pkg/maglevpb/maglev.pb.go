@@ -0,0 +1,1481 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: maglev.proto
+
+package maglevpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Agency struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	Timezone      string                 `protobuf:"bytes,4,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	Lang          string                 `protobuf:"bytes,5,opt,name=lang,proto3" json:"lang,omitempty"`
+	Phone         string                 `protobuf:"bytes,6,opt,name=phone,proto3" json:"phone,omitempty"`
+	FareUrl       string                 `protobuf:"bytes,7,opt,name=fare_url,json=fareUrl,proto3" json:"fare_url,omitempty"`
+	Email         string                 `protobuf:"bytes,8,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Agency) Reset() {
+	*x = Agency{}
+	mi := &file_maglev_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Agency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Agency) ProtoMessage() {}
+
+func (x *Agency) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Agency.ProtoReflect.Descriptor instead.
+func (*Agency) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Agency) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Agency) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Agency) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Agency) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *Agency) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *Agency) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *Agency) GetFareUrl() string {
+	if x != nil {
+		return x.FareUrl
+	}
+	return ""
+}
+
+func (x *Agency) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type Route struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AgencyId    string                 `protobuf:"bytes,2,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	ShortName   string                 `protobuf:"bytes,3,opt,name=short_name,json=shortName,proto3" json:"short_name,omitempty"`
+	LongName    string                 `protobuf:"bytes,4,opt,name=long_name,json=longName,proto3" json:"long_name,omitempty"`
+	Description string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	// The GTFS route_type, e.g. 3 for bus.
+	Type          int32  `protobuf:"varint,6,opt,name=type,proto3" json:"type,omitempty"`
+	Url           string `protobuf:"bytes,7,opt,name=url,proto3" json:"url,omitempty"`
+	Color         string `protobuf:"bytes,8,opt,name=color,proto3" json:"color,omitempty"`
+	TextColor     string `protobuf:"bytes,9,opt,name=text_color,json=textColor,proto3" json:"text_color,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Route) Reset() {
+	*x = Route{}
+	mi := &file_maglev_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Route) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Route) ProtoMessage() {}
+
+func (x *Route) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Route.ProtoReflect.Descriptor instead.
+func (*Route) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Route) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Route) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+func (x *Route) GetShortName() string {
+	if x != nil {
+		return x.ShortName
+	}
+	return ""
+}
+
+func (x *Route) GetLongName() string {
+	if x != nil {
+		return x.LongName
+	}
+	return ""
+}
+
+func (x *Route) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Route) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Route) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Route) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *Route) GetTextColor() string {
+	if x != nil {
+		return x.TextColor
+	}
+	return ""
+}
+
+type Stop struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Code      string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Name      string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Lat       float64                `protobuf:"fixed64,4,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon       float64                `protobuf:"fixed64,5,opt,name=lon,proto3" json:"lon,omitempty"`
+	Direction string                 `protobuf:"bytes,6,opt,name=direction,proto3" json:"direction,omitempty"`
+	// The GTFS location_type: 0 for a stop, 1 for a station.
+	LocationType int32 `protobuf:"varint,7,opt,name=location_type,json=locationType,proto3" json:"location_type,omitempty"`
+	// ACCESSIBLE, NOT_ACCESSIBLE or UNKNOWN.
+	WheelchairBoarding string   `protobuf:"bytes,8,opt,name=wheelchair_boarding,json=wheelchairBoarding,proto3" json:"wheelchair_boarding,omitempty"`
+	RouteIds           []string `protobuf:"bytes,9,rep,name=route_ids,json=routeIds,proto3" json:"route_ids,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Stop) Reset() {
+	*x = Stop{}
+	mi := &file_maglev_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stop) ProtoMessage() {}
+
+func (x *Stop) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stop.ProtoReflect.Descriptor instead.
+func (*Stop) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Stop) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Stop) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Stop) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Stop) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *Stop) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+func (x *Stop) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *Stop) GetLocationType() int32 {
+	if x != nil {
+		return x.LocationType
+	}
+	return 0
+}
+
+func (x *Stop) GetWheelchairBoarding() string {
+	if x != nil {
+		return x.WheelchairBoarding
+	}
+	return ""
+}
+
+func (x *Stop) GetRouteIds() []string {
+	if x != nil {
+		return x.RouteIds
+	}
+	return nil
+}
+
+type Arrival struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RouteId        string                 `protobuf:"bytes,1,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`
+	RouteShortName string                 `protobuf:"bytes,2,opt,name=route_short_name,json=routeShortName,proto3" json:"route_short_name,omitempty"`
+	TripId         string                 `protobuf:"bytes,3,opt,name=trip_id,json=tripId,proto3" json:"trip_id,omitempty"`
+	TripHeadsign   string                 `protobuf:"bytes,4,opt,name=trip_headsign,json=tripHeadsign,proto3" json:"trip_headsign,omitempty"`
+	// Midnight of the service day, in the agency's time zone.
+	ServiceDate            int64 `protobuf:"varint,5,opt,name=service_date,json=serviceDate,proto3" json:"service_date,omitempty"`
+	StopSequence           int32 `protobuf:"varint,6,opt,name=stop_sequence,json=stopSequence,proto3" json:"stop_sequence,omitempty"`
+	ScheduledArrivalTime   int64 `protobuf:"varint,7,opt,name=scheduled_arrival_time,json=scheduledArrivalTime,proto3" json:"scheduled_arrival_time,omitempty"`
+	ScheduledDepartureTime int64 `protobuf:"varint,8,opt,name=scheduled_departure_time,json=scheduledDepartureTime,proto3" json:"scheduled_departure_time,omitempty"`
+	// Whether the predicted times come from realtime data; they are the scheduled times otherwise.
+	Predicted              bool  `protobuf:"varint,9,opt,name=predicted,proto3" json:"predicted,omitempty"`
+	PredictedArrivalTime   int64 `protobuf:"varint,10,opt,name=predicted_arrival_time,json=predictedArrivalTime,proto3" json:"predicted_arrival_time,omitempty"`
+	PredictedDepartureTime int64 `protobuf:"varint,11,opt,name=predicted_departure_time,json=predictedDepartureTime,proto3" json:"predicted_departure_time,omitempty"`
+	// The ID of the vehicle serving the trip, as in Vehicle.id, when one reports it.
+	VehicleId     string `protobuf:"bytes,12,opt,name=vehicle_id,json=vehicleId,proto3" json:"vehicle_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Arrival) Reset() {
+	*x = Arrival{}
+	mi := &file_maglev_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Arrival) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Arrival) ProtoMessage() {}
+
+func (x *Arrival) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Arrival.ProtoReflect.Descriptor instead.
+func (*Arrival) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Arrival) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *Arrival) GetRouteShortName() string {
+	if x != nil {
+		return x.RouteShortName
+	}
+	return ""
+}
+
+func (x *Arrival) GetTripId() string {
+	if x != nil {
+		return x.TripId
+	}
+	return ""
+}
+
+func (x *Arrival) GetTripHeadsign() string {
+	if x != nil {
+		return x.TripHeadsign
+	}
+	return ""
+}
+
+func (x *Arrival) GetServiceDate() int64 {
+	if x != nil {
+		return x.ServiceDate
+	}
+	return 0
+}
+
+func (x *Arrival) GetStopSequence() int32 {
+	if x != nil {
+		return x.StopSequence
+	}
+	return 0
+}
+
+func (x *Arrival) GetScheduledArrivalTime() int64 {
+	if x != nil {
+		return x.ScheduledArrivalTime
+	}
+	return 0
+}
+
+func (x *Arrival) GetScheduledDepartureTime() int64 {
+	if x != nil {
+		return x.ScheduledDepartureTime
+	}
+	return 0
+}
+
+func (x *Arrival) GetPredicted() bool {
+	if x != nil {
+		return x.Predicted
+	}
+	return false
+}
+
+func (x *Arrival) GetPredictedArrivalTime() int64 {
+	if x != nil {
+		return x.PredictedArrivalTime
+	}
+	return 0
+}
+
+func (x *Arrival) GetPredictedDepartureTime() int64 {
+	if x != nil {
+		return x.PredictedDepartureTime
+	}
+	return 0
+}
+
+func (x *Arrival) GetVehicleId() string {
+	if x != nil {
+		return x.VehicleId
+	}
+	return ""
+}
+
+type Position struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Lat   float32                `protobuf:"fixed32,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon   float32                `protobuf:"fixed32,2,opt,name=lon,proto3" json:"lon,omitempty"`
+	// Degrees clockwise from true north.
+	Bearing float32 `protobuf:"fixed32,3,opt,name=bearing,proto3" json:"bearing,omitempty"`
+	// Meters per second.
+	Speed         float32 `protobuf:"fixed32,4,opt,name=speed,proto3" json:"speed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Position) Reset() {
+	*x = Position{}
+	mi := &file_maglev_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Position) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Position) ProtoMessage() {}
+
+func (x *Position) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Position.ProtoReflect.Descriptor instead.
+func (*Position) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Position) GetLat() float32 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *Position) GetLon() float32 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+func (x *Position) GetBearing() float32 {
+	if x != nil {
+		return x.Bearing
+	}
+	return 0
+}
+
+func (x *Position) GetSpeed() float32 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+type Vehicle struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Label   string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	TripId  string                 `protobuf:"bytes,3,opt,name=trip_id,json=tripId,proto3" json:"trip_id,omitempty"`
+	RouteId string                 `protobuf:"bytes,4,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`
+	// Unset when the vehicle reported no position.
+	Position *Position `protobuf:"bytes,5,opt,name=position,proto3" json:"position,omitempty"`
+	// When the position was measured.
+	Timestamp     int64 `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Vehicle) Reset() {
+	*x = Vehicle{}
+	mi := &file_maglev_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Vehicle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Vehicle) ProtoMessage() {}
+
+func (x *Vehicle) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Vehicle.ProtoReflect.Descriptor instead.
+func (*Vehicle) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Vehicle) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Vehicle) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Vehicle) GetTripId() string {
+	if x != nil {
+		return x.TripId
+	}
+	return ""
+}
+
+func (x *Vehicle) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *Vehicle) GetPosition() *Position {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *Vehicle) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ListAgenciesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAgenciesRequest) Reset() {
+	*x = ListAgenciesRequest{}
+	mi := &file_maglev_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAgenciesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAgenciesRequest) ProtoMessage() {}
+
+func (x *ListAgenciesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAgenciesRequest.ProtoReflect.Descriptor instead.
+func (*ListAgenciesRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{6}
+}
+
+type ListAgenciesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Agencies      []*Agency              `protobuf:"bytes,1,rep,name=agencies,proto3" json:"agencies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAgenciesResponse) Reset() {
+	*x = ListAgenciesResponse{}
+	mi := &file_maglev_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAgenciesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAgenciesResponse) ProtoMessage() {}
+
+func (x *ListAgenciesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAgenciesResponse.ProtoReflect.Descriptor instead.
+func (*ListAgenciesResponse) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListAgenciesResponse) GetAgencies() []*Agency {
+	if x != nil {
+		return x.Agencies
+	}
+	return nil
+}
+
+type GetAgencyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAgencyRequest) Reset() {
+	*x = GetAgencyRequest{}
+	mi := &file_maglev_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAgencyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAgencyRequest) ProtoMessage() {}
+
+func (x *GetAgencyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAgencyRequest.ProtoReflect.Descriptor instead.
+func (*GetAgencyRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetAgencyRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListRoutesForAgencyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoutesForAgencyRequest) Reset() {
+	*x = ListRoutesForAgencyRequest{}
+	mi := &file_maglev_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoutesForAgencyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoutesForAgencyRequest) ProtoMessage() {}
+
+func (x *ListRoutesForAgencyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoutesForAgencyRequest.ProtoReflect.Descriptor instead.
+func (*ListRoutesForAgencyRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListRoutesForAgencyRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+type ListRoutesForAgencyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Routes        []*Route               `protobuf:"bytes,1,rep,name=routes,proto3" json:"routes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoutesForAgencyResponse) Reset() {
+	*x = ListRoutesForAgencyResponse{}
+	mi := &file_maglev_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoutesForAgencyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoutesForAgencyResponse) ProtoMessage() {}
+
+func (x *ListRoutesForAgencyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoutesForAgencyResponse.ProtoReflect.Descriptor instead.
+func (*ListRoutesForAgencyResponse) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListRoutesForAgencyResponse) GetRoutes() []*Route {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+type GetRouteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRouteRequest) Reset() {
+	*x = GetRouteRequest{}
+	mi := &file_maglev_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRouteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRouteRequest) ProtoMessage() {}
+
+func (x *GetRouteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRouteRequest.ProtoReflect.Descriptor instead.
+func (*GetRouteRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetRouteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetStopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStopRequest) Reset() {
+	*x = GetStopRequest{}
+	mi := &file_maglev_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStopRequest) ProtoMessage() {}
+
+func (x *GetStopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStopRequest.ProtoReflect.Descriptor instead.
+func (*GetStopRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetStopRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListStopsForLocationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Lat   float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon   float64                `protobuf:"fixed64,2,opt,name=lon,proto3" json:"lon,omitempty"`
+	// Meters around the point to search; 0 uses the REST API's default.
+	Radius float64 `protobuf:"fixed64,3,opt,name=radius,proto3" json:"radius,omitempty"`
+	// 0 uses the REST API's default.
+	MaxCount      int32 `protobuf:"varint,4,opt,name=max_count,json=maxCount,proto3" json:"max_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStopsForLocationRequest) Reset() {
+	*x = ListStopsForLocationRequest{}
+	mi := &file_maglev_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStopsForLocationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStopsForLocationRequest) ProtoMessage() {}
+
+func (x *ListStopsForLocationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStopsForLocationRequest.ProtoReflect.Descriptor instead.
+func (*ListStopsForLocationRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListStopsForLocationRequest) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *ListStopsForLocationRequest) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+func (x *ListStopsForLocationRequest) GetRadius() float64 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+func (x *ListStopsForLocationRequest) GetMaxCount() int32 {
+	if x != nil {
+		return x.MaxCount
+	}
+	return 0
+}
+
+type ListStopsForLocationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stops         []*Stop                `protobuf:"bytes,1,rep,name=stops,proto3" json:"stops,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStopsForLocationResponse) Reset() {
+	*x = ListStopsForLocationResponse{}
+	mi := &file_maglev_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStopsForLocationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStopsForLocationResponse) ProtoMessage() {}
+
+func (x *ListStopsForLocationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStopsForLocationResponse.ProtoReflect.Descriptor instead.
+func (*ListStopsForLocationResponse) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListStopsForLocationResponse) GetStops() []*Stop {
+	if x != nil {
+		return x.Stops
+	}
+	return nil
+}
+
+type ListArrivalsForStopRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	StopId string                 `protobuf:"bytes,1,opt,name=stop_id,json=stopId,proto3" json:"stop_id,omitempty"`
+	// How far ahead to look; 0 uses the REST API's default of 35 minutes.
+	MinutesAfter  int32 `protobuf:"varint,2,opt,name=minutes_after,json=minutesAfter,proto3" json:"minutes_after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArrivalsForStopRequest) Reset() {
+	*x = ListArrivalsForStopRequest{}
+	mi := &file_maglev_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArrivalsForStopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArrivalsForStopRequest) ProtoMessage() {}
+
+func (x *ListArrivalsForStopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArrivalsForStopRequest.ProtoReflect.Descriptor instead.
+func (*ListArrivalsForStopRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListArrivalsForStopRequest) GetStopId() string {
+	if x != nil {
+		return x.StopId
+	}
+	return ""
+}
+
+func (x *ListArrivalsForStopRequest) GetMinutesAfter() int32 {
+	if x != nil {
+		return x.MinutesAfter
+	}
+	return 0
+}
+
+type ListArrivalsForStopResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Arrivals      []*Arrival             `protobuf:"bytes,1,rep,name=arrivals,proto3" json:"arrivals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArrivalsForStopResponse) Reset() {
+	*x = ListArrivalsForStopResponse{}
+	mi := &file_maglev_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArrivalsForStopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArrivalsForStopResponse) ProtoMessage() {}
+
+func (x *ListArrivalsForStopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArrivalsForStopResponse.ProtoReflect.Descriptor instead.
+func (*ListArrivalsForStopResponse) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListArrivalsForStopResponse) GetArrivals() []*Arrival {
+	if x != nil {
+		return x.Arrivals
+	}
+	return nil
+}
+
+type ListVehiclesForAgencyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVehiclesForAgencyRequest) Reset() {
+	*x = ListVehiclesForAgencyRequest{}
+	mi := &file_maglev_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVehiclesForAgencyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVehiclesForAgencyRequest) ProtoMessage() {}
+
+func (x *ListVehiclesForAgencyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVehiclesForAgencyRequest.ProtoReflect.Descriptor instead.
+func (*ListVehiclesForAgencyRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListVehiclesForAgencyRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+type ListVehiclesForAgencyResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Vehicles []*Vehicle             `protobuf:"bytes,1,rep,name=vehicles,proto3" json:"vehicles,omitempty"`
+	// When the realtime feeds were last refreshed.
+	Updated       int64 `protobuf:"varint,2,opt,name=updated,proto3" json:"updated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVehiclesForAgencyResponse) Reset() {
+	*x = ListVehiclesForAgencyResponse{}
+	mi := &file_maglev_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVehiclesForAgencyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVehiclesForAgencyResponse) ProtoMessage() {}
+
+func (x *ListVehiclesForAgencyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVehiclesForAgencyResponse.ProtoReflect.Descriptor instead.
+func (*ListVehiclesForAgencyResponse) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListVehiclesForAgencyResponse) GetVehicles() []*Vehicle {
+	if x != nil {
+		return x.Vehicles
+	}
+	return nil
+}
+
+func (x *ListVehiclesForAgencyResponse) GetUpdated() int64 {
+	if x != nil {
+		return x.Updated
+	}
+	return 0
+}
+
+type StreamVehiclePositionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamVehiclePositionsRequest) Reset() {
+	*x = StreamVehiclePositionsRequest{}
+	mi := &file_maglev_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamVehiclePositionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamVehiclePositionsRequest) ProtoMessage() {}
+
+func (x *StreamVehiclePositionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maglev_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamVehiclePositionsRequest.ProtoReflect.Descriptor instead.
+func (*StreamVehiclePositionsRequest) Descriptor() ([]byte, []int) {
+	return file_maglev_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StreamVehiclePositionsRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+var File_maglev_proto protoreflect.FileDescriptor
+
+const file_maglev_proto_rawDesc = "" +
+	"\n" +
+	"\fmaglev.proto\x12\tmaglev.v1\"\xb5\x01\n" +
+	"\x06Agency\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x1a\n" +
+	"\btimezone\x18\x04 \x01(\tR\btimezone\x12\x12\n" +
+	"\x04lang\x18\x05 \x01(\tR\x04lang\x12\x14\n" +
+	"\x05phone\x18\x06 \x01(\tR\x05phone\x12\x19\n" +
+	"\bfare_url\x18\a \x01(\tR\afareUrl\x12\x14\n" +
+	"\x05email\x18\b \x01(\tR\x05email\"\xed\x01\n" +
+	"\x05Route\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tagency_id\x18\x02 \x01(\tR\bagencyId\x12\x1d\n" +
+	"\n" +
+	"short_name\x18\x03 \x01(\tR\tshortName\x12\x1b\n" +
+	"\tlong_name\x18\x04 \x01(\tR\blongName\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04type\x18\x06 \x01(\x05R\x04type\x12\x10\n" +
+	"\x03url\x18\a \x01(\tR\x03url\x12\x14\n" +
+	"\x05color\x18\b \x01(\tR\x05color\x12\x1d\n" +
+	"\n" +
+	"text_color\x18\t \x01(\tR\ttextColor\"\xf3\x01\n" +
+	"\x04Stop\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x10\n" +
+	"\x03lat\x18\x04 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x05 \x01(\x01R\x03lon\x12\x1c\n" +
+	"\tdirection\x18\x06 \x01(\tR\tdirection\x12#\n" +
+	"\rlocation_type\x18\a \x01(\x05R\flocationType\x12/\n" +
+	"\x13wheelchair_boarding\x18\b \x01(\tR\x12wheelchairBoarding\x12\x1b\n" +
+	"\troute_ids\x18\t \x03(\tR\brouteIds\"\xf1\x03\n" +
+	"\aArrival\x12\x19\n" +
+	"\broute_id\x18\x01 \x01(\tR\arouteId\x12(\n" +
+	"\x10route_short_name\x18\x02 \x01(\tR\x0erouteShortName\x12\x17\n" +
+	"\atrip_id\x18\x03 \x01(\tR\x06tripId\x12#\n" +
+	"\rtrip_headsign\x18\x04 \x01(\tR\ftripHeadsign\x12!\n" +
+	"\fservice_date\x18\x05 \x01(\x03R\vserviceDate\x12#\n" +
+	"\rstop_sequence\x18\x06 \x01(\x05R\fstopSequence\x124\n" +
+	"\x16scheduled_arrival_time\x18\a \x01(\x03R\x14scheduledArrivalTime\x128\n" +
+	"\x18scheduled_departure_time\x18\b \x01(\x03R\x16scheduledDepartureTime\x12\x1c\n" +
+	"\tpredicted\x18\t \x01(\bR\tpredicted\x124\n" +
+	"\x16predicted_arrival_time\x18\n" +
+	" \x01(\x03R\x14predictedArrivalTime\x128\n" +
+	"\x18predicted_departure_time\x18\v \x01(\x03R\x16predictedDepartureTime\x12\x1d\n" +
+	"\n" +
+	"vehicle_id\x18\f \x01(\tR\tvehicleId\"^\n" +
+	"\bPosition\x12\x10\n" +
+	"\x03lat\x18\x01 \x01(\x02R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x02 \x01(\x02R\x03lon\x12\x18\n" +
+	"\abearing\x18\x03 \x01(\x02R\abearing\x12\x14\n" +
+	"\x05speed\x18\x04 \x01(\x02R\x05speed\"\xb2\x01\n" +
+	"\aVehicle\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x12\x17\n" +
+	"\atrip_id\x18\x03 \x01(\tR\x06tripId\x12\x19\n" +
+	"\broute_id\x18\x04 \x01(\tR\arouteId\x12/\n" +
+	"\bposition\x18\x05 \x01(\v2\x13.maglev.v1.PositionR\bposition\x12\x1c\n" +
+	"\ttimestamp\x18\x06 \x01(\x03R\ttimestamp\"\x15\n" +
+	"\x13ListAgenciesRequest\"E\n" +
+	"\x14ListAgenciesResponse\x12-\n" +
+	"\bagencies\x18\x01 \x03(\v2\x11.maglev.v1.AgencyR\bagencies\"\"\n" +
+	"\x10GetAgencyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"9\n" +
+	"\x1aListRoutesForAgencyRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\"G\n" +
+	"\x1bListRoutesForAgencyResponse\x12(\n" +
+	"\x06routes\x18\x01 \x03(\v2\x10.maglev.v1.RouteR\x06routes\"!\n" +
+	"\x0fGetRouteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\" \n" +
+	"\x0eGetStopRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"v\n" +
+	"\x1bListStopsForLocationRequest\x12\x10\n" +
+	"\x03lat\x18\x01 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x02 \x01(\x01R\x03lon\x12\x16\n" +
+	"\x06radius\x18\x03 \x01(\x01R\x06radius\x12\x1b\n" +
+	"\tmax_count\x18\x04 \x01(\x05R\bmaxCount\"E\n" +
+	"\x1cListStopsForLocationResponse\x12%\n" +
+	"\x05stops\x18\x01 \x03(\v2\x0f.maglev.v1.StopR\x05stops\"Z\n" +
+	"\x1aListArrivalsForStopRequest\x12\x17\n" +
+	"\astop_id\x18\x01 \x01(\tR\x06stopId\x12#\n" +
+	"\rminutes_after\x18\x02 \x01(\x05R\fminutesAfter\"M\n" +
+	"\x1bListArrivalsForStopResponse\x12.\n" +
+	"\barrivals\x18\x01 \x03(\v2\x12.maglev.v1.ArrivalR\barrivals\";\n" +
+	"\x1cListVehiclesForAgencyRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\"i\n" +
+	"\x1dListVehiclesForAgencyResponse\x12.\n" +
+	"\bvehicles\x18\x01 \x03(\v2\x12.maglev.v1.VehicleR\bvehicles\x12\x18\n" +
+	"\aupdated\x18\x02 \x01(\x03R\aupdated\"<\n" +
+	"\x1dStreamVehiclePositionsRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId2\xa0\x06\n" +
+	"\x0eTransitService\x12O\n" +
+	"\fListAgencies\x12\x1e.maglev.v1.ListAgenciesRequest\x1a\x1f.maglev.v1.ListAgenciesResponse\x12;\n" +
+	"\tGetAgency\x12\x1b.maglev.v1.GetAgencyRequest\x1a\x11.maglev.v1.Agency\x12d\n" +
+	"\x13ListRoutesForAgency\x12%.maglev.v1.ListRoutesForAgencyRequest\x1a&.maglev.v1.ListRoutesForAgencyResponse\x128\n" +
+	"\bGetRoute\x12\x1a.maglev.v1.GetRouteRequest\x1a\x10.maglev.v1.Route\x125\n" +
+	"\aGetStop\x12\x19.maglev.v1.GetStopRequest\x1a\x0f.maglev.v1.Stop\x12g\n" +
+	"\x14ListStopsForLocation\x12&.maglev.v1.ListStopsForLocationRequest\x1a'.maglev.v1.ListStopsForLocationResponse\x12d\n" +
+	"\x13ListArrivalsForStop\x12%.maglev.v1.ListArrivalsForStopRequest\x1a&.maglev.v1.ListArrivalsForStopResponse\x12j\n" +
+	"\x15ListVehiclesForAgency\x12'.maglev.v1.ListVehiclesForAgencyRequest\x1a(.maglev.v1.ListVehiclesForAgencyResponse\x12n\n" +
+	"\x16StreamVehiclePositions\x12(.maglev.v1.StreamVehiclePositionsRequest\x1a(.maglev.v1.ListVehiclesForAgencyResponse0\x01B$Z\"maglev.onebusaway.org/pkg/maglevpbb\x06proto3"
+
+var (
+	file_maglev_proto_rawDescOnce sync.Once
+	file_maglev_proto_rawDescData []byte
+)
+
+func file_maglev_proto_rawDescGZIP() []byte {
+	file_maglev_proto_rawDescOnce.Do(func() {
+		file_maglev_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_maglev_proto_rawDesc), len(file_maglev_proto_rawDesc)))
+	})
+	return file_maglev_proto_rawDescData
+}
+
+var file_maglev_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_maglev_proto_goTypes = []any{
+	(*Agency)(nil),                        // 0: maglev.v1.Agency
+	(*Route)(nil),                         // 1: maglev.v1.Route
+	(*Stop)(nil),                          // 2: maglev.v1.Stop
+	(*Arrival)(nil),                       // 3: maglev.v1.Arrival
+	(*Position)(nil),                      // 4: maglev.v1.Position
+	(*Vehicle)(nil),                       // 5: maglev.v1.Vehicle
+	(*ListAgenciesRequest)(nil),           // 6: maglev.v1.ListAgenciesRequest
+	(*ListAgenciesResponse)(nil),          // 7: maglev.v1.ListAgenciesResponse
+	(*GetAgencyRequest)(nil),              // 8: maglev.v1.GetAgencyRequest
+	(*ListRoutesForAgencyRequest)(nil),    // 9: maglev.v1.ListRoutesForAgencyRequest
+	(*ListRoutesForAgencyResponse)(nil),   // 10: maglev.v1.ListRoutesForAgencyResponse
+	(*GetRouteRequest)(nil),               // 11: maglev.v1.GetRouteRequest
+	(*GetStopRequest)(nil),                // 12: maglev.v1.GetStopRequest
+	(*ListStopsForLocationRequest)(nil),   // 13: maglev.v1.ListStopsForLocationRequest
+	(*ListStopsForLocationResponse)(nil),  // 14: maglev.v1.ListStopsForLocationResponse
+	(*ListArrivalsForStopRequest)(nil),    // 15: maglev.v1.ListArrivalsForStopRequest
+	(*ListArrivalsForStopResponse)(nil),   // 16: maglev.v1.ListArrivalsForStopResponse
+	(*ListVehiclesForAgencyRequest)(nil),  // 17: maglev.v1.ListVehiclesForAgencyRequest
+	(*ListVehiclesForAgencyResponse)(nil), // 18: maglev.v1.ListVehiclesForAgencyResponse
+	(*StreamVehiclePositionsRequest)(nil), // 19: maglev.v1.StreamVehiclePositionsRequest
+}
+var file_maglev_proto_depIdxs = []int32{
+	4,  // 0: maglev.v1.Vehicle.position:type_name -> maglev.v1.Position
+	0,  // 1: maglev.v1.ListAgenciesResponse.agencies:type_name -> maglev.v1.Agency
+	1,  // 2: maglev.v1.ListRoutesForAgencyResponse.routes:type_name -> maglev.v1.Route
+	2,  // 3: maglev.v1.ListStopsForLocationResponse.stops:type_name -> maglev.v1.Stop
+	3,  // 4: maglev.v1.ListArrivalsForStopResponse.arrivals:type_name -> maglev.v1.Arrival
+	5,  // 5: maglev.v1.ListVehiclesForAgencyResponse.vehicles:type_name -> maglev.v1.Vehicle
+	6,  // 6: maglev.v1.TransitService.ListAgencies:input_type -> maglev.v1.ListAgenciesRequest
+	8,  // 7: maglev.v1.TransitService.GetAgency:input_type -> maglev.v1.GetAgencyRequest
+	9,  // 8: maglev.v1.TransitService.ListRoutesForAgency:input_type -> maglev.v1.ListRoutesForAgencyRequest
+	11, // 9: maglev.v1.TransitService.GetRoute:input_type -> maglev.v1.GetRouteRequest
+	12, // 10: maglev.v1.TransitService.GetStop:input_type -> maglev.v1.GetStopRequest
+	13, // 11: maglev.v1.TransitService.ListStopsForLocation:input_type -> maglev.v1.ListStopsForLocationRequest
+	15, // 12: maglev.v1.TransitService.ListArrivalsForStop:input_type -> maglev.v1.ListArrivalsForStopRequest
+	17, // 13: maglev.v1.TransitService.ListVehiclesForAgency:input_type -> maglev.v1.ListVehiclesForAgencyRequest
+	19, // 14: maglev.v1.TransitService.StreamVehiclePositions:input_type -> maglev.v1.StreamVehiclePositionsRequest
+	7,  // 15: maglev.v1.TransitService.ListAgencies:output_type -> maglev.v1.ListAgenciesResponse
+	0,  // 16: maglev.v1.TransitService.GetAgency:output_type -> maglev.v1.Agency
+	10, // 17: maglev.v1.TransitService.ListRoutesForAgency:output_type -> maglev.v1.ListRoutesForAgencyResponse
+	1,  // 18: maglev.v1.TransitService.GetRoute:output_type -> maglev.v1.Route
+	2,  // 19: maglev.v1.TransitService.GetStop:output_type -> maglev.v1.Stop
+	14, // 20: maglev.v1.TransitService.ListStopsForLocation:output_type -> maglev.v1.ListStopsForLocationResponse
+	16, // 21: maglev.v1.TransitService.ListArrivalsForStop:output_type -> maglev.v1.ListArrivalsForStopResponse
+	18, // 22: maglev.v1.TransitService.ListVehiclesForAgency:output_type -> maglev.v1.ListVehiclesForAgencyResponse
+	18, // 23: maglev.v1.TransitService.StreamVehiclePositions:output_type -> maglev.v1.ListVehiclesForAgencyResponse
+	15, // [15:24] is the sub-list for method output_type
+	6,  // [6:15] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_maglev_proto_init() }
+func file_maglev_proto_init() {
+	if File_maglev_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_maglev_proto_rawDesc), len(file_maglev_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_maglev_proto_goTypes,
+		DependencyIndexes: file_maglev_proto_depIdxs,
+		MessageInfos:      file_maglev_proto_msgTypes,
+	}.Build()
+	File_maglev_proto = out.File
+	file_maglev_proto_goTypes = nil
+	file_maglev_proto_depIdxs = nil
+}
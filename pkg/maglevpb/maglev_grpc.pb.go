@@ -0,0 +1,449 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: maglev.proto
+
+package maglevpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TransitService_ListAgencies_FullMethodName           = "/maglev.v1.TransitService/ListAgencies"
+	TransitService_GetAgency_FullMethodName              = "/maglev.v1.TransitService/GetAgency"
+	TransitService_ListRoutesForAgency_FullMethodName    = "/maglev.v1.TransitService/ListRoutesForAgency"
+	TransitService_GetRoute_FullMethodName               = "/maglev.v1.TransitService/GetRoute"
+	TransitService_GetStop_FullMethodName                = "/maglev.v1.TransitService/GetStop"
+	TransitService_ListStopsForLocation_FullMethodName   = "/maglev.v1.TransitService/ListStopsForLocation"
+	TransitService_ListArrivalsForStop_FullMethodName    = "/maglev.v1.TransitService/ListArrivalsForStop"
+	TransitService_ListVehiclesForAgency_FullMethodName  = "/maglev.v1.TransitService/ListVehiclesForAgency"
+	TransitService_StreamVehiclePositions_FullMethodName = "/maglev.v1.TransitService/StreamVehiclePositions"
+)
+
+// TransitServiceClient is the client API for TransitService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TransitService serves the same agencies, routes, stops, arrivals and vehicles as the REST API.
+// Every call needs a valid API key in the "x-api-key" metadata, and counts against the key's
+// rate limit like a REST request.
+//
+// IDs are the combined IDs of the REST API, e.g. "1_75403", except agency IDs. Times are in
+// milliseconds since the Unix epoch.
+type TransitServiceClient interface {
+	ListAgencies(ctx context.Context, in *ListAgenciesRequest, opts ...grpc.CallOption) (*ListAgenciesResponse, error)
+	GetAgency(ctx context.Context, in *GetAgencyRequest, opts ...grpc.CallOption) (*Agency, error)
+	ListRoutesForAgency(ctx context.Context, in *ListRoutesForAgencyRequest, opts ...grpc.CallOption) (*ListRoutesForAgencyResponse, error)
+	GetRoute(ctx context.Context, in *GetRouteRequest, opts ...grpc.CallOption) (*Route, error)
+	GetStop(ctx context.Context, in *GetStopRequest, opts ...grpc.CallOption) (*Stop, error)
+	ListStopsForLocation(ctx context.Context, in *ListStopsForLocationRequest, opts ...grpc.CallOption) (*ListStopsForLocationResponse, error)
+	// ListArrivalsForStop lists the departures from a stop that have not left yet, soonest first.
+	ListArrivalsForStop(ctx context.Context, in *ListArrivalsForStopRequest, opts ...grpc.CallOption) (*ListArrivalsForStopResponse, error)
+	ListVehiclesForAgency(ctx context.Context, in *ListVehiclesForAgencyRequest, opts ...grpc.CallOption) (*ListVehiclesForAgencyResponse, error)
+	// StreamVehiclePositions sends an agency's vehicles straight away and again after every
+	// realtime refresh that changes them. The stream ends when the server shuts down.
+	StreamVehiclePositions(ctx context.Context, in *StreamVehiclePositionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListVehiclesForAgencyResponse], error)
+}
+
+type transitServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTransitServiceClient(cc grpc.ClientConnInterface) TransitServiceClient {
+	return &transitServiceClient{cc}
+}
+
+func (c *transitServiceClient) ListAgencies(ctx context.Context, in *ListAgenciesRequest, opts ...grpc.CallOption) (*ListAgenciesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAgenciesResponse)
+	err := c.cc.Invoke(ctx, TransitService_ListAgencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transitServiceClient) GetAgency(ctx context.Context, in *GetAgencyRequest, opts ...grpc.CallOption) (*Agency, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Agency)
+	err := c.cc.Invoke(ctx, TransitService_GetAgency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transitServiceClient) ListRoutesForAgency(ctx context.Context, in *ListRoutesForAgencyRequest, opts ...grpc.CallOption) (*ListRoutesForAgencyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRoutesForAgencyResponse)
+	err := c.cc.Invoke(ctx, TransitService_ListRoutesForAgency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transitServiceClient) GetRoute(ctx context.Context, in *GetRouteRequest, opts ...grpc.CallOption) (*Route, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Route)
+	err := c.cc.Invoke(ctx, TransitService_GetRoute_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transitServiceClient) GetStop(ctx context.Context, in *GetStopRequest, opts ...grpc.CallOption) (*Stop, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Stop)
+	err := c.cc.Invoke(ctx, TransitService_GetStop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transitServiceClient) ListStopsForLocation(ctx context.Context, in *ListStopsForLocationRequest, opts ...grpc.CallOption) (*ListStopsForLocationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListStopsForLocationResponse)
+	err := c.cc.Invoke(ctx, TransitService_ListStopsForLocation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transitServiceClient) ListArrivalsForStop(ctx context.Context, in *ListArrivalsForStopRequest, opts ...grpc.CallOption) (*ListArrivalsForStopResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListArrivalsForStopResponse)
+	err := c.cc.Invoke(ctx, TransitService_ListArrivalsForStop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transitServiceClient) ListVehiclesForAgency(ctx context.Context, in *ListVehiclesForAgencyRequest, opts ...grpc.CallOption) (*ListVehiclesForAgencyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVehiclesForAgencyResponse)
+	err := c.cc.Invoke(ctx, TransitService_ListVehiclesForAgency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transitServiceClient) StreamVehiclePositions(ctx context.Context, in *StreamVehiclePositionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListVehiclesForAgencyResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TransitService_ServiceDesc.Streams[0], TransitService_StreamVehiclePositions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamVehiclePositionsRequest, ListVehiclesForAgencyResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TransitService_StreamVehiclePositionsClient = grpc.ServerStreamingClient[ListVehiclesForAgencyResponse]
+
+// TransitServiceServer is the server API for TransitService service.
+// All implementations must embed UnimplementedTransitServiceServer
+// for forward compatibility.
+//
+// TransitService serves the same agencies, routes, stops, arrivals and vehicles as the REST API.
+// Every call needs a valid API key in the "x-api-key" metadata, and counts against the key's
+// rate limit like a REST request.
+//
+// IDs are the combined IDs of the REST API, e.g. "1_75403", except agency IDs. Times are in
+// milliseconds since the Unix epoch.
+type TransitServiceServer interface {
+	ListAgencies(context.Context, *ListAgenciesRequest) (*ListAgenciesResponse, error)
+	GetAgency(context.Context, *GetAgencyRequest) (*Agency, error)
+	ListRoutesForAgency(context.Context, *ListRoutesForAgencyRequest) (*ListRoutesForAgencyResponse, error)
+	GetRoute(context.Context, *GetRouteRequest) (*Route, error)
+	GetStop(context.Context, *GetStopRequest) (*Stop, error)
+	ListStopsForLocation(context.Context, *ListStopsForLocationRequest) (*ListStopsForLocationResponse, error)
+	// ListArrivalsForStop lists the departures from a stop that have not left yet, soonest first.
+	ListArrivalsForStop(context.Context, *ListArrivalsForStopRequest) (*ListArrivalsForStopResponse, error)
+	ListVehiclesForAgency(context.Context, *ListVehiclesForAgencyRequest) (*ListVehiclesForAgencyResponse, error)
+	// StreamVehiclePositions sends an agency's vehicles straight away and again after every
+	// realtime refresh that changes them. The stream ends when the server shuts down.
+	StreamVehiclePositions(*StreamVehiclePositionsRequest, grpc.ServerStreamingServer[ListVehiclesForAgencyResponse]) error
+	mustEmbedUnimplementedTransitServiceServer()
+}
+
+// UnimplementedTransitServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTransitServiceServer struct{}
+
+func (UnimplementedTransitServiceServer) ListAgencies(context.Context, *ListAgenciesRequest) (*ListAgenciesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAgencies not implemented")
+}
+func (UnimplementedTransitServiceServer) GetAgency(context.Context, *GetAgencyRequest) (*Agency, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAgency not implemented")
+}
+func (UnimplementedTransitServiceServer) ListRoutesForAgency(context.Context, *ListRoutesForAgencyRequest) (*ListRoutesForAgencyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRoutesForAgency not implemented")
+}
+func (UnimplementedTransitServiceServer) GetRoute(context.Context, *GetRouteRequest) (*Route, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRoute not implemented")
+}
+func (UnimplementedTransitServiceServer) GetStop(context.Context, *GetStopRequest) (*Stop, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStop not implemented")
+}
+func (UnimplementedTransitServiceServer) ListStopsForLocation(context.Context, *ListStopsForLocationRequest) (*ListStopsForLocationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStopsForLocation not implemented")
+}
+func (UnimplementedTransitServiceServer) ListArrivalsForStop(context.Context, *ListArrivalsForStopRequest) (*ListArrivalsForStopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListArrivalsForStop not implemented")
+}
+func (UnimplementedTransitServiceServer) ListVehiclesForAgency(context.Context, *ListVehiclesForAgencyRequest) (*ListVehiclesForAgencyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVehiclesForAgency not implemented")
+}
+func (UnimplementedTransitServiceServer) StreamVehiclePositions(*StreamVehiclePositionsRequest, grpc.ServerStreamingServer[ListVehiclesForAgencyResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamVehiclePositions not implemented")
+}
+func (UnimplementedTransitServiceServer) mustEmbedUnimplementedTransitServiceServer() {}
+func (UnimplementedTransitServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeTransitServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TransitServiceServer will
+// result in compilation errors.
+type UnsafeTransitServiceServer interface {
+	mustEmbedUnimplementedTransitServiceServer()
+}
+
+func RegisterTransitServiceServer(s grpc.ServiceRegistrar, srv TransitServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTransitServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TransitService_ServiceDesc, srv)
+}
+
+func _TransitService_ListAgencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAgenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransitServiceServer).ListAgencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransitService_ListAgencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransitServiceServer).ListAgencies(ctx, req.(*ListAgenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransitService_GetAgency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAgencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransitServiceServer).GetAgency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransitService_GetAgency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransitServiceServer).GetAgency(ctx, req.(*GetAgencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransitService_ListRoutesForAgency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRoutesForAgencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransitServiceServer).ListRoutesForAgency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransitService_ListRoutesForAgency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransitServiceServer).ListRoutesForAgency(ctx, req.(*ListRoutesForAgencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransitService_GetRoute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransitServiceServer).GetRoute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransitService_GetRoute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransitServiceServer).GetRoute(ctx, req.(*GetRouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransitService_GetStop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransitServiceServer).GetStop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransitService_GetStop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransitServiceServer).GetStop(ctx, req.(*GetStopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransitService_ListStopsForLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListStopsForLocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransitServiceServer).ListStopsForLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransitService_ListStopsForLocation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransitServiceServer).ListStopsForLocation(ctx, req.(*ListStopsForLocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransitService_ListArrivalsForStop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArrivalsForStopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransitServiceServer).ListArrivalsForStop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransitService_ListArrivalsForStop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransitServiceServer).ListArrivalsForStop(ctx, req.(*ListArrivalsForStopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransitService_ListVehiclesForAgency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVehiclesForAgencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransitServiceServer).ListVehiclesForAgency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransitService_ListVehiclesForAgency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransitServiceServer).ListVehiclesForAgency(ctx, req.(*ListVehiclesForAgencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransitService_StreamVehiclePositions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamVehiclePositionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TransitServiceServer).StreamVehiclePositions(m, &grpc.GenericServerStream[StreamVehiclePositionsRequest, ListVehiclesForAgencyResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TransitService_StreamVehiclePositionsServer = grpc.ServerStreamingServer[ListVehiclesForAgencyResponse]
+
+// TransitService_ServiceDesc is the grpc.ServiceDesc for TransitService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TransitService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "maglev.v1.TransitService",
+	HandlerType: (*TransitServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListAgencies",
+			Handler:    _TransitService_ListAgencies_Handler,
+		},
+		{
+			MethodName: "GetAgency",
+			Handler:    _TransitService_GetAgency_Handler,
+		},
+		{
+			MethodName: "ListRoutesForAgency",
+			Handler:    _TransitService_ListRoutesForAgency_Handler,
+		},
+		{
+			MethodName: "GetRoute",
+			Handler:    _TransitService_GetRoute_Handler,
+		},
+		{
+			MethodName: "GetStop",
+			Handler:    _TransitService_GetStop_Handler,
+		},
+		{
+			MethodName: "ListStopsForLocation",
+			Handler:    _TransitService_ListStopsForLocation_Handler,
+		},
+		{
+			MethodName: "ListArrivalsForStop",
+			Handler:    _TransitService_ListArrivalsForStop_Handler,
+		},
+		{
+			MethodName: "ListVehiclesForAgency",
+			Handler:    _TransitService_ListVehiclesForAgency_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamVehiclePositions",
+			Handler:       _TransitService_StreamVehiclePositions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "maglev.proto",
+}